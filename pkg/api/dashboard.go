@@ -1039,6 +1039,73 @@ func (hs *HTTPServer) RestoreDashboardVersion(c *contextmodel.ReqContext) respon
 	return hs.postDashboard(c, saveCmd)
 }
 
+// swagger:route POST /dashboards/uid/{uid}/merge dashboard_versions mergeDashboard
+//
+// Merge concurrent edits of a dashboard.
+//
+// Performs a three-way merge of an edited dashboard with the changes saved since the version the edit started from.
+// The merged dashboard is returned and not saved. Panels are matched by id and template variables by name.
+//
+// Responses:
+// 200: mergeDashboardResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 409: mergeDashboardResponse
+// 500: internalServerError
+func (hs *HTTPServer) MergeDashboard(c *contextmodel.ReqContext) response.Response {
+	apiCmd := dtos.MergeDashboardCommand{}
+	if err := web.Bind(c.Req, &apiCmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	dash, rsp := hs.getDashboardHelper(c.Req.Context(), c.OrgID, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian, err := guardian.NewByDashboard(c.Req.Context(), dash, c.OrgID, c.SignedInUser)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	baseQuery := dashver.GetDashboardVersionQuery{DashboardUID: dash.UID, Version: apiCmd.BaseVersion, OrgID: c.OrgID}
+	base, err := hs.dashboardVersionService.Get(c.Req.Context(), &baseQuery)
+	if err != nil {
+		return response.Error(http.StatusNotFound, "Dashboard base version not found", nil)
+	}
+
+	theirs, theirsVersion := dash.Data, dash.Version
+	if apiCmd.TheirsVersion != 0 && apiCmd.TheirsVersion != dash.Version {
+		theirsQuery := dashver.GetDashboardVersionQuery{DashboardUID: dash.UID, Version: apiCmd.TheirsVersion, OrgID: c.OrgID}
+		version, err := hs.dashboardVersionService.Get(c.Req.Context(), &theirsQuery)
+		if err != nil {
+			return response.Error(http.StatusNotFound, "Dashboard version not found", nil)
+		}
+		theirs, theirsVersion = version.Data, version.Version
+	}
+
+	result, err := dashdiffs.Merge(base.Data, theirs, apiCmd.Dashboard)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Unable to merge dashboards", err)
+	}
+
+	status := http.StatusOK
+	if len(result.Conflicts) > 0 {
+		status = http.StatusConflict
+	}
+	return response.JSON(status, dtos.MergeDashboardResult{
+		Dashboard: result.Dashboard,
+		Version:   theirsVersion,
+		Conflicts: result.Conflicts,
+	})
+}
+
 // swagger:route GET /dashboards/tags dashboards getDashboardTags
 //
 // Get all dashboards tags of an organisation.
@@ -1094,6 +1161,16 @@ type RestoreDashboardVersionByIDParams struct {
 	DashboardID int64
 }
 
+// swagger:parameters mergeDashboard
+type MergeDashboardParams struct {
+	// in:body
+	// required:true
+	Body dtos.MergeDashboardCommand
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+}
+
 // swagger:parameters getDashboardVersionsByID
 type GetDashboardVersionsByIDParams struct {
 	// in:path
@@ -1264,6 +1341,12 @@ type CalculateDashboardDiffResponse struct {
 	Body []byte `json:"body"`
 }
 
+// swagger:response mergeDashboardResponse
+type MergeDashboardResponse struct {
+	// in: body
+	Body dtos.MergeDashboardResult `json:"body"`
+}
+
 // swagger:response trimDashboardResponse
 type TrimDashboardResponse struct {
 	// in: body
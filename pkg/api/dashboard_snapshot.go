@@ -190,6 +190,64 @@ func (hs *HTTPServer) CreateDashboardSnapshot(c *contextmodel.ReqContext) respon
 	return nil
 }
 
+// swagger:route POST /dashboards/uid/{uid}/snapshot snapshots createServerSideDashboardSnapshot
+//
+// Create a snapshot of a dashboard by executing its queries in the backend.
+//
+// Every panel query is executed as the signed in user for the given time range and variables, and the results are
+// embedded in the snapshot. This endpoint is designed for scripts and automation.
+//
+// Responses:
+// 200: createDashboardSnapshotResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) CreateServerSideDashboardSnapshot(c *contextmodel.ReqContext) response.Response {
+	if !hs.Cfg.SnapshotEnabled {
+		return response.Error(http.StatusForbidden, "Dashboard Snapshots are disabled", nil)
+	}
+
+	cmd := dashboardsnapshots.CreateServerSideDashboardSnapshotCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	dash, rsp := hs.getDashboardHelper(c.Req.Context(), c.OrgID, 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian, err := guardian.NewByDashboard(c.Req.Context(), dash, c.OrgID, c.SignedInUser)
+	if err != nil {
+		return response.Err(err)
+	}
+	if canView, err := guardian.CanView(); err != nil || !canView {
+		return dashboardGuardianResponse(err)
+	}
+
+	cmd.DashboardUID = dash.UID
+	cmd.Dashboard = dash.Data
+	cmd.OrgID = c.OrgID
+	cmd.SignedInUser = c.SignedInUser
+
+	result, err := hs.dashboardsnapshotsService.CreateServerSideDashboardSnapshot(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to create snapshot", err)
+	}
+
+	metrics.MApiDashboardSnapshotCreate.Inc()
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"key":       result.Key,
+		"deleteKey": result.DeleteKey,
+		"url":       setting.ToAbsUrl("dashboard/snapshot/" + result.Key),
+		"deleteUrl": setting.ToAbsUrl("api/snapshots-delete/" + result.DeleteKey),
+		"id":        result.ID,
+	})
+}
+
 // GET /api/snapshots/:key
 // swagger:route GET /snapshots/{key} snapshots getDashboardSnapshot
 //
@@ -452,6 +510,16 @@ type CreateSnapshotParams struct {
 	Body dashboardsnapshots.CreateDashboardSnapshotCommand `json:"body"`
 }
 
+// swagger:parameters createServerSideDashboardSnapshot
+type CreateServerSideSnapshotParams struct {
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+	// in:body
+	// required:true
+	Body dashboardsnapshots.CreateServerSideDashboardSnapshotCommand `json:"body"`
+}
+
 // swagger:parameters searchDashboardSnapshots
 type GetSnapshotsParams struct {
 	// Search Query
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -31,6 +32,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/registry/corekind"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
 	accesscontrolmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
@@ -59,6 +61,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
+	"github.com/grafana/grafana/pkg/web/webtest"
 )
 
 func TestGetHomeDashboard(t *testing.T) {
@@ -1153,7 +1156,7 @@ func (hs *HTTPServer) callGetDashboardVersions(sc *scenarioContext) {
 func (hs *HTTPServer) callDeleteDashboardByUID(t *testing.T,
 	sc *scenarioContext, mockDashboard *dashboards.FakeDashboardService, mockPubdashService *publicdashboards.FakePublicDashboardService) {
 	hs.DashboardService = mockDashboard
-	pubdashApi := api.ProvideApi(mockPubdashService, nil, nil, featuremgmt.WithFeatures())
+	pubdashApi := api.ProvideApi(mockPubdashService, nil, nil, featuremgmt.WithFeatures(), setting.NewCfg())
 	hs.PublicDashboardsApi = pubdashApi
 	sc.handlerFunc = hs.DeleteDashboardByUID
 	sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
@@ -1364,6 +1367,16 @@ func (l *mockLibraryElementService) GetElement(c context.Context, signedInUser *
 	return model.LibraryElementDTO{}, nil
 }
 
+// GetAllElements gets the elements matching a search query.
+func (l *mockLibraryElementService) GetAllElements(c context.Context, signedInUser *user.SignedInUser, query model.SearchLibraryElementsQuery) (model.LibraryElementSearchResult, error) {
+	return model.LibraryElementSearchResult{}, nil
+}
+
+// PatchLibraryElement updates an element.
+func (l *mockLibraryElementService) PatchLibraryElement(c context.Context, signedInUser *user.SignedInUser, cmd model.PatchLibraryElementCommand, uid string) (model.LibraryElementDTO, error) {
+	return model.LibraryElementDTO{}, nil
+}
+
 // GetElementsForDashboard gets all connected elements for a specific dashboard.
 func (l *mockLibraryElementService) GetElementsForDashboard(c context.Context, dashboardID int64) (map[string]model.LibraryElementDTO, error) {
 	return map[string]model.LibraryElementDTO{}, nil
@@ -1383,3 +1396,79 @@ func (l *mockLibraryElementService) DisconnectElementsFromDashboard(c context.Co
 func (l *mockLibraryElementService) DeleteLibraryElementsInFolder(c context.Context, signedInUser *user.SignedInUser, folderUID string) error {
 	return nil
 }
+
+func TestMergeDashboardAPIEndpoint(t *testing.T) {
+	data := func(title, panelTitle string) *simplejson.Json {
+		return simplejson.NewFromAny(map[string]interface{}{
+			"title": title,
+			"panels": []interface{}{
+				map[string]interface{}{"id": 1, "title": panelTitle},
+			},
+		})
+	}
+
+	setup := func(t *testing.T, canSave bool, current *simplejson.Json) *webtest.Server {
+		t.Helper()
+		origNewByDashboard := guardian.NewByDashboard
+		t.Cleanup(func() { guardian.NewByDashboard = origNewByDashboard })
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanSaveValue: canSave})
+
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, mock.AnythingOfType("*dashboards.GetDashboardQuery")).
+			Return(&dashboards.Dashboard{ID: 1, UID: "dash", OrgID: 1, Version: 3, Data: current}, nil).Maybe()
+
+		versionService := dashvertest.NewDashboardVersionServiceFake()
+		versionService.ExpectedDashboardVersion = &dashver.DashboardVersionDTO{DashboardID: 1, Version: 2, Data: data("Servers", "CPU")}
+
+		return SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.DashboardService = dashboardService
+			hs.dashboardVersionService = versionService
+		})
+	}
+
+	merge := func(t *testing.T, srv *webtest.Server, mine *simplejson.Json) (*http.Response, dtos.MergeDashboardResult) {
+		t.Helper()
+		body, err := json.Marshal(dtos.MergeDashboardCommand{BaseVersion: 2, Dashboard: mine})
+		require.NoError(t, err)
+
+		req := srv.NewPostRequest("/api/dashboards/uid/dash/merge", bytes.NewReader(body))
+		req = webtest.RequestWithSignedInUser(req, userWithPermissions(1, []accesscontrol.Permission{
+			{Action: dashboards.ActionDashboardsWrite, Scope: dashboards.ScopeDashboardsAll},
+		}))
+		resp, err := srv.SendJSON(req)
+		require.NoError(t, err)
+
+		result := dtos.MergeDashboardResult{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		require.NoError(t, resp.Body.Close())
+		return resp, result
+	}
+
+	t.Run("returns the merged dashboard", func(t *testing.T) {
+		srv := setup(t, true, data("Servers", "CPU usage"))
+
+		resp, result := merge(t, srv, data("Servers overview", "CPU"))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Empty(t, result.Conflicts)
+		require.Equal(t, 3, result.Version)
+		require.Equal(t, "Servers overview", result.Dashboard.Get("title").MustString())
+		require.Equal(t, "CPU usage", result.Dashboard.Get("panels").GetIndex(0).Get("title").MustString())
+	})
+
+	t.Run("returns conflicts", func(t *testing.T) {
+		srv := setup(t, true, data("Servers", "CPU usage"))
+
+		resp, result := merge(t, srv, data("Servers", "Processor"))
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+		require.Nil(t, result.Dashboard)
+		require.Len(t, result.Conflicts, 1)
+		require.Equal(t, "panels[id=1].title", result.Conflicts[0].Path)
+	})
+
+	t.Run("requires permission to save the dashboard", func(t *testing.T) {
+		srv := setup(t, false, data("Servers", "CPU usage"))
+
+		resp, _ := merge(t, srv, data("Servers", "CPU"))
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
@@ -3,6 +3,7 @@ package dtos
 import (
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 )
 
@@ -76,3 +77,18 @@ type CalculateDiffTarget struct {
 type RestoreDashboardVersionCommand struct {
 	Version int `json:"version" binding:"Required"`
 }
+
+type MergeDashboardCommand struct {
+	// The version both edits started from
+	BaseVersion int `json:"baseVersion" binding:"Required"`
+	// The version the edit is merged into, the latest version when omitted
+	TheirsVersion int              `json:"theirsVersion"`
+	Dashboard     *simplejson.Json `json:"dashboard" binding:"Required"`
+}
+
+type MergeDashboardResult struct {
+	// The merged dashboard, to be saved with the given version. Omitted when there are conflicts.
+	Dashboard *simplejson.Json     `json:"dashboard,omitempty"`
+	Version   int                  `json:"version"`
+	Conflicts []dashdiffs.Conflict `json:"conflicts"`
+}
@@ -13,4 +13,10 @@ type UserToken struct {
 	BrowserVersion         string    `json:"browserVersion"`
 	CreatedAt              time.Time `json:"createdAt"`
 	SeenAt                 time.Time `json:"seenAt"`
+	DeviceName             string    `json:"deviceName"`
+	LastSeenAt             time.Time `json:"lastSeenAt"`
+	Country                string    `json:"country,omitempty"`
+	City                   string    `json:"city,omitempty"`
+	ASN                    int64     `json:"asn,omitempty"`
+	ASOrganization         string    `json:"asOrganization,omitempty"`
 }
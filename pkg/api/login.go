@@ -239,7 +239,7 @@ func (hs *HTTPServer) LoginPost(c *contextmodel.ReqContext) response.Response {
 		ReqContext: c,
 		Username:   cmd.User,
 		Password:   cmd.Password,
-		IpAddress:  c.RemoteAddr(),
+		IpAddress:  web.ClientIP(c.Req, hs.Cfg.TrustedProxies),
 		Cfg:        hs.Cfg,
 	}
 
@@ -289,6 +289,26 @@ func (hs *HTTPServer) LoginPost(c *contextmodel.ReqContext) response.Response {
 	return resp
 }
 
+// LoginWebAuthnPost logs in Grafana users with the assertion of a WebAuthn ceremony started
+// by POST /api/login/webauthn/begin
+func (hs *HTTPServer) LoginWebAuthnPost(c *contextmodel.ReqContext) response.Response {
+	if !hs.Cfg.AuthBrokerEnabled {
+		return response.Error(http.StatusNotFound, "WebAuthn login requires the auth broker", nil)
+	}
+
+	identity, err := hs.authnService.Login(c.Req.Context(), authn.ClientWebAuthn, &authn.Request{HTTPRequest: c.Req, Resp: c.Resp})
+	if err != nil {
+		tokenErr := &auth.CreateTokenErr{}
+		if errors.As(err, &tokenErr) {
+			return response.Error(tokenErr.StatusCode, tokenErr.ExternalErr, tokenErr.InternalErr)
+		}
+		return response.Err(err)
+	}
+
+	metrics.MApiLoginPost.Inc()
+	return authn.HandleLoginResponse(c.Req, c.Resp, hs.Cfg, identity, hs.ValidateRedirectTo)
+}
+
 func (hs *HTTPServer) loginUserWithUser(user *user.User, c *contextmodel.ReqContext) error {
 	if user == nil {
 		return errors.New("could not login user")
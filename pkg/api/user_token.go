@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/ua-parser/uap-go/uaparser"
@@ -54,6 +55,103 @@ func (hs *HTTPServer) RevokeUserAuthToken(c *contextmodel.ReqContext) response.R
 	return hs.revokeUserAuthTokenInternal(c, c.UserID, cmd)
 }
 
+// swagger:route PUT /user/auth-tokens/{auth_token_id} signed_in_user updateUserAuthToken
+//
+// Update an auth token of the actual User.
+//
+// Names the given auth token (device) of the actual user, an empty name resets it.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) UpdateUserAuthToken(c *contextmodel.ReqContext) response.Response {
+	tokenID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+
+	cmd := auth.UpdateAuthTokenCmd{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if err := hs.AuthTokenService.SetUserTokenDeviceName(c.Req.Context(), c.UserID, tokenID, cmd.DeviceName); err != nil {
+		if errors.Is(err, auth.ErrUserTokenNotFound) {
+			return response.Error(http.StatusNotFound, "User auth token not found", err)
+		}
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to update user auth token", err)
+	}
+
+	return response.Success("User auth token updated")
+}
+
+// swagger:route POST /user/revoke-all-auth-tokens signed_in_user revokeAllUserAuthTokens
+//
+// Revoke all auth tokens of the actual User.
+//
+// Signs the actual user out everywhere, including the device of the request.
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) RevokeAllUserAuthTokens(c *contextmodel.ReqContext) response.Response {
+	if err := hs.AuthTokenService.RevokeAllUserTokens(c.Req.Context(), c.UserID); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to revoke user auth tokens", err)
+	}
+
+	authn.DeleteSessionCookie(c.Resp, hs.Cfg)
+	return response.Success("User logged out everywhere")
+}
+
+// swagger:route GET /org/session-policy org getOrgSessionPolicy
+//
+// Get the session policy of the current organization.
+//
+// Responses:
+// 200: getOrgSessionPolicyResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) GetOrgSessionPolicy(c *contextmodel.ReqContext) response.Response {
+	policy, err := hs.AuthTokenService.GetOrgSessionPolicy(c.Req.Context(), c.OrgID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get session policy", err)
+	}
+	return response.JSON(http.StatusOK, policy)
+}
+
+// swagger:route PUT /org/session-policy org updateOrgSessionPolicy
+//
+// Update the session policy of the current organization.
+//
+// Sessions of the users whose current organization is this one are logged out after being idle for longer
+// than idleTimeoutSeconds, 0 disables the policy.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) UpdateOrgSessionPolicy(c *contextmodel.ReqContext) response.Response {
+	policy := auth.OrgSessionPolicy{}
+	if err := web.Bind(c.Req, &policy); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	policy.OrgID = c.OrgID
+
+	if err := hs.AuthTokenService.SetOrgSessionPolicy(c.Req.Context(), &policy); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to update session policy", err)
+	}
+	return response.Success("Session policy updated")
+}
+
 func (hs *HTTPServer) RotateUserAuthTokenRedirect(c *contextmodel.ReqContext) response.Response {
 	if err := hs.rotateToken(c); err != nil {
 		hs.log.FromContext(c.Req.Context()).Debug("Failed to rotate token", "error", err)
@@ -195,7 +293,12 @@ func (hs *HTTPServer) getUserAuthTokensInternal(c *contextmodel.ReqContext, user
 			seenAt = createdAt
 		}
 
-		result = append(result, &dtos.UserToken{
+		lastSeenAt := time.Unix(token.LastSeenAt, 0)
+		if token.LastSeenAt == 0 {
+			lastSeenAt = seenAt
+		}
+
+		dto := &dtos.UserToken{
 			Id:                     token.Id,
 			IsActive:               isActive,
 			ClientIp:               token.ClientIp,
@@ -206,7 +309,16 @@ func (hs *HTTPServer) getUserAuthTokensInternal(c *contextmodel.ReqContext, user
 			BrowserVersion:         browserVersion,
 			CreatedAt:              createdAt,
 			SeenAt:                 seenAt,
-		})
+			DeviceName:             token.DeviceName,
+			LastSeenAt:             lastSeenAt,
+		}
+		if location := hs.geoIPService.Lookup(token.ClientIp); location != nil {
+			dto.Country = location.Country
+			dto.City = location.City
+			dto.ASN = location.ASN
+			dto.ASOrganization = location.ASOrganization
+		}
+		result = append(result, dto)
 	}
 
 	return response.JSON(http.StatusOK, result)
@@ -254,6 +366,29 @@ type RevokeUserAuthTokenParams struct {
 	Body auth.RevokeAuthTokenCmd `json:"body"`
 }
 
+// swagger:parameters updateUserAuthToken
+type UpdateUserAuthTokenParams struct {
+	// in:path
+	// required:true
+	AuthTokenID int64 `json:"auth_token_id"`
+	// in:body
+	// required:true
+	Body auth.UpdateAuthTokenCmd `json:"body"`
+}
+
+// swagger:parameters updateOrgSessionPolicy
+type UpdateOrgSessionPolicyParams struct {
+	// in:body
+	// required:true
+	Body auth.OrgSessionPolicy `json:"body"`
+}
+
+// swagger:response getOrgSessionPolicyResponse
+type GetOrgSessionPolicyResponse struct {
+	// in:body
+	Body auth.OrgSessionPolicy `json:"body"`
+}
+
 // swagger:response getUserAuthTokensResponse
 type GetUserAuthTokensResponse struct {
 	// in:body
@@ -129,6 +129,11 @@ var adminCommands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:   "reset-user-mfa",
+		Usage:  "reset-user-mfa <login or email>",
+		Action: runRunnerCommand(resetUserMFACommand),
+	},
 	{
 		Name:  "data-migration",
 		Usage: "Runs a script that migrates or cleanups data in your database",
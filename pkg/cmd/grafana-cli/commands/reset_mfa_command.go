@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/server"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func resetUserMFACommand(c utils.CommandLine, runner server.Runner) error {
+	login := c.Args().First()
+	if login == "" {
+		return fmt.Errorf("the login or email of the user is required")
+	}
+
+	if err := resetUserMFA(login, runner.UserService, runner.MFAService); err != nil {
+		return err
+	}
+
+	logger.Infof("\n")
+	logger.Infof("Multi-factor authentication of %s reset successfully %s", login, color.GreenString("✔"))
+	return nil
+}
+
+func resetUserMFA(login string, userSvc user.Service, mfaSvc mfa.Service) error {
+	usr, err := userSvc.GetByLogin(context.Background(), &user.GetUserByLoginQuery{LoginOrEmail: login})
+	if err != nil {
+		return fmt.Errorf("could not read user from database. Error: %v", err)
+	}
+
+	if err := mfaSvc.Reset(context.Background(), usr.ID); err != nil {
+		return fmt.Errorf("failed to reset multi-factor authentication: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/mfa/mfatest"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+)
+
+func TestResetUserMFA(t *testing.T) {
+	t.Run("resets the user", func(t *testing.T) {
+		userSvc := &usertest.FakeUserService{ExpectedUser: &user.User{ID: 2, Login: "editor"}}
+		require.NoError(t, resetUserMFA("editor", userSvc, &mfatest.FakeService{}))
+	})
+
+	t.Run("fails when the user doesn't exist", func(t *testing.T) {
+		userSvc := &usertest.FakeUserService{ExpectedError: user.ErrUserNotFound}
+		require.Error(t, resetUserMFA("unknown", userSvc, &mfatest.FakeService{}))
+	})
+
+	t.Run("fails when the reset fails", func(t *testing.T) {
+		userSvc := &usertest.FakeUserService{ExpectedUser: &user.User{ID: 2, Login: "editor"}}
+		require.Error(t, resetUserMFA("editor", userSvc, &mfatest.FakeService{ExpectedErr: errors.New("db error")}))
+	})
+}
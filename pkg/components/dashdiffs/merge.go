@@ -0,0 +1,378 @@
+package dashdiffs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Conflict reasons
+const (
+	ConflictModified        = "modified in both"
+	ConflictAdded           = "added in both"
+	ConflictDeletedInTheirs = "deleted in theirs, modified in mine"
+	ConflictDeletedInMine   = "modified in theirs, deleted in mine"
+)
+
+// Conflict is a change made differently in both versions of a dashboard. Absent values are
+// left out: a conflict without theirs means the element was deleted in theirs.
+type Conflict struct {
+	// Path to the conflicting value, e.g. panels[id=2].title or templating.list[name=env].query
+	Path   string      `json:"path"`
+	Reason string      `json:"reason"`
+	Base   interface{} `json:"base,omitempty"`
+	Theirs interface{} `json:"theirs,omitempty"`
+	Mine   interface{} `json:"mine,omitempty"`
+}
+
+// MergeResult is the outcome of a three-way merge. Dashboard is only set when there are no conflicts.
+type MergeResult struct {
+	Dashboard *simplejson.Json `json:"dashboard,omitempty"`
+	Conflicts []Conflict       `json:"conflicts"`
+}
+
+// Fields that change on every save, they are taken from theirs
+var mergeIgnoredFields = map[string]bool{
+	"id":      true,
+	"uid":     true,
+	"version": true,
+}
+
+// absent marks a value missing from one of the versions
+type absentValue struct{}
+
+var absent = absentValue{}
+
+// Merge performs a three-way merge of two dashboards derived from the same base version.
+// Panels are matched by id, or by grid position when they have no id, template variables
+// and annotations by name. Other arrays are merged as a whole.
+func Merge(base, theirs, mine *simplejson.Json) (*MergeResult, error) {
+	b, err := normalize(base)
+	if err != nil {
+		return nil, err
+	}
+	t, err := normalize(theirs)
+	if err != nil {
+		return nil, err
+	}
+	m, err := normalize(mine)
+	if err != nil {
+		return nil, err
+	}
+
+	mg := &merger{conflicts: []Conflict{}}
+	merged := mg.mergeValue("", "", b, t, m)
+
+	result := &MergeResult{Conflicts: mg.conflicts}
+	if len(mg.conflicts) > 0 {
+		return result, nil
+	}
+
+	dash, ok := merged.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("dashdiff: merged dashboard is not an object")
+	}
+	for field := range mergeIgnoredFields {
+		if v, ok := t.(map[string]interface{})[field]; ok {
+			dash[field] = v
+		}
+	}
+	result.Dashboard = simplejson.NewFromAny(dash)
+	return result, nil
+}
+
+// normalize converts a dashboard to plain JSON values, so that values decoded from the
+// database and from requests compare equal.
+func normalize(dash *simplejson.Json) (interface{}, error) {
+	if dash == nil {
+		return nil, fmt.Errorf("dashdiff: missing dashboard")
+	}
+	data, err := dash.Encode()
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("dashdiff: dashboard is not an object")
+	}
+	return v, nil
+}
+
+type merger struct {
+	conflicts []Conflict
+}
+
+func (mg *merger) conflict(path, reason string, base, theirs, mine interface{}) {
+	c := Conflict{Path: path, Reason: reason}
+	if base != absent {
+		c.Base = base
+	}
+	if theirs != absent {
+		c.Theirs = theirs
+	}
+	if mine != absent {
+		c.Mine = mine
+	}
+	mg.conflicts = append(mg.conflicts, c)
+}
+
+// mergeValue merges one value. field is the name of the object field holding the value,
+// used to pick how arrays are matched. It returns absent when the value is deleted.
+func (mg *merger) mergeValue(path, field string, base, theirs, mine interface{}) interface{} {
+	switch {
+	case reflect.DeepEqual(theirs, mine):
+		return theirs
+	case reflect.DeepEqual(base, theirs):
+		return mine
+	case reflect.DeepEqual(base, mine):
+		return theirs
+	}
+
+	// Both sides changed the value, differently
+	switch {
+	case theirs == absent:
+		mg.conflict(path, ConflictDeletedInTheirs, base, theirs, mine)
+		return mine
+	case mine == absent:
+		mg.conflict(path, ConflictDeletedInMine, base, theirs, mine)
+		return theirs
+	}
+
+	bObj, bOk := base.(map[string]interface{})
+	tObj, tOk := theirs.(map[string]interface{})
+	mObj, mOk := mine.(map[string]interface{})
+	if tOk && mOk {
+		if !bOk {
+			bObj = map[string]interface{}{}
+		}
+		return mg.mergeObject(path, bObj, tObj, mObj)
+	}
+
+	bArr, bOk := base.([]interface{})
+	tArr, tOk := theirs.([]interface{})
+	mArr, mOk := mine.([]interface{})
+	if key := arrayKey(path, field); key != nil && tOk && mOk {
+		if !bOk {
+			bArr = []interface{}{}
+		}
+		return mg.mergeArray(path, field, key, bArr, tArr, mArr)
+	}
+
+	reason := ConflictModified
+	if base == absent {
+		reason = ConflictAdded
+	}
+	mg.conflict(path, reason, base, theirs, mine)
+	return theirs
+}
+
+func (mg *merger) mergeObject(path string, base, theirs, mine map[string]interface{}) interface{} {
+	keys := map[string]bool{}
+	for _, obj := range []map[string]interface{}{base, theirs, mine} {
+		for k := range obj {
+			keys[k] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	result := map[string]interface{}{}
+	for _, k := range sorted {
+		if path == "" && mergeIgnoredFields[k] {
+			continue
+		}
+		v := mg.mergeValue(joinPath(path, k), k, get(base, k), get(theirs, k), get(mine, k))
+		if v != absent {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// mergeArray merges arrays whose elements are matched by key. The result keeps the order
+// of theirs, elements added in mine are inserted after the element preceding them in mine.
+func (mg *merger) mergeArray(path, field string, key func(interface{}) string, base, theirs, mine []interface{}) interface{} {
+	bByKey, bKeys := indexArray(base, key)
+	tByKey, tKeys := indexArray(theirs, key)
+	mByKey, mKeys := indexArray(mine, key)
+
+	if field == "panels" {
+		all := make([]interface{}, 0, len(theirs)+len(mine))
+		all = append(append(all, theirs...), mine...)
+		mKeys = mg.renumberAddedPanels(bByKey, tByKey, mByKey, mKeys, all)
+	}
+
+	order := make([]string, 0, len(tKeys)+len(mKeys))
+	order = append(order, tKeys...)
+	for i, k := range mKeys {
+		if _, ok := tByKey[k]; ok {
+			continue
+		}
+		pos := 0
+		for j := i - 1; j >= 0; j-- {
+			if idx := indexOf(order, mKeys[j]); idx >= 0 {
+				pos = idx + 1
+				break
+			}
+		}
+		order = append(order[:pos], append([]string{k}, order[pos:]...)...)
+	}
+	for _, k := range bKeys {
+		if indexOf(order, k) < 0 {
+			order = append(order, k)
+		}
+	}
+
+	result := make([]interface{}, 0, len(order))
+	for _, k := range order {
+		v := mg.mergeValue(fmt.Sprintf("%s[%s]", path, k), "", getKey(bByKey, k), getKey(tByKey, k), getKey(mByKey, k))
+		if v != absent {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// renumberAddedPanels gives a new id to panels added in mine with an id already used by a
+// different panel added in theirs, since both editors allocate ids from the same base.
+func (mg *merger) renumberAddedPanels(base, theirs, mine map[string]interface{}, mKeys []string, all []interface{}) []string {
+	maxID := maxPanelID(all)
+	keys := make([]string, 0, len(mKeys))
+	for _, k := range mKeys {
+		mPanel := mine[k]
+		tPanel, inTheirs := theirs[k]
+		_, inBase := base[k]
+		if inBase || !inTheirs || reflect.DeepEqual(tPanel, mPanel) || !strings.HasPrefix(k, "id=") {
+			keys = append(keys, k)
+			continue
+		}
+
+		maxID++
+		p := copyObject(mPanel.(map[string]interface{}))
+		p["id"] = float64(maxID)
+		newKey := fmt.Sprintf("id=%d", maxID)
+		delete(mine, k)
+		mine[newKey] = p
+		keys = append(keys, newKey)
+	}
+	return keys
+}
+
+// arrayKey returns how elements of an array are matched, or nil when the array is merged as a whole
+func arrayKey(path, field string) func(interface{}) string {
+	switch {
+	case field == "panels":
+		return panelKey
+	case field == "list" && (strings.HasSuffix(path, "templating.list") || strings.HasSuffix(path, "annotations.list")):
+		return nameKey
+	}
+	return nil
+}
+
+func panelKey(v interface{}) string {
+	p, ok := v.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if id, ok := p["id"].(float64); ok {
+		return fmt.Sprintf("id=%d", int64(id))
+	}
+	if pos, ok := p["gridPos"].(map[string]interface{}); ok {
+		return fmt.Sprintf("gridPos=%v,%v", pos["x"], pos["y"])
+	}
+	return ""
+}
+
+func nameKey(v interface{}) string {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if name, ok := obj["name"].(string); ok {
+		return "name=" + name
+	}
+	return ""
+}
+
+// indexArray maps elements to their keys. Elements without a key, or with a duplicate key,
+// are keyed by position.
+func indexArray(arr []interface{}, key func(interface{}) string) (map[string]interface{}, []string) {
+	byKey := make(map[string]interface{}, len(arr))
+	keys := make([]string, 0, len(arr))
+	for i, v := range arr {
+		k := key(v)
+		if _, exists := byKey[k]; k == "" || exists {
+			k = fmt.Sprintf("%d", i)
+		}
+		byKey[k] = v
+		keys = append(keys, k)
+	}
+	return byKey, keys
+}
+
+func maxPanelID(panels []interface{}) int64 {
+	var maxID int64
+	for _, v := range panels {
+		p, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, ok := p["id"].(float64); ok && int64(id) > maxID {
+			maxID = int64(id)
+		}
+		if nested, ok := p["panels"].([]interface{}); ok {
+			if id := maxPanelID(nested); id > maxID {
+				maxID = id
+			}
+		}
+	}
+	return maxID
+}
+
+func get(obj map[string]interface{}, k string) interface{} {
+	if v, ok := obj[k]; ok {
+		return v
+	}
+	return absent
+}
+
+func getKey(byKey map[string]interface{}, k string) interface{} {
+	if v, ok := byKey[k]; ok {
+		return v
+	}
+	return absent
+}
+
+func copyObject(obj map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		c[k] = v
+	}
+	return c
+}
+
+func indexOf(keys []string, k string) int {
+	for i, key := range keys {
+		if key == k {
+			return i
+		}
+	}
+	return -1
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
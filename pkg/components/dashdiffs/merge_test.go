@@ -0,0 +1,141 @@
+package dashdiffs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func mustJSON(t *testing.T, data string) *simplejson.Json {
+	t.Helper()
+	j, err := simplejson.NewJson([]byte(data))
+	require.NoError(t, err)
+	return j
+}
+
+const mergeBase = `{
+	"uid": "abc",
+	"version": 1,
+	"title": "Servers",
+	"tags": ["prod"],
+	"panels": [
+		{"id": 1, "title": "CPU", "type": "timeseries", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}},
+		{"id": 2, "title": "Memory", "type": "timeseries", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8}},
+		{"id": 3, "title": "Row", "type": "row", "panels": [
+			{"id": 4, "title": "Disk", "type": "stat"}
+		]}
+	],
+	"templating": {"list": [
+		{"name": "server", "query": "label_values(up, instance)"},
+		{"name": "env", "query": "prod,dev"}
+	]}
+}`
+
+func TestMerge(t *testing.T) {
+	t.Run("merges changes to different panels and variables", func(t *testing.T) {
+		base := mustJSON(t, mergeBase)
+
+		theirs := mustJSON(t, mergeBase)
+		theirs.Set("version", 2)
+		theirs.Get("panels").GetIndex(0).Set("title", "CPU usage")
+		theirs.Get("templating").Get("list").GetIndex(1).Set("query", "prod,dev,test")
+
+		mine := mustJSON(t, mergeBase)
+		mine.Get("panels").GetIndex(1).Set("title", "Memory usage")
+		mine.Get("panels").GetIndex(2).Get("panels").GetIndex(0).Set("type", "gauge")
+		mine.Get("templating").Get("list").GetIndex(0).Set("query", "label_values(up, job)")
+		mine.Set("title", "Servers overview")
+
+		result, err := Merge(base, theirs, mine)
+		require.NoError(t, err)
+		require.Empty(t, result.Conflicts)
+
+		dash := result.Dashboard
+		require.Equal(t, 2, dash.Get("version").MustInt())
+		require.Equal(t, "Servers overview", dash.Get("title").MustString())
+		require.Equal(t, "CPU usage", dash.Get("panels").GetIndex(0).Get("title").MustString())
+		require.Equal(t, "Memory usage", dash.Get("panels").GetIndex(1).Get("title").MustString())
+		require.Equal(t, "gauge", dash.Get("panels").GetIndex(2).Get("panels").GetIndex(0).Get("type").MustString())
+		require.Equal(t, "label_values(up, job)", dash.Get("templating").Get("list").GetIndex(0).Get("query").MustString())
+		require.Equal(t, "prod,dev,test", dash.Get("templating").Get("list").GetIndex(1).Get("query").MustString())
+	})
+
+	t.Run("keeps panels added and removed on both sides", func(t *testing.T) {
+		base := mustJSON(t, mergeBase)
+
+		theirs := mustJSON(t, mergeBase)
+		theirs.Set("panels", []interface{}{
+			theirs.Get("panels").GetIndex(0).Interface(),
+			map[string]interface{}{"id": 5, "title": "Network", "type": "timeseries"},
+			theirs.Get("panels").GetIndex(2).Interface(),
+		})
+
+		mine := mustJSON(t, mergeBase)
+		mine.Set("panels", []interface{}{
+			map[string]interface{}{"id": 5, "title": "Load", "type": "stat"},
+			mine.Get("panels").GetIndex(0).Interface(),
+			mine.Get("panels").GetIndex(1).Interface(),
+			mine.Get("panels").GetIndex(2).Interface(),
+		})
+
+		result, err := Merge(base, theirs, mine)
+		require.NoError(t, err)
+		require.Empty(t, result.Conflicts)
+
+		panels := result.Dashboard.Get("panels").MustArray()
+		titles := make([]string, 0, len(panels))
+		for i := range panels {
+			titles = append(titles, result.Dashboard.Get("panels").GetIndex(i).Get("title").MustString())
+		}
+		// Memory is deleted in theirs, mine's panel 5 is renumbered since theirs added a different panel 5
+		require.Equal(t, []string{"Load", "CPU", "Network", "Row"}, titles)
+		require.Equal(t, 6, result.Dashboard.Get("panels").GetIndex(0).Get("id").MustInt())
+	})
+
+	t.Run("matches panels without id by grid position", func(t *testing.T) {
+		base := mustJSON(t, `{"panels": [{"title": "A", "gridPos": {"x": 0, "y": 0}}, {"title": "B", "gridPos": {"x": 0, "y": 8}}]}`)
+		theirs := mustJSON(t, `{"panels": [{"title": "B", "gridPos": {"x": 0, "y": 8}}, {"title": "A2", "gridPos": {"x": 0, "y": 0}}]}`)
+		mine := mustJSON(t, `{"panels": [{"title": "A", "gridPos": {"x": 0, "y": 0}}, {"title": "B2", "gridPos": {"x": 0, "y": 8}}]}`)
+
+		result, err := Merge(base, theirs, mine)
+		require.NoError(t, err)
+		require.Empty(t, result.Conflicts)
+		require.Equal(t, "B2", result.Dashboard.Get("panels").GetIndex(0).Get("title").MustString())
+		require.Equal(t, "A2", result.Dashboard.Get("panels").GetIndex(1).Get("title").MustString())
+	})
+
+	t.Run("returns conflicts", func(t *testing.T) {
+		base := mustJSON(t, mergeBase)
+
+		theirs := mustJSON(t, mergeBase)
+		theirs.Get("panels").GetIndex(0).Set("title", "CPU usage")
+		theirs.Set("tags", []interface{}{"prod", "servers"})
+		theirs.Get("templating").Set("list", []interface{}{theirs.Get("templating").Get("list").GetIndex(0).Interface()})
+
+		mine := mustJSON(t, mergeBase)
+		mine.Get("panels").GetIndex(0).Set("title", "Processor")
+		mine.Set("tags", []interface{}{"production"})
+		mine.Get("templating").Get("list").GetIndex(1).Set("query", "prod")
+
+		result, err := Merge(base, theirs, mine)
+		require.NoError(t, err)
+		require.Nil(t, result.Dashboard)
+		require.Equal(t, []Conflict{
+			{Path: "panels[id=1].title", Reason: ConflictModified, Base: "CPU", Theirs: "CPU usage", Mine: "Processor"},
+			{Path: "tags", Reason: ConflictModified, Base: []interface{}{"prod"}, Theirs: []interface{}{"prod", "servers"}, Mine: []interface{}{"production"}},
+			{
+				Path:   "templating.list[name=env]",
+				Reason: ConflictDeletedInTheirs,
+				Base:   map[string]interface{}{"name": "env", "query": "prod,dev"},
+				Mine:   map[string]interface{}{"name": "env", "query": "prod"},
+			},
+		}, result.Conflicts)
+	})
+
+	t.Run("returns an error for invalid dashboards", func(t *testing.T) {
+		_, err := Merge(mustJSON(t, mergeBase), mustJSON(t, `[]`), mustJSON(t, mergeBase))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,115 @@
+// Package geoip locates IP addresses with local MaxMind databases, so that the sessions of users can
+// show where they were opened from without calling an external service.
+package geoip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/network"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Location is where an IP address is, the fields missing in the databases are empty
+type Location struct {
+	Country        string `json:"country,omitempty"`
+	City           string `json:"city,omitempty"`
+	ASN            int64  `json:"asn,omitempty"`
+	ASOrganization string `json:"asOrganization,omitempty"`
+}
+
+type Service struct {
+	// city is a GeoLite2 or GeoIP2 City or Country database
+	city *geoip2.Reader
+	// asn is a GeoLite2 ASN or GeoIP2 ISP database
+	asn *geoip2.Reader
+	log log.Logger
+}
+
+// ProvideService opens the MaxMind databases of the session_geoip_database_path and
+// session_geoip_asn_database_path settings of the auth section, the lookups don't find
+// anything when they aren't set
+func ProvideService(cfg *setting.Cfg) (*Service, error) {
+	section := cfg.SectionWithEnvOverrides("auth")
+	s := &Service{log: log.New("geoip")}
+
+	var err error
+	s.city, err = open(section.Key("session_geoip_database_path").String(), func(r *geoip2.Reader) error {
+		_, err := r.City(net.IPv4zero)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.asn, err = open(section.Key("session_geoip_asn_database_path").String(), func(r *geoip2.Reader) error {
+		_, err := r.ASN(net.IPv4zero)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open opens a database, check is a lookup failing when the database is of the wrong type
+func open(path string, check func(r *geoip2.Reader) error) (*geoip2.Reader, error) {
+	if path == "" {
+		return nil, nil
+	}
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database %s: %w", path, err)
+	}
+	if err := check(reader); err != nil {
+		_ = reader.Close()
+		return nil, fmt.Errorf("invalid GeoIP database %s: %w", path, err)
+	}
+	return reader, nil
+}
+
+// Lookup returns the location of the address, or nil when it isn't found in any database
+func (s *Service) Lookup(addr string) *Location {
+	if s == nil || (s.city == nil && s.asn == nil) {
+		return nil
+	}
+
+	ip, err := network.GetIPFromAddress(addr)
+	if err != nil {
+		return nil
+	}
+
+	location := Location{}
+	if s.city != nil {
+		s.lookupCity(ip, &location)
+	}
+	if s.asn != nil {
+		s.lookupASN(ip, &location)
+	}
+	if location == (Location{}) {
+		return nil
+	}
+	return &location
+}
+
+func (s *Service) lookupCity(ip net.IP, location *Location) {
+	record, err := s.city.City(ip)
+	if err != nil {
+		s.log.Debug("Failed to look up the location of an IP address", "error", err)
+		return
+	}
+	location.Country = record.Country.IsoCode
+	location.City = record.City.Names["en"]
+}
+
+func (s *Service) lookupASN(ip net.IP, location *Location) {
+	record, err := s.asn.ASN(ip)
+	if err != nil {
+		s.log.Debug("Failed to look up the autonomous system of an IP address", "error", err)
+		return
+	}
+	location.ASN = int64(record.AutonomousSystemNumber)
+	location.ASOrganization = record.AutonomousSystemOrganization
+}
@@ -0,0 +1,223 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestService_Lookup(t *testing.T) {
+	cityPath := writeDatabase(t, "GeoLite2-City", map[string]map[string]interface{}{
+		"10.1.0.0/16": {
+			"country": map[string]interface{}{"iso_code": "SE"},
+			"city":    map[string]interface{}{"names": map[string]interface{}{"en": "Stockholm"}},
+		},
+		"2001:db8::/32": {
+			"country": map[string]interface{}{"iso_code": "FR"},
+		},
+	})
+	asnPath := writeDatabase(t, "GeoLite2-ASN", map[string]map[string]interface{}{
+		"10.0.0.0/8": {
+			"autonomous_system_number":       uint32(64512),
+			"autonomous_system_organization": "Private",
+		},
+	})
+
+	s, err := ProvideService(testCfg(t, cityPath, asnPath))
+	require.NoError(t, err)
+
+	require.Equal(t, &Location{Country: "SE", City: "Stockholm", ASN: 64512, ASOrganization: "Private"}, s.Lookup("10.1.3.4:3000"))
+	require.Equal(t, &Location{ASN: 64512, ASOrganization: "Private"}, s.Lookup("10.2.3.4"))
+	require.Equal(t, &Location{Country: "FR"}, s.Lookup("[2001:db8::1]"))
+	require.Nil(t, s.Lookup("192.168.0.1"))
+	require.Nil(t, s.Lookup("::ffff:c0a8:1"))
+	require.Nil(t, s.Lookup("unknown"))
+
+	s, err = ProvideService(testCfg(t, cityPath, ""))
+	require.NoError(t, err)
+	require.Equal(t, &Location{Country: "SE", City: "Stockholm"}, s.Lookup("10.1.3.4"))
+
+	s, err = ProvideService(testCfg(t, "", ""))
+	require.NoError(t, err)
+	require.Nil(t, s.Lookup("10.1.3.4"))
+}
+
+func TestProvideService(t *testing.T) {
+	asnPath := writeDatabase(t, "GeoLite2-ASN", map[string]map[string]interface{}{
+		"10.0.0.0/8": {"autonomous_system_number": uint32(64512)},
+	})
+
+	// the databases are swapped
+	_, err := ProvideService(testCfg(t, asnPath, ""))
+	require.Error(t, err)
+
+	_, err = ProvideService(testCfg(t, filepath.Join(t.TempDir(), "missing.mmdb"), ""))
+	require.Error(t, err)
+
+	notADatabase := filepath.Join(t.TempDir(), "geoip.csv")
+	require.NoError(t, os.WriteFile(notADatabase, []byte("network,country\n10.0.0.0/8,SE\n"), 0600))
+	_, err = ProvideService(testCfg(t, notADatabase, ""))
+	require.Error(t, err)
+}
+
+func testCfg(t *testing.T, cityPath, asnPath string) *setting.Cfg {
+	t.Helper()
+	cfg := setting.NewCfg()
+	section := cfg.Raw.Section("auth")
+	_, err := section.NewKey("session_geoip_database_path", cityPath)
+	require.NoError(t, err)
+	_, err = section.NewKey("session_geoip_asn_database_path", asnPath)
+	require.NoError(t, err)
+	return cfg
+}
+
+// writeDatabase writes a MaxMind DB of IPv6 type with the records of non overlapping networks,
+// IPv4 networks are stored in ::/96 like in the MaxMind databases.
+func writeDatabase(t *testing.T, databaseType string, records map[string]map[string]interface{}) string {
+	t.Helper()
+
+	type node struct {
+		children [2]*node
+		// data is the offset + 1 in the data section of the records ending at each child
+		data [2]int
+	}
+	root := &node{}
+	dataSection := &bytes.Buffer{}
+	for cidr, record := range records {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		ones, _ := ipNet.Mask.Size()
+		ip := ipNet.IP.To16()
+		if ipNet.IP.To4() != nil {
+			ip = append(make(net.IP, 12), ipNet.IP.To4()...)
+			ones += 96
+		}
+
+		offset := dataSection.Len()
+		writeData(t, dataSection, record)
+		current := root
+		for i := 0; i < ones; i++ {
+			bit := (ip[i/8] >> (7 - i%8)) & 1
+			if i == ones-1 {
+				current.data[bit] = offset + 1
+				break
+			}
+			if current.children[bit] == nil {
+				current.children[bit] = &node{}
+			}
+			current = current.children[bit]
+		}
+	}
+
+	// nodes are numbered breadth first, the root is the first one
+	nodes := []*node{root}
+	index := map[*node]int{root: 0}
+	for i := 0; i < len(nodes); i++ {
+		for _, child := range nodes[i].children {
+			if child != nil {
+				index[child] = len(nodes)
+				nodes = append(nodes, child)
+			}
+		}
+	}
+
+	db := &bytes.Buffer{}
+	nodeCount := len(nodes)
+	for _, n := range nodes {
+		for bit := 0; bit < 2; bit++ {
+			record := nodeCount
+			if n.children[bit] != nil {
+				record = index[n.children[bit]]
+			} else if n.data[bit] > 0 {
+				record = nodeCount + 16 + n.data[bit] - 1
+			}
+			db.Write([]byte{byte(record >> 16), byte(record >> 8), byte(record)})
+		}
+	}
+	db.Write(make([]byte, 16))
+	db.Write(dataSection.Bytes())
+	db.WriteString("\xAB\xCD\xEFMaxMind.com")
+	writeData(t, db, map[string]interface{}{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1672531200),
+		"database_type":               databaseType,
+		"description":                 map[string]interface{}{"en": "Test database"},
+		"ip_version":                  uint16(6),
+		"languages":                   []interface{}{"en"},
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+	})
+
+	path := filepath.Join(t.TempDir(), databaseType+".mmdb")
+	require.NoError(t, os.WriteFile(path, db.Bytes(), 0600))
+	return path
+}
+
+// writeData encodes a value of the data section of a MaxMind DB
+func writeData(t *testing.T, w *bytes.Buffer, value interface{}) {
+	t.Helper()
+
+	control := func(dataType, size int) {
+		require.Less(t, size, 29+256)
+		var extra []byte
+		if size >= 29 {
+			size, extra = 29, []byte{byte(size - 29)}
+		}
+		if dataType > 7 {
+			w.Write([]byte{byte(size), byte(dataType - 7)})
+		} else {
+			w.WriteByte(byte(dataType<<5 | size))
+		}
+		w.Write(extra)
+	}
+	uintBytes := func(v uint64, size int) []byte {
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, v)
+		return bytes.TrimLeft(b[8-size:], "\x00")
+	}
+
+	switch v := value.(type) {
+	case string:
+		control(2, len(v))
+		w.WriteString(v)
+	case uint16:
+		b := uintBytes(uint64(v), 2)
+		control(5, len(b))
+		w.Write(b)
+	case uint32:
+		b := uintBytes(uint64(v), 4)
+		control(6, len(b))
+		w.Write(b)
+	case uint64:
+		b := uintBytes(v, 8)
+		control(9, len(b))
+		w.Write(b)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		control(7, len(v))
+		for _, k := range keys {
+			writeData(t, w, k)
+			writeData(t, w, v[k])
+		}
+	case []interface{}:
+		control(11, len(v))
+		for _, item := range v {
+			writeData(t, w, item)
+		}
+	default:
+		t.Fatalf("unsupported type %T", value)
+	}
+}
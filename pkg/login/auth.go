@@ -54,7 +54,7 @@ func ProvideService(store db.DB, loginService login.Service,
 
 // AuthenticateUser authenticates the user via username & password
 func (a *AuthenticatorService) AuthenticateUser(ctx context.Context, query *login.LoginUserQuery) error {
-	ok, err := a.loginAttemptService.Validate(ctx, query.Username)
+	ok, err := a.loginAttemptService.Validate(ctx, query.Username, query.IpAddress)
 	if err != nil {
 		return err
 	}
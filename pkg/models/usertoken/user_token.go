@@ -35,6 +35,10 @@ type UserToken struct {
 	UpdatedAt     int64
 	RevokedAt     int64
 	UnhashedToken string
+	// DeviceName is the name given to the session by the user
+	DeviceName string
+	// LastSeenAt is the unix time of the last request made with the token, it's updated at most once a minute
+	LastSeenAt int64
 }
 
 const UrgentRotateTime = 1 * time.Minute
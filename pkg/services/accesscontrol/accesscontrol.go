@@ -46,6 +46,26 @@ type Service interface {
 	IsDisabled() bool
 }
 
+// RoleService manages custom roles and their assignments to users, teams and service accounts.
+type RoleService interface {
+	// GetRoles returns the custom roles of an organization, including global ones.
+	GetRoles(ctx context.Context, query GetRolesQuery) ([]RoleDTO, error)
+	// GetRole returns a custom role with its permissions.
+	GetRole(ctx context.Context, orgID int64, uid string) (*RoleDTO, error)
+	// CreateRole creates a custom role after validating its permissions against the registered ones.
+	CreateRole(ctx context.Context, cmd CreateRoleCommand) (*RoleDTO, error)
+	// UpdateRole updates a custom role and increases its version.
+	UpdateRole(ctx context.Context, cmd UpdateRoleCommand) (*RoleDTO, error)
+	// DeleteRole deletes a custom role.
+	DeleteRole(ctx context.Context, cmd DeleteRoleCommand) error
+	// GetAssignedRoles returns the custom roles assigned to a user or a team.
+	GetAssignedRoles(ctx context.Context, query GetAssignedRolesQuery) ([]RoleDTO, error)
+	// AssignRole assigns a custom role to a user or a team.
+	AssignRole(ctx context.Context, cmd RoleAssignmentCommand) error
+	// UnassignRole removes a custom role from a user or a team.
+	UnassignRole(ctx context.Context, cmd RoleAssignmentCommand) error
+}
+
 type RoleRegistry interface {
 	// RegisterFixedRoles registers all roles declared to AccessControl
 	RegisterFixedRoles(ctx context.Context) error
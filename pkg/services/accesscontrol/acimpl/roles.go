@@ -0,0 +1,216 @@
+package acimpl
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// GetRoles returns the custom roles of an organization, including global ones, and the declared fixed roles
+// when requested. Fixed roles are read only.
+func (s *Service) GetRoles(ctx context.Context, query accesscontrol.GetRolesQuery) ([]accesscontrol.RoleDTO, error) {
+	roles, err := s.store.GetCustomRoles(ctx, query.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if !query.IncludeFixed {
+		return roles, nil
+	}
+
+	fixed := make([]accesscontrol.RoleDTO, 0)
+	s.registrations.Range(func(registration accesscontrol.RoleRegistration) bool {
+		fixed = append(fixed, registration.Role)
+		return true
+	})
+	sort.Slice(fixed, func(i, j int) bool { return fixed[i].Name < fixed[j].Name })
+	return append(roles, fixed...), nil
+}
+
+func (s *Service) GetRole(ctx context.Context, orgID int64, uid string) (*accesscontrol.RoleDTO, error) {
+	return s.store.GetCustomRole(ctx, orgID, uid)
+}
+
+func (s *Service) CreateRole(ctx context.Context, cmd accesscontrol.CreateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.validateRolePermissions(cmd.Permissions); err != nil {
+		return nil, err
+	}
+	role, err := s.store.CreateRole(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        role.OrgID,
+		Action:       auditlog.ActionCreate,
+		ResourceKind: auditResourceKindRole,
+		ResourceUID:  role.UID,
+		After:        role,
+	})
+	return role, nil
+}
+
+func (s *Service) UpdateRole(ctx context.Context, cmd accesscontrol.UpdateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.validateRolePermissions(cmd.Permissions); err != nil {
+		return nil, err
+	}
+	before := s.auditedRole(ctx, cmd.OrgID, cmd.UID)
+	role, err := s.store.UpdateRole(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        role.OrgID,
+		Action:       auditlog.ActionUpdate,
+		ResourceKind: auditResourceKindRole,
+		ResourceUID:  role.UID,
+		Before:       before,
+		After:        role,
+	})
+	return role, nil
+}
+
+func (s *Service) DeleteRole(ctx context.Context, cmd accesscontrol.DeleteRoleCommand) error {
+	before := s.auditedRole(ctx, cmd.OrgID, cmd.UID)
+	if err := s.store.DeleteRole(ctx, cmd); err != nil {
+		return err
+	}
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        cmd.OrgID,
+		Action:       auditlog.ActionDelete,
+		ResourceKind: auditResourceKindRole,
+		ResourceUID:  cmd.UID,
+		Before:       before,
+	})
+	return nil
+}
+
+func (s *Service) GetAssignedRoles(ctx context.Context, query accesscontrol.GetAssignedRolesQuery) ([]accesscontrol.RoleDTO, error) {
+	return s.store.GetAssignedRoles(ctx, query)
+}
+
+func (s *Service) AssignRole(ctx context.Context, cmd accesscontrol.RoleAssignmentCommand) error {
+	if err := s.store.AssignRole(ctx, cmd); err != nil {
+		return err
+	}
+	s.clearAssigneeCache(cmd)
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        cmd.OrgID,
+		Action:       auditlog.ActionAssign,
+		ResourceKind: auditResourceKindRole,
+		ResourceUID:  cmd.RoleUID,
+		After:        auditedAssignment(cmd),
+	})
+	return nil
+}
+
+func (s *Service) UnassignRole(ctx context.Context, cmd accesscontrol.RoleAssignmentCommand) error {
+	if err := s.store.UnassignRole(ctx, cmd); err != nil {
+		return err
+	}
+	s.clearAssigneeCache(cmd)
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        cmd.OrgID,
+		Action:       auditlog.ActionUnassign,
+		ResourceKind: auditResourceKindRole,
+		ResourceUID:  cmd.RoleUID,
+		Before:       auditedAssignment(cmd),
+	})
+	return nil
+}
+
+// auditResourceKindRole is the kind of custom roles in the audit log
+const auditResourceKindRole = "roles"
+
+// auditedRole returns a custom role to be recorded in the audit log before it's changed.
+func (s *Service) auditedRole(ctx context.Context, orgID int64, uid string) *accesscontrol.RoleDTO {
+	if !auditlog.IsRecording(ctx) {
+		return nil
+	}
+	role, err := s.store.GetCustomRole(ctx, orgID, uid)
+	if err != nil {
+		return nil
+	}
+	return role
+}
+
+type roleAssignment struct {
+	UserID int64 `json:"userId,omitempty"`
+	TeamID int64 `json:"teamId,omitempty"`
+	Global bool  `json:"global,omitempty"`
+}
+
+func auditedAssignment(cmd accesscontrol.RoleAssignmentCommand) roleAssignment {
+	return roleAssignment{UserID: cmd.UserID, TeamID: cmd.TeamID, Global: cmd.Global}
+}
+
+// clearAssigneeCache reloads the permissions of the user or service account a role was assigned to.
+// Team members and global assignments in other organizations are updated when their cache expires.
+func (s *Service) clearAssigneeCache(cmd accesscontrol.RoleAssignmentCommand) {
+	if cmd.UserID == 0 {
+		return
+	}
+	s.ClearUserPermissionCache(&user.SignedInUser{OrgID: cmd.OrgID, UserID: cmd.UserID})
+	s.ClearUserPermissionCache(&user.SignedInUser{OrgID: cmd.OrgID, UserID: cmd.UserID, IsServiceAccount: true})
+}
+
+// validateRolePermissions checks that custom role permissions use actions declared by Grafana, with
+// scopes of the kinds these actions are declared with.
+func (s *Service) validateRolePermissions(permissions []accesscontrol.Permission) error {
+	registered := s.registeredScopeKinds()
+	for _, p := range permissions {
+		kinds, ok := registered[p.Action]
+		if !ok {
+			return accesscontrol.NewRoleInvalidError("action %q is not registered", p.Action)
+		}
+		if p.Scope == "" {
+			continue
+		}
+		if !accesscontrol.ValidateScope(p.Scope) {
+			return accesscontrol.NewRoleInvalidError("scope %q of action %q is invalid", p.Scope, p.Action)
+		}
+		kind := scopeKind(p.Scope)
+		if !kinds[kind] && !(kind == "*" && len(kinds) > 0) {
+			return accesscontrol.NewRoleInvalidError("action %q can't be scoped with %q", p.Action, p.Scope)
+		}
+	}
+	return nil
+}
+
+// registeredScopeKinds returns the scope kinds each declared action is used with.
+func (s *Service) registeredScopeKinds() map[string]map[string]bool {
+	registered := map[string]map[string]bool{}
+	add := func(permissions []accesscontrol.Permission) {
+		for _, p := range permissions {
+			if registered[p.Action] == nil {
+				registered[p.Action] = map[string]bool{}
+			}
+			if p.Scope != "" {
+				registered[p.Action][scopeKind(p.Scope)] = true
+			}
+		}
+	}
+
+	s.registrations.Range(func(registration accesscontrol.RoleRegistration) bool {
+		add(registration.Role.Permissions)
+		return true
+	})
+	for _, basicRole := range s.roles {
+		add(basicRole.Permissions)
+	}
+	return registered
+}
+
+// scopeKind returns the kind of resource a scope applies to, for example "dashboards" for "dashboards:uid:abc".
+func scopeKind(scope string) string {
+	kind, _, _ := strings.Cut(scope, ":")
+	return kind
+}
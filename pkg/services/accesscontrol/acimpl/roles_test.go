@@ -0,0 +1,95 @@
+package acimpl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestService_CustomRoles(t *testing.T) {
+	ctx := context.Background()
+	ac := setupTestEnv(t)
+	ac.cache = localcache.ProvideService()
+	ac.registrations.Append(accesscontrol.RoleRegistration{
+		Role: accesscontrol.RoleDTO{
+			Name: "fixed:dashboards:writer",
+			Permissions: []accesscontrol.Permission{
+				{Action: "dashboards:read", Scope: "dashboards:uid:*"},
+				{Action: "dashboards:write", Scope: "folders:uid:*"},
+				{Action: "dashboards:create"},
+			},
+		},
+	})
+
+	tests := []struct {
+		name        string
+		cmd         accesscontrol.CreateRoleCommand
+		expectedErr error
+	}{
+		{
+			name: "should create a role with registered permissions",
+			cmd: accesscontrol.CreateRoleCommand{OrgID: 1, Name: "custom:dashboards:reader", Permissions: []accesscontrol.Permission{
+				{Action: "dashboards:read", Scope: "dashboards:uid:abc"},
+				{Action: "dashboards:write", Scope: "*"},
+				{Action: "dashboards:create"},
+			}},
+		},
+		{
+			name:        "should not create a role without the custom prefix",
+			cmd:         accesscontrol.CreateRoleCommand{OrgID: 1, Name: "dashboards:reader"},
+			expectedErr: accesscontrol.ErrRoleInvalid,
+		},
+		{
+			name:        "should not create a role with a reserved prefix",
+			cmd:         accesscontrol.CreateRoleCommand{OrgID: 1, Name: "fixed:dashboards:reader"},
+			expectedErr: accesscontrol.ErrRoleImmutable,
+		},
+		{
+			name: "should not create a role with an unknown action",
+			cmd: accesscontrol.CreateRoleCommand{OrgID: 1, Name: "custom:unknown", Permissions: []accesscontrol.Permission{
+				{Action: "unknown:read"},
+			}},
+			expectedErr: accesscontrol.ErrRoleInvalid,
+		},
+		{
+			name: "should not create a role with a scope of another kind",
+			cmd: accesscontrol.CreateRoleCommand{OrgID: 1, Name: "custom:datasources", Permissions: []accesscontrol.Permission{
+				{Action: "dashboards:read", Scope: "datasources:uid:abc"},
+			}},
+			expectedErr: accesscontrol.ErrRoleInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ac.CreateRole(ctx, tt.cmd)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("should grant the permissions of assigned roles", func(t *testing.T) {
+		role, err := ac.CreateRole(ctx, accesscontrol.CreateRoleCommand{OrgID: 1, Name: "custom:dashboards:creator", Permissions: []accesscontrol.Permission{
+			{Action: "dashboards:create"},
+		}})
+		require.NoError(t, err)
+
+		require.NoError(t, ac.AssignRole(ctx, accesscontrol.RoleAssignmentCommand{OrgID: 1, UserID: 2, RoleUID: role.UID}))
+		perms, err := ac.getUserPermissions(ctx, &user.SignedInUser{OrgID: 1, UserID: 2}, accesscontrol.Options{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []accesscontrol.Permission{{Action: "dashboards:create"}}, perms)
+
+		roles, err := ac.GetRoles(ctx, accesscontrol.GetRolesQuery{OrgID: 1, IncludeFixed: true})
+		require.NoError(t, err)
+		require.Len(t, roles, 3)
+		require.Equal(t, "fixed:dashboards:writer", roles[2].Name)
+	})
+}
@@ -26,6 +26,7 @@ import (
 )
 
 var _ plugins.RoleRegistry = &Service{}
+var _ accesscontrol.RoleService = &Service{}
 
 const (
 	cacheTTL = 10 * time.Second
@@ -36,7 +37,7 @@ func ProvideService(cfg *setting.Cfg, store db.DB, routeRegister routing.RouteRe
 	service := ProvideOSSService(cfg, database.ProvideService(store), cache, features)
 
 	if !accesscontrol.IsDisabled(cfg) {
-		api.NewAccessControlAPI(routeRegister, accessControl, service, features).RegisterAPIEndpoints()
+		api.NewAccessControlAPI(routeRegister, accessControl, service, service, features).RegisterAPIEndpoints()
 		if err := accesscontrol.DeclareFixedRoles(service, cfg); err != nil {
 			return nil, err
 		}
@@ -66,6 +67,14 @@ type store interface {
 	DeleteUserPermissions(ctx context.Context, orgID, userID int64) error
 	SaveExternalServiceRole(ctx context.Context, cmd accesscontrol.SaveExternalServiceRoleCommand) error
 	DeleteExternalServiceRole(ctx context.Context, externalServiceID string) error
+	GetCustomRoles(ctx context.Context, orgID int64) ([]accesscontrol.RoleDTO, error)
+	GetCustomRole(ctx context.Context, orgID int64, uid string) (*accesscontrol.RoleDTO, error)
+	CreateRole(ctx context.Context, cmd accesscontrol.CreateRoleCommand) (*accesscontrol.RoleDTO, error)
+	UpdateRole(ctx context.Context, cmd accesscontrol.UpdateRoleCommand) (*accesscontrol.RoleDTO, error)
+	DeleteRole(ctx context.Context, cmd accesscontrol.DeleteRoleCommand) error
+	GetAssignedRoles(ctx context.Context, query accesscontrol.GetAssignedRolesQuery) ([]accesscontrol.RoleDTO, error)
+	AssignRole(ctx context.Context, cmd accesscontrol.RoleAssignmentCommand) error
+	UnassignRole(ctx context.Context, cmd accesscontrol.RoleAssignmentCommand) error
 }
 
 // Service is the service implementing role based access control.
@@ -115,7 +124,7 @@ func (s *Service) getUserPermissions(ctx context.Context, user *user.SignedInUse
 		UserID:       user.UserID,
 		Roles:        accesscontrol.GetOrgRoles(user),
 		TeamIDs:      user.Teams,
-		RolePrefixes: []string{accesscontrol.ManagedRolePrefix, accesscontrol.ExternalServiceRolePrefix},
+		RolePrefixes: []string{accesscontrol.ManagedRolePrefix, accesscontrol.ExternalServiceRolePrefix, accesscontrol.CustomRolePrefix},
 	})
 	if err != nil {
 		return nil, err
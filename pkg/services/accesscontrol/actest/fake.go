@@ -80,10 +80,52 @@ func (f FakeAccessControl) IsDisabled() bool {
 	return f.ExpectedDisabled
 }
 
+var _ accesscontrol.RoleService = new(FakeRoleService)
+
+type FakeRoleService struct {
+	ExpectedErr   error
+	ExpectedRole  *accesscontrol.RoleDTO
+	ExpectedRoles []accesscontrol.RoleDTO
+}
+
+func (f FakeRoleService) GetRoles(ctx context.Context, query accesscontrol.GetRolesQuery) ([]accesscontrol.RoleDTO, error) {
+	return f.ExpectedRoles, f.ExpectedErr
+}
+
+func (f FakeRoleService) GetRole(ctx context.Context, orgID int64, uid string) (*accesscontrol.RoleDTO, error) {
+	return f.ExpectedRole, f.ExpectedErr
+}
+
+func (f FakeRoleService) CreateRole(ctx context.Context, cmd accesscontrol.CreateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	return f.ExpectedRole, f.ExpectedErr
+}
+
+func (f FakeRoleService) UpdateRole(ctx context.Context, cmd accesscontrol.UpdateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	return f.ExpectedRole, f.ExpectedErr
+}
+
+func (f FakeRoleService) DeleteRole(ctx context.Context, cmd accesscontrol.DeleteRoleCommand) error {
+	return f.ExpectedErr
+}
+
+func (f FakeRoleService) GetAssignedRoles(ctx context.Context, query accesscontrol.GetAssignedRolesQuery) ([]accesscontrol.RoleDTO, error) {
+	return f.ExpectedRoles, f.ExpectedErr
+}
+
+func (f FakeRoleService) AssignRole(ctx context.Context, cmd accesscontrol.RoleAssignmentCommand) error {
+	return f.ExpectedErr
+}
+
+func (f FakeRoleService) UnassignRole(ctx context.Context, cmd accesscontrol.RoleAssignmentCommand) error {
+	return f.ExpectedErr
+}
+
 type FakeStore struct {
 	ExpectedUserPermissions  []accesscontrol.Permission
 	ExpectedUsersPermissions map[int64][]accesscontrol.Permission
 	ExpectedUsersRoles       map[int64][]string
+	ExpectedRole             *accesscontrol.RoleDTO
+	ExpectedRoles            []accesscontrol.RoleDTO
 	ExpectedErr              error
 }
 
@@ -111,6 +153,38 @@ func (f FakeStore) DeleteExternalServiceRole(ctx context.Context, externalServic
 	return f.ExpectedErr
 }
 
+func (f FakeStore) GetCustomRoles(ctx context.Context, orgID int64) ([]accesscontrol.RoleDTO, error) {
+	return f.ExpectedRoles, f.ExpectedErr
+}
+
+func (f FakeStore) GetCustomRole(ctx context.Context, orgID int64, uid string) (*accesscontrol.RoleDTO, error) {
+	return f.ExpectedRole, f.ExpectedErr
+}
+
+func (f FakeStore) CreateRole(ctx context.Context, cmd accesscontrol.CreateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	return f.ExpectedRole, f.ExpectedErr
+}
+
+func (f FakeStore) UpdateRole(ctx context.Context, cmd accesscontrol.UpdateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	return f.ExpectedRole, f.ExpectedErr
+}
+
+func (f FakeStore) DeleteRole(ctx context.Context, cmd accesscontrol.DeleteRoleCommand) error {
+	return f.ExpectedErr
+}
+
+func (f FakeStore) GetAssignedRoles(ctx context.Context, query accesscontrol.GetAssignedRolesQuery) ([]accesscontrol.RoleDTO, error) {
+	return f.ExpectedRoles, f.ExpectedErr
+}
+
+func (f FakeStore) AssignRole(ctx context.Context, cmd accesscontrol.RoleAssignmentCommand) error {
+	return f.ExpectedErr
+}
+
+func (f FakeStore) UnassignRole(ctx context.Context, cmd accesscontrol.RoleAssignmentCommand) error {
+	return f.ExpectedErr
+}
+
 var _ accesscontrol.PermissionsService = new(FakePermissionsService)
 
 type FakePermissionsService struct {
@@ -15,6 +15,46 @@ type MockStore struct {
 	mock.Mock
 }
 
+// AssignRole provides a mock function with given fields: ctx, cmd
+func (_m *MockStore) AssignRole(ctx context.Context, cmd accesscontrol.RoleAssignmentCommand) error {
+	ret := _m.Called(ctx, cmd)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, accesscontrol.RoleAssignmentCommand) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateRole provides a mock function with given fields: ctx, cmd
+func (_m *MockStore) CreateRole(ctx context.Context, cmd accesscontrol.CreateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	ret := _m.Called(ctx, cmd)
+
+	var r0 *accesscontrol.RoleDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, accesscontrol.CreateRoleCommand) (*accesscontrol.RoleDTO, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, accesscontrol.CreateRoleCommand) *accesscontrol.RoleDTO); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*accesscontrol.RoleDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, accesscontrol.CreateRoleCommand) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteExternalServiceRole provides a mock function with given fields: ctx, externalServiceID
 func (_m *MockStore) DeleteExternalServiceRole(ctx context.Context, externalServiceID string) error {
 	ret := _m.Called(ctx, externalServiceID)
@@ -29,6 +69,20 @@ func (_m *MockStore) DeleteExternalServiceRole(ctx context.Context, externalServ
 	return r0
 }
 
+// DeleteRole provides a mock function with given fields: ctx, cmd
+func (_m *MockStore) DeleteRole(ctx context.Context, cmd accesscontrol.DeleteRoleCommand) error {
+	ret := _m.Called(ctx, cmd)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, accesscontrol.DeleteRoleCommand) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteUserPermissions provides a mock function with given fields: ctx, orgID, userID
 func (_m *MockStore) DeleteUserPermissions(ctx context.Context, orgID int64, userID int64) error {
 	ret := _m.Called(ctx, orgID, userID)
@@ -43,6 +97,84 @@ func (_m *MockStore) DeleteUserPermissions(ctx context.Context, orgID int64, use
 	return r0
 }
 
+// GetAssignedRoles provides a mock function with given fields: ctx, query
+func (_m *MockStore) GetAssignedRoles(ctx context.Context, query accesscontrol.GetAssignedRolesQuery) ([]accesscontrol.RoleDTO, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 []accesscontrol.RoleDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, accesscontrol.GetAssignedRolesQuery) ([]accesscontrol.RoleDTO, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, accesscontrol.GetAssignedRolesQuery) []accesscontrol.RoleDTO); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]accesscontrol.RoleDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, accesscontrol.GetAssignedRolesQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCustomRole provides a mock function with given fields: ctx, orgID, uid
+func (_m *MockStore) GetCustomRole(ctx context.Context, orgID int64, uid string) (*accesscontrol.RoleDTO, error) {
+	ret := _m.Called(ctx, orgID, uid)
+
+	var r0 *accesscontrol.RoleDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (*accesscontrol.RoleDTO, error)); ok {
+		return rf(ctx, orgID, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) *accesscontrol.RoleDTO); ok {
+		r0 = rf(ctx, orgID, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*accesscontrol.RoleDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, orgID, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCustomRoles provides a mock function with given fields: ctx, orgID
+func (_m *MockStore) GetCustomRoles(ctx context.Context, orgID int64) ([]accesscontrol.RoleDTO, error) {
+	ret := _m.Called(ctx, orgID)
+
+	var r0 []accesscontrol.RoleDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]accesscontrol.RoleDTO, error)); ok {
+		return rf(ctx, orgID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []accesscontrol.RoleDTO); ok {
+		r0 = rf(ctx, orgID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]accesscontrol.RoleDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, orgID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserPermissions provides a mock function with given fields: ctx, query
 func (_m *MockStore) GetUserPermissions(ctx context.Context, query accesscontrol.GetUserPermissionsQuery) ([]accesscontrol.Permission, error) {
 	ret := _m.Called(ctx, query)
@@ -135,6 +267,46 @@ func (_m *MockStore) SearchUsersPermissions(ctx context.Context, orgID int64, op
 	return r0, r1
 }
 
+// UnassignRole provides a mock function with given fields: ctx, cmd
+func (_m *MockStore) UnassignRole(ctx context.Context, cmd accesscontrol.RoleAssignmentCommand) error {
+	ret := _m.Called(ctx, cmd)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, accesscontrol.RoleAssignmentCommand) error); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateRole provides a mock function with given fields: ctx, cmd
+func (_m *MockStore) UpdateRole(ctx context.Context, cmd accesscontrol.UpdateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	ret := _m.Called(ctx, cmd)
+
+	var r0 *accesscontrol.RoleDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, accesscontrol.UpdateRoleCommand) (*accesscontrol.RoleDTO, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, accesscontrol.UpdateRoleCommand) *accesscontrol.RoleDTO); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*accesscontrol.RoleDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, accesscontrol.UpdateRoleCommand) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewMockStore interface {
 	mock.TestingT
 	Cleanup(func())
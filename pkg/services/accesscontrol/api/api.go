@@ -14,10 +14,11 @@ import (
 )
 
 func NewAccessControlAPI(router routing.RouteRegister, accesscontrol ac.AccessControl, service ac.Service,
-	features *featuremgmt.FeatureManager) *AccessControlAPI {
+	roleService ac.RoleService, features *featuremgmt.FeatureManager) *AccessControlAPI {
 	return &AccessControlAPI{
 		RouteRegister: router,
 		Service:       service,
+		RoleService:   roleService,
 		AccessControl: accesscontrol,
 		features:      features,
 	}
@@ -25,6 +26,7 @@ func NewAccessControlAPI(router routing.RouteRegister, accesscontrol ac.AccessCo
 
 type AccessControlAPI struct {
 	Service       ac.Service
+	RoleService   ac.RoleService
 	AccessControl ac.AccessControl
 	RouteRegister routing.RouteRegister
 	features      *featuremgmt.FeatureManager
@@ -41,6 +43,22 @@ func (api *AccessControlAPI) RegisterAPIEndpoints() {
 			rr.Get("/users/permissions/search", authorize(ac.EvalPermission(ac.ActionUsersPermissionsRead)), routing.Wrap(api.searchUsersPermissions))
 			rr.Get("/user/:userID/permissions/search", authorize(ac.EvalPermission(ac.ActionUsersPermissionsRead, userIDScope)), routing.Wrap(api.searchUserPermissions))
 		}
+
+		// Custom roles
+		rr.Get("/roles", authorize(ac.EvalPermission(ac.ActionRolesRead)), routing.Wrap(api.getRoles))
+		rr.Post("/roles", authorize(ac.EvalPermission(ac.ActionRolesWrite)), routing.Wrap(api.createRole))
+		rr.Get("/roles/:roleUID", authorize(ac.EvalPermission(ac.ActionRolesRead, ac.ScopeRolesUID)), routing.Wrap(api.getRole))
+		rr.Put("/roles/:roleUID", authorize(ac.EvalPermission(ac.ActionRolesWrite, ac.ScopeRolesUID)), routing.Wrap(api.updateRole))
+		rr.Delete("/roles/:roleUID", authorize(ac.EvalPermission(ac.ActionRolesDelete, ac.ScopeRolesUID)), routing.Wrap(api.deleteRole))
+
+		// Role assignments, service accounts are assigned roles as users
+		userIDScope := ac.Scope("users", "id", ac.Parameter(":userId"))
+		rr.Get("/users/:userId/roles", authorize(ac.EvalPermission(ac.ActionUsersRolesRead, userIDScope)), routing.Wrap(api.getAssignedRoles))
+		rr.Post("/users/:userId/roles", authorize(ac.EvalPermission(ac.ActionUsersRolesAdd, userIDScope)), routing.Wrap(api.assignRole))
+		rr.Delete("/users/:userId/roles/:roleUID", authorize(ac.EvalPermission(ac.ActionUsersRolesRemove, userIDScope)), routing.Wrap(api.unassignRole))
+		rr.Get("/teams/:teamId/roles", authorize(ac.EvalPermission(ac.ActionTeamsRolesRead, ac.ScopeTeamsID)), routing.Wrap(api.getAssignedRoles))
+		rr.Post("/teams/:teamId/roles", authorize(ac.EvalPermission(ac.ActionTeamsRolesAdd, ac.ScopeTeamsID)), routing.Wrap(api.assignRole))
+		rr.Delete("/teams/:teamId/roles/:roleUID", authorize(ac.EvalPermission(ac.ActionTeamsRolesRemove, ac.ScopeTeamsID)), routing.Wrap(api.unassignRole))
 	})
 }
 
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GET /api/access-control/roles
+func (api *AccessControlAPI) getRoles(c *contextmodel.ReqContext) response.Response {
+	roles, err := api.RoleService.GetRoles(c.Req.Context(), ac.GetRolesQuery{
+		OrgID:        c.OrgID,
+		IncludeFixed: c.QueryBool("includeFixed"),
+	})
+	if err != nil {
+		return roleErrorResponse(err, "Failed to get roles")
+	}
+	return response.JSON(http.StatusOK, roles)
+}
+
+// GET /api/access-control/roles/:roleUID
+func (api *AccessControlAPI) getRole(c *contextmodel.ReqContext) response.Response {
+	role, err := api.RoleService.GetRole(c.Req.Context(), c.OrgID, web.Params(c.Req)[":roleUID"])
+	if err != nil {
+		return roleErrorResponse(err, "Failed to get role")
+	}
+	return response.JSON(http.StatusOK, role)
+}
+
+// POST /api/access-control/roles
+func (api *AccessControlAPI) createRole(c *contextmodel.ReqContext) response.Response {
+	cmd := ac.CreateRoleCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgID
+	if cmd.Global && !c.SignedInUser.IsGrafanaAdmin {
+		return response.Error(http.StatusForbidden, "Only server admins can create global roles", nil)
+	}
+
+	if err := api.checkDelegation(c, cmd.Permissions); err != nil {
+		return roleErrorResponse(err, "Failed to create role")
+	}
+
+	role, err := api.RoleService.CreateRole(c.Req.Context(), cmd)
+	if err != nil {
+		return roleErrorResponse(err, "Failed to create role")
+	}
+	return response.JSON(http.StatusCreated, role)
+}
+
+// PUT /api/access-control/roles/:roleUID
+func (api *AccessControlAPI) updateRole(c *contextmodel.ReqContext) response.Response {
+	cmd := ac.UpdateRoleCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgID
+	cmd.UID = web.Params(c.Req)[":roleUID"]
+	cmd.AllowGlobal = c.SignedInUser.IsGrafanaAdmin
+
+	if err := api.checkDelegation(c, cmd.Permissions); err != nil {
+		return roleErrorResponse(err, "Failed to update role")
+	}
+
+	role, err := api.RoleService.UpdateRole(c.Req.Context(), cmd)
+	if err != nil {
+		return roleErrorResponse(err, "Failed to update role")
+	}
+	return response.JSON(http.StatusOK, role)
+}
+
+// DELETE /api/access-control/roles/:roleUID
+func (api *AccessControlAPI) deleteRole(c *contextmodel.ReqContext) response.Response {
+	err := api.RoleService.DeleteRole(c.Req.Context(), ac.DeleteRoleCommand{
+		OrgID:       c.OrgID,
+		UID:         web.Params(c.Req)[":roleUID"],
+		Force:       c.QueryBool("force"),
+		AllowGlobal: c.SignedInUser.IsGrafanaAdmin,
+	})
+	if err != nil {
+		return roleErrorResponse(err, "Failed to delete role")
+	}
+	return response.Success("Role deleted")
+}
+
+// GET /api/access-control/users/:userId/roles
+// GET /api/access-control/teams/:teamId/roles
+func (api *AccessControlAPI) getAssignedRoles(c *contextmodel.ReqContext) response.Response {
+	query := ac.GetAssignedRolesQuery{OrgID: c.OrgID}
+	var err error
+	if query.UserID, query.TeamID, err = assigneeFromParams(c); err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+
+	roles, err := api.RoleService.GetAssignedRoles(c.Req.Context(), query)
+	if err != nil {
+		return roleErrorResponse(err, "Failed to get assigned roles")
+	}
+	return response.JSON(http.StatusOK, roles)
+}
+
+// POST /api/access-control/users/:userId/roles
+// POST /api/access-control/teams/:teamId/roles
+func (api *AccessControlAPI) assignRole(c *contextmodel.ReqContext) response.Response {
+	cmd := ac.RoleAssignmentCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgID
+	cmd.AllowGlobal = c.SignedInUser.IsGrafanaAdmin
+	var err error
+	if cmd.UserID, cmd.TeamID, err = assigneeFromParams(c); err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	if cmd.TeamID != 0 && cmd.Global {
+		return response.Error(http.StatusBadRequest, "roles can't be assigned globally to teams", nil)
+	}
+	if cmd.Global && !cmd.AllowGlobal {
+		return response.Error(http.StatusForbidden, "Only server admins can assign roles globally", nil)
+	}
+
+	if err := api.checkRoleDelegation(c, cmd.RoleUID); err != nil {
+		return roleErrorResponse(err, "Failed to assign role")
+	}
+
+	if err := api.RoleService.AssignRole(c.Req.Context(), cmd); err != nil {
+		return roleErrorResponse(err, "Failed to assign role")
+	}
+	return response.Success("Role assigned")
+}
+
+// DELETE /api/access-control/users/:userId/roles/:roleUID
+// DELETE /api/access-control/teams/:teamId/roles/:roleUID
+func (api *AccessControlAPI) unassignRole(c *contextmodel.ReqContext) response.Response {
+	cmd := ac.RoleAssignmentCommand{
+		OrgID:       c.OrgID,
+		RoleUID:     web.Params(c.Req)[":roleUID"],
+		Global:      c.QueryBool("global"),
+		AllowGlobal: c.SignedInUser.IsGrafanaAdmin,
+	}
+	var err error
+	if cmd.UserID, cmd.TeamID, err = assigneeFromParams(c); err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	if cmd.Global && !cmd.AllowGlobal {
+		return response.Error(http.StatusForbidden, "Only server admins can remove global role assignments", nil)
+	}
+
+	if err := api.checkRoleDelegation(c, cmd.RoleUID); err != nil {
+		return roleErrorResponse(err, "Failed to remove role assignment")
+	}
+
+	if err := api.RoleService.UnassignRole(c.Req.Context(), cmd); err != nil {
+		return roleErrorResponse(err, "Failed to remove role assignment")
+	}
+	return response.Success("Role assignment removed")
+}
+
+// checkRoleDelegation prevents users from assigning roles with permissions they don't have.
+func (api *AccessControlAPI) checkRoleDelegation(c *contextmodel.ReqContext, roleUID string) error {
+	role, err := api.RoleService.GetRole(c.Req.Context(), c.OrgID, roleUID)
+	if err != nil {
+		return err
+	}
+	return api.checkDelegation(c, role.Permissions)
+}
+
+// checkDelegation prevents users from granting permissions they don't have.
+func (api *AccessControlAPI) checkDelegation(c *contextmodel.ReqContext, permissions []ac.Permission) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	evaluators := make([]ac.Evaluator, 0, len(permissions))
+	for _, p := range permissions {
+		if p.Scope == "" {
+			evaluators = append(evaluators, ac.EvalPermission(p.Action))
+		} else {
+			evaluators = append(evaluators, ac.EvalPermission(p.Action, p.Scope))
+		}
+	}
+	hasAccess, err := api.AccessControl.Evaluate(c.Req.Context(), c.SignedInUser, ac.EvalAll(evaluators...))
+	if err != nil {
+		return err
+	}
+	if !hasAccess {
+		return ac.ErrRoleEscalation.Errorf("user %d can't delegate the role permissions", c.UserID)
+	}
+	return nil
+}
+
+func assigneeFromParams(c *contextmodel.ReqContext) (userID int64, teamID int64, err error) {
+	params := web.Params(c.Req)
+	if id, ok := params[":teamId"]; ok {
+		teamID, err = strconv.ParseInt(id, 10, 64)
+		return 0, teamID, err
+	}
+	userID, err = strconv.ParseInt(params[":userId"], 10, 64)
+	return userID, 0, err
+}
+
+func roleErrorResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ac.ErrRoleNotFound):
+		return response.Error(http.StatusNotFound, "Role not found", err)
+	case errors.Is(err, ac.ErrRoleAlreadyExists), errors.Is(err, ac.ErrRoleVersionConflict), errors.Is(err, ac.ErrRoleAssigned):
+		return response.Error(http.StatusConflict, err.Error(), err)
+	}
+	return response.ErrOrFallback(http.StatusInternalServerError, message, err)
+}
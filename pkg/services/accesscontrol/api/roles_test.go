@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+func TestAPI_roles(t *testing.T) {
+	customRole := &ac.RoleDTO{
+		OrgID:       1,
+		UID:         "editor",
+		Name:        "custom:dashboards:editor",
+		Permissions: []ac.Permission{{Action: "dashboards:write", Scope: "dashboards:*"}},
+	}
+
+	type testCase struct {
+		desc         string
+		method       string
+		url          string
+		body         string
+		permissions  []ac.Permission
+		serverAdmin  bool
+		roleService  actest.FakeRoleService
+		expectedCode int
+	}
+
+	tests := []testCase{
+		{
+			desc:         "should list roles with roles:read",
+			method:       http.MethodGet,
+			url:          "/api/access-control/roles",
+			permissions:  []ac.Permission{{Action: ac.ActionRolesRead, Scope: ac.ScopeRolesAll}},
+			roleService:  actest.FakeRoleService{ExpectedRoles: []ac.RoleDTO{*customRole}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "should not list roles without roles:read",
+			method:       http.MethodGet,
+			url:          "/api/access-control/roles",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should return not found for unknown roles",
+			method:       http.MethodGet,
+			url:          "/api/access-control/roles/unknown",
+			permissions:  []ac.Permission{{Action: ac.ActionRolesRead, Scope: ac.ScopeRolesAll}},
+			roleService:  actest.FakeRoleService{ExpectedErr: ac.ErrRoleNotFound},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			desc:   "should create roles with permissions the user has",
+			method: http.MethodPost,
+			url:    "/api/access-control/roles",
+			body:   `{"name": "custom:dashboards:editor", "permissions": [{"action": "dashboards:write", "scope": "dashboards:*"}]}`,
+			permissions: []ac.Permission{
+				{Action: ac.ActionRolesWrite, Scope: ac.ScopeRolesAll},
+				{Action: "dashboards:write", Scope: "dashboards:*"},
+			},
+			roleService:  actest.FakeRoleService{ExpectedRole: customRole},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			desc:         "should not create roles with permissions the user doesn't have",
+			method:       http.MethodPost,
+			url:          "/api/access-control/roles",
+			body:         `{"name": "custom:dashboards:editor", "permissions": [{"action": "dashboards:write", "scope": "dashboards:*"}]}`,
+			permissions:  []ac.Permission{{Action: ac.ActionRolesWrite, Scope: ac.ScopeRolesAll}},
+			roleService:  actest.FakeRoleService{ExpectedRole: customRole},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should not create global roles as organization admin",
+			method:       http.MethodPost,
+			url:          "/api/access-control/roles",
+			body:         `{"name": "custom:dashboards:editor", "global": true}`,
+			permissions:  []ac.Permission{{Action: ac.ActionRolesWrite, Scope: ac.ScopeRolesAll}},
+			roleService:  actest.FakeRoleService{ExpectedRole: customRole},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should create global roles as server admin",
+			method:       http.MethodPost,
+			url:          "/api/access-control/roles",
+			body:         `{"name": "custom:dashboards:editor", "global": true}`,
+			permissions:  []ac.Permission{{Action: ac.ActionRolesWrite, Scope: ac.ScopeRolesAll}},
+			serverAdmin:  true,
+			roleService:  actest.FakeRoleService{ExpectedRole: customRole},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			desc:         "should return conflict when updating an outdated version",
+			method:       http.MethodPut,
+			url:          "/api/access-control/roles/editor",
+			body:         `{"name": "custom:dashboards:editor", "version": 1}`,
+			permissions:  []ac.Permission{{Action: ac.ActionRolesWrite, Scope: ac.ScopeRolesAll}},
+			roleService:  actest.FakeRoleService{ExpectedErr: ac.ErrRoleVersionConflict},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			desc:         "should return conflict when deleting an assigned role",
+			method:       http.MethodDelete,
+			url:          "/api/access-control/roles/editor",
+			permissions:  []ac.Permission{{Action: ac.ActionRolesDelete, Scope: ac.ScopeRolesAll}},
+			roleService:  actest.FakeRoleService{ExpectedErr: ac.ErrRoleAssigned},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			desc:   "should assign roles with permissions the user has",
+			method: http.MethodPost,
+			url:    "/api/access-control/users/2/roles",
+			body:   `{"roleUid": "editor"}`,
+			permissions: []ac.Permission{
+				{Action: ac.ActionUsersRolesAdd, Scope: "users:*"},
+				{Action: "dashboards:write", Scope: "dashboards:*"},
+			},
+			roleService:  actest.FakeRoleService{ExpectedRole: customRole},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "should not assign roles with permissions the user doesn't have",
+			method:       http.MethodPost,
+			url:          "/api/access-control/users/2/roles",
+			body:         `{"roleUid": "editor"}`,
+			permissions:  []ac.Permission{{Action: ac.ActionUsersRolesAdd, Scope: "users:*"}},
+			roleService:  actest.FakeRoleService{ExpectedRole: customRole},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should not assign roles globally as organization admin",
+			method:       http.MethodPost,
+			url:          "/api/access-control/users/2/roles",
+			body:         `{"roleUid": "editor", "global": true}`,
+			permissions:  []ac.Permission{{Action: ac.ActionUsersRolesAdd, Scope: "users:*"}},
+			roleService:  actest.FakeRoleService{ExpectedRole: &ac.RoleDTO{OrgID: ac.GlobalOrgID, UID: "editor"}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should not remove global role assignments as organization admin",
+			method:       http.MethodDelete,
+			url:          "/api/access-control/users/2/roles/editor?global=true",
+			permissions:  []ac.Permission{{Action: ac.ActionUsersRolesRemove, Scope: "users:*"}},
+			roleService:  actest.FakeRoleService{ExpectedRole: &ac.RoleDTO{OrgID: ac.GlobalOrgID, UID: "editor"}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should not assign roles globally to teams",
+			method:       http.MethodPost,
+			url:          "/api/access-control/teams/3/roles",
+			body:         `{"roleUid": "editor", "global": true}`,
+			permissions:  []ac.Permission{{Action: ac.ActionTeamsRolesAdd, Scope: "teams:*"}},
+			roleService:  actest.FakeRoleService{ExpectedRole: customRole},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			api := NewAccessControlAPI(routing.NewRouteRegister(), permissionsAccessControl{}, actest.FakeService{}, tt.roleService, featuremgmt.WithFeatures())
+			api.RegisterAPIEndpoints()
+
+			server := webtest.NewServer(t, api.RouteRegister)
+			req := server.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			webtest.RequestWithSignedInUser(req, &user.SignedInUser{
+				OrgID:          1,
+				IsGrafanaAdmin: tt.serverAdmin,
+				Permissions:    map[int64]map[string][]string{1: ac.GroupScopesByAction(tt.permissions)},
+			})
+			res, err := server.Send(req)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			require.Equal(t, tt.expectedCode, res.StatusCode)
+		})
+	}
+}
+
+// permissionsAccessControl evaluates permissions against the permissions of the signed in user.
+type permissionsAccessControl struct {
+	actest.FakeAccessControl
+}
+
+func (permissionsAccessControl) Evaluate(_ context.Context, user *user.SignedInUser, evaluator ac.Evaluator) (bool, error) {
+	return evaluator.Evaluate(user.Permissions[user.OrgID]), nil
+}
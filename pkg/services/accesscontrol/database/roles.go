@@ -0,0 +1,300 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GetCustomRoles returns the custom roles of an organization and the global ones, with their permissions.
+func (s *AccessControlStore) GetCustomRoles(ctx context.Context, orgID int64) ([]accesscontrol.RoleDTO, error) {
+	var result []accesscontrol.RoleDTO
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		var roles []accesscontrol.Role
+		if err := sess.Where("(org_id = ? OR org_id = ?) AND name LIKE ?", orgID, accesscontrol.GlobalOrgID, accesscontrol.CustomRolePrefix+"%").
+			Asc("name").Find(&roles); err != nil {
+			return err
+		}
+		var err error
+		result, err = withPermissions(ctx, sess, roles)
+		return err
+	})
+	return result, err
+}
+
+// GetCustomRole returns a custom role of an organization, or a global one, with its permissions.
+func (s *AccessControlStore) GetCustomRole(ctx context.Context, orgID int64, uid string) (*accesscontrol.RoleDTO, error) {
+	var result *accesscontrol.RoleDTO
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		role, err := getCustomRole(sess, orgID, uid, true)
+		if err != nil {
+			return err
+		}
+		roles, err := withPermissions(ctx, sess, []accesscontrol.Role{*role})
+		if err != nil {
+			return err
+		}
+		result = &roles[0]
+		return nil
+	})
+	return result, err
+}
+
+func (s *AccessControlStore) CreateRole(ctx context.Context, cmd accesscontrol.CreateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	now := time.Now()
+	role := accesscontrol.Role{
+		OrgID:       cmd.OrgID,
+		Version:     1,
+		UID:         cmd.UID,
+		Name:        cmd.Name,
+		DisplayName: cmd.DisplayName,
+		Description: cmd.Description,
+		Group:       cmd.Group,
+		Hidden:      cmd.Hidden,
+		Created:     now,
+		Updated:     now,
+	}
+	if cmd.Version > 0 {
+		role.Version = cmd.Version
+	}
+	if cmd.Global {
+		role.OrgID = accesscontrol.GlobalOrgID
+	}
+	if role.UID == "" {
+		role.UID = util.GenerateShortUID()
+	}
+
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		exists, err := sess.Table("role").Where("uid = ? OR ((org_id = ? OR org_id = ?) AND name = ?)",
+			role.UID, cmd.OrgID, accesscontrol.GlobalOrgID, role.Name).Exist()
+		if err != nil {
+			return err
+		}
+		if exists {
+			return accesscontrol.ErrRoleAlreadyExists
+		}
+		if _, err := sess.Insert(&role); err != nil {
+			return err
+		}
+		return s.savePermissions(ctx, sess, role.ID, cmd.Permissions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCustomRole(ctx, cmd.OrgID, role.UID)
+}
+
+func (s *AccessControlStore) UpdateRole(ctx context.Context, cmd accesscontrol.UpdateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	var orgID int64
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		stored, err := getCustomRole(sess, cmd.OrgID, cmd.UID, cmd.AllowGlobal)
+		if err != nil {
+			return err
+		}
+		orgID = stored.OrgID
+
+		version := cmd.Version
+		if version == 0 {
+			version = stored.Version + 1
+		}
+		if version <= stored.Version {
+			return accesscontrol.ErrRoleVersionConflict
+		}
+
+		if cmd.Name != stored.Name {
+			exists, err := sess.Table("role").Where("id <> ? AND (org_id = ? OR org_id = ?) AND name = ?",
+				stored.ID, cmd.OrgID, accesscontrol.GlobalOrgID, cmd.Name).Exist()
+			if err != nil {
+				return err
+			}
+			if exists {
+				return accesscontrol.ErrRoleAlreadyExists
+			}
+		}
+
+		role := accesscontrol.Role{
+			Version:     version,
+			Name:        cmd.Name,
+			DisplayName: cmd.DisplayName,
+			Description: cmd.Description,
+			Group:       cmd.Group,
+			Hidden:      cmd.Hidden,
+			Updated:     time.Now(),
+		}
+		// Only update the role if it wasn't updated concurrently
+		affected, err := sess.Where("id = ? AND version = ?", stored.ID, stored.Version).
+			MustCols("display_name", "description", "group_name", "hidden").Update(&role)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return accesscontrol.ErrRoleVersionConflict
+		}
+		return s.savePermissions(ctx, sess, stored.ID, cmd.Permissions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if orgID == accesscontrol.GlobalOrgID {
+		orgID = cmd.OrgID
+	}
+	return s.GetCustomRole(ctx, orgID, cmd.UID)
+}
+
+func (s *AccessControlStore) DeleteRole(ctx context.Context, cmd accesscontrol.DeleteRoleCommand) error {
+	return s.sql.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		role, err := getCustomRole(sess, cmd.OrgID, cmd.UID, cmd.AllowGlobal)
+		if err != nil {
+			return err
+		}
+
+		if !cmd.Force {
+			for _, table := range []string{"user_role", "team_role"} {
+				assigned, err := sess.Table(table).Where("role_id = ?", role.ID).Exist()
+				if err != nil {
+					return err
+				}
+				if assigned {
+					return accesscontrol.ErrRoleAssigned
+				}
+			}
+		}
+
+		for _, q := range []string{
+			"DELETE FROM user_role WHERE role_id = ?",
+			"DELETE FROM team_role WHERE role_id = ?",
+			"DELETE FROM permission WHERE role_id = ?",
+			"DELETE FROM role WHERE id = ?",
+		} {
+			if _, err := sess.Exec(q, role.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetAssignedRoles returns the custom roles assigned to a user, including global assignments, or to a team.
+func (s *AccessControlStore) GetAssignedRoles(ctx context.Context, query accesscontrol.GetAssignedRolesQuery) ([]accesscontrol.RoleDTO, error) {
+	var result []accesscontrol.RoleDTO
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		var roles []accesscontrol.Role
+		q := "SELECT role.* FROM role "
+		var params []interface{}
+		if query.TeamID != 0 {
+			q += "INNER JOIN team_role ON team_role.role_id = role.id WHERE team_role.team_id = ? AND team_role.org_id = ?"
+			params = append(params, query.TeamID, query.OrgID)
+		} else {
+			q += "INNER JOIN user_role ON user_role.role_id = role.id WHERE user_role.user_id = ? AND (user_role.org_id = ? OR user_role.org_id = ?)"
+			params = append(params, query.UserID, query.OrgID, accesscontrol.GlobalOrgID)
+		}
+		q += " AND role.name LIKE ? ORDER BY role.name ASC"
+		params = append(params, accesscontrol.CustomRolePrefix+"%")
+		if err := sess.SQL(q, params...).Find(&roles); err != nil {
+			return err
+		}
+		var err error
+		result, err = withPermissions(ctx, sess, roles)
+		return err
+	})
+	return result, err
+}
+
+func (s *AccessControlStore) AssignRole(ctx context.Context, cmd accesscontrol.RoleAssignmentCommand) error {
+	return s.sql.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		role, err := getCustomRole(sess, cmd.OrgID, cmd.RoleUID, cmd.AllowGlobal)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if cmd.TeamID != 0 {
+			assignment := accesscontrol.TeamRole{OrgID: cmd.OrgID, RoleID: role.ID, TeamID: cmd.TeamID, Created: now}
+			exists, err := sess.Table("team_role").Where("org_id = ? AND role_id = ? AND team_id = ?", assignment.OrgID, role.ID, cmd.TeamID).Exist()
+			if err != nil || exists {
+				return err
+			}
+			_, err = sess.Insert(&assignment)
+			return err
+		}
+
+		assignment := accesscontrol.UserRole{OrgID: cmd.OrgID, RoleID: role.ID, UserID: cmd.UserID, Created: now}
+		if cmd.Global {
+			if !role.Global() {
+				return accesscontrol.NewRoleInvalidError("only global roles can be assigned globally")
+			}
+			assignment.OrgID = accesscontrol.GlobalOrgID
+		}
+		exists, err := sess.Table("user_role").Where("org_id = ? AND role_id = ? AND user_id = ?", assignment.OrgID, role.ID, cmd.UserID).Exist()
+		if err != nil || exists {
+			return err
+		}
+		_, err = sess.Insert(&assignment)
+		return err
+	})
+}
+
+func (s *AccessControlStore) UnassignRole(ctx context.Context, cmd accesscontrol.RoleAssignmentCommand) error {
+	return s.sql.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		role, err := getCustomRole(sess, cmd.OrgID, cmd.RoleUID, cmd.AllowGlobal)
+		if err != nil {
+			return err
+		}
+
+		if cmd.TeamID != 0 {
+			_, err = sess.Exec("DELETE FROM team_role WHERE org_id = ? AND role_id = ? AND team_id = ?", cmd.OrgID, role.ID, cmd.TeamID)
+			return err
+		}
+		orgID := cmd.OrgID
+		if cmd.Global {
+			orgID = accesscontrol.GlobalOrgID
+		}
+		_, err = sess.Exec("DELETE FROM user_role WHERE org_id = ? AND role_id = ? AND user_id = ?", orgID, role.ID, cmd.UserID)
+		return err
+	})
+}
+
+// getCustomRole returns a custom role of an organization, global roles are only returned with includeGlobal
+func getCustomRole(sess *db.Session, orgID int64, uid string, includeGlobal bool) (*accesscontrol.Role, error) {
+	globalOrgID := orgID
+	if includeGlobal {
+		globalOrgID = accesscontrol.GlobalOrgID
+	}
+	var role accesscontrol.Role
+	has, err := sess.Where("uid = ? AND (org_id = ? OR org_id = ?) AND name LIKE ?", uid, orgID, globalOrgID, accesscontrol.CustomRolePrefix+"%").Get(&role)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, accesscontrol.ErrRoleNotFound
+	}
+	return &role, nil
+}
+
+func withPermissions(ctx context.Context, sess *db.Session, roles []accesscontrol.Role) ([]accesscontrol.RoleDTO, error) {
+	result := make([]accesscontrol.RoleDTO, 0, len(roles))
+	for _, role := range roles {
+		permissions, err := getRolePermissions(ctx, sess, role.ID)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, accesscontrol.RoleDTO{
+			ID:          role.ID,
+			OrgID:       role.OrgID,
+			Version:     role.Version,
+			UID:         role.UID,
+			Name:        role.Name,
+			DisplayName: role.DisplayName,
+			Description: role.Description,
+			Group:       role.Group,
+			Hidden:      role.Hidden,
+			Permissions: permissions,
+			Created:     role.Created,
+			Updated:     role.Updated,
+		})
+	}
+	return result, nil
+}
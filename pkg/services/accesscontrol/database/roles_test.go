@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+func TestIntegrationAccessControlStore_CustomRoles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	s := &AccessControlStore{sql: db.InitTestDB(t)}
+
+	created, err := s.CreateRole(ctx, accesscontrol.CreateRoleCommand{
+		OrgID:       1,
+		UID:         "editor",
+		Name:        "custom:dashboards:editor",
+		Permissions: []accesscontrol.Permission{{Action: "dashboards:write", Scope: "dashboards:*"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), created.Version)
+	require.Equal(t, int64(1), created.OrgID)
+	require.Len(t, created.Permissions, 1)
+
+	_, err = s.CreateRole(ctx, accesscontrol.CreateRoleCommand{OrgID: 1, Name: "custom:dashboards:editor"})
+	require.ErrorIs(t, err, accesscontrol.ErrRoleAlreadyExists)
+
+	global, err := s.CreateRole(ctx, accesscontrol.CreateRoleCommand{OrgID: 1, Name: "custom:users:reader", Global: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, global.UID)
+	require.Equal(t, int64(accesscontrol.GlobalOrgID), global.OrgID)
+
+	t.Run("should list the organization and global roles", func(t *testing.T) {
+		roles, err := s.GetCustomRoles(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, roles, 2)
+
+		roles, err = s.GetCustomRoles(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		require.Equal(t, global.UID, roles[0].UID)
+
+		_, err = s.GetCustomRole(ctx, 2, "editor")
+		require.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+	})
+
+	t.Run("should update the role when the version is newer", func(t *testing.T) {
+		updated, err := s.UpdateRole(ctx, accesscontrol.UpdateRoleCommand{
+			OrgID:       1,
+			UID:         "editor",
+			Name:        "custom:dashboards:editor",
+			DisplayName: "Dashboard editor",
+			Permissions: []accesscontrol.Permission{{Action: "dashboards:read", Scope: "dashboards:*"}, {Action: "dashboards:write", Scope: "dashboards:*"}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), updated.Version)
+		require.Equal(t, "Dashboard editor", updated.DisplayName)
+		require.Len(t, updated.Permissions, 2)
+
+		_, err = s.UpdateRole(ctx, accesscontrol.UpdateRoleCommand{OrgID: 1, UID: "editor", Version: 2, Name: "custom:dashboards:editor"})
+		require.ErrorIs(t, err, accesscontrol.ErrRoleVersionConflict)
+	})
+
+	t.Run("should assign and unassign roles", func(t *testing.T) {
+		require.NoError(t, s.AssignRole(ctx, accesscontrol.RoleAssignmentCommand{OrgID: 1, UserID: 2, RoleUID: "editor"}))
+		// Assigning twice is a no-op
+		require.NoError(t, s.AssignRole(ctx, accesscontrol.RoleAssignmentCommand{OrgID: 1, UserID: 2, RoleUID: "editor"}))
+		// Global roles are only found for commands allowed to change them
+		err := s.AssignRole(ctx, accesscontrol.RoleAssignmentCommand{OrgID: 1, UserID: 2, RoleUID: global.UID, Global: true})
+		require.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+		require.NoError(t, s.AssignRole(ctx, accesscontrol.RoleAssignmentCommand{OrgID: 1, UserID: 2, RoleUID: global.UID, Global: true, AllowGlobal: true}))
+		require.NoError(t, s.AssignRole(ctx, accesscontrol.RoleAssignmentCommand{OrgID: 1, TeamID: 3, RoleUID: "editor"}))
+
+		err = s.AssignRole(ctx, accesscontrol.RoleAssignmentCommand{OrgID: 1, UserID: 2, RoleUID: "editor", Global: true, AllowGlobal: true})
+		require.ErrorIs(t, err, accesscontrol.ErrRoleInvalid)
+
+		roles, err := s.GetAssignedRoles(ctx, accesscontrol.GetAssignedRolesQuery{OrgID: 1, UserID: 2})
+		require.NoError(t, err)
+		require.Len(t, roles, 2)
+
+		// Global assignments apply to every organization
+		roles, err = s.GetAssignedRoles(ctx, accesscontrol.GetAssignedRolesQuery{OrgID: 2, UserID: 2})
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+
+		roles, err = s.GetAssignedRoles(ctx, accesscontrol.GetAssignedRolesQuery{OrgID: 1, TeamID: 3})
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+
+		require.NoError(t, s.UnassignRole(ctx, accesscontrol.RoleAssignmentCommand{OrgID: 1, UserID: 2, RoleUID: global.UID, Global: true, AllowGlobal: true}))
+		roles, err = s.GetAssignedRoles(ctx, accesscontrol.GetAssignedRolesQuery{OrgID: 1, UserID: 2})
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+	})
+
+	t.Run("should only change global roles when allowed", func(t *testing.T) {
+		_, err := s.UpdateRole(ctx, accesscontrol.UpdateRoleCommand{OrgID: 1, UID: global.UID, Name: global.Name, DisplayName: "Users reader"})
+		require.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+		err = s.DeleteRole(ctx, accesscontrol.DeleteRoleCommand{OrgID: 1, UID: global.UID})
+		require.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+
+		updated, err := s.UpdateRole(ctx, accesscontrol.UpdateRoleCommand{OrgID: 1, UID: global.UID, Name: global.Name, DisplayName: "Users reader", AllowGlobal: true})
+		require.NoError(t, err)
+		require.Equal(t, "Users reader", updated.DisplayName)
+	})
+
+	t.Run("should only delete assigned roles when forced", func(t *testing.T) {
+		err := s.DeleteRole(ctx, accesscontrol.DeleteRoleCommand{OrgID: 1, UID: "editor"})
+		require.ErrorIs(t, err, accesscontrol.ErrRoleAssigned)
+
+		require.NoError(t, s.DeleteRole(ctx, accesscontrol.DeleteRoleCommand{OrgID: 1, UID: "editor", Force: true}))
+		_, err = s.GetCustomRole(ctx, 1, "editor")
+		require.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+
+		roles, err := s.GetAssignedRoles(ctx, accesscontrol.GetAssignedRolesQuery{OrgID: 1, TeamID: 3})
+		require.NoError(t, err)
+		require.Len(t, roles, 0)
+	})
+}
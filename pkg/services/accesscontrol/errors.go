@@ -3,6 +3,8 @@ package accesscontrol
 import (
 	"errors"
 	"fmt"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
 var (
@@ -12,8 +14,27 @@ var (
 	ErrResolverNotFound       = errors.New("no resolver found")
 	ErrPluginIDRequired       = errors.New("plugin ID is required")
 	ErrRoleNotFound           = errors.New("role not found")
+	ErrRoleAlreadyExists      = errors.New("a role with the same name or uid already exists")
+	ErrRoleVersionConflict    = errors.New("role version must be greater than the stored version")
+	ErrRoleAssigned           = errors.New("role is assigned, use force to delete it along with its assignments")
+)
+
+var (
+	ErrRoleInvalid = errutil.NewBase(errutil.StatusBadRequest, "accesscontrol.roleInvalid").MustTemplate(
+		"invalid role: {{ .Public.Reason }}",
+		errutil.WithPublic("Invalid role: {{ .Public.Reason }}"),
+	)
+	ErrRoleImmutable  = errutil.NewBase(errutil.StatusForbidden, "accesscontrol.roleImmutable", errutil.WithPublicMessage("Only custom roles can be modified"))
+	ErrRoleEscalation = errutil.NewBase(errutil.StatusForbidden, "accesscontrol.roleEscalation", errutil.WithPublicMessage("Cannot grant permissions you do not have"))
 )
 
+// NewRoleInvalidError returns an ErrRoleInvalid error with the given reason.
+func NewRoleInvalidError(format string, args ...interface{}) error {
+	return ErrRoleInvalid.Build(errutil.TemplateData{
+		Public: map[string]interface{}{"Reason": fmt.Sprintf(format, args...)},
+	})
+}
+
 type ErrorInvalidRole struct{}
 
 func (e *ErrorInvalidRole) Error() string {
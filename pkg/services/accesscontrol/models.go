@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/slugify"
 	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
@@ -131,6 +132,10 @@ func (r *RoleDTO) IsBasic() bool {
 	return strings.HasPrefix(r.Name, BasicRolePrefix) || strings.HasPrefix(r.UID, BasicRoleUIDPrefix)
 }
 
+func (r *RoleDTO) IsCustom() bool {
+	return strings.HasPrefix(r.Name, CustomRolePrefix)
+}
+
 func (r *RoleDTO) IsExternalService() bool {
 	return strings.HasPrefix(r.Name, ExternalServiceRolePrefix) || strings.HasPrefix(r.UID, ExternalServiceRoleUIDPrefix)
 }
@@ -296,6 +301,128 @@ func (cmd *SaveExternalServiceRoleCommand) Validate() error {
 	return nil
 }
 
+// CreateRoleCommand creates a custom role in an organization, or in all organizations
+// when Global is set. Version defaults to 1.
+type CreateRoleCommand struct {
+	OrgID       int64        `json:"-"`
+	Version     int64        `json:"version"`
+	UID         string       `json:"uid"`
+	Name        string       `json:"name"`
+	DisplayName string       `json:"displayName"`
+	Description string       `json:"description"`
+	Group       string       `json:"group"`
+	Hidden      bool         `json:"hidden"`
+	Global      bool         `json:"global"`
+	Permissions []Permission `json:"permissions"`
+}
+
+func (cmd *CreateRoleCommand) Validate() error {
+	if err := validateCustomRoleName(cmd.Name); err != nil {
+		return err
+	}
+	if cmd.UID != "" && !util.IsValidShortUID(cmd.UID) {
+		return NewRoleInvalidError("uid %q contains illegal characters", cmd.UID)
+	}
+	if util.IsShortUIDTooLong(cmd.UID) {
+		return NewRoleInvalidError("uid %q is too long", cmd.UID)
+	}
+	if cmd.Version < 0 {
+		return NewRoleInvalidError("version can't be negative")
+	}
+	cmd.Permissions = dedupPermissions(cmd.Permissions)
+	return nil
+}
+
+// UpdateRoleCommand updates a custom role. Version must be greater than the version of the
+// stored role, when it is left empty the stored version is incremented.
+type UpdateRoleCommand struct {
+	OrgID       int64        `json:"-"`
+	UID         string       `json:"-"`
+	Version     int64        `json:"version"`
+	Name        string       `json:"name"`
+	DisplayName string       `json:"displayName"`
+	Description string       `json:"description"`
+	Group       string       `json:"group"`
+	Hidden      bool         `json:"hidden"`
+	Permissions []Permission `json:"permissions"`
+	// AllowGlobal allows updating global roles, only Grafana server admins can change them
+	AllowGlobal bool `json:"-"`
+}
+
+func (cmd *UpdateRoleCommand) Validate() error {
+	if err := validateCustomRoleName(cmd.Name); err != nil {
+		return err
+	}
+	cmd.Permissions = dedupPermissions(cmd.Permissions)
+	return nil
+}
+
+// DeleteRoleCommand deletes a custom role. Roles that are assigned are only deleted, along
+// with their assignments, when Force is set.
+type DeleteRoleCommand struct {
+	OrgID int64
+	UID   string
+	Force bool
+	// AllowGlobal allows deleting global roles, only Grafana server admins can change them
+	AllowGlobal bool
+}
+
+// RoleAssignmentCommand assigns a custom role to, or removes it from, a user or a team.
+// Service accounts are assigned roles as users. Global user assignments apply to all
+// organizations and require a global role.
+type RoleAssignmentCommand struct {
+	OrgID   int64  `json:"-"`
+	UserID  int64  `json:"-"`
+	TeamID  int64  `json:"-"`
+	RoleUID string `json:"roleUid"`
+	Global  bool   `json:"global"`
+	// AllowGlobal allows assigning global roles, only Grafana server admins can assign them
+	AllowGlobal bool `json:"-"`
+}
+
+// GetRolesQuery lists the custom roles of an organization, including global ones.
+type GetRolesQuery struct {
+	OrgID int64
+	// IncludeFixed adds the fixed roles declared by Grafana to the result
+	IncludeFixed bool
+}
+
+// GetAssignedRolesQuery lists the custom roles assigned to a user or a team.
+type GetAssignedRolesQuery struct {
+	OrgID  int64
+	UserID int64
+	TeamID int64
+}
+
+func validateCustomRoleName(name string) error {
+	if name == "" {
+		return NewRoleInvalidError("name is required")
+	}
+	for _, prefix := range []string{FixedRolePrefix, ManagedRolePrefix, BasicRolePrefix, PluginRolePrefix, ExternalServiceRolePrefix} {
+		if strings.HasPrefix(name, prefix) {
+			return ErrRoleImmutable.Errorf("role name %q uses the reserved prefix %q", name, prefix)
+		}
+	}
+	if !strings.HasPrefix(name, CustomRolePrefix) || len(name) == len(CustomRolePrefix) {
+		return NewRoleInvalidError("name %q must be prefixed with %q", name, CustomRolePrefix)
+	}
+	return nil
+}
+
+func dedupPermissions(permissions []Permission) []Permission {
+	seen := make(map[Permission]bool, len(permissions))
+	dedup := make([]Permission, 0, len(permissions))
+	for _, p := range permissions {
+		p = p.OSSPermission()
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		dedup = append(dedup, p)
+	}
+	return dedup
+}
+
 const (
 	GlobalOrgID                  = 0
 	FixedRolePrefix              = "fixed:"
@@ -303,6 +430,7 @@ const (
 	BasicRolePrefix              = "basic:"
 	PluginRolePrefix             = "plugins:"
 	ExternalServiceRolePrefix    = "externalservice:"
+	CustomRolePrefix             = "custom:"
 	BasicRoleUIDPrefix           = "basic_"
 	ExternalServiceRoleUIDPrefix = "externalservice_"
 	RoleGrafanaAdmin             = "Grafana Admin"
@@ -396,6 +524,22 @@ const (
 	// Team related scopes
 	ScopeTeamsAll = "teams:*"
 
+	// Role related actions
+	ActionRolesRead   = "roles:read"
+	ActionRolesWrite  = "roles:write"
+	ActionRolesDelete = "roles:delete"
+
+	// Role assignment related actions
+	ActionUsersRolesRead   = "users.roles:read"
+	ActionUsersRolesAdd    = "users.roles:add"
+	ActionUsersRolesRemove = "users.roles:remove"
+	ActionTeamsRolesRead   = "teams.roles:read"
+	ActionTeamsRolesAdd    = "teams.roles:add"
+	ActionTeamsRolesRemove = "teams.roles:remove"
+
+	// Role related scopes
+	ScopeRolesAll = "roles:*"
+
 	// Annotations related actions
 	ActionAnnotationsCreate = "annotations:create"
 	ActionAnnotationsDelete = "annotations:delete"
@@ -442,6 +586,10 @@ var (
 	// Team scope
 	ScopeTeamsID = Scope("teams", "id", Parameter(":teamId"))
 
+	// Role scopes
+	ScopeRolesProvider = NewScopeProvider("roles")
+	ScopeRolesUID      = Scope("roles", "uid", Parameter(":roleUID"))
+
 	// Annotation scopes
 	ScopeAnnotationsRoot             = "annotations"
 	ScopeAnnotationsProvider         = NewScopeProvider(ScopeAnnotationsRoot)
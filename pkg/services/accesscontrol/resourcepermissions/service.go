@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auditlog"
 	"github.com/grafana/grafana/pkg/services/licensing"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/team"
@@ -150,13 +152,19 @@ func (s *Service) SetUserPermission(ctx context.Context, orgID int64, user acces
 		return nil, err
 	}
 
-	return s.store.SetUserResourcePermission(ctx, orgID, user, SetResourcePermissionCommand{
+	before := s.auditedPermissions(ctx, orgID, resourceID)
+	result, err := s.store.SetUserResourcePermission(ctx, orgID, user, SetResourcePermissionCommand{
 		Actions:           actions,
 		Permission:        permission,
 		Resource:          s.options.Resource,
 		ResourceID:        resourceID,
 		ResourceAttribute: s.options.ResourceAttribute,
 	}, s.options.OnSetUser)
+	if err != nil {
+		return nil, err
+	}
+	s.recordPermissionsChange(ctx, orgID, resourceID, before)
+	return result, nil
 }
 
 func (s *Service) SetTeamPermission(ctx context.Context, orgID, teamID int64, resourceID, permission string) (*accesscontrol.ResourcePermission, error) {
@@ -173,13 +181,19 @@ func (s *Service) SetTeamPermission(ctx context.Context, orgID, teamID int64, re
 		return nil, err
 	}
 
-	return s.store.SetTeamResourcePermission(ctx, orgID, teamID, SetResourcePermissionCommand{
+	before := s.auditedPermissions(ctx, orgID, resourceID)
+	result, err := s.store.SetTeamResourcePermission(ctx, orgID, teamID, SetResourcePermissionCommand{
 		Actions:           actions,
 		Permission:        permission,
 		Resource:          s.options.Resource,
 		ResourceID:        resourceID,
 		ResourceAttribute: s.options.ResourceAttribute,
 	}, s.options.OnSetTeam)
+	if err != nil {
+		return nil, err
+	}
+	s.recordPermissionsChange(ctx, orgID, resourceID, before)
+	return result, nil
 }
 
 func (s *Service) SetBuiltInRolePermission(ctx context.Context, orgID int64, builtInRole, resourceID, permission string) (*accesscontrol.ResourcePermission, error) {
@@ -196,13 +210,19 @@ func (s *Service) SetBuiltInRolePermission(ctx context.Context, orgID int64, bui
 		return nil, err
 	}
 
-	return s.store.SetBuiltInResourcePermission(ctx, orgID, builtInRole, SetResourcePermissionCommand{
+	before := s.auditedPermissions(ctx, orgID, resourceID)
+	result, err := s.store.SetBuiltInResourcePermission(ctx, orgID, builtInRole, SetResourcePermissionCommand{
 		Actions:           actions,
 		Permission:        permission,
 		Resource:          s.options.Resource,
 		ResourceID:        resourceID,
 		ResourceAttribute: s.options.ResourceAttribute,
 	}, s.options.OnSetBuiltInRole)
+	if err != nil {
+		return nil, err
+	}
+	s.recordPermissionsChange(ctx, orgID, resourceID, before)
+	return result, nil
 }
 
 func (s *Service) SetPermissions(
@@ -248,11 +268,68 @@ func (s *Service) SetPermissions(
 		})
 	}
 
-	return s.store.SetResourcePermissions(ctx, orgID, dbCommands, ResourceHooks{
+	before := s.auditedPermissions(ctx, orgID, resourceID)
+	result, err := s.store.SetResourcePermissions(ctx, orgID, dbCommands, ResourceHooks{
 		User:        s.options.OnSetUser,
 		Team:        s.options.OnSetTeam,
 		BuiltInRole: s.options.OnSetBuiltInRole,
 	})
+	if err != nil {
+		return nil, err
+	}
+	s.recordPermissionsChange(ctx, orgID, resourceID, before)
+	return result, nil
+}
+
+// auditedPermissions returns the managed permissions of a resource by assignee, to be recorded in the
+// audit log. Nothing is loaded when changes aren't recorded.
+func (s *Service) auditedPermissions(ctx context.Context, orgID int64, resourceID string) map[string]string {
+	if !auditlog.IsRecording(ctx) {
+		return nil
+	}
+
+	permissions, err := s.store.GetResourcePermissions(ctx, orgID, GetResourcePermissionsQuery{
+		// Team permissions are filtered by the teams the user can read
+		User: &user.SignedInUser{
+			OrgID:       orgID,
+			Permissions: map[int64]map[string][]string{orgID: {accesscontrol.ActionTeamsRead: {accesscontrol.ScopeTeamsAll}}},
+		},
+		Actions:           s.actions,
+		Resource:          s.options.Resource,
+		ResourceID:        resourceID,
+		ResourceAttribute: s.options.ResourceAttribute,
+		OnlyManaged:       true,
+	})
+	if err != nil {
+		return nil
+	}
+
+	result := make(map[string]string, len(permissions))
+	for _, p := range permissions {
+		switch {
+		case p.UserId != 0:
+			result["user:"+strconv.FormatInt(p.UserId, 10)] = s.MapActions(p)
+		case p.TeamId != 0:
+			result["team:"+strconv.FormatInt(p.TeamId, 10)] = s.MapActions(p)
+		case p.BuiltInRole != "":
+			result["role:"+p.BuiltInRole] = s.MapActions(p)
+		}
+	}
+	return result
+}
+
+func (s *Service) recordPermissionsChange(ctx context.Context, orgID int64, resourceID string, before map[string]string) {
+	if !auditlog.IsRecording(ctx) {
+		return
+	}
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        orgID,
+		Action:       auditlog.ActionSetPermissions,
+		ResourceKind: s.options.Resource,
+		ResourceUID:  resourceID,
+		Before:       before,
+		After:        s.auditedPermissions(ctx, orgID, resourceID),
+	})
 }
 
 func (s *Service) MapActions(permission accesscontrol.ResourcePermission) string {
@@ -0,0 +1,16 @@
+package auditlog
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type Service interface {
+	// Middleware records the changes made by HTTP requests
+	Middleware() web.Middleware
+	SearchEvents(ctx context.Context, query *SearchEventsQuery) (*SearchEventsResult, error)
+	GetEvent(ctx context.Context, id int64) (*Event, error)
+	// DeleteExpiredEvents deletes the events older than the configured retention
+	DeleteExpiredEvents(ctx context.Context) (int64, error)
+}
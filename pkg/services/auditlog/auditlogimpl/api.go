@@ -0,0 +1,63 @@
+package auditlogimpl
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+
+	routeRegister.Group("/api/admin/audit-events", func(auditRoute routing.RouteRegister) {
+		auditRoute.Get("/", authorize(ac.EvalPermission(ActionRead)), routing.Wrap(s.searchHandler))
+		auditRoute.Get("/:id", authorize(ac.EvalPermission(ActionRead)), routing.Wrap(s.getHandler))
+	}, middleware.ReqSignedIn)
+}
+
+func (s *Service) searchHandler(c *contextmodel.ReqContext) response.Response {
+	query := &auditlog.SearchEventsQuery{
+		OrgID:        c.QueryInt64("orgId"),
+		ActorID:      c.QueryInt64("actorId"),
+		ActorLogin:   c.Query("actorLogin"),
+		Action:       c.Query("action"),
+		ResourceKind: c.Query("resourceKind"),
+		ResourceUID:  c.Query("resourceUid"),
+		RequestID:    c.Query("requestId"),
+		Page:         c.QueryInt("page"),
+		Limit:        c.QueryInt("limit"),
+	}
+	if from := c.QueryInt64("from"); from > 0 {
+		query.From = time.UnixMilli(from)
+	}
+	if to := c.QueryInt64("to"); to > 0 {
+		query.To = time.UnixMilli(to)
+	}
+
+	result, err := s.SearchEvents(c.Req.Context(), query)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to search audit events", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (s *Service) getHandler(c *contextmodel.ReqContext) response.Response {
+	id, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+
+	event, err := s.GetEvent(c.Req.Context(), id)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get audit event", err)
+	}
+	return response.JSON(http.StatusOK, event)
+}
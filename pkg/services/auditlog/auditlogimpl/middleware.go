@@ -0,0 +1,193 @@
+package auditlogimpl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const requestIDHeader = "X-Request-Id"
+
+var apiVersionRegex = regexp.MustCompile(`^v[0-9]+$`)
+
+// resourceUIDParams are the route parameters identifying the resource of a request, by priority
+var resourceUIDParams = []string{":uid", ":id", ":dashboardUid", ":folderUid", ":roleUID", ":userId", ":teamId", ":orgId"}
+
+// Middleware records the requests changing Grafana. It must run after the context handler so
+// that the actor is known. Services record the detail of their changes with auditlog.RecordChange,
+// requests without recorded changes are stored as a single event.
+func (s *Service) Middleware() web.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := contexthandler.FromContext(r.Context())
+			if !s.enabled || c == nil || !s.shouldAudit(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			requestID := r.Header.Get(requestIDHeader)
+			if requestID == "" {
+				requestID = util.GenerateShortUID()
+			}
+			c.Resp.Header().Set(requestIDHeader, requestID)
+
+			recorder := auditlog.NewRecorder()
+			*r = *r.WithContext(auditlog.WithRecorder(r.Context(), recorder))
+			*c.Req = *c.Req.WithContext(auditlog.WithRecorder(c.Req.Context(), recorder))
+
+			next.ServeHTTP(w, r)
+
+			// The request is done, events are saved even if the client went away
+			s.saveEvents(context.WithoutCancel(c.Req.Context()), s.requestEvents(c, recorder, requestID))
+		})
+	}
+}
+
+func (s *Service) shouldAudit(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return false
+	}
+	if !strings.HasPrefix(r.URL.Path, "/api/") {
+		return false
+	}
+	for _, p := range s.excludedPaths {
+		if strings.HasPrefix(r.URL.Path, p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Service) requestEvents(c *contextmodel.ReqContext, recorder *auditlog.Recorder, requestID string) []*auditlog.Event {
+	base := auditlog.Event{
+		OrgID:      c.OrgID,
+		Created:    s.now(),
+		IPAddress:  c.RemoteAddr(),
+		RequestID:  requestID,
+		Method:     c.Req.Method,
+		Path:       c.Req.URL.Path,
+		StatusCode: c.Resp.Status(),
+	}
+	base.Route, _ = middleware.RouteOperationName(c.Req)
+	setActor(&base, c)
+
+	changes := recorder.Changes()
+	if len(changes) == 0 {
+		e := base
+		e.Action = methodAction(c.Req.Method)
+		e.ResourceKind = resourceKind(c.Req.URL.Path)
+		e.ResourceUID = resourceUID(web.Params(c.Req))
+		return []*auditlog.Event{&e}
+	}
+
+	events := make([]*auditlog.Event, 0, len(changes))
+	for _, change := range changes {
+		e := base
+		if change.OrgID != 0 {
+			e.OrgID = change.OrgID
+		}
+		e.Action = change.Action
+		e.ResourceKind = change.ResourceKind
+		e.ResourceUID = change.ResourceUID
+		e.Before = s.marshalState(change.Before)
+		e.After = s.marshalState(change.After)
+		diff, err := auditlog.ComputeDiff([]byte(e.Before), []byte(e.After))
+		if err != nil {
+			s.log.Warn("Failed to compute audit event diff", "resourceKind", e.ResourceKind, "error", err)
+		}
+		e.Diff = diff
+		events = append(events, &e)
+	}
+	return events
+}
+
+func (s *Service) marshalState(state any) string {
+	if state == nil {
+		return ""
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		s.log.Warn("Failed to marshal audited resource", "error", err)
+		return ""
+	}
+	if string(data) == "null" {
+		return ""
+	}
+	return string(data)
+}
+
+func setActor(e *auditlog.Event, c *contextmodel.ReqContext) {
+	u := c.SignedInUser
+	if u == nil {
+		e.ActorKind = auditlog.ActorKindAnonymous
+		return
+	}
+	e.ActorID = u.UserID
+	e.ActorLogin = u.Login
+	switch {
+	case c.IsRenderCall:
+		e.ActorKind = auditlog.ActorKindRenderer
+	case u.IsServiceAccount:
+		e.ActorKind = auditlog.ActorKindServiceAccount
+	case u.ApiKeyID != 0:
+		e.ActorKind = auditlog.ActorKindAPIKey
+		e.ActorID = u.ApiKeyID
+		e.ActorLogin = u.Name
+	case u.IsAnonymous || !c.IsSignedIn:
+		e.ActorKind = auditlog.ActorKindAnonymous
+	default:
+		e.ActorKind = auditlog.ActorKindUser
+	}
+}
+
+func methodAction(method string) string {
+	switch method {
+	case http.MethodPost:
+		return auditlog.ActionCreate
+	case http.MethodDelete:
+		return auditlog.ActionDelete
+	default:
+		return auditlog.ActionUpdate
+	}
+}
+
+// resourceKind returns the first segment of an API path, skipping API versions,
+// for example "datasources" for /api/datasources/uid/abc.
+func resourceKind(path string) string {
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/api/"), "/") {
+		if segment == "" || apiVersionRegex.MatchString(segment) {
+			continue
+		}
+		return segment
+	}
+	return ""
+}
+
+func resourceUID(params map[string]string) string {
+	for _, p := range resourceUIDParams {
+		if v := params[p]; v != "" {
+			return v
+		}
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return params[keys[0]]
+}
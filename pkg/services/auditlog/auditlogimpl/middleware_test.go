@@ -0,0 +1,199 @@
+package auditlogimpl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestMiddleware(t *testing.T) {
+	signedInUser := &user.SignedInUser{UserID: 2, OrgID: 1, Login: "admin"}
+
+	t.Run("records a single event for requests without recorded changes", func(t *testing.T) {
+		s, store := setupTestService(t)
+		server := setupTestServer(s, signedInUser)
+		server.Post("/api/folders/:uid/permissions", func(c *contextmodel.ReqContext) {
+			c.Resp.WriteHeader(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/folders/abc/permissions", nil)
+		req.Header.Set(requestIDHeader, "request-1")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+
+		require.Len(t, store.events, 1)
+		e := store.events[0]
+		assert.Equal(t, int64(1), e.OrgID)
+		assert.Equal(t, int64(2), e.ActorID)
+		assert.Equal(t, "admin", e.ActorLogin)
+		assert.Equal(t, auditlog.ActorKindUser, e.ActorKind)
+		assert.Equal(t, auditlog.ActionCreate, e.Action)
+		assert.Equal(t, "folders", e.ResourceKind)
+		assert.Equal(t, "abc", e.ResourceUID)
+		assert.Equal(t, "request-1", e.RequestID)
+		assert.Equal(t, http.StatusOK, e.StatusCode)
+		assert.Equal(t, "request-1", recorder.Header().Get(requestIDHeader))
+	})
+
+	t.Run("records the changes made by services", func(t *testing.T) {
+		s, store := setupTestService(t)
+		server := setupTestServer(s, signedInUser)
+		server.Put("/api/v1/things/:uid", func(c *contextmodel.ReqContext) {
+			auditlog.RecordChange(c.Req.Context(), auditlog.Change{
+				Action:       auditlog.ActionUpdate,
+				ResourceKind: "things",
+				ResourceUID:  "a",
+				Before:       map[string]any{"name": "old"},
+				After:        map[string]any{"name": "new"},
+			})
+			auditlog.RecordChange(c.Req.Context(), auditlog.Change{
+				OrgID:        3,
+				Action:       auditlog.ActionDelete,
+				ResourceKind: "things",
+				ResourceUID:  "b",
+				Before:       map[string]any{"name": "b"},
+			})
+			c.Resp.WriteHeader(http.StatusOK)
+		})
+
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/api/v1/things/a", nil))
+
+		require.Len(t, store.events, 2)
+		assert.Equal(t, int64(1), store.events[0].OrgID)
+		assert.Equal(t, "a", store.events[0].ResourceUID)
+		assert.Equal(t, `{"name":"old"}`, store.events[0].Before)
+		assert.Equal(t, `{"name":"new"}`, store.events[0].After)
+		assert.Equal(t, []auditlog.Diff{{Path: "name", Old: "old", New: "new"}}, store.events[0].Diff)
+		assert.NotEmpty(t, store.events[0].RequestID)
+		assert.Equal(t, store.events[0].RequestID, store.events[1].RequestID)
+
+		assert.Equal(t, int64(3), store.events[1].OrgID)
+		assert.Equal(t, auditlog.ActionDelete, store.events[1].Action)
+		assert.Empty(t, store.events[1].After)
+	})
+
+	t.Run("ignores reads and excluded paths", func(t *testing.T) {
+		s, store := setupTestService(t)
+		server := setupTestServer(s, signedInUser)
+		handler := func(c *contextmodel.ReqContext) {
+			assert.False(t, auditlog.IsRecording(c.Req.Context()))
+			c.Resp.WriteHeader(http.StatusOK)
+		}
+		server.Get("/api/datasources", handler)
+		server.Post("/api/ds/query", handler)
+		server.Post("/login", handler)
+
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/datasources", nil))
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/ds/query", nil))
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", nil))
+		require.Empty(t, store.events)
+	})
+
+	t.Run("records API keys as actors", func(t *testing.T) {
+		s, store := setupTestService(t)
+		server := setupTestServer(s, &user.SignedInUser{OrgID: 1, ApiKeyID: 7, Name: "deploy"})
+		server.Delete("/api/dashboards/uid/:uid", func(c *contextmodel.ReqContext) {
+			c.Resp.WriteHeader(http.StatusOK)
+		})
+
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/dashboards/uid/abc", nil))
+
+		require.Len(t, store.events, 1)
+		assert.Equal(t, auditlog.ActorKindAPIKey, store.events[0].ActorKind)
+		assert.Equal(t, int64(7), store.events[0].ActorID)
+		assert.Equal(t, "deploy", store.events[0].ActorLogin)
+		assert.Equal(t, auditlog.ActionDelete, store.events[0].Action)
+		assert.Equal(t, "dashboards", store.events[0].ResourceKind)
+	})
+}
+
+func TestRecordChange(t *testing.T) {
+	ctx := context.Background()
+	require.False(t, auditlog.IsRecording(ctx))
+	// Changes are ignored outside of audited requests
+	auditlog.RecordChange(ctx, auditlog.Change{Action: auditlog.ActionCreate})
+
+	recorder := auditlog.NewRecorder()
+	ctx = auditlog.WithRecorder(ctx, recorder)
+	require.True(t, auditlog.IsRecording(ctx))
+	auditlog.RecordChange(ctx, auditlog.Change{Action: auditlog.ActionCreate})
+	require.Len(t, recorder.Changes(), 1)
+}
+
+func setupTestService(t *testing.T) (*Service, *fakeStore) {
+	t.Helper()
+	store := &fakeStore{}
+	return &Service{
+		store:         store,
+		queue:         make(chan *auditlog.Event, sinkQueueSize),
+		log:           log.NewNopLogger(),
+		now:           time.Now,
+		enabled:       true,
+		maxAge:        24 * time.Hour,
+		excludedPaths: defaultExcludedPaths,
+	}, store
+}
+
+func setupTestServer(s *Service, signedInUser *user.SignedInUser) *web.Mux {
+	server := web.New()
+	server.UseMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqCtx := &contextmodel.ReqContext{
+				Context:      web.FromContext(r.Context()),
+				SignedInUser: signedInUser,
+				IsSignedIn:   true,
+				Logger:       log.NewNopLogger(),
+			}
+			*r = *r.WithContext(ctxkey.Set(r.Context(), reqCtx))
+			next.ServeHTTP(w, r)
+		})
+	})
+	server.UseMiddleware(s.Middleware())
+	return server
+}
+
+type fakeStore struct {
+	events []*auditlog.Event
+}
+
+func (f *fakeStore) Insert(_ context.Context, events []*auditlog.Event) error {
+	f.events = append(f.events, events...)
+	return nil
+}
+
+func (f *fakeStore) Get(_ context.Context, id int64) (*auditlog.Event, error) {
+	for _, e := range f.events {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return nil, auditlog.ErrEventNotFound.Errorf("audit event %d not found", id)
+}
+
+func (f *fakeStore) Search(_ context.Context, query *auditlog.SearchEventsQuery) (*auditlog.SearchEventsResult, error) {
+	return &auditlog.SearchEventsResult{TotalCount: int64(len(f.events)), Events: f.events, Page: query.Page, PerPage: query.Limit}, nil
+}
+
+func (f *fakeStore) DeleteOlderThan(_ context.Context, before time.Time) (int64, error) {
+	kept := f.events[:0]
+	for _, e := range f.events {
+		if !e.Created.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	deleted := int64(len(f.events) - len(kept))
+	f.events = kept
+	return deleted, nil
+}
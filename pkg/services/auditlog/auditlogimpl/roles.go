@@ -0,0 +1,30 @@
+package auditlogimpl
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+const (
+	ActionRead = "auditlog:read"
+)
+
+var (
+	auditLogReaderRole = accesscontrol.RoleDTO{
+		Name:        "fixed:auditlog:reader",
+		DisplayName: "Audit log reader",
+		Description: "Search the audit log of all organizations",
+		Group:       "Audit log",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionRead},
+		},
+	}
+)
+
+func declareFixedRoles(ac accesscontrol.Service) error {
+	reader := accesscontrol.RoleRegistration{
+		Role:   auditLogReaderRole,
+		Grants: []string{accesscontrol.RoleGrafanaAdmin},
+	}
+
+	return ac.DeclareFixedRoles(reader)
+}
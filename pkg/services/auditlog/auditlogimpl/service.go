@@ -0,0 +1,238 @@
+package auditlogimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+
+	// sinkQueueSize is the number of events waiting to be delivered to sinks,
+	// events are dropped when sinks can't keep up
+	sinkQueueSize     = 10000
+	sinkBatchSize     = 100
+	sinkFlushInterval = 5 * time.Second
+)
+
+// defaultExcludedPaths are the API paths of requests which don't change the configuration
+var defaultExcludedPaths = []string{
+	"/api/ds/query",
+	"/api/tsdb/query",
+	"/api/frontend-metrics",
+	"/api/frontend/log",
+	"/api/live/",
+	"/api/query-history",
+}
+
+var (
+	sinkEventsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Subsystem: "auditlog",
+		Name:      "sink_events_dropped_total",
+		Help:      "A counter for audit events which couldn't be queued for delivery to sinks",
+	})
+	sinkWriteFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Subsystem: "auditlog",
+		Name:      "sink_write_failures_total",
+		Help:      "A counter for failed deliveries of audit events to sinks",
+	}, []string{"sink"})
+)
+
+type Service struct {
+	cfg           *setting.Cfg
+	store         store
+	accessControl ac.AccessControl
+	sinks         []sink
+	queue         chan *auditlog.Event
+	log           log.Logger
+	now           func() time.Time
+
+	enabled       bool
+	maxAge        time.Duration
+	excludedPaths []string
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sql db.DB,
+	routeRegister routing.RouteRegister,
+	accessControl ac.AccessControl,
+	accesscontrolService ac.Service,
+) (*Service, error) {
+	section := cfg.SectionWithEnvOverrides("auditing")
+	maxAge, err := gtime.ParseDuration(section.Key("max_age").MustString("90d"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid auditing max_age: %w", err)
+	}
+
+	s := &Service{
+		cfg:           cfg,
+		store:         &sqlStore{db: sql},
+		accessControl: accessControl,
+		queue:         make(chan *auditlog.Event, sinkQueueSize),
+		log:           log.New("auditlog"),
+		now:           time.Now,
+		enabled:       section.Key("enabled").MustBool(false),
+		maxAge:        maxAge,
+		excludedPaths: append(append([]string{}, defaultExcludedPaths...), splitList(section.Key("exclude_paths").String())...),
+	}
+
+	if !s.enabled {
+		return s, nil
+	}
+
+	if path := section.Key("log_file").String(); path != "" {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cfg.LogsPath, path)
+		}
+		fileSink, err := newFileSink(path)
+		if err != nil {
+			return nil, err
+		}
+		s.sinks = append(s.sinks, fileSink)
+	}
+	if url := section.Key("webhook_url").String(); url != "" {
+		headers, err := parseHeaders(splitList(section.Key("webhook_headers").String()))
+		if err != nil {
+			return nil, err
+		}
+		s.sinks = append(s.sinks, newWebhookSink(url, headers, section.Key("webhook_timeout").MustDuration(10*time.Second)))
+	}
+
+	if err := declareFixedRoles(accesscontrolService); err != nil {
+		return nil, err
+	}
+
+	s.registerAPIEndpoints(routeRegister)
+
+	return s, nil
+}
+
+// Run delivers the recorded events to the configured sinks in batches.
+func (s *Service) Run(ctx context.Context) error {
+	if !s.enabled || len(s.sinks) == 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(sinkFlushInterval)
+	defer ticker.Stop()
+	batch := make([]*auditlog.Event, 0, sinkBatchSize)
+	for {
+		select {
+		case e := <-s.queue:
+			batch = append(batch, e)
+			if len(batch) >= sinkBatchSize {
+				s.writeToSinks(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.writeToSinks(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ctx.Done():
+			// Deliver the events recorded before shutdown
+			for len(s.queue) > 0 {
+				batch = append(batch, <-s.queue)
+			}
+			if len(batch) > 0 {
+				flushCtx, cancel := context.WithTimeout(context.Background(), sinkFlushInterval)
+				s.writeToSinks(flushCtx, batch)
+				cancel()
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) writeToSinks(ctx context.Context, events []*auditlog.Event) {
+	for _, sink := range s.sinks {
+		if err := sink.Write(ctx, events); err != nil {
+			sinkWriteFailures.WithLabelValues(sink.Name()).Inc()
+			s.log.Error("Failed to write audit events", "sink", sink.Name(), "numEvents", len(events), "error", err)
+		}
+	}
+}
+
+// saveEvents stores the events and queues them for delivery to the sinks.
+func (s *Service) saveEvents(ctx context.Context, events []*auditlog.Event) {
+	if err := s.store.Insert(ctx, events); err != nil {
+		s.log.Error("Failed to save audit events", "numEvents", len(events), "error", err)
+	}
+	if len(s.sinks) == 0 {
+		return
+	}
+	for _, e := range events {
+		select {
+		case s.queue <- e:
+		default:
+			sinkEventsDropped.Inc()
+			s.log.Warn("Audit event queue is full, dropping event", "action", e.Action, "resourceKind", e.ResourceKind, "requestId", e.RequestID)
+		}
+	}
+}
+
+func (s *Service) SearchEvents(ctx context.Context, query *auditlog.SearchEventsQuery) (*auditlog.SearchEventsResult, error) {
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchLimit
+	}
+	if query.Limit > maxSearchLimit {
+		query.Limit = maxSearchLimit
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	return s.store.Search(ctx, query)
+}
+
+func (s *Service) GetEvent(ctx context.Context, id int64) (*auditlog.Event, error) {
+	return s.store.Get(ctx, id)
+}
+
+func (s *Service) DeleteExpiredEvents(ctx context.Context) (int64, error) {
+	if s.maxAge <= 0 {
+		return 0, nil
+	}
+	return s.store.DeleteOlderThan(ctx, s.now().Add(-s.maxAge))
+}
+
+func splitList(value string) []string {
+	var result []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// parseHeaders parses headers formatted as Name:Value
+func parseHeaders(values []string) (map[string]string, error) {
+	headers := make(map[string]string, len(values))
+	for _, v := range values {
+		name, value, ok := strings.Cut(v, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, errors.New("invalid auditing webhook_headers, headers must be formatted as Name:Value")
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
@@ -0,0 +1,66 @@
+package auditlogimpl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/auditlog"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+	sink, err := newFileSink(path)
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Write(context.Background(), []*auditlog.Event{{ResourceUID: "a"}, {ResourceUID: "b"}}))
+	require.NoError(t, sink.Write(context.Background(), []*auditlog.Event{{ResourceUID: "c"}}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+	for i, uid := range []string{"a", "b", "c"} {
+		var e auditlog.Event
+		require.NoError(t, json.Unmarshal([]byte(lines[i]), &e))
+		assert.Equal(t, uid, e.ResourceUID)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received []*auditlog.Event
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	sink := newWebhookSink(server.URL, map[string]string{"Authorization": "Bearer token"}, time.Second)
+	require.NoError(t, sink.Write(context.Background(), []*auditlog.Event{{ResourceUID: "a"}, {ResourceUID: "b"}}))
+	require.Len(t, received, 2)
+	assert.Equal(t, "b", received[1].ResourceUID)
+
+	status = http.StatusInternalServerError
+	require.Error(t, sink.Write(context.Background(), []*auditlog.Event{{ResourceUID: "c"}}))
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := parseHeaders(splitList("Authorization: Bearer token, X-Scope:grafana"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer token", "X-Scope": "grafana"}, headers)
+
+	_, err = parseHeaders([]string{"invalid"})
+	require.Error(t, err)
+}
@@ -0,0 +1,138 @@
+package auditlogimpl
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+)
+
+// deleteBatchSize is the number of expired events deleted per statement, to avoid
+// holding locks on the table for too long
+const deleteBatchSize = 1000
+
+type store interface {
+	Insert(ctx context.Context, events []*auditlog.Event) error
+	Get(ctx context.Context, id int64) (*auditlog.Event, error)
+	Search(ctx context.Context, query *auditlog.SearchEventsQuery) (*auditlog.SearchEventsResult, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
+}
+
+type sqlStore struct {
+	db db.DB
+}
+
+func (s *sqlStore) Insert(ctx context.Context, events []*auditlog.Event) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		for _, e := range events {
+			if _, err := sess.Insert(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *sqlStore) Get(ctx context.Context, id int64) (*auditlog.Event, error) {
+	event := auditlog.Event{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.ID(id).Get(&event)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return auditlog.ErrEventNotFound.Errorf("audit event %d not found", id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (s *sqlStore) Search(ctx context.Context, query *auditlog.SearchEventsQuery) (*auditlog.SearchEventsResult, error) {
+	result := &auditlog.SearchEventsResult{
+		Events:  []*auditlog.Event{},
+		Page:    query.Page,
+		PerPage: query.Limit,
+	}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		where := []string{"1 = 1"}
+		args := []any{}
+		addFilter := func(clause string, arg any) {
+			where = append(where, clause)
+			args = append(args, arg)
+		}
+		if query.OrgID != 0 {
+			addFilter("org_id = ?", query.OrgID)
+		}
+		if query.ActorID != 0 {
+			addFilter("actor_id = ?", query.ActorID)
+		}
+		if query.ActorLogin != "" {
+			addFilter("actor_login = ?", query.ActorLogin)
+		}
+		if query.Action != "" {
+			addFilter("action = ?", query.Action)
+		}
+		if query.ResourceKind != "" {
+			addFilter("resource_kind = ?", query.ResourceKind)
+		}
+		if query.ResourceUID != "" {
+			addFilter("resource_uid = ?", query.ResourceUID)
+		}
+		if query.RequestID != "" {
+			addFilter("request_id = ?", query.RequestID)
+		}
+		if !query.From.IsZero() {
+			addFilter("created >= ?", query.From)
+		}
+		if !query.To.IsZero() {
+			addFilter("created <= ?", query.To)
+		}
+		filter := strings.Join(where, " AND ")
+
+		count, err := sess.Table("audit_event").Where(filter, args...).Count()
+		if err != nil {
+			return err
+		}
+		result.TotalCount = count
+
+		offset := (query.Page - 1) * query.Limit
+		return sess.Where(filter, args...).Desc("created", "id").Limit(query.Limit, offset).Find(&result.Events)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *sqlStore) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	for {
+		var affected int64
+		err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+			var ids []int64
+			if err := sess.Table("audit_event").Cols("id").Where("created < ?", before).
+				Limit(deleteBatchSize).Find(&ids); err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				return nil
+			}
+			var err error
+			affected, err = sess.Table("audit_event").In("id", ids).Delete(&auditlog.Event{})
+			return err
+		})
+		if err != nil {
+			return deleted, err
+		}
+		deleted += affected
+		if affected < deleteBatchSize {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package auditlogimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+)
+
+func TestIntegrationAuditEventStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	ss := &sqlStore{db: db.InitTestDB(t)}
+	now := time.Now().Truncate(time.Second)
+
+	newEvent := func(orgID int64, action, uid string, created time.Time) *auditlog.Event {
+		return &auditlog.Event{
+			OrgID:        orgID,
+			Created:      created,
+			ActorID:      1,
+			ActorLogin:   "admin",
+			ActorKind:    auditlog.ActorKindUser,
+			Action:       action,
+			ResourceKind: "datasources",
+			ResourceUID:  uid,
+			Before:       `{"url":"http://a"}`,
+			After:        `{"url":"http://b"}`,
+			Diff:         []auditlog.Diff{{Path: "url", Old: "http://a", New: "http://b"}},
+			RequestID:    "request-" + uid,
+			Method:       "PUT",
+			Path:         "/api/datasources/uid/" + uid,
+			StatusCode:   200,
+		}
+	}
+
+	require.NoError(t, ss.Insert(ctx, []*auditlog.Event{
+		newEvent(1, auditlog.ActionCreate, "a", now.Add(-100*24*time.Hour)),
+		newEvent(1, auditlog.ActionUpdate, "a", now.Add(-time.Hour)),
+		newEvent(1, auditlog.ActionUpdate, "b", now.Add(-time.Minute)),
+		newEvent(2, auditlog.ActionDelete, "c", now),
+	}))
+
+	t.Run("searches events, latest first", func(t *testing.T) {
+		result, err := ss.Search(ctx, &auditlog.SearchEventsQuery{OrgID: 1, Page: 1, Limit: 2})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), result.TotalCount)
+		require.Len(t, result.Events, 2)
+		require.Equal(t, "b", result.Events[0].ResourceUID)
+		require.Equal(t, []auditlog.Diff{{Path: "url", Old: "http://a", New: "http://b"}}, result.Events[0].Diff)
+
+		result, err = ss.Search(ctx, &auditlog.SearchEventsQuery{OrgID: 1, Page: 2, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+		require.Equal(t, auditlog.ActionCreate, result.Events[0].Action)
+	})
+
+	t.Run("filters events", func(t *testing.T) {
+		result, err := ss.Search(ctx, &auditlog.SearchEventsQuery{ResourceUID: "a", Action: auditlog.ActionUpdate, Page: 1, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+
+		result, err = ss.Search(ctx, &auditlog.SearchEventsQuery{From: now.Add(-2 * time.Hour), To: now.Add(-30 * time.Second), Page: 1, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 2)
+
+		result, err = ss.Search(ctx, &auditlog.SearchEventsQuery{RequestID: "request-c", Page: 1, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+
+		event, err := ss.Get(ctx, result.Events[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), event.OrgID)
+	})
+
+	t.Run("returns an error when the event doesn't exist", func(t *testing.T) {
+		_, err := ss.Get(ctx, 1000)
+		require.ErrorIs(t, err, auditlog.ErrEventNotFound)
+	})
+
+	t.Run("deletes expired events", func(t *testing.T) {
+		deleted, err := ss.DeleteOlderThan(ctx, now.Add(-90*24*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+
+		result, err := ss.Search(ctx, &auditlog.SearchEventsQuery{Page: 1, Limit: 10})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), result.TotalCount)
+	})
+}
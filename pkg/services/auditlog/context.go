@@ -0,0 +1,49 @@
+package auditlog
+
+import (
+	"context"
+	"sync"
+)
+
+type recorderKey struct{}
+
+// Recorder collects the changes made while handling a request.
+type Recorder struct {
+	mu      sync.Mutex
+	changes []Change
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// WithRecorder returns a context in which the changes recorded by services are collected.
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// Changes returns the changes recorded so far.
+func (r *Recorder) Changes() []Change {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Change(nil), r.changes...)
+}
+
+// IsRecording reports whether changes made in this context are recorded. Services can use it
+// to avoid loading the state of a resource before a change when it isn't needed.
+func IsRecording(ctx context.Context) bool {
+	_, ok := ctx.Value(recorderKey{}).(*Recorder)
+	return ok
+}
+
+// RecordChange records a change made by a service. It must only be called once the change
+// is successfully saved, and is a no-op outside of audited requests.
+func RecordChange(ctx context.Context, change Change) {
+	r, ok := ctx.Value(recorderKey{}).(*Recorder)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	r.changes = append(r.changes, change)
+	r.mu.Unlock()
+}
@@ -0,0 +1,60 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// ComputeDiff returns the values that changed between two JSON documents. Objects are compared
+// key by key, other values, arrays included, are compared as a whole.
+func ComputeDiff(before, after []byte) ([]Diff, error) {
+	var b, a any
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &b); err != nil {
+			return nil, err
+		}
+	}
+	if len(after) > 0 {
+		if err := json.Unmarshal(after, &a); err != nil {
+			return nil, err
+		}
+	}
+
+	diff := []Diff{}
+	diffValues("", b, a, &diff)
+	return diff, nil
+}
+
+func diffValues(path string, before, after any, diff *[]Diff) {
+	b, bIsObject := before.(map[string]any)
+	a, aIsObject := after.(map[string]any)
+	if !bIsObject || !aIsObject {
+		if !reflect.DeepEqual(before, after) {
+			*diff = append(*diff, Diff{Path: path, Old: before, New: after})
+		}
+		return
+	}
+
+	keys := make([]string, 0, len(b)+len(a))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		diffValues(joinPath(path, k), b[k], a[k], diff)
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
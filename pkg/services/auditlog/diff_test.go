@@ -0,0 +1,52 @@
+package auditlog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeDiff(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		before   string
+		after    string
+		expected []Diff
+	}{
+		{
+			desc:     "created resource",
+			after:    `{"name": "prometheus"}`,
+			expected: []Diff{{Path: "", New: map[string]any{"name": "prometheus"}}},
+		},
+		{
+			desc:     "deleted resource",
+			before:   `{"name": "prometheus"}`,
+			expected: []Diff{{Path: "", Old: map[string]any{"name": "prometheus"}}},
+		},
+		{
+			desc:     "unchanged resource",
+			before:   `{"name": "prometheus", "jsonData": {"timeout": 30}}`,
+			after:    `{"jsonData": {"timeout": 30}, "name": "prometheus"}`,
+			expected: []Diff{},
+		},
+		{
+			desc:   "nested changes",
+			before: `{"name": "prometheus", "jsonData": {"timeout": 30, "tags": ["a"]}, "removed": true}`,
+			after:  `{"name": "mimir", "jsonData": {"timeout": 30, "tags": ["a", "b"], "httpMethod": "POST"}}`,
+			expected: []Diff{
+				{Path: "jsonData.httpMethod", New: "POST"},
+				{Path: "jsonData.tags", Old: []any{"a"}, New: []any{"a", "b"}},
+				{Path: "name", Old: "prometheus", New: "mimir"},
+				{Path: "removed", Old: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			diff, err := ComputeDiff([]byte(tc.before), []byte(tc.after))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, diff)
+		})
+	}
+}
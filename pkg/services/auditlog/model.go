@@ -0,0 +1,101 @@
+package auditlog
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrEventNotFound = errutil.NewBase(errutil.StatusNotFound, "auditlog.notFound", errutil.WithPublicMessage("Audit event not found"))
+)
+
+// Actions recorded by the service layers. HTTP requests without recorded changes
+// use the action matching their method.
+const (
+	ActionCreate         = "create"
+	ActionUpdate         = "update"
+	ActionDelete         = "delete"
+	ActionAssign         = "assign"
+	ActionUnassign       = "unassign"
+	ActionSetPermissions = "set-permissions"
+)
+
+// Kinds of actors making changes
+const (
+	ActorKindUser           = "user"
+	ActorKindServiceAccount = "service-account"
+	ActorKindAPIKey         = "api-key"
+	ActorKindAnonymous      = "anonymous"
+	ActorKindRenderer       = "renderer"
+)
+
+// Event is a change made to Grafana
+type Event struct {
+	ID      int64     `xorm:"pk autoincr 'id'" json:"id"`
+	OrgID   int64     `xorm:"org_id" json:"orgId"`
+	Created time.Time `xorm:"'created'" json:"created"`
+
+	ActorID    int64  `xorm:"actor_id" json:"actorId"`
+	ActorLogin string `xorm:"actor_login" json:"actorLogin"`
+	ActorKind  string `xorm:"actor_kind" json:"actorKind"`
+
+	Action       string `xorm:"action" json:"action"`
+	ResourceKind string `xorm:"resource_kind" json:"resourceKind"`
+	ResourceUID  string `xorm:"resource_uid" json:"resourceUid"`
+	// Before and After are the JSON representations of the resource, secrets excluded
+	Before string `xorm:"before_state" json:"before,omitempty"`
+	After  string `xorm:"after_state" json:"after,omitempty"`
+	Diff   []Diff `xorm:"diff" json:"diff,omitempty"`
+
+	IPAddress  string `xorm:"ip_address" json:"ipAddress"`
+	RequestID  string `xorm:"request_id" json:"requestId"`
+	Method     string `xorm:"method" json:"method"`
+	Path       string `xorm:"path" json:"path"`
+	Route      string `xorm:"route" json:"route"`
+	StatusCode int    `xorm:"status_code" json:"statusCode"`
+}
+
+func (e Event) TableName() string {
+	return "audit_event"
+}
+
+// Diff is a value that changed between the before and after state of a resource
+type Diff struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// Change is a change recorded by a service while handling a request
+type Change struct {
+	// OrgID is the organization of the resource, the organization of the request is used when not set
+	OrgID        int64
+	Action       string
+	ResourceKind string
+	ResourceUID  string
+	// Before and After are marshalled to JSON, they must not contain secrets
+	Before any
+	After  any
+}
+
+type SearchEventsQuery struct {
+	OrgID        int64
+	ActorID      int64
+	ActorLogin   string
+	Action       string
+	ResourceKind string
+	ResourceUID  string
+	RequestID    string
+	From         time.Time
+	To           time.Time
+	Page         int
+	Limit        int
+}
+
+type SearchEventsResult struct {
+	TotalCount int64    `json:"totalCount"`
+	Events     []*Event `json:"events"`
+	Page       int      `json:"page"`
+	PerPage    int      `json:"perPage"`
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/auth/jwt"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
 const (
@@ -22,6 +23,9 @@ const (
 var (
 	ErrUserTokenNotFound   = errors.New("user token not found")
 	ErrInvalidSessionToken = usertoken.ErrInvalidSessionToken
+
+	ErrInvalidDeviceName    = errutil.NewBase(errutil.StatusBadRequest, "auth.invalid-device-name", errutil.WithPublicMessage("Device name is too long"))
+	ErrInvalidSessionPolicy = errutil.NewBase(errutil.StatusBadRequest, "auth.invalid-session-policy", errutil.WithPublicMessage("Idle timeout must be 0 or at least 60 seconds"))
 )
 
 type (
@@ -58,6 +62,17 @@ type RevokeAuthTokenCmd struct {
 	AuthTokenId int64 `json:"authTokenId"`
 }
 
+type UpdateAuthTokenCmd struct {
+	DeviceName string `json:"deviceName"`
+}
+
+// OrgSessionPolicy applies to the sessions of the users whose current organization is OrgID
+type OrgSessionPolicy struct {
+	OrgID int64 `json:"orgId"`
+	// IdleTimeoutSeconds logs out the sessions without activity for longer, 0 disables the policy
+	IdleTimeoutSeconds int64 `json:"idleTimeoutSeconds"`
+}
+
 type RotateCommand struct {
 	// token is the un-hashed token
 	UnHashedToken string
@@ -77,6 +92,10 @@ type UserTokenService interface {
 	GetUserToken(ctx context.Context, userId, userTokenId int64) (*UserToken, error)
 	GetUserTokens(ctx context.Context, userId int64) ([]*UserToken, error)
 	GetUserRevokedTokens(ctx context.Context, userId int64) ([]*UserToken, error)
+	// SetUserTokenDeviceName names a session of the user, an empty name resets it
+	SetUserTokenDeviceName(ctx context.Context, userId, userTokenId int64, deviceName string) error
+	GetOrgSessionPolicy(ctx context.Context, orgID int64) (*OrgSessionPolicy, error)
+	SetOrgSessionPolicy(ctx context.Context, policy *OrgSessionPolicy) error
 }
 
 type UserTokenBackgroundService interface {
@@ -40,6 +40,14 @@ func ProvideUserAuthTokenService(sqlStore db.DB,
 		singleflight:      new(singleflight.Group),
 	}
 
+	section := cfg.SectionWithEnvOverrides("auth")
+	s.maxSessionsPerUser = section.Key("max_concurrent_sessions").MustInt64(0)
+	maxSessionsPerRole, err := parseSessionLimits(section.Key("max_concurrent_sessions_per_role").String())
+	if err != nil {
+		return s, fmt.Errorf("invalid auth max_concurrent_sessions_per_role: %w", err)
+	}
+	s.maxSessionsPerRole = maxSessionsPerRole
+
 	defaultLimits, err := readQuotaConfig(cfg)
 	if err != nil {
 		return s, err
@@ -62,6 +70,11 @@ type UserAuthTokenService struct {
 	cfg               *setting.Cfg
 	log               log.Logger
 	singleflight      *singleflight.Group
+
+	// maxSessionsPerUser is the default limit of concurrent sessions, maxSessionsPerRole overrides
+	// it for the roles of the users in their current organization, 0 means unlimited
+	maxSessionsPerUser int64
+	maxSessionsPerRole map[string]int64
 }
 
 func (s *UserAuthTokenService) CreateToken(ctx context.Context, user *user.User, clientIP net.IP, userAgent string) (*auth.UserToken, error) {
@@ -87,6 +100,7 @@ func (s *UserAuthTokenService) CreateToken(ctx context.Context, user *user.User,
 		UpdatedAt:     now,
 		SeenAt:        0,
 		RevokedAt:     0,
+		LastSeenAt:    now,
 		AuthTokenSeen: false,
 	}
 
@@ -104,6 +118,10 @@ func (s *UserAuthTokenService) CreateToken(ctx context.Context, user *user.User,
 	ctxLogger := s.log.FromContext(ctx)
 	ctxLogger.Debug("user auth token created", "tokenId", userAuthToken.Id, "userId", userAuthToken.UserId, "clientIP", userAuthToken.ClientIp, "userAgent", userAuthToken.UserAgent, "authToken", userAuthToken.AuthToken)
 
+	if err := s.enforceSessionLimit(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
 	var userToken auth.UserToken
 	err = userAuthToken.toUserToken(&userToken)
 
@@ -135,9 +153,15 @@ func (s *UserAuthTokenService) LookupToken(ctx context.Context, unhashedToken st
 
 	if model.RevokedAt > 0 {
 		ctxLogger.Debug("user token has been revoked", "user ID", model.UserId, "token ID", model.Id)
+		// tokens are only soft revoked when their user goes over the session limit
+		maxSessions, err := s.maxSessions(ctx, model.UserId)
+		if err != nil {
+			return nil, err
+		}
 		return nil, &auth.TokenRevokedError{
-			UserID:  model.UserId,
-			TokenID: model.Id,
+			UserID:                model.UserId,
+			TokenID:               model.Id,
+			MaxConcurrentSessions: maxSessions,
 		}
 	}
 
@@ -149,6 +173,10 @@ func (s *UserAuthTokenService) LookupToken(ctx context.Context, unhashedToken st
 		}
 	}
 
+	if err := s.updateLastSeen(ctx, &model); err != nil {
+		return nil, err
+	}
+
 	// Current incoming token is the previous auth token in the DB and the auth_token_seen is true
 	if model.AuthToken != hashedToken && model.PrevAuthToken == hashedToken && model.AuthTokenSeen {
 		model.AuthTokenSeen = false
@@ -502,6 +530,22 @@ func (s *UserAuthTokenService) GetUserToken(ctx context.Context, userId, userTok
 	return &result, err
 }
 
+func (s *UserAuthTokenService) SetUserTokenDeviceName(ctx context.Context, userId, userTokenId int64, deviceName string) error {
+	deviceName = strings.TrimSpace(deviceName)
+	if len(deviceName) > maxDeviceNameLength {
+		return auth.ErrInvalidDeviceName.Errorf("device name is longer than %d characters", maxDeviceNameLength)
+	}
+
+	if _, err := s.GetUserToken(ctx, userId, userTokenId); err != nil {
+		return err
+	}
+
+	return s.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
+		_, err := dbSession.Exec("UPDATE user_auth_token SET device_name = ? WHERE id = ? AND user_id = ?", deviceName, userTokenId, userId)
+		return err
+	})
+}
+
 func (s *UserAuthTokenService) GetUserTokens(ctx context.Context, userId int64) ([]*auth.UserToken, error) {
 	result := []*auth.UserToken{}
 	err := s.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
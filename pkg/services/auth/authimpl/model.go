@@ -19,9 +19,19 @@ type userAuthToken struct {
 	CreatedAt     int64
 	UpdatedAt     int64
 	RevokedAt     int64
+	DeviceName    string
+	LastSeenAt    int64
 	UnhashedToken string `xorm:"-"`
 }
 
+type orgSessionPolicy struct {
+	Id          int64
+	OrgId       int64
+	IdleTimeout int64
+	Created     int64
+	Updated     int64
+}
+
 func userAuthTokenFromUserToken(ut *auth.UserToken) (*userAuthToken, error) {
 	var uat userAuthToken
 	err := uat.fromUserToken(ut)
@@ -45,6 +55,8 @@ func (uat *userAuthToken) fromUserToken(ut *auth.UserToken) error {
 	uat.CreatedAt = ut.CreatedAt
 	uat.UpdatedAt = ut.UpdatedAt
 	uat.RevokedAt = ut.RevokedAt
+	uat.DeviceName = ut.DeviceName
+	uat.LastSeenAt = ut.LastSeenAt
 	uat.UnhashedToken = ut.UnhashedToken
 
 	return nil
@@ -67,6 +79,8 @@ func (uat *userAuthToken) toUserToken(ut *auth.UserToken) error {
 	ut.CreatedAt = uat.CreatedAt
 	ut.UpdatedAt = uat.UpdatedAt
 	ut.RevokedAt = uat.RevokedAt
+	ut.DeviceName = uat.DeviceName
+	ut.LastSeenAt = uat.LastSeenAt
 	ut.UnhashedToken = uat.UnhashedToken
 	return nil
 }
@@ -0,0 +1,219 @@
+package authimpl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+const (
+	// lastSeenInterval throttles the updates of the last activity of the sessions
+	lastSeenInterval = time.Minute
+	// maxDeviceNameLength is the length of the device_name column
+	maxDeviceNameLength = 190
+	// roleGrafanaAdmin is the key of the session limit of server admins
+	roleGrafanaAdmin = "GrafanaAdmin"
+)
+
+// parseSessionLimits parses the max_concurrent_sessions_per_role setting, e.g. "Viewer:2, Admin:10"
+func parseSessionLimits(value string) (map[string]int64, error) {
+	limits := map[string]int64{}
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		role, limit, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("expected role:limit, got %q", entry)
+		}
+		role = strings.TrimSpace(role)
+		if role != roleGrafanaAdmin && !org.RoleType(role).IsValid() {
+			return nil, fmt.Errorf("invalid role %q", role)
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(limit), 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid limit %q for role %s", limit, role)
+		}
+		limits[role] = n
+	}
+	return limits, nil
+}
+
+// maxSessions returns the number of concurrent sessions allowed for the user, 0 means unlimited.
+// Role limits use the strictest limit of the roles of the user across all their organizations.
+func (s *UserAuthTokenService) maxSessions(ctx context.Context, userID int64) (int64, error) {
+	if len(s.maxSessionsPerRole) == 0 {
+		return s.maxSessionsPerUser, nil
+	}
+
+	var results []struct {
+		IsAdmin bool   `xorm:"is_admin"`
+		Role    string `xorm:"role"`
+	}
+	err := s.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
+		return dbSession.SQL("SELECT u.is_admin, ou.role FROM "+s.sqlStore.GetDialect().Quote("user")+" u "+
+			"LEFT JOIN org_user ou ON ou.user_id = u.id WHERE u.id = ?", userID).Find(&results)
+	})
+	if err != nil || len(results) == 0 {
+		return s.maxSessionsPerUser, err
+	}
+
+	if limit, ok := s.maxSessionsPerRole[roleGrafanaAdmin]; ok && results[0].IsAdmin {
+		return limit, nil
+	}
+
+	var strictest int64
+	for _, result := range results {
+		limit, ok := s.maxSessionsPerRole[result.Role]
+		if !ok {
+			limit = s.maxSessionsPerUser
+		}
+		if limit > 0 && (strictest == 0 || limit < strictest) {
+			strictest = limit
+		}
+	}
+	return strictest, nil
+}
+
+// enforceSessionLimit revokes the least recently used sessions of the user over their limit. The
+// sessions are soft revoked, so that their users are told why they were logged out.
+func (s *UserAuthTokenService) enforceSessionLimit(ctx context.Context, userID int64) error {
+	limit, err := s.maxSessions(ctx, userID)
+	if err != nil || limit <= 0 {
+		return err
+	}
+
+	tokens, err := s.GetUserTokens(ctx, userID)
+	if err != nil || int64(len(tokens)) <= limit {
+		return err
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		if lastActivity(tokens[i]) != lastActivity(tokens[j]) {
+			return lastActivity(tokens[i]) > lastActivity(tokens[j])
+		}
+		return tokens[i].Id > tokens[j].Id
+	})
+
+	revoked := tokens[limit:]
+	ids := make([]any, 0, len(revoked))
+	for _, token := range revoked {
+		ids = append(ids, token.Id)
+	}
+
+	err = s.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
+		_, err := dbSession.Table("user_auth_token").In("id", ids...).Update(map[string]any{"revoked_at": getTime().Unix()})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.log.FromContext(ctx).Debug("revoked user auth tokens over the session limit", "userId", userID, "limit", limit, "count", len(ids))
+	return nil
+}
+
+func lastActivity(token *auth.UserToken) int64 {
+	if token.LastSeenAt > 0 {
+		return token.LastSeenAt
+	}
+	return token.CreatedAt
+}
+
+// updateLastSeen records the activity of a session, at most once per lastSeenInterval. Sessions
+// which were idle for longer than the policy of any organization of the user are expired instead.
+func (s *UserAuthTokenService) updateLastSeen(ctx context.Context, model *userAuthToken) error {
+	now := getTime()
+	if now.Sub(time.Unix(model.LastSeenAt, 0)) < lastSeenInterval {
+		return nil
+	}
+
+	idleTimeout, err := s.idleTimeout(ctx, model.UserId)
+	if err != nil {
+		return err
+	}
+
+	// tokens created before the activity was recorded are active since their last rotation
+	lastSeen := model.LastSeenAt
+	if lastSeen == 0 {
+		lastSeen = model.RotatedAt
+	}
+	if idleTimeout > 0 && now.Sub(time.Unix(lastSeen, 0)) > idleTimeout {
+		s.log.FromContext(ctx).Debug("user token is idle", "user ID", model.UserId, "token ID", model.Id)
+		return &auth.TokenExpiredError{
+			UserID:  model.UserId,
+			TokenID: model.Id,
+		}
+	}
+
+	model.LastSeenAt = now.Unix()
+	return s.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
+		_, err := dbSession.Exec("UPDATE user_auth_token SET last_seen_at = ? WHERE id = ?", model.LastSeenAt, model.Id)
+		return err
+	})
+}
+
+// idleTimeout returns the shortest idle timeout of the organizations of the user, or 0 without policy
+func (s *UserAuthTokenService) idleTimeout(ctx context.Context, userID int64) (time.Duration, error) {
+	var seconds int64
+	err := s.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
+		_, err := dbSession.SQL("SELECT COALESCE(MIN(p.idle_timeout), 0) FROM org_session_policy p "+
+			"INNER JOIN org_user ou ON ou.org_id = p.org_id WHERE ou.user_id = ?", userID).Get(&seconds)
+		return err
+	})
+	return time.Duration(seconds) * time.Second, err
+}
+
+func (s *UserAuthTokenService) GetOrgSessionPolicy(ctx context.Context, orgID int64) (*auth.OrgSessionPolicy, error) {
+	var policy orgSessionPolicy
+	err := s.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
+		_, err := dbSession.Where("org_id = ?", orgID).Get(&policy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.OrgSessionPolicy{OrgID: orgID, IdleTimeoutSeconds: policy.IdleTimeout}, nil
+}
+
+func (s *UserAuthTokenService) SetOrgSessionPolicy(ctx context.Context, policy *auth.OrgSessionPolicy) error {
+	if policy.IdleTimeoutSeconds < 0 || (policy.IdleTimeoutSeconds > 0 && policy.IdleTimeoutSeconds < int64(lastSeenInterval.Seconds())) {
+		return auth.ErrInvalidSessionPolicy.Errorf("invalid idle timeout %d", policy.IdleTimeoutSeconds)
+	}
+
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(dbSession *db.Session) error {
+		if policy.IdleTimeoutSeconds == 0 {
+			_, err := dbSession.Exec("DELETE FROM org_session_policy WHERE org_id = ?", policy.OrgID)
+			return err
+		}
+
+		now := getTime().Unix()
+		var existing orgSessionPolicy
+		found, err := dbSession.Where("org_id = ?", policy.OrgID).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if found {
+			existing.IdleTimeout = policy.IdleTimeoutSeconds
+			existing.Updated = now
+			_, err = dbSession.ID(existing.Id).Cols("idle_timeout", "updated").Update(&existing)
+			return err
+		}
+
+		_, err = dbSession.Insert(&orgSessionPolicy{
+			OrgId:       policy.OrgID,
+			IdleTimeout: policy.IdleTimeoutSeconds,
+			Created:     now,
+			Updated:     now,
+		})
+		return err
+	})
+}
@@ -0,0 +1,211 @@
+package authimpl
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestUserAuthTokenSessionLimits(t *testing.T) {
+	now := time.Date(2018, 12, 13, 13, 45, 0, 0, time.UTC)
+	getTime = func() time.Time { return now }
+	defer func() { getTime = time.Now }()
+
+	createTokens := func(t *testing.T, ctx *testContext, usr *user.User, count int) []*auth.UserToken {
+		tokens := make([]*auth.UserToken, 0, count)
+		for i := 0; i < count; i++ {
+			now = now.Add(time.Minute)
+			token, err := ctx.tokenService.CreateToken(context.Background(), usr, net.ParseIP("192.168.10.11"), "some user agent")
+			require.NoError(t, err)
+			tokens = append(tokens, token)
+		}
+		return tokens
+	}
+
+	t.Run("revokes the least recently used sessions over the user limit", func(t *testing.T) {
+		ctx := createTestContext(t)
+		ctx.tokenService.maxSessionsPerUser = 2
+		usr := &user.User{ID: 10}
+
+		tokens := createTokens(t, ctx, usr, 2)
+		now = now.Add(time.Minute)
+		_, err := ctx.tokenService.LookupToken(context.Background(), tokens[0].UnhashedToken)
+		require.NoError(t, err)
+		createTokens(t, ctx, usr, 1)
+
+		_, err = ctx.tokenService.LookupToken(context.Background(), tokens[1].UnhashedToken)
+		var revokedErr *auth.TokenRevokedError
+		require.ErrorAs(t, err, &revokedErr)
+		require.Equal(t, int64(2), revokedErr.MaxConcurrentSessions)
+
+		active, err := ctx.tokenService.GetUserTokens(context.Background(), usr.ID)
+		require.NoError(t, err)
+		require.Len(t, active, 2)
+	})
+
+	t.Run("uses the limit of the role of the user", func(t *testing.T) {
+		ctx := createTestContext(t)
+		ctx.tokenService.maxSessionsPerUser = 3
+		ctx.tokenService.maxSessionsPerRole = map[string]int64{string(org.RoleViewer): 1, roleGrafanaAdmin: 0}
+		viewer := insertTestUser(t, ctx, "viewer", false, org.RoleViewer)
+		editor := insertTestUser(t, ctx, "editor", false, org.RoleEditor)
+		admin := insertTestUser(t, ctx, "admin", true, org.RoleViewer)
+
+		createTokens(t, ctx, viewer, 2)
+		createTokens(t, ctx, editor, 4)
+		createTokens(t, ctx, admin, 4)
+
+		for _, tc := range []struct {
+			usr      *user.User
+			expected int
+		}{{viewer, 1}, {editor, 3}, {admin, 4}} {
+			active, err := ctx.tokenService.GetUserTokens(context.Background(), tc.usr.ID)
+			require.NoError(t, err)
+			require.Len(t, active, tc.expected, tc.usr.Login)
+		}
+	})
+
+	t.Run("uses the strictest limit of the roles of the user across their organizations", func(t *testing.T) {
+		ctx := createTestContext(t)
+		ctx.tokenService.maxSessionsPerRole = map[string]int64{string(org.RoleViewer): 1, string(org.RoleAdmin): 3}
+		usr := insertTestUser(t, ctx, "user", false, org.RoleAdmin)
+		addTestOrgUser(t, ctx, usr, 2, org.RoleViewer)
+
+		createTokens(t, ctx, usr, 2)
+
+		active, err := ctx.tokenService.GetUserTokens(context.Background(), usr.ID)
+		require.NoError(t, err)
+		require.Len(t, active, 1)
+	})
+}
+
+func TestUserAuthTokenIdlePolicy(t *testing.T) {
+	now := time.Date(2018, 12, 13, 13, 45, 0, 0, time.UTC)
+	getTime = func() time.Time { return now }
+	defer func() { getTime = time.Now }()
+
+	ctx := createTestContext(t)
+	usr := insertTestUser(t, ctx, "user", false, org.RoleViewer)
+	require.NoError(t, ctx.tokenService.SetOrgSessionPolicy(context.Background(), &auth.OrgSessionPolicy{OrgID: usr.OrgID, IdleTimeoutSeconds: 300}))
+
+	policy, err := ctx.tokenService.GetOrgSessionPolicy(context.Background(), usr.OrgID)
+	require.NoError(t, err)
+	require.Equal(t, &auth.OrgSessionPolicy{OrgID: usr.OrgID, IdleTimeoutSeconds: 300}, policy)
+
+	token, err := ctx.tokenService.CreateToken(context.Background(), usr, net.ParseIP("192.168.10.11"), "some user agent")
+	require.NoError(t, err)
+
+	t.Run("records the activity of the sessions", func(t *testing.T) {
+		now = now.Add(4 * time.Minute)
+		_, err := ctx.tokenService.LookupToken(context.Background(), token.UnhashedToken)
+		require.NoError(t, err)
+
+		stored, err := ctx.getAuthTokenByID(token.Id)
+		require.NoError(t, err)
+		require.Equal(t, now.Unix(), stored.LastSeenAt)
+	})
+
+	t.Run("expires the idle sessions", func(t *testing.T) {
+		now = now.Add(6 * time.Minute)
+		_, err := ctx.tokenService.LookupToken(context.Background(), token.UnhashedToken)
+		var expiredErr *auth.TokenExpiredError
+		require.ErrorAs(t, err, &expiredErr)
+
+		deleted, err := ctx.tokenService.deleteIdleTokens(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+	})
+
+	t.Run("rejects idle timeouts shorter than the activity updates", func(t *testing.T) {
+		err := ctx.tokenService.SetOrgSessionPolicy(context.Background(), &auth.OrgSessionPolicy{OrgID: usr.OrgID, IdleTimeoutSeconds: 30})
+		require.ErrorIs(t, err, auth.ErrInvalidSessionPolicy)
+	})
+
+	t.Run("uses the shortest idle timeout of the organizations of the user", func(t *testing.T) {
+		addTestOrgUser(t, ctx, usr, 2, org.RoleViewer)
+		require.NoError(t, ctx.tokenService.SetOrgSessionPolicy(context.Background(), &auth.OrgSessionPolicy{OrgID: 2, IdleTimeoutSeconds: 120}))
+		defer func() {
+			require.NoError(t, ctx.tokenService.SetOrgSessionPolicy(context.Background(), &auth.OrgSessionPolicy{OrgID: 2}))
+		}()
+
+		token, err := ctx.tokenService.CreateToken(context.Background(), usr, net.ParseIP("192.168.10.11"), "some user agent")
+		require.NoError(t, err)
+		now = now.Add(3 * time.Minute)
+		_, err = ctx.tokenService.LookupToken(context.Background(), token.UnhashedToken)
+		var expiredErr *auth.TokenExpiredError
+		require.ErrorAs(t, err, &expiredErr)
+	})
+
+	t.Run("disables the policy", func(t *testing.T) {
+		require.NoError(t, ctx.tokenService.SetOrgSessionPolicy(context.Background(), &auth.OrgSessionPolicy{OrgID: usr.OrgID}))
+
+		token, err := ctx.tokenService.CreateToken(context.Background(), usr, net.ParseIP("192.168.10.11"), "some user agent")
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+		_, err = ctx.tokenService.LookupToken(context.Background(), token.UnhashedToken)
+		require.NoError(t, err)
+	})
+}
+
+func TestUserAuthTokenDeviceName(t *testing.T) {
+	ctx := createTestContext(t)
+	usr := &user.User{ID: 10}
+	token, err := ctx.tokenService.CreateToken(context.Background(), usr, net.ParseIP("192.168.10.11"), "some user agent")
+	require.NoError(t, err)
+
+	require.NoError(t, ctx.tokenService.SetUserTokenDeviceName(context.Background(), usr.ID, token.Id, " Work laptop "))
+	stored, err := ctx.tokenService.GetUserToken(context.Background(), usr.ID, token.Id)
+	require.NoError(t, err)
+	require.Equal(t, "Work laptop", stored.DeviceName)
+
+	err = ctx.tokenService.SetUserTokenDeviceName(context.Background(), usr.ID, token.Id, strings.Repeat("a", maxDeviceNameLength+1))
+	require.ErrorIs(t, err, auth.ErrInvalidDeviceName)
+
+	err = ctx.tokenService.SetUserTokenDeviceName(context.Background(), 11, token.Id, "Phone")
+	require.ErrorIs(t, err, auth.ErrUserTokenNotFound)
+}
+
+func TestParseSessionLimits(t *testing.T) {
+	limits, err := parseSessionLimits("Viewer:2, Admin: 10,GrafanaAdmin:0")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"Viewer": 2, "Admin": 10, "GrafanaAdmin": 0}, limits)
+
+	for _, value := range []string{"Viewer", "Owner:2", "Viewer:-1", "Viewer:two"} {
+		_, err := parseSessionLimits(value)
+		require.Error(t, err, value)
+	}
+}
+
+func insertTestUser(t *testing.T, ctx *testContext, login string, isAdmin bool, role org.RoleType) *user.User {
+	t.Helper()
+
+	usr := &user.User{Login: login, Email: login + "@example.org", OrgID: 1, IsAdmin: isAdmin, Created: time.Now(), Updated: time.Now()}
+	err := ctx.sqlstore.WithDbSession(context.Background(), func(sess *db.Session) error {
+		if _, err := sess.Insert(usr); err != nil {
+			return err
+		}
+		_, err := sess.Insert(&org.OrgUser{OrgID: usr.OrgID, UserID: usr.ID, Role: role, Created: time.Now(), Updated: time.Now()})
+		return err
+	})
+	require.NoError(t, err)
+	return usr
+}
+
+func addTestOrgUser(t *testing.T, ctx *testContext, usr *user.User, orgID int64, role org.RoleType) {
+	t.Helper()
+
+	err := ctx.sqlstore.WithDbSession(context.Background(), func(sess *db.Session) error {
+		_, err := sess.Insert(&org.OrgUser{OrgID: orgID, UserID: usr.ID, Role: role, Created: time.Now(), Updated: time.Now()})
+		return err
+	})
+	require.NoError(t, err)
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/grafana/grafana/pkg/infra/db"
 )
 
+// idleTokensBatchSize is the number of idle tokens deleted per query
+const idleTokensBatchSize = 500
+
 func (s *UserAuthTokenService) Run(ctx context.Context) error {
 	ticker := time.NewTicker(time.Hour)
 	maxInactiveLifetime := s.cfg.LoginMaxInactiveLifetime
@@ -16,6 +19,9 @@ func (s *UserAuthTokenService) Run(ctx context.Context) error {
 		if _, err := s.deleteExpiredTokens(ctx, maxInactiveLifetime, maxLifetime); err != nil {
 			s.log.Error("An error occurred while deleting expired tokens", "err", err)
 		}
+		if _, err := s.deleteIdleTokens(ctx); err != nil {
+			s.log.Error("An error occurred while deleting idle tokens", "err", err)
+		}
 	})
 	if err != nil {
 		s.log.Error("failed to lock and execute cleanup of expired auth token", "error", err)
@@ -28,6 +34,9 @@ func (s *UserAuthTokenService) Run(ctx context.Context) error {
 				if _, err := s.deleteExpiredTokens(ctx, maxInactiveLifetime, maxLifetime); err != nil {
 					s.log.Error("An error occurred while deleting expired tokens", "err", err)
 				}
+				if _, err := s.deleteIdleTokens(ctx); err != nil {
+					s.log.Error("An error occurred while deleting idle tokens", "err", err)
+				}
 			})
 			if err != nil {
 				s.log.Error("failed to lock and execute cleanup of expired auth token", "error", err)
@@ -66,3 +75,36 @@ func (s *UserAuthTokenService) deleteExpiredTokens(ctx context.Context, maxInact
 
 	return affected, err
 }
+
+// deleteIdleTokens deletes the tokens which were idle for longer than the session policy of any
+// organization of their user
+func (s *UserAuthTokenService) deleteIdleTokens(ctx context.Context) (int64, error) {
+	var affected int64
+	err := s.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
+		var ids []int64
+		err := dbSession.SQL(`SELECT DISTINCT t.id FROM user_auth_token t
+			INNER JOIN org_user ou ON ou.user_id = t.user_id
+			INNER JOIN org_session_policy p ON p.org_id = ou.org_id
+			WHERE COALESCE(NULLIF(t.last_seen_at, 0), t.rotated_at) + p.idle_timeout < ?`, getTime().Unix()).Find(&ids)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		for start := 0; start < len(ids); start += idleTokensBatchSize {
+			end := start + idleTokensBatchSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+			deleted, err := dbSession.In("id", ids[start:end]).Delete(&userAuthToken{})
+			if err != nil {
+				return err
+			}
+			affected += deleted
+		}
+
+		s.log.Debug("cleanup of idle auth tokens done", "count", affected)
+		return nil
+	})
+
+	return affected, err
+}
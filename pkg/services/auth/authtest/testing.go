@@ -25,6 +25,9 @@ type FakeUserAuthTokenService struct {
 	GetUserTokensProvider        func(ctx context.Context, userId int64) ([]*auth.UserToken, error)
 	GetUserRevokedTokensProvider func(ctx context.Context, userId int64) ([]*auth.UserToken, error)
 	BatchRevokedTokenProvider    func(ctx context.Context, userIds []int64) error
+	SetDeviceNameProvider        func(ctx context.Context, userId, userTokenId int64, deviceName string) error
+	GetOrgSessionPolicyProvider  func(ctx context.Context, orgID int64) (*auth.OrgSessionPolicy, error)
+	SetOrgSessionPolicyProvider  func(ctx context.Context, policy *auth.OrgSessionPolicy) error
 }
 
 func NewFakeUserAuthTokenService() *FakeUserAuthTokenService {
@@ -116,6 +119,18 @@ func (s *FakeUserAuthTokenService) BatchRevokeAllUserTokens(ctx context.Context,
 	return s.BatchRevokedTokenProvider(ctx, userIds)
 }
 
+func (s *FakeUserAuthTokenService) SetUserTokenDeviceName(ctx context.Context, userId, userTokenId int64, deviceName string) error {
+	return s.SetDeviceNameProvider(ctx, userId, userTokenId, deviceName)
+}
+
+func (s *FakeUserAuthTokenService) GetOrgSessionPolicy(ctx context.Context, orgID int64) (*auth.OrgSessionPolicy, error) {
+	return s.GetOrgSessionPolicyProvider(ctx, orgID)
+}
+
+func (s *FakeUserAuthTokenService) SetOrgSessionPolicy(ctx context.Context, policy *auth.OrgSessionPolicy) error {
+	return s.SetOrgSessionPolicyProvider(ctx, policy)
+}
+
 type FakeOAuthTokenService struct {
 	passThruEnabled  bool
 	ExpectedAuthUser *login.UserAuth
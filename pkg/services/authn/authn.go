@@ -32,11 +32,17 @@ const (
 	ClientForm        = "auth.client.form"
 	ClientProxy       = "auth.client.proxy"
 	ClientSAML        = "auth.client.saml"
+	ClientWebAuthn    = "auth.client.webauthn"
+	ClientEmbedToken  = "auth.client.embed-token"
 )
 
 const (
 	MetaKeyUsername   = "username"
 	MetaKeyAuthModule = "authModule"
+	// MetaKeyMFACode is the multi-factor authentication code sent with the password
+	MetaKeyMFACode = "mfaCode"
+	// MetaKeyWebAuthnAssertion is the JSON encoded WebAuthn assertion sent with the password
+	MetaKeyWebAuthnAssertion = "webauthnAssertion"
 )
 
 // ClientParams are hints to the auth service about how to handle the identity management
@@ -50,7 +56,7 @@ type ClientParams struct {
 	EnableDisabledUsers bool
 	// FetchSyncedUser ensure that all required information is added to the identity
 	FetchSyncedUser bool
-	// SyncTeams will sync the groups from identity to teams in grafana, enterprise only feature
+	// SyncTeams will sync the groups from identity to teams in grafana
 	SyncTeams bool
 	// SyncOrgRoles will sync the roles from the identity to orgs in grafana
 	SyncOrgRoles bool
@@ -22,17 +22,21 @@ import (
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/services/authn/authnimpl/sync"
 	"github.com/grafana/grafana/pkg/services/authn/clients"
+	"github.com/grafana/grafana/pkg/services/embedtoken"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/ldap/service"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/services/mfa"
 	"github.com/grafana/grafana/pkg/services/oauthserver"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/signingkeys"
+	"github.com/grafana/grafana/pkg/services/teamsync"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/webauthn"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
 	"github.com/grafana/grafana/pkg/web"
@@ -65,6 +69,8 @@ func ProvideService(
 	socialService social.Service, cache *remotecache.RemoteCache,
 	ldapService service.LDAP, registerer prometheus.Registerer,
 	signingKeysService signingkeys.Service, oauthServer oauthserver.OAuth2Server,
+	mfaService mfa.Service, teamSyncService teamsync.Service,
+	webauthnService webauthn.Service, embedTokenService embedtoken.Service,
 ) authn.Service {
 	s := &Service{
 		log:            log.New("authn.service"),
@@ -83,6 +89,10 @@ func ProvideService(
 	s.RegisterClient(clients.ProvideRender(userService, renderService))
 	s.RegisterClient(clients.ProvideAPIKey(apikeyService, userService))
 
+	if embedTokenService.IsEnabled() {
+		s.RegisterClient(clients.ProvideEmbedToken(embedTokenService))
+	}
+
 	if cfg.LoginCookieName != "" {
 		s.RegisterClient(clients.ProvideSession(cfg, sessionService, features))
 	}
@@ -107,16 +117,32 @@ func ProvideService(
 
 	// if we have password clients configure check if basic auth or form auth is enabled
 	if len(passwordClients) > 0 {
-		passwordClient := clients.ProvidePassword(loginAttempts, passwordClients...)
+		passwordClient := clients.ProvidePassword(cfg, loginAttempts, passwordClients...)
+		var basicClient, formClient authn.PasswordClient = passwordClient, passwordClient
+		// grafana users authenticate with their password and a multi-factor authentication code
+		if mfaService.IsEnabled() {
+			basicClient = clients.ProvideMFA(cfg, mfaService, loginAttempts, passwordClient, true)
+			formClient = clients.ProvideMFA(cfg, mfaService, loginAttempts, passwordClient, false)
+		}
+		// grafana users with WebAuthn credentials complete an assertion after their password
+		if webauthnService.SecondFactorEnabled() {
+			basicClient = clients.ProvideWebAuthnSecondFactor(cfg, webauthnService, loginAttempts, basicClient)
+			formClient = clients.ProvideWebAuthnSecondFactor(cfg, webauthnService, loginAttempts, formClient)
+		}
+
 		if s.cfg.BasicAuthEnabled {
-			s.RegisterClient(clients.ProvideBasic(passwordClient))
+			s.RegisterClient(clients.ProvideBasic(basicClient))
 		}
 
 		if !s.cfg.DisableLoginForm {
-			s.RegisterClient(clients.ProvideForm(passwordClient))
+			s.RegisterClient(clients.ProvideForm(formClient))
 		}
 	}
 
+	if webauthnService.PasswordlessEnabled() && !s.cfg.DisableLogin {
+		s.RegisterClient(clients.ProvideWebAuthn(cfg, webauthnService, mfaService, loginAttempts, userService))
+	}
+
 	if s.cfg.AuthProxyEnabled && len(proxyClients) > 0 {
 		proxy, err := clients.ProvideProxy(cfg, cache, userService, proxyClients...)
 		if err != nil {
@@ -156,6 +182,7 @@ func ProvideService(
 	s.RegisterPostAuthHook(userSyncService.EnableDisabledUserHook, 20)
 	s.RegisterPostAuthHook(orgUserSyncService.SyncOrgRolesHook, 30)
 	s.RegisterPostAuthHook(userSyncService.SyncLastSeenHook, 40)
+	s.RegisterPostAuthHook(sync.ProvideTeamSync(teamSyncService).SyncTeamsHook, 50)
 
 	if features.IsEnabled(featuremgmt.FlagAccessTokenExpirationCheck) {
 		s.RegisterPostAuthHook(sync.ProvideOAuthTokenSync(oauthTokenService, sessionService).SyncOauthTokenHook, 60)
@@ -0,0 +1,41 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/teamsync"
+)
+
+func ProvideTeamSync(teamSyncService teamsync.Service) *TeamSync {
+	return &TeamSync{teamSyncService, log.New("team.sync")}
+}
+
+type TeamSync struct {
+	teamSyncService teamsync.Service
+
+	log log.Logger
+}
+
+func (s *TeamSync) SyncTeamsHook(ctx context.Context, id *authn.Identity, _ *authn.Request) error {
+	if !id.ClientParams.SyncTeams {
+		return nil
+	}
+
+	ctxLogger := s.log.FromContext(ctx)
+
+	namespace, userID := id.NamespacedID()
+	if namespace != authn.NamespaceUser || userID <= 0 {
+		ctxLogger.Warn("Failed to sync teams, invalid namespace for identity", "id", id.ID, "namespace", namespace)
+		return nil
+	}
+
+	ctxLogger.Debug("Syncing teams", "id", id.ID, "groups", id.Groups)
+	if err := s.teamSyncService.SyncUser(ctx, userID, id.Groups); err != nil {
+		ctxLogger.Error("Failed to sync teams", "id", id.ID, "error", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/teamsync/teamsynctest"
+)
+
+func TestTeamSync_SyncTeamsHook(t *testing.T) {
+	t.Run("should sync the groups of users", func(t *testing.T) {
+		service := &teamsynctest.FakeService{}
+		s := ProvideTeamSync(service)
+
+		err := s.SyncTeamsHook(context.Background(), &authn.Identity{
+			ID:           "user:2",
+			Groups:       []string{"admins", "editors"},
+			ClientParams: authn.ClientParams{SyncTeams: true},
+		}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), service.SyncedUserID)
+		assert.Equal(t, []string{"admins", "editors"}, service.SyncedGroups)
+	})
+
+	t.Run("should skip identities without team sync", func(t *testing.T) {
+		service := &teamsynctest.FakeService{}
+		s := ProvideTeamSync(service)
+
+		err := s.SyncTeamsHook(context.Background(), &authn.Identity{ID: "user:2", Groups: []string{"admins"}}, nil)
+		require.NoError(t, err)
+		assert.Zero(t, service.SyncedUserID)
+	})
+
+	t.Run("should skip identities which aren't users", func(t *testing.T) {
+		service := &teamsynctest.FakeService{}
+		s := ProvideTeamSync(service)
+
+		err := s.SyncTeamsHook(context.Background(), &authn.Identity{
+			ID:           "service-account:2",
+			ClientParams: authn.ClientParams{SyncTeams: true},
+		}, nil)
+		require.NoError(t, err)
+		assert.Zero(t, service.SyncedUserID)
+	})
+
+	t.Run("should return errors of the team sync", func(t *testing.T) {
+		service := &teamsynctest.FakeService{ExpectedErr: errors.New("db error")}
+		s := ProvideTeamSync(service)
+
+		err := s.SyncTeamsHook(context.Background(), &authn.Identity{
+			ID:           "user:2",
+			ClientParams: authn.ClientParams{SyncTeams: true},
+		}, nil)
+		require.Error(t, err)
+	})
+}
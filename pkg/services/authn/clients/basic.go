@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/services/webauthn"
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
@@ -35,6 +37,12 @@ func (c *Basic) Authenticate(ctx context.Context, r *authn.Request) (*authn.Iden
 	if !ok {
 		return nil, errDecodingBasicAuthHeader.Errorf("failed to decode basic auth header")
 	}
+	if code := r.HTTPRequest.Header.Get(mfa.CodeHeader); code != "" {
+		r.SetMeta(authn.MetaKeyMFACode, code)
+	}
+	if assertion := r.HTTPRequest.Header.Get(webauthn.AssertionHeader); assertion != "" {
+		r.SetMeta(authn.MetaKeyWebAuthnAssertion, assertion)
+	}
 
 	return c.client.AuthenticatePassword(ctx, r, username, password)
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/services/authn/authntest"
+	"github.com/grafana/grafana/pkg/services/mfa"
 )
 
 func TestBasic_Authenticate(t *testing.T) {
@@ -18,6 +19,7 @@ func TestBasic_Authenticate(t *testing.T) {
 		client           authn.PasswordClient
 		expectedErr      error
 		expectedIdentity *authn.Identity
+		expectedMFACode  string
 	}
 
 	tests := []TestCase{
@@ -27,6 +29,16 @@ func TestBasic_Authenticate(t *testing.T) {
 			client:           authntest.FakePasswordClient{ExpectedIdentity: &authn.Identity{ID: "user:1"}},
 			expectedIdentity: &authn.Identity{ID: "user:1"},
 		},
+		{
+			desc: "should pass multi-factor authentication code from header",
+			req: &authn.Request{HTTPRequest: &http.Request{Header: map[string][]string{
+				authorizationHeaderName:                 {encodeBasicAuth("user", "password")},
+				http.CanonicalHeaderKey(mfa.CodeHeader): {"123456"},
+			}}},
+			client:           authntest.FakePasswordClient{ExpectedIdentity: &authn.Identity{ID: "user:1"}},
+			expectedIdentity: &authn.Identity{ID: "user:1"},
+			expectedMFACode:  "123456",
+		},
 		{
 			desc:        "should fail when basic auth header could not be decoded",
 			req:         &authn.Request{HTTPRequest: &http.Request{Header: map[string][]string{authorizationHeaderName: {}}}},
@@ -45,6 +57,7 @@ func TestBasic_Authenticate(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.EqualValues(t, *tt.expectedIdentity, *identity)
+				assert.Equal(t, tt.expectedMFACode, tt.req.GetMeta(authn.MetaKeyMFACode))
 			}
 		})
 	}
@@ -0,0 +1,60 @@
+package clients
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/embedtoken"
+)
+
+var _ authn.ContextAwareClient = new(EmbedToken)
+
+func ProvideEmbedToken(embedTokenService embedtoken.Service) *EmbedToken {
+	return &EmbedToken{embedTokenService}
+}
+
+// EmbedToken authenticates the requests of embedded dashboards with the signed embed
+// tokens, the identity can only view the dashboard of the token.
+type EmbedToken struct {
+	embedTokenService embedtoken.Service
+}
+
+func (c *EmbedToken) Name() string {
+	return authn.ClientEmbedToken
+}
+
+func (c *EmbedToken) Authenticate(ctx context.Context, r *authn.Request) (*authn.Identity, error) {
+	claims, err := c.embedTokenService.Verify(ctx, embedtoken.TokenFromRequest(r.HTTPRequest))
+	if err != nil {
+		return nil, err
+	}
+	if r.OrgID > 0 && r.OrgID != claims.OrgID {
+		return nil, embedtoken.ErrInvalidToken.Errorf("embed token of organization %d used for organization %d", claims.OrgID, r.OrgID)
+	}
+
+	// the panel queries of the dashboard run with the permissions of the token,
+	// so the identity doesn't get any data source permission
+	return &authn.Identity{
+		IsAnonymous: true,
+		OrgID:       claims.OrgID,
+		AuthID:      claims.ID,
+		LastSeenAt:  time.Now(),
+		Permissions: map[int64]map[string][]string{
+			claims.OrgID: {
+				dashboards.ActionDashboardsRead: {dashboards.ScopeDashboardsProvider.GetResourceScopeUID(claims.DashboardUID)},
+			},
+		},
+		ClientParams: authn.ClientParams{SyncPermissions: false},
+	}, nil
+}
+
+func (c *EmbedToken) Test(ctx context.Context, r *authn.Request) bool {
+	return embedtoken.TokenFromRequest(r.HTTPRequest) != ""
+}
+
+func (c *EmbedToken) Priority() uint {
+	// embed tokens are sent explicitly, they take precedence over the API keys and the sessions
+	return 25
+}
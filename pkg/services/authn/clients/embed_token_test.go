@@ -0,0 +1,113 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/embedtoken"
+	"github.com/grafana/grafana/pkg/services/embedtoken/embedtokentest"
+)
+
+func TestEmbedToken_Test(t *testing.T) {
+	type TestCase struct {
+		desc     string
+		req      *authn.Request
+		expected bool
+	}
+
+	tests := []TestCase{
+		{
+			desc:     "should succeed with the token in the header",
+			req:      &authn.Request{HTTPRequest: &http.Request{Header: http.Header{embedtoken.HeaderName: {"token"}}}},
+			expected: true,
+		},
+		{
+			desc:     "should succeed with the token in the URL",
+			req:      &authn.Request{HTTPRequest: &http.Request{Header: http.Header{}, URL: &url.URL{RawQuery: "embed_token=token"}}},
+			expected: true,
+		},
+		{
+			desc:     "should fail without token",
+			req:      &authn.Request{HTTPRequest: &http.Request{Header: http.Header{}, URL: &url.URL{RawQuery: "orgId=1"}}},
+			expected: false,
+		},
+		{
+			desc:     "should fail without HTTP request",
+			req:      &authn.Request{},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := ProvideEmbedToken(&embedtokentest.FakeService{})
+			assert.Equal(t, tt.expected, c.Test(context.Background(), tt.req))
+		})
+	}
+}
+
+func TestEmbedToken_Authenticate(t *testing.T) {
+	type TestCase struct {
+		desc             string
+		req              *authn.Request
+		claims           *embedtoken.Claims
+		verifyErr        error
+		expectedErr      error
+		expectedIdentity *authn.Identity
+	}
+
+	claims := &embedtoken.Claims{OrgID: 2, DashboardUID: "dash"}
+	claims.ID = "token-id"
+
+	tests := []TestCase{
+		{
+			desc:   "should return an identity restricted to the dashboard",
+			req:    &authn.Request{HTTPRequest: &http.Request{Header: http.Header{embedtoken.HeaderName: {"token"}}}},
+			claims: claims,
+			expectedIdentity: &authn.Identity{
+				IsAnonymous: true,
+				OrgID:       2,
+				AuthID:      "token-id",
+				Permissions: map[int64]map[string][]string{
+					2: {"dashboards:read": {"dashboards:uid:dash"}},
+				},
+			},
+		},
+		{
+			desc:        "should fail when the token is invalid",
+			req:         &authn.Request{HTTPRequest: &http.Request{Header: http.Header{embedtoken.HeaderName: {"token"}}}},
+			verifyErr:   embedtoken.ErrInvalidToken.Errorf("expired"),
+			expectedErr: embedtoken.ErrInvalidToken,
+		},
+		{
+			desc:        "should fail when the token is used for another organization",
+			req:         &authn.Request{OrgID: 1, HTTPRequest: &http.Request{Header: http.Header{embedtoken.HeaderName: {"token"}}}},
+			claims:      claims,
+			expectedErr: embedtoken.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := ProvideEmbedToken(&embedtokentest.FakeService{ExpectedClaims: tt.claims, ExpectedErr: tt.verifyErr})
+
+			identity, err := c.Authenticate(context.Background(), tt.req)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, identity)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotZero(t, identity.LastSeenAt)
+			identity.LastSeenAt = tt.expectedIdentity.LastSeenAt
+			assert.Equal(t, tt.expectedIdentity, identity)
+		})
+	}
+}
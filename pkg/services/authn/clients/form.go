@@ -2,6 +2,7 @@ package clients
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -25,6 +26,9 @@ type Form struct {
 type loginForm struct {
 	Username string `json:"user" binding:"Required"`
 	Password string `json:"password" binding:"Required"`
+	MFACode  string `json:"mfaCode"`
+	// WebAuthnAssertion is the assertion of users with WebAuthn as second factor
+	WebAuthnAssertion json.RawMessage `json:"webauthnAssertion"`
 }
 
 func (c *Form) Name() string {
@@ -36,5 +40,11 @@ func (c *Form) Authenticate(ctx context.Context, r *authn.Request) (*authn.Ident
 	if err := web.Bind(r.HTTPRequest, &form); err != nil {
 		return nil, errBadForm.Errorf("failed to parse request: %w", err)
 	}
+	if form.MFACode != "" {
+		r.SetMeta(authn.MetaKeyMFACode, form.MFACode)
+	}
+	if len(form.WebAuthnAssertion) > 0 {
+		r.SetMeta(authn.MetaKeyWebAuthnAssertion, string(form.WebAuthnAssertion))
+	}
 	return c.client.AuthenticatePassword(ctx, r, form.Username, form.Password)
 }
@@ -74,6 +74,7 @@ func (s *JWT) Authenticate(ctx context.Context, r *authn.Request) (*authn.Identi
 			FetchSyncedUser: true,
 			SyncPermissions: true,
 			SyncOrgRoles:    !s.cfg.JWTAuthSkipOrgRoleSync,
+			SyncTeams:       s.cfg.JWTAuthGroupsAttributePath != "",
 			AllowSignUp:     s.cfg.JWTAuthAutoSignUp,
 		}}
 
@@ -90,6 +91,11 @@ func (s *JWT) Authenticate(ctx context.Context, r *authn.Request) (*authn.Identi
 		id.Name = name
 	}
 
+	// teams are only synced when the groups claim is configured, since tokens are verified on each request
+	if s.cfg.JWTAuthGroupsAttributePath != "" {
+		id.Groups, _ = searchClaimsForStringArrayAttr(s.cfg.JWTAuthGroupsAttributePath, claims)
+	}
+
 	orgRoles, isGrafanaAdmin, err := getRoles(s.cfg, func() (org.RoleType, *bool, error) {
 		if s.cfg.JWTAuthSkipOrgRoleSync {
 			return "", nil, nil
@@ -200,6 +206,27 @@ func searchClaimsForStringAttr(attributePath string, claims map[string]interface
 	return "", nil
 }
 
+func searchClaimsForStringArrayAttr(attributePath string, claims map[string]interface{}) ([]string, error) {
+	val, err := searchClaimsForAttr(attributePath, claims)
+	if err != nil {
+		return []string{}, err
+	}
+
+	ifArr, ok := val.([]interface{})
+	if !ok {
+		return []string{}, nil
+	}
+
+	result := []string{}
+	for _, v := range ifArr {
+		if strVal, ok := v.(string); ok {
+			result = append(result, strVal)
+		}
+	}
+
+	return result, nil
+}
+
 func searchClaimsForAttr(attributePath string, claims map[string]interface{}) (interface{}, error) {
 	if attributePath == "" {
 		return "", errors.New("no attribute path specified")
@@ -88,6 +88,48 @@ func TestAuthenticateJWT(t *testing.T) {
 	assert.EqualValues(t, wantID, id, fmt.Sprintf("%+v", id))
 }
 
+func TestAuthenticateJWTGroups(t *testing.T) {
+	jwtService := &jwt.FakeJWTService{
+		VerifyProvider: func(context.Context, string) (jwt.JWTClaims, error) {
+			return jwt.JWTClaims{
+				"sub":    "1234567890",
+				"email":  "eai.doe@cor.po",
+				"groups": []interface{}{"admins", "editors", 3},
+			}, nil
+		},
+	}
+	jwtHeaderName := "X-Forwarded-User"
+	validHTTPReq := &http.Request{
+		Header: map[string][]string{
+			jwtHeaderName: {"sample-token"}},
+	}
+
+	t.Run("should sync teams from the groups claim", func(t *testing.T) {
+		cfg := &setting.Cfg{
+			JWTAuthEnabled:             true,
+			JWTAuthHeaderName:          jwtHeaderName,
+			JWTAuthEmailClaim:          "email",
+			JWTAuthGroupsAttributePath: "groups",
+		}
+		id, err := ProvideJWT(jwtService, cfg).Authenticate(context.Background(), &authn.Request{OrgID: 1, HTTPRequest: validHTTPReq})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admins", "editors"}, id.Groups)
+		assert.True(t, id.ClientParams.SyncTeams)
+	})
+
+	t.Run("should not sync teams without groups claim", func(t *testing.T) {
+		cfg := &setting.Cfg{
+			JWTAuthEnabled:    true,
+			JWTAuthHeaderName: jwtHeaderName,
+			JWTAuthEmailClaim: "email",
+		}
+		id, err := ProvideJWT(jwtService, cfg).Authenticate(context.Background(), &authn.Request{OrgID: 1, HTTPRequest: validHTTPReq})
+		require.NoError(t, err)
+		assert.Empty(t, id.Groups)
+		assert.False(t, id.ClientParams.SyncTeams)
+	})
+}
+
 func TestJWTClaimConfig(t *testing.T) {
 	jwtService := &jwt.FakeJWTService{
 		VerifyProvider: func(context.Context, string) (jwt.JWTClaims, error) {
@@ -0,0 +1,56 @@
+package clients
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+var _ authn.PasswordClient = new(MFA)
+
+// ProvideMFA wraps a password client with the multi-factor authentication of Grafana users.
+// basicAuth is true when the client authenticates basic authenticated requests.
+func ProvideMFA(cfg *setting.Cfg, mfaService mfa.Service, loginAttempts loginattempt.Service, client authn.PasswordClient, basicAuth bool) *MFA {
+	return &MFA{cfg, mfaService, loginAttempts, client, basicAuth}
+}
+
+type MFA struct {
+	cfg           *setting.Cfg
+	mfaService    mfa.Service
+	loginAttempts loginattempt.Service
+	client        authn.PasswordClient
+	basicAuth     bool
+}
+
+func (c *MFA) AuthenticatePassword(ctx context.Context, r *authn.Request, username, password string) (*authn.Identity, error) {
+	identity, err := c.client.AuthenticatePassword(ctx, r, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// users of external providers like LDAP use the multi-factor authentication of the provider
+	if r.GetMeta(authn.MetaKeyAuthModule) != "grafana" {
+		return identity, nil
+	}
+
+	_, userID := identity.NamespacedID()
+	err = c.mfaService.VerifyLogin(ctx, &mfa.VerifyLoginCommand{
+		UserID:    userID,
+		Login:     identity.Login,
+		Code:      r.GetMeta(authn.MetaKeyMFACode),
+		BasicAuth: c.basicAuth,
+	})
+	if err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			_ = c.loginAttempts.Add(ctx, username, web.ClientIP(r.HTTPRequest, c.cfg.TrustedProxies))
+		}
+		return nil, err
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,100 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/authn/authntest"
+	"github.com/grafana/grafana/pkg/services/loginattempt/loginattempttest"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/services/mfa/mfatest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestMFA_AuthenticatePassword(t *testing.T) {
+	type TestCase struct {
+		desc             string
+		authModule       string
+		code             string
+		basicAuth        bool
+		clientErr        error
+		mfaErr           error
+		expectedErr      error
+		expectedVerify   *mfa.VerifyLoginCommand
+		expectedAttempts bool
+	}
+
+	tests := []TestCase{
+		{
+			desc:           "should verify the code of grafana users",
+			authModule:     "grafana",
+			code:           "123456",
+			expectedVerify: &mfa.VerifyLoginCommand{UserID: 1, Login: "test", Code: "123456"},
+		},
+		{
+			desc:           "should verify basic authenticated requests",
+			authModule:     "grafana",
+			basicAuth:      true,
+			expectedVerify: &mfa.VerifyLoginCommand{UserID: 1, Login: "test", BasicAuth: true},
+		},
+		{
+			desc:       "should skip users of other auth modules",
+			authModule: "ldap",
+		},
+		{
+			desc:        "should not verify when the password client fails",
+			authModule:  "grafana",
+			clientErr:   errInvalidPassword,
+			expectedErr: errInvalidPassword,
+		},
+		{
+			desc:             "should record login attempt on invalid code",
+			authModule:       "grafana",
+			code:             "000000",
+			mfaErr:           mfa.ErrInvalidCode.Errorf("invalid code"),
+			expectedErr:      mfa.ErrInvalidCode,
+			expectedVerify:   &mfa.VerifyLoginCommand{UserID: 1, Login: "test", Code: "000000"},
+			expectedAttempts: true,
+		},
+		{
+			desc:           "should fail when code is required",
+			authModule:     "grafana",
+			mfaErr:         mfa.ErrCodeRequired.Errorf("code required"),
+			expectedErr:    mfa.ErrCodeRequired,
+			expectedVerify: &mfa.VerifyLoginCommand{UserID: 1, Login: "test"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mfaService := &mfatest.FakeService{ExpectedErr: tt.mfaErr}
+			loginAttempts := &loginattempttest.MockLoginAttemptService{}
+			client := authntest.FakePasswordClient{
+				ExpectedIdentity: &authn.Identity{ID: "user:1", Login: "test"},
+				ExpectedErr:      tt.clientErr,
+			}
+			c := ProvideMFA(setting.NewCfg(), mfaService, loginAttempts, client, tt.basicAuth)
+
+			req := &authn.Request{HTTPRequest: &http.Request{}}
+			req.SetMeta(authn.MetaKeyAuthModule, tt.authModule)
+			if tt.code != "" {
+				req.SetMeta(authn.MetaKeyMFACode, tt.code)
+			}
+
+			identity, err := c.AuthenticatePassword(context.Background(), req, "test", "password")
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, identity)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "user:1", identity.ID)
+			}
+			assert.Equal(t, tt.expectedVerify, mfaService.VerifyLoginCmd)
+			assert.Equal(t, tt.expectedAttempts, loginAttempts.AddCalled)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+var (
+	errBadWebAuthnAssertion = errutil.NewBase(errutil.StatusBadRequest, "webauthn-auth.invalid", errutil.WithPublicMessage("bad WebAuthn assertion"))
+)
+
+var _ authn.ContextAwareClient = new(WebAuthn)
+
+// ProvideWebAuthn authenticates Grafana users with a WebAuthn assertion instead of their password,
+// the multi-factor authentication policies apply like to the password logins
+func ProvideWebAuthn(cfg *setting.Cfg, webauthnService webauthn.Service, mfaService mfa.Service, loginAttempts loginattempt.Service, userService user.Service) *WebAuthn {
+	return &WebAuthn{cfg, webauthnService, mfaService, loginAttempts, userService}
+}
+
+type WebAuthn struct {
+	cfg             *setting.Cfg
+	webauthnService webauthn.Service
+	mfaService      mfa.Service
+	loginAttempts   loginattempt.Service
+	userService     user.Service
+}
+
+func (c *WebAuthn) String() string {
+	return c.Name()
+}
+
+func (c *WebAuthn) Name() string {
+	return authn.ClientWebAuthn
+}
+
+func (c *WebAuthn) Authenticate(ctx context.Context, r *authn.Request) (*authn.Identity, error) {
+	assertion := webauthn.AssertionResponse{}
+	if header := r.HTTPRequest.Header.Get(webauthn.AssertionHeader); header != "" {
+		if err := json.Unmarshal([]byte(header), &assertion); err != nil {
+			return nil, errBadWebAuthnAssertion.Errorf("failed to parse header: %w", err)
+		}
+	} else if err := web.Bind(r.HTTPRequest, &assertion); err != nil {
+		return nil, errBadWebAuthnAssertion.Errorf("failed to parse request: %w", err)
+	}
+
+	userID, err := c.webauthnService.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: assertion})
+	if err != nil {
+		return nil, err
+	}
+	r.SetMeta(authn.MetaKeyAuthModule, "grafana")
+
+	signedInUser, err := c.userService.GetSignedInUserWithCacheCtx(ctx, &user.GetSignedInUserQuery{OrgID: r.OrgID, UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	if c.mfaService.IsEnabled() {
+		err := c.mfaService.VerifyLogin(ctx, &mfa.VerifyLoginCommand{
+			UserID: userID,
+			Login:  signedInUser.Login,
+			Code:   r.HTTPRequest.Header.Get(mfa.CodeHeader),
+		})
+		if err != nil {
+			if errors.Is(err, mfa.ErrInvalidCode) {
+				_ = c.loginAttempts.Add(ctx, signedInUser.Login, web.ClientIP(r.HTTPRequest, c.cfg.TrustedProxies))
+			}
+			return nil, err
+		}
+	}
+
+	return authn.IdentityFromSignedInUser(authn.NamespacedID(authn.NamespaceUser, signedInUser.UserID), signedInUser, authn.ClientParams{SyncPermissions: true}), nil
+}
+
+func (c *WebAuthn) Test(ctx context.Context, r *authn.Request) bool {
+	if r.HTTPRequest == nil {
+		return false
+	}
+	// basic authenticated requests send the assertion as second factor
+	return r.HTTPRequest.Header.Get(webauthn.AssertionHeader) != "" && !looksLikeBasicAuthRequest(r)
+}
+
+func (c *WebAuthn) Priority() uint {
+	return 45
+}
+
+var _ authn.PasswordClient = new(WebAuthnSecondFactor)
+
+// ProvideWebAuthnSecondFactor wraps a password client so Grafana users with WebAuthn credentials
+// must complete an assertion after their password
+func ProvideWebAuthnSecondFactor(cfg *setting.Cfg, webauthnService webauthn.Service, loginAttempts loginattempt.Service, client authn.PasswordClient) *WebAuthnSecondFactor {
+	return &WebAuthnSecondFactor{cfg, webauthnService, loginAttempts, client}
+}
+
+type WebAuthnSecondFactor struct {
+	cfg             *setting.Cfg
+	webauthnService webauthn.Service
+	loginAttempts   loginattempt.Service
+	client          authn.PasswordClient
+}
+
+func (c *WebAuthnSecondFactor) AuthenticatePassword(ctx context.Context, r *authn.Request, username, password string) (*authn.Identity, error) {
+	identity, err := c.client.AuthenticatePassword(ctx, r, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// WebAuthn credentials are only registered by Grafana users
+	if r.GetMeta(authn.MetaKeyAuthModule) != "grafana" {
+		return identity, nil
+	}
+
+	_, userID := identity.NamespacedID()
+	raw := r.GetMeta(authn.MetaKeyWebAuthnAssertion)
+	if raw == "" {
+		has, err := c.webauthnService.HasCredentials(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if has {
+			return nil, webauthn.ErrAssertionRequired.Errorf("user has WebAuthn credentials")
+		}
+		return identity, nil
+	}
+
+	assertion := webauthn.AssertionResponse{}
+	if err := json.Unmarshal([]byte(raw), &assertion); err != nil {
+		return nil, errBadWebAuthnAssertion.Errorf("failed to parse assertion: %w", err)
+	}
+	if _, err := c.webauthnService.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: assertion, UserID: userID}); err != nil {
+		if errors.Is(err, webauthn.ErrInvalidAssertion) {
+			_ = c.loginAttempts.Add(ctx, username, web.ClientIP(r.HTTPRequest, c.cfg.TrustedProxies))
+		}
+		return nil, err
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,228 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/authn/authntest"
+	"github.com/grafana/grafana/pkg/services/loginattempt/loginattempttest"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/services/mfa/mfatest"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+	"github.com/grafana/grafana/pkg/services/webauthn/webauthntest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const testAssertion = `{"id":"cred","rawId":"cred","type":"public-key","response":{"clientDataJSON":"data","authenticatorData":"auth","signature":"sig","userHandle":"MQ"}}`
+
+func TestWebAuthn_Authenticate(t *testing.T) {
+	type TestCase struct {
+		desc             string
+		header           string
+		body             string
+		mfaCode          string
+		webauthnErr      error
+		mfaErr           error
+		expectedErr      error
+		expectedCmdID    string
+		expectedAttempts bool
+	}
+
+	tests := []TestCase{
+		{
+			desc:          "should authenticate assertion in body",
+			body:          testAssertion,
+			expectedCmdID: "cred",
+		},
+		{
+			desc:          "should authenticate assertion in header",
+			header:        testAssertion,
+			expectedCmdID: "cred",
+		},
+		{
+			desc:        "should fail on malformed header",
+			header:      "not json",
+			expectedErr: errBadWebAuthnAssertion,
+		},
+		{
+			desc:          "should fail on invalid assertion",
+			body:          testAssertion,
+			webauthnErr:   webauthn.ErrInvalidAssertion.Errorf("invalid signature"),
+			expectedErr:   webauthn.ErrInvalidAssertion,
+			expectedCmdID: "cred",
+		},
+		{
+			desc:          "should fail when multi-factor authentication is required",
+			body:          testAssertion,
+			mfaErr:        mfa.ErrCodeRequired.Errorf("code required"),
+			expectedErr:   mfa.ErrCodeRequired,
+			expectedCmdID: "cred",
+		},
+		{
+			desc:             "should count invalid multi-factor authentication codes as failed attempts",
+			body:             testAssertion,
+			mfaCode:          "000000",
+			mfaErr:           mfa.ErrInvalidCode.Errorf("invalid code"),
+			expectedErr:      mfa.ErrInvalidCode,
+			expectedCmdID:    "cred",
+			expectedAttempts: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			webauthnService := &webauthntest.FakeService{ExpectedUserID: 1, ExpectedErr: tt.webauthnErr}
+			userService := &usertest.FakeUserService{ExpectedSignedInUser: &user.SignedInUser{UserID: 1, OrgID: 1, Login: "test"}}
+			mfaService := &mfatest.FakeService{ExpectedEnabled: true, ExpectedErr: tt.mfaErr}
+			loginAttempts := &loginattempttest.MockLoginAttemptService{}
+			c := ProvideWebAuthn(setting.NewCfg(), webauthnService, mfaService, loginAttempts, userService)
+
+			req, err := http.NewRequest(http.MethodPost, "/login/webauthn", strings.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				req.Header.Set(webauthn.AssertionHeader, tt.header)
+			}
+			if tt.mfaCode != "" {
+				req.Header.Set(mfa.CodeHeader, tt.mfaCode)
+			}
+
+			identity, err := c.Authenticate(context.Background(), &authn.Request{HTTPRequest: req})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, identity)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "user:1", identity.ID)
+				assert.Equal(t, "test", identity.Login)
+			}
+			if tt.expectedCmdID != "" {
+				require.NotNil(t, webauthnService.FinishLoginCmd)
+				assert.Equal(t, tt.expectedCmdID, webauthnService.FinishLoginCmd.Assertion.ID)
+				assert.Zero(t, webauthnService.FinishLoginCmd.UserID)
+			}
+			if tt.webauthnErr == nil && tt.expectedErr != errBadWebAuthnAssertion {
+				require.NotNil(t, mfaService.VerifyLoginCmd)
+				assert.Equal(t, int64(1), mfaService.VerifyLoginCmd.UserID)
+				assert.Equal(t, tt.mfaCode, mfaService.VerifyLoginCmd.Code)
+				assert.False(t, mfaService.VerifyLoginCmd.BasicAuth)
+			}
+			assert.Equal(t, tt.expectedAttempts, loginAttempts.AddCalled)
+		})
+	}
+}
+
+func TestWebAuthn_Test(t *testing.T) {
+	c := ProvideWebAuthn(setting.NewCfg(), &webauthntest.FakeService{}, &mfatest.FakeService{}, &loginattempttest.FakeLoginAttemptService{}, &usertest.FakeUserService{})
+
+	req := &authn.Request{HTTPRequest: &http.Request{Header: http.Header{}}}
+	assert.False(t, c.Test(context.Background(), req))
+
+	req.HTTPRequest.Header.Set(webauthn.AssertionHeader, testAssertion)
+	assert.True(t, c.Test(context.Background(), req))
+
+	// the assertion is the second factor of basic authenticated requests
+	req.HTTPRequest.SetBasicAuth("user", "password")
+	assert.False(t, c.Test(context.Background(), req))
+}
+
+func TestWebAuthnSecondFactor_AuthenticatePassword(t *testing.T) {
+	type TestCase struct {
+		desc             string
+		authModule       string
+		assertion        string
+		credentials      bool
+		clientErr        error
+		webauthnErr      error
+		expectedErr      error
+		expectedUserID   int64
+		expectedAttempts bool
+	}
+
+	tests := []TestCase{
+		{
+			desc:       "should accept grafana users without credentials",
+			authModule: "grafana",
+		},
+		{
+			desc:        "should require an assertion of grafana users with credentials",
+			authModule:  "grafana",
+			credentials: true,
+			expectedErr: webauthn.ErrAssertionRequired,
+		},
+		{
+			desc:           "should verify the assertion of the user",
+			authModule:     "grafana",
+			assertion:      testAssertion,
+			credentials:    true,
+			expectedUserID: 1,
+		},
+		{
+			desc:        "should skip users of other auth modules",
+			authModule:  "ldap",
+			credentials: true,
+		},
+		{
+			desc:        "should not verify when the password client fails",
+			authModule:  "grafana",
+			assertion:   testAssertion,
+			clientErr:   errInvalidPassword,
+			expectedErr: errInvalidPassword,
+		},
+		{
+			desc:             "should record login attempt on invalid assertion",
+			authModule:       "grafana",
+			assertion:        testAssertion,
+			credentials:      true,
+			webauthnErr:      webauthn.ErrInvalidAssertion.Errorf("invalid signature"),
+			expectedErr:      webauthn.ErrInvalidAssertion,
+			expectedUserID:   1,
+			expectedAttempts: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			webauthnService := &webauthntest.FakeService{ExpectedErr: tt.webauthnErr}
+			if tt.credentials {
+				webauthnService.ExpectedCredentials = []*webauthn.CredentialDTO{{ID: 1}}
+			}
+			loginAttempts := &loginattempttest.MockLoginAttemptService{}
+			client := authntest.FakePasswordClient{
+				ExpectedIdentity: &authn.Identity{ID: "user:1", Login: "test"},
+				ExpectedErr:      tt.clientErr,
+			}
+			c := ProvideWebAuthnSecondFactor(setting.NewCfg(), webauthnService, loginAttempts, client)
+
+			req := &authn.Request{HTTPRequest: &http.Request{}}
+			req.SetMeta(authn.MetaKeyAuthModule, tt.authModule)
+			if tt.assertion != "" {
+				req.SetMeta(authn.MetaKeyWebAuthnAssertion, tt.assertion)
+			}
+
+			identity, err := c.AuthenticatePassword(context.Background(), req, "test", "password")
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, identity)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "user:1", identity.ID)
+			}
+			if tt.expectedUserID != 0 {
+				require.NotNil(t, webauthnService.FinishLoginCmd)
+				assert.Equal(t, tt.expectedUserID, webauthnService.FinishLoginCmd.UserID)
+			} else {
+				assert.Nil(t, webauthnService.FinishLoginCmd)
+			}
+			assert.Equal(t, tt.expectedAttempts, loginAttempts.AddCalled)
+		})
+	}
+}
@@ -69,8 +69,13 @@ func (h *ContextHandler) initContextWithJWT(ctx *contextmodel.ReqContext, orgId
 		AuthModule: "jwt",
 		AuthId:     sub,
 		OrgRoles:   map[int64]org.RoleType{},
-		// we do not want to sync team memberships from JWT authentication see - https://github.com/grafana/grafana/issues/62175
-		SkipTeamSync: true,
+		// we do not want to sync team memberships from JWT authentication unless the groups claim is configured
+		// see - https://github.com/grafana/grafana/issues/62175
+		SkipTeamSync: h.Cfg.JWTAuthGroupsAttributePath == "",
+	}
+
+	if h.Cfg.JWTAuthGroupsAttributePath != "" {
+		extUser.Groups, _ = searchClaimsForStringArrayAttr(h.Cfg.JWTAuthGroupsAttributePath, claims)
 	}
 
 	if key := h.Cfg.JWTAuthUsernameClaim; key != "" {
@@ -195,6 +200,27 @@ func searchClaimsForAttr(attributePath string, claims map[string]interface{}) (i
 	return val, nil
 }
 
+func searchClaimsForStringArrayAttr(attributePath string, claims map[string]interface{}) ([]string, error) {
+	val, err := searchClaimsForAttr(attributePath, claims)
+	if err != nil {
+		return []string{}, err
+	}
+
+	ifArr, ok := val.([]interface{})
+	if !ok {
+		return []string{}, nil
+	}
+
+	result := []string{}
+	for _, v := range ifArr {
+		if strVal, ok := v.(string); ok {
+			result = append(result, strVal)
+		}
+	}
+
+	return result, nil
+}
+
 func searchClaimsForStringAttr(attributePath string, claims map[string]interface{}) (string, error) {
 	val, err := searchClaimsForAttr(attributePath, claims)
 	if err != nil {
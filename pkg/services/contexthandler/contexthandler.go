@@ -435,9 +435,10 @@ func (h *ContextHandler) initContextWithBasicAuth(reqContext *contextmodel.ReqCo
 	}
 
 	authQuery := login.LoginUserQuery{
-		Username: username,
-		Password: password,
-		Cfg:      h.Cfg,
+		Username:  username,
+		Password:  password,
+		Cfg:       h.Cfg,
+		IpAddress: web.ClientIP(reqContext.Req, h.Cfg.TrustedProxies),
 	}
 	if err := h.authenticator.AuthenticateUser(reqContext.Req.Context(), &authQuery); err != nil {
 		reqContext.Logger.Debug(
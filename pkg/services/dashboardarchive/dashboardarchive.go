@@ -0,0 +1,15 @@
+package dashboardarchive
+
+import (
+	"context"
+	"io"
+)
+
+// Service exports folders, with their dashboards, library panels, permissions and alert rules,
+// to a zip archive that can be imported in another organization or instance.
+type Service interface {
+	// Export writes the archive of the folders to w.
+	Export(ctx context.Context, cmd *ExportCommand, w io.Writer) (*Manifest, error)
+	// Import creates or updates the objects of an archive, and reports the result of each object.
+	Import(ctx context.Context, cmd *ImportCommand) (*ImportResult, error)
+}
@@ -0,0 +1,96 @@
+package dashboardarchiveimpl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboardarchive"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// maxArchiveSize is the maximum size of an uploaded archive
+const maxArchiveSize = 100 << 20
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+
+	routeRegister.Group("/api/dashboards/archive", func(archiveRoute routing.RouteRegister) {
+		archiveRoute.Post("/export", authorize(ac.EvalPermission(dashboards.ActionFoldersRead)), routing.Wrap(s.exportHandler))
+		archiveRoute.Post("/import", authorize(ac.EvalPermission(dashboards.ActionDashboardsCreate)), routing.Wrap(s.importHandler))
+	}, middleware.ReqSignedIn)
+}
+
+func (s *Service) exportHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := dashboardarchive.ExportCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgID
+	cmd.SignedInUser = c.SignedInUser
+
+	buf := &bytes.Buffer{}
+	manifest, err := s.Export(c.Req.Context(), &cmd, buf)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to export dashboards", err)
+	}
+
+	filename := fmt.Sprintf("grafana-dashboards-%s.zip", manifest.Exported.Format("20060102-150405"))
+	return response.Respond(http.StatusOK, buf.Bytes()).
+		SetHeader("Content-Type", "application/zip").
+		SetHeader("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+}
+
+// importHandler imports a multipart form with the archive in the archive field, the mapping of
+// inputs to data sources as JSON in the dataSources field and the overwrite flag.
+func (s *Service) importHandler(c *contextmodel.ReqContext) response.Response {
+	limitReached, err := s.quotaService.QuotaReached(c, dashboards.QuotaTargetSrv)
+	if err != nil {
+		return response.Err(err)
+	}
+	if limitReached {
+		return response.Error(http.StatusForbidden, "Quota reached", nil)
+	}
+
+	c.Req.Body = http.MaxBytesReader(c.Resp, c.Req.Body, maxArchiveSize)
+	if err := c.Req.ParseMultipartForm(maxArchiveSize); err != nil {
+		return response.Err(dashboardarchive.ErrArchiveTooLarge.Errorf("failed to read form: %w", err))
+	}
+	file, header, err := c.Req.FormFile("archive")
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Archive is missing", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	cmd := dashboardarchive.ImportCommand{
+		OrgID:        c.OrgID,
+		Archive:      file,
+		Size:         header.Size,
+		DataSources:  map[string]string{},
+		SignedInUser: c.SignedInUser,
+	}
+	if value := c.Req.FormValue("dataSources"); value != "" {
+		if err := json.Unmarshal([]byte(value), &cmd.DataSources); err != nil {
+			return response.Error(http.StatusBadRequest, "Invalid data sources", err)
+		}
+	}
+	if value := c.Req.FormValue("overwrite"); value != "" {
+		if cmd.Overwrite, err = strconv.ParseBool(value); err != nil {
+			return response.Error(http.StatusBadRequest, "Invalid overwrite", err)
+		}
+	}
+
+	result, err := s.Import(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to import archive", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
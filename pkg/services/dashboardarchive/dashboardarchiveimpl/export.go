@@ -0,0 +1,405 @@
+package dashboardarchiveimpl
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboardarchive"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/libraryelements/model"
+	"github.com/grafana/grafana/pkg/services/ngalert/api"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	searchmodel "github.com/grafana/grafana/pkg/services/search/model"
+)
+
+const searchPageSize = 1000
+
+// libraryPanelFile is the content of the file of a library panel
+type libraryPanelFile struct {
+	UID   string          `json:"uid"`
+	Name  string          `json:"name"`
+	Kind  int64           `json:"kind"`
+	Model json.RawMessage `json:"model"`
+}
+
+type exporter struct {
+	s        *Service
+	cmd      *dashboardarchive.ExportCommand
+	inputs   *inputCollector
+	zip      *zip.Writer
+	manifest *dashboardarchive.Manifest
+}
+
+// Export writes the archive of the folders readable by the user. Dashboards, library panels and
+// alert rules the user can't read are left out.
+func (s *Service) Export(ctx context.Context, cmd *dashboardarchive.ExportCommand, w io.Writer) (*dashboardarchive.Manifest, error) {
+	folders, err := s.exportedFolders(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &exporter{
+		s:      s,
+		cmd:    cmd,
+		inputs: newInputCollector(s.dataSourceService, cmd.OrgID),
+		zip:    zip.NewWriter(w),
+		manifest: &dashboardarchive.Manifest{
+			Version:        dashboardarchive.Version,
+			Exported:       time.Now().UTC(),
+			GrafanaVersion: s.cfg.BuildVersion,
+			Folders:        []dashboardarchive.FolderEntry{},
+			Dashboards:     []dashboardarchive.ObjectEntry{},
+			LibraryPanels:  []dashboardarchive.ObjectEntry{},
+			AlertRules:     []dashboardarchive.ObjectEntry{},
+		},
+	}
+
+	for _, f := range folders {
+		if f.UID == folder.GeneralFolderUID {
+			continue
+		}
+		permissions, err := s.exportPermissions(ctx, s.folderPermissionsService, cmd, f.UID)
+		if err != nil {
+			return nil, err
+		}
+		e.manifest.Folders = append(e.manifest.Folders, dashboardarchive.FolderEntry{UID: f.UID, Title: f.Title, Permissions: permissions})
+	}
+	if err := e.exportDashboards(ctx, folders); err != nil {
+		return nil, err
+	}
+	if err := e.exportLibraryPanels(ctx, folders); err != nil {
+		return nil, err
+	}
+	if err := e.exportAlertRules(ctx, folders); err != nil {
+		return nil, err
+	}
+
+	e.manifest.Inputs = e.inputs.inputs()
+	if err := e.writeJSON(dashboardarchive.ManifestFile, e.manifest); err != nil {
+		return nil, err
+	}
+	if err := e.zip.Close(); err != nil {
+		return nil, err
+	}
+	return e.manifest, nil
+}
+
+// exportedFolders returns the folders to export, sorted by title. The General folder has the
+// general UID and a zero ID.
+func (s *Service) exportedFolders(ctx context.Context, cmd *dashboardarchive.ExportCommand) ([]*folder.Folder, error) {
+	general := &folder.Folder{UID: folder.GeneralFolderUID, Title: "General"}
+	result := []*folder.Folder{}
+
+	if len(cmd.FolderUIDs) == 0 {
+		result = append(result, general)
+		for page := int64(1); ; page++ {
+			hits, err := s.dashboardService.FindDashboards(ctx, &dashboards.FindPersistedDashboardsQuery{
+				OrgId:        cmd.OrgID,
+				SignedInUser: cmd.SignedInUser,
+				Type:         string(searchmodel.DashHitFolder),
+				Limit:        searchPageSize,
+				Page:         page,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, hit := range hits {
+				result = append(result, &folder.Folder{ID: hit.ID, UID: hit.UID, Title: hit.Title})
+			}
+			if len(hits) < searchPageSize {
+				break
+			}
+		}
+	} else {
+		seen := map[string]bool{}
+		for _, uid := range cmd.FolderUIDs {
+			if seen[uid] {
+				continue
+			}
+			seen[uid] = true
+			if uid == folder.GeneralFolderUID {
+				result = append(result, general)
+				continue
+			}
+			uid := uid
+			f, err := s.folderService.Get(ctx, &folder.GetFolderQuery{UID: &uid, OrgID: cmd.OrgID, SignedInUser: cmd.SignedInUser})
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, f)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if (result[i].ID == 0) != (result[j].ID == 0) {
+			return result[i].ID == 0
+		}
+		return result[i].Title < result[j].Title
+	})
+	return result, nil
+}
+
+func (e *exporter) exportDashboards(ctx context.Context, folders []*folder.Folder) error {
+	for _, f := range folders {
+		for page := int64(1); ; page++ {
+			hits, err := e.s.dashboardService.FindDashboards(ctx, &dashboards.FindPersistedDashboardsQuery{
+				OrgId:        e.cmd.OrgID,
+				SignedInUser: e.cmd.SignedInUser,
+				Type:         string(searchmodel.DashHitDB),
+				FolderIds:    []int64{f.ID},
+				Limit:        searchPageSize,
+				Page:         page,
+			})
+			if err != nil {
+				return err
+			}
+			for _, hit := range hits {
+				if err := e.exportDashboard(ctx, f, hit.UID); err != nil {
+					return err
+				}
+			}
+			if len(hits) < searchPageSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (e *exporter) exportDashboard(ctx context.Context, f *folder.Folder, uid string) error {
+	dash, err := e.s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: uid, OrgID: e.cmd.OrgID})
+	if err != nil {
+		return err
+	}
+	data, err := copyJSON(dash.Data)
+	if err != nil {
+		return err
+	}
+	// The ID is specific to this instance
+	delete(data, "id")
+
+	used := map[string]bool{}
+	if err := e.inputs.replaceInDashboard(ctx, data, used); err != nil {
+		return err
+	}
+	data["__inputs"] = e.usedInputs(used)
+
+	permissions, err := e.s.exportPermissions(ctx, e.s.dashboardPermissionsService, e.cmd, uid)
+	if err != nil {
+		return err
+	}
+	entry := dashboardarchive.ObjectEntry{
+		UID:         uid,
+		Title:       dash.Title,
+		FolderUID:   folderUID(f),
+		File:        "dashboards/" + uid + ".json",
+		Inputs:      inputNames(used),
+		Permissions: permissions,
+	}
+	e.manifest.Dashboards = append(e.manifest.Dashboards, entry)
+	return e.writeJSON(entry.File, data)
+}
+
+func (e *exporter) exportLibraryPanels(ctx context.Context, folders []*folder.Folder) error {
+	ids := make([]string, 0, len(folders))
+	byID := map[int64]*folder.Folder{}
+	for _, f := range folders {
+		ids = append(ids, strconv.FormatInt(f.ID, 10))
+		byID[f.ID] = f
+	}
+
+	for page := 1; ; page++ {
+		result, err := e.s.libraryElementService.GetAllElements(ctx, e.cmd.SignedInUser, model.SearchLibraryElementsQuery{
+			Kind:         int(model.PanelElement),
+			FolderFilter: strings.Join(ids, ","),
+			PerPage:      searchPageSize,
+			Page:         page,
+		})
+		if err != nil {
+			return err
+		}
+		for _, element := range result.Elements {
+			if err := e.exportLibraryPanel(ctx, byID[element.FolderID], element); err != nil {
+				return err
+			}
+		}
+		if len(result.Elements) < searchPageSize {
+			return nil
+		}
+	}
+}
+
+func (e *exporter) exportLibraryPanel(ctx context.Context, f *folder.Folder, element model.LibraryElementDTO) error {
+	panel := map[string]interface{}{}
+	if err := json.Unmarshal(element.Model, &panel); err != nil {
+		return err
+	}
+	used := map[string]bool{}
+	if err := e.inputs.replaceInPanel(ctx, panel, used); err != nil {
+		return err
+	}
+	panelModel, err := json.Marshal(panel)
+	if err != nil {
+		return err
+	}
+
+	entry := dashboardarchive.ObjectEntry{
+		UID:       element.UID,
+		Title:     element.Name,
+		FolderUID: folderUID(f),
+		File:      "library-panels/" + element.UID + ".json",
+		Inputs:    inputNames(used),
+	}
+	e.manifest.LibraryPanels = append(e.manifest.LibraryPanels, entry)
+	return e.writeJSON(entry.File, libraryPanelFile{UID: element.UID, Name: element.Name, Kind: element.Kind, Model: panelModel})
+}
+
+// exportAlertRules exports the alert rules of the folders the user can read the rules of. Alert
+// rules are stored in the format of the provisioning API.
+func (e *exporter) exportAlertRules(ctx context.Context, folders []*folder.Folder) error {
+	uids := make([]string, 0, len(folders))
+	for _, f := range folders {
+		if f.UID == folder.GeneralFolderUID {
+			continue
+		}
+		evaluator := ac.EvalPermission(ac.ActionAlertingRuleRead, dashboards.ScopeFoldersProvider.GetResourceScopeUID(f.UID))
+		allowed, err := e.s.accessControl.Evaluate(ctx, e.cmd.SignedInUser, evaluator)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			uids = append(uids, f.UID)
+		}
+	}
+	if len(uids) == 0 {
+		return nil
+	}
+
+	rules, err := e.s.alertRuleStore.ListAlertRules(ctx, &ngmodels.ListAlertRulesQuery{OrgID: e.cmd.OrgID, NamespaceUIDs: uids})
+	if err != nil {
+		return err
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].UID < rules[j].UID })
+	for _, rule := range rules {
+		provisioned := api.ProvisionedAlertRuleFromAlertRule(*rule, ngmodels.ProvenanceNone)
+		raw, err := json.Marshal(provisioned)
+		if err != nil {
+			return err
+		}
+		data := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return err
+		}
+		// These are specific to this instance
+		for _, key := range []string{"id", "orgID", "updated", "provenance"} {
+			delete(data, key)
+		}
+
+		used := map[string]bool{}
+		if err := e.inputs.replaceInAlertRule(ctx, data, used); err != nil {
+			return err
+		}
+		entry := dashboardarchive.ObjectEntry{
+			UID:       rule.UID,
+			Title:     rule.Title,
+			FolderUID: rule.NamespaceUID,
+			File:      "alert-rules/" + rule.UID + ".json",
+			Inputs:    inputNames(used),
+		}
+		e.manifest.AlertRules = append(e.manifest.AlertRules, entry)
+		if err := e.writeJSON(entry.File, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// usedInputs returns the definitions of the inputs of an object, as stored in dashboards.
+func (e *exporter) usedInputs(used map[string]bool) []dashboardarchive.Input {
+	result := []dashboardarchive.Input{}
+	for _, input := range e.inputs.inputs() {
+		if used[input.Name] {
+			result = append(result, input)
+		}
+	}
+	return result
+}
+
+func (e *exporter) writeJSON(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	w, err := e.zip.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// exportPermissions returns the permissions managed on a folder or dashboard. Permissions
+// inherited from folders are exported with the folders.
+func (s *Service) exportPermissions(ctx context.Context, permissionsService ac.PermissionsService, cmd *dashboardarchive.ExportCommand, uid string) ([]dashboardarchive.Permission, error) {
+	permissions, err := permissionsService.GetPermissions(ctx, cmd.SignedInUser, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dashboardarchive.Permission, 0, len(permissions))
+	for _, p := range permissions {
+		if !p.IsManaged || p.IsInherited {
+			continue
+		}
+		permission := dashboardarchive.Permission{Permission: permissionsService.MapActions(p)}
+		switch {
+		case p.UserId != 0:
+			permission.UserLogin = p.UserLogin
+		case p.TeamId != 0:
+			permission.Team = p.Team
+		case p.BuiltInRole != "":
+			permission.BuiltInRole = p.BuiltInRole
+		default:
+			continue
+		}
+		if permission.Permission == "" {
+			continue
+		}
+		result = append(result, permission)
+	}
+	return result, nil
+}
+
+func copyJSON(data interface{ MarshalJSON() ([]byte, error) }) (map[string]interface{}, error) {
+	raw, err := data.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{}
+	return result, json.Unmarshal(raw, &result)
+}
+
+func inputNames(used map[string]bool) []string {
+	result := make([]string, 0, len(used))
+	for name := range used {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// folderUID returns the UID of a folder in an archive, which is empty for the General folder.
+func folderUID(f *folder.Folder) string {
+	if f == nil || f.UID == folder.GeneralFolderUID {
+		return ""
+	}
+	return f.UID
+}
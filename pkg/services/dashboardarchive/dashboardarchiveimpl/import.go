@@ -0,0 +1,463 @@
+package dashboardarchiveimpl
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboardarchive"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/libraryelements/model"
+	"github.com/grafana/grafana/pkg/services/ngalert/api"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// maxFileSize is the maximum size of a file of an archive once uncompressed
+const maxFileSize = 50 << 20
+
+type importer struct {
+	s        *Service
+	cmd      *dashboardarchive.ImportCommand
+	files    map[string]*zip.File
+	manifest *dashboardarchive.Manifest
+	result   *dashboardarchive.ImportResult
+
+	inputs map[string]dashboardarchive.Input
+	// unresolved are the inputs without data source, with the reason
+	unresolved map[string]string
+	// folders are the IDs of the imported folders by UID, the failed folders are missing
+	folders map[string]int64
+}
+
+// Import imports the folders of an archive, then their library panels, dashboards and alert
+// rules. Objects are imported with the permissions of the user, and the import of an object
+// doesn't stop when another object fails.
+func (s *Service) Import(ctx context.Context, cmd *dashboardarchive.ImportCommand) (*dashboardarchive.ImportResult, error) {
+	reader, err := zip.NewReader(cmd.Archive, cmd.Size)
+	if err != nil {
+		return nil, dashboardarchive.ErrInvalidArchive.Errorf("failed to read archive: %w", err)
+	}
+
+	imp := &importer{
+		s:     s,
+		cmd:   cmd,
+		files: map[string]*zip.File{},
+		result: &dashboardarchive.ImportResult{
+			Results:     []*dashboardarchive.ObjectResult{},
+			DataSources: map[string]string{},
+		},
+		inputs:     map[string]dashboardarchive.Input{},
+		unresolved: map[string]string{},
+		folders:    map[string]int64{"": 0},
+	}
+	for _, f := range reader.File {
+		imp.files[f.Name] = f
+	}
+
+	manifest := &dashboardarchive.Manifest{}
+	if err := imp.readJSON(dashboardarchive.ManifestFile, manifest); err != nil {
+		return nil, dashboardarchive.ErrInvalidArchive.Errorf("failed to read manifest: %w", err)
+	}
+	if manifest.Version != dashboardarchive.Version {
+		return nil, dashboardarchive.ErrUnsupportedVersion.Errorf("unsupported archive version %d", manifest.Version)
+	}
+	imp.manifest = manifest
+
+	if err := imp.resolveInputs(ctx); err != nil {
+		return nil, err
+	}
+	for _, entry := range manifest.Folders {
+		imp.importFolder(ctx, entry)
+	}
+	for _, entry := range manifest.LibraryPanels {
+		imp.importLibraryPanel(ctx, entry)
+	}
+	for _, entry := range manifest.Dashboards {
+		imp.importDashboard(ctx, entry)
+	}
+	for _, entry := range manifest.AlertRules {
+		imp.importAlertRule(ctx, entry)
+	}
+
+	for _, r := range imp.result.Results {
+		if r.Status == dashboardarchive.StatusFailed {
+			imp.result.Failed++
+		}
+	}
+	return imp.result, nil
+}
+
+// resolveInputs finds the data sources of the inputs: the data source they are mapped to, or
+// else the data source with the same name and type.
+func (imp *importer) resolveInputs(ctx context.Context) error {
+	for _, input := range imp.manifest.Inputs {
+		imp.inputs[input.Name] = input
+
+		query := &datasources.GetDataSourceQuery{OrgID: imp.cmd.OrgID, Name: input.Label}
+		uid, mapped := imp.cmd.DataSources[input.Name]
+		if mapped {
+			query = &datasources.GetDataSourceQuery{OrgID: imp.cmd.OrgID, UID: uid}
+		}
+
+		ds, err := imp.s.dataSourceService.GetDataSource(ctx, query)
+		switch {
+		case errors.Is(err, datasources.ErrDataSourceNotFound) && mapped:
+			imp.unresolved[input.Name] = fmt.Sprintf("data source %q not found", uid)
+		case errors.Is(err, datasources.ErrDataSourceNotFound), err == nil && !mapped && ds.Type != input.PluginID:
+			imp.unresolved[input.Name] = fmt.Sprintf("no %s data source mapped to %s", input.PluginID, input.Name)
+		case err != nil:
+			return err
+		default:
+			imp.result.DataSources[input.Name] = ds.UID
+		}
+	}
+	return nil
+}
+
+func (imp *importer) importFolder(ctx context.Context, entry dashboardarchive.FolderEntry) {
+	result := imp.addResult(dashboardarchive.KindFolder, entry.UID, entry.Title)
+
+	uid := entry.UID
+	f, err := imp.s.folderService.Get(ctx, &folder.GetFolderQuery{UID: &uid, OrgID: imp.cmd.OrgID, SignedInUser: imp.cmd.SignedInUser})
+	switch {
+	case err == nil:
+		result.Status = dashboardarchive.StatusExists
+	case errors.Is(err, dashboards.ErrFolderNotFound):
+		f, err = imp.s.folderService.Create(ctx, &folder.CreateFolderCommand{
+			UID:          entry.UID,
+			OrgID:        imp.cmd.OrgID,
+			Title:        entry.Title,
+			SignedInUser: imp.cmd.SignedInUser,
+		})
+		if err != nil {
+			result.fail(err)
+			return
+		}
+		result.Status = dashboardarchive.StatusCreated
+	default:
+		result.fail(err)
+		return
+	}
+	imp.folders[entry.UID] = f.ID
+
+	scope := dashboards.ScopeFoldersProvider.GetResourceScopeUID(entry.UID)
+	imp.setPermissions(ctx, result, imp.s.folderPermissionsService, dashboards.ActionFoldersPermissionsWrite, scope, entry.UID, entry.Permissions)
+}
+
+func (imp *importer) importLibraryPanel(ctx context.Context, entry dashboardarchive.ObjectEntry) {
+	result := imp.addResult(dashboardarchive.KindLibraryPanel, entry.UID, entry.Title)
+	folderID, data, err := imp.prepare(entry)
+	if err != nil {
+		result.fail(err)
+		return
+	}
+	file := libraryPanelFile{}
+	if err := unmarshal(data, &file); err != nil {
+		result.fail(err)
+		return
+	}
+
+	existing, err := imp.s.libraryElementService.GetElement(ctx, imp.cmd.SignedInUser, entry.UID)
+	switch {
+	case err == nil && !imp.cmd.Overwrite:
+		result.fail(model.ErrLibraryElementAlreadyExists)
+	case err == nil:
+		_, err = imp.s.libraryElementService.PatchLibraryElement(ctx, imp.cmd.SignedInUser, model.PatchLibraryElementCommand{
+			FolderID: folderID,
+			Name:     file.Name,
+			Model:    file.Model,
+			Kind:     file.Kind,
+			Version:  existing.Version,
+		}, entry.UID)
+		result.done(dashboardarchive.StatusUpdated, err)
+	case errors.Is(err, model.ErrLibraryElementNotFound):
+		_, err = imp.s.libraryElementService.CreateElement(ctx, imp.cmd.SignedInUser, model.CreateLibraryElementCommand{
+			FolderID: folderID,
+			Name:     file.Name,
+			Model:    file.Model,
+			Kind:     file.Kind,
+			UID:      entry.UID,
+		})
+		result.done(dashboardarchive.StatusCreated, err)
+	default:
+		result.fail(err)
+	}
+}
+
+func (imp *importer) importDashboard(ctx context.Context, entry dashboardarchive.ObjectEntry) {
+	result := imp.addResult(dashboardarchive.KindDashboard, entry.UID, entry.Title)
+	folderID, data, err := imp.prepare(entry)
+	if err != nil {
+		result.fail(err)
+		return
+	}
+	data.Del("__inputs")
+	data.Del("__requires")
+	data.Del("__elements")
+
+	status := dashboardarchive.StatusCreated
+	_, err = imp.s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: entry.UID, OrgID: imp.cmd.OrgID})
+	if err == nil {
+		status = dashboardarchive.StatusUpdated
+	} else if !errors.Is(err, dashboards.ErrDashboardNotFound) {
+		result.fail(err)
+		return
+	}
+
+	saveCmd := dashboards.SaveDashboardCommand{
+		Dashboard: data,
+		OrgID:     imp.cmd.OrgID,
+		UserID:    imp.cmd.SignedInUser.UserID,
+		Overwrite: imp.cmd.Overwrite,
+		FolderID:  folderID,
+	}
+	saved, err := imp.s.dashboardService.ImportDashboard(ctx, &dashboards.SaveDashboardDTO{
+		OrgID:     imp.cmd.OrgID,
+		Dashboard: saveCmd.GetDashboardModel(),
+		Overwrite: imp.cmd.Overwrite,
+		User:      imp.cmd.SignedInUser,
+	})
+	if err != nil {
+		result.fail(err)
+		return
+	}
+	if err := imp.s.libraryPanelService.ConnectLibraryPanelsForDashboard(ctx, imp.cmd.SignedInUser, saved); err != nil {
+		result.fail(err)
+		return
+	}
+	result.Status = status
+
+	scope := dashboards.ScopeDashboardsProvider.GetResourceScopeUID(entry.UID)
+	imp.setPermissions(ctx, result, imp.s.dashboardPermissionsService, dashboards.ActionDashboardsPermissionsWrite, scope, entry.UID, entry.Permissions)
+}
+
+func (imp *importer) importAlertRule(ctx context.Context, entry dashboardarchive.ObjectEntry) {
+	result := imp.addResult(dashboardarchive.KindAlertRule, entry.UID, entry.Title)
+	if entry.FolderUID == "" {
+		result.fail(errors.New("alert rules must be in a folder"))
+		return
+	}
+	_, data, err := imp.prepare(entry)
+	if err != nil {
+		result.fail(err)
+		return
+	}
+	provisioned := definitions.ProvisionedAlertRule{}
+	if err := unmarshal(data, &provisioned); err != nil {
+		result.fail(err)
+		return
+	}
+	provisioned.UID = entry.UID
+	provisioned.OrgID = imp.cmd.OrgID
+	provisioned.FolderUID = entry.FolderUID
+	rule, err := api.AlertRuleFromProvisionedAlertRule(provisioned)
+	if err != nil {
+		result.fail(err)
+		return
+	}
+
+	_, _, err = imp.s.alertRuleService.GetAlertRule(ctx, imp.cmd.OrgID, entry.UID)
+	exists := err == nil
+	if err != nil && !errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+		result.fail(err)
+		return
+	}
+	if exists && !imp.cmd.Overwrite {
+		result.fail(errors.New("an alert rule with the same uid already exists"))
+		return
+	}
+
+	action := ac.ActionAlertingRuleCreate
+	if exists {
+		action = ac.ActionAlertingRuleUpdate
+	}
+	scope := dashboards.ScopeFoldersProvider.GetResourceScopeUID(entry.FolderUID)
+	allowed, err := imp.s.accessControl.Evaluate(ctx, imp.cmd.SignedInUser, ac.EvalPermission(action, scope))
+	if err != nil {
+		result.fail(err)
+		return
+	}
+	if !allowed {
+		result.fail(errors.New("not allowed to change the alert rules of the folder"))
+		return
+	}
+
+	if exists {
+		_, err = imp.s.alertRuleService.UpdateAlertRule(ctx, rule, ngmodels.ProvenanceNone)
+		result.done(dashboardarchive.StatusUpdated, err)
+		return
+	}
+	_, err = imp.s.alertRuleService.CreateAlertRule(ctx, rule, ngmodels.ProvenanceNone, imp.cmd.SignedInUser.UserID)
+	result.done(dashboardarchive.StatusCreated, err)
+}
+
+// prepare returns the folder of an object and its content, with the inputs replaced by the data
+// sources they were resolved to.
+func (imp *importer) prepare(entry dashboardarchive.ObjectEntry) (int64, *simplejson.Json, error) {
+	folderID, ok := imp.folders[entry.FolderUID]
+	if !ok {
+		return 0, nil, fmt.Errorf("folder %q was not imported", entry.FolderUID)
+	}
+
+	inputs := make([]dashboardarchive.Input, 0, len(entry.Inputs))
+	for _, name := range entry.Inputs {
+		input, ok := imp.inputs[name]
+		if !ok {
+			return 0, nil, dashboardarchive.ErrInvalidArchive.Errorf("unknown input %q", name)
+		}
+		if reason, ok := imp.unresolved[name]; ok {
+			return 0, nil, fmt.Errorf("data source input %s is not resolved: %s", name, reason)
+		}
+		inputs = append(inputs, input)
+	}
+
+	raw, err := imp.readFile(entry.File)
+	if err != nil {
+		return 0, nil, err
+	}
+	data, err := simplejson.NewJson(raw)
+	if err != nil {
+		return 0, nil, dashboardarchive.ErrInvalidArchive.Errorf("invalid file %q: %w", entry.File, err)
+	}
+	data, err = interpolate(data, inputs, imp.result.DataSources)
+	if err != nil {
+		return 0, nil, err
+	}
+	return folderID, data, nil
+}
+
+// setPermissions adds the permissions of a folder or dashboard. Permissions of users and teams
+// which don't exist in the organization are reported as warnings.
+func (imp *importer) setPermissions(ctx context.Context, result *objectResult, permissionsService ac.PermissionsService, action, scope, uid string, permissions []dashboardarchive.Permission) {
+	if len(permissions) == 0 {
+		return
+	}
+	allowed, err := imp.s.accessControl.Evaluate(ctx, imp.cmd.SignedInUser, ac.EvalPermission(action, scope))
+	if err != nil || !allowed {
+		result.warn("not allowed to set permissions")
+		return
+	}
+
+	commands := make([]ac.SetResourcePermissionCommand, 0, len(permissions))
+	for _, p := range permissions {
+		cmd := ac.SetResourcePermissionCommand{Permission: p.Permission}
+		switch {
+		case p.UserLogin != "":
+			u, err := imp.s.userService.GetByLogin(ctx, &user.GetUserByLoginQuery{LoginOrEmail: p.UserLogin})
+			if err != nil {
+				result.warn(fmt.Sprintf("permission of user %q not imported: %s", p.UserLogin, err))
+				continue
+			}
+			// Users of other organizations are not granted access to this organization
+			if isMember, err := imp.isOrgMember(ctx, u.ID); err != nil || !isMember {
+				result.warn(fmt.Sprintf("permission of user %q not imported: user not found", p.UserLogin))
+				continue
+			}
+			cmd.UserID = u.ID
+		case p.Team != "":
+			teams, err := imp.s.teamService.SearchTeams(ctx, &team.SearchTeamsQuery{OrgID: imp.cmd.OrgID, Name: p.Team, Limit: 1, SignedInUser: imp.cmd.SignedInUser})
+			if err != nil || len(teams.Teams) == 0 {
+				result.warn(fmt.Sprintf("permission of team %q not imported: team not found", p.Team))
+				continue
+			}
+			cmd.TeamID = teams.Teams[0].ID
+		case p.BuiltInRole != "":
+			cmd.BuiltinRole = p.BuiltInRole
+		default:
+			continue
+		}
+		commands = append(commands, cmd)
+	}
+	if len(commands) == 0 {
+		return
+	}
+	if _, err := permissionsService.SetPermissions(ctx, imp.cmd.OrgID, uid, commands...); err != nil {
+		result.warn(fmt.Sprintf("failed to set permissions: %s", err))
+	}
+}
+
+func (imp *importer) isOrgMember(ctx context.Context, userID int64) (bool, error) {
+	orgs, err := imp.s.orgService.GetUserOrgList(ctx, &org.GetUserOrgListQuery{UserID: userID})
+	if err != nil {
+		return false, err
+	}
+	for _, o := range orgs {
+		if o.OrgID == imp.cmd.OrgID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (imp *importer) readFile(name string) ([]byte, error) {
+	f, ok := imp.files[name]
+	if !ok {
+		return nil, dashboardarchive.ErrInvalidArchive.Errorf("file %q not found", name)
+	}
+	if f.UncompressedSize64 > maxFileSize {
+		return nil, dashboardarchive.ErrArchiveTooLarge.Errorf("file %q is too large", name)
+	}
+	r, err := f.Open()
+	if err != nil {
+		return nil, dashboardarchive.ErrInvalidArchive.Errorf("failed to open %q: %w", name, err)
+	}
+	defer func() { _ = r.Close() }()
+	return io.ReadAll(io.LimitReader(r, maxFileSize))
+}
+
+func (imp *importer) readJSON(name string, v interface{}) error {
+	data, err := imp.readFile(name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (imp *importer) addResult(kind dashboardarchive.ObjectKind, uid, title string) *objectResult {
+	result := &objectResult{&dashboardarchive.ObjectResult{Kind: kind, UID: uid, Title: title}}
+	imp.result.Results = append(imp.result.Results, result.ObjectResult)
+	return result
+}
+
+type objectResult struct {
+	*dashboardarchive.ObjectResult
+}
+
+func (r *objectResult) fail(err error) {
+	r.Status = dashboardarchive.StatusFailed
+	r.Error = err.Error()
+}
+
+// done sets the status of the object, unless the import failed.
+func (r *objectResult) done(status dashboardarchive.ImportStatus, err error) {
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	r.Status = status
+}
+
+func (r *objectResult) warn(warning string) {
+	r.Warnings = append(r.Warnings, warning)
+	sort.Strings(r.Warnings)
+}
+
+func unmarshal(data *simplejson.Json, v interface{}) error {
+	raw, err := data.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
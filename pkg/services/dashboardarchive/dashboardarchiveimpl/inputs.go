@@ -0,0 +1,255 @@
+package dashboardarchiveimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/dashboardarchive"
+	"github.com/grafana/grafana/pkg/services/dashboardimport"
+	"github.com/grafana/grafana/pkg/services/dashboardimport/utils"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/tsdb/grafanads"
+)
+
+const inputTypeDataSource = "datasource"
+
+var inputNameRegex = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// inputCollector replaces the references to data sources of exported objects by inputs. Objects
+// are walked the same way as the dashboard exporter of the frontend does.
+type inputCollector struct {
+	dataSourceService datasources.DataSourceService
+	orgID             int64
+
+	byUID  map[string]*dashboardarchive.Input
+	byName map[string]*dashboardarchive.Input
+	names  map[string]bool
+}
+
+func newInputCollector(dataSourceService datasources.DataSourceService, orgID int64) *inputCollector {
+	return &inputCollector{
+		dataSourceService: dataSourceService,
+		orgID:             orgID,
+		byUID:             map[string]*dashboardarchive.Input{},
+		byName:            map[string]*dashboardarchive.Input{},
+		names:             map[string]bool{},
+	}
+}
+
+// inputs returns the inputs of all the data sources replaced, sorted by name.
+func (c *inputCollector) inputs() []dashboardarchive.Input {
+	result := make([]dashboardarchive.Input, 0, len(c.byUID))
+	for _, input := range c.byUID {
+		result = append(result, *input)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// input returns the input of a data source, found by UID or by name. It returns nil for data sources
+// which don't exist, which are left as they are.
+func (c *inputCollector) input(ctx context.Context, uid, name string) (*dashboardarchive.Input, error) {
+	if input, ok := c.byUID[uid]; ok && uid != "" {
+		return input, nil
+	}
+	if input, ok := c.byName[name]; ok && name != "" {
+		return input, nil
+	}
+
+	ds, err := c.dataSourceService.GetDataSource(ctx, &datasources.GetDataSourceQuery{UID: uid, Name: name, OrgID: c.orgID})
+	if errors.Is(err, datasources.ErrDataSourceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if input, ok := c.byUID[ds.UID]; ok {
+		return input, nil
+	}
+
+	base := "DS_" + strings.Trim(inputNameRegex.ReplaceAllString(strings.ToUpper(ds.Name), "_"), "_")
+	inputName := base
+	for i := 2; c.names[inputName]; i++ {
+		inputName = fmt.Sprintf("%s_%d", base, i)
+	}
+	input := &dashboardarchive.Input{
+		Name:       inputName,
+		Label:      ds.Name,
+		Type:       inputTypeDataSource,
+		PluginID:   ds.Type,
+		PluginName: ds.Type,
+	}
+	c.names[inputName] = true
+	c.byUID[ds.UID] = input
+	c.byName[ds.Name] = input
+	return input, nil
+}
+
+// replaceRef returns the reference to a data source, either its name or a {type, uid} object,
+// with the data source replaced by an input.
+func (c *inputCollector) replaceRef(ctx context.Context, ref interface{}, used map[string]bool) (interface{}, error) {
+	var uid, name string
+	switch v := ref.(type) {
+	case string:
+		if isBuiltInDataSource(v) {
+			return ref, nil
+		}
+		name = v
+	case map[string]interface{}:
+		uid, _ = v["uid"].(string)
+		if isBuiltInDataSource(uid) {
+			return ref, nil
+		}
+	default:
+		return ref, nil
+	}
+
+	input, err := c.input(ctx, uid, name)
+	if err != nil || input == nil {
+		return ref, err
+	}
+	used[input.Name] = true
+	return map[string]interface{}{"type": input.PluginID, "uid": "${" + input.Name + "}"}, nil
+}
+
+// replaceInDashboard replaces the data sources of the panels, variables and annotations.
+func (c *inputCollector) replaceInDashboard(ctx context.Context, dash map[string]interface{}, used map[string]bool) error {
+	if err := c.replaceInPanels(ctx, dash["panels"], used); err != nil {
+		return err
+	}
+	for _, key := range []string{"templating", "annotations"} {
+		section, _ := dash[key].(map[string]interface{})
+		list, _ := section["list"].([]interface{})
+		for _, item := range list {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			// Data source variables reference a type of data source, not a data source
+			if key == "templating" && obj["type"] == "datasource" {
+				continue
+			}
+			if err := c.replaceField(ctx, obj, "datasource", used); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *inputCollector) replaceInPanels(ctx context.Context, panels interface{}, used map[string]bool) error {
+	list, _ := panels.([]interface{})
+	for _, item := range list {
+		panel, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if err := c.replaceInPanel(ctx, panel, used); err != nil {
+			return err
+		}
+		// Collapsed rows contain their panels
+		if err := c.replaceInPanels(ctx, panel["panels"], used); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *inputCollector) replaceInPanel(ctx context.Context, panel map[string]interface{}, used map[string]bool) error {
+	if err := c.replaceField(ctx, panel, "datasource", used); err != nil {
+		return err
+	}
+	targets, _ := panel["targets"].([]interface{})
+	for _, item := range targets {
+		if target, ok := item.(map[string]interface{}); ok {
+			if err := c.replaceField(ctx, target, "datasource", used); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// replaceInAlertRule replaces the data sources of the queries of an alert rule.
+func (c *inputCollector) replaceInAlertRule(ctx context.Context, rule map[string]interface{}, used map[string]bool) error {
+	queries, _ := rule["data"].([]interface{})
+	for _, item := range queries {
+		query, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		uid, _ := query["datasourceUid"].(string)
+		if isBuiltInDataSource(uid) {
+			continue
+		}
+		input, err := c.input(ctx, uid, "")
+		if err != nil {
+			return err
+		}
+		if input == nil {
+			continue
+		}
+		used[input.Name] = true
+		query["datasourceUid"] = "${" + input.Name + "}"
+		if model, ok := query["model"].(map[string]interface{}); ok {
+			if err := c.replaceField(ctx, model, "datasource", used); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *inputCollector) replaceField(ctx context.Context, obj map[string]interface{}, key string, used map[string]bool) error {
+	ref, ok := obj[key]
+	if !ok {
+		return nil
+	}
+	replaced, err := c.replaceRef(ctx, ref, used)
+	if err != nil {
+		return err
+	}
+	obj[key] = replaced
+	return nil
+}
+
+// isBuiltInDataSource returns true for the references which aren't to a data source of the
+// organization: the default data source, variables, expressions and built-in data sources.
+func isBuiltInDataSource(ref string) bool {
+	switch ref {
+	case "", grafanads.DatasourceUID, grafanads.DatasourceName, expr.DatasourceUID, expr.OldDatasourceUID, "-- Mixed --", "-- Dashboard --":
+		return true
+	}
+	return strings.HasPrefix(ref, "$")
+}
+
+// interpolate replaces the inputs of an object by the data sources they are mapped to.
+func interpolate(obj *simplejson.Json, inputs []dashboardarchive.Input, dataSources map[string]string) (*simplejson.Json, error) {
+	defs := make([]interface{}, 0, len(inputs))
+	values := make([]dashboardimport.ImportDashboardInput, 0, len(inputs))
+	for _, input := range inputs {
+		defs = append(defs, map[string]interface{}{"name": input.Name, "type": input.Type, "pluginId": input.PluginID})
+		values = append(values, dashboardimport.ImportDashboardInput{
+			Type:     input.Type,
+			PluginId: input.PluginID,
+			Name:     input.Name,
+			Value:    dataSources[input.Name],
+		})
+	}
+
+	template := simplejson.NewFromAny(map[string]interface{}{
+		"__inputs": defs,
+		"object":   obj.Interface(),
+	})
+	result, err := utils.NewDashTemplateEvaluator(template, values).Eval()
+	if err != nil {
+		return nil, err
+	}
+	return result.Get("object"), nil
+}
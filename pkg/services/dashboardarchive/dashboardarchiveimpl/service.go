@@ -0,0 +1,104 @@
+package dashboardarchiveimpl
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	ngstore "github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// alertRuleService creates and updates alert rules the way the provisioning API does
+type alertRuleService interface {
+	GetAlertRule(ctx context.Context, orgID int64, ruleUID string) (ngmodels.AlertRule, ngmodels.Provenance, error)
+	CreateAlertRule(ctx context.Context, rule ngmodels.AlertRule, provenance ngmodels.Provenance, userID int64) (ngmodels.AlertRule, error)
+	UpdateAlertRule(ctx context.Context, rule ngmodels.AlertRule, provenance ngmodels.Provenance) (ngmodels.AlertRule, error)
+}
+
+type alertRuleStore interface {
+	ListAlertRules(ctx context.Context, query *ngmodels.ListAlertRulesQuery) (ngmodels.RulesGroup, error)
+}
+
+type Service struct {
+	cfg                         *setting.Cfg
+	accessControl               ac.AccessControl
+	dashboardService            dashboards.DashboardService
+	folderService               folder.Service
+	libraryElementService       libraryelements.Service
+	libraryPanelService         librarypanels.Service
+	dataSourceService           datasources.DataSourceService
+	folderPermissionsService    ac.FolderPermissionsService
+	dashboardPermissionsService ac.DashboardPermissionsService
+	userService                 user.Service
+	orgService                  org.Service
+	teamService                 team.Service
+	quotaService                quota.Service
+	alertRuleStore              alertRuleStore
+	alertRuleService            alertRuleService
+	log                         log.Logger
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sql db.DB,
+	routeRegister routing.RouteRegister,
+	accessControl ac.AccessControl,
+	dashboardService dashboards.DashboardService,
+	folderService folder.Service,
+	libraryElementService libraryelements.Service,
+	libraryPanelService librarypanels.Service,
+	dataSourceService datasources.DataSourceService,
+	folderPermissionsService ac.FolderPermissionsService,
+	dashboardPermissionsService ac.DashboardPermissionsService,
+	userService user.Service,
+	orgService org.Service,
+	teamService team.Service,
+	quotaService quota.Service,
+	ruleStore *ngstore.DBstore,
+) *Service {
+	logger := log.New("dashboardarchive")
+	s := &Service{
+		cfg:                         cfg,
+		accessControl:               accessControl,
+		dashboardService:            dashboardService,
+		folderService:               folderService,
+		libraryElementService:       libraryElementService,
+		libraryPanelService:         libraryPanelService,
+		dataSourceService:           dataSourceService,
+		folderPermissionsService:    folderPermissionsService,
+		dashboardPermissionsService: dashboardPermissionsService,
+		userService:                 userService,
+		orgService:                  orgService,
+		teamService:                 teamService,
+		quotaService:                quotaService,
+		alertRuleStore:              ruleStore,
+		alertRuleService: provisioning.NewAlertRuleService(
+			ruleStore,
+			ruleStore,
+			dashboardService,
+			quotaService,
+			sql,
+			int64(cfg.UnifiedAlerting.DefaultRuleEvaluationInterval.Seconds()),
+			int64(cfg.UnifiedAlerting.BaseInterval.Seconds()),
+			logger),
+		log: logger,
+	}
+
+	s.registerAPIEndpoints(routeRegister)
+
+	return s
+}
@@ -0,0 +1,551 @@
+package dashboardarchiveimpl
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/dashboardarchive"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/libraryelements/model"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const testOrgID = 1
+
+func TestExportImport(t *testing.T) {
+	signedInUser := &user.SignedInUser{UserID: 1, OrgID: testOrgID, OrgRole: "Admin"}
+
+	setupSource := func(t *testing.T) *testInstance {
+		t.Helper()
+		source := newTestInstance(&datasources.DataSource{UID: "prom-a", Name: "Prometheus", Type: "prometheus"})
+		folderID := source.addFolder("team-a", "Team A")
+		source.folderPermissions.permissions["team-a"] = []ac.ResourcePermission{
+			{TeamId: 1, Team: "Editors", Actions: []string{"Edit"}, IsManaged: true},
+			{UserId: 2, UserLogin: "unknown", Actions: []string{"View"}, IsManaged: true},
+			{BuiltInRole: "Viewer", Actions: []string{"View"}, IsManaged: false},
+		}
+		source.addDashboard(folderID, map[string]interface{}{
+			"uid":   "dash-a",
+			"title": "Dashboard A",
+			"panels": []interface{}{
+				map[string]interface{}{
+					"id":         1,
+					"datasource": map[string]interface{}{"type": "prometheus", "uid": "prom-a"},
+					"targets": []interface{}{
+						map[string]interface{}{"refId": "A", "datasource": map[string]interface{}{"type": "prometheus", "uid": "prom-a"}},
+						map[string]interface{}{"refId": "B", "datasource": map[string]interface{}{"type": "__expr__", "uid": "__expr__"}},
+					},
+				},
+			},
+		})
+		source.addDashboard(0, map[string]interface{}{"uid": "dash-general", "title": "General dashboard"})
+		source.libraryElements.add(folderID, model.LibraryElementDTO{
+			UID:   "panel-a",
+			Name:  "Panel A",
+			Kind:  int64(model.PanelElement),
+			Model: json.RawMessage(`{"type":"graph","datasource":"Prometheus"}`),
+		})
+		source.alertRules.rules["rule-a"] = &ngmodels.AlertRule{
+			UID:          "rule-a",
+			OrgID:        testOrgID,
+			Title:        "Rule A",
+			NamespaceUID: "team-a",
+			RuleGroup:    "group",
+			Condition:    "A",
+			Data: []ngmodels.AlertQuery{
+				{RefID: "A", DatasourceUID: "prom-a", Model: json.RawMessage(`{"expr":"up","datasource":{"type":"prometheus","uid":"prom-a"}}`)},
+			},
+		}
+		return source
+	}
+
+	export := func(t *testing.T, source *testInstance, folderUIDs ...string) ([]byte, *dashboardarchive.Manifest) {
+		t.Helper()
+		buf := &bytes.Buffer{}
+		manifest, err := source.service.Export(context.Background(), &dashboardarchive.ExportCommand{
+			OrgID:        testOrgID,
+			FolderUIDs:   folderUIDs,
+			SignedInUser: signedInUser,
+		}, buf)
+		require.NoError(t, err)
+		return buf.Bytes(), manifest
+	}
+
+	importArchive := func(t *testing.T, target *testInstance, archive []byte, overwrite bool, dataSources map[string]string) *dashboardarchive.ImportResult {
+		t.Helper()
+		result, err := target.service.Import(context.Background(), &dashboardarchive.ImportCommand{
+			OrgID:        testOrgID,
+			Archive:      bytes.NewReader(archive),
+			Size:         int64(len(archive)),
+			DataSources:  dataSources,
+			Overwrite:    overwrite,
+			SignedInUser: signedInUser,
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("export replaces data sources by inputs", func(t *testing.T) {
+		archive, manifest := export(t, setupSource(t))
+
+		require.Len(t, manifest.Inputs, 1)
+		assert.Equal(t, dashboardarchive.Input{Name: "DS_PROMETHEUS", Label: "Prometheus", Type: "datasource", PluginID: "prometheus", PluginName: "prometheus"}, manifest.Inputs[0])
+		assert.Equal(t, []dashboardarchive.FolderEntry{{
+			UID:   "team-a",
+			Title: "Team A",
+			Permissions: []dashboardarchive.Permission{
+				{Team: "Editors", Permission: "Edit"},
+				{UserLogin: "unknown", Permission: "View"},
+			},
+		}}, manifest.Folders)
+		require.Len(t, manifest.Dashboards, 2)
+		assert.Equal(t, "dash-general", manifest.Dashboards[0].UID)
+		assert.Equal(t, "", manifest.Dashboards[0].FolderUID)
+		assert.Equal(t, "dash-a", manifest.Dashboards[1].UID)
+		assert.Equal(t, []string{"DS_PROMETHEUS"}, manifest.Dashboards[1].Inputs)
+		require.Len(t, manifest.LibraryPanels, 1)
+		require.Len(t, manifest.AlertRules, 1)
+
+		dash := readArchiveFile(t, archive, "dashboards/dash-a.json")
+		assert.Nil(t, dash.Get("id").Interface())
+		panel := dash.Get("panels").GetIndex(0)
+		assert.Equal(t, "${DS_PROMETHEUS}", panel.GetPath("datasource", "uid").MustString())
+		assert.Equal(t, "${DS_PROMETHEUS}", panel.Get("targets").GetIndex(0).GetPath("datasource", "uid").MustString())
+		assert.Equal(t, "__expr__", panel.Get("targets").GetIndex(1).GetPath("datasource", "uid").MustString())
+
+		libraryPanel := readArchiveFile(t, archive, "library-panels/panel-a.json")
+		assert.Equal(t, "${DS_PROMETHEUS}", libraryPanel.GetPath("model", "datasource", "uid").MustString())
+
+		rule := readArchiveFile(t, archive, "alert-rules/rule-a.json")
+		assert.Equal(t, "${DS_PROMETHEUS}", rule.Get("data").GetIndex(0).Get("datasourceUid").MustString())
+	})
+
+	t.Run("export of selected folders", func(t *testing.T) {
+		_, manifest := export(t, setupSource(t), folder.GeneralFolderUID)
+
+		assert.Empty(t, manifest.Folders)
+		require.Len(t, manifest.Dashboards, 1)
+		assert.Equal(t, "dash-general", manifest.Dashboards[0].UID)
+		assert.Empty(t, manifest.AlertRules)
+	})
+
+	t.Run("import uses the data source with the same name", func(t *testing.T) {
+		archive, _ := export(t, setupSource(t))
+		target := newTestInstance(&datasources.DataSource{UID: "prom-b", Name: "Prometheus", Type: "prometheus"})
+		target.teams = append(target.teams, &team.TeamDTO{ID: 5, Name: "Editors"})
+
+		result := importArchive(t, target, archive, false, nil)
+
+		assert.Equal(t, 0, result.Failed)
+		assert.Equal(t, map[string]string{"DS_PROMETHEUS": "prom-b"}, result.DataSources)
+		assert.Equal(t, []*dashboardarchive.ObjectResult{
+			{Kind: dashboardarchive.KindFolder, UID: "team-a", Title: "Team A", Status: dashboardarchive.StatusCreated, Warnings: []string{`permission of user "unknown" not imported: user not found`}},
+			{Kind: dashboardarchive.KindLibraryPanel, UID: "panel-a", Title: "Panel A", Status: dashboardarchive.StatusCreated},
+			{Kind: dashboardarchive.KindDashboard, UID: "dash-general", Title: "General dashboard", Status: dashboardarchive.StatusCreated},
+			{Kind: dashboardarchive.KindDashboard, UID: "dash-a", Title: "Dashboard A", Status: dashboardarchive.StatusCreated},
+			{Kind: dashboardarchive.KindAlertRule, UID: "rule-a", Title: "Rule A", Status: dashboardarchive.StatusCreated},
+		}, result.Results)
+
+		f := target.folders.byUID["team-a"]
+		require.NotNil(t, f)
+		assert.Equal(t, []ac.SetResourcePermissionCommand{{TeamID: 5, Permission: "Edit"}}, target.folderPermissions.set["team-a"])
+
+		dash := target.dashboards.byUID["dash-a"]
+		require.NotNil(t, dash)
+		assert.Equal(t, f.ID, dash.FolderID)
+		panel := dash.Data.Get("panels").GetIndex(0)
+		assert.Equal(t, "prom-b", panel.GetPath("datasource", "uid").MustString())
+		assert.Equal(t, "prom-b", panel.Get("targets").GetIndex(0).GetPath("datasource", "uid").MustString())
+		assert.Nil(t, dash.Data.Get("__inputs").Interface())
+
+		element := target.libraryElements.elements["panel-a"]
+		assert.Equal(t, f.ID, element.FolderID)
+		assert.JSONEq(t, `{"type":"graph","datasource":{"type":"prometheus","uid":"prom-b"}}`, string(element.Model))
+
+		rule := target.alertRules.rules["rule-a"]
+		require.NotNil(t, rule)
+		assert.Equal(t, "team-a", rule.NamespaceUID)
+		assert.Equal(t, "prom-b", rule.Data[0].DatasourceUID)
+	})
+
+	t.Run("import grants permissions to the users of the organization only", func(t *testing.T) {
+		archive, _ := export(t, setupSource(t))
+		target := newTestInstance(&datasources.DataSource{UID: "prom-b", Name: "Prometheus", Type: "prometheus"})
+		target.service.userService = &usertest.FakeUserService{ExpectedUser: &user.User{ID: 7, Login: "unknown"}}
+		orgService := orgtest.NewOrgServiceFake()
+		orgService.ExpectedUserOrgDTO = []*org.UserOrgDTO{{OrgID: testOrgID + 1}}
+		target.service.orgService = orgService
+
+		result := importArchive(t, target, archive, false, nil)
+		assert.Equal(t, []string{`permission of team "Editors" not imported: team not found`, `permission of user "unknown" not imported: user not found`}, result.Results[0].Warnings)
+		assert.Empty(t, target.folderPermissions.set["team-a"])
+
+		orgService.ExpectedUserOrgDTO = append(orgService.ExpectedUserOrgDTO, &org.UserOrgDTO{OrgID: testOrgID})
+		result = importArchive(t, target, archive, true, nil)
+		assert.Equal(t, []string{`permission of team "Editors" not imported: team not found`}, result.Results[0].Warnings)
+		assert.Equal(t, []ac.SetResourcePermissionCommand{{UserID: 7, Permission: "View"}}, target.folderPermissions.set["team-a"])
+	})
+
+	t.Run("import with mapped data sources", func(t *testing.T) {
+		archive, _ := export(t, setupSource(t))
+		target := newTestInstance(
+			&datasources.DataSource{UID: "prom-b", Name: "Prometheus", Type: "prometheus"},
+			&datasources.DataSource{UID: "prom-c", Name: "Other", Type: "prometheus"},
+		)
+
+		result := importArchive(t, target, archive, false, map[string]string{"DS_PROMETHEUS": "prom-c"})
+
+		assert.Equal(t, 0, result.Failed)
+		assert.Equal(t, map[string]string{"DS_PROMETHEUS": "prom-c"}, result.DataSources)
+		panel := target.dashboards.byUID["dash-a"].Data.Get("panels").GetIndex(0)
+		assert.Equal(t, "prom-c", panel.GetPath("datasource", "uid").MustString())
+	})
+
+	t.Run("objects using unresolved inputs fail", func(t *testing.T) {
+		archive, _ := export(t, setupSource(t))
+		target := newTestInstance(&datasources.DataSource{UID: "loki", Name: "Prometheus", Type: "loki"})
+
+		result := importArchive(t, target, archive, false, nil)
+
+		assert.Equal(t, 3, result.Failed)
+		assert.Empty(t, result.DataSources)
+		statuses := map[string]dashboardarchive.ImportStatus{}
+		for _, r := range result.Results {
+			statuses[r.UID] = r.Status
+		}
+		assert.Equal(t, map[string]dashboardarchive.ImportStatus{
+			"team-a":       dashboardarchive.StatusCreated,
+			"panel-a":      dashboardarchive.StatusFailed,
+			"dash-general": dashboardarchive.StatusCreated,
+			"dash-a":       dashboardarchive.StatusFailed,
+			"rule-a":       dashboardarchive.StatusFailed,
+		}, statuses)
+	})
+
+	t.Run("existing objects are only replaced with overwrite", func(t *testing.T) {
+		archive, _ := export(t, setupSource(t))
+		target := newTestInstance(&datasources.DataSource{UID: "prom-b", Name: "Prometheus", Type: "prometheus"})
+		importArchive(t, target, archive, false, nil)
+
+		result := importArchive(t, target, archive, false, nil)
+		assert.Equal(t, 4, result.Failed)
+		assert.Equal(t, dashboardarchive.StatusExists, result.Results[0].Status)
+
+		result = importArchive(t, target, archive, true, nil)
+		assert.Equal(t, 0, result.Failed)
+		for _, r := range result.Results[1:] {
+			assert.Equal(t, dashboardarchive.StatusUpdated, r.Status, r.UID)
+		}
+	})
+
+	t.Run("invalid archives are rejected", func(t *testing.T) {
+		target := newTestInstance()
+		_, err := target.service.Import(context.Background(), &dashboardarchive.ImportCommand{
+			OrgID:        testOrgID,
+			Archive:      bytes.NewReader([]byte("not a zip")),
+			Size:         9,
+			SignedInUser: signedInUser,
+		})
+		assert.ErrorIs(t, err, dashboardarchive.ErrInvalidArchive)
+
+		buf := &bytes.Buffer{}
+		w := zip.NewWriter(buf)
+		f, err := w.Create(dashboardarchive.ManifestFile)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(`{"version": 2}`))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		_, err = target.service.Import(context.Background(), &dashboardarchive.ImportCommand{
+			OrgID:        testOrgID,
+			Archive:      bytes.NewReader(buf.Bytes()),
+			Size:         int64(buf.Len()),
+			SignedInUser: signedInUser,
+		})
+		assert.ErrorIs(t, err, dashboardarchive.ErrUnsupportedVersion)
+	})
+}
+
+func readArchiveFile(t *testing.T, archive []byte, name string) *simplejson.Json {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	f, err := reader.Open(name)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	data, err := simplejson.NewFromReader(f)
+	require.NoError(t, err)
+	return data
+}
+
+// testInstance is an instance with fake services, shared by the services so that folders
+// created by an import can be used by the dashboards imported next.
+type testInstance struct {
+	service              *Service
+	folders              *fakeFolderService
+	dashboards           *fakeDashboardService
+	libraryElements      *fakeLibraryElementService
+	folderPermissions    *fakePermissionsService
+	dashboardPermissions *fakePermissionsService
+	alertRules           *fakeAlertRules
+	teams                []*team.TeamDTO
+}
+
+func newTestInstance(dataSources ...*datasources.DataSource) *testInstance {
+	for _, ds := range dataSources {
+		ds.OrgID = testOrgID
+	}
+	folders := &fakeFolderService{byUID: map[string]*folder.Folder{}}
+	i := &testInstance{
+		folders:              folders,
+		dashboards:           &fakeDashboardService{folders: folders, byUID: map[string]*dashboards.Dashboard{}},
+		libraryElements:      &fakeLibraryElementService{elements: map[string]*model.LibraryElementDTO{}},
+		folderPermissions:    newFakePermissionsService(),
+		dashboardPermissions: newFakePermissionsService(),
+		alertRules:           &fakeAlertRules{rules: map[string]*ngmodels.AlertRule{}},
+	}
+	folders.dashboards = i.dashboards
+	i.service = &Service{
+		cfg:                         setting.NewCfg(),
+		accessControl:               actest.FakeAccessControl{ExpectedEvaluate: true},
+		dashboardService:            i.dashboards,
+		folderService:               folders,
+		libraryElementService:       i.libraryElements,
+		libraryPanelService:         &fakeLibraryPanelService{},
+		dataSourceService:           &fakeDatasources.FakeDataSourceService{DataSources: dataSources},
+		folderPermissionsService:    i.folderPermissions,
+		dashboardPermissionsService: i.dashboardPermissions,
+		userService:                 &usertest.FakeUserService{ExpectedError: user.ErrUserNotFound},
+		orgService:                  orgtest.NewOrgServiceFake(),
+		teamService:                 &fakeTeamService{instance: i},
+		alertRuleStore:              i.alertRules,
+		alertRuleService:            i.alertRules,
+		log:                         log.NewNopLogger(),
+	}
+	return i
+}
+
+func (i *testInstance) addFolder(uid, title string) int64 {
+	f, _ := i.folders.Create(context.Background(), &folder.CreateFolderCommand{UID: uid, Title: title, OrgID: testOrgID})
+	return f.ID
+}
+
+func (i *testInstance) addDashboard(folderID int64, data map[string]interface{}) {
+	dash := dashboards.NewDashboardFromJson(simplejson.NewFromAny(data))
+	dash.FolderID = folderID
+	_, _ = i.dashboards.ImportDashboard(context.Background(), &dashboards.SaveDashboardDTO{OrgID: testOrgID, Dashboard: dash})
+}
+
+type fakeDashboardService struct {
+	dashboards.DashboardService
+	folders *fakeFolderService
+	byUID   map[string]*dashboards.Dashboard
+	lastID  int64
+}
+
+func (s *fakeDashboardService) FindDashboards(ctx context.Context, query *dashboards.FindPersistedDashboardsQuery) ([]dashboards.DashboardSearchProjection, error) {
+	result := []dashboards.DashboardSearchProjection{}
+	if query.Type == "dash-folder" {
+		for _, f := range s.folders.byUID {
+			result = append(result, dashboards.DashboardSearchProjection{ID: f.ID, UID: f.UID, Title: f.Title, IsFolder: true})
+		}
+		return result, nil
+	}
+	for _, d := range s.byUID {
+		if len(query.FolderIds) == 0 || query.FolderIds[0] == d.FolderID {
+			result = append(result, dashboards.DashboardSearchProjection{ID: d.ID, UID: d.UID, Title: d.Title, FolderID: d.FolderID})
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeDashboardService) GetDashboard(ctx context.Context, query *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error) {
+	if d, ok := s.byUID[query.UID]; ok {
+		return d, nil
+	}
+	return nil, dashboards.ErrDashboardNotFound
+}
+
+func (s *fakeDashboardService) ImportDashboard(ctx context.Context, dto *dashboards.SaveDashboardDTO) (*dashboards.Dashboard, error) {
+	dash := dto.Dashboard
+	if existing, ok := s.byUID[dash.UID]; ok {
+		if !dto.Overwrite {
+			return nil, dashboards.ErrDashboardWithSameUIDExists
+		}
+		dash.SetID(existing.ID)
+	} else {
+		s.lastID++
+		dash.SetID(s.lastID)
+	}
+	dash.OrgID = dto.OrgID
+	s.byUID[dash.UID] = dash
+	return dash, nil
+}
+
+type fakeFolderService struct {
+	folder.Service
+	dashboards *fakeDashboardService
+	byUID      map[string]*folder.Folder
+}
+
+func (s *fakeFolderService) Get(ctx context.Context, query *folder.GetFolderQuery) (*folder.Folder, error) {
+	if f, ok := s.byUID[*query.UID]; ok {
+		return f, nil
+	}
+	return nil, dashboards.ErrFolderNotFound
+}
+
+func (s *fakeFolderService) Create(ctx context.Context, cmd *folder.CreateFolderCommand) (*folder.Folder, error) {
+	// Folders and dashboards share their IDs
+	s.dashboards.lastID++
+	f := &folder.Folder{ID: s.dashboards.lastID, UID: cmd.UID, Title: cmd.Title, OrgID: cmd.OrgID}
+	s.byUID[f.UID] = f
+	return f, nil
+}
+
+type fakeLibraryElementService struct {
+	libraryelements.Service
+	elements map[string]*model.LibraryElementDTO
+}
+
+func (s *fakeLibraryElementService) add(folderID int64, element model.LibraryElementDTO) {
+	element.FolderID = folderID
+	element.Version = 1
+	s.elements[element.UID] = &element
+}
+
+func (s *fakeLibraryElementService) GetAllElements(c context.Context, signedInUser *user.SignedInUser, query model.SearchLibraryElementsQuery) (model.LibraryElementSearchResult, error) {
+	result := model.LibraryElementSearchResult{Elements: []model.LibraryElementDTO{}}
+	for _, element := range s.elements {
+		result.Elements = append(result.Elements, *element)
+	}
+	return result, nil
+}
+
+func (s *fakeLibraryElementService) GetElement(c context.Context, signedInUser *user.SignedInUser, uid string) (model.LibraryElementDTO, error) {
+	if element, ok := s.elements[uid]; ok {
+		return *element, nil
+	}
+	return model.LibraryElementDTO{}, model.ErrLibraryElementNotFound
+}
+
+func (s *fakeLibraryElementService) CreateElement(c context.Context, signedInUser *user.SignedInUser, cmd model.CreateLibraryElementCommand) (model.LibraryElementDTO, error) {
+	s.add(cmd.FolderID, model.LibraryElementDTO{UID: cmd.UID, Name: cmd.Name, Kind: cmd.Kind, Model: cmd.Model})
+	return *s.elements[cmd.UID], nil
+}
+
+func (s *fakeLibraryElementService) PatchLibraryElement(c context.Context, signedInUser *user.SignedInUser, cmd model.PatchLibraryElementCommand, uid string) (model.LibraryElementDTO, error) {
+	element := s.elements[uid]
+	if element.Version != cmd.Version {
+		return model.LibraryElementDTO{}, model.ErrLibraryElementVersionMismatch
+	}
+	element.FolderID = cmd.FolderID
+	element.Name = cmd.Name
+	element.Model = cmd.Model
+	element.Version++
+	return *element, nil
+}
+
+type fakeLibraryPanelService struct {
+	librarypanels.Service
+}
+
+func (s *fakeLibraryPanelService) ConnectLibraryPanelsForDashboard(c context.Context, signedInUser *user.SignedInUser, dash *dashboards.Dashboard) error {
+	return nil
+}
+
+type fakePermissionsService struct {
+	ac.PermissionsService
+	permissions map[string][]ac.ResourcePermission
+	set         map[string][]ac.SetResourcePermissionCommand
+}
+
+func newFakePermissionsService() *fakePermissionsService {
+	return &fakePermissionsService{
+		permissions: map[string][]ac.ResourcePermission{},
+		set:         map[string][]ac.SetResourcePermissionCommand{},
+	}
+}
+
+func (s *fakePermissionsService) GetPermissions(ctx context.Context, user *user.SignedInUser, resourceID string) ([]ac.ResourcePermission, error) {
+	return s.permissions[resourceID], nil
+}
+
+func (s *fakePermissionsService) SetPermissions(ctx context.Context, orgID int64, resourceID string, commands ...ac.SetResourcePermissionCommand) ([]ac.ResourcePermission, error) {
+	s.set[resourceID] = commands
+	return nil, nil
+}
+
+// MapActions returns the action of the permission, the permissions of the tests having a
+// single action named after the permission
+func (s *fakePermissionsService) MapActions(permission ac.ResourcePermission) string {
+	return permission.Actions[0]
+}
+
+type fakeTeamService struct {
+	team.Service
+	instance *testInstance
+}
+
+func (s *fakeTeamService) SearchTeams(ctx context.Context, query *team.SearchTeamsQuery) (team.SearchTeamQueryResult, error) {
+	result := team.SearchTeamQueryResult{Teams: []*team.TeamDTO{}}
+	for _, t := range s.instance.teams {
+		if t.Name == query.Name {
+			result.Teams = append(result.Teams, t)
+		}
+	}
+	return result, nil
+}
+
+type fakeAlertRules struct {
+	rules map[string]*ngmodels.AlertRule
+}
+
+func (f *fakeAlertRules) ListAlertRules(ctx context.Context, query *ngmodels.ListAlertRulesQuery) (ngmodels.RulesGroup, error) {
+	result := ngmodels.RulesGroup{}
+	for _, rule := range f.rules {
+		for _, uid := range query.NamespaceUIDs {
+			if rule.NamespaceUID == uid {
+				result = append(result, rule)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeAlertRules) GetAlertRule(ctx context.Context, orgID int64, ruleUID string) (ngmodels.AlertRule, ngmodels.Provenance, error) {
+	if rule, ok := f.rules[ruleUID]; ok {
+		return *rule, ngmodels.ProvenanceNone, nil
+	}
+	return ngmodels.AlertRule{}, ngmodels.ProvenanceNone, ngmodels.ErrAlertRuleNotFound
+}
+
+func (f *fakeAlertRules) CreateAlertRule(ctx context.Context, rule ngmodels.AlertRule, provenance ngmodels.Provenance, userID int64) (ngmodels.AlertRule, error) {
+	f.rules[rule.UID] = &rule
+	return rule, nil
+}
+
+func (f *fakeAlertRules) UpdateAlertRule(ctx context.Context, rule ngmodels.AlertRule, provenance ngmodels.Provenance) (ngmodels.AlertRule, error) {
+	f.rules[rule.UID] = &rule
+	return rule, nil
+}
@@ -0,0 +1,130 @@
+package dashboardarchive
+
+import (
+	"io"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrInvalidArchive     = errutil.NewBase(errutil.StatusBadRequest, "dashboardarchive.invalidArchive", errutil.WithPublicMessage("Invalid archive"))
+	ErrUnsupportedVersion = errutil.NewBase(errutil.StatusBadRequest, "dashboardarchive.unsupportedVersion", errutil.WithPublicMessage("Unsupported archive version"))
+	ErrArchiveTooLarge    = errutil.NewBase(errutil.StatusBadRequest, "dashboardarchive.tooLarge", errutil.WithPublicMessage("Archive is too large"))
+)
+
+const (
+	// Version is the version of the archive format
+	Version = 1
+	// ManifestFile is the file of the archive listing its content
+	ManifestFile = "manifest.json"
+)
+
+// Manifest lists the content of an archive. Objects are stored in their own file, with the data
+// sources they use replaced by inputs.
+type Manifest struct {
+	Version        int       `json:"version"`
+	Exported       time.Time `json:"exported"`
+	GrafanaVersion string    `json:"grafanaVersion"`
+	// Inputs are the data sources used by the objects of the archive
+	Inputs        []Input       `json:"__inputs"`
+	Folders       []FolderEntry `json:"folders"`
+	Dashboards    []ObjectEntry `json:"dashboards"`
+	LibraryPanels []ObjectEntry `json:"libraryPanels"`
+	AlertRules    []ObjectEntry `json:"alertRules"`
+}
+
+// Input is a data source used by exported objects, in the format of dashboard inputs. References
+// to the data source are replaced by ${Name}.
+type Input struct {
+	Name        string `json:"name"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	PluginID    string `json:"pluginId"`
+	PluginName  string `json:"pluginName"`
+}
+
+// Permission is a permission on a folder or a dashboard. Users and teams are referenced by login
+// and name, which are the same across organizations and instances.
+type Permission struct {
+	UserLogin   string `json:"userLogin,omitempty"`
+	Team        string `json:"team,omitempty"`
+	BuiltInRole string `json:"builtInRole,omitempty"`
+	Permission  string `json:"permission"`
+}
+
+type FolderEntry struct {
+	UID         string       `json:"uid"`
+	Title       string       `json:"title"`
+	Permissions []Permission `json:"permissions,omitempty"`
+}
+
+// ObjectEntry is a dashboard, library panel or alert rule of an archive.
+type ObjectEntry struct {
+	UID       string `json:"uid"`
+	Title     string `json:"title"`
+	FolderUID string `json:"folderUid"`
+	File      string `json:"file"`
+	// Inputs are the names of the inputs used by the object
+	Inputs      []string     `json:"inputs,omitempty"`
+	Permissions []Permission `json:"permissions,omitempty"`
+}
+
+type ExportCommand struct {
+	OrgID int64 `json:"-"`
+	// FolderUIDs are the folders to export, general for the General folder. All folders are
+	// exported when empty.
+	FolderUIDs   []string           `json:"folderUids"`
+	SignedInUser *user.SignedInUser `json:"-"`
+}
+
+type ImportCommand struct {
+	OrgID   int64
+	Archive io.ReaderAt
+	Size    int64
+	// DataSources maps the inputs of the archive to the UIDs of data sources. Inputs that aren't
+	// mapped use the data source with the same name and type, if any.
+	DataSources map[string]string
+	// Overwrite replaces the dashboards, library panels and alert rules with the same UIDs
+	Overwrite    bool
+	SignedInUser *user.SignedInUser
+}
+
+type ObjectKind string
+
+const (
+	KindFolder       ObjectKind = "folder"
+	KindDashboard    ObjectKind = "dashboard"
+	KindLibraryPanel ObjectKind = "library-panel"
+	KindAlertRule    ObjectKind = "alert-rule"
+)
+
+type ImportStatus string
+
+const (
+	StatusCreated ImportStatus = "created"
+	StatusUpdated ImportStatus = "updated"
+	// StatusExists means an existing folder was used
+	StatusExists ImportStatus = "exists"
+	StatusFailed ImportStatus = "failed"
+)
+
+// ObjectResult is the result of the import of an object. Warnings report what couldn't be
+// imported with the object, such as permissions of unknown users.
+type ObjectResult struct {
+	Kind     ObjectKind   `json:"kind"`
+	UID      string       `json:"uid"`
+	Title    string       `json:"title"`
+	Status   ImportStatus `json:"status"`
+	Error    string       `json:"error,omitempty"`
+	Warnings []string     `json:"warnings,omitempty"`
+}
+
+type ImportResult struct {
+	Results []*ObjectResult `json:"results"`
+	// DataSources are the data sources used for the inputs of the archive
+	DataSources map[string]string `json:"dataSources"`
+	Failed      int               `json:"failed"`
+}
@@ -5,3 +5,4 @@ import (
 )
 
 var ErrBaseNotFound = errutil.NewBase(errutil.StatusNotFound, "dashboardsnapshots.not-found", errutil.WithPublicMessage("Snapshot not found"))
+var ErrBaseInvalidTimeRange = errutil.NewBase(errutil.StatusBadRequest, "dashboardsnapshots.invalid-time-range", errutil.WithPublicMessage("Invalid time range"))
@@ -1,6 +1,8 @@
 package dashboardsnapshots
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -79,6 +81,50 @@ type CreateDashboardSnapshotCommand struct {
 	DashboardEncrypted []byte `json:"-"`
 }
 
+// swagger:model
+type CreateServerSideDashboardSnapshotCommand struct {
+	// Start of the time range, absolute in epoch milliseconds or relative using Grafana time units. Defaults to the dashboard time range.
+	// required:false
+	// example: now-6h
+	From string `json:"from"`
+	// End of the time range, absolute in epoch milliseconds or relative using Grafana time units. Defaults to the dashboard time range.
+	// required:false
+	// example: now
+	To string `json:"to"`
+	// Values of the dashboard variables, by variable name. Variables that are not set keep their current value in the dashboard.
+	// required:false
+	Variables map[string]SnapshotVariableValue `json:"variables"`
+	// Snapshot name. Defaults to the dashboard title.
+	// required:false
+	Name string `json:"name"`
+	// When the snapshot should expire in seconds. Default is never to expire.
+	// required:false
+	// default:0
+	Expires int64 `json:"expires"`
+
+	DashboardUID string             `json:"-"`
+	Dashboard    *simplejson.Json   `json:"-"`
+	OrgID        int64              `json:"-"`
+	SignedInUser *user.SignedInUser `json:"-"`
+}
+
+// SnapshotVariableValue is the value of a dashboard variable, either a single string or a list of strings.
+type SnapshotVariableValue []string
+
+func (v *SnapshotVariableValue) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*v = SnapshotVariableValue{single}
+		return nil
+	}
+	var multi []string
+	if err := json.Unmarshal(b, &multi); err != nil {
+		return fmt.Errorf("variable value must be a string or a list of strings")
+	}
+	*v = multi
+	return nil
+}
+
 type DeleteDashboardSnapshotCommand struct {
 	DeleteKey string `json:"-"`
 }
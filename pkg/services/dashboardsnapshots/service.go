@@ -7,6 +7,7 @@ import (
 //go:generate mockery --name Service --structname MockService --inpackage --filename service_mock.go
 type Service interface {
 	CreateDashboardSnapshot(context.Context, *CreateDashboardSnapshotCommand) (*DashboardSnapshot, error)
+	CreateServerSideDashboardSnapshot(context.Context, *CreateServerSideDashboardSnapshotCommand) (*DashboardSnapshot, error)
 	DeleteDashboardSnapshot(context.Context, *DeleteDashboardSnapshotCommand) error
 	DeleteExpiredSnapshots(context.Context, *DeleteExpiredSnapshotsCommand) error
 	GetDashboardSnapshot(context.Context, *GetDashboardSnapshotQuery) (*DashboardSnapshot, error)
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
+	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	// Used when a panel does not define max data points, close to the width of a full width panel
+	defaultMaxDataPoints = 1000
+
+	mixedDatasource     = "-- Mixed --"
+	dashboardDatasource = "-- Dashboard --"
+)
+
+// CreateServerSideDashboardSnapshot executes the queries of every panel of the dashboard as the
+// signed in user, embeds the results in the dashboard like the frontend does, and stores the snapshot.
+func (s *ServiceImpl) CreateServerSideDashboardSnapshot(ctx context.Context, cmd *dashboardsnapshots.CreateServerSideDashboardSnapshotCommand) (*dashboardsnapshots.DashboardSnapshot, error) {
+	dash, err := cmd.Dashboard.Encode()
+	if err != nil {
+		return nil, err
+	}
+	// Work on a copy, the panels are modified while baking
+	snapshot, err := simplejson.NewJson(dash)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := cmd.From, cmd.To
+	if from == "" {
+		from = snapshot.Get("time").Get("from").MustString("now-6h")
+	}
+	if to == "" {
+		to = snapshot.Get("time").Get("to").MustString("now")
+	}
+	timeRange := legacydata.NewDataTimeRange(from, to)
+	tr := backend.TimeRange{From: timeRange.GetFromAsTimeUTC(), To: timeRange.GetToAsTimeUTC()}
+	if !tr.From.Before(tr.To) {
+		return nil, dashboardsnapshots.ErrBaseInvalidTimeRange.Errorf("invalid time range %s to %s", from, to)
+	}
+
+	variables := resolveVariables(snapshot, cmd.Variables)
+	for _, panel := range flattenPanels(snapshot) {
+		if err := s.bakePanel(ctx, cmd, panel, tr, variables); err != nil {
+			return nil, err
+		}
+	}
+
+	name := cmd.Name
+	if name == "" {
+		name = snapshot.Get("title").MustString("Unnamed snapshot")
+	}
+	scrubDashboard(snapshot, name, tr, variables)
+	snapshot.SetPath([]string{"snapshot", "timestamp"}, time.Now().UTC().Format(time.RFC3339))
+	snapshot.SetPath([]string{"snapshot", "originalUrl"}, fmt.Sprintf("%s/d/%v", s.cfg.AppSubURL, cmd.DashboardUID))
+
+	key, err := util.GetRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	deleteKey, err := util.GetRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.CreateDashboardSnapshot(ctx, &dashboardsnapshots.CreateDashboardSnapshotCommand{
+		Dashboard: snapshot,
+		Name:      name,
+		Expires:   cmd.Expires,
+		Key:       key,
+		DeleteKey: deleteKey,
+		OrgID:     cmd.OrgID,
+		UserID:    cmd.SignedInUser.UserID,
+	})
+}
+
+// bakePanel runs the visible queries of a panel and stores the frames in its snapshotData.
+// Query errors are stored as error notices on the frames of the failed queries.
+func (s *ServiceImpl) bakePanel(ctx context.Context, cmd *dashboardsnapshots.CreateServerSideDashboardSnapshotCommand, panel *simplejson.Json, tr backend.TimeRange, variables map[string][]string) error {
+	panelDatasource := interpolate(deepCopy(panel.Get("datasource").Interface()), variables, "")
+	if getDatasourceUID(panel) == dashboardDatasource {
+		// Results of other panels are not re-used, these panels are left empty
+		return nil
+	}
+
+	maxDataPoints := panel.Get("maxDataPoints").MustInt64(defaultMaxDataPoints)
+	minInterval, _ := intervalv2.ParseIntervalStringToTimeDuration(panel.Get("interval").MustString("1ms"))
+	interval := s.intervalCalculator.Calculate(tr, minInterval, maxDataPoints)
+
+	panelVariables := make(map[string][]string, len(variables)+7)
+	for k, v := range variables {
+		panelVariables[k] = v
+	}
+	rangeDuration := tr.To.Sub(tr.From)
+	panelVariables["__interval"] = []string{interval.Text}
+	panelVariables["__interval_ms"] = []string{strconv.FormatInt(interval.Milliseconds(), 10)}
+	panelVariables["__from"] = []string{strconv.FormatInt(tr.From.UnixMilli(), 10)}
+	panelVariables["__to"] = []string{strconv.FormatInt(tr.To.UnixMilli(), 10)}
+	panelVariables["__range"] = []string{intervalv2.FormatDuration(rangeDuration)}
+	panelVariables["__range_s"] = []string{strconv.FormatInt(int64(rangeDuration.Seconds()), 10)}
+	panelVariables["__range_ms"] = []string{strconv.FormatInt(rangeDuration.Milliseconds(), 10)}
+
+	queries := []*simplejson.Json{}
+	for _, obj := range panel.Get("targets").MustArray() {
+		target, ok := obj.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		datasourceType := simplejson.NewFromAny(target).Get("datasource").Get("type").MustString(panel.Get("datasource").Get("type").MustString())
+		query := simplejson.NewFromAny(interpolate(deepCopy(target), panelVariables, datasourceType))
+		if query.Get("hide").MustBool() {
+			continue
+		}
+		// Queries without a data source use the data source of the panel
+		if _, ok := query.CheckGet("datasource"); !ok && panelDatasource != nil && getDatasourceUID(panel) != mixedDatasource {
+			query.Set("datasource", panelDatasource)
+		}
+		query.Set("intervalMs", interval.Milliseconds())
+		query.Set("maxDataPoints", maxDataPoints)
+		queries = append(queries, query)
+	}
+	if len(queries) == 0 {
+		return nil
+	}
+
+	res, err := s.queryDataService.QueryData(ctx, cmd.SignedInUser, false, dtos.MetricRequest{
+		From:    strconv.FormatInt(tr.From.UnixMilli(), 10),
+		To:      strconv.FormatInt(tr.To.UnixMilli(), 10),
+		Queries: queries,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// A failing panel does not fail the whole snapshot, the error is shown on the panel instead
+		s.log.Warn("Panel queries failed while creating dashboard snapshot", "dashboardUid", cmd.DashboardUID, "panelId", panel.Get("id").MustInt64(), "error", err)
+		snapshotData := make([]interface{}, 0, len(queries))
+		for _, query := range queries {
+			snapshotData = append(snapshotData, errorSnapshotData(query.Get("refId").MustString("A"), err))
+		}
+		panel.Set("snapshotData", snapshotData)
+		return nil
+	}
+
+	snapshotData := []interface{}{}
+	for _, query := range queries {
+		refID := query.Get("refId").MustString("A")
+		dr, ok := res.Responses[refID]
+		if !ok {
+			continue
+		}
+		if dr.Error != nil {
+			s.log.Warn("Query failed while creating dashboard snapshot", "dashboardUid", cmd.DashboardUID, "panelId", panel.Get("id").MustInt64(), "refId", refID, "error", dr.Error)
+			snapshotData = append(snapshotData, errorSnapshotData(refID, dr.Error))
+		}
+		for _, frame := range dr.Frames {
+			snapshotData = append(snapshotData, frameToSnapshotData(refID, frame))
+		}
+	}
+	panel.Set("snapshotData", snapshotData)
+	return nil
+}
+
+// scrubDashboard removes the queries, links and variable queries from the snapshot,
+// the same way the frontend does when sharing a snapshot.
+func scrubDashboard(dash *simplejson.Json, name string, tr backend.TimeRange, variables map[string][]string) {
+	dash.Set("title", name)
+	dash.Set("time", map[string]interface{}{
+		"from": tr.From.Format(time.RFC3339Nano),
+		"to":   tr.To.Format(time.RFC3339Nano),
+	})
+	dash.Set("links", []interface{}{})
+
+	for _, panel := range flattenPanels(dash) {
+		panel.Set("targets", []interface{}{})
+		panel.Set("links", []interface{}{})
+		panel.Set("datasource", nil)
+	}
+
+	annotations := []interface{}{}
+	for _, obj := range dash.Get("annotations").Get("list").MustArray() {
+		annotation := simplejson.NewFromAny(obj)
+		if !annotation.Get("enable").MustBool() {
+			continue
+		}
+		annotations = append(annotations, map[string]interface{}{
+			"name":      annotation.Get("name").Interface(),
+			"enable":    true,
+			"iconColor": annotation.Get("iconColor").Interface(),
+			"type":      annotation.Get("type").Interface(),
+			"builtIn":   annotation.Get("builtIn").Interface(),
+			"hide":      annotation.Get("hide").Interface(),
+		})
+	}
+	dash.SetPath([]string{"annotations", "list"}, annotations)
+
+	for _, obj := range dash.Get("templating").Get("list").MustArray() {
+		variable := simplejson.NewFromAny(obj)
+		values := variables[variable.Get("name").MustString()]
+		var value interface{} = values
+		text := formatValues(values, "csv")
+		if len(values) == 1 {
+			value = values[0]
+		}
+		current := map[string]interface{}{"text": text, "value": value}
+		if _, ok := variable.CheckGet("query"); ok {
+			variable.Set("query", "")
+		}
+		variable.Set("current", current)
+		variable.Set("options", []interface{}{current})
+		if _, ok := variable.CheckGet("refresh"); ok {
+			variable.Set("refresh", 0)
+		}
+	}
+}
+
+// flattenPanels returns all panels, including the panels of collapsed rows.
+func flattenPanels(dash *simplejson.Json) []*simplejson.Json {
+	var panels []*simplejson.Json
+	for _, obj := range dash.Get("panels").MustArray() {
+		panel := simplejson.NewFromAny(obj)
+		if panel.Get("type").MustString() == "row" {
+			for _, nested := range panel.Get("panels").MustArray() {
+				panels = append(panels, simplejson.NewFromAny(nested))
+			}
+			continue
+		}
+		panels = append(panels, panel)
+	}
+	return panels
+}
+
+func getDatasourceUID(panel *simplejson.Json) string {
+	uid := panel.Get("datasource").Get("uid").MustString()
+	// before 8.3 the data source was referenced by name
+	if uid == "" {
+		uid = panel.Get("datasource").MustString()
+	}
+	return uid
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			c[k] = deepCopy(item)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, item := range v {
+			c[i] = deepCopy(item)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// frameToSnapshotData converts a frame to the DataFrameDTO format the frontend stores in
+// panel snapshots.
+func frameToSnapshotData(refID string, frame *data.Frame) map[string]interface{} {
+	fields := make([]interface{}, 0, len(frame.Fields))
+	for _, field := range frame.Fields {
+		values := make([]interface{}, field.Len())
+		for i := 0; i < field.Len(); i++ {
+			values[i] = snapshotValue(field, i)
+		}
+		dto := map[string]interface{}{
+			"name":   field.Name,
+			"type":   snapshotFieldType(field.Type()),
+			"values": values,
+		}
+		if field.Config != nil {
+			dto["config"] = field.Config
+		} else {
+			dto["config"] = map[string]interface{}{}
+		}
+		if len(field.Labels) > 0 {
+			dto["labels"] = field.Labels
+		}
+		fields = append(fields, dto)
+	}
+
+	if frame.RefID != "" {
+		refID = frame.RefID
+	}
+	dto := map[string]interface{}{
+		"refId":  refID,
+		"fields": fields,
+	}
+	if frame.Name != "" {
+		dto["name"] = frame.Name
+	}
+	if frame.Meta != nil {
+		dto["meta"] = frame.Meta
+	}
+	return dto
+}
+
+// errorSnapshotData returns an empty frame carrying the query error as an error notice,
+// which the frontend displays on the panel.
+func errorSnapshotData(refID string, err error) map[string]interface{} {
+	return map[string]interface{}{
+		"refId":  refID,
+		"fields": []interface{}{},
+		"meta": map[string]interface{}{
+			"notices": []data.Notice{{Severity: data.NoticeSeverityError, Text: err.Error()}},
+		},
+	}
+}
+
+func snapshotValue(field *data.Field, i int) interface{} {
+	v, ok := field.ConcreteAt(i)
+	if !ok {
+		return nil
+	}
+	switch value := v.(type) {
+	case time.Time:
+		return value.UnixMilli()
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil
+		}
+	case float32:
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+			return nil
+		}
+	}
+	return v
+}
+
+func snapshotFieldType(t data.FieldType) string {
+	switch {
+	case t.Time():
+		return "time"
+	case t.Numeric():
+		return "number"
+	case t == data.FieldTypeString || t == data.FieldTypeNullableString:
+		return "string"
+	case t == data.FieldTypeBool || t == data.FieldTypeNullableBool:
+		return "boolean"
+	default:
+		return "other"
+	}
+}
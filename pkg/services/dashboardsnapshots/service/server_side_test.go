@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
+	dashsnapdb "github.com/grafana/grafana/pkg/services/dashboardsnapshots/database"
+	"github.com/grafana/grafana/pkg/services/secrets/database"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeQueryService struct {
+	requests []dtos.MetricRequest
+	users    []*user.SignedInUser
+	// errors returned by QueryData or by a single query, keyed by the query expression
+	queryDataErrors map[string]error
+	responseErrors  map[string]error
+}
+
+func (f *fakeQueryService) Run(context.Context) error {
+	return nil
+}
+
+func (f *fakeQueryService) QueryData(_ context.Context, u *user.SignedInUser, _ bool, reqDTO dtos.MetricRequest) (*backend.QueryDataResponse, error) {
+	f.requests = append(f.requests, reqDTO)
+	f.users = append(f.users, u)
+
+	res := backend.NewQueryDataResponse()
+	for _, q := range reqDTO.Queries {
+		expr := q.Get("expr").MustString()
+		if err := f.queryDataErrors[expr]; err != nil {
+			return nil, err
+		}
+		refID := q.Get("refId").MustString()
+		if err := f.responseErrors[expr]; err != nil {
+			res.Responses[refID] = backend.DataResponse{Error: err}
+			continue
+		}
+		res.Responses[refID] = backend.DataResponse{Frames: data.Frames{
+			data.NewFrame("cpu",
+				data.NewField("time", nil, []time.Time{time.UnixMilli(1000), time.UnixMilli(2000)}),
+				data.NewField("value", data.Labels{"host": "a"}, []*float64{pointer(1.5), pointer(math.NaN())}),
+			),
+		}}
+	}
+	return res, nil
+}
+
+func pointer(f float64) *float64 {
+	return &f
+}
+
+const testDashboard = `{
+  "uid": "dash-uid",
+  "title": "Production",
+  "time": {"from": "now-1h", "to": "now"},
+  "links": [{"title": "docs"}],
+  "templating": {"list": [
+    {"name": "host", "type": "query", "query": "label_values(host)", "refresh": 1, "current": {"text": "a", "value": "a"}},
+    {"name": "env", "type": "custom", "includeAll": true, "current": {"text": "All", "value": ["$__all"]},
+     "options": [{"value": "$__all"}, {"value": "dev"}, {"value": "prod"}]}
+  ]},
+  "annotations": {"list": [{"name": "Annotations & Alerts", "enable": true, "builtIn": 1}, {"name": "Deploys", "enable": false}]},
+  "panels": [
+    {"id": 1, "type": "timeseries", "datasource": {"type": "prometheus", "uid": "prom"},
+     "targets": [
+       {"refId": "A", "expr": "up{host=\"$host\", env=~\"${env:regex}\"}"},
+       {"refId": "B", "expr": "hidden", "hide": true}
+     ]},
+    {"id": 2, "type": "text"},
+    {"id": 3, "type": "row", "collapsed": true, "panels": [
+      {"id": 4, "type": "stat", "datasource": {"type": "prometheus", "uid": "prom"}, "targets": [{"refId": "A", "expr": "$__interval"}]}
+    ]}
+  ]
+}`
+
+func TestCreateServerSideDashboardSnapshot(t *testing.T) {
+	sqlStore := db.InitTestDB(t)
+	dsStore := dashsnapdb.ProvideStore(sqlStore, setting.NewCfg())
+	secretsService := secretsManager.SetupTestService(t, database.ProvideSecretsStore(sqlStore))
+	queryService := &fakeQueryService{}
+	cfg := setting.NewCfg()
+	cfg.AppSubURL = "/grafana"
+	s := ProvideService(dsStore, secretsService, queryService, cfg)
+
+	dashboard, err := simplejson.NewJson([]byte(testDashboard))
+	require.NoError(t, err)
+	signedInUser := &user.SignedInUser{UserID: 10, OrgID: 1}
+
+	result, err := s.CreateServerSideDashboardSnapshot(context.Background(), &dashboardsnapshots.CreateServerSideDashboardSnapshotCommand{
+		From:         "1672574400000",
+		To:           "1672578000000",
+		Variables:    map[string]dashboardsnapshots.SnapshotVariableValue{"host": {"b"}},
+		DashboardUID: "dash-uid",
+		Dashboard:    dashboard,
+		OrgID:        1,
+		SignedInUser: signedInUser,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Key)
+	require.NotEmpty(t, result.DeleteKey)
+	require.Equal(t, "Production", result.Name)
+	require.Equal(t, int64(10), result.UserID)
+
+	// queries run as the calling user, with interpolated variables and without hidden queries
+	require.Len(t, queryService.requests, 2)
+	require.Equal(t, signedInUser, queryService.users[0])
+	require.Equal(t, "1672574400000", queryService.requests[0].From)
+	require.Len(t, queryService.requests[0].Queries, 1)
+	require.Equal(t, `up{host="b", env=~"(dev|prod)"}`, queryService.requests[0].Queries[0].Get("expr").MustString())
+	require.Equal(t, "prom", queryService.requests[0].Queries[0].Get("datasource").Get("uid").MustString())
+	require.Equal(t, "5s", queryService.requests[1].Queries[0].Get("expr").MustString())
+
+	// the source dashboard is not modified
+	require.Equal(t, "now-1h", dashboard.Get("time").Get("from").MustString())
+	require.Len(t, dashboard.Get("panels").GetIndex(0).Get("targets").MustArray(), 2)
+
+	stored, err := s.GetDashboardSnapshot(context.Background(), &dashboardsnapshots.GetDashboardSnapshotQuery{Key: result.Key})
+	require.NoError(t, err)
+	snapshot := stored.Dashboard
+
+	require.Equal(t, "/grafana/d/dash-uid", snapshot.Get("snapshot").Get("originalUrl").MustString())
+	require.Equal(t, "2023-01-01T12:00:00Z", snapshot.Get("time").Get("from").MustString())
+	require.Empty(t, snapshot.Get("links").MustArray())
+	require.Len(t, snapshot.Get("annotations").Get("list").MustArray(), 1)
+
+	host := snapshot.Get("templating").Get("list").GetIndex(0)
+	require.Equal(t, "", host.Get("query").MustString())
+	require.Equal(t, 0, host.Get("refresh").MustInt())
+	require.Equal(t, "b", host.Get("current").Get("value").MustString())
+
+	panel := snapshot.Get("panels").GetIndex(0)
+	require.Empty(t, panel.Get("targets").MustArray())
+	require.Nil(t, panel.Get("datasource").Interface())
+
+	snapshotData, err := panel.Get("snapshotData").Encode()
+	require.NoError(t, err)
+	require.JSONEq(t, `[{
+		"name": "cpu",
+		"refId": "A",
+		"fields": [
+			{"name": "time", "type": "time", "config": {}, "values": [1000, 2000]},
+			{"name": "value", "type": "number", "config": {}, "labels": {"host": "a"}, "values": [1.5, null]}
+		]
+	}]`, string(snapshotData))
+
+	rowPanel := snapshot.Get("panels").GetIndex(2).Get("panels").GetIndex(0)
+	require.Len(t, rowPanel.Get("snapshotData").MustArray(), 1)
+	require.Nil(t, snapshot.Get("panels").GetIndex(1).Get("snapshotData").Interface())
+}
+
+func TestCreateServerSideDashboardSnapshot_QueryErrors(t *testing.T) {
+	sqlStore := db.InitTestDB(t)
+	dsStore := dashsnapdb.ProvideStore(sqlStore, setting.NewCfg())
+	secretsService := secretsManager.SetupTestService(t, database.ProvideSecretsStore(sqlStore))
+	queryService := &fakeQueryService{
+		queryDataErrors: map[string]error{"5s": errors.New("data source not found")},
+		responseErrors:  map[string]error{`up{host="b", env=~"(dev|prod)"}`: errors.New("bad_data: parse error")},
+	}
+	s := ProvideService(dsStore, secretsService, queryService, setting.NewCfg())
+
+	dashboard, err := simplejson.NewJson([]byte(testDashboard))
+	require.NoError(t, err)
+
+	result, err := s.CreateServerSideDashboardSnapshot(context.Background(), &dashboardsnapshots.CreateServerSideDashboardSnapshotCommand{
+		From:         "1672574400000",
+		To:           "1672578000000",
+		Variables:    map[string]dashboardsnapshots.SnapshotVariableValue{"host": {"b"}},
+		Dashboard:    dashboard,
+		OrgID:        1,
+		SignedInUser: &user.SignedInUser{UserID: 10, OrgID: 1},
+	})
+	require.NoError(t, err)
+	// the other panels are still queried
+	require.Len(t, queryService.requests, 2)
+
+	stored, err := s.GetDashboardSnapshot(context.Background(), &dashboardsnapshots.GetDashboardSnapshotQuery{Key: result.Key})
+	require.NoError(t, err)
+
+	snapshotData, err := stored.Dashboard.Get("panels").GetIndex(0).Get("snapshotData").Encode()
+	require.NoError(t, err)
+	require.JSONEq(t, `[{
+		"refId": "A",
+		"fields": [],
+		"meta": {"notices": [{"severity": "error", "text": "bad_data: parse error"}]}
+	}]`, string(snapshotData))
+
+	snapshotData, err = stored.Dashboard.Get("panels").GetIndex(2).Get("panels").GetIndex(0).Get("snapshotData").Encode()
+	require.NoError(t, err)
+	require.JSONEq(t, `[{
+		"refId": "A",
+		"fields": [],
+		"meta": {"notices": [{"severity": "error", "text": "data source not found"}]}
+	}]`, string(snapshotData))
+}
+
+func TestCreateServerSideDashboardSnapshot_InvalidTimeRange(t *testing.T) {
+	s := ProvideService(nil, nil, &fakeQueryService{}, setting.NewCfg())
+	dashboard, err := simplejson.NewJson([]byte(testDashboard))
+	require.NoError(t, err)
+
+	_, err = s.CreateServerSideDashboardSnapshot(context.Background(), &dashboardsnapshots.CreateServerSideDashboardSnapshotCommand{
+		From:         "now",
+		To:           "now-1h",
+		Dashboard:    dashboard,
+		SignedInUser: &user.SignedInUser{},
+	})
+	require.ErrorIs(t, err, dashboardsnapshots.ErrBaseInvalidTimeRange)
+}
+
+func TestSnapshotVariableValue_UnmarshalJSON(t *testing.T) {
+	var cmd dashboardsnapshots.CreateServerSideDashboardSnapshotCommand
+	require.NoError(t, json.Unmarshal([]byte(`{"variables": {"host": "a", "env": ["dev", "prod"]}}`), &cmd))
+	require.Equal(t, dashboardsnapshots.SnapshotVariableValue{"a"}, cmd.Variables["host"])
+	require.Equal(t, dashboardsnapshots.SnapshotVariableValue{"dev", "prod"}, cmd.Variables["env"])
+
+	require.Error(t, json.Unmarshal([]byte(`{"variables": {"host": 1}}`), &cmd))
+}
@@ -4,22 +4,34 @@ import (
 	"context"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
+	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
 )
 
 type ServiceImpl struct {
-	store          dashboardsnapshots.Store
-	secretsService secrets.Service
+	store              dashboardsnapshots.Store
+	secretsService     secrets.Service
+	queryDataService   query.Service
+	intervalCalculator intervalv2.Calculator
+	cfg                *setting.Cfg
+	log                log.Logger
 }
 
 // ServiceImpl implements the dashboardsnapshots Service interface
 var _ dashboardsnapshots.Service = (*ServiceImpl)(nil)
 
-func ProvideService(store dashboardsnapshots.Store, secretsService secrets.Service) *ServiceImpl {
+func ProvideService(store dashboardsnapshots.Store, secretsService secrets.Service, queryDataService query.Service, cfg *setting.Cfg) *ServiceImpl {
 	s := &ServiceImpl{
-		store:          store,
-		secretsService: secretsService,
+		store:              store,
+		secretsService:     secretsService,
+		queryDataService:   queryDataService,
+		intervalCalculator: intervalv2.NewCalculator(),
+		cfg:                cfg,
+		log:                log.New("dashboardsnapshots"),
 	}
 
 	return s
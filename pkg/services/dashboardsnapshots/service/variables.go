@@ -0,0 +1,213 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
+	"github.com/grafana/grafana/pkg/services/datasources"
+)
+
+const allValue = "$__all"
+
+// variableRegexp matches the variable syntaxes supported by the frontend: $var, [[var]],
+// [[var:format]], ${var} and ${var:format}.
+var variableRegexp = regexp.MustCompile(`\$(\w+)|\[\[(\w+?)(?::(\w+))?\]\]|\$\{(\w+)(?:\.[^:^\}]+)?(?::([^\}]+))?\}`)
+
+// resolveVariables returns the values of the dashboard variables, by name. Values set in
+// the command take precedence over the current values saved in the dashboard.
+func resolveVariables(dash *simplejson.Json, overrides map[string]dashboardsnapshots.SnapshotVariableValue) map[string][]string {
+	values := map[string][]string{}
+	for _, obj := range dash.Get("templating").Get("list").MustArray() {
+		variable := simplejson.NewFromAny(obj)
+		name := variable.Get("name").MustString()
+		if name == "" {
+			continue
+		}
+
+		current := jsonStrings(variable.Get("current").Get("value"))
+		if override, ok := overrides[name]; ok {
+			current = override
+		}
+
+		if len(current) == 1 && current[0] == allValue {
+			current = allValues(variable)
+		}
+		values[name] = current
+	}
+	return values
+}
+
+// allValues expands the All option to the custom all value, or to every option of the variable.
+func allValues(variable *simplejson.Json) []string {
+	if custom := variable.Get("allValue").MustString(); custom != "" {
+		return []string{custom}
+	}
+	values := []string{}
+	for _, obj := range variable.Get("options").MustArray() {
+		for _, v := range jsonStrings(simplejson.NewFromAny(obj).Get("value")) {
+			if v != allValue {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+func jsonStrings(j *simplejson.Json) []string {
+	if s, err := j.String(); err == nil {
+		return []string{s}
+	}
+	values := []string{}
+	for _, v := range j.MustArray() {
+		values = append(values, fmt.Sprintf("%v", v))
+	}
+	return values
+}
+
+// interpolate replaces the variables found in every string of a query model. Multi value
+// variables without an explicit format use the default format of the data source type.
+func interpolate(value interface{}, variables map[string][]string, datasourceType string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return interpolateString(v, variables, datasourceType)
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = interpolate(item, variables, datasourceType)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = interpolate(item, variables, datasourceType)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func interpolateString(s string, variables map[string][]string, datasourceType string) string {
+	return variableRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		groups := variableRegexp.FindStringSubmatch(match)
+		name, format := groups[1], ""
+		switch {
+		case groups[2] != "":
+			name, format = groups[2], groups[3]
+		case groups[4] != "":
+			name, format = groups[4], groups[5]
+		}
+
+		values, ok := variables[name]
+		if !ok {
+			// Unknown variables, like the ones handled by the data sources, are kept
+			return match
+		}
+		if format == "" {
+			if len(values) == 1 {
+				return values[0]
+			}
+			format = defaultFormat(datasourceType)
+		}
+		return formatValues(values, format)
+	})
+}
+
+// defaultFormat returns the format the frontend applies to multi value variables
+// interpolated in the queries of a data source type.
+func defaultFormat(datasourceType string) string {
+	switch datasourceType {
+	case datasources.DS_PROMETHEUS, datasources.DS_LOKI:
+		return "promregex"
+	case datasources.DS_INFLUXDB:
+		return "regex"
+	case datasources.DS_MYSQL, datasources.DS_POSTGRES, datasources.DS_MSSQL:
+		return "sqlstring"
+	case datasources.DS_ES, datasources.DS_ES_OPENSEARCH, datasources.DS_ES_OPEN_DISTRO:
+		return "lucene"
+	default:
+		return "glob"
+	}
+}
+
+func formatValues(values []string, format string) string {
+	if len(values) == 1 && format == "" {
+		return values[0]
+	}
+
+	switch format {
+	case "csv", "raw":
+		return strings.Join(values, ",")
+	case "pipe":
+		return strings.Join(values, "|")
+	case "regex":
+		escaped := make([]string, 0, len(values))
+		for _, v := range values {
+			escaped = append(escaped, regexp.QuoteMeta(v))
+		}
+		if len(escaped) == 1 {
+			return escaped[0]
+		}
+		return "(" + strings.Join(escaped, "|") + ")"
+	case "promregex":
+		// Prometheus and Loki escape the regex twice as the expression is also a string literal
+		escaped := make([]string, 0, len(values))
+		for _, v := range values {
+			escaped = append(escaped, promRegexEscaper.Replace(v))
+		}
+		return "(" + strings.Join(escaped, "|") + ")"
+	case "sqlstring":
+		quoted := make([]string, 0, len(values))
+		for _, v := range values {
+			quoted = append(quoted, "'"+strings.ReplaceAll(v, "'", "''")+"'")
+		}
+		return strings.Join(quoted, ",")
+	case "lucene":
+		if len(values) == 1 {
+			return luceneEscape(values[0])
+		}
+		quoted := make([]string, 0, len(values))
+		for _, v := range values {
+			quoted = append(quoted, `"`+luceneEscape(v)+`"`)
+		}
+		return "(" + strings.Join(quoted, " OR ") + ")"
+	case "json":
+		b, _ := json.Marshal(values)
+		return string(b)
+	case "singlequote":
+		return quoteValues(values, "'")
+	case "doublequote":
+		return quoteValues(values, `"`)
+	default:
+		// glob is the default format of multi value variables
+		if len(values) == 1 {
+			return values[0]
+		}
+		return "{" + strings.Join(values, ",") + "}"
+	}
+}
+
+var promRegexEscaper = strings.NewReplacer(
+	`\`, `\\\\`, "$", `\\$`, "^", `\\^`, "*", `\\*`, "{", `\\{`, "}", `\\}`, "[", `\\[`, "]", `\\]`,
+	"'", `\\'`, "+", `\\+`, "?", `\\?`, ".", `\\.`, "(", `\\(`, ")", `\\)`, "|", `\\|`,
+)
+
+var luceneSpecialChars = regexp.MustCompile(`([\\!"+\-&|(){}\[\]^~*?:/])`)
+
+func luceneEscape(v string) string {
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return v
+	}
+	return luceneSpecialChars.ReplaceAllString(v, `\$1`)
+}
+
+func quoteValues(values []string, quote string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, quote+strings.ReplaceAll(v, quote, `\`+quote)+quote)
+	}
+	return strings.Join(quoted, ",")
+}
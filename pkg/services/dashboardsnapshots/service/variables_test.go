@@ -0,0 +1,63 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolateString(t *testing.T) {
+	variables := map[string][]string{
+		"host": {"a"},
+		"env":  {"dev", "prod"},
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "$host", expected: "a"},
+		{input: "${host}", expected: "a"},
+		{input: "[[host]]", expected: "a"},
+		{input: "$env", expected: "{dev,prod}"},
+		{input: "${env:csv}", expected: "dev,prod"},
+		{input: "${env:pipe}", expected: "dev|prod"},
+		{input: "[[env:regex]]", expected: "(dev|prod)"},
+		{input: "${env:json}", expected: `["dev","prod"]`},
+		{input: "${env:singlequote}", expected: `'dev','prod'`},
+		{input: "${env:doublequote}", expected: `"dev","prod"`},
+		{input: "rate(x[$__rate_interval]) $unknown", expected: "rate(x[$__rate_interval]) $unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			require.Equal(t, tt.expected, interpolateString(tt.input, variables, ""))
+		})
+	}
+}
+
+func TestInterpolateString_DatasourceDefaultFormat(t *testing.T) {
+	variables := map[string][]string{
+		"host": {"a.b"},
+		"env":  {"dev", "prod.eu", "o'neil"},
+	}
+
+	tests := []struct {
+		datasourceType string
+		input          string
+		expected       string
+	}{
+		{datasourceType: "prometheus", input: `up{env=~"$env"}`, expected: `up{env=~"(dev|prod\\.eu|o\\'neil)"}`},
+		{datasourceType: "loki", input: `{env=~"$env"}`, expected: `{env=~"(dev|prod\\.eu|o\\'neil)"}`},
+		{datasourceType: "influxdb", input: `WHERE env =~ /^$env$/`, expected: `WHERE env =~ /^(dev|prod\.eu|o'neil)$/`},
+		{datasourceType: "postgres", input: `WHERE env IN ($env)`, expected: `WHERE env IN ('dev','prod.eu','o''neil')`},
+		{datasourceType: "elasticsearch", input: `env:$env`, expected: `env:("dev" OR "prod.eu" OR "o'neil")`},
+		{datasourceType: "graphite", input: `servers.$env.cpu`, expected: `servers.{dev,prod.eu,o'neil}.cpu`},
+		{datasourceType: "postgres", input: `WHERE host = '$host'`, expected: `WHERE host = 'a.b'`},
+		{datasourceType: "prometheus", input: `up{env=~"${env:pipe}"}`, expected: `up{env=~"dev|prod.eu|o'neil"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.datasourceType+" "+tt.input, func(t *testing.T) {
+			require.Equal(t, tt.expected, interpolateString(tt.input, variables, tt.datasourceType))
+		})
+	}
+}
@@ -36,6 +36,29 @@ func (_m *MockService) CreateDashboardSnapshot(_a0 context.Context, _a1 *CreateD
 	return r0, r1
 }
 
+// CreateServerSideDashboardSnapshot provides a mock function with given fields: _a0, _a1
+func (_m *MockService) CreateServerSideDashboardSnapshot(_a0 context.Context, _a1 *CreateServerSideDashboardSnapshotCommand) (*DashboardSnapshot, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *DashboardSnapshot
+	if rf, ok := ret.Get(0).(func(context.Context, *CreateServerSideDashboardSnapshotCommand) *DashboardSnapshot); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*DashboardSnapshot)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *CreateServerSideDashboardSnapshotCommand) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteDashboardSnapshot provides a mock function with given fields: _a0, _a1
 func (_m *MockService) DeleteDashboardSnapshot(_a0 context.Context, _a1 *DeleteDashboardSnapshotCommand) error {
 	ret := _m.Called(_a0, _a1)
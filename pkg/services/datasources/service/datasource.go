@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auditlog"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/quota"
@@ -56,7 +57,7 @@ func ProvideService(
 	quotaService quota.Service,
 ) (*Service, error) {
 	dslogger := log.New("datasources")
-	store := &SqlStore{db: db, logger: dslogger}
+	store := &SqlStore{db: db, logger: dslogger, features: features}
 	s := &Service{
 		SQLStore:       store,
 		SecretsStore:   secretsStore,
@@ -172,7 +173,7 @@ func (s *Service) GetDataSourcesByType(ctx context.Context, query *datasources.G
 func (s *Service) AddDataSource(ctx context.Context, cmd *datasources.AddDataSourceCommand) (*datasources.DataSource, error) {
 	var dataSource *datasources.DataSource
 
-	return dataSource, s.db.InTransaction(ctx, func(ctx context.Context) error {
+	err := s.db.InTransaction(ctx, func(ctx context.Context) error {
 		var err error
 
 		cmd.EncryptedSecureJsonData = make(map[string][]byte)
@@ -217,21 +218,53 @@ func (s *Service) AddDataSource(ctx context.Context, cmd *datasources.AddDataSou
 
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        dataSource.OrgID,
+		Action:       auditlog.ActionCreate,
+		ResourceKind: datasources.ScopeRoot,
+		ResourceUID:  dataSource.UID,
+		After:        auditedDataSource(dataSource),
+	})
+	return dataSource, nil
 }
 
 func (s *Service) DeleteDataSource(ctx context.Context, cmd *datasources.DeleteDataSourceCommand) error {
-	return s.db.InTransaction(ctx, func(ctx context.Context) error {
+	var before *datasources.DataSource
+	if auditlog.IsRecording(ctx) {
+		// The data source may not exist, in which case nothing is deleted
+		before, _ = s.SQLStore.GetDataSource(ctx, &datasources.GetDataSourceQuery{ID: cmd.ID, UID: cmd.UID, Name: cmd.Name, OrgID: cmd.OrgID})
+	}
+
+	err := s.db.InTransaction(ctx, func(ctx context.Context) error {
 		cmd.UpdateSecretFn = func() error {
 			return s.SecretsStore.Del(ctx, cmd.OrgID, cmd.Name, kvstore.DataSourceSecretType)
 		}
 
 		return s.SQLStore.DeleteDataSource(ctx, cmd)
 	})
+	if err != nil {
+		return err
+	}
+
+	if before != nil && cmd.DeletedDatasourcesCount > 0 {
+		auditlog.RecordChange(ctx, auditlog.Change{
+			OrgID:        before.OrgID,
+			Action:       auditlog.ActionDelete,
+			ResourceKind: datasources.ScopeRoot,
+			ResourceUID:  before.UID,
+			Before:       auditedDataSource(before),
+		})
+	}
+	return nil
 }
 
 func (s *Service) UpdateDataSource(ctx context.Context, cmd *datasources.UpdateDataSourceCommand) (*datasources.DataSource, error) {
-	var dataSource *datasources.DataSource
-	return dataSource, s.db.InTransaction(ctx, func(ctx context.Context) error {
+	var before, dataSource *datasources.DataSource
+	err := s.db.InTransaction(ctx, func(ctx context.Context) error {
 		var err error
 
 		query := &datasources.GetDataSourceQuery{
@@ -242,6 +275,7 @@ func (s *Service) UpdateDataSource(ctx context.Context, cmd *datasources.UpdateD
 		if err != nil {
 			return err
 		}
+		before = dataSource
 
 		err = s.fillWithSecureJSONData(ctx, cmd, dataSource)
 		if err != nil {
@@ -269,6 +303,26 @@ func (s *Service) UpdateDataSource(ctx context.Context, cmd *datasources.UpdateD
 		dataSource, err = s.SQLStore.UpdateDataSource(ctx, cmd)
 		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        dataSource.OrgID,
+		Action:       auditlog.ActionUpdate,
+		ResourceKind: datasources.ScopeRoot,
+		ResourceUID:  dataSource.UID,
+		Before:       auditedDataSource(before),
+		After:        auditedDataSource(dataSource),
+	})
+	return dataSource, nil
+}
+
+// auditedDataSource returns a copy of a data source without its secrets, to be recorded in the audit log.
+func auditedDataSource(ds *datasources.DataSource) datasources.DataSource {
+	audited := *ds
+	audited.SecureJsonData = nil
+	return audited
 }
 
 func (s *Service) GetDefaultDataSource(ctx context.Context, query *datasources.GetDefaultDataSourceQuery) (*datasources.DataSource, error) {
@@ -16,8 +16,10 @@ import (
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/store"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -36,8 +38,9 @@ type Store interface {
 }
 
 type SqlStore struct {
-	db     db.DB
-	logger log.Logger
+	db       db.DB
+	logger   log.Logger
+	features featuremgmt.FeatureToggles
 }
 
 func CreateStore(db db.DB, logger log.Logger) *SqlStore {
@@ -155,6 +158,10 @@ func (ss *SqlStore) DeleteDataSource(ctx context.Context, cmd *datasources.Delet
 				ac.Scope(datasources.ScopeProvider.GetResourceScope(ds.UID))); errDeletingPerms != nil {
 				return errDeletingPerms
 			}
+
+			if err := ss.insertEntityEvent(sess, ds.OrgID, ds.UID, store.EntityEventTypeDelete); err != nil {
+				return err
+			}
 		}
 
 		if cmd.UpdateSecretFn != nil {
@@ -278,6 +285,9 @@ func (ss *SqlStore) AddDataSource(ctx context.Context, cmd *datasources.AddDataS
 		if err := updateIsDefaultFlag(ds, sess); err != nil {
 			return err
 		}
+		if err := ss.insertEntityEvent(sess, ds.OrgID, ds.UID, store.EntityEventTypeCreate); err != nil {
+			return err
+		}
 
 		if cmd.UpdateSecretFn != nil {
 			if err := cmd.UpdateSecretFn(); err != nil {
@@ -315,6 +325,11 @@ func (ss *SqlStore) UpdateDataSource(ctx context.Context, cmd *datasources.Updat
 			cmd.JsonData = simplejson.New()
 		}
 
+		var previousUID string
+		if _, err := sess.SQL("SELECT uid FROM data_source WHERE id=? AND org_id=?", cmd.ID, cmd.OrgID).Get(&previousUID); err != nil {
+			return err
+		}
+
 		ds = &datasources.DataSource{
 			ID:              cmd.ID,
 			OrgID:           cmd.OrgID,
@@ -371,6 +386,20 @@ func (ss *SqlStore) UpdateDataSource(ctx context.Context, cmd *datasources.Updat
 		}
 
 		err = updateIsDefaultFlag(ds, sess)
+		if err != nil {
+			return err
+		}
+
+		uid := previousUID
+		if ds.UID != "" && ds.UID != previousUID {
+			if err := ss.insertEntityEvent(sess, ds.OrgID, previousUID, store.EntityEventTypeDelete); err != nil {
+				return err
+			}
+			uid = ds.UID
+		}
+		if err := ss.insertEntityEvent(sess, ds.OrgID, uid, store.EntityEventTypeUpdate); err != nil {
+			return err
+		}
 
 		if cmd.UpdateSecretFn != nil {
 			if err := cmd.UpdateSecretFn(); err != nil {
@@ -387,6 +416,19 @@ func (ss *SqlStore) UpdateDataSource(ctx context.Context, cmd *datasources.Updat
 	})
 }
 
+// insertEntityEvent records the change of a data source for the search index.
+func (ss *SqlStore) insertEntityEvent(sess *db.Session, orgID int64, uid string, eventType store.EntityEventType) error {
+	if ss.features == nil || !ss.features.IsEnabled(featuremgmt.FlagPanelTitleSearch) {
+		return nil
+	}
+	_, err := sess.Insert(&store.EntityEvent{
+		EventType: eventType,
+		EntityId:  store.CreateDatabaseEntityId(uid, orgID, store.EntityTypeDataSource),
+		Created:   time.Now().Unix(),
+	})
+	return err
+}
+
 func generateNewDatasourceUid(sess *db.Session, orgId int64) (string, error) {
 	for i := 0; i < 3; i++ {
 		uid := generateNewUid()
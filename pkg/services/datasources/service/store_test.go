@@ -14,6 +14,9 @@ import (
 	"github.com/grafana/grafana/pkg/infra/db"
 	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/store"
 )
 
 func TestIntegrationDataAccess(t *testing.T) {
@@ -293,6 +296,40 @@ func TestIntegrationDataAccess(t *testing.T) {
 		}, time.Second, time.Millisecond)
 	})
 
+	t.Run("records entity events for the search index", func(t *testing.T) {
+		db := db.InitTestDB(t)
+		ss := SqlStore{db: db, features: featuremgmt.WithFeatures(featuremgmt.FlagPanelTitleSearch)}
+		cmd := defaultAddDatasourceCommand
+		cmd.UID = "nisse"
+		ds, err := ss.AddDataSource(context.Background(), &cmd)
+		require.NoError(t, err)
+
+		update := defaultUpdateDatasourceCommand
+		update.ID = ds.ID
+		update.UID = "nisse-updated"
+		_, err = ss.UpdateDataSource(context.Background(), &update)
+		require.NoError(t, err)
+
+		err = ss.DeleteDataSource(context.Background(), &datasources.DeleteDataSourceCommand{UID: "nisse-updated", OrgID: 10})
+		require.NoError(t, err)
+
+		var entityEvents []*store.EntityEvent
+		err = db.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			return sess.OrderBy("id").Find(&entityEvents)
+		})
+		require.NoError(t, err)
+		actual := make([]string, 0, len(entityEvents))
+		for _, e := range entityEvents {
+			actual = append(actual, string(e.EventType)+" "+e.EntityId)
+		}
+		require.Equal(t, []string{
+			"create database/10/datasource/nisse",
+			"delete database/10/datasource/nisse",
+			"update database/10/datasource/nisse-updated",
+			"delete database/10/datasource/nisse-updated",
+		}, actual)
+	})
+
 	t.Run("DeleteDataSourceByName", func(t *testing.T) {
 		db := db.InitTestDB(t)
 		ds := initDatasource(db)
@@ -0,0 +1,40 @@
+package embedtoken
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+const (
+	// QueryParam is the URL query parameter of the embed token, for the pages loaded in iframes
+	QueryParam = "embed_token"
+	// HeaderName is the header of the embed token, for the API requests of embedded pages
+	HeaderName = "X-Grafana-Embed-Token"
+)
+
+type Service interface {
+	// IsEnabled returns true when embed tokens are enabled in the configuration
+	IsEnabled() bool
+	// CreateToken signs a token granting view access to a dashboard or some of its panels
+	CreateToken(ctx context.Context, cmd *CreateTokenCommand) (*TokenDTO, error)
+	// Verify validates a token and returns its claims
+	Verify(ctx context.Context, token string) (*Claims, error)
+	// QueryPanel queries a panel of the dashboard of the claims, with the variables and the time range of the claims
+	QueryPanel(ctx context.Context, claims *Claims, cmd *QueryPanelCommand) (*backend.QueryDataResponse, error)
+}
+
+// TokenFromRequest returns the embed token of a request, from the header or the URL
+func TokenFromRequest(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	if token := r.Header.Get(HeaderName); token != "" {
+		return token
+	}
+	if r.URL == nil {
+		return ""
+	}
+	return r.URL.Query().Get(QueryParam)
+}
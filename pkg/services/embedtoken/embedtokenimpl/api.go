@@ -0,0 +1,81 @@
+package embedtokenimpl
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/embedtoken"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+	uidScope := dashboards.ScopeDashboardsProvider.GetResourceScopeUID(ac.Parameter(":uid"))
+
+	// the creator must be able to view the dashboard shared with the token
+	routeRegister.Post("/api/dashboards/uid/:uid/embed-tokens", middleware.ReqSignedInNoAnonymous,
+		authorize(ac.EvalAll(
+			ac.EvalPermission(dashboards.ActionDashboardsRead, uidScope),
+			ac.EvalPermission(ActionCreate, uidScope),
+		)),
+		routing.Wrap(s.createTokenHandler))
+
+	// panel queries are authorized by the embed token sent with the request
+	routeRegister.Post("/api/embed/dashboards/:uid/panels/:panelId/query", routing.Wrap(s.queryPanelHandler))
+}
+
+func (s *Service) createTokenHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := embedtoken.CreateTokenCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgID
+	cmd.DashboardUID = web.Params(c.Req)[":uid"]
+	cmd.SignedInUser = c.SignedInUser
+
+	token, err := s.CreateToken(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to create embed token", err)
+	}
+	return response.JSON(http.StatusOK, token)
+}
+
+func (s *Service) queryPanelHandler(c *contextmodel.ReqContext) response.Response {
+	claims, err := s.Verify(c.Req.Context(), embedtoken.TokenFromRequest(c.Req))
+	if err != nil {
+		return response.Err(err)
+	}
+	if claims.OrgID != c.OrgID || claims.DashboardUID != web.Params(c.Req)[":uid"] {
+		return response.Err(embedtoken.ErrInvalidToken.Errorf("embed token %s doesn't grant access to the dashboard", claims.ID))
+	}
+
+	panelID, err := strconv.ParseInt(web.Params(c.Req)[":panelId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "panelId is invalid", err)
+	}
+	cmd := embedtoken.QueryPanelCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.PanelID = panelID
+	cmd.SkipDSCache = c.SkipDSCache
+
+	resp, err := s.QueryPanel(c.Req.Context(), claims, &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to query panel", err)
+	}
+
+	statusCode := http.StatusOK
+	for _, res := range resp.Responses {
+		if res.Error != nil {
+			statusCode = http.StatusBadRequest
+		}
+	}
+	return response.JSONStreaming(statusCode, resp)
+}
@@ -0,0 +1,268 @@
+package embedtokenimpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/embedtoken"
+)
+
+const (
+	mixedDataSource = "-- Mixed --"
+	allValue        = "$__all"
+)
+
+// variableRegex matches the variables of queries: $var, [[var]], [[var:format]], ${var} and ${var:format}
+var variableRegex = regexp.MustCompile(`\$(\w+)|\[\[(\w+?)(?::(\w+))?\]\]|\$\{(\w+)(?::([^}]+))?\}`)
+
+// getPanels returns the panels of the dashboard by ID, including the panels of collapsed rows
+func getPanels(dashboard *simplejson.Json) map[int64]*simplejson.Json {
+	panels := map[int64]*simplejson.Json{}
+	addPanels(dashboard.Get("panels").MustArray(), panels)
+	return panels
+}
+
+func addPanels(list []interface{}, panels map[int64]*simplejson.Json) {
+	for _, obj := range list {
+		panel := simplejson.NewFromAny(obj)
+		if panel.Get("type").MustString() == "row" {
+			addPanels(panel.Get("panels").MustArray(), panels)
+			continue
+		}
+		panels[panel.Get("id").MustInt64()] = panel
+	}
+}
+
+// getPanelQueries returns the visible queries of a panel, queries without data source use the data source of the panel
+func getPanelQueries(panel *simplejson.Json) []*simplejson.Json {
+	var queries []*simplejson.Json
+	for _, obj := range panel.Get("targets").MustArray() {
+		// the queries are modified by the interpolation, they're copied to leave the dashboard untouched
+		query, err := simplejson.NewJson(mustMarshal(obj))
+		if err != nil || query.Get("hide").MustBool() {
+			continue
+		}
+		if _, ok := query.CheckGet("datasource"); !ok {
+			query.Set("datasource", panel.Get("datasource").Interface())
+		}
+		queries = append(queries, query)
+	}
+	return queries
+}
+
+func getDataSourceUID(query *simplejson.Json) string {
+	uid := query.Get("datasource").Get("uid").MustString()
+	// before 8.3 the data source of queries could be a name
+	if uid == "" {
+		uid = query.Get("datasource").MustString()
+	}
+	if uid == mixedDataSource {
+		return ""
+	}
+	return uid
+}
+
+// getVariables returns the variables of the dashboard by name
+func getVariables(dashboard *simplejson.Json) map[string]*simplejson.Json {
+	variables := map[string]*simplejson.Json{}
+	for _, obj := range dashboard.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(obj)
+		if name := variable.Get("name").MustString(); name != "" {
+			variables[name] = variable
+		}
+	}
+	return variables
+}
+
+// validateVariableValues checks that the values of a token are among the saved values and options
+// of the variable. The values are interpolated in the queries, so free form variables can't be set.
+func validateVariableValues(variable *simplejson.Json, values []string) error {
+	name := variable.Get("name").MustString()
+	switch variable.Get("type").MustString() {
+	case "textbox", "adhoc", "constant":
+		return fmt.Errorf("variable %s of type %s can't be set", name, variable.Get("type").MustString())
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("variable %s has no value", name)
+	}
+
+	allowed := stringValues(variable.GetPath("current", "value").Interface())
+	for _, obj := range variable.Get("options").MustArray() {
+		allowed = append(allowed, stringValues(simplejson.NewFromAny(obj).Get("value").Interface())...)
+	}
+	if variable.Get("includeAll").MustBool() {
+		allowed = append(allowed, allValue)
+	}
+	for _, value := range values {
+		if !slices.Contains(allowed, value) {
+			return fmt.Errorf("value %q isn't an option of variable %s", value, name)
+		}
+	}
+	return nil
+}
+
+// resolveVariables returns the values of the dashboard variables, the current values saved with
+// the dashboard are replaced by the values of the token
+func resolveVariables(dashboard *simplejson.Json, fixed map[string][]string) (map[string][]string, error) {
+	values := map[string][]string{}
+	for name, variable := range getVariables(dashboard) {
+		switch variable.Get("type").MustString() {
+		case "adhoc":
+			continue
+		case "constant":
+			values[name] = []string{variable.Get("query").MustString()}
+			continue
+		}
+
+		current := fixed[name]
+		if current != nil {
+			// the dashboard may have changed since the token was created
+			if err := validateVariableValues(variable, current); err != nil {
+				return nil, embedtoken.ErrInvalidVariable.Errorf("%w", err)
+			}
+		} else {
+			current = stringValues(variable.GetPath("current", "value").Interface())
+		}
+		if len(current) == 1 && current[0] == allValue {
+			all, err := allValues(variable)
+			if err != nil {
+				return nil, err
+			}
+			current = all
+		}
+		values[name] = current
+	}
+	return values, nil
+}
+
+// allValues returns the values of a variable set to All
+func allValues(variable *simplejson.Json) ([]string, error) {
+	if custom := variable.Get("allValue").MustString(); custom != "" {
+		return []string{custom}, nil
+	}
+	var values []string
+	for _, obj := range variable.Get("options").MustArray() {
+		if value := simplejson.NewFromAny(obj).Get("value").MustString(); value != "" && value != allValue {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return nil, embedtoken.ErrInvalidVariable.Errorf("variable %s is set to All without custom all value or saved options", variable.Get("name").MustString())
+	}
+	return values, nil
+}
+
+func stringValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	case nil:
+		return nil
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// interpolateQuery replaces the variables in the strings of the query, the variables
+// unknown to the dashboard such as $__interval are left to the data sources
+func interpolateQuery(query *simplejson.Json, variables map[string][]string) {
+	for key, value := range query.MustMap() {
+		if key == "datasource" {
+			continue
+		}
+		query.Set(key, interpolateValue(value, variables))
+	}
+}
+
+func interpolateValue(value interface{}, variables map[string][]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return interpolateString(v, variables)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = interpolateValue(item, variables)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = interpolateValue(item, variables)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func interpolateString(s string, variables map[string][]string) string {
+	return variableRegex.ReplaceAllStringFunc(s, func(match string) string {
+		groups := variableRegex.FindStringSubmatch(match)
+		name, format := groups[1], ""
+		switch {
+		case groups[2] != "":
+			name, format = groups[2], groups[3]
+		case groups[4] != "":
+			name, format = groups[4], groups[5]
+		}
+		values, ok := variables[name]
+		if !ok {
+			return match
+		}
+		return formatValues(values, format)
+	})
+}
+
+// formatValues formats the values of a variable like the variable formats of the dashboards
+func formatValues(values []string, format string) string {
+	switch format {
+	case "raw", "csv":
+		return strings.Join(values, ",")
+	case "pipe":
+		return strings.Join(values, "|")
+	case "regex":
+		quoted := make([]string, len(values))
+		for i, value := range values {
+			quoted[i] = regexp.QuoteMeta(value)
+		}
+		if len(quoted) == 1 {
+			return quoted[0]
+		}
+		return "(" + strings.Join(quoted, "|") + ")"
+	case "singlequote", "doublequote":
+		quote := "'"
+		if format == "doublequote" {
+			quote = `"`
+		}
+		quoted := make([]string, len(values))
+		for i, value := range values {
+			quoted[i] = quote + strings.ReplaceAll(value, quote, `\`+quote) + quote
+		}
+		return strings.Join(quoted, ",")
+	case "json":
+		if len(values) == 1 {
+			return string(mustMarshal(values[0]))
+		}
+		return string(mustMarshal(values))
+	default:
+		// glob
+		if len(values) == 1 {
+			return values[0]
+		}
+		return "{" + strings.Join(values, ",") + "}"
+	}
+}
+
+func mustMarshal(v interface{}) []byte {
+	b, _ := json.Marshal(v)
+	return b
+}
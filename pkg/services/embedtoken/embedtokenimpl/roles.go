@@ -0,0 +1,32 @@
+package embedtokenimpl
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+const (
+	ActionCreate = "dashboards.embed:create"
+)
+
+var (
+	embedTokenCreatorRole = accesscontrol.RoleDTO{
+		Name:        "fixed:dashboards.embed:creator",
+		DisplayName: "Embed token creator",
+		Description: "Create embed tokens granting view access to dashboards.",
+		Group:       "Dashboards",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionCreate, Scope: dashboards.ScopeDashboardsAll},
+		},
+	}
+)
+
+func declareFixedRoles(ac accesscontrol.Service) error {
+	creator := accesscontrol.RoleRegistration{
+		Role:   embedTokenCreatorRole,
+		Grants: []string{string(org.RoleAdmin)},
+	}
+
+	return ac.DeclareFixedRoles(creator)
+}
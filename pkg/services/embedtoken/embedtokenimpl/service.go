@@ -0,0 +1,388 @@
+package embedtokenimpl
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"golang.org/x/exp/slices"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/embedtoken"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/signingkeys"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var _ embedtoken.Service = (*Service)(nil)
+
+const (
+	// tokenType is the typ header of embed tokens, it tells them apart from the other tokens signed by Grafana
+	tokenType = "embed+jwt"
+	audience  = "grafana-embed"
+	// maxDataPoints is the resolution limit of panel queries
+	maxDataPoints = 11000
+)
+
+var acceptedAlgorithms = []string{string(jose.ES256), string(jose.RS256)}
+
+type Service struct {
+	signingKeys      signingkeys.Service
+	dashboardService dashboards.DashboardService
+	queryService     query.Service
+	accessControl    ac.AccessControl
+	log              log.Logger
+	now              func() time.Time
+
+	enabled         bool
+	issuer          string
+	defaultLifetime time.Duration
+	maxLifetime     time.Duration
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	routeRegister routing.RouteRegister,
+	accessControl ac.AccessControl,
+	accesscontrolService ac.Service,
+	signingKeys signingkeys.Service,
+	dashboardService dashboards.DashboardService,
+	queryService query.Service,
+) (*Service, error) {
+	section := cfg.SectionWithEnvOverrides("auth.embed_tokens")
+	s := &Service{
+		signingKeys:      signingKeys,
+		dashboardService: dashboardService,
+		queryService:     queryService,
+		accessControl:    accessControl,
+		log:              log.New("embedtoken"),
+		now:              time.Now,
+		enabled:          section.Key("enabled").MustBool(false),
+		issuer:           cfg.AppURL,
+		defaultLifetime:  section.Key("default_lifetime").MustDuration(10 * time.Minute),
+		maxLifetime:      section.Key("max_lifetime").MustDuration(time.Hour),
+	}
+	if s.maxLifetime <= 0 {
+		return nil, fmt.Errorf("invalid auth.embed_tokens max_lifetime: %s", s.maxLifetime)
+	}
+	if s.defaultLifetime <= 0 || s.defaultLifetime > s.maxLifetime {
+		return nil, fmt.Errorf("invalid auth.embed_tokens default_lifetime: %s, it must be within max_lifetime", s.defaultLifetime)
+	}
+
+	if err := declareFixedRoles(accesscontrolService); err != nil {
+		return nil, err
+	}
+
+	s.registerAPIEndpoints(routeRegister)
+
+	return s, nil
+}
+
+func (s *Service) IsEnabled() bool {
+	return s.enabled
+}
+
+func (s *Service) CreateToken(ctx context.Context, cmd *embedtoken.CreateTokenCommand) (*embedtoken.TokenDTO, error) {
+	if !s.enabled {
+		return nil, embedtoken.ErrDisabled.Errorf("embed tokens are disabled")
+	}
+
+	lifetime := time.Duration(cmd.ExpiresIn) * time.Second
+	if cmd.ExpiresIn == 0 {
+		lifetime = s.defaultLifetime
+	}
+	if lifetime <= 0 || lifetime > s.maxLifetime {
+		return nil, embedtoken.ErrInvalidCommand.Errorf("expiresIn must be between 1 and %d seconds", int64(s.maxLifetime.Seconds()))
+	}
+
+	dashboard, err := s.getDashboard(ctx, cmd.OrgID, cmd.DashboardUID)
+	if err != nil {
+		return nil, err
+	}
+
+	panels := getPanels(dashboard.Data)
+	embedded := make([]*simplejson.Json, 0, len(panels))
+	for _, panelID := range cmd.PanelIDs {
+		panel, ok := panels[panelID]
+		if !ok {
+			return nil, embedtoken.ErrInvalidCommand.Errorf("panel %d not found in the dashboard", panelID)
+		}
+		embedded = append(embedded, panel)
+	}
+	if len(cmd.PanelIDs) == 0 {
+		for _, panel := range panels {
+			embedded = append(embedded, panel)
+		}
+	}
+	variables := getVariables(dashboard.Data)
+	for name, values := range cmd.Variables {
+		variable, ok := variables[name]
+		if !ok {
+			return nil, embedtoken.ErrInvalidCommand.Errorf("variable %s not found in the dashboard", name)
+		}
+		if err := validateVariableValues(variable, values); err != nil {
+			return nil, embedtoken.ErrInvalidCommand.Errorf("%w", err)
+		}
+	}
+	if err := validateTimeRange(cmd.TimeRange); err != nil {
+		return nil, err
+	}
+
+	// the token runs the queries of the panels, the creator must be allowed to run them
+	if err := s.checkDataSourceAccess(ctx, cmd.SignedInUser, embedded); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	expiry := now.Add(lifetime)
+	claims := embedtoken.Claims{
+		Claims: jwt.Claims{
+			ID:        util.GenerateShortUID(),
+			Issuer:    s.issuer,
+			Subject:   fmt.Sprintf("user:id:%d", cmd.SignedInUser.UserID),
+			Audience:  jwt.Audience{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Expiry:    jwt.NewNumericDate(expiry),
+		},
+		OrgID:        cmd.OrgID,
+		DashboardUID: dashboard.UID,
+		PanelIDs:     cmd.PanelIDs,
+		Variables:    cmd.Variables,
+		TimeRange:    cmd.TimeRange,
+	}
+
+	token, err := s.sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.FromContext(ctx).Info("Created embed token", "id", claims.ID, "dashboardUid", dashboard.UID, "userId", cmd.SignedInUser.UserID, "expiresAt", expiry)
+	return &embedtoken.TokenDTO{Token: token, ExpiresAt: expiry.UTC()}, nil
+}
+
+func (s *Service) sign(claims embedtoken.Claims) (string, error) {
+	key := s.signingKeys.GetServerPrivateKey()
+
+	var algorithm jose.SignatureAlgorithm
+	switch key.(type) {
+	case *ecdsa.PrivateKey:
+		algorithm = jose.ES256
+	case *rsa.PrivateKey:
+		algorithm = jose.RS256
+	default:
+		return "", fmt.Errorf("unsupported signing key type %T", key)
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: algorithm, Key: key}, (&jose.SignerOptions{}).WithType(tokenType))
+	if err != nil {
+		return "", fmt.Errorf("failed to create signer: %w", err)
+	}
+	return jwt.Signed(signer).Claims(claims).CompactSerialize()
+}
+
+func (s *Service) Verify(ctx context.Context, token string) (*embedtoken.Claims, error) {
+	if !s.enabled {
+		return nil, embedtoken.ErrDisabled.Errorf("embed tokens are disabled")
+	}
+
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, embedtoken.ErrInvalidToken.Errorf("failed to parse token: %w", err)
+	}
+	if len(parsed.Headers) != 1 {
+		return nil, embedtoken.ErrInvalidToken.Errorf("only one header supported, got %d", len(parsed.Headers))
+	}
+	header := parsed.Headers[0]
+	if typ, _ := header.ExtraHeaders[jose.HeaderType].(string); typ != tokenType {
+		return nil, embedtoken.ErrInvalidToken.Errorf("invalid token type: %s", typ)
+	}
+	if !slices.Contains(acceptedAlgorithms, header.Algorithm) {
+		return nil, embedtoken.ErrInvalidToken.Errorf("invalid algorithm: %s", header.Algorithm)
+	}
+
+	var claims embedtoken.Claims
+	if err := parsed.Claims(s.signingKeys.GetServerPublicKey(), &claims); err != nil {
+		return nil, embedtoken.ErrInvalidToken.Errorf("failed to verify the signature: %w", err)
+	}
+	if claims.Expiry == nil || claims.IssuedAt == nil {
+		return nil, embedtoken.ErrInvalidToken.Errorf("missing 'exp' or 'iat' claim")
+	}
+	if claims.Expiry.Time().Sub(claims.IssuedAt.Time()) > s.maxLifetime {
+		return nil, embedtoken.ErrInvalidToken.Errorf("token lifetime exceeds max_lifetime")
+	}
+	if err := claims.ValidateWithLeeway(jwt.Expected{
+		Issuer:   s.issuer,
+		Audience: jwt.Audience{audience},
+		Time:     s.now(),
+	}, 0); err != nil {
+		return nil, embedtoken.ErrInvalidToken.Errorf("failed to validate token: %w", err)
+	}
+	if claims.OrgID <= 0 || claims.DashboardUID == "" {
+		return nil, embedtoken.ErrInvalidToken.Errorf("missing 'org_id' or 'dashboard_uid' claim")
+	}
+
+	return &claims, nil
+}
+
+func (s *Service) QueryPanel(ctx context.Context, claims *embedtoken.Claims, cmd *embedtoken.QueryPanelCommand) (*backend.QueryDataResponse, error) {
+	if !claims.AllowsPanel(cmd.PanelID) {
+		return nil, embedtoken.ErrPanelNotAllowed.Errorf("panel %d isn't allowed by embed token %s", cmd.PanelID, claims.ID)
+	}
+
+	dashboard, err := s.getDashboard(ctx, claims.OrgID, claims.DashboardUID)
+	if err != nil {
+		return nil, err
+	}
+	panel, ok := getPanels(dashboard.Data)[cmd.PanelID]
+	if !ok {
+		return nil, embedtoken.ErrPanelNotFound.Errorf("panel %d not found in dashboard %s", cmd.PanelID, dashboard.UID)
+	}
+
+	variables, err := resolveVariables(dashboard.Data, claims.Variables)
+	if err != nil {
+		return nil, err
+	}
+	queries := getPanelQueries(panel)
+	if len(queries) == 0 {
+		return nil, embedtoken.ErrPanelNotFound.Errorf("panel %d has no queries", cmd.PanelID)
+	}
+
+	maxPoints := cmd.MaxDataPoints
+	if maxPoints > maxDataPoints {
+		maxPoints = maxDataPoints
+	}
+	for _, q := range queries {
+		interpolateQuery(q, variables)
+		if cmd.IntervalMs > 0 {
+			q.Set("intervalMs", cmd.IntervalMs)
+		}
+		if maxPoints > 0 {
+			q.Set("maxDataPoints", maxPoints)
+		}
+	}
+
+	from, to, err := queryTimeRange(dashboard.Data, claims.TimeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queryService.QueryData(ctx, queryUser(claims.OrgID, queries), cmd.SkipDSCache, dtos.MetricRequest{
+		From:    from,
+		To:      to,
+		Queries: queries,
+	})
+}
+
+func (s *Service) getDashboard(ctx context.Context, orgID int64, uid string) (*dashboards.Dashboard, error) {
+	dashboard, err := s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{OrgID: orgID, UID: uid})
+	if err != nil {
+		if errors.Is(err, dashboards.ErrDashboardNotFound) {
+			return nil, embedtoken.ErrDashboardNotFound.Errorf("dashboard %s not found", uid)
+		}
+		return nil, err
+	}
+	return dashboard, nil
+}
+
+// checkDataSourceAccess checks that the user can query the data sources of the panels
+func (s *Service) checkDataSourceAccess(ctx context.Context, usr *user.SignedInUser, panels []*simplejson.Json) error {
+	var queries []*simplejson.Json
+	for _, panel := range panels {
+		queries = append(queries, getPanelQueries(panel)...)
+	}
+	for _, scope := range dataSourceScopes(queries) {
+		ok, err := s.accessControl.Evaluate(ctx, usr, ac.EvalPermission(datasources.ActionQuery, scope))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return embedtoken.ErrDataSourceAccessDenied.Errorf("user %d can't query %s", usr.UserID, scope)
+		}
+	}
+	return nil
+}
+
+// dataSourceScopes returns the scopes of the data sources of the queries
+func dataSourceScopes(queries []*simplejson.Json) []string {
+	scopes := []string{}
+	for _, q := range queries {
+		uid := getDataSourceUID(q)
+		scope := datasources.ScopeProvider.GetResourceScopeUID(uid)
+		if uid != "" && !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// queryUser returns the user running the queries of the panel, it can only query the data sources of the queries
+func queryUser(orgID int64, queries []*simplejson.Json) *user.SignedInUser {
+	scopes := dataSourceScopes(queries)
+	return &user.SignedInUser{
+		OrgID: orgID,
+		Permissions: map[int64]map[string][]string{
+			orgID: {
+				datasources.ActionQuery: scopes,
+				datasources.ActionRead:  scopes,
+			},
+		},
+	}
+}
+
+func validateTimeRange(tr embedtoken.TimeRange) error {
+	if tr.From == "" && tr.To == "" {
+		return nil
+	}
+	if tr.From == "" || tr.To == "" {
+		return embedtoken.ErrInvalidCommand.Errorf("time range requires from and to")
+	}
+	timeRange := legacydata.NewDataTimeRange(tr.From, tr.To)
+	if _, err := timeRange.ParseFrom(); err != nil {
+		return embedtoken.ErrInvalidCommand.Errorf("invalid time range from: %w", err)
+	}
+	if _, err := timeRange.ParseTo(); err != nil {
+		return embedtoken.ErrInvalidCommand.Errorf("invalid time range to: %w", err)
+	}
+	return nil
+}
+
+// queryTimeRange returns the time range of the queries in epoch milliseconds, relative
+// times are resolved in the timezone of the dashboard
+func queryTimeRange(dashboard *simplejson.Json, tr embedtoken.TimeRange) (string, string, error) {
+	if tr.From == "" || tr.To == "" {
+		tr.From = dashboard.GetPath("time", "from").MustString("now-6h")
+		tr.To = dashboard.GetPath("time", "to").MustString("now")
+	}
+	location, err := time.LoadLocation(dashboard.Get("timezone").MustString())
+	if err != nil {
+		location = time.UTC
+	}
+
+	timeRange := legacydata.NewDataTimeRange(tr.From, tr.To)
+	from, err := timeRange.ParseFrom(legacydata.WithLocation(location))
+	if err != nil {
+		return "", "", embedtoken.ErrInvalidCommand.Errorf("invalid time range from: %w", err)
+	}
+	to, err := timeRange.ParseTo(legacydata.WithLocation(location))
+	if err != nil {
+		return "", "", embedtoken.ErrInvalidCommand.Errorf("invalid time range to: %w", err)
+	}
+	return strconv.FormatInt(from.UnixMilli(), 10), strconv.FormatInt(to.UnixMilli(), 10), nil
+}
@@ -0,0 +1,345 @@
+package embedtokenimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/embedtoken"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/signingkeys/signingkeysimpl"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const testDashboard = `{
+	"uid": "dash",
+	"time": {"from": "now-6h", "to": "now"},
+	"timezone": "utc",
+	"templating": {"list": [
+		{"name": "env", "type": "custom", "current": {"value": "dev"}, "options": [{"value": "dev"}, {"value": "prod"}]},
+		{"name": "host", "type": "query", "current": {"value": ["a", "b"]}},
+		{"name": "region", "type": "custom", "current": {"value": "$__all"}, "options": [{"value": "$__all"}, {"value": "eu"}, {"value": "us"}]},
+		{"name": "team", "type": "constant", "query": "core"},
+		{"name": "filter", "type": "textbox", "current": {"value": ""}}
+	]},
+	"panels": [
+		{"id": 1, "type": "timeseries", "datasource": {"uid": "prom"}, "targets": [
+			{"refId": "A", "expr": "up{env=\"$env\", host=~\"${host:regex}\", region=~\"${region:regex}\", team=\"[[team]]\"}[$__interval]"},
+			{"refId": "B", "expr": "hidden", "hide": true}
+		]},
+		{"id": 2, "type": "row", "collapsed": true, "panels": [
+			{"id": 3, "type": "stat", "datasource": {"uid": "-- Mixed --"}, "targets": [
+				{"refId": "A", "datasource": {"uid": "loki"}, "expr": "{env=\"$env\"}"},
+				{"refId": "B", "datasource": {"uid": "prom"}, "expr": "up"}
+			]}
+		]}
+	]
+}`
+
+func TestService_CreateToken(t *testing.T) {
+	ctx := context.Background()
+	cmd := func() *embedtoken.CreateTokenCommand {
+		return &embedtoken.CreateTokenCommand{
+			OrgID:        1,
+			DashboardUID: "dash",
+			SignedInUser: testUser("prom", "loki"),
+			PanelIDs:     []int64{1, 3},
+			Variables:    map[string][]string{"env": {"prod"}},
+			TimeRange:    embedtoken.TimeRange{From: "now-1h", To: "now"},
+		}
+	}
+
+	t.Run("creates a token verified by the service", func(t *testing.T) {
+		s := setupTestService(t)
+
+		token, err := s.CreateToken(ctx, cmd())
+		require.NoError(t, err)
+		assert.Equal(t, s.now().Add(s.defaultLifetime).UTC(), token.ExpiresAt)
+
+		claims, err := s.Verify(ctx, token.Token)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), claims.OrgID)
+		assert.Equal(t, "dash", claims.DashboardUID)
+		assert.Equal(t, []int64{1, 3}, claims.PanelIDs)
+		assert.Equal(t, map[string][]string{"env": {"prod"}}, claims.Variables)
+		assert.Equal(t, embedtoken.TimeRange{From: "now-1h", To: "now"}, claims.TimeRange)
+		assert.Equal(t, "user:id:10", claims.Subject)
+		assert.NotEmpty(t, claims.ID)
+	})
+
+	t.Run("fails when disabled", func(t *testing.T) {
+		s := setupTestService(t)
+		s.enabled = false
+
+		_, err := s.CreateToken(ctx, cmd())
+		require.ErrorIs(t, err, embedtoken.ErrDisabled)
+	})
+
+	t.Run("fails with invalid commands", func(t *testing.T) {
+		s := setupTestService(t)
+
+		tooLong := cmd()
+		tooLong.ExpiresIn = int64((2 * time.Hour).Seconds())
+		unknownPanel := cmd()
+		unknownPanel.PanelIDs = []int64{2}
+		unknownVariable := cmd()
+		unknownVariable.Variables = map[string][]string{"cluster": {"a"}}
+		emptyVariable := cmd()
+		emptyVariable.Variables = map[string][]string{"env": {}}
+		unknownValue := cmd()
+		unknownValue.Variables = map[string][]string{"env": {"prod\"} or vector(1) or up{env=\"prod"}}
+		freeFormVariable := cmd()
+		freeFormVariable.Variables = map[string][]string{"filter": {"anything"}}
+		allValueNotIncluded := cmd()
+		allValueNotIncluded.Variables = map[string][]string{"env": {"$__all"}}
+		invalidTimeRange := cmd()
+		invalidTimeRange.TimeRange = embedtoken.TimeRange{From: "yesterday", To: "now"}
+		partialTimeRange := cmd()
+		partialTimeRange.TimeRange = embedtoken.TimeRange{From: "now-1h"}
+
+		for _, c := range []*embedtoken.CreateTokenCommand{tooLong, unknownPanel, unknownVariable, emptyVariable, unknownValue, freeFormVariable, allValueNotIncluded, invalidTimeRange, partialTimeRange} {
+			_, err := s.CreateToken(ctx, c)
+			assert.ErrorIs(t, err, embedtoken.ErrInvalidCommand)
+		}
+	})
+
+	t.Run("accepts the saved values and options of the variables", func(t *testing.T) {
+		s := setupTestService(t)
+		c := cmd()
+		c.Variables = map[string][]string{"host": {"b"}, "region": {"$__all"}}
+
+		_, err := s.CreateToken(ctx, c)
+		require.NoError(t, err)
+	})
+
+	t.Run("fails when the creator can't query the data sources of the panels", func(t *testing.T) {
+		s := setupTestService(t)
+		c := cmd()
+		c.SignedInUser = testUser("prom")
+
+		_, err := s.CreateToken(ctx, c)
+		require.ErrorIs(t, err, embedtoken.ErrDataSourceAccessDenied)
+
+		// all the panels are embedded without panel IDs
+		c.PanelIDs = nil
+		_, err = s.CreateToken(ctx, c)
+		require.ErrorIs(t, err, embedtoken.ErrDataSourceAccessDenied)
+
+		c.PanelIDs = []int64{1}
+		_, err = s.CreateToken(ctx, c)
+		require.NoError(t, err)
+	})
+
+	t.Run("fails when the dashboard doesn't exist", func(t *testing.T) {
+		s := setupTestService(t)
+		c := cmd()
+		c.DashboardUID = "unknown"
+
+		_, err := s.CreateToken(ctx, c)
+		require.ErrorIs(t, err, embedtoken.ErrDashboardNotFound)
+	})
+}
+
+func TestService_Verify(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rejects expired tokens", func(t *testing.T) {
+		s := setupTestService(t)
+		token, err := s.CreateToken(ctx, &embedtoken.CreateTokenCommand{OrgID: 1, DashboardUID: "dash", SignedInUser: testUser("prom", "loki"), ExpiresIn: 60})
+		require.NoError(t, err)
+
+		now := s.now()
+		s.now = func() time.Time { return now.Add(2 * time.Minute) }
+		_, err = s.Verify(ctx, token.Token)
+		require.ErrorIs(t, err, embedtoken.ErrInvalidToken)
+	})
+
+	t.Run("rejects tokens signed by another key", func(t *testing.T) {
+		other := setupTestService(t)
+		token, err := other.CreateToken(ctx, &embedtoken.CreateTokenCommand{OrgID: 1, DashboardUID: "dash", SignedInUser: testUser("prom", "loki")})
+		require.NoError(t, err)
+
+		_, err = setupTestService(t).Verify(ctx, token.Token)
+		require.ErrorIs(t, err, embedtoken.ErrInvalidToken)
+	})
+
+	t.Run("rejects other tokens signed by the server", func(t *testing.T) {
+		s := setupTestService(t)
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: s.signingKeys.GetServerPrivateKey()}, (&jose.SignerOptions{}).WithType("at+jwt"))
+		require.NoError(t, err)
+		token, err := jwt.Signed(signer).Claims(embedtoken.Claims{
+			Claims: jwt.Claims{
+				Issuer:   s.issuer,
+				Audience: jwt.Audience{audience},
+				IssuedAt: jwt.NewNumericDate(s.now()),
+				Expiry:   jwt.NewNumericDate(s.now().Add(time.Minute)),
+			},
+			OrgID:        1,
+			DashboardUID: "dash",
+		}).CompactSerialize()
+		require.NoError(t, err)
+
+		_, err = s.Verify(ctx, token)
+		require.ErrorIs(t, err, embedtoken.ErrInvalidToken)
+	})
+
+	t.Run("rejects malformed tokens", func(t *testing.T) {
+		_, err := setupTestService(t).Verify(ctx, "not-a-token")
+		require.ErrorIs(t, err, embedtoken.ErrInvalidToken)
+	})
+}
+
+func TestService_QueryPanel(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("queries the panel with the variables of the token", func(t *testing.T) {
+		s := setupTestService(t)
+		queryService := s.queryService.(*query.FakeQueryService)
+		var usr *user.SignedInUser
+		var req dtos.MetricRequest
+		queryService.On("QueryData", mock.Anything, mock.Anything, false, mock.Anything).Run(func(args mock.Arguments) {
+			usr = args.Get(1).(*user.SignedInUser)
+			req = args.Get(3).(dtos.MetricRequest)
+		}).Return(&backend.QueryDataResponse{}, nil)
+
+		claims := &embedtoken.Claims{
+			OrgID:        1,
+			DashboardUID: "dash",
+			PanelIDs:     []int64{1},
+			Variables:    map[string][]string{"env": {"prod"}},
+			TimeRange:    embedtoken.TimeRange{From: "2023-01-01T00:00:00Z", To: "2023-01-02T00:00:00Z"},
+		}
+		_, err := s.QueryPanel(ctx, claims, &embedtoken.QueryPanelCommand{PanelID: 1, IntervalMs: 15000, MaxDataPoints: 20000})
+		require.NoError(t, err)
+
+		assert.Equal(t, "1672531200000", req.From)
+		assert.Equal(t, "1672617600000", req.To)
+		require.Len(t, req.Queries, 1)
+		assert.Equal(t, `up{env="prod", host=~"(a|b)", region=~"(eu|us)", team="core"}[$__interval]`, req.Queries[0].Get("expr").MustString())
+		assert.Equal(t, "prom", req.Queries[0].GetPath("datasource", "uid").MustString())
+		assert.Equal(t, int64(15000), req.Queries[0].Get("intervalMs").MustInt64())
+		assert.Equal(t, int64(maxDataPoints), req.Queries[0].Get("maxDataPoints").MustInt64())
+
+		assert.Equal(t, map[int64]map[string][]string{1: {
+			"datasources:query": {"datasources:uid:prom"},
+			"datasources:read":  {"datasources:uid:prom"},
+		}}, usr.Permissions)
+	})
+
+	t.Run("queries the panels of collapsed rows", func(t *testing.T) {
+		s := setupTestService(t)
+		queryService := s.queryService.(*query.FakeQueryService)
+		var usr *user.SignedInUser
+		var req dtos.MetricRequest
+		queryService.On("QueryData", mock.Anything, mock.Anything, false, mock.Anything).Run(func(args mock.Arguments) {
+			usr = args.Get(1).(*user.SignedInUser)
+			req = args.Get(3).(dtos.MetricRequest)
+		}).Return(&backend.QueryDataResponse{}, nil)
+
+		_, err := s.QueryPanel(ctx, &embedtoken.Claims{OrgID: 1, DashboardUID: "dash"}, &embedtoken.QueryPanelCommand{PanelID: 3})
+		require.NoError(t, err)
+
+		require.Len(t, req.Queries, 2)
+		assert.Equal(t, `{env="dev"}`, req.Queries[0].Get("expr").MustString())
+		assert.Equal(t, []string{"datasources:uid:loki", "datasources:uid:prom"}, usr.Permissions[1]["datasources:query"])
+	})
+
+	t.Run("fails for panels not allowed by the token", func(t *testing.T) {
+		s := setupTestService(t)
+
+		_, err := s.QueryPanel(ctx, &embedtoken.Claims{OrgID: 1, DashboardUID: "dash", PanelIDs: []int64{1}}, &embedtoken.QueryPanelCommand{PanelID: 3})
+		require.ErrorIs(t, err, embedtoken.ErrPanelNotAllowed)
+	})
+
+	t.Run("fails when the variables of the token aren't options anymore", func(t *testing.T) {
+		s := setupTestService(t)
+
+		claims := &embedtoken.Claims{OrgID: 1, DashboardUID: "dash", Variables: map[string][]string{"env": {"staging"}}}
+		_, err := s.QueryPanel(ctx, claims, &embedtoken.QueryPanelCommand{PanelID: 1})
+		require.ErrorIs(t, err, embedtoken.ErrInvalidVariable)
+	})
+
+	t.Run("fails for unknown panels", func(t *testing.T) {
+		s := setupTestService(t)
+
+		_, err := s.QueryPanel(ctx, &embedtoken.Claims{OrgID: 1, DashboardUID: "dash"}, &embedtoken.QueryPanelCommand{PanelID: 4})
+		require.ErrorIs(t, err, embedtoken.ErrPanelNotFound)
+	})
+}
+
+func TestFormatValues(t *testing.T) {
+	tests := []struct {
+		format   string
+		values   []string
+		expected string
+	}{
+		{format: "", values: []string{"a"}, expected: "a"},
+		{format: "", values: []string{"a", "b"}, expected: "{a,b}"},
+		{format: "csv", values: []string{"a", "b"}, expected: "a,b"},
+		{format: "pipe", values: []string{"a", "b"}, expected: "a|b"},
+		{format: "regex", values: []string{"a.b"}, expected: `a\.b`},
+		{format: "regex", values: []string{"a", "b"}, expected: "(a|b)"},
+		{format: "singlequote", values: []string{"a", "b'c"}, expected: `'a','b\'c'`},
+		{format: "doublequote", values: []string{"a", "b"}, expected: `"a","b"`},
+		{format: "json", values: []string{"a"}, expected: `"a"`},
+		{format: "json", values: []string{"a", "b"}, expected: `["a","b"]`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, formatValues(tt.values, tt.format), "format %q of %v", tt.format, tt.values)
+	}
+}
+
+func setupTestService(t *testing.T) *Service {
+	t.Helper()
+
+	signingKeys, err := signingkeysimpl.ProvideEmbeddedSigningKeysService()
+	require.NoError(t, err)
+
+	data, err := simplejson.NewJson([]byte(testDashboard))
+	require.NoError(t, err)
+	dashboardService := dashboards.NewFakeDashboardService(t)
+	dashboardService.On("GetDashboard", mock.Anything, &dashboards.GetDashboardQuery{OrgID: 1, UID: "dash"}).
+		Return(&dashboards.Dashboard{OrgID: 1, UID: "dash", Data: data}, nil).Maybe()
+	dashboardService.On("GetDashboard", mock.Anything, mock.Anything).
+		Return(nil, dashboards.ErrDashboardNotFound).Maybe()
+
+	now := time.Now().Truncate(time.Second)
+	return &Service{
+		signingKeys:      signingKeys,
+		dashboardService: dashboardService,
+		queryService:     query.NewFakeQueryService(t),
+		accessControl:    acimpl.ProvideAccessControl(setting.NewCfg()),
+		log:              log.NewNopLogger(),
+		now:              func() time.Time { return now },
+		enabled:          true,
+		issuer:           "http://localhost:3000/",
+		defaultLifetime:  10 * time.Minute,
+		maxLifetime:      time.Hour,
+	}
+}
+
+// testUser returns a user allowed to query the data sources
+func testUser(uids ...string) *user.SignedInUser {
+	scopes := make([]string, 0, len(uids))
+	for _, uid := range uids {
+		scopes = append(scopes, datasources.ScopeProvider.GetResourceScopeUID(uid))
+	}
+	return &user.SignedInUser{UserID: 10, OrgID: 1, Permissions: map[int64]map[string][]string{
+		1: {datasources.ActionQuery: scopes},
+	}}
+}
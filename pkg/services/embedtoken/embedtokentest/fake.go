@@ -0,0 +1,35 @@
+package embedtokentest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/services/embedtoken"
+)
+
+var _ embedtoken.Service = new(FakeService)
+
+type FakeService struct {
+	ExpectedEnabled  bool
+	ExpectedToken    *embedtoken.TokenDTO
+	ExpectedClaims   *embedtoken.Claims
+	ExpectedResponse *backend.QueryDataResponse
+	ExpectedErr      error
+}
+
+func (f *FakeService) IsEnabled() bool {
+	return f.ExpectedEnabled
+}
+
+func (f *FakeService) CreateToken(ctx context.Context, cmd *embedtoken.CreateTokenCommand) (*embedtoken.TokenDTO, error) {
+	return f.ExpectedToken, f.ExpectedErr
+}
+
+func (f *FakeService) Verify(ctx context.Context, token string) (*embedtoken.Claims, error) {
+	return f.ExpectedClaims, f.ExpectedErr
+}
+
+func (f *FakeService) QueryPanel(ctx context.Context, claims *embedtoken.Claims, cmd *embedtoken.QueryPanelCommand) (*backend.QueryDataResponse, error) {
+	return f.ExpectedResponse, f.ExpectedErr
+}
@@ -0,0 +1,77 @@
+package embedtoken
+
+import (
+	"time"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	"golang.org/x/exp/slices"
+
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrDisabled = errutil.NewBase(errutil.StatusForbidden, "embedtoken.disabled",
+		errutil.WithPublicMessage("Embed tokens are disabled"))
+	ErrInvalidToken = errutil.NewBase(errutil.StatusUnauthorized, "embedtoken.invalid",
+		errutil.WithPublicMessage("Invalid embed token"))
+	ErrInvalidCommand    = errutil.NewBase(errutil.StatusBadRequest, "embedtoken.invalidCommand")
+	ErrDashboardNotFound = errutil.NewBase(errutil.StatusNotFound, "embedtoken.dashboardNotFound",
+		errutil.WithPublicMessage("Dashboard not found"))
+	ErrPanelNotAllowed = errutil.NewBase(errutil.StatusForbidden, "embedtoken.panelNotAllowed",
+		errutil.WithPublicMessage("The embed token doesn't grant access to the panel"))
+	ErrPanelNotFound = errutil.NewBase(errutil.StatusNotFound, "embedtoken.panelNotFound",
+		errutil.WithPublicMessage("Panel not found"))
+	ErrInvalidVariable        = errutil.NewBase(errutil.StatusBadRequest, "embedtoken.invalidVariable")
+	ErrDataSourceAccessDenied = errutil.NewBase(errutil.StatusForbidden, "embedtoken.dataSourceAccessDenied",
+		errutil.WithPublicMessage("You aren't allowed to query the data sources of the embedded panels"))
+)
+
+// TimeRange is a dashboard time range, with absolute or relative times such as now-6h
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Claims are the claims of embed tokens
+type Claims struct {
+	jwt.Claims
+	OrgID        int64  `json:"org_id"`
+	DashboardUID string `json:"dashboard_uid"`
+	// PanelIDs are the panels that can be queried, all the panels of the dashboard when empty
+	PanelIDs []int64 `json:"panel_ids,omitempty"`
+	// Variables are the values of the dashboard variables, they can't be changed by the embedded dashboard
+	Variables map[string][]string `json:"variables,omitempty"`
+	// TimeRange is the time range of the queries, the time range of the dashboard when empty
+	TimeRange TimeRange `json:"time_range"`
+}
+
+// AllowsPanel returns true when the panel can be queried with the claims
+func (c *Claims) AllowsPanel(panelID int64) bool {
+	return len(c.PanelIDs) == 0 || slices.Contains(c.PanelIDs, panelID)
+}
+
+type CreateTokenCommand struct {
+	OrgID        int64  `json:"-"`
+	DashboardUID string `json:"-"`
+	// SignedInUser is the user creating the token, who must be able to query the data sources of the panels
+	SignedInUser *user.SignedInUser `json:"-"`
+	PanelIDs     []int64            `json:"panelIds"`
+	// Variables are restricted to the saved values and options of the dashboard variables
+	Variables map[string][]string `json:"variables"`
+	TimeRange TimeRange           `json:"timeRange"`
+	// ExpiresIn is the lifetime of the token in seconds, the configured default lifetime when 0
+	ExpiresIn int64 `json:"expiresIn"`
+}
+
+type TokenDTO struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type QueryPanelCommand struct {
+	PanelID       int64 `json:"-"`
+	IntervalMs    int64 `json:"intervalMs"`
+	MaxDataPoints int64 `json:"maxDataPoints"`
+	SkipDSCache   bool  `json:"-"`
+}
@@ -0,0 +1,17 @@
+package gitsync
+
+import (
+	"context"
+)
+
+// Service mirrors the dashboards of an organization to a Git repository, and applies the
+// changes made in the repository to the dashboards.
+type Service interface {
+	// Sync pulls the changes made in the repository and commits the changes made to dashboards.
+	Sync(ctx context.Context) error
+	GetStatus(ctx context.Context, query *GetStatusQuery) (*Status, error)
+	GetDashboardStatus(ctx context.Context, query *GetDashboardStatusQuery) (*DashboardStatus, error)
+	// ResolveConflict chooses the version of a dashboard kept by the next sync, when it was changed
+	// both in Grafana and in the repository.
+	ResolveConflict(ctx context.Context, cmd *ResolveConflictCommand) error
+}
@@ -0,0 +1,68 @@
+package gitsyncimpl
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/gitsync"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+
+	routeRegister.Group("/api/git-sync", func(syncRoute routing.RouteRegister) {
+		syncRoute.Get("/status", authorize(ac.EvalPermission(ActionRead)), routing.Wrap(s.statusHandler))
+		syncRoute.Post("/sync", authorize(ac.EvalPermission(ActionWrite)), routing.Wrap(s.syncHandler))
+		syncRoute.Get("/dashboards/:uid", authorize(ac.EvalPermission(ActionRead)), routing.Wrap(s.dashboardStatusHandler))
+		syncRoute.Post("/dashboards/:uid/resolve", authorize(ac.EvalPermission(ActionWrite)), routing.Wrap(s.resolveHandler))
+	}, middleware.ReqSignedIn)
+}
+
+func (s *Service) statusHandler(c *contextmodel.ReqContext) response.Response {
+	result, err := s.GetStatus(c.Req.Context(), &gitsync.GetStatusQuery{OrgID: c.OrgID})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get sync status", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// syncHandler syncs the repository immediately and returns the resulting status
+func (s *Service) syncHandler(c *contextmodel.ReqContext) response.Response {
+	if err := s.checkOrg(c.OrgID); err != nil {
+		return response.Err(err)
+	}
+	if err := s.Sync(c.Req.Context()); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to sync dashboards", err)
+	}
+	return s.statusHandler(c)
+}
+
+func (s *Service) dashboardStatusHandler(c *contextmodel.ReqContext) response.Response {
+	result, err := s.GetDashboardStatus(c.Req.Context(), &gitsync.GetDashboardStatusQuery{
+		OrgID:        c.OrgID,
+		DashboardUID: web.Params(c.Req)[":uid"],
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get dashboard sync status", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (s *Service) resolveHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := gitsync.ResolveConflictCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgID
+	cmd.DashboardUID = web.Params(c.Req)[":uid"]
+
+	if err := s.ResolveConflict(c.Req.Context(), &cmd); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to resolve conflict", err)
+	}
+	return response.Success("Conflict resolved, it will be applied by the next sync")
+}
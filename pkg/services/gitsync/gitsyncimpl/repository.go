@@ -0,0 +1,245 @@
+package gitsyncimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+const remoteName = "origin"
+
+// repository is the local working copy of the synced repository. When a remote is configured,
+// the working copy is reset to the remote branch before each sync, and commits are pushed after.
+type repository struct {
+	repo   *git.Repository
+	dir    string
+	remote string
+	branch string
+	auth   transport.AuthMethod
+}
+
+func openRepository(dir, remote, branch, token string) (*repository, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("can't create git sync directory: %w", err)
+	}
+
+	repo, err := git.PlainOpen(dir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		repo, err = git.PlainInit(dir, false)
+		if err != nil {
+			return nil, err
+		}
+		// Commit on the configured branch rather than the default one
+		head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(branch))
+		if err := repo.Storer.SetReference(head); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := &repository{
+		repo:   repo,
+		dir:    dir,
+		remote: remote,
+		branch: branch,
+	}
+	if token != "" {
+		r.auth = &githttp.BasicAuth{Username: "grafana", Password: token}
+	}
+
+	if remote != "" {
+		if err := r.configureRemote(); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *repository) configureRemote() error {
+	existing, err := r.repo.Remote(remoteName)
+	if err != nil && !errors.Is(err, git.ErrRemoteNotFound) {
+		return err
+	}
+	if existing != nil {
+		if urls := existing.Config().URLs; len(urls) == 1 && urls[0] == r.remote {
+			return nil
+		}
+		if err := r.repo.DeleteRemote(remoteName); err != nil {
+			return err
+		}
+	}
+	_, err = r.repo.CreateRemote(&config.RemoteConfig{Name: remoteName, URLs: []string{r.remote}})
+	return err
+}
+
+// pull fetches the remote branch and resets the working copy to it, discarding the commits that
+// weren't pushed. It returns the head commit, which is zero until the first commit.
+func (r *repository) pull(ctx context.Context) (plumbing.Hash, error) {
+	if r.remote == "" {
+		return r.head()
+	}
+
+	err := r.repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: remoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("+refs/heads/%[1]s:refs/remotes/%[2]s/%[1]s", r.branch, remoteName))},
+		Auth:       r.auth,
+		Force:      true,
+	})
+	switch {
+	case err == nil, errors.Is(err, git.NoErrAlreadyUpToDate):
+	case errors.Is(err, transport.ErrEmptyRemoteRepository), errors.Is(err, git.NoMatchingRefSpecError{}):
+		// The branch is created by the first push
+		return r.head()
+	default:
+		return plumbing.ZeroHash, err
+	}
+
+	ref, err := r.repo.Reference(plumbing.NewRemoteReferenceName(remoteName, r.branch), true)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	// Reset fails on branches without commits, so the branch is moved before resetting the working copy
+	branch := plumbing.NewHashReference(plumbing.NewBranchReferenceName(r.branch), ref.Hash())
+	if err := r.repo.Storer.SetReference(branch); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if err := w.Reset(&git.ResetOptions{Commit: ref.Hash(), Mode: git.HardReset}); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	// Remove the files of discarded commits
+	if err := w.Clean(&git.CleanOptions{Dir: true}); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return ref.Hash(), nil
+}
+
+func (r *repository) head() (plumbing.Hash, error) {
+	ref, err := r.repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return plumbing.ZeroHash, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return ref.Hash(), nil
+}
+
+// files returns the JSON files of the head commit in a directory and its subdirectories, by path.
+func (r *repository) files(dir string) (map[string][]byte, error) {
+	result := map[string][]byte{}
+	head, err := r.head()
+	if err != nil || head.IsZero() {
+		return result, err
+	}
+
+	commit, err := r.repo.CommitObject(head)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		tree, err = tree.Tree(dir)
+		if errors.Is(err, object.ErrDirectoryNotFound) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if !strings.HasSuffix(f.Name, ".json") {
+			return nil
+		}
+		contents, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		result[path.Join(dir, f.Name)] = []byte(contents)
+		return nil
+	})
+	return result, err
+}
+
+// write stages a file of the working copy.
+func (r *repository) write(p string, data []byte) error {
+	fullPath := filepath.Join(r.dir, filepath.FromSlash(p))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0750); err != nil {
+		return err
+	}
+	if err := os.WriteFile(fullPath, data, 0640); err != nil {
+		return err
+	}
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	_, err = w.Add(p)
+	return err
+}
+
+// remove stages the removal of a file of the working copy.
+func (r *repository) remove(p string) error {
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	_, err = w.Remove(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// commit commits the staged changes, if any, and returns the head commit.
+func (r *repository) commit(message string, author *object.Signature) (plumbing.Hash, error) {
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if status.IsClean() {
+		return r.head()
+	}
+	return w.Commit(message, &git.CommitOptions{Author: author})
+}
+
+// push pushes the commits to the remote branch. Pushes are rejected when the branch was
+// changed since it was pulled, the commits are then made again by the next sync.
+func (r *repository) push(ctx context.Context) error {
+	if r.remote == "" {
+		return nil
+	}
+	err := r.repo.PushContext(ctx, &git.PushOptions{
+		RemoteName: remoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("refs/heads/%[1]s:refs/heads/%[1]s", r.branch))},
+		Auth:       r.auth,
+	})
+	if errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil
+	}
+	return err
+}
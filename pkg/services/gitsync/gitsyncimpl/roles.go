@@ -0,0 +1,47 @@
+package gitsyncimpl
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+const (
+	ActionRead  = "gitsync:read"
+	ActionWrite = "gitsync:write"
+)
+
+var (
+	gitSyncReaderRole = accesscontrol.RoleDTO{
+		Name:        "fixed:gitsync:reader",
+		DisplayName: "Git sync reader",
+		Description: "Read the Git sync status of dashboards",
+		Group:       "Git sync",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionRead},
+		},
+	}
+
+	gitSyncWriterRole = accesscontrol.RoleDTO{
+		Name:        "fixed:gitsync:writer",
+		DisplayName: "Git sync writer",
+		Description: "Sync dashboards with Git and resolve conflicts",
+		Group:       "Git sync",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionRead},
+			{Action: ActionWrite},
+		},
+	}
+)
+
+func declareFixedRoles(ac accesscontrol.Service) error {
+	reader := accesscontrol.RoleRegistration{
+		Role:   gitSyncReaderRole,
+		Grants: []string{string(org.RoleAdmin)},
+	}
+	writer := accesscontrol.RoleRegistration{
+		Role:   gitSyncWriterRole,
+		Grants: []string{string(org.RoleAdmin)},
+	}
+
+	return ac.DeclareFixedRoles(reader, writer)
+}
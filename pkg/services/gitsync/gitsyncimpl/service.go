@@ -0,0 +1,226 @@
+package gitsyncimpl
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/gitsync"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type Service struct {
+	store            store
+	accessControl    ac.AccessControl
+	dashboardService dashboards.DashboardService
+	folderService    folder.Service
+	userService      user.Service
+	serverLock       *serverlock.ServerLockService
+	log              log.Logger
+	now              func() time.Time
+
+	enabled    bool
+	remote     string
+	branch     string
+	path       string
+	orgID      int64
+	interval   time.Duration
+	repo       *repository
+	syncMutex  sync.Mutex
+	statusLock sync.RWMutex
+	lastSync   *time.Time
+	lastCommit string
+	lastError  string
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sql db.DB,
+	routeRegister routing.RouteRegister,
+	accessControl ac.AccessControl,
+	accesscontrolService ac.Service,
+	dashboardService dashboards.DashboardService,
+	folderService folder.Service,
+	userService user.Service,
+	serverLock *serverlock.ServerLockService,
+) (*Service, error) {
+	section := cfg.SectionWithEnvOverrides("git_sync")
+	s := &Service{
+		store:            &sqlStore{db: sql},
+		accessControl:    accessControl,
+		dashboardService: dashboardService,
+		folderService:    folderService,
+		userService:      userService,
+		serverLock:       serverLock,
+		log:              log.New("gitsync"),
+		now:              time.Now,
+		enabled:          section.Key("enabled").MustBool(false),
+		remote:           section.Key("repository").MustString(""),
+		branch:           section.Key("branch").MustString("main"),
+		path:             cleanPath(section.Key("path").MustString("")),
+		orgID:            section.Key("org_id").MustInt64(1),
+		interval:         section.Key("interval").MustDuration(time.Minute),
+	}
+
+	if !s.enabled {
+		return s, nil
+	}
+
+	localPath := section.Key("local_path").MustString("")
+	switch {
+	case localPath == "":
+		localPath = filepath.Join(cfg.DataPath, "git-sync")
+	case !filepath.IsAbs(localPath):
+		localPath = filepath.Join(cfg.DataPath, localPath)
+	}
+	token := section.Key("access_token").MustString("")
+	if strings.HasPrefix(token, "$") {
+		token = os.Getenv(token[1:])
+	}
+
+	repo, err := openRepository(localPath, s.remote, s.branch, token)
+	if err != nil {
+		return nil, err
+	}
+	s.repo = repo
+
+	if err := declareFixedRoles(accesscontrolService); err != nil {
+		return nil, err
+	}
+
+	s.registerAPIEndpoints(routeRegister)
+
+	return s, nil
+}
+
+func (s *Service) IsDisabled() bool {
+	return !s.enabled
+}
+
+// Run syncs the repository periodically. In HA setups the server lock keeps instances from
+// syncing at the same time.
+func (s *Service) Run(ctx context.Context) error {
+	syncDashboards := func(ctx context.Context) {
+		if err := s.Sync(ctx); err != nil {
+			s.log.Error("Failed to sync dashboards", "error", err)
+		}
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		err := s.serverLock.LockAndExecute(ctx, "git sync dashboards", s.interval/2, syncDashboards)
+		if err != nil {
+			s.log.Error("Failed to lock and execute git sync", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) Sync(ctx context.Context) error {
+	if !s.enabled {
+		return gitsync.ErrNotConfigured.Errorf("git sync is disabled")
+	}
+	if !s.syncMutex.TryLock() {
+		return gitsync.ErrSyncAlreadyRunning.Errorf("a sync is already running")
+	}
+	defer s.syncMutex.Unlock()
+
+	commit, err := s.syncRepository(ctx)
+
+	now := s.now()
+	s.statusLock.Lock()
+	defer s.statusLock.Unlock()
+	s.lastSync = &now
+	if err != nil {
+		s.lastError = err.Error()
+		return err
+	}
+	s.lastError = ""
+	s.lastCommit = commit
+	return nil
+}
+
+func (s *Service) GetStatus(ctx context.Context, query *gitsync.GetStatusQuery) (*gitsync.Status, error) {
+	if err := s.checkOrg(query.OrgID); err != nil {
+		return nil, err
+	}
+	statuses, err := s.store.GetStatuses(ctx, query.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.statusLock.RLock()
+	defer s.statusLock.RUnlock()
+	return &gitsync.Status{
+		Repository: s.remote,
+		Branch:     s.branch,
+		Path:       s.path,
+		LastSync:   s.lastSync,
+		LastCommit: s.lastCommit,
+		LastError:  s.lastError,
+		Dashboards: statuses,
+	}, nil
+}
+
+func (s *Service) GetDashboardStatus(ctx context.Context, query *gitsync.GetDashboardStatusQuery) (*gitsync.DashboardStatus, error) {
+	if err := s.checkOrg(query.OrgID); err != nil {
+		return nil, err
+	}
+	return s.store.GetStatus(ctx, query.OrgID, query.DashboardUID)
+}
+
+func (s *Service) ResolveConflict(ctx context.Context, cmd *gitsync.ResolveConflictCommand) error {
+	if err := s.checkOrg(cmd.OrgID); err != nil {
+		return err
+	}
+	if cmd.Resolution != gitsync.ResolutionGrafana && cmd.Resolution != gitsync.ResolutionGit {
+		return gitsync.ErrInvalidResolution.Errorf("invalid resolution %q", cmd.Resolution)
+	}
+
+	status, err := s.store.GetStatus(ctx, cmd.OrgID, cmd.DashboardUID)
+	if err != nil {
+		return err
+	}
+	if status.State != gitsync.SyncStateConflict {
+		return gitsync.ErrNoConflict.Errorf("dashboard %q has no conflict", cmd.DashboardUID)
+	}
+	status.Resolution = cmd.Resolution
+	status.Updated = s.now()
+	return s.store.SaveStatus(ctx, status)
+}
+
+// checkOrg returns an error unless the dashboards of the organization are synced.
+func (s *Service) checkOrg(orgID int64) error {
+	if !s.enabled || orgID != s.orgID {
+		return gitsync.ErrNotConfigured.Errorf("git sync is not configured for organization %d", orgID)
+	}
+	return nil
+}
+
+// cleanPath returns the directory of the repository where dashboards are synced, relative to the
+// root of the repository.
+func cleanPath(p string) string {
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if p == "." {
+		return ""
+	}
+	return p
+}
@@ -0,0 +1,87 @@
+package gitsyncimpl
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/gitsync"
+)
+
+// dashboardRef is a dashboard or folder as listed from the database
+type dashboardRef struct {
+	ID        int64  `xorm:"id"`
+	UID       string `xorm:"uid"`
+	Title     string `xorm:"title"`
+	Version   int    `xorm:"'version'"`
+	UpdatedBy int64  `xorm:"updated_by"`
+	IsFolder  bool   `xorm:"is_folder"`
+	FolderID  int64  `xorm:"folder_id"`
+}
+
+type store interface {
+	// ListDashboards returns the dashboards and folders of an organization, without their content
+	ListDashboards(ctx context.Context, orgID int64) ([]*dashboardRef, error)
+
+	GetStatuses(ctx context.Context, orgID int64) ([]*gitsync.DashboardStatus, error)
+	GetStatus(ctx context.Context, orgID int64, dashboardUID string) (*gitsync.DashboardStatus, error)
+	// SaveStatus inserts or updates the sync status of a dashboard
+	SaveStatus(ctx context.Context, status *gitsync.DashboardStatus) error
+	DeleteStatus(ctx context.Context, orgID int64, dashboardUID string) error
+}
+
+type sqlStore struct {
+	db db.DB
+}
+
+func (s *sqlStore) ListDashboards(ctx context.Context, orgID int64) ([]*dashboardRef, error) {
+	var result []*dashboardRef
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Table("dashboard").Cols("id", "uid", "title", "version", "updated_by", "is_folder", "folder_id").
+			Where("org_id = ?", orgID).Find(&result)
+	})
+	return result, err
+}
+
+func (s *sqlStore) GetStatuses(ctx context.Context, orgID int64) ([]*gitsync.DashboardStatus, error) {
+	result := make([]*gitsync.DashboardStatus, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("org_id = ?", orgID).Asc("path").Find(&result)
+	})
+	return result, err
+}
+
+func (s *sqlStore) GetStatus(ctx context.Context, orgID int64, dashboardUID string) (*gitsync.DashboardStatus, error) {
+	status := gitsync.DashboardStatus{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("org_id = ? AND dashboard_uid = ?", orgID, dashboardUID).Get(&status)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return gitsync.ErrDashboardNotSynced.Errorf("dashboard %q has not been synced", dashboardUID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (s *sqlStore) SaveStatus(ctx context.Context, status *gitsync.DashboardStatus) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		if status.ID == 0 {
+			_, err := sess.Insert(status)
+			return err
+		}
+		_, err := sess.ID(status.ID).AllCols().Update(status)
+		return err
+	})
+}
+
+func (s *sqlStore) DeleteStatus(ctx context.Context, orgID int64, dashboardUID string) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("DELETE FROM dashboard_git_sync WHERE org_id = ? AND dashboard_uid = ?", orgID, dashboardUID)
+		return err
+	})
+}
@@ -0,0 +1,74 @@
+package gitsyncimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/gitsync"
+)
+
+func TestIntegrationGitSyncStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	sqlDB := db.InitTestDB(t)
+	ss := &sqlStore{db: sqlDB}
+	now := time.Now().Truncate(time.Second)
+
+	err := sqlDB.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec(`INSERT INTO dashboard (version, slug, title, data, org_id, created, updated, updated_by, created_by, uid, is_folder, folder_id)
+			VALUES (3, 'my-dashboard', 'My dashboard', '{}', 1, ?, ?, 2, 2, 'abc', ?, 0)`, now, now, false)
+		return err
+	})
+	require.NoError(t, err)
+
+	t.Run("lists dashboards without their content", func(t *testing.T) {
+		refs, err := ss.ListDashboards(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, refs, 1)
+		require.Equal(t, &dashboardRef{ID: refs[0].ID, UID: "abc", Title: "My dashboard", Version: 3, UpdatedBy: 2}, refs[0])
+
+		refs, err = ss.ListDashboards(ctx, 2)
+		require.NoError(t, err)
+		require.Empty(t, refs)
+	})
+
+	t.Run("saves and deletes statuses", func(t *testing.T) {
+		status := &gitsync.DashboardStatus{
+			OrgID:        1,
+			DashboardUID: "abc",
+			Path:         "my-dashboard.json",
+			Version:      3,
+			Hash:         "hash",
+			Commit:       "commit",
+			State:        gitsync.SyncStateSynced,
+			Updated:      now,
+		}
+		require.NoError(t, ss.SaveStatus(ctx, status))
+		require.NotZero(t, status.ID)
+
+		status.State = gitsync.SyncStateConflict
+		status.Message = "conflict"
+		require.NoError(t, ss.SaveStatus(ctx, status))
+
+		saved, err := ss.GetStatus(ctx, 1, "abc")
+		require.NoError(t, err)
+		require.Equal(t, gitsync.SyncStateConflict, saved.State)
+		require.Equal(t, "conflict", saved.Message)
+		require.Equal(t, "hash", saved.Hash)
+
+		statuses, err := ss.GetStatuses(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, statuses, 1)
+
+		require.NoError(t, ss.DeleteStatus(ctx, 1, "abc"))
+		_, err = ss.GetStatus(ctx, 1, "abc")
+		require.ErrorIs(t, err, gitsync.ErrDashboardNotSynced)
+	})
+}
@@ -0,0 +1,506 @@
+package gitsyncimpl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/slugify"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/gitsync"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// syncPermissions are the permissions used to apply the changes pulled from the repository
+var syncPermissions = []accesscontrol.Permission{
+	{Action: dashboards.ActionFoldersCreate},
+	{Action: dashboards.ActionFoldersRead, Scope: dashboards.ScopeFoldersAll},
+	{Action: dashboards.ActionFoldersWrite, Scope: dashboards.ScopeFoldersAll},
+	{Action: dashboards.ActionDashboardsCreate, Scope: dashboards.ScopeFoldersAll},
+	{Action: dashboards.ActionDashboardsWrite, Scope: dashboards.ScopeFoldersAll},
+	{Action: dashboards.ActionDashboardsDelete, Scope: dashboards.ScopeFoldersAll},
+}
+
+// gitFile is a dashboard file of the repository
+type gitFile struct {
+	path  string
+	hash  string
+	title string
+	data  *simplejson.Json
+}
+
+// syncRun compares the dashboards with the files of the repository, and with their state when they
+// were last synced, to apply the changes made on either side:
+//   - dashboards changed in Grafana are written to the repository, with one commit per change
+//     attributed to the user who saved the dashboard
+//   - files changed in the repository are saved as dashboards
+//   - dashboards changed on both sides are marked as conflicts until the conflict is resolved
+type syncRun struct {
+	s    *Service
+	head string
+	user *user.SignedInUser
+
+	files      map[string]*gitFile // by dashboard UID
+	owners     map[string]string   // dashboard UID by file path
+	dashboards map[string]*dashboardRef
+	folders    map[int64]*dashboardRef
+	statuses   map[string]*gitsync.DashboardStatus
+
+	// pushed are the statuses of the dashboards committed, saved once the commits are pushed
+	pushed  []*gitsync.DashboardStatus
+	removed []string
+}
+
+func (s *Service) syncRepository(ctx context.Context) (string, error) {
+	head, err := s.repo.pull(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to pull: %w", err)
+	}
+
+	r := &syncRun{
+		s:          s,
+		head:       head.String(),
+		user:       accesscontrol.BackgroundUser("git_sync", s.orgID, org.RoleAdmin, syncPermissions),
+		files:      map[string]*gitFile{},
+		owners:     map[string]string{},
+		dashboards: map[string]*dashboardRef{},
+		folders:    map[int64]*dashboardRef{},
+		statuses:   map[string]*gitsync.DashboardStatus{},
+	}
+	if err := r.load(ctx); err != nil {
+		return "", err
+	}
+
+	uids := map[string]bool{}
+	for uid := range r.files {
+		uids[uid] = true
+	}
+	for uid := range r.dashboards {
+		uids[uid] = true
+	}
+	for uid := range r.statuses {
+		uids[uid] = true
+	}
+	sorted := make([]string, 0, len(uids))
+	for uid := range uids {
+		sorted = append(sorted, uid)
+	}
+	sort.Strings(sorted)
+
+	for _, uid := range sorted {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		r.syncDashboard(ctx, uid)
+	}
+
+	if len(r.pushed) == 0 && len(r.removed) == 0 {
+		return r.head, nil
+	}
+	if err := s.repo.push(ctx); err != nil {
+		return "", fmt.Errorf("failed to push: %w", err)
+	}
+	newHead, err := s.repo.head()
+	if err != nil {
+		return "", err
+	}
+	for _, status := range r.pushed {
+		status.Commit = newHead.String()
+		r.saveStatus(ctx, status)
+	}
+	for _, uid := range r.removed {
+		if err := s.store.DeleteStatus(ctx, s.orgID, uid); err != nil {
+			s.log.Error("Failed to delete dashboard sync status", "dashboardUid", uid, "error", err)
+		}
+	}
+	return newHead.String(), nil
+}
+
+func (r *syncRun) load(ctx context.Context) error {
+	files, err := r.s.repo.files(r.s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read repository: %w", err)
+	}
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		data, err := simplejson.NewJson(files[p])
+		if err != nil {
+			r.s.log.Warn("Ignoring invalid dashboard file", "path", p, "error", err)
+			continue
+		}
+		uid := data.Get("uid").MustString()
+		if uid == "" {
+			r.s.log.Warn("Ignoring dashboard file without uid", "path", p)
+			continue
+		}
+		if existing, ok := r.files[uid]; ok {
+			r.s.log.Warn("Ignoring dashboard file with a duplicate uid", "path", p, "uid", uid, "existingPath", existing.path)
+			continue
+		}
+		r.files[uid] = &gitFile{path: p, hash: hashContent(files[p]), title: data.Get("title").MustString(), data: data}
+		r.owners[p] = uid
+	}
+
+	refs, err := r.s.store.ListDashboards(ctx, r.s.orgID)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref.IsFolder {
+			r.folders[ref.ID] = ref
+		} else {
+			r.dashboards[ref.UID] = ref
+		}
+	}
+
+	statuses, err := r.s.store.GetStatuses(ctx, r.s.orgID)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		r.statuses[status.DashboardUID] = status
+		if _, ok := r.owners[status.Path]; !ok && status.Path != "" {
+			r.owners[status.Path] = status.DashboardUID
+		}
+	}
+	return nil
+}
+
+func (r *syncRun) syncDashboard(ctx context.Context, uid string) {
+	status, ok := r.statuses[uid]
+	if !ok {
+		status = &gitsync.DashboardStatus{OrgID: r.s.orgID, DashboardUID: uid}
+	}
+	dash := r.dashboards[uid]
+	file := r.files[uid]
+
+	// Dashboards and files which didn't exist when last synced have a zero version and an empty hash
+	version, hash := 0, ""
+	if dash != nil {
+		version = dash.Version
+	}
+	if file != nil {
+		hash = file.hash
+	}
+	changedInGrafana := version != status.Version
+	changedInGit := hash != status.Hash || (file != nil && file.path != status.Path)
+
+	if changedInGrafana && changedInGit {
+		switch status.Resolution {
+		case gitsync.ResolutionGrafana:
+			changedInGit = false
+		case gitsync.ResolutionGit:
+			changedInGrafana = false
+		}
+	}
+
+	switch {
+	case changedInGrafana && changedInGit:
+		r.syncConflict(ctx, status, dash, file)
+	case changedInGrafana:
+		r.export(ctx, status, dash)
+	case changedInGit:
+		r.importFile(ctx, status, dash, file)
+	case dash != nil && path.Dir(status.Path) != r.folderPath(dash.FolderID):
+		// The folder was renamed
+		r.export(ctx, status, dash)
+	}
+}
+
+// syncConflict records a conflict, unless both sides made the same change.
+func (r *syncRun) syncConflict(ctx context.Context, status *gitsync.DashboardStatus, dash *dashboardRef, file *gitFile) {
+	if dash == nil && file == nil {
+		r.deleteStatus(ctx, status)
+		return
+	}
+	if dash != nil && file != nil {
+		d, err := r.getDashboard(ctx, dash.UID)
+		if err != nil {
+			r.setError(ctx, status, err)
+			return
+		}
+		content, err := dashboardFile(d)
+		if err != nil {
+			r.setError(ctx, status, err)
+			return
+		}
+		if hashContent(content) == file.hash && path.Dir(file.path) == r.folderPath(dash.FolderID) {
+			r.setSynced(status, file.path, dash.Version, file.hash)
+			r.saveStatus(ctx, status)
+			return
+		}
+	}
+
+	status.State = gitsync.SyncStateConflict
+	status.Message = "The dashboard was changed both in Grafana and in the repository"
+	status.Updated = r.s.now()
+	r.saveStatus(ctx, status)
+}
+
+// export writes a dashboard changed in Grafana to the repository, or removes the file of a
+// dashboard deleted from Grafana.
+func (r *syncRun) export(ctx context.Context, status *gitsync.DashboardStatus, ref *dashboardRef) {
+	if ref == nil {
+		if status.Path != "" && r.owners[status.Path] == status.DashboardUID {
+			if err := r.s.repo.remove(status.Path); err != nil {
+				r.setError(ctx, status, err)
+				return
+			}
+			title := strings.TrimSuffix(path.Base(status.Path), ".json")
+			if file := r.files[status.DashboardUID]; file != nil && file.title != "" {
+				title = file.title
+			}
+			if _, err := r.s.repo.commit(fmt.Sprintf("Delete dashboard %q", title), r.s.defaultAuthor()); err != nil {
+				r.setError(ctx, status, err)
+				return
+			}
+		}
+		r.removed = append(r.removed, status.DashboardUID)
+		return
+	}
+
+	dash, err := r.getDashboard(ctx, ref.UID)
+	if err != nil {
+		r.setError(ctx, status, err)
+		return
+	}
+	content, err := dashboardFile(dash)
+	if err != nil {
+		r.setError(ctx, status, err)
+		return
+	}
+
+	p := r.dashboardPath(ref)
+	action := "Update"
+	switch {
+	case status.Path == "":
+		action = "Add"
+	case status.Path != p:
+		if ref.Version == status.Version {
+			action = "Move"
+		}
+		if r.owners[status.Path] == ref.UID {
+			if err := r.s.repo.remove(status.Path); err != nil {
+				r.setError(ctx, status, err)
+				return
+			}
+			delete(r.owners, status.Path)
+		}
+	}
+	if err := r.s.repo.write(p, content); err != nil {
+		r.setError(ctx, status, err)
+		return
+	}
+	r.owners[p] = ref.UID
+
+	message := fmt.Sprintf("%s dashboard %q (version %d)", action, dash.Title, dash.Version)
+	if _, err := r.s.repo.commit(message, r.s.author(ctx, dash.UpdatedBy)); err != nil {
+		r.setError(ctx, status, err)
+		return
+	}
+	r.setSynced(status, p, dash.Version, hashContent(content))
+	r.pushed = append(r.pushed, status)
+}
+
+// importFile saves a dashboard changed in the repository, or deletes a dashboard whose file was
+// removed from the repository.
+func (r *syncRun) importFile(ctx context.Context, status *gitsync.DashboardStatus, ref *dashboardRef, file *gitFile) {
+	if file == nil {
+		if ref != nil {
+			if err := r.s.dashboardService.DeleteDashboard(ctx, ref.ID, r.s.orgID); err != nil {
+				r.setError(ctx, status, err)
+				return
+			}
+		}
+		r.deleteStatus(ctx, status)
+		return
+	}
+
+	folderID, err := r.folderID(ctx, file.path)
+	if err != nil {
+		r.setError(ctx, status, err)
+		return
+	}
+
+	// The id and version of the dashboard in this instance are not stored in the repository
+	dash := dashboards.NewDashboardFromJson(file.data)
+	dash.ID = 0
+	dash.OrgID = r.s.orgID
+	dash.FolderID = folderID
+	saved, err := r.s.dashboardService.SaveDashboard(ctx, &dashboards.SaveDashboardDTO{
+		OrgID:     r.s.orgID,
+		User:      r.user,
+		Message:   fmt.Sprintf("Synced from Git commit %s", shortHash(r.head)),
+		Overwrite: true,
+		Dashboard: dash,
+	}, true)
+	if err != nil {
+		r.setError(ctx, status, err)
+		return
+	}
+
+	r.setSynced(status, file.path, saved.Version, file.hash)
+	status.Commit = r.head
+	r.saveStatus(ctx, status)
+}
+
+func (r *syncRun) getDashboard(ctx context.Context, uid string) (*dashboards.Dashboard, error) {
+	return r.s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: uid, OrgID: r.s.orgID})
+}
+
+// folderPath returns the directory of the dashboards of a folder. Dashboards of the General folder
+// are stored at the root of the synced directory.
+func (r *syncRun) folderPath(folderID int64) string {
+	if f, ok := r.folders[folderID]; ok && folderID != 0 {
+		return path.Join(r.s.path, slugify.Slugify(f.Title))
+	}
+	if r.s.path == "" {
+		return "."
+	}
+	return r.s.path
+}
+
+// dashboardPath returns the path of the file of a dashboard, named after its title. The UID of the
+// dashboard is added when another dashboard of the folder has the same name.
+func (r *syncRun) dashboardPath(ref *dashboardRef) string {
+	dir := r.folderPath(ref.FolderID)
+	name := slugify.Slugify(ref.Title)
+	if name == "" {
+		name = ref.UID
+	}
+	p := path.Join(dir, name+".json")
+	if owner, ok := r.owners[p]; ok && owner != ref.UID {
+		p = path.Join(dir, name+"-"+ref.UID+".json")
+	}
+	return p
+}
+
+// folderID returns the folder of a dashboard file, from its directory. Folders are matched by the
+// name of their directory, and created when they don't exist.
+func (r *syncRun) folderID(ctx context.Context, p string) (int64, error) {
+	dir := path.Dir(strings.TrimPrefix(strings.TrimPrefix(p, r.s.path), "/"))
+	if dir == "." {
+		return 0, nil
+	}
+	// Nested directories are mapped to folders by their first directory
+	name, _, _ := strings.Cut(dir, "/")
+	for _, f := range r.folders {
+		if slugify.Slugify(f.Title) == name || f.Title == name {
+			return f.ID, nil
+		}
+	}
+
+	created, err := r.s.folderService.Create(ctx, &folder.CreateFolderCommand{
+		OrgID:        r.s.orgID,
+		Title:        name,
+		SignedInUser: r.user,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create folder %q: %w", name, err)
+	}
+	r.folders[created.ID] = &dashboardRef{ID: created.ID, UID: created.UID, Title: created.Title, IsFolder: true}
+	return created.ID, nil
+}
+
+func (r *syncRun) setSynced(status *gitsync.DashboardStatus, p string, version int, hash string) {
+	status.Path = p
+	status.Version = version
+	status.Hash = hash
+	status.State = gitsync.SyncStateSynced
+	status.Message = ""
+	status.Resolution = ""
+	status.Updated = r.s.now()
+}
+
+// setError records that a dashboard couldn't be synced. The state of the last sync is kept, so
+// that the sync is retried.
+func (r *syncRun) setError(ctx context.Context, status *gitsync.DashboardStatus, err error) {
+	r.s.log.Warn("Failed to sync dashboard", "dashboardUid", status.DashboardUID, "error", err)
+	status.State = gitsync.SyncStateError
+	status.Message = err.Error()
+	status.Updated = r.s.now()
+	r.saveStatus(ctx, status)
+}
+
+func (r *syncRun) saveStatus(ctx context.Context, status *gitsync.DashboardStatus) {
+	if err := r.s.store.SaveStatus(ctx, status); err != nil {
+		r.s.log.Error("Failed to save dashboard sync status", "dashboardUid", status.DashboardUID, "error", err)
+	}
+}
+
+func (r *syncRun) deleteStatus(ctx context.Context, status *gitsync.DashboardStatus) {
+	if status.ID == 0 {
+		return
+	}
+	if err := r.s.store.DeleteStatus(ctx, r.s.orgID, status.DashboardUID); err != nil {
+		r.s.log.Error("Failed to delete dashboard sync status", "dashboardUid", status.DashboardUID, "error", err)
+	}
+}
+
+// author returns the signature of the commits of a user.
+func (s *Service) author(ctx context.Context, userID int64) *object.Signature {
+	if userID <= 0 {
+		return s.defaultAuthor()
+	}
+	u, err := s.userService.GetByID(ctx, &user.GetUserByIDQuery{ID: userID})
+	if err != nil {
+		return s.defaultAuthor()
+	}
+	name := u.Name
+	if name == "" {
+		name = u.Login
+	}
+	return &object.Signature{Name: name, Email: u.Email, When: s.now()}
+}
+
+func (s *Service) defaultAuthor() *object.Signature {
+	return &object.Signature{Name: "Grafana", Email: "grafana@localhost", When: s.now()}
+}
+
+// dashboardFile returns the content of the file of a dashboard. The id and version of the
+// dashboard are specific to this instance, and left out.
+func dashboardFile(dash *dashboards.Dashboard) ([]byte, error) {
+	model, err := dash.Data.Map()
+	if err != nil {
+		return nil, err
+	}
+	content := make(map[string]interface{}, len(model))
+	for k, v := range model {
+		if k == "id" || k == "version" {
+			continue
+		}
+		content[k] = v
+	}
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
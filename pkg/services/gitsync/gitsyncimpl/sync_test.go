@@ -0,0 +1,403 @@
+package gitsyncimpl
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/gitsync"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+)
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("commits dashboards saved in Grafana", func(t *testing.T) {
+		env := setupSyncTest(t)
+		env.dashboards.add(&dashboards.Dashboard{UID: "abc", Title: "My dashboard", Version: 1, UpdatedBy: 1})
+		folderID := env.dashboards.add(&dashboards.Dashboard{UID: "folder", Title: "Team A", IsFolder: true})
+		env.dashboards.add(&dashboards.Dashboard{UID: "def", Title: "Other", Version: 3, FolderID: folderID})
+
+		require.NoError(t, env.service.Sync(ctx))
+
+		files := env.clone.pullFiles(t)
+		require.Len(t, files, 2)
+		file := simplejson.MustJson(files["dashboards/my-dashboard.json"])
+		require.Equal(t, "abc", file.Get("uid").MustString())
+		_, hasVersion := file.CheckGet("version")
+		require.False(t, hasVersion)
+		require.Contains(t, files, "dashboards/team-a/other.json")
+
+		commit := env.remoteHead(t)
+		require.Equal(t, "Add dashboard \"Other\" (version 3)", commit.Message)
+
+		status, err := env.service.GetDashboardStatus(ctx, &gitsync.GetDashboardStatusQuery{OrgID: 1, DashboardUID: "abc"})
+		require.NoError(t, err)
+		require.Equal(t, gitsync.SyncStateSynced, status.State)
+		require.Equal(t, "dashboards/my-dashboard.json", status.Path)
+		require.Equal(t, commit.Hash.String(), status.Commit)
+
+		// Nothing to commit until dashboards change
+		require.NoError(t, env.service.Sync(ctx))
+		require.Equal(t, commit.Hash, env.remoteHead(t).Hash)
+
+		env.dashboards.update("abc", func(d *dashboards.Dashboard) {
+			d.Data.Set("description", "updated")
+		})
+		require.NoError(t, env.service.Sync(ctx))
+		commit = env.remoteHead(t)
+		require.Equal(t, "Update dashboard \"My dashboard\" (version 2)", commit.Message)
+		require.Equal(t, "Jane Doe", commit.Author.Name)
+		require.Equal(t, "jane@example.com", commit.Author.Email)
+	})
+
+	t.Run("applies changes pulled from the repository", func(t *testing.T) {
+		env := setupSyncTest(t)
+		env.dashboards.add(&dashboards.Dashboard{UID: "abc", Title: "My dashboard", Version: 1})
+		require.NoError(t, env.service.Sync(ctx))
+
+		files := env.clone.pullFiles(t)
+		file := simplejson.MustJson(files["dashboards/my-dashboard.json"])
+		file.Set("description", "from git")
+		env.clone.commitFile(t, "dashboards/my-dashboard.json", file)
+
+		require.NoError(t, env.service.Sync(ctx))
+		dash := env.dashboards.byUID["abc"]
+		require.Equal(t, "from git", dash.Data.Get("description").MustString())
+		require.Equal(t, 2, dash.Version)
+
+		status, err := env.service.GetDashboardStatus(ctx, &gitsync.GetDashboardStatusQuery{OrgID: 1, DashboardUID: "abc"})
+		require.NoError(t, err)
+		require.Equal(t, gitsync.SyncStateSynced, status.State)
+		require.Equal(t, 2, status.Version)
+
+		// The imported version isn't committed back
+		head := env.remoteHead(t).Hash
+		require.NoError(t, env.service.Sync(ctx))
+		require.Equal(t, head, env.remoteHead(t).Hash)
+	})
+
+	t.Run("creates the folders of new dashboard files", func(t *testing.T) {
+		env := setupSyncTest(t)
+		env.clone.commitFile(t, "dashboards/team-b/new.json", simplejson.NewFromAny(map[string]interface{}{
+			"uid":   "new",
+			"title": "New",
+		}))
+
+		require.NoError(t, env.service.Sync(ctx))
+		dash := env.dashboards.byUID["new"]
+		require.NotNil(t, dash)
+		folder := env.dashboards.byID[dash.FolderID]
+		require.NotNil(t, folder)
+		require.Equal(t, "team-b", folder.Title)
+
+		// The file is where it would be exported
+		head := env.remoteHead(t).Hash
+		require.NoError(t, env.service.Sync(ctx))
+		require.Equal(t, head, env.remoteHead(t).Hash)
+	})
+
+	t.Run("detects and resolves conflicts", func(t *testing.T) {
+		env := setupSyncTest(t)
+		env.dashboards.add(&dashboards.Dashboard{UID: "abc", Title: "My dashboard", Version: 1})
+		require.NoError(t, env.service.Sync(ctx))
+
+		files := env.clone.pullFiles(t)
+		file := simplejson.MustJson(files["dashboards/my-dashboard.json"])
+		file.Set("description", "from git")
+		env.clone.commitFile(t, "dashboards/my-dashboard.json", file)
+		env.dashboards.update("abc", func(d *dashboards.Dashboard) {
+			d.Data.Set("description", "from grafana")
+		})
+
+		require.NoError(t, env.service.Sync(ctx))
+		status, err := env.service.GetDashboardStatus(ctx, &gitsync.GetDashboardStatusQuery{OrgID: 1, DashboardUID: "abc"})
+		require.NoError(t, err)
+		require.Equal(t, gitsync.SyncStateConflict, status.State)
+		require.Equal(t, "from grafana", env.dashboards.byUID["abc"].Data.Get("description").MustString())
+
+		err = env.service.ResolveConflict(ctx, &gitsync.ResolveConflictCommand{OrgID: 1, DashboardUID: "abc", Resolution: "theirs"})
+		require.ErrorIs(t, err, gitsync.ErrInvalidResolution)
+		err = env.service.ResolveConflict(ctx, &gitsync.ResolveConflictCommand{OrgID: 1, DashboardUID: "abc", Resolution: gitsync.ResolutionGit})
+		require.NoError(t, err)
+
+		require.NoError(t, env.service.Sync(ctx))
+		require.Equal(t, "from git", env.dashboards.byUID["abc"].Data.Get("description").MustString())
+		status, err = env.service.GetDashboardStatus(ctx, &gitsync.GetDashboardStatusQuery{OrgID: 1, DashboardUID: "abc"})
+		require.NoError(t, err)
+		require.Equal(t, gitsync.SyncStateSynced, status.State)
+		require.Empty(t, status.Resolution)
+
+		err = env.service.ResolveConflict(ctx, &gitsync.ResolveConflictCommand{OrgID: 1, DashboardUID: "abc", Resolution: gitsync.ResolutionGit})
+		require.ErrorIs(t, err, gitsync.ErrNoConflict)
+	})
+
+	t.Run("removes the files of deleted dashboards", func(t *testing.T) {
+		env := setupSyncTest(t)
+		env.dashboards.add(&dashboards.Dashboard{UID: "abc", Title: "My dashboard", Version: 1})
+		require.NoError(t, env.service.Sync(ctx))
+		require.Len(t, env.clone.pullFiles(t), 1)
+
+		delete(env.dashboards.byID, env.dashboards.byUID["abc"].ID)
+		delete(env.dashboards.byUID, "abc")
+		require.NoError(t, env.service.Sync(ctx))
+
+		require.Empty(t, env.clone.pullFiles(t))
+		require.Equal(t, "Delete dashboard \"My dashboard\"", env.remoteHead(t).Message)
+		_, err := env.service.GetDashboardStatus(ctx, &gitsync.GetDashboardStatusQuery{OrgID: 1, DashboardUID: "abc"})
+		require.ErrorIs(t, err, gitsync.ErrDashboardNotSynced)
+	})
+
+	t.Run("deletes dashboards whose file was removed", func(t *testing.T) {
+		env := setupSyncTest(t)
+		env.dashboards.add(&dashboards.Dashboard{UID: "abc", Title: "My dashboard", Version: 1})
+		require.NoError(t, env.service.Sync(ctx))
+
+		env.clone.pullFiles(t)
+		require.NoError(t, env.clone.remove("dashboards/my-dashboard.json"))
+		_, err := env.clone.commit("Remove dashboard", env.service.defaultAuthor())
+		require.NoError(t, err)
+		require.NoError(t, env.clone.push(ctx))
+
+		require.NoError(t, env.service.Sync(ctx))
+		require.NotContains(t, env.dashboards.byUID, "abc")
+	})
+
+	t.Run("only reports the status of the synced organization", func(t *testing.T) {
+		env := setupSyncTest(t)
+		_, err := env.service.GetStatus(ctx, &gitsync.GetStatusQuery{OrgID: 2})
+		require.ErrorIs(t, err, gitsync.ErrNotConfigured)
+
+		require.NoError(t, env.service.Sync(ctx))
+		status, err := env.service.GetStatus(ctx, &gitsync.GetStatusQuery{OrgID: 1})
+		require.NoError(t, err)
+		require.NotNil(t, status.LastSync)
+		require.Empty(t, status.LastError)
+		require.Equal(t, "dashboards", status.Path)
+	})
+}
+
+type syncTestEnv struct {
+	service    *Service
+	dashboards *fakeDashboards
+	clone      *testClone
+	remoteDir  string
+}
+
+func setupSyncTest(t *testing.T) *syncTestEnv {
+	t.Helper()
+
+	remoteDir := t.TempDir()
+	_, err := git.PlainInit(remoteDir, true)
+	require.NoError(t, err)
+
+	repo, err := openRepository(filepath.Join(t.TempDir(), "local"), remoteDir, "main", "")
+	require.NoError(t, err)
+	clone, err := openRepository(filepath.Join(t.TempDir(), "clone"), remoteDir, "main", "")
+	require.NoError(t, err)
+
+	dash := newFakeDashboards()
+	s := &Service{
+		store:            &fakeStore{dashboards: dash, statuses: map[string]*gitsync.DashboardStatus{}},
+		dashboardService: &fakeDashboardService{dashboards: dash},
+		folderService:    &fakeFolderService{dashboards: dash},
+		userService: &usertest.FakeUserService{ExpectedUser: &user.User{
+			ID: 1, Login: "jane", Name: "Jane Doe", Email: "jane@example.com",
+		}},
+		log:     log.NewNopLogger(),
+		now:     time.Now,
+		enabled: true,
+		remote:  remoteDir,
+		branch:  "main",
+		path:    "dashboards",
+		orgID:   1,
+		repo:    repo,
+	}
+	return &syncTestEnv{service: s, dashboards: dash, clone: &testClone{repository: clone}, remoteDir: remoteDir}
+}
+
+func (e *syncTestEnv) remoteHead(t *testing.T) *object.Commit {
+	t.Helper()
+	remote, err := git.PlainOpen(e.remoteDir)
+	require.NoError(t, err)
+	ref, err := remote.Reference(plumbing.NewBranchReferenceName("main"), true)
+	require.NoError(t, err)
+	commit, err := remote.CommitObject(ref.Hash())
+	require.NoError(t, err)
+	return commit
+}
+
+// testClone is another working copy of the remote repository, making changes as users would
+type testClone struct {
+	*repository
+}
+
+func (c *testClone) pullFiles(t *testing.T) map[string][]byte {
+	t.Helper()
+	_, err := c.pull(context.Background())
+	require.NoError(t, err)
+	files, err := c.files("")
+	require.NoError(t, err)
+	return files
+}
+
+func (c *testClone) commitFile(t *testing.T, p string, data *simplejson.Json) {
+	t.Helper()
+	_, err := c.pull(context.Background())
+	require.NoError(t, err)
+	content, err := data.EncodePretty()
+	require.NoError(t, err)
+	require.NoError(t, c.write(p, content))
+	_, err = c.commit("Change "+p, &object.Signature{Name: "John", Email: "john@example.com", When: time.Now()})
+	require.NoError(t, err)
+	require.NoError(t, c.push(context.Background()))
+}
+
+type fakeDashboards struct {
+	byID   map[int64]*dashboards.Dashboard
+	byUID  map[string]*dashboards.Dashboard
+	nextID int64
+}
+
+func newFakeDashboards() *fakeDashboards {
+	return &fakeDashboards{byID: map[int64]*dashboards.Dashboard{}, byUID: map[string]*dashboards.Dashboard{}, nextID: 1}
+}
+
+func (f *fakeDashboards) add(d *dashboards.Dashboard) int64 {
+	d.ID = f.nextID
+	d.OrgID = 1
+	f.nextID++
+	if d.Data == nil {
+		d.Data = simplejson.NewFromAny(map[string]interface{}{"uid": d.UID, "title": d.Title})
+	}
+	d.Data.Set("id", d.ID)
+	d.Data.Set("version", d.Version)
+	f.byID[d.ID] = d
+	f.byUID[d.UID] = d
+	return d.ID
+}
+
+func (f *fakeDashboards) update(uid string, fn func(d *dashboards.Dashboard)) {
+	d := f.byUID[uid]
+	fn(d)
+	d.Version++
+	d.Data.Set("version", d.Version)
+}
+
+type fakeDashboardService struct {
+	dashboards.DashboardService
+	dashboards *fakeDashboards
+}
+
+func (s *fakeDashboardService) GetDashboard(ctx context.Context, query *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error) {
+	d, ok := s.dashboards.byUID[query.UID]
+	if !ok {
+		return nil, dashboards.ErrDashboardNotFound
+	}
+	return d, nil
+}
+
+func (s *fakeDashboardService) SaveDashboard(ctx context.Context, dto *dashboards.SaveDashboardDTO, allowUiUpdate bool) (*dashboards.Dashboard, error) {
+	d := dto.Dashboard
+	existing, ok := s.dashboards.byUID[d.UID]
+	if !ok {
+		d.Version = 1
+		s.dashboards.add(d)
+		return d, nil
+	}
+	d.ID = existing.ID
+	d.OrgID = existing.OrgID
+	d.Version = existing.Version + 1
+	d.Data.Set("id", d.ID)
+	d.Data.Set("version", d.Version)
+	s.dashboards.byID[d.ID] = d
+	s.dashboards.byUID[d.UID] = d
+	return d, nil
+}
+
+func (s *fakeDashboardService) DeleteDashboard(ctx context.Context, dashboardID int64, orgID int64) error {
+	d, ok := s.dashboards.byID[dashboardID]
+	if !ok {
+		return dashboards.ErrDashboardNotFound
+	}
+	delete(s.dashboards.byID, d.ID)
+	delete(s.dashboards.byUID, d.UID)
+	return nil
+}
+
+type fakeFolderService struct {
+	folder.Service
+	dashboards *fakeDashboards
+}
+
+func (s *fakeFolderService) Create(ctx context.Context, cmd *folder.CreateFolderCommand) (*folder.Folder, error) {
+	uid := "folder-" + cmd.Title
+	id := s.dashboards.add(&dashboards.Dashboard{UID: uid, Title: cmd.Title, IsFolder: true, Version: 1})
+	return &folder.Folder{ID: id, OrgID: cmd.OrgID, UID: uid, Title: cmd.Title}, nil
+}
+
+type fakeStore struct {
+	dashboards *fakeDashboards
+	statuses   map[string]*gitsync.DashboardStatus
+	nextID     int64
+}
+
+func (s *fakeStore) ListDashboards(ctx context.Context, orgID int64) ([]*dashboardRef, error) {
+	result := make([]*dashboardRef, 0, len(s.dashboards.byID))
+	for _, d := range s.dashboards.byID {
+		result = append(result, &dashboardRef{
+			ID:        d.ID,
+			UID:       d.UID,
+			Title:     d.Title,
+			Version:   d.Version,
+			UpdatedBy: d.UpdatedBy,
+			IsFolder:  d.IsFolder,
+			FolderID:  d.FolderID,
+		})
+	}
+	return result, nil
+}
+
+func (s *fakeStore) GetStatuses(ctx context.Context, orgID int64) ([]*gitsync.DashboardStatus, error) {
+	result := make([]*gitsync.DashboardStatus, 0, len(s.statuses))
+	for _, status := range s.statuses {
+		copied := *status
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+func (s *fakeStore) GetStatus(ctx context.Context, orgID int64, dashboardUID string) (*gitsync.DashboardStatus, error) {
+	status, ok := s.statuses[dashboardUID]
+	if !ok {
+		return nil, gitsync.ErrDashboardNotSynced.Errorf("not synced")
+	}
+	copied := *status
+	return &copied, nil
+}
+
+func (s *fakeStore) SaveStatus(ctx context.Context, status *gitsync.DashboardStatus) error {
+	if status.ID == 0 {
+		s.nextID++
+		status.ID = s.nextID
+	}
+	copied := *status
+	s.statuses[status.DashboardUID] = &copied
+	return nil
+}
+
+func (s *fakeStore) DeleteStatus(ctx context.Context, orgID int64, dashboardUID string) error {
+	delete(s.statuses, dashboardUID)
+	return nil
+}
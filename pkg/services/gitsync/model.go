@@ -0,0 +1,84 @@
+package gitsync
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrNotConfigured      = errutil.NewBase(errutil.StatusNotFound, "gitsync.notConfigured", errutil.WithPublicMessage("Git sync is not configured for this organization"))
+	ErrDashboardNotSynced = errutil.NewBase(errutil.StatusNotFound, "gitsync.dashboardNotSynced", errutil.WithPublicMessage("Dashboard has not been synced yet"))
+	ErrNoConflict         = errutil.NewBase(errutil.StatusBadRequest, "gitsync.noConflict", errutil.WithPublicMessage("Dashboard has no conflict to resolve"))
+	ErrInvalidResolution  = errutil.NewBase(errutil.StatusBadRequest, "gitsync.invalidResolution", errutil.WithPublicMessage("Resolution must be either grafana or git"))
+	ErrSyncAlreadyRunning = errutil.NewBase(errutil.StatusTooManyRequests, "gitsync.alreadyRunning", errutil.WithPublicMessage("A sync is already running"))
+)
+
+// SyncState is the state of a dashboard after the last sync
+type SyncState string
+
+const (
+	// SyncStateSynced means the dashboard is the same in Grafana and in the repository
+	SyncStateSynced SyncState = "synced"
+	// SyncStateConflict means the dashboard was changed both in Grafana and in the repository,
+	// it isn't synced until the conflict is resolved
+	SyncStateConflict SyncState = "conflict"
+	// SyncStateError means the dashboard couldn't be synced, it's retried on the next sync
+	SyncStateError SyncState = "error"
+)
+
+// Resolution is the version of a dashboard kept when resolving a conflict
+type Resolution string
+
+const (
+	ResolutionGrafana Resolution = "grafana"
+	ResolutionGit     Resolution = "git"
+)
+
+// DashboardStatus is the sync status of a dashboard. Version and Hash are the dashboard version and
+// the hash of its file when it was last synced, changes are detected by comparing them with the
+// current dashboard and file.
+type DashboardStatus struct {
+	ID           int64     `xorm:"pk autoincr 'id'" json:"-"`
+	OrgID        int64     `xorm:"org_id" json:"-"`
+	DashboardUID string    `xorm:"dashboard_uid" json:"dashboardUid"`
+	Path         string    `xorm:"path" json:"path"`
+	Version      int       `xorm:"'version'" json:"version"`
+	Hash         string    `xorm:"hash" json:"-"`
+	Commit       string    `xorm:"commit_hash" json:"commit"`
+	State        SyncState `xorm:"state" json:"state"`
+	Message      string    `xorm:"message" json:"message,omitempty"`
+	// Resolution is set when a conflict is resolved, and applied by the next sync
+	Resolution Resolution `xorm:"resolution" json:"resolution,omitempty"`
+	Updated    time.Time  `xorm:"'updated'" json:"updated"`
+}
+
+func (s DashboardStatus) TableName() string {
+	return "dashboard_git_sync"
+}
+
+// Status is the status of the repository and of the synced dashboards
+type Status struct {
+	Repository string             `json:"repository"`
+	Branch     string             `json:"branch"`
+	Path       string             `json:"path"`
+	LastSync   *time.Time         `json:"lastSync,omitempty"`
+	LastCommit string             `json:"lastCommit,omitempty"`
+	LastError  string             `json:"lastError,omitempty"`
+	Dashboards []*DashboardStatus `json:"dashboards"`
+}
+
+type GetStatusQuery struct {
+	OrgID int64
+}
+
+type GetDashboardStatusQuery struct {
+	OrgID        int64
+	DashboardUID string
+}
+
+type ResolveConflictCommand struct {
+	OrgID        int64      `json:"-"`
+	DashboardUID string     `json:"-"`
+	Resolution   Resolution `json:"resolution"`
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"time"
+
 	"github.com/grafana/grafana/pkg/services/ldap"
 	"github.com/grafana/grafana/pkg/services/org"
 )
@@ -47,8 +49,19 @@ type LDAPUserDTO struct {
 
 // LDAPServerDTO is a serializer for LDAP server statuses
 type LDAPServerDTO struct {
-	Host      string `json:"host"`
-	Port      int    `json:"port"`
-	Available bool   `json:"available"`
-	Error     string `json:"error"`
+	Host      string               `json:"host"`
+	Port      int                  `json:"port"`
+	Available bool                 `json:"available"`
+	Error     string               `json:"error"`
+	Health    *LDAPServerHealthDTO `json:"health,omitempty"`
+}
+
+// LDAPServerHealthDTO is a serializer for the health of the LDAP servers, as seen by the health checks of their connection pools
+type LDAPServerHealthDTO struct {
+	Healthy             bool      `json:"healthy"`
+	LastCheck           time.Time `json:"lastCheck"`
+	LastError           string    `json:"lastError"`
+	LatencyMs           int64     `json:"latencyMs"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	IdleConnections     int       `json:"idleConnections"`
 }
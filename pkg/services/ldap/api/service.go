@@ -106,7 +106,8 @@ func (s *Service) ReloadLDAPCfg(c *contextmodel.ReqContext) response.Response {
 
 // swagger:route GET /admin/ldap/status admin_ldap getLDAPStatus
 //
-// Attempts to connect to all the configured LDAP servers and returns information on whenever they're available or not.
+// Attempts to connect to all the configured LDAP servers and returns information on whenever they're available or not,
+// along with the health of the servers as seen by the health checks of their connection pools.
 //
 // If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `ldap.status:read`.
 //
@@ -145,6 +146,19 @@ func (s *Service) GetLDAPStatus(c *contextmodel.ReqContext) response.Response {
 			s.Error = status.Error.Error()
 		}
 
+		if status.Health != nil {
+			s.Health = &LDAPServerHealthDTO{
+				Healthy:             status.Health.Healthy,
+				LastCheck:           status.Health.LastCheck,
+				LatencyMs:           status.Health.Latency.Milliseconds(),
+				ConsecutiveFailures: status.Health.ConsecutiveFailures,
+				IdleConnections:     status.Health.IdleConnections,
+			}
+			if status.Health.LastError != nil {
+				s.Health.LastError = status.Health.LastError.Error()
+			}
+		}
+
 		serverDTOs = append(serverDTOs, s)
 	}
 
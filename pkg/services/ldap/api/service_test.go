@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -396,6 +397,55 @@ func TestGetLDAPStatusAPIEndpoint(t *testing.T) {
 	assert.JSONEq(t, expected, string(bodyBytes))
 }
 
+func TestGetLDAPStatusAPIEndpoint_Health(t *testing.T) {
+	lastCheck := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	pingResult = []*multildap.ServerStatus{
+		{Host: "10.0.0.3", Port: 361, Available: true, Health: &multildap.ServerHealth{
+			Healthy: true, LastCheck: lastCheck, Latency: 12 * time.Millisecond, IdleConnections: 2,
+		}},
+		{Host: "10.0.0.5", Port: 361, Available: false, Error: errors.New("connection refused"), Health: &multildap.ServerHealth{
+			Healthy: false, LastCheck: lastCheck, Latency: time.Second, LastError: errors.New("connection refused"), ConsecutiveFailures: 3,
+		}},
+	}
+	t.Cleanup(func() { pingResult = nil })
+
+	_, server := setupAPITest(t, func(a *Service) {
+		a.ldapService = &service.LDAPFakeService{
+			ExpectedClient: &LDAPMock{},
+			ExpectedConfig: &ldap.Config{},
+		}
+	})
+
+	req := server.NewGetRequest("/api/admin/ldap/status")
+	webtest.RequestWithSignedInUser(req, &user.SignedInUser{
+		OrgID: 1,
+		Permissions: map[int64]map[string][]string{
+			1: {"ldap.status:read": {}}},
+	})
+
+	res, err := server.Send(req)
+	defer func() { require.NoError(t, res.Body.Close()) }()
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	expected := `
+	[
+		{
+			"host": "10.0.0.3", "port": 361, "available": true, "error": "",
+			"health": { "healthy": true, "lastCheck": "2023-06-01T10:00:00Z", "lastError": "", "latencyMs": 12, "consecutiveFailures": 0, "idleConnections": 2 }
+		},
+		{
+			"host": "10.0.0.5", "port": 361, "available": false, "error": "connection refused",
+			"health": { "healthy": false, "lastCheck": "2023-06-01T10:00:00Z", "lastError": "connection refused", "latencyMs": 1000, "consecutiveFailures": 3, "idleConnections": 0 }
+		}
+	]
+	`
+
+	bodyBytes, _ := io.ReadAll(res.Body)
+	assert.JSONEq(t, expected, string(bodyBytes))
+}
+
 func TestPostSyncUserWithLDAPAPIEndpoint_Success(t *testing.T) {
 	userServiceMock := usertest.NewUserServiceFake()
 	userServiceMock.ExpectedUser = &user.User{Login: "ldap-daniel", ID: 34}
@@ -34,6 +34,15 @@ func (s *Service) supportBundleCollector(context.Context) (*supportbundles.Suppo
 			if server.Error != nil {
 				bWriter.WriteString(fmt.Sprintf("Error: %s\n", server.Error))
 			}
+			if server.Health != nil {
+				bWriter.WriteString(fmt.Sprintf("Healthy: %v  \n", server.Health.Healthy))
+				bWriter.WriteString(fmt.Sprintf("Health check latency: %s  \n", server.Health.Latency))
+				bWriter.WriteString(fmt.Sprintf("Consecutive failures: %d  \n", server.Health.ConsecutiveFailures))
+				bWriter.WriteString(fmt.Sprintf("Idle connections: %d  \n", server.Health.IdleConnections))
+				if server.Health.LastError != nil {
+					bWriter.WriteString(fmt.Sprintf("Last error: %s\n", server.Health.LastError))
+				}
+			}
 		}
 
 		bWriter.WriteString("\n## LDAP Common Configuration issues\n\n")
@@ -78,6 +87,10 @@ func (s *Service) supportBundleCollector(context.Context) (*supportbundles.Suppo
 	bWriter.WriteString(fmt.Sprintf("sync_cron = %s\n", s.cfg.LDAPSyncCron))
 	bWriter.WriteString(fmt.Sprintf("active_sync_enabled = %v\n", s.cfg.LDAPActiveSyncEnabled))
 	bWriter.WriteString(fmt.Sprintf("skip_org_role_sync = %v\n", s.cfg.LDAPSkipOrgRoleSync))
+	bWriter.WriteString(fmt.Sprintf("pool_max_idle_connections = %d\n", s.cfg.LDAPPoolMaxIdleConnections))
+	bWriter.WriteString(fmt.Sprintf("pool_idle_timeout = %s\n", s.cfg.LDAPPoolIdleTimeout))
+	bWriter.WriteString(fmt.Sprintf("pool_strategy = %s\n", s.cfg.LDAPPoolStrategy))
+	bWriter.WriteString(fmt.Sprintf("health_check_interval = %s\n", s.cfg.LDAPHealthCheckInterval))
 
 	bWriter.WriteString("```\n\n")
 
@@ -19,6 +19,12 @@ func IsMemberOf(memberOf []string, group string) bool {
 	return false
 }
 
+// IsNetworkError checks if the error comes from the connection with the LDAP server,
+// such as a connection closed by the server, rather than from the LDAP request.
+func IsNetworkError(err error) bool {
+	return ldap.IsErrorWithCode(err, ldap.ErrorNetwork)
+}
+
 func appendIfNotEmpty(slice []string, values ...string) []string {
 	for _, v := range values {
 		if v != "" {
@@ -0,0 +1,226 @@
+package multildap
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ldap"
+)
+
+const (
+	fakeAdminDN       = "cn=admin,dc=grafana,dc=org"
+	fakeAdminPassword = "admin"
+)
+
+type fakeUser struct {
+	password string
+	email    string
+	name     string
+}
+
+// fakeServer is an in-process LDAP server answering the binds and the user searches
+// of the ldap package, so the pools are tested with real connections.
+type fakeServer struct {
+	t        *testing.T
+	listener net.Listener
+	users    map[string]fakeUser
+
+	mu       sync.Mutex
+	conns    []net.Conn
+	accepted int
+	binds    map[string]int
+	delay    time.Duration
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeServer{
+		t:        t,
+		listener: listener,
+		users: map[string]fakeUser{
+			"alice": {password: "secret", email: "alice@grafana.org", name: "Alice"},
+		},
+		binds: map[string]int{},
+	}
+	go s.serve()
+	t.Cleanup(s.stop)
+
+	return s
+}
+
+func (s *fakeServer) config() *ldap.ServerConfig {
+	return &ldap.ServerConfig{
+		Host:          "127.0.0.1",
+		Port:          s.listener.Addr().(*net.TCPAddr).Port,
+		BindDN:        fakeAdminDN,
+		BindPassword:  fakeAdminPassword,
+		Timeout:       1,
+		SearchFilter:  "(uid=%s)",
+		SearchBaseDNs: []string{"dc=grafana,dc=org"},
+		Attr: ldap.AttributeMap{
+			Username: "uid",
+			Email:    "mail",
+			Name:     "cn",
+		},
+	}
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.accepted++
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		s.mu.Lock()
+		delay := s.delay
+		s.mu.Unlock()
+		time.Sleep(delay)
+
+		messageID, request := packet.Children[0].Value, packet.Children[1]
+		switch request.Tag {
+		case goldap.ApplicationBindRequest:
+			s.write(conn, messageID, s.bind(request))
+		case goldap.ApplicationSearchRequest:
+			for _, response := range s.search(request) {
+				s.write(conn, messageID, response)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (s *fakeServer) bind(request *ber.Packet) *ber.Packet {
+	dn := request.Children[1].Value.(string)
+	password := request.Children[2].Data.String()
+
+	s.mu.Lock()
+	s.binds[dn]++
+	s.mu.Unlock()
+
+	if dn == fakeAdminDN && password == fakeAdminPassword {
+		return fakeResult(goldap.ApplicationBindResponse, goldap.LDAPResultSuccess)
+	}
+	for uid, user := range s.users {
+		if dn == fakeUserDN(uid) && password == user.password {
+			return fakeResult(goldap.ApplicationBindResponse, goldap.LDAPResultSuccess)
+		}
+	}
+
+	return fakeResult(goldap.ApplicationBindResponse, goldap.LDAPResultInvalidCredentials)
+}
+
+func (s *fakeServer) search(request *ber.Packet) []*ber.Packet {
+	filter, err := goldap.DecompileFilter(request.Children[6])
+	require.NoError(s.t, err)
+
+	var responses []*ber.Packet
+	for uid, user := range s.users {
+		if !strings.Contains(filter, "(uid="+uid+")") {
+			continue
+		}
+
+		entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+		entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, fakeUserDN(uid), "objectName"))
+		attributes := ber.NewSequence("attributes")
+		for name, value := range map[string]string{"uid": uid, "mail": user.email, "cn": user.name} {
+			attribute := ber.NewSequence("attribute")
+			attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+			values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+			values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "value"))
+			attribute.AppendChild(values)
+			attributes.AppendChild(attribute)
+		}
+		entry.AppendChild(attributes)
+		responses = append(responses, entry)
+	}
+
+	return append(responses, fakeResult(goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess))
+}
+
+func (s *fakeServer) write(conn net.Conn, messageID interface{}, response *ber.Packet) {
+	packet := ber.NewSequence("LDAPMessage")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "messageID"))
+	packet.AppendChild(response)
+	_, _ = conn.Write(packet.Bytes())
+}
+
+func fakeResult(tag ber.Tag, code uint16) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, goldap.ApplicationMap[uint8(tag)])
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "resultCode"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	return result
+}
+
+func fakeUserDN(uid string) string {
+	return "uid=" + uid + ",ou=users,dc=grafana,dc=org"
+}
+
+// dropConnections closes the connections on the server side, like servers closing idle connections
+func (s *fakeServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.conns = nil
+}
+
+// stop closes the listener and the connections, the server is then unreachable
+func (s *fakeServer) stop() {
+	_ = s.listener.Close()
+	s.dropConnections()
+}
+
+func (s *fakeServer) setDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delay = delay
+}
+
+func (s *fakeServer) acceptedConnections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.accepted
+}
+
+func (s *fakeServer) bindCount(dn string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.binds[dn]
+}
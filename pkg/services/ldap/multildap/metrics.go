@@ -0,0 +1,93 @@
+package multildap
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "grafana"
+	metricsSubSystem = "ldap"
+)
+
+// Metrics holds the per server metrics of the LDAP pools. They're registered once
+// and shared by the clients created when the LDAP configuration is reloaded.
+type Metrics struct {
+	requestDuration *prometheus.HistogramVec
+	requestFailures *prometheus.CounterVec
+	serverHealthy   *prometheus.GaugeVec
+	idleConnections *prometheus.GaugeVec
+}
+
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubSystem,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the requests to the LDAP servers",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"server", "operation"}),
+		requestFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubSystem,
+			Name:      "request_failures_total",
+			Help:      "Number of failed requests to the LDAP servers, invalid credentials and unknown users excluded",
+		}, []string{"server", "operation"}),
+		serverHealthy: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubSystem,
+			Name:      "server_healthy",
+			Help:      "Whether the LDAP server passed its last health check",
+		}, []string{"server"}),
+		idleConnections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubSystem,
+			Name:      "pool_idle_connections",
+			Help:      "Number of idle connections in the pool of the LDAP server",
+		}, []string{"server"}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(
+			m.requestDuration,
+			m.requestFailures,
+			m.serverHealthy,
+			m.idleConnections,
+		)
+	}
+
+	return m
+}
+
+func (m *Metrics) observeRequest(server, operation string, duration time.Duration, failed bool) {
+	if m == nil {
+		return
+	}
+
+	m.requestDuration.WithLabelValues(server, operation).Observe(duration.Seconds())
+	if failed {
+		m.requestFailures.WithLabelValues(server, operation).Inc()
+	}
+}
+
+func (m *Metrics) setHealthy(server string, healthy bool) {
+	if m == nil {
+		return
+	}
+
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	m.serverHealthy.WithLabelValues(server).Set(value)
+}
+
+func (m *Metrics) setIdleConnections(server string, count int) {
+	if m == nil {
+		return
+	}
+
+	m.idleConnections.WithLabelValues(server).Set(float64(count))
+}
@@ -2,6 +2,9 @@ package multildap
 
 import (
 	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -33,6 +36,8 @@ type ServerStatus struct {
 	Port      int
 	Available bool
 	Error     error
+	// Health is only set for the pooled clients
+	Health *ServerHealth
 }
 
 // IMultiLDAP is interface for MultiLDAP
@@ -56,15 +61,116 @@ type MultiLDAP struct {
 	configs []*ldap.ServerConfig
 	cfg     *setting.Cfg
 	log     log.Logger
+
+	pools     []*serverPool
+	pooling   bool
+	next      uint64
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-// New creates the new LDAP auth
+// New creates the new LDAP auth, dialing the servers for each request
 func New(configs []*ldap.ServerConfig, cfg *setting.Cfg) IMultiLDAP {
+	return newMultiLDAP(configs, cfg, false, nil)
+}
+
+// NewPooled creates the new LDAP auth with a pool of connections per server. The
+// servers are checked in the background every health_check_interval, and the
+// unhealthy servers are skipped by the logins while another server is healthy.
+// Close stops the health checks and closes the connections.
+func NewPooled(configs []*ldap.ServerConfig, cfg *setting.Cfg, metrics *Metrics) *MultiLDAP {
+	multiples := newMultiLDAP(configs, cfg, true, metrics)
+
+	if cfg.LDAPHealthCheckInterval > 0 && len(configs) > 0 {
+		go multiples.runHealthChecks(cfg.LDAPHealthCheckInterval)
+	}
+
+	return multiples
+}
+
+func newMultiLDAP(configs []*ldap.ServerConfig, cfg *setting.Cfg, pooling bool, metrics *Metrics) *MultiLDAP {
+	pools := make([]*serverPool, 0, len(configs))
+	for _, config := range configs {
+		pools = append(pools, newServerPool(config, cfg, pooling, metrics))
+	}
+
 	return &MultiLDAP{
 		configs: configs,
 		cfg:     cfg,
 		log:     log.New("ldap"),
+		pools:   pools,
+		pooling: pooling,
+		done:    make(chan struct{}),
+	}
+}
+
+// Close stops the health checks and closes the idle connections of the pools
+func (multiples *MultiLDAP) Close() {
+	multiples.closeOnce.Do(func() {
+		if multiples.done != nil {
+			close(multiples.done)
+		}
+
+		for _, pool := range multiples.pools {
+			pool.close()
+		}
+	})
+}
+
+func (multiples *MultiLDAP) runHealthChecks(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	multiples.checkHealth()
+	for {
+		select {
+		case <-multiples.done:
+			return
+		case <-ticker.C:
+			multiples.checkHealth()
+		}
+	}
+}
+
+// checkHealth checks the servers concurrently, so a slow server doesn't delay the
+// checks of the others
+func (multiples *MultiLDAP) checkHealth() {
+	var wg sync.WaitGroup
+	for _, pool := range multiples.pools {
+		wg.Add(1)
+		go func(pool *serverPool) {
+			defer wg.Done()
+			pool.check()
+		}(pool)
+	}
+	wg.Wait()
+}
+
+// orderedPools returns the pools in the order they're tried by the logins and the
+// user lookups. The unhealthy servers are skipped while another server is healthy,
+// and the round robin strategy rotates the first healthy server.
+func (multiples *MultiLDAP) orderedPools() []*serverPool {
+	healthy := make([]*serverPool, 0, len(multiples.pools))
+	for _, pool := range multiples.pools {
+		if pool.healthy() {
+			healthy = append(healthy, pool)
+		} else {
+			multiples.log.Debug("skipping unhealthy LDAP server", "host", pool.config.Host, "port", pool.config.Port)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return multiples.pools
 	}
+
+	if multiples.cfg.LDAPPoolStrategy == StrategyRoundRobin && len(healthy) > 1 {
+		start := int((atomic.AddUint64(&multiples.next, 1) - 1) % uint64(len(healthy)))
+		rotated := make([]*serverPool, 0, len(healthy))
+		rotated = append(rotated, healthy[start:]...)
+		healthy = append(rotated, healthy[:start]...)
+	}
+
+	return healthy
 }
 
 // Ping dials each of the LDAP servers and returns their status. If the server is unavailable, it also returns the error.
@@ -94,6 +200,12 @@ func (multiples *MultiLDAP) Ping() ([]*ServerStatus, error) {
 		}
 	}
 
+	if multiples.pooling {
+		for i, pool := range multiples.pools {
+			serverStatuses[i].Health = pool.healthStatus()
+		}
+	}
+
 	return serverStatuses, nil
 }
 
@@ -107,22 +219,27 @@ func (multiples *MultiLDAP) Login(query *login.LoginUserQuery) (
 
 	ldapSilentErrors := []error{}
 
-	for index, config := range multiples.configs {
-		server := newLDAP(config, multiples.cfg)
+	pools := multiples.orderedPools()
+	for index, pool := range pools {
+		config := pool.config
 
-		if err := server.Dial(); err != nil {
-			logDialFailure(err, config)
+		var user *login.ExternalUserInfo
+		dialed := false
+		err := pool.do("login", func(server ldap.IServer) error {
+			dialed = true
 
+			var err error
+			user, err = server.Login(query)
+			return err
+		})
+		if !dialed || ldap.IsNetworkError(err) {
 			// Only return an error if it is the last server so we can try next server
-			if index == len(multiples.configs)-1 {
+			if index == len(pools)-1 {
 				return nil, err
 			}
 			continue
 		}
 
-		defer server.Close()
-
-		user, err := server.Login(query)
 		if err != nil {
 			if isSilentError(err) {
 				ldapSilentErrors = append(ldapSilentErrors, err)
@@ -155,7 +272,7 @@ func (multiples *MultiLDAP) Login(query *login.LoginUserQuery) (
 }
 
 // User attempts to find an user by login/username by searching into all of the configured LDAP servers. Then, if the user is found it returns the user alongisde the server it was found.
-func (multiples *MultiLDAP) User(login string) (
+func (multiples *MultiLDAP) User(username string) (
 	*login.ExternalUserInfo,
 	ldap.ServerConfig,
 	error,
@@ -164,27 +281,32 @@ func (multiples *MultiLDAP) User(login string) (
 		return nil, ldap.ServerConfig{}, ErrNoLDAPServers
 	}
 
-	search := []string{login}
-	for index, config := range multiples.configs {
-		server := newLDAP(config, multiples.cfg)
+	search := []string{username}
+	pools := multiples.orderedPools()
+	for index, pool := range pools {
+		config := pool.config
 
-		if err := server.Dial(); err != nil {
-			logDialFailure(err, config)
+		var users []*login.ExternalUserInfo
+		dialed := false
+		err := pool.do("search", func(server ldap.IServer) error {
+			dialed = true
 
+			if err := server.Bind(); err != nil {
+				return err
+			}
+
+			var err error
+			users, err = server.Users(search)
+			return err
+		})
+		if !dialed || ldap.IsNetworkError(err) {
 			// Only return an error if it is the last server so we can try next server
-			if index == len(multiples.configs)-1 {
+			if index == len(pools)-1 {
 				return nil, *config, err
 			}
 			continue
 		}
 
-		defer server.Close()
-
-		if err := server.Bind(); err != nil {
-			return nil, *config, err
-		}
-
-		users, err := server.Users(search)
 		if err != nil {
 			return nil, *config, err
 		}
@@ -208,26 +330,30 @@ func (multiples *MultiLDAP) Users(logins []string) (
 		return nil, ErrNoLDAPServers
 	}
 
-	for index, config := range multiples.configs {
-		server := newLDAP(config, multiples.cfg)
+	// all the servers are searched, the unhealthy ones included, so the users of a
+	// server that is temporarily unreachable aren't missing from the results
+	for index, pool := range multiples.pools {
+		var users []*login.ExternalUserInfo
+		dialed := false
+		err := pool.do("search", func(server ldap.IServer) error {
+			dialed = true
 
-		if err := server.Dial(); err != nil {
-			logDialFailure(err, config)
+			if err := server.Bind(); err != nil {
+				return err
+			}
 
+			var err error
+			users, err = server.Users(logins)
+			return err
+		})
+		if !dialed || ldap.IsNetworkError(err) {
 			// Only return an error if it is the last server so we can try next server
-			if index == len(multiples.configs)-1 {
+			if index == len(multiples.pools)-1 {
 				return nil, err
 			}
 			continue
 		}
 
-		defer server.Close()
-
-		if err := server.Bind(); err != nil {
-			return nil, err
-		}
-
-		users, err := server.Users(logins)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,259 @@
+package multildap
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// StrategyFailover tries the healthy servers in the order of the configuration
+	StrategyFailover = "failover"
+	// StrategyRoundRobin rotates the first server tried between the healthy servers
+	StrategyRoundRobin = "round_robin"
+)
+
+// defaultHealthCheckTimeout is used for the servers without timeout
+const defaultHealthCheckTimeout = 10 * time.Second
+
+// ServerHealth holds the health of a LDAP server, as seen by the health checks
+// and the requests to the server.
+type ServerHealth struct {
+	Healthy             bool
+	LastCheck           time.Time
+	LastError           error
+	Latency             time.Duration
+	ConsecutiveFailures int
+	IdleConnections     int
+}
+
+type idleServer struct {
+	server    ldap.IServer
+	idleSince time.Time
+}
+
+// serverPool keeps the connections of a LDAP server once the requests are done,
+// so they're reused by the following requests instead of dialing the server again.
+// The requests bind the connections again before using them.
+type serverPool struct {
+	config      *ldap.ServerConfig
+	cfg         *setting.Cfg
+	name        string
+	maxIdle     int
+	idleTimeout time.Duration
+	metrics     *Metrics
+	// trackFailures marks the server unhealthy when a request can't reach it,
+	// the health checks then mark it healthy again once it's back
+	trackFailures bool
+
+	mu     sync.Mutex
+	idle   []idleServer
+	health ServerHealth
+	closed bool
+}
+
+// newServerPool creates the pool of a server. Without pooling, the connections are
+// closed after each request and the health of the server isn't tracked.
+func newServerPool(config *ldap.ServerConfig, cfg *setting.Cfg, pooling bool, metrics *Metrics) *serverPool {
+	p := &serverPool{
+		config:  config,
+		cfg:     cfg,
+		name:    fmt.Sprintf("%s:%d", config.Host, config.Port),
+		metrics: metrics,
+		health:  ServerHealth{Healthy: true},
+	}
+
+	if pooling {
+		p.maxIdle = cfg.LDAPPoolMaxIdleConnections
+		p.idleTimeout = cfg.LDAPPoolIdleTimeout
+		p.trackFailures = cfg.LDAPHealthCheckInterval > 0
+	}
+
+	metrics.setHealthy(p.name, true)
+	metrics.setIdleConnections(p.name, 0)
+
+	return p
+}
+
+// do runs fn with an idle connection of the pool, or a new connection when there's
+// none. Requests failing on an idle connection are retried once on a new connection,
+// since the server may have closed the connection while it was idle.
+func (p *serverPool) do(operation string, fn func(server ldap.IServer) error) (err error) {
+	start := time.Now()
+	defer func() {
+		p.observe(operation, time.Since(start), err)
+	}()
+
+	server, reused, err := p.get()
+	if err != nil {
+		return err
+	}
+
+	err = fn(server)
+	if err != nil && reused && !isSilentError(err) {
+		server.Close()
+
+		if server, err = p.dial(); err != nil {
+			return err
+		}
+		err = fn(server)
+	}
+
+	p.put(server, err)
+	return err
+}
+
+func (p *serverPool) get() (ldap.IServer, bool, error) {
+	p.mu.Lock()
+	for len(p.idle) > 0 {
+		last := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+
+		if p.idleTimeout > 0 && time.Since(last.idleSince) > p.idleTimeout {
+			last.server.Close()
+			continue
+		}
+
+		p.metrics.setIdleConnections(p.name, len(p.idle))
+		p.mu.Unlock()
+		return last.server, true, nil
+	}
+	p.metrics.setIdleConnections(p.name, 0)
+	p.mu.Unlock()
+
+	server, err := p.dial()
+	return server, false, err
+}
+
+func (p *serverPool) dial() (ldap.IServer, error) {
+	server := newLDAP(p.config, p.cfg)
+	if err := server.Dial(); err != nil {
+		logDialFailure(err, p.config)
+		return nil, err
+	}
+
+	return server, nil
+}
+
+func (p *serverPool) put(server ldap.IServer, err error) {
+	// the state of the connection is unknown after errors other than the failed
+	// authentications, it's safer to dial a new one next time
+	if err != nil && !isSilentError(err) {
+		server.Close()
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || len(p.idle) >= p.maxIdle {
+		server.Close()
+		return
+	}
+
+	p.idle = append(p.idle, idleServer{server: server, idleSince: time.Now()})
+	p.metrics.setIdleConnections(p.name, len(p.idle))
+}
+
+func (p *serverPool) observe(operation string, duration time.Duration, err error) {
+	// invalid credentials and unknown users are answers of the server, not failures
+	failed := err != nil && !isSilentError(err)
+	p.metrics.observeRequest(p.name, operation, duration, failed)
+
+	if failed && p.trackFailures && operation != "health_check" {
+		p.recordHealth(err)
+	}
+}
+
+// check binds a connection of the pool with the search user of the configuration,
+// or dials a new connection when there's none. Servers that don't answer within
+// their timeout are unhealthy.
+func (p *serverPool) check() {
+	timeout := time.Duration(p.config.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() {
+		if p.config.BindPassword == "" {
+			// without search user, binding would depend on anonymous binds being allowed
+			server, err := p.dial()
+			p.metrics.observeRequest(p.name, "health_check", time.Since(start), err != nil)
+			if err == nil {
+				p.put(server, nil)
+			}
+			result <- err
+			return
+		}
+
+		result <- p.do("health_check", func(server ldap.IServer) error {
+			return server.Bind()
+		})
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-time.After(timeout):
+		err = fmt.Errorf("no answer from the LDAP server after %s", timeout)
+	}
+
+	p.mu.Lock()
+	p.health.LastCheck = time.Now()
+	p.health.Latency = time.Since(start)
+	p.mu.Unlock()
+
+	p.recordHealth(err)
+}
+
+// recordHealth updates the health of the server with the result of a request or a
+// health check. Only the health checks mark the server healthy again.
+func (p *serverPool) recordHealth(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err != nil {
+		p.health.Healthy = false
+		p.health.LastError = err
+		p.health.ConsecutiveFailures++
+	} else {
+		p.health.Healthy = true
+		p.health.LastError = nil
+		p.health.ConsecutiveFailures = 0
+	}
+
+	p.metrics.setHealthy(p.name, p.health.Healthy)
+}
+
+func (p *serverPool) healthy() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.health.Healthy
+}
+
+func (p *serverPool) healthStatus() *ServerHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	health := p.health
+	health.IdleConnections = len(p.idle)
+	return &health
+}
+
+func (p *serverPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for _, idle := range p.idle {
+		idle.server.Close()
+	}
+	p.idle = nil
+	p.metrics.setIdleConnections(p.name, 0)
+}
@@ -0,0 +1,200 @@
+package multildap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestPooledMultiLDAP(t *testing.T) {
+	t.Run("Should reuse the connections between logins", func(t *testing.T) {
+		server := newFakeServer(t)
+		metrics := NewMetrics(prometheus.NewRegistry())
+		multi := newTestPooledMultiLDAP(t, poolCfg(), metrics, server)
+
+		for i := 0; i < 3; i++ {
+			user, err := multi.Login(&login.LoginUserQuery{Username: "alice", Password: "secret"})
+			require.NoError(t, err)
+			assert.Equal(t, "alice", user.Login)
+			assert.Equal(t, "alice@grafana.org", user.Email)
+		}
+
+		_, err := multi.Login(&login.LoginUserQuery{Username: "alice", Password: "wrong"})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+
+		user, _, err := multi.User("alice")
+		require.NoError(t, err)
+		assert.Equal(t, "alice", user.Login)
+
+		assert.Equal(t, 1, server.acceptedConnections())
+		assert.Equal(t, 2, testutil.CollectAndCount(metrics.requestDuration), "login and search durations")
+		assert.Zero(t, testutil.CollectAndCount(metrics.requestFailures), "invalid credentials aren't failures")
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.idleConnections.WithLabelValues(multi.pools[0].name)))
+	})
+
+	t.Run("Should not reuse the connections idle for longer than the idle timeout", func(t *testing.T) {
+		server := newFakeServer(t)
+		cfg := poolCfg()
+		cfg.LDAPPoolIdleTimeout = time.Nanosecond
+		multi := newTestPooledMultiLDAP(t, cfg, nil, server)
+
+		for i := 0; i < 2; i++ {
+			_, err := multi.Login(&login.LoginUserQuery{Username: "alice", Password: "secret"})
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, 2, server.acceptedConnections())
+	})
+
+	t.Run("Should dial again when the server closed the idle connection", func(t *testing.T) {
+		server := newFakeServer(t)
+		multi := newTestPooledMultiLDAP(t, poolCfg(), nil, server)
+
+		_, err := multi.Login(&login.LoginUserQuery{Username: "alice", Password: "secret"})
+		require.NoError(t, err)
+
+		server.dropConnections()
+
+		user, err := multi.Login(&login.LoginUserQuery{Username: "alice", Password: "secret"})
+		require.NoError(t, err)
+		assert.Equal(t, "alice", user.Login)
+		assert.Equal(t, 2, server.acceptedConnections())
+	})
+
+	t.Run("Should skip the servers that are down", func(t *testing.T) {
+		down, up := newFakeServer(t), newFakeServer(t)
+		metrics := NewMetrics(prometheus.NewRegistry())
+		multi := newTestPooledMultiLDAP(t, poolCfg(), metrics, down, up)
+
+		down.stop()
+		multi.checkHealth()
+
+		user, err := multi.Login(&login.LoginUserQuery{Username: "alice", Password: "secret"})
+		require.NoError(t, err)
+		assert.Equal(t, "alice", user.Login)
+		assert.Equal(t, 0, down.acceptedConnections())
+
+		downName, upName := multi.pools[0].name, multi.pools[1].name
+		assert.Equal(t, 0.0, testutil.ToFloat64(metrics.serverHealthy.WithLabelValues(downName)))
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.serverHealthy.WithLabelValues(upName)))
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requestFailures.WithLabelValues(downName, "health_check")))
+
+		statuses, err := multi.Ping()
+		require.NoError(t, err)
+		require.NotNil(t, statuses[0].Health)
+		assert.False(t, statuses[0].Health.Healthy)
+		assert.Error(t, statuses[0].Health.LastError)
+		assert.Equal(t, 1, statuses[0].Health.ConsecutiveFailures)
+		assert.True(t, statuses[1].Health.Healthy)
+		assert.Equal(t, 1, statuses[1].Health.IdleConnections)
+	})
+
+	t.Run("Should mark the server unhealthy when a login can't reach it", func(t *testing.T) {
+		down, up := newFakeServer(t), newFakeServer(t)
+		multi := newTestPooledMultiLDAP(t, poolCfg(), nil, down, up)
+
+		down.stop()
+
+		for i := 0; i < 2; i++ {
+			_, err := multi.Login(&login.LoginUserQuery{Username: "alice", Password: "secret"})
+			require.NoError(t, err)
+		}
+
+		assert.False(t, multi.pools[0].healthy())
+		assert.Equal(t, 2, up.bindCount(fakeUserDN("alice")))
+	})
+
+	t.Run("Should skip the servers that don't answer within their timeout", func(t *testing.T) {
+		slow, fast := newFakeServer(t), newFakeServer(t)
+		multi := newTestPooledMultiLDAP(t, poolCfg(), nil, slow, fast)
+
+		slow.setDelay(2 * time.Second)
+		multi.checkHealth()
+		require.False(t, multi.pools[0].healthy())
+
+		start := time.Now()
+		_, err := multi.Login(&login.LoginUserQuery{Username: "alice", Password: "secret"})
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Zero(t, slow.bindCount(fakeUserDN("alice")))
+
+		slow.setDelay(0)
+		multi.checkHealth()
+		assert.True(t, multi.pools[0].healthy())
+	})
+
+	t.Run("Should try all the servers when none is healthy", func(t *testing.T) {
+		server := newFakeServer(t)
+		multi := newTestPooledMultiLDAP(t, poolCfg(), nil, server)
+
+		server.setDelay(2 * time.Second)
+		multi.checkHealth()
+		server.setDelay(0)
+
+		_, err := multi.Login(&login.LoginUserQuery{Username: "alice", Password: "secret"})
+		require.NoError(t, err)
+	})
+
+	t.Run("Should rotate the servers with the round robin strategy", func(t *testing.T) {
+		first, second := newFakeServer(t), newFakeServer(t)
+		cfg := poolCfg()
+		cfg.LDAPPoolStrategy = StrategyRoundRobin
+		multi := newTestPooledMultiLDAP(t, cfg, nil, first, second)
+
+		for i := 0; i < 4; i++ {
+			_, err := multi.Login(&login.LoginUserQuery{Username: "alice", Password: "secret"})
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, 2, first.bindCount(fakeUserDN("alice")))
+		assert.Equal(t, 2, second.bindCount(fakeUserDN("alice")))
+	})
+
+	t.Run("Should close the idle connections", func(t *testing.T) {
+		server := newFakeServer(t)
+		multi := newTestPooledMultiLDAP(t, poolCfg(), nil, server)
+
+		_, err := multi.Login(&login.LoginUserQuery{Username: "alice", Password: "secret"})
+		require.NoError(t, err)
+
+		multi.Close()
+		assert.Zero(t, multi.pools[0].healthStatus().IdleConnections)
+
+		_, err = multi.Login(&login.LoginUserQuery{Username: "alice", Password: "secret"})
+		require.NoError(t, err)
+		assert.Zero(t, multi.pools[0].healthStatus().IdleConnections)
+	})
+}
+
+func poolCfg() *setting.Cfg {
+	cfg := setting.NewCfg()
+	cfg.LDAPPoolMaxIdleConnections = 2
+	cfg.LDAPPoolIdleTimeout = time.Minute
+	cfg.LDAPPoolStrategy = StrategyFailover
+	cfg.LDAPHealthCheckInterval = time.Hour
+	return cfg
+}
+
+// newTestPooledMultiLDAP creates a pooled client without the background health
+// checks, the tests run them with checkHealth.
+func newTestPooledMultiLDAP(t *testing.T, cfg *setting.Cfg, metrics *Metrics, servers ...*fakeServer) *MultiLDAP {
+	t.Helper()
+
+	configs := make([]*ldap.ServerConfig, 0, len(servers))
+	for _, server := range servers {
+		configs = append(configs, server.config())
+	}
+
+	multi := newMultiLDAP(configs, cfg, true, metrics)
+	t.Cleanup(multi.Close)
+
+	return multi
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"sync"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/ldap"
 	"github.com/grafana/grafana/pkg/services/ldap/multildap"
@@ -29,21 +31,23 @@ type LDAP interface {
 }
 
 type LDAPImpl struct {
-	client  multildap.IMultiLDAP
+	client  *multildap.MultiLDAP
 	cfg     *setting.Cfg
 	ldapCfg *ldap.Config
 	log     log.Logger
+	metrics *multildap.Metrics
 
 	// loadingMutex locks the reading of the config so multiple requests for reloading are sequential.
 	loadingMutex *sync.Mutex
 }
 
-func ProvideService(cfg *setting.Cfg) *LDAPImpl {
+func ProvideService(cfg *setting.Cfg, registerer prometheus.Registerer) *LDAPImpl {
 	s := &LDAPImpl{
 		client:       nil,
 		ldapCfg:      nil,
 		cfg:          cfg,
 		log:          log.New("ldap.service"),
+		metrics:      multildap.NewMetrics(registerer),
 		loadingMutex: &sync.Mutex{},
 	}
 
@@ -56,7 +60,7 @@ func ProvideService(cfg *setting.Cfg) *LDAPImpl {
 		s.log.Error("Failed to get LDAP config", "error", err)
 	} else {
 		s.ldapCfg = ldapCfg
-		s.client = multildap.New(s.ldapCfg.Servers, s.cfg)
+		s.client = multildap.NewPooled(s.ldapCfg.Servers, s.cfg, s.metrics)
 	}
 
 	return s
@@ -75,18 +79,28 @@ func (s *LDAPImpl) ReloadConfig() error {
 		return err
 	}
 
-	client := multildap.New(config.Servers, s.cfg)
+	client := multildap.NewPooled(config.Servers, s.cfg, s.metrics)
 	if client == nil {
 		return ErrUnableToCreateLDAPClient
 	}
 
+	previous := s.client
 	s.ldapCfg = config
 	s.client = client
 
+	// the connections of the previous client are closed once the requests using it are done
+	if previous != nil {
+		previous.Close()
+	}
+
 	return nil
 }
 
 func (s *LDAPImpl) Client() multildap.IMultiLDAP {
+	// a nil *MultiLDAP would be a non-nil IMultiLDAP for the callers
+	if s.client == nil {
+		return nil
+	}
 	return s.client
 }
 
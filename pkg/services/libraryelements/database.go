@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/search"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/services/store"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
@@ -150,7 +151,7 @@ func (l *LibraryElementService) createLibraryElement(c context.Context, signedIn
 			}
 			return err
 		}
-		return nil
+		return l.insertEntityEvent(session, element.OrgID, element.UID, store.EntityEventTypeCreate)
 	})
 
 	dto := model.LibraryElementDTO{
@@ -222,11 +223,24 @@ func (l *LibraryElementService) deleteLibraryElement(c context.Context, signedIn
 		}
 
 		elementID = element.ID
-		return nil
+		return l.insertEntityEvent(session, signedInUser.OrgID, element.UID, store.EntityEventTypeDelete)
 	})
 	return elementID, err
 }
 
+// insertEntityEvent records the change of a library element for the search index.
+func (l *LibraryElementService) insertEntityEvent(session *db.Session, orgID int64, uid string, eventType store.EntityEventType) error {
+	if l.features == nil || !l.features.IsEnabled(featuremgmt.FlagPanelTitleSearch) {
+		return nil
+	}
+	_, err := session.Insert(&store.EntityEvent{
+		EventType: eventType,
+		EntityId:  store.CreateDatabaseEntityId(uid, orgID, store.EntityTypeLibraryPanel),
+		Created:   time.Now().Unix(),
+	})
+	return err
+}
+
 // getLibraryElements gets a Library Element where param == value
 func getLibraryElements(c context.Context, store db.DB, cfg *setting.Cfg, signedInUser *user.SignedInUser, params []Pair, features featuremgmt.FeatureToggles) ([]model.LibraryElementDTO, error) {
 	libraryElements := make([]model.LibraryElementWithMeta, 0)
@@ -548,6 +562,14 @@ func (l *LibraryElementService) patchLibraryElement(c context.Context, signedInU
 		} else if rowsAffected != 1 {
 			return model.ErrLibraryElementNotFound
 		}
+		if updateUID != uid {
+			if err := l.insertEntityEvent(session, signedInUser.OrgID, uid, store.EntityEventTypeDelete); err != nil {
+				return err
+			}
+		}
+		if err := l.insertEntityEvent(session, signedInUser.OrgID, updateUID, store.EntityEventTypeUpdate); err != nil {
+			return err
+		}
 
 		dto = model.LibraryElementDTO{
 			ID:          libraryElement.ID,
@@ -772,9 +794,10 @@ func (l *LibraryElementService) deleteLibraryElementsInFolderUID(c context.Conte
 		}
 
 		var elementIDs []struct {
-			ID int64 `xorm:"id"`
+			ID  int64  `xorm:"id"`
+			UID string `xorm:"uid"`
 		}
-		err = session.SQL("SELECT id from library_element WHERE folder_id=? AND org_id=?", folderID, signedInUser.OrgID).Find(&elementIDs)
+		err = session.SQL("SELECT id, uid from library_element WHERE folder_id=? AND org_id=?", folderID, signedInUser.OrgID).Find(&elementIDs)
 		if err != nil {
 			return err
 		}
@@ -783,6 +806,9 @@ func (l *LibraryElementService) deleteLibraryElementsInFolderUID(c context.Conte
 			if err != nil {
 				return err
 			}
+			if err := l.insertEntityEvent(session, signedInUser.OrgID, elementID.UID, store.EntityEventTypeDelete); err != nil {
+				return err
+			}
 		}
 		if _, err := session.Exec("DELETE FROM library_element WHERE folder_id=? AND org_id=?", folderID, signedInUser.OrgID); err != nil {
 			return err
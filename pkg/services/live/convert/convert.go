@@ -5,12 +5,15 @@ import (
 	"fmt"
 
 	"github.com/grafana/grafana/pkg/services/live/telemetry"
+	"github.com/grafana/grafana/pkg/services/live/telemetry/otlp"
 	"github.com/grafana/grafana/pkg/services/live/telemetry/telegraf"
 )
 
 type Converter struct {
 	telegrafConverterWide         *telegraf.Converter
 	telegrafConverterLabelsColumn *telegraf.Converter
+	otlpConverterWide             *otlp.Converter
+	otlpConverterLabelsColumn     *otlp.Converter
 }
 
 func NewConverter() *Converter {
@@ -22,6 +25,10 @@ func NewConverter() *Converter {
 			telegraf.WithUseLabelsColumn(true),
 			telegraf.WithFloat64Numbers(true),
 		),
+		otlpConverterWide: otlp.NewConverter(),
+		otlpConverterLabelsColumn: otlp.NewConverter(
+			otlp.WithUseLabelsColumn(true),
+		),
 	}
 }
 
@@ -44,3 +51,23 @@ func (c *Converter) Convert(data []byte, frameFormat string) ([]telemetry.FrameW
 	}
 	return metricFrames, nil
 }
+
+// ConvertOTLP converts an OTLP/HTTP metrics export request, encoded as protobuf or JSON.
+// The encoding is detected from the payload when it's unknown.
+func (c *Converter) ConvertOTLP(data []byte, frameFormat string, encoding otlp.Encoding) ([]telemetry.FrameWrapper, error) {
+	var converter *otlp.Converter
+	switch frameFormat {
+	case "wide":
+		converter = c.otlpConverterWide
+	case "labels_column":
+		converter = c.otlpConverterLabelsColumn
+	default:
+		return nil, ErrUnsupportedFrameFormat
+	}
+
+	metricFrames, err := converter.ConvertEncoded(data, encoding)
+	if err != nil {
+		return nil, fmt.Errorf("error converting metrics: %w", err)
+	}
+	return metricFrames, nil
+}
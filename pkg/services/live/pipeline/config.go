@@ -39,6 +39,7 @@ type ConverterConfig struct {
 	AutoJsonConverterConfig   *AutoJsonConverterConfig   `json:"jsonAuto,omitempty"`
 	ExactJsonConverterConfig  *ExactJsonConverterConfig  `json:"jsonExact,omitempty"`
 	AutoInfluxConverterConfig *AutoInfluxConverterConfig `json:"influxAuto,omitempty"`
+	AutoOTLPConverterConfig   *AutoOTLPConverterConfig   `json:"otlpAuto,omitempty"`
 	JsonFrameConverterConfig  *JsonFrameConverterConfig  `json:"jsonFrame,omitempty"`
 }
 
@@ -150,6 +151,11 @@ type AutoInfluxConverterConfig struct {
 	FrameFormat string `json:"frameFormat"`
 }
 
+// AutoOTLPConverterConfig ...
+type AutoOTLPConverterConfig struct {
+	FrameFormat string `json:"frameFormat"`
+}
+
 type JsonFrameConverterConfig struct{}
 
 type ManagedStreamOutputConfig struct{}
@@ -0,0 +1,27 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoOTLPConverter_Convert(t *testing.T) {
+	body := []byte(`{"resourceMetrics": [{
+		"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "checkout"}}]},
+		"scopeMetrics": [{"metrics": [
+			{"name": "queue.size", "gauge": {"dataPoints": [{"timeUnixNano": "1672574400000000000", "asInt": "3"}]}},
+			{"name": "queue.latency", "gauge": {"dataPoints": [{"timeUnixNano": "1672574400000000000", "asDouble": 1.5}]}}
+		]}]
+	}]}`)
+
+	converter := NewAutoOTLPConverter(AutoOTLPConverterConfig{})
+	channelFrames, err := converter.Convert(context.Background(), Vars{Channel: "stream/otel/checkout"}, body)
+	require.NoError(t, err)
+	require.Len(t, channelFrames, 2)
+	require.Equal(t, "stream/otel/checkout/queue.size", channelFrames[0].Channel)
+	require.Equal(t, "stream/otel/checkout/queue.latency", channelFrames[1].Channel)
+	require.Equal(t, "labels", channelFrames[0].Frame.Fields[0].Name)
+	require.Equal(t, "service.name=checkout", channelFrames[0].Frame.Fields[0].At(0))
+}
@@ -1,10 +1,13 @@
 package pushhttp
 
 import (
+	"compress/gzip"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	liveDto "github.com/grafana/grafana-plugin-sdk-go/live"
 
@@ -13,6 +16,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/live"
 	"github.com/grafana/grafana/pkg/services/live/convert"
 	"github.com/grafana/grafana/pkg/services/live/pushurl"
+	"github.com/grafana/grafana/pkg/services/live/telemetry/otlp"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -98,6 +102,120 @@ func (g *Gateway) Handle(ctx *contextmodel.ReqContext) {
 	ctx.Resp.WriteHeader(http.StatusOK)
 }
 
+// HandleOTLPMetrics receives OTLP/HTTP metrics export requests. OpenTelemetry exporters
+// append /v1/metrics to the configured endpoint, so the endpoint to configure is the
+// same as for the Influx line protocol push.
+func (g *Gateway) HandleOTLPMetrics(ctx *contextmodel.ReqContext) {
+	streamID := web.Params(ctx.Req)[":streamId"]
+
+	stream, err := g.GrafanaLive.ManagedStreamRunner.GetOrCreateStream(ctx.SignedInUser.OrgID, liveDto.ScopeStream, streamID)
+	if err != nil {
+		logger.Error("Error getting stream", "error", err)
+		ctx.Resp.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	urlValues := ctx.Req.URL.Query()
+	frameFormat := pushurl.FrameFormatFromValues(urlValues)
+
+	encoding, ok := otlp.EncodingFromContentType(ctx.Req.Header.Get("Content-Type"))
+	if !ok {
+		logger.Debug("Unsupported OTLP content type", "contentType", ctx.Req.Header.Get("Content-Type"))
+		ctx.Resp.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := readOTLPBody(ctx.Resp, ctx.Req, g.Cfg.LiveMaxOTLPBodyBytes)
+	if err != nil {
+		logger.Error("Error reading body", "error", err)
+		if errors.Is(err, errUnsupportedContentEncoding) {
+			ctx.Resp.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		if errors.Is(err, errBodyTooLarge) {
+			ctx.Resp.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		// Malformed payloads must not be retried by the exporter
+		ctx.Resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	logger.Debug("Live OTLP push request",
+		"protocol", "http",
+		"streamId", streamID,
+		"bodyLength", len(body),
+		"frameFormat", frameFormat,
+	)
+
+	metricFrames, err := g.converter.ConvertOTLP(body, frameFormat, encoding)
+	if err != nil {
+		logger.Error("Error converting OTLP metrics", "error", err, "frameFormat", frameFormat)
+		// Malformed payloads must not be retried by the exporter
+		ctx.Resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	for _, mf := range metricFrames {
+		err := stream.Push(ctx.Req.Context(), mf.Key(), mf.Frame())
+		if err != nil {
+			logger.Error("Error pushing frame", "error", err, "key", mf.Key())
+			ctx.Resp.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// The response is an empty ExportMetricsServiceResponse in the encoding of the request
+	if encoding == otlp.EncodingJSON {
+		ctx.Resp.Header().Set("Content-Type", "application/json")
+		ctx.Resp.WriteHeader(http.StatusOK)
+		_, _ = ctx.Resp.Write([]byte("{}"))
+		return
+	}
+	ctx.Resp.Header().Set("Content-Type", "application/x-protobuf")
+	ctx.Resp.WriteHeader(http.StatusOK)
+}
+
+var (
+	errUnsupportedContentEncoding = errors.New("unsupported content encoding")
+	errBodyTooLarge               = errors.New("body too large")
+)
+
+// readOTLPBody reads the body of an OTLP/HTTP request, exporters may compress it with gzip.
+// Bodies larger than maxSize, compressed or not, are rejected with errBodyTooLarge.
+func readOTLPBody(w http.ResponseWriter, req *http.Request, maxSize int64) ([]byte, error) {
+	var reader io.Reader = http.MaxBytesReader(w, req.Body, maxSize)
+	switch encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+	case "gzip":
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, readOTLPError(err)
+		}
+		defer func() { _ = gzipReader.Close() }()
+		// the decompressed size is limited too, small bodies can decompress to gigabytes
+		reader = io.LimitReader(gzipReader, maxSize+1)
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedContentEncoding, encoding)
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, readOTLPError(err)
+	}
+	if int64(len(body)) > maxSize {
+		return nil, fmt.Errorf("%w: decompressed body exceeds %d bytes", errBodyTooLarge, maxSize)
+	}
+	return body, nil
+}
+
+func readOTLPError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return fmt.Errorf("%w: %s", errBodyTooLarge, err)
+	}
+	return err
+}
+
 func (g *Gateway) HandlePipelinePush(ctx *contextmodel.ReqContext) {
 	channelID := web.Params(ctx.Req)["*"]
 
@@ -0,0 +1,69 @@
+package pushhttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOTLPBody(t *testing.T) {
+	t.Run("reads uncompressed bodies", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/v1/metrics", strings.NewReader("{}"))
+		body, err := readOTLPBody(httptest.NewRecorder(), req, 1024)
+		require.NoError(t, err)
+		require.Equal(t, "{}", string(body))
+	})
+
+	t.Run("decompresses gzip bodies", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write([]byte("{}"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		req := httptest.NewRequest("POST", "/v1/metrics", &buf)
+		req.Header.Set("Content-Encoding", "gzip")
+		body, err := readOTLPBody(httptest.NewRecorder(), req, 1024)
+		require.NoError(t, err)
+		require.Equal(t, "{}", string(body))
+	})
+
+	t.Run("rejects invalid gzip bodies", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/v1/metrics", strings.NewReader("{}"))
+		req.Header.Set("Content-Encoding", "gzip")
+		_, err := readOTLPBody(httptest.NewRecorder(), req, 1024)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, errUnsupportedContentEncoding)
+	})
+
+	t.Run("rejects large bodies", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/v1/metrics", strings.NewReader(strings.Repeat("a", 1025)))
+		_, err := readOTLPBody(httptest.NewRecorder(), req, 1024)
+		require.ErrorIs(t, err, errBodyTooLarge)
+	})
+
+	t.Run("rejects gzip bodies decompressing beyond the limit", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write(make([]byte, 1<<20))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		require.Less(t, buf.Len(), 4096)
+
+		req := httptest.NewRequest("POST", "/v1/metrics", &buf)
+		req.Header.Set("Content-Encoding", "gzip")
+		_, err = readOTLPBody(httptest.NewRecorder(), req, 4096)
+		require.ErrorIs(t, err, errBodyTooLarge)
+	})
+
+	t.Run("rejects other encodings", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/v1/metrics", strings.NewReader("{}"))
+		req.Header.Set("Content-Encoding", "br")
+		_, err := readOTLPBody(httptest.NewRecorder(), req, 1024)
+		require.ErrorIs(t, err, errUnsupportedContentEncoding)
+	})
+}
@@ -0,0 +1,401 @@
+package otlp
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	metricsv1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/grafana/grafana/pkg/services/live/telemetry"
+)
+
+var _ telemetry.Converter = (*Converter)(nil)
+
+// Converter converts OTLP metrics to Grafana frames.
+type Converter struct {
+	useLabelsColumn bool
+}
+
+// ConverterOption ...
+type ConverterOption func(*Converter)
+
+// WithUseLabelsColumn ...
+func WithUseLabelsColumn(enabled bool) ConverterOption {
+	return func(h *Converter) {
+		h.useLabelsColumn = enabled
+	}
+}
+
+// NewConverter creates new Converter from OTLP/HTTP metrics export requests to Grafana Data Frames.
+// This converter generates frames for each input metric name. Resource and data point attributes
+// become frame labels.
+func NewConverter(opts ...ConverterOption) *Converter {
+	c := &Converter{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Encoding is the encoding of an OTLP/HTTP payload.
+type Encoding int
+
+const (
+	// EncodingUnknown detects the encoding from the payload, for the inputs without content type.
+	EncodingUnknown Encoding = iota
+	EncodingProtobuf
+	EncodingJSON
+)
+
+// EncodingFromContentType returns the encoding of an OTLP/HTTP request from its Content-Type
+// header, false when the content type isn't one of the OTLP encodings.
+func EncodingFromContentType(contentType string) (Encoding, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return EncodingUnknown, false
+	}
+	switch mediaType {
+	case "application/x-protobuf":
+		return EncodingProtobuf, true
+	case "application/json":
+		return EncodingJSON, true
+	default:
+		return EncodingUnknown, false
+	}
+}
+
+// Unmarshal decodes an OTLP metrics export request, encoded either as protobuf or as JSON.
+// The encoding is detected from the payload when it's unknown.
+func Unmarshal(body []byte, encoding Encoding) (*collectormetrics.ExportMetricsServiceRequest, error) {
+	if encoding == EncodingUnknown {
+		encoding = EncodingProtobuf
+		if IsJSON(body) {
+			encoding = EncodingJSON
+		}
+	}
+
+	req := &collectormetrics.ExportMetricsServiceRequest{}
+	var err error
+	if encoding == EncodingJSON {
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, req)
+	} else {
+		err = proto.Unmarshal(body, req)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error decoding OTLP metrics: %w", err)
+	}
+	return req, nil
+}
+
+// IsJSON reports whether body looks like an OTLP/JSON payload. A protobuf export
+// request starts with the tag of its first field and never with '{'.
+func IsJSON(body []byte) bool {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// Convert metrics, the encoding is detected from the payload.
+func (c *Converter) Convert(body []byte) ([]telemetry.FrameWrapper, error) {
+	return c.ConvertEncoded(body, EncodingUnknown)
+}
+
+// ConvertEncoded converts metrics of a known encoding.
+func (c *Converter) ConvertEncoded(body []byte, encoding Encoding) ([]telemetry.FrameWrapper, error) {
+	req, err := Unmarshal(body, encoding)
+	if err != nil {
+		return nil, err
+	}
+	metrics := collectSamples(req)
+	if !c.useLabelsColumn {
+		return convertWideFields(metrics), nil
+	}
+	return convertWithLabelsColumn(metrics), nil
+}
+
+// sample is a single value of a metric series. Histograms and summaries produce
+// several samples per data point, named following the Prometheus conventions.
+type sample struct {
+	field  string
+	labels data.Labels
+	time   time.Time
+	value  float64
+}
+
+type metricSamples struct {
+	key     string
+	samples []sample
+}
+
+var invalidKeyChars = regexp.MustCompile(`[^A-Za-z0-9_\-.]`)
+
+// metricKey makes a metric name usable as a channel path segment.
+func metricKey(name string) string {
+	return invalidKeyChars.ReplaceAllString(name, "_")
+}
+
+func collectSamples(req *collectormetrics.ExportMetricsServiceRequest) []*metricSamples {
+	// maintain the order of metrics as they appear in input.
+	var result []*metricSamples
+	byKey := map[string]*metricSamples{}
+
+	for _, rm := range req.GetResourceMetrics() {
+		resourceLabels := attributesToLabels(nil, rm.GetResource().GetAttributes())
+		for _, sm := range rm.GetScopeMetrics() {
+			for _, m := range sm.GetMetrics() {
+				key := metricKey(m.GetName())
+				if key == "" {
+					continue
+				}
+				ms, ok := byKey[key]
+				if !ok {
+					ms = &metricSamples{key: key}
+					byKey[key] = ms
+					result = append(result, ms)
+				}
+				ms.samples = append(ms.samples, metricToSamples(key, resourceLabels, m)...)
+			}
+		}
+	}
+	return result
+}
+
+func metricToSamples(name string, resourceLabels data.Labels, m *metricsv1.Metric) []sample {
+	var samples []sample
+	switch {
+	case m.GetGauge() != nil:
+		for _, dp := range m.GetGauge().GetDataPoints() {
+			samples = append(samples, numberSample(name, resourceLabels, dp))
+		}
+	case m.GetSum() != nil:
+		for _, dp := range m.GetSum().GetDataPoints() {
+			samples = append(samples, numberSample(name, resourceLabels, dp))
+		}
+	case m.GetHistogram() != nil:
+		for _, dp := range m.GetHistogram().GetDataPoints() {
+			labels := attributesToLabels(resourceLabels, dp.GetAttributes())
+			ts := unixNano(dp.GetTimeUnixNano())
+			samples = append(samples,
+				sample{field: name + "_count", labels: labels, time: ts, value: float64(dp.GetCount())},
+				sample{field: name + "_sum", labels: labels, time: ts, value: dp.GetSum()},
+			)
+			// Buckets are cumulative, like Prometheus histograms
+			var cumulative uint64
+			bounds := dp.GetExplicitBounds()
+			for i, count := range dp.GetBucketCounts() {
+				cumulative += count
+				le := "+Inf"
+				if i < len(bounds) {
+					le = strconv.FormatFloat(bounds[i], 'g', -1, 64)
+				}
+				samples = append(samples, sample{field: name + "_bucket", labels: withLabel(labels, "le", le), time: ts, value: float64(cumulative)})
+			}
+		}
+	case m.GetExponentialHistogram() != nil:
+		for _, dp := range m.GetExponentialHistogram().GetDataPoints() {
+			labels := attributesToLabels(resourceLabels, dp.GetAttributes())
+			ts := unixNano(dp.GetTimeUnixNano())
+			samples = append(samples,
+				sample{field: name + "_count", labels: labels, time: ts, value: float64(dp.GetCount())},
+				sample{field: name + "_sum", labels: labels, time: ts, value: dp.GetSum()},
+			)
+		}
+	case m.GetSummary() != nil:
+		for _, dp := range m.GetSummary().GetDataPoints() {
+			labels := attributesToLabels(resourceLabels, dp.GetAttributes())
+			ts := unixNano(dp.GetTimeUnixNano())
+			samples = append(samples,
+				sample{field: name + "_count", labels: labels, time: ts, value: float64(dp.GetCount())},
+				sample{field: name + "_sum", labels: labels, time: ts, value: dp.GetSum()},
+			)
+			for _, q := range dp.GetQuantileValues() {
+				quantile := strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64)
+				samples = append(samples, sample{field: name, labels: withLabel(labels, "quantile", quantile), time: ts, value: q.GetValue()})
+			}
+		}
+	}
+	return samples
+}
+
+func numberSample(name string, resourceLabels data.Labels, dp *metricsv1.NumberDataPoint) sample {
+	var value float64
+	switch v := dp.GetValue().(type) {
+	case *metricsv1.NumberDataPoint_AsInt:
+		value = float64(v.AsInt)
+	case *metricsv1.NumberDataPoint_AsDouble:
+		value = v.AsDouble
+	}
+	return sample{
+		field:  name,
+		labels: attributesToLabels(resourceLabels, dp.GetAttributes()),
+		time:   unixNano(dp.GetTimeUnixNano()),
+		value:  value,
+	}
+}
+
+func unixNano(ns uint64) time.Time {
+	if ns == 0 {
+		return time.Now()
+	}
+	return time.Unix(0, int64(ns))
+}
+
+// attributesToLabels returns base labels extended with attributes. Attributes take
+// precedence, so data point attributes override resource attributes with the same key.
+func attributesToLabels(base data.Labels, attributes []*commonv1.KeyValue) data.Labels {
+	labels := data.Labels{}
+	for k, v := range base {
+		labels[k] = v
+	}
+	for _, kv := range attributes {
+		labels[kv.GetKey()] = anyValueToString(kv.GetValue())
+	}
+	return labels
+}
+
+func withLabel(labels data.Labels, key, value string) data.Labels {
+	l := labels.Copy()
+	l[key] = value
+	return l
+}
+
+func anyValueToString(v *commonv1.AnyValue) string {
+	switch value := v.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		return value.StringValue
+	case *commonv1.AnyValue_BoolValue:
+		return strconv.FormatBool(value.BoolValue)
+	case *commonv1.AnyValue_IntValue:
+		return strconv.FormatInt(value.IntValue, 10)
+	case *commonv1.AnyValue_DoubleValue:
+		return strconv.FormatFloat(value.DoubleValue, 'g', -1, 64)
+	case *commonv1.AnyValue_BytesValue:
+		return fmt.Sprintf("%x", value.BytesValue)
+	case *commonv1.AnyValue_ArrayValue:
+		values := make([]string, 0, len(value.ArrayValue.GetValues()))
+		for _, item := range value.ArrayValue.GetValues() {
+			values = append(values, anyValueToString(item))
+		}
+		return "[" + strings.Join(values, ",") + "]"
+	case *commonv1.AnyValue_KvlistValue:
+		values := make([]string, 0, len(value.KvlistValue.GetValues()))
+		for _, kv := range value.KvlistValue.GetValues() {
+			values = append(values, kv.GetKey()+"="+anyValueToString(kv.GetValue()))
+		}
+		return "{" + strings.Join(values, ",") + "}"
+	default:
+		return ""
+	}
+}
+
+type metricFrame struct {
+	key   string
+	frame *data.Frame
+}
+
+// Key returns a key which describes Frame metrics.
+func (s *metricFrame) Key() string {
+	return s.key
+}
+
+// Frame returns the converted data.Frame.
+func (s *metricFrame) Frame() *data.Frame {
+	return s.frame
+}
+
+// convertWideFields returns a frame for each metric name and time combination, with
+// a field for each series.
+func convertWideFields(metrics []*metricSamples) []telemetry.FrameWrapper {
+	frameWrappers := make([]telemetry.FrameWrapper, 0, len(metrics))
+	for _, m := range metrics {
+		// maintain the order of times as they appear in input.
+		var times []time.Time
+		byTime := map[time.Time][]sample{}
+		for _, s := range m.samples {
+			t := s.time.UTC()
+			if _, ok := byTime[t]; !ok {
+				times = append(times, t)
+			}
+			byTime[t] = append(byTime[t], s)
+		}
+
+		for _, t := range times {
+			samples := byTime[t]
+			sort.SliceStable(samples, func(i, j int) bool {
+				if samples[i].field != samples[j].field {
+					return samples[i].field < samples[j].field
+				}
+				return samples[i].labels.String() < samples[j].labels.String()
+			})
+
+			fields := []*data.Field{data.NewField("time", nil, []time.Time{t})}
+			seen := map[string]*data.Field{}
+			for _, s := range samples {
+				value := s.value
+				seriesKey := s.field + s.labels.String()
+				if field, ok := seen[seriesKey]; ok {
+					field.Set(0, &value)
+					continue
+				}
+				field := data.NewField(s.field, s.labels, []*float64{&value})
+				seen[seriesKey] = field
+				fields = append(fields, field)
+			}
+			frameWrappers = append(frameWrappers, &metricFrame{key: m.key, frame: data.NewFrame(m.key, fields...)})
+		}
+	}
+	return frameWrappers
+}
+
+// convertWithLabelsColumn returns a frame for each metric name, with a row for each
+// series and time combination. Labels are kept as a string column.
+func convertWithLabelsColumn(metrics []*metricSamples) []telemetry.FrameWrapper {
+	frameWrappers := make([]telemetry.FrameWrapper, 0, len(metrics))
+	for _, m := range metrics {
+		labelsField := data.NewField("labels", nil, []string{})
+		timeField := data.NewField("time", nil, []time.Time{})
+		var valueFields []*data.Field
+		fieldIndex := map[string]int{}
+		rowIndex := map[string]int{}
+
+		for _, s := range m.samples {
+			labels := s.labels.String()
+			rowKey := labels + "|" + strconv.FormatInt(s.time.UnixNano(), 10)
+			row, ok := rowIndex[rowKey]
+			if !ok {
+				row = labelsField.Len()
+				rowIndex[rowKey] = row
+				labelsField.Append(labels)
+				timeField.Append(s.time.UTC())
+				for _, f := range valueFields {
+					f.Append(nil)
+				}
+			}
+
+			idx, ok := fieldIndex[s.field]
+			if !ok {
+				// Fields appearing later are filled with nulls up to the current row.
+				f := data.NewField(s.field, nil, make([]*float64, labelsField.Len()))
+				idx = len(valueFields)
+				fieldIndex[s.field] = idx
+				valueFields = append(valueFields, f)
+			}
+			value := s.value
+			valueFields[idx].Set(row, &value)
+		}
+
+		fields := append([]*data.Field{labelsField, timeField}, valueFields...)
+		frameWrappers = append(frameWrappers, &metricFrame{key: m.key, frame: data.NewFrame(m.key, fields...)})
+	}
+	return frameWrappers
+}
@@ -0,0 +1,132 @@
+package otlp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func loadTestData(tb testing.TB, file string) []byte {
+	tb.Helper()
+	// Safe to disable, this is a test.
+	// nolint:gosec
+	content, err := os.ReadFile(filepath.Join("testdata", file))
+	require.NoError(tb, err, "expected to be able to read file")
+	require.True(tb, len(content) > 0)
+	return content
+}
+
+func framesByKey(t *testing.T, c *Converter, body []byte) map[string][]*data.Frame {
+	t.Helper()
+	frameWrappers, err := c.Convert(body)
+	require.NoError(t, err)
+
+	frames := map[string][]*data.Frame{}
+	for _, fw := range frameWrappers {
+		_, err := data.FrameToJSON(fw.Frame(), data.IncludeAll)
+		require.NoError(t, err)
+		frames[fw.Key()] = append(frames[fw.Key()], fw.Frame())
+	}
+	return frames
+}
+
+func TestConverter_Convert_Wide(t *testing.T) {
+	frames := framesByKey(t, NewConverter(), loadTestData(t, "metrics.json"))
+	require.Len(t, frames, 3)
+	ts := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cpu := frames["process.cpu.utilization"]
+	require.Len(t, cpu, 1)
+	require.Len(t, cpu[0].Fields, 3)
+	require.Equal(t, ts, cpu[0].Fields[0].At(0))
+	require.Equal(t, data.Labels{"service.name": "checkout", "host.cores": "8", "state": "system"}, cpu[0].Fields[1].Labels)
+	require.Equal(t, 0.1, *cpu[0].Fields[1].At(0).(*float64))
+	require.Equal(t, data.Labels{"service.name": "checkout", "host.cores": "8", "state": "user"}, cpu[0].Fields[2].Labels)
+
+	requests := frames["http.server.requests"]
+	require.Len(t, requests, 1)
+	// Data point attributes override resource attributes
+	require.Equal(t, "checkout-api", requests[0].Fields[1].Labels["service.name"])
+	require.Equal(t, "200", requests[0].Fields[1].Labels["http.status_code"])
+	require.Equal(t, 42.0, *requests[0].Fields[1].At(0).(*float64))
+
+	duration := frames["http.server.duration"]
+	require.Len(t, duration, 1)
+	buckets := map[string]float64{}
+	for _, f := range duration[0].Fields[1:] {
+		if f.Name == "http.server.duration_bucket" {
+			buckets[f.Labels["le"]] = *f.At(0).(*float64)
+		}
+	}
+	require.Equal(t, map[string]float64{"10": 1, "100": 4, "+Inf": 6}, buckets)
+}
+
+func TestConverter_Convert_LabelsColumn(t *testing.T) {
+	frames := framesByKey(t, NewConverter(WithUseLabelsColumn(true)), loadTestData(t, "metrics.json"))
+	require.Len(t, frames, 3)
+
+	cpu := frames["process.cpu.utilization"]
+	require.Len(t, cpu, 1)
+	require.Len(t, cpu[0].Fields, 3)
+	require.Equal(t, "labels", cpu[0].Fields[0].Name)
+	require.Equal(t, 2, cpu[0].Rows())
+	require.Equal(t, `host.cores=8, service.name=checkout, state=user`, cpu[0].Fields[0].At(0))
+
+	// Histogram buckets are separate rows, with nulls in the count and sum columns
+	duration := frames["http.server.duration"]
+	require.Len(t, duration, 1)
+	require.Equal(t, 4, duration[0].Rows())
+	require.Len(t, duration[0].Fields, 5)
+	require.Nil(t, duration[0].Fields[2].At(1))
+}
+
+func TestConverter_Convert_Protobuf(t *testing.T) {
+	req, err := Unmarshal(loadTestData(t, "metrics.json"), EncodingJSON)
+	require.NoError(t, err)
+	body, err := proto.Marshal(req)
+	require.NoError(t, err)
+	require.False(t, IsJSON(body))
+
+	frames := framesByKey(t, NewConverter(), body)
+	require.Len(t, frames, 3)
+	require.Len(t, frames["process.cpu.utilization"][0].Fields, 3)
+
+	frameWrappers, err := NewConverter().ConvertEncoded(body, EncodingProtobuf)
+	require.NoError(t, err)
+	require.Len(t, frameWrappers, 3)
+
+	_, err = NewConverter().ConvertEncoded(body, EncodingJSON)
+	require.Error(t, err)
+}
+
+func TestEncodingFromContentType(t *testing.T) {
+	for contentType, expected := range map[string]Encoding{
+		"application/x-protobuf":          EncodingProtobuf,
+		"application/json":                EncodingJSON,
+		"application/json; charset=utf-8": EncodingJSON,
+	} {
+		encoding, ok := EncodingFromContentType(contentType)
+		require.True(t, ok, contentType)
+		require.Equal(t, expected, encoding, contentType)
+	}
+
+	for _, contentType := range []string{"", "text/plain", "application/jsonx"} {
+		_, ok := EncodingFromContentType(contentType)
+		require.False(t, ok, contentType)
+	}
+}
+
+func TestConverter_Convert_Invalid(t *testing.T) {
+	_, err := NewConverter().Convert([]byte(`{"resourceMetrics": 1}`))
+	require.Error(t, err)
+}
+
+func TestMetricKey(t *testing.T) {
+	require.Equal(t, "http.server.duration", metricKey("http.server.duration"))
+	require.Equal(t, "queue_size_bytes_", metricKey("queue size/bytes?"))
+}
@@ -2,16 +2,28 @@ package loginattempt
 
 import (
 	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrInvalidBlock = errutil.NewBase(errutil.StatusBadRequest, "login-attempt.invalid-block", errutil.WithPublicMessage("Either username or ip is required"))
 )
 
 type Service interface {
 	// Add adds a new login attempt record for provided username
 	Add(ctx context.Context, username, IPAddress string) error
-	// Validate checks if username has to many login attempts inside a window.
-	// Will return true if provided username do not have too many attempts.
-	Validate(ctx context.Context, username string) (bool, error)
+	// Validate checks if the username or the IP address have too many failed login attempts inside a window.
+	// Once the limits are reached, the delay between login attempts doubles on each failed attempt.
+	// Will return true if the login attempt is allowed.
+	Validate(ctx context.Context, username, IPAddress string) (bool, error)
 	// Reset resets all login attempts attached to username
 	Reset(ctx context.Context, username string) error
+	// GetBlocks returns the usernames and the IP addresses which reached the limits of failed login attempts
+	GetBlocks(ctx context.Context) ([]*Block, error)
+	// ClearBlock resets the login attempts of a username or an IP address
+	ClearBlock(ctx context.Context, cmd *ClearBlockCommand) error
 }
 
 type LoginAttempt struct {
@@ -20,3 +32,25 @@ type LoginAttempt struct {
 	IpAddress string
 	Created   int64
 }
+
+type BlockType string
+
+const (
+	BlockTypeUsername  BlockType = "username"
+	BlockTypeIPAddress BlockType = "ip"
+)
+
+// Block is a username or an IP address which reached the limit of failed login attempts
+type Block struct {
+	Type        BlockType `json:"type"`
+	Value       string    `json:"value"`
+	Attempts    int64     `json:"attempts"`
+	LastAttempt time.Time `json:"lastAttempt"`
+	// DelayedUntil is the time of the next allowed login attempt
+	DelayedUntil time.Time `json:"delayedUntil"`
+}
+
+type ClearBlockCommand struct {
+	Username  string
+	IPAddress string
+}
@@ -0,0 +1,40 @@
+package loginattemptimpl
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+
+	routeRegister.Group("/api/admin/login-blocks", func(blocksRoute routing.RouteRegister) {
+		blocksRoute.Get("/", authorize(ac.EvalPermission(ActionBlocksRead)), routing.Wrap(s.getBlocksHandler))
+		blocksRoute.Delete("/", authorize(ac.EvalPermission(ActionBlocksDelete)), routing.Wrap(s.clearBlockHandler))
+	}, middleware.ReqSignedIn)
+}
+
+func (s *Service) getBlocksHandler(c *contextmodel.ReqContext) response.Response {
+	blocks, err := s.GetBlocks(c.Req.Context())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get login blocks", err)
+	}
+	return response.JSON(http.StatusOK, blocks)
+}
+
+func (s *Service) clearBlockHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := loginattempt.ClearBlockCommand{
+		Username:  c.Query("username"),
+		IPAddress: c.Query("ip"),
+	}
+	if err := s.ClearBlock(c.Req.Context(), &cmd); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to clear login block", err)
+	}
+	return response.Success("Login block cleared")
+}
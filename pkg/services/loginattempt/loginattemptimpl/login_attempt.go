@@ -2,33 +2,91 @@ package loginattemptimpl
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/serverlock"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 const (
-	maxInvalidLoginAttempts int64 = 5
-	loginAttemptsWindow           = time.Minute * 5
+	defaultMaxAttempts   int64 = 5
+	defaultIPMaxAttempts int64 = 50
+	defaultDelay               = time.Minute
+	defaultMaxDelay            = time.Hour
+	defaultWindow              = 24 * time.Hour
+	cleanupInterval            = time.Minute * 10
 )
 
-func ProvideService(db db.DB, cfg *setting.Cfg, lock *serverlock.ServerLockService) *Service {
-	return &Service{
-		&xormStore{db: db, now: time.Now},
-		cfg,
-		lock,
-		log.New("login_attempt"),
+var _ loginattempt.Service = (*Service)(nil)
+
+func ProvideService(
+	db db.DB,
+	cfg *setting.Cfg,
+	lock *serverlock.ServerLockService,
+	routeRegister routing.RouteRegister,
+	accessControl ac.AccessControl,
+	accesscontrolService ac.Service,
+) (*Service, error) {
+	section := cfg.SectionWithEnvOverrides("security")
+	s := &Service{
+		store:         &xormStore{db: db, now: time.Now},
+		cfg:           cfg,
+		lock:          lock,
+		accessControl: accessControl,
+		logger:        log.New("login_attempt"),
+		now:           time.Now,
+		maxAttempts:   section.Key("brute_force_login_protection_max_attempts").MustInt64(defaultMaxAttempts),
+		ipMaxAttempts: section.Key("brute_force_login_protection_ip_max_attempts").MustInt64(defaultIPMaxAttempts),
+		delay:         section.Key("brute_force_login_protection_delay").MustDuration(defaultDelay),
+		maxDelay:      section.Key("brute_force_login_protection_max_delay").MustDuration(defaultMaxDelay),
+		window:        section.Key("brute_force_login_protection_window").MustDuration(defaultWindow),
+	}
+
+	var err error
+	if s.allowedNetworks, err = parseCIDRs(section.Key("brute_force_login_protection_allowed_cidrs").String()); err != nil {
+		return nil, fmt.Errorf("invalid security brute_force_login_protection_allowed_cidrs: %w", err)
+	}
+	if s.deniedNetworks, err = parseCIDRs(section.Key("brute_force_login_protection_denied_cidrs").String()); err != nil {
+		return nil, fmt.Errorf("invalid security brute_force_login_protection_denied_cidrs: %w", err)
 	}
+
+	if err := declareFixedRoles(accesscontrolService); err != nil {
+		return nil, err
+	}
+	s.registerAPIEndpoints(routeRegister)
+
+	return s, nil
 }
 
 type Service struct {
-	store  store
-	cfg    *setting.Cfg
-	lock   *serverlock.ServerLockService
-	logger log.Logger
+	store         store
+	cfg           *setting.Cfg
+	lock          *serverlock.ServerLockService
+	accessControl ac.AccessControl
+	logger        log.Logger
+	now           func() time.Time
+
+	// maxAttempts and ipMaxAttempts are the failed login attempts allowed inside the window,
+	// for a username and for an IP address, before logins are delayed
+	maxAttempts   int64
+	ipMaxAttempts int64
+	// delay is doubled on each failed attempt after the limit, up to maxDelay
+	delay    time.Duration
+	maxDelay time.Duration
+	window   time.Duration
+
+	allowedNetworks []*net.IPNet
+	deniedNetworks  []*net.IPNet
 }
 
 func (s *Service) Run(ctx context.Context) error {
@@ -37,7 +95,7 @@ func (s *Service) Run(ctx context.Context) error {
 		return nil
 	}
 
-	ticker := time.NewTicker(time.Minute * 10)
+	ticker := time.NewTicker(cleanupInterval)
 	for {
 		select {
 		case <-ticker.C:
@@ -53,43 +111,144 @@ func (s *Service) Add(ctx context.Context, username, IPAddress string) error {
 		return nil
 	}
 
+	ip := normalizeIP(IPAddress)
+	// failed logins from allowed networks still count for the username, only the
+	// address isn't recorded since the allowed networks are never blocked
+	if ip != "" && containsIP(s.allowedNetworks, ip) {
+		ip = ""
+	}
+
 	_, err := s.store.CreateLoginAttempt(ctx, CreateLoginAttemptCommand{
 		Username:  username,
-		IpAddress: IPAddress,
+		IpAddress: ip,
 	})
 	return err
 }
 
 func (s *Service) Reset(ctx context.Context, username string) error {
-	return s.store.DeleteLoginAttempts(ctx, DeleteLoginAttemptsCommand{username})
+	return s.store.DeleteLoginAttempts(ctx, DeleteLoginAttemptsCommand{Username: username})
 }
 
-func (s *Service) Validate(ctx context.Context, username string) (bool, error) {
+func (s *Service) Validate(ctx context.Context, username, IPAddress string) (bool, error) {
 	if s.cfg.DisableBruteForceLoginProtection {
 		return true, nil
 	}
 
-	loginAttemptCountQuery := GetUserLoginAttemptCountQuery{
-		Username: username,
-		Since:    time.Now().Add(-loginAttemptsWindow),
+	ip := normalizeIP(IPAddress)
+	if ip != "" && containsIP(s.deniedNetworks, ip) {
+		return false, nil
 	}
 
-	count, err := s.store.GetUserLoginAttemptCount(ctx, loginAttemptCountQuery)
+	now := s.now()
+	since := now.Add(-s.window)
+
+	stats, err := s.store.GetLoginAttemptStats(ctx, GetLoginAttemptStatsQuery{Username: username, Since: since})
 	if err != nil {
 		return false, err
 	}
+	if now.Before(s.delayedUntil(stats, s.maxAttempts)) {
+		return false, nil
+	}
+
+	// the allowed networks are only exempt from the limit of the IP addresses, the
+	// usernames are always locked after too many failed attempts
+	if ip == "" || containsIP(s.allowedNetworks, ip) {
+		return true, nil
+	}
 
-	if count >= maxInvalidLoginAttempts {
+	stats, err = s.store.GetLoginAttemptStats(ctx, GetLoginAttemptStatsQuery{IpAddress: ip, Since: since})
+	if err != nil {
+		return false, err
+	}
+	if now.Before(s.delayedUntil(stats, s.ipMaxAttempts)) {
 		return false, nil
 	}
 
 	return true, nil
 }
 
+func (s *Service) GetBlocks(ctx context.Context) ([]*loginattempt.Block, error) {
+	if s.cfg.DisableBruteForceLoginProtection {
+		return []*loginattempt.Block{}, nil
+	}
+
+	since := s.now().Add(-s.window)
+	result := make([]*loginattempt.Block, 0)
+	for _, group := range []struct {
+		blockType   loginattempt.BlockType
+		byIpAddress bool
+		maxAttempts int64
+	}{
+		{loginattempt.BlockTypeUsername, false, s.maxAttempts},
+		{loginattempt.BlockTypeIPAddress, true, s.ipMaxAttempts},
+	} {
+		stats, err := s.store.GetLoginAttemptGroups(ctx, GetLoginAttemptGroupsQuery{
+			ByIpAddress: group.byIpAddress,
+			Since:       since,
+			MinCount:    group.maxAttempts,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, st := range stats {
+			// attempts made before the IP addresses were recorded have none
+			if st.Value == "" {
+				continue
+			}
+			result = append(result, &loginattempt.Block{
+				Type:         group.blockType,
+				Value:        st.Value,
+				Attempts:     st.Count,
+				LastAttempt:  time.Unix(st.Last, 0),
+				DelayedUntil: s.delayedUntil(st, group.maxAttempts),
+			})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].LastAttempt.After(result[j].LastAttempt)
+	})
+	return result, nil
+}
+
+func (s *Service) ClearBlock(ctx context.Context, cmd *loginattempt.ClearBlockCommand) error {
+	switch {
+	case cmd.Username != "":
+		return s.store.DeleteLoginAttempts(ctx, DeleteLoginAttemptsCommand{Username: cmd.Username})
+	case cmd.IPAddress != "":
+		ip := normalizeIP(cmd.IPAddress)
+		if ip == "" {
+			return loginattempt.ErrInvalidBlock.Errorf("invalid IP address %q", cmd.IPAddress)
+		}
+		return s.store.DeleteLoginAttempts(ctx, DeleteLoginAttemptsCommand{IpAddress: ip})
+	default:
+		return loginattempt.ErrInvalidBlock.Errorf("either username or IP address is required")
+	}
+}
+
+// delayedUntil returns the time of the next allowed login attempt. Once the failed attempts
+// reach the limit, the delay after the last attempt doubles on each new failed attempt.
+func (s *Service) delayedUntil(stats LoginAttemptStats, maxAttempts int64) time.Time {
+	if stats.Count < maxAttempts {
+		return time.Time{}
+	}
+
+	delay := s.delay
+	for i := maxAttempts; i < stats.Count && delay < s.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.maxDelay {
+		delay = s.maxDelay
+	}
+
+	return time.Unix(stats.Last, 0).Add(delay)
+}
+
 func (s *Service) cleanup(ctx context.Context) {
-	err := s.lock.LockAndExecute(ctx, "delete old login attempts", time.Minute*10, func(context.Context) {
+	err := s.lock.LockAndExecute(ctx, "delete old login attempts", cleanupInterval, func(context.Context) {
 		cmd := DeleteOldLoginAttemptsCommand{
-			OlderThan: time.Now().Add(time.Minute * -10),
+			OlderThan: s.now().Add(-s.window),
 		}
 		if deletedLogs, err := s.store.DeleteOldLoginAttempts(ctx, cmd); err != nil {
 			s.logger.Error("Problem deleting expired login attempts", "error", err.Error())
@@ -102,3 +261,35 @@ func (s *Service) cleanup(ctx context.Context) {
 		s.logger.Error("failed to lock and execute cleanup of old login attempts", "error", err)
 	}
 }
+
+// normalizeIP returns the IP address without the brackets of IPv6 remote addresses, or an
+// empty string when the address isn't valid
+func normalizeIP(addr string) string {
+	ip := net.ParseIP(strings.Trim(strings.TrimSpace(addr), "[]"))
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+func containsIP(networks []*net.IPNet, addr string) bool {
+	ip := net.ParseIP(addr)
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCIDRs(value string) ([]*net.IPNet, error) {
+	networks := []*net.IPNet{}
+	for _, cidr := range util.SplitString(value) {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
@@ -3,102 +3,231 @@ package loginattemptimpl
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/services/loginattempt"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
+var testNow = time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
 func TestService_Validate(t *testing.T) {
 	testCases := []struct {
-		name          string
-		loginAttempts int64
-		disabled      bool
-		expected      bool
-		expectedErr   error
+		name        string
+		userStats   LoginAttemptStats
+		ipStats     LoginAttemptStats
+		ip          string
+		disabled    bool
+		expected    bool
+		expectedErr error
 	}{
 		{
-			name:          "When brute force protection enabled and user login attempt count is less than max",
-			loginAttempts: maxInvalidLoginAttempts - 1,
-			expected:      true,
-			expectedErr:   nil,
+			name:      "When brute force protection enabled and user login attempt count is less than max",
+			userStats: LoginAttemptStats{Count: defaultMaxAttempts - 1, Last: testNow.Unix()},
+			expected:  true,
 		},
 		{
-			name:          "When brute force protection enabled and user login attempt count equals max",
-			loginAttempts: maxInvalidLoginAttempts,
-			expected:      false,
-			expectedErr:   nil,
+			name:      "When brute force protection enabled and user login attempt count equals max",
+			userStats: LoginAttemptStats{Count: defaultMaxAttempts, Last: testNow.Unix()},
+			expected:  false,
 		},
 		{
-			name:          "When brute force protection enabled and user login attempt count is greater than max",
-			loginAttempts: maxInvalidLoginAttempts + 1,
-			expected:      false,
-			expectedErr:   nil,
+			name:      "When brute force protection enabled and the delay after the last user login attempt is over",
+			userStats: LoginAttemptStats{Count: defaultMaxAttempts, Last: testNow.Add(-defaultDelay).Unix()},
+			expected:  true,
+		},
+		{
+			name:      "When brute force protection enabled and the delay doubled after each user login attempt over max",
+			userStats: LoginAttemptStats{Count: defaultMaxAttempts + 2, Last: testNow.Add(-3 * defaultDelay).Unix()},
+			expected:  false,
+		},
+		{
+			name:      "When brute force protection enabled and the delay is capped",
+			userStats: LoginAttemptStats{Count: defaultMaxAttempts + 20, Last: testNow.Add(-defaultMaxDelay).Unix()},
+			expected:  true,
+		},
+		{
+			name:     "When brute force protection enabled and IP login attempt count equals max",
+			ip:       "10.0.0.1",
+			ipStats:  LoginAttemptStats{Count: defaultIPMaxAttempts, Last: testNow.Unix()},
+			expected: false,
+		},
+		{
+			name:     "When brute force protection enabled and IPv6 login attempt count is less than max",
+			ip:       "[::1]",
+			ipStats:  LoginAttemptStats{Count: defaultIPMaxAttempts - 1, Last: testNow.Unix()},
+			expected: true,
+		},
+		{
+			name:     "When brute force protection enabled and IP is allowed",
+			ip:       "192.168.1.10",
+			ipStats:  LoginAttemptStats{Count: defaultIPMaxAttempts, Last: testNow.Unix()},
+			expected: true,
+		},
+		{
+			name:      "When brute force protection enabled and IP is allowed and user login attempt count equals max",
+			ip:        "192.168.1.10",
+			userStats: LoginAttemptStats{Count: defaultMaxAttempts, Last: testNow.Unix()},
+			expected:  false,
 		},
-
 		{
-			name:          "When brute force protection disabled and user login attempt count is less than max",
-			loginAttempts: maxInvalidLoginAttempts - 1,
-			disabled:      true,
-			expected:      true,
-			expectedErr:   nil,
+			name:     "When brute force protection enabled and IP is denied",
+			ip:       "172.16.0.1",
+			expected: false,
 		},
 		{
-			name:          "When brute force protection disabled and user login attempt count equals max",
-			loginAttempts: maxInvalidLoginAttempts,
-			disabled:      true,
-			expected:      true,
-			expectedErr:   nil,
+			name:      "When brute force protection disabled and user login attempt count equals max",
+			userStats: LoginAttemptStats{Count: defaultMaxAttempts, Last: testNow.Unix()},
+			disabled:  true,
+			expected:  true,
 		},
 		{
-			name:          "When brute force protection disabled and user login attempt count is greater than max",
-			loginAttempts: maxInvalidLoginAttempts + 1,
-			disabled:      true,
-			expected:      true,
-			expectedErr:   nil,
+			name:     "When brute force protection disabled and IP is denied",
+			ip:       "172.16.0.1",
+			disabled: true,
+			expected: true,
 		},
 	}
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := setting.NewCfg()
-			cfg.DisableBruteForceLoginProtection = tt.disabled
-			service := &Service{
-				store: fakeStore{
-					ExpectedCount: tt.loginAttempts,
-					ExpectedErr:   tt.expectedErr,
-				},
-				cfg: cfg,
-			}
-
-			ok, err := service.Validate(context.Background(), "test")
+			service := setupTestService(t, &fakeStore{
+				ExpectedUserStats: tt.userStats,
+				ExpectedIPStats:   tt.ipStats,
+				ExpectedErr:       tt.expectedErr,
+			})
+			service.cfg.DisableBruteForceLoginProtection = tt.disabled
+
+			ok, err := service.Validate(context.Background(), "test", tt.ip)
 			assert.Equal(t, tt.expected, ok)
 			assert.Equal(t, tt.expectedErr, err)
 		})
 	}
 }
 
+func TestService_Add(t *testing.T) {
+	store := &fakeStore{}
+	service := setupTestService(t, store)
+
+	require.NoError(t, service.Add(context.Background(), "test", "[2001:db8::1]"))
+	require.Equal(t, []string{"2001:db8::1"}, store.CreatedIPs)
+
+	require.NoError(t, service.Add(context.Background(), "test", "192.168.1.10"))
+	require.Equal(t, []string{"2001:db8::1", ""}, store.CreatedIPs)
+}
+
+func TestService_GetBlocks(t *testing.T) {
+	service := setupTestService(t, &fakeStore{
+		ExpectedGroups: map[bool][]LoginAttemptStats{
+			false: {{Value: "test", Count: defaultMaxAttempts + 1, Last: testNow.Add(-time.Minute).Unix()}},
+			true:  {{Value: "10.0.0.1", Count: defaultIPMaxAttempts, Last: testNow.Unix()}},
+		},
+	})
+
+	blocks, err := service.GetBlocks(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []*loginattempt.Block{
+		{
+			Type:         loginattempt.BlockTypeIPAddress,
+			Value:        "10.0.0.1",
+			Attempts:     defaultIPMaxAttempts,
+			LastAttempt:  time.Unix(testNow.Unix(), 0),
+			DelayedUntil: time.Unix(testNow.Unix(), 0).Add(defaultDelay),
+		},
+		{
+			Type:         loginattempt.BlockTypeUsername,
+			Value:        "test",
+			Attempts:     defaultMaxAttempts + 1,
+			LastAttempt:  time.Unix(testNow.Add(-time.Minute).Unix(), 0),
+			DelayedUntil: time.Unix(testNow.Add(-time.Minute).Unix(), 0).Add(2 * defaultDelay),
+		},
+	}, blocks)
+}
+
+func TestService_ClearBlock(t *testing.T) {
+	store := &fakeStore{}
+	service := setupTestService(t, store)
+
+	require.NoError(t, service.ClearBlock(context.Background(), &loginattempt.ClearBlockCommand{IPAddress: "[::1]"}))
+	require.Equal(t, []DeleteLoginAttemptsCommand{{IpAddress: "::1"}}, store.Deleted)
+
+	err := service.ClearBlock(context.Background(), &loginattempt.ClearBlockCommand{IPAddress: "not-an-ip"})
+	require.ErrorIs(t, err, loginattempt.ErrInvalidBlock)
+
+	err = service.ClearBlock(context.Background(), &loginattempt.ClearBlockCommand{})
+	require.ErrorIs(t, err, loginattempt.ErrInvalidBlock)
+}
+
+func setupTestService(t *testing.T, store store) *Service {
+	t.Helper()
+
+	allowed, err := parseCIDRs("192.168.1.0/24")
+	require.NoError(t, err)
+	denied, err := parseCIDRs("172.16.0.0/12")
+	require.NoError(t, err)
+
+	return &Service{
+		store:           store,
+		cfg:             setting.NewCfg(),
+		now:             func() time.Time { return testNow },
+		maxAttempts:     defaultMaxAttempts,
+		ipMaxAttempts:   defaultIPMaxAttempts,
+		delay:           defaultDelay,
+		maxDelay:        defaultMaxDelay,
+		window:          defaultWindow,
+		allowedNetworks: allowed,
+		deniedNetworks:  denied,
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	networks, err := parseCIDRs("10.0.0.0/8, 2001:db8::/32")
+	require.NoError(t, err)
+	require.Len(t, networks, 2)
+	require.True(t, containsIP(networks, "2001:db8::1"))
+	require.False(t, containsIP(networks, "11.0.0.1"))
+
+	_, err = parseCIDRs("10.0.0.1")
+	require.Error(t, err)
+}
+
 var _ store = new(fakeStore)
 
 type fakeStore struct {
 	ExpectedErr         error
-	ExpectedCount       int64
+	ExpectedUserStats   LoginAttemptStats
+	ExpectedIPStats     LoginAttemptStats
+	ExpectedGroups      map[bool][]LoginAttemptStats
 	ExpectedDeletedRows int64
+
+	CreatedIPs []string
+	Deleted    []DeleteLoginAttemptsCommand
+}
+
+func (f *fakeStore) GetLoginAttemptStats(ctx context.Context, query GetLoginAttemptStatsQuery) (LoginAttemptStats, error) {
+	if query.IpAddress != "" {
+		return f.ExpectedIPStats, f.ExpectedErr
+	}
+	return f.ExpectedUserStats, f.ExpectedErr
 }
 
-func (f fakeStore) GetUserLoginAttemptCount(ctx context.Context, query GetUserLoginAttemptCountQuery) (int64, error) {
-	return f.ExpectedCount, f.ExpectedErr
+func (f *fakeStore) GetLoginAttemptGroups(ctx context.Context, query GetLoginAttemptGroupsQuery) ([]LoginAttemptStats, error) {
+	return f.ExpectedGroups[query.ByIpAddress], f.ExpectedErr
 }
 
-func (f fakeStore) CreateLoginAttempt(ctx context.Context, command CreateLoginAttemptCommand) (loginattempt.LoginAttempt, error) {
+func (f *fakeStore) CreateLoginAttempt(ctx context.Context, command CreateLoginAttemptCommand) (loginattempt.LoginAttempt, error) {
+	f.CreatedIPs = append(f.CreatedIPs, command.IpAddress)
 	return loginattempt.LoginAttempt{}, f.ExpectedErr
 }
 
-func (f fakeStore) DeleteOldLoginAttempts(ctx context.Context, command DeleteOldLoginAttemptsCommand) (int64, error) {
+func (f *fakeStore) DeleteOldLoginAttempts(ctx context.Context, command DeleteOldLoginAttemptsCommand) (int64, error) {
 	return f.ExpectedDeletedRows, f.ExpectedErr
 }
 
-func (f fakeStore) DeleteLoginAttempts(ctx context.Context, cmd DeleteLoginAttemptsCommand) error {
+func (f *fakeStore) DeleteLoginAttempts(ctx context.Context, cmd DeleteLoginAttemptsCommand) error {
+	f.Deleted = append(f.Deleted, cmd)
 	return f.ExpectedErr
 }
@@ -9,9 +9,25 @@ type CreateLoginAttemptCommand struct {
 	IpAddress string
 }
 
-type GetUserLoginAttemptCountQuery struct {
-	Username string
-	Since    time.Time
+// GetLoginAttemptStatsQuery filters the login attempts by username or by IP address
+type GetLoginAttemptStatsQuery struct {
+	Username  string
+	IpAddress string
+	Since     time.Time
+}
+
+type LoginAttemptStats struct {
+	Value string `xorm:"value"`
+	Count int64  `xorm:"count"`
+	// Last is the unix time of the last attempt
+	Last int64 `xorm:"last"`
+}
+
+// GetLoginAttemptGroupsQuery groups the login attempts by username or by IP address
+type GetLoginAttemptGroupsQuery struct {
+	ByIpAddress bool
+	Since       time.Time
+	MinCount    int64
 }
 
 type DeleteOldLoginAttemptsCommand struct {
@@ -19,5 +35,6 @@ type DeleteOldLoginAttemptsCommand struct {
 }
 
 type DeleteLoginAttemptsCommand struct {
-	Username string
+	Username  string
+	IpAddress string
 }
@@ -0,0 +1,46 @@
+package loginattemptimpl
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+const (
+	ActionBlocksRead   = "login.blocks:read"
+	ActionBlocksDelete = "login.blocks:delete"
+)
+
+var (
+	blocksReaderRole = accesscontrol.RoleDTO{
+		Name:        "fixed:login.blocks:reader",
+		DisplayName: "Login blocks reader",
+		Description: "List the usernames and IP addresses blocked after failed login attempts",
+		Group:       "Users",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionBlocksRead},
+		},
+	}
+
+	blocksWriterRole = accesscontrol.RoleDTO{
+		Name:        "fixed:login.blocks:writer",
+		DisplayName: "Login blocks writer",
+		Description: "List and clear the usernames and IP addresses blocked after failed login attempts",
+		Group:       "Users",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionBlocksRead},
+			{Action: ActionBlocksDelete},
+		},
+	}
+)
+
+func declareFixedRoles(ac accesscontrol.Service) error {
+	reader := accesscontrol.RoleRegistration{
+		Role:   blocksReaderRole,
+		Grants: []string{accesscontrol.RoleGrafanaAdmin},
+	}
+	writer := accesscontrol.RoleRegistration{
+		Role:   blocksWriterRole,
+		Grants: []string{accesscontrol.RoleGrafanaAdmin},
+	}
+
+	return ac.DeclareFixedRoles(reader, writer)
+}
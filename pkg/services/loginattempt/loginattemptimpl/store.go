@@ -17,7 +17,8 @@ type store interface {
 	CreateLoginAttempt(ctx context.Context, cmd CreateLoginAttemptCommand) (loginattempt.LoginAttempt, error)
 	DeleteOldLoginAttempts(ctx context.Context, cmd DeleteOldLoginAttemptsCommand) (int64, error)
 	DeleteLoginAttempts(ctx context.Context, cmd DeleteLoginAttemptsCommand) error
-	GetUserLoginAttemptCount(ctx context.Context, query GetUserLoginAttemptCountQuery) (int64, error)
+	GetLoginAttemptStats(ctx context.Context, query GetLoginAttemptStatsQuery) (LoginAttemptStats, error)
+	GetLoginAttemptGroups(ctx context.Context, query GetLoginAttemptGroupsQuery) ([]LoginAttemptStats, error)
 }
 
 func (xs *xormStore) CreateLoginAttempt(ctx context.Context, cmd CreateLoginAttemptCommand) (result loginattempt.LoginAttempt, err error) {
@@ -58,27 +59,45 @@ func (xs *xormStore) DeleteOldLoginAttempts(ctx context.Context, cmd DeleteOldLo
 
 func (xs *xormStore) DeleteLoginAttempts(ctx context.Context, cmd DeleteLoginAttemptsCommand) error {
 	return xs.db.WithDbSession(ctx, func(sess *db.Session) error {
-		_, err := sess.Exec("DELETE FROM login_attempt WHERE username = ?", cmd.Username)
+		var err error
+		if cmd.IpAddress != "" {
+			_, err = sess.Exec("DELETE FROM login_attempt WHERE ip_address = ?", cmd.IpAddress)
+		} else {
+			_, err = sess.Exec("DELETE FROM login_attempt WHERE username = ?", cmd.Username)
+		}
 		return err
 	})
 }
 
-func (xs *xormStore) GetUserLoginAttemptCount(ctx context.Context, query GetUserLoginAttemptCountQuery) (int64, error) {
-	var total int64
+func (xs *xormStore) GetLoginAttemptStats(ctx context.Context, query GetLoginAttemptStatsQuery) (LoginAttemptStats, error) {
+	column, value := "username", query.Username
+	if query.IpAddress != "" {
+		column, value = "ip_address", query.IpAddress
+	}
+
+	stats := LoginAttemptStats{Value: value}
 	err := xs.db.WithDbSession(ctx, func(dbSession *db.Session) error {
-		var queryErr error
-		loginAttempt := new(loginattempt.LoginAttempt)
-		total, queryErr = dbSession.
-			Where("username = ?", query.Username).
-			And("created >= ?", query.Since.Unix()).
-			Count(loginAttempt)
-
-		if queryErr != nil {
-			return queryErr
-		}
+		_, err := dbSession.SQL("SELECT COUNT(*) AS count, COALESCE(MAX(created), 0) AS last FROM login_attempt WHERE "+
+			column+" = ? AND created >= ?", value, query.Since.Unix()).Get(&stats)
+		return err
+	})
+	stats.Value = value
 
-		return nil
+	return stats, err
+}
+
+func (xs *xormStore) GetLoginAttemptGroups(ctx context.Context, query GetLoginAttemptGroupsQuery) ([]LoginAttemptStats, error) {
+	column := "username"
+	if query.ByIpAddress {
+		column = "ip_address"
+	}
+
+	result := make([]LoginAttemptStats, 0)
+	err := xs.db.WithDbSession(ctx, func(dbSession *db.Session) error {
+		return dbSession.SQL("SELECT "+column+" AS value, COUNT(*) AS count, MAX(created) AS last FROM login_attempt "+
+			"WHERE created >= ? GROUP BY "+column+" HAVING COUNT(*) >= ? ORDER BY "+column, query.Since.Unix(), query.MinCount).
+			Find(&result)
 	})
 
-	return total, err
+	return result, err
 }
@@ -22,29 +22,29 @@ func TestIntegrationLoginAttemptsQuery(t *testing.T) {
 
 	for _, test := range []struct {
 		Name   string
-		Query  GetUserLoginAttemptCountQuery
+		Query  GetLoginAttemptStatsQuery
 		Err    error
 		Result int64
 	}{
 		{
 			"Should return a total count of zero login attempts when comparing since beginning of time + 2min and 1s",
-			GetUserLoginAttemptCountQuery{Username: user, Since: timePlusTwoMinutes.Add(time.Second * 1)}, nil, 0,
+			GetLoginAttemptStatsQuery{Username: user, Since: timePlusTwoMinutes.Add(time.Second * 1)}, nil, 0,
 		},
 		{
 			"Should return a total count of zero login attempts when comparing since beginning of time + 2min and 1s",
-			GetUserLoginAttemptCountQuery{Username: user, Since: timePlusTwoMinutes.Add(time.Second * 1)}, nil, 0,
+			GetLoginAttemptStatsQuery{Username: user, Since: timePlusTwoMinutes.Add(time.Second * 1)}, nil, 0,
 		},
 		{
 			"Should return the total count of login attempts since beginning of time",
-			GetUserLoginAttemptCountQuery{Username: user, Since: beginningOfTime}, nil, 3,
+			GetLoginAttemptStatsQuery{Username: user, Since: beginningOfTime}, nil, 3,
 		},
 		{
 			"Should return the total count of login attempts since beginning of time + 1min",
-			GetUserLoginAttemptCountQuery{Username: user, Since: timePlusOneMinute}, nil, 2,
+			GetLoginAttemptStatsQuery{Username: user, Since: timePlusOneMinute}, nil, 2,
 		},
 		{
 			"Should return the total count of login attempts since beginning of time + 2min",
-			GetUserLoginAttemptCountQuery{Username: user, Since: timePlusTwoMinutes}, nil, 1,
+			GetLoginAttemptStatsQuery{Username: user, Since: timePlusTwoMinutes}, nil, 1,
 		},
 	} {
 		mockTime := beginningOfTime
@@ -73,9 +73,9 @@ func TestIntegrationLoginAttemptsQuery(t *testing.T) {
 		})
 		require.Nil(t, err)
 
-		count, err := s.GetUserLoginAttemptCount(context.Background(), test.Query)
+		stats, err := s.GetLoginAttemptStats(context.Background(), test.Query)
 		require.Equal(t, test.Err, err, test.Name)
-		require.Equal(t, test.Result, count, test.Name)
+		require.Equal(t, test.Result, stats.Count, test.Name)
 	}
 }
 
@@ -143,3 +143,48 @@ func TestIntegrationLoginAttemptsDelete(t *testing.T) {
 		require.Equal(t, test.DeletedRows, deletedRows, test.Name)
 	}
 }
+
+func TestIntegrationLoginAttemptsByIPAddress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	beginningOfTime := time.Date(2017, 10, 22, 8, 0, 0, 0, time.Local)
+	mockTime := beginningOfTime
+	s := &xormStore{
+		db:  db.InitTestDB(t),
+		now: func() time.Time { return mockTime },
+	}
+	ctx := context.Background()
+
+	for i, attempt := range []CreateLoginAttemptCommand{
+		{Username: "alice", IpAddress: "2001:db8:85a3::8a2e:370:7334"},
+		{Username: "bob", IpAddress: "2001:db8:85a3::8a2e:370:7334"},
+		{Username: "bob", IpAddress: "192.168.0.1"},
+	} {
+		mockTime = beginningOfTime.Add(time.Duration(i) * time.Minute)
+		_, err := s.CreateLoginAttempt(ctx, attempt)
+		require.NoError(t, err)
+	}
+
+	stats, err := s.GetLoginAttemptStats(ctx, GetLoginAttemptStatsQuery{IpAddress: "2001:db8:85a3::8a2e:370:7334", Since: beginningOfTime})
+	require.NoError(t, err)
+	require.Equal(t, LoginAttemptStats{
+		Value: "2001:db8:85a3::8a2e:370:7334",
+		Count: 2,
+		Last:  beginningOfTime.Add(time.Minute).Unix(),
+	}, stats)
+
+	groups, err := s.GetLoginAttemptGroups(ctx, GetLoginAttemptGroupsQuery{Since: beginningOfTime, MinCount: 2})
+	require.NoError(t, err)
+	require.Equal(t, []LoginAttemptStats{{Value: "bob", Count: 2, Last: beginningOfTime.Add(2 * time.Minute).Unix()}}, groups)
+
+	groups, err = s.GetLoginAttemptGroups(ctx, GetLoginAttemptGroupsQuery{ByIpAddress: true, Since: beginningOfTime, MinCount: 1})
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+
+	require.NoError(t, s.DeleteLoginAttempts(ctx, DeleteLoginAttemptsCommand{IpAddress: "2001:db8:85a3::8a2e:370:7334"}))
+	stats, err = s.GetLoginAttemptStats(ctx, GetLoginAttemptStatsQuery{Username: "bob", Since: beginningOfTime})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), stats.Count)
+}
@@ -9,12 +9,15 @@ import (
 var _ loginattempt.Service = new(MockLoginAttemptService)
 
 type MockLoginAttemptService struct {
-	AddCalled      bool
-	ResetCalled    bool
-	ValidateCalled bool
-
-	ExpectedValid bool
-	ExpectedErr   error
+	AddCalled        bool
+	ResetCalled      bool
+	ValidateCalled   bool
+	GetBlocksCalled  bool
+	ClearBlockCalled bool
+
+	ExpectedValid  bool
+	ExpectedBlocks []*loginattempt.Block
+	ExpectedErr    error
 }
 
 func (f *MockLoginAttemptService) Add(ctx context.Context, username, IPAddress string) error {
@@ -27,7 +30,17 @@ func (f *MockLoginAttemptService) Reset(ctx context.Context, username string) er
 	return f.ExpectedErr
 }
 
-func (f *MockLoginAttemptService) Validate(ctx context.Context, username string) (bool, error) {
+func (f *MockLoginAttemptService) Validate(ctx context.Context, username, IPAddress string) (bool, error) {
 	f.ValidateCalled = true
 	return f.ExpectedValid, f.ExpectedErr
 }
+
+func (f *MockLoginAttemptService) GetBlocks(ctx context.Context) ([]*loginattempt.Block, error) {
+	f.GetBlocksCalled = true
+	return f.ExpectedBlocks, f.ExpectedErr
+}
+
+func (f *MockLoginAttemptService) ClearBlock(ctx context.Context, cmd *loginattempt.ClearBlockCommand) error {
+	f.ClearBlockCalled = true
+	return f.ExpectedErr
+}
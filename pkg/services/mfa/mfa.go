@@ -0,0 +1,24 @@
+package mfa
+
+import (
+	"context"
+)
+
+type Service interface {
+	// IsEnabled returns true when multi-factor authentication is enabled in the configuration
+	IsEnabled() bool
+	// VerifyLogin is the second authentication step of users who logged in with their password.
+	// It returns ErrEnrollmentRequired with a new enrollment when the policies require
+	// multi-factor authentication and the user isn't enrolled yet.
+	VerifyLogin(ctx context.Context, cmd *VerifyLoginCommand) error
+	GetStatus(ctx context.Context, userID int64) (*Status, error)
+	// Enroll starts the enrollment of a user, it's completed by Confirm
+	Enroll(ctx context.Context, userID int64, login string) (*Enrollment, error)
+	Confirm(ctx context.Context, userID int64, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error)
+	Disable(ctx context.Context, userID int64, code string) error
+	// Reset removes the enrollment of a user without verification, for administrators
+	Reset(ctx context.Context, userID int64) error
+	GetOrgPolicy(ctx context.Context, orgID int64) (*OrgPolicy, error)
+	SetOrgPolicy(ctx context.Context, cmd *SetOrgPolicyCommand) error
+}
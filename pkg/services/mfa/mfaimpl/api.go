@@ -0,0 +1,126 @@
+package mfaimpl
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type codeForm struct {
+	Code string `json:"code"`
+}
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+
+	routeRegister.Group("/api/user/mfa", func(userRoute routing.RouteRegister) {
+		userRoute.Get("/", routing.Wrap(s.getStatusHandler))
+		userRoute.Post("/enroll", routing.Wrap(s.enrollHandler))
+		userRoute.Post("/confirm", routing.Wrap(s.confirmHandler))
+		userRoute.Post("/recovery-codes", routing.Wrap(s.regenerateRecoveryCodesHandler))
+		userRoute.Post("/disable", routing.Wrap(s.disableHandler))
+	}, middleware.ReqSignedInNoAnonymous)
+
+	routeRegister.Group("/api/org/mfa/policy", func(orgRoute routing.RouteRegister) {
+		orgRoute.Get("/", authorize(ac.EvalPermission(ac.ActionOrgsRead)), routing.Wrap(s.getOrgPolicyHandler))
+		orgRoute.Put("/", authorize(ac.EvalPermission(ac.ActionOrgsWrite)), routing.Wrap(s.setOrgPolicyHandler))
+	}, middleware.ReqSignedIn)
+
+	userIDScope := ac.Scope("global.users", "id", ac.Parameter(":id"))
+	routeRegister.Delete("/api/admin/users/:id/mfa", middleware.ReqSignedIn, authorize(ac.EvalPermission(ActionReset, userIDScope)), routing.Wrap(s.resetHandler))
+}
+
+func (s *Service) getStatusHandler(c *contextmodel.ReqContext) response.Response {
+	if c.IsServiceAccount {
+		return response.Error(http.StatusBadRequest, "Service accounts can't use multi-factor authentication", nil)
+	}
+	status, err := s.GetStatus(c.Req.Context(), c.UserID)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get multi-factor authentication status", err)
+	}
+	return response.JSON(http.StatusOK, status)
+}
+
+func (s *Service) enrollHandler(c *contextmodel.ReqContext) response.Response {
+	if c.IsServiceAccount {
+		return response.Error(http.StatusBadRequest, "Service accounts can't use multi-factor authentication", nil)
+	}
+	enrollment, err := s.Enroll(c.Req.Context(), c.UserID, c.Login)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to enroll user", err)
+	}
+	return response.JSON(http.StatusOK, enrollment)
+}
+
+func (s *Service) confirmHandler(c *contextmodel.ReqContext) response.Response {
+	form := codeForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if err := s.Confirm(c.Req.Context(), c.UserID, form.Code); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to confirm enrollment", err)
+	}
+	return response.Success("Multi-factor authentication enabled")
+}
+
+func (s *Service) regenerateRecoveryCodesHandler(c *contextmodel.ReqContext) response.Response {
+	form := codeForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	codes, err := s.RegenerateRecoveryCodes(c.Req.Context(), c.UserID, form.Code)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to generate recovery codes", err)
+	}
+	return response.JSON(http.StatusOK, map[string]interface{}{"recoveryCodes": codes})
+}
+
+func (s *Service) disableHandler(c *contextmodel.ReqContext) response.Response {
+	form := codeForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if err := s.Disable(c.Req.Context(), c.UserID, form.Code); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to disable multi-factor authentication", err)
+	}
+	return response.Success("Multi-factor authentication disabled")
+}
+
+func (s *Service) resetHandler(c *contextmodel.ReqContext) response.Response {
+	id, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	if err := s.Reset(c.Req.Context(), id); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to reset multi-factor authentication", err)
+	}
+	s.log.FromContext(c.Req.Context()).Info("Multi-factor authentication reset", "userId", id, "resetBy", c.UserID)
+	return response.Success("Multi-factor authentication reset")
+}
+
+func (s *Service) getOrgPolicyHandler(c *contextmodel.ReqContext) response.Response {
+	policy, err := s.GetOrgPolicy(c.Req.Context(), c.OrgID)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get multi-factor authentication policy", err)
+	}
+	return response.JSON(http.StatusOK, policy)
+}
+
+func (s *Service) setOrgPolicyHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := mfa.SetOrgPolicyCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgID
+	if err := s.SetOrgPolicy(c.Req.Context(), &cmd); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to update multi-factor authentication policy", err)
+	}
+	return response.Success("Multi-factor authentication policy updated")
+}
@@ -0,0 +1,30 @@
+package mfaimpl
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+const (
+	ActionReset = "users.mfa:reset"
+)
+
+var (
+	mfaResetterRole = accesscontrol.RoleDTO{
+		Name:        "fixed:users.mfa:resetter",
+		DisplayName: "Multi-factor authentication resetter",
+		Description: "Reset the multi-factor authentication of all users",
+		Group:       "Users",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionReset, Scope: accesscontrol.ScopeGlobalUsersAll},
+		},
+	}
+)
+
+func declareFixedRoles(ac accesscontrol.Service) error {
+	resetter := accesscontrol.RoleRegistration{
+		Role:   mfaResetterRole,
+		Grants: []string{accesscontrol.RoleGrafanaAdmin},
+	}
+
+	return ac.DeclareFixedRoles(resetter)
+}
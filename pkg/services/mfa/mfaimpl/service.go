@@ -0,0 +1,415 @@
+package mfaimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var _ mfa.Service = (*Service)(nil)
+
+type Service struct {
+	store          store
+	secretsService secrets.Service
+	userService    user.Service
+	orgService     org.Service
+	accessControl  ac.AccessControl
+	log            log.Logger
+	now            func() time.Time
+
+	enabled bool
+	issuer  string
+	// defaultPolicy applies to the organizations without policy
+	defaultPolicy mfa.OrgPolicy
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sql db.DB,
+	routeRegister routing.RouteRegister,
+	accessControl ac.AccessControl,
+	accesscontrolService ac.Service,
+	secretsService secrets.Service,
+	userService user.Service,
+	orgService org.Service,
+) (*Service, error) {
+	section := cfg.SectionWithEnvOverrides("auth.mfa")
+	s := &Service{
+		store:          &sqlStore{db: sql},
+		secretsService: secretsService,
+		userService:    userService,
+		orgService:     orgService,
+		accessControl:  accessControl,
+		log:            log.New("mfa"),
+		now:            time.Now,
+		enabled:        section.Key("enabled").MustBool(false),
+		issuer:         section.Key("issuer").MustString("Grafana"),
+		defaultPolicy: mfa.OrgPolicy{
+			Enforcement: mfa.Enforcement(section.Key("default_enforcement").MustString(string(mfa.EnforcementOptional))),
+			BasicAuth:   mfa.BasicAuthPolicy(section.Key("default_basic_auth").MustString(string(mfa.BasicAuthDeny))),
+		},
+	}
+	if !s.defaultPolicy.Enforcement.IsValid() {
+		return nil, fmt.Errorf("invalid auth.mfa default_enforcement: %s", s.defaultPolicy.Enforcement)
+	}
+	if !s.defaultPolicy.BasicAuth.IsValid() {
+		return nil, fmt.Errorf("invalid auth.mfa default_basic_auth: %s", s.defaultPolicy.BasicAuth)
+	}
+
+	if !s.enabled {
+		return s, nil
+	}
+
+	// the codes are only verified by the password clients of the authentication broker,
+	// the logins of the legacy authentication would skip them
+	if !cfg.AuthBrokerEnabled {
+		return nil, errors.New("auth.mfa requires the authentication broker, set broker = true in the auth section")
+	}
+
+	if err := declareFixedRoles(accesscontrolService); err != nil {
+		return nil, err
+	}
+
+	s.registerAPIEndpoints(routeRegister)
+
+	return s, nil
+}
+
+func (s *Service) IsEnabled() bool {
+	return s.enabled
+}
+
+func (s *Service) VerifyLogin(ctx context.Context, cmd *mfa.VerifyLoginCommand) error {
+	m, err := s.get(ctx, cmd.UserID)
+	if err != nil {
+		return err
+	}
+	required, basicAuth, err := s.evaluatePolicies(ctx, cmd.UserID)
+	if err != nil {
+		return err
+	}
+
+	enrolled := m != nil && m.Enabled
+	if !enrolled && !required {
+		return nil
+	}
+
+	if cmd.BasicAuth {
+		switch {
+		case basicAuth == mfa.BasicAuthAllow:
+			return nil
+		case basicAuth == mfa.BasicAuthDeny:
+			return mfa.ErrBasicAuthDenied.Errorf("basic authentication denied by policy")
+		case !enrolled:
+			return mfa.ErrBasicAuthDenied.Errorf("user must enroll before using basic authentication")
+		}
+	}
+
+	code := normalizeCode(cmd.Code)
+	if !enrolled {
+		// Users logging in for the first time since the policy requires multi-factor
+		// authentication enroll with the secret returned in the error
+		if code == "" || m == nil {
+			enrollment, err := s.Enroll(ctx, cmd.UserID, cmd.Login)
+			if err != nil {
+				return err
+			}
+			return mfa.ErrEnrollmentRequired.Build(errutil.TemplateData{
+				Public: map[string]interface{}{
+					"secret":          enrollment.Secret,
+					"provisioningUri": enrollment.ProvisioningURI,
+					"recoveryCodes":   enrollment.RecoveryCodes,
+				},
+			})
+		}
+		return s.confirm(ctx, m, code)
+	}
+
+	if code == "" {
+		return mfa.ErrCodeRequired.Errorf("multi-factor authentication code required")
+	}
+	return s.verify(ctx, m, code)
+}
+
+func (s *Service) GetStatus(ctx context.Context, userID int64) (*mfa.Status, error) {
+	m, err := s.get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	required, _, err := s.evaluatePolicies(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &mfa.Status{Required: required}
+	if m != nil {
+		status.Enabled = m.Enabled
+		status.Pending = !m.Enabled
+		if m.Enabled {
+			status.RecoveryCodesLeft = len(m.RecoveryCodes)
+		}
+	}
+	return status, nil
+}
+
+func (s *Service) Enroll(ctx context.Context, userID int64, login string) (*mfa.Enrollment, error) {
+	m, err := s.get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil && m.Enabled {
+		return nil, mfa.ErrAlreadyEnrolled.Errorf("user %d is already enrolled", userID)
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.secretsService.Encrypt(ctx, []byte(secret), secrets.WithoutScope())
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if err := s.store.Save(ctx, &userMFA{
+		UserID:        userID,
+		Secret:        encrypted,
+		RecoveryCodes: hashes,
+		Created:       now,
+		Updated:       now,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &mfa.Enrollment{
+		Secret:          secret,
+		ProvisioningURI: provisioningURI(s.issuer, login, secret),
+		RecoveryCodes:   codes,
+	}, nil
+}
+
+func (s *Service) Confirm(ctx context.Context, userID int64, code string) error {
+	m, err := s.store.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if m.Enabled {
+		return mfa.ErrAlreadyEnrolled.Errorf("user %d is already enrolled", userID)
+	}
+	return s.confirm(ctx, m, normalizeCode(code))
+}
+
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	m, err := s.getEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verify(ctx, m, normalizeCode(code)); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	m.RecoveryCodes = hashes
+	m.Updated = s.now()
+	if err := s.store.Save(ctx, m); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *Service) Disable(ctx context.Context, userID int64, code string) error {
+	m, err := s.getEnabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	required, _, err := s.evaluatePolicies(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return mfa.ErrRequiredByPolicy.Errorf("user %d can't disable multi-factor authentication", userID)
+	}
+	if err := s.verify(ctx, m, normalizeCode(code)); err != nil {
+		return err
+	}
+	return s.store.Delete(ctx, userID)
+}
+
+func (s *Service) Reset(ctx context.Context, userID int64) error {
+	return s.store.Delete(ctx, userID)
+}
+
+func (s *Service) GetOrgPolicy(ctx context.Context, orgID int64) (*mfa.OrgPolicy, error) {
+	policies, err := s.store.GetOrgPolicies(ctx, []int64{orgID})
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) > 0 {
+		return policies[0], nil
+	}
+	policy := s.defaultPolicy
+	policy.OrgID = orgID
+	return &policy, nil
+}
+
+func (s *Service) SetOrgPolicy(ctx context.Context, cmd *mfa.SetOrgPolicyCommand) error {
+	if !cmd.Enforcement.IsValid() {
+		return mfa.ErrInvalidPolicy.Errorf("invalid enforcement: %s", cmd.Enforcement)
+	}
+	if !cmd.BasicAuth.IsValid() {
+		return mfa.ErrInvalidPolicy.Errorf("invalid basic auth policy: %s", cmd.BasicAuth)
+	}
+	return s.store.SetOrgPolicy(ctx, &mfa.OrgPolicy{
+		OrgID:       cmd.OrgID,
+		Enforcement: cmd.Enforcement,
+		BasicAuth:   cmd.BasicAuth,
+		Updated:     s.now(),
+	})
+}
+
+// get returns the enrollment of the user, nil if the user isn't enrolled
+func (s *Service) get(ctx context.Context, userID int64) (*userMFA, error) {
+	m, err := s.store.Get(ctx, userID)
+	if errors.Is(err, mfa.ErrNotEnrolled) {
+		return nil, nil
+	}
+	return m, err
+}
+
+// getEnabled returns the confirmed enrollment of the user
+func (s *Service) getEnabled(ctx context.Context, userID int64) (*userMFA, error) {
+	m, err := s.store.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !m.Enabled {
+		return nil, mfa.ErrNotEnrolled.Errorf("enrollment of user %d isn't confirmed", userID)
+	}
+	return m, nil
+}
+
+// confirm enables a pending enrollment with a TOTP code, recovery codes aren't accepted
+func (s *Service) confirm(ctx context.Context, m *userMFA, code string) error {
+	step, ok, err := s.validateTOTP(ctx, m, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return mfa.ErrInvalidCode.Errorf("invalid code for pending enrollment of user %d", m.UserID)
+	}
+
+	m.Enabled = true
+	m.LastUsedStep = step
+	m.Updated = s.now()
+	return s.store.Save(ctx, m)
+}
+
+// verify accepts a TOTP code which wasn't used yet, or a recovery code which is removed
+func (s *Service) verify(ctx context.Context, m *userMFA, code string) error {
+	step, ok, err := s.validateTOTP(ctx, m, code)
+	if err != nil {
+		return err
+	}
+	if ok {
+		used, err := s.store.UseStep(ctx, m.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return mfa.ErrInvalidCode.Errorf("code of user %d was already used", m.UserID)
+		}
+		return nil
+	}
+
+	if i := matchRecoveryCode(m.RecoveryCodes, code); i >= 0 {
+		m.RecoveryCodes = append(m.RecoveryCodes[:i:i], m.RecoveryCodes[i+1:]...)
+		m.Updated = s.now()
+		if err := s.store.Save(ctx, m); err != nil {
+			return err
+		}
+		s.log.FromContext(ctx).Info("Recovery code used", "userId", m.UserID, "recoveryCodesLeft", len(m.RecoveryCodes))
+		return nil
+	}
+
+	return mfa.ErrInvalidCode.Errorf("invalid code for user %d", m.UserID)
+}
+
+func (s *Service) validateTOTP(ctx context.Context, m *userMFA, code string) (int64, bool, error) {
+	secret, err := s.secretsService.Decrypt(ctx, m.Secret)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return validateTOTP(string(secret), code, s.now())
+}
+
+// evaluatePolicies returns whether the policies of the organizations of the user require
+// multi-factor authentication, and the strictest of their basic auth policies
+func (s *Service) evaluatePolicies(ctx context.Context, userID int64) (bool, mfa.BasicAuthPolicy, error) {
+	usr, err := s.userService.GetByID(ctx, &user.GetUserByIDQuery{ID: userID})
+	if err != nil {
+		return false, "", err
+	}
+	orgs, err := s.orgService.GetUserOrgList(ctx, &org.GetUserOrgListQuery{UserID: userID})
+	if err != nil {
+		return false, "", err
+	}
+
+	orgIDs := make([]int64, 0, len(orgs))
+	for _, o := range orgs {
+		orgIDs = append(orgIDs, o.OrgID)
+	}
+	policies, err := s.store.GetOrgPolicies(ctx, orgIDs)
+	if err != nil {
+		return false, "", err
+	}
+	policyByOrg := make(map[int64]*mfa.OrgPolicy, len(policies))
+	for _, p := range policies {
+		policyByOrg[p.OrgID] = p
+	}
+
+	isRequired := func(p *mfa.OrgPolicy, role org.RoleType) bool {
+		switch p.Enforcement {
+		case mfa.EnforcementAll:
+			return true
+		case mfa.EnforcementAdmins:
+			return usr.IsAdmin || role == org.RoleAdmin
+		default:
+			return false
+		}
+	}
+
+	if len(orgs) == 0 {
+		return isRequired(&s.defaultPolicy, ""), s.defaultPolicy.BasicAuth, nil
+	}
+
+	required := false
+	basicAuth := mfa.BasicAuthAllow
+	for _, o := range orgs {
+		p, ok := policyByOrg[o.OrgID]
+		if !ok {
+			p = &s.defaultPolicy
+		}
+		required = required || isRequired(p, o.Role)
+		basicAuth = basicAuth.Strictest(p.BasicAuth)
+	}
+	return required, basicAuth, nil
+}
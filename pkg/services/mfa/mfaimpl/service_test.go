@@ -0,0 +1,272 @@
+package mfaimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+func TestService_VerifyLogin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("accepts users without enrollment when not required", func(t *testing.T) {
+		s, _ := setupTestService(t, org.RoleViewer, mfa.EnforcementAdmins)
+		require.NoError(t, s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user"}))
+	})
+
+	t.Run("requires enrollment by policy", func(t *testing.T) {
+		s, store := setupTestService(t, org.RoleAdmin, mfa.EnforcementAdmins)
+
+		err := s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user"})
+		require.ErrorIs(t, err, mfa.ErrEnrollmentRequired)
+		var grafanaErr errutil.Error
+		require.ErrorAs(t, err, &grafanaErr)
+		secret := grafanaErr.PublicPayload["secret"].(string)
+		require.NotEmpty(t, secret)
+		require.Contains(t, grafanaErr.PublicPayload["provisioningUri"], "otpauth://totp/Grafana:user?")
+		require.Len(t, grafanaErr.PublicPayload["recoveryCodes"], recoveryCodeCount)
+		require.False(t, store.users[1].Enabled)
+
+		err = s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user", Code: "000000"})
+		require.ErrorIs(t, err, mfa.ErrInvalidCode)
+
+		require.NoError(t, s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user", Code: currentCode(t, s, secret)}))
+		require.True(t, store.users[1].Enabled)
+	})
+
+	t.Run("requires code of enrolled users", func(t *testing.T) {
+		s, _ := setupTestService(t, org.RoleViewer, mfa.EnforcementOptional)
+		enrollment := enroll(t, s)
+
+		err := s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user"})
+		require.ErrorIs(t, err, mfa.ErrCodeRequired)
+
+		// the code was used by the confirmation
+		err = s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user", Code: currentCode(t, s, enrollment.Secret)})
+		require.ErrorIs(t, err, mfa.ErrInvalidCode)
+
+		s.now = func() time.Time { return time.Unix(1700000030, 0) }
+		require.NoError(t, s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user", Code: currentCode(t, s, enrollment.Secret)}))
+	})
+
+	t.Run("accepts recovery codes once", func(t *testing.T) {
+		s, store := setupTestService(t, org.RoleViewer, mfa.EnforcementOptional)
+		enrollment := enroll(t, s)
+
+		require.NoError(t, s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user", Code: enrollment.RecoveryCodes[0]}))
+		require.Len(t, store.users[1].RecoveryCodes, recoveryCodeCount-1)
+
+		err := s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user", Code: enrollment.RecoveryCodes[0]})
+		require.ErrorIs(t, err, mfa.ErrInvalidCode)
+	})
+
+	t.Run("applies the basic auth policy", func(t *testing.T) {
+		s, store := setupTestService(t, org.RoleViewer, mfa.EnforcementOptional)
+		enrollment := enroll(t, s)
+		s.now = func() time.Time { return time.Unix(1700000030, 0) }
+
+		err := s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user", BasicAuth: true})
+		require.ErrorIs(t, err, mfa.ErrCodeRequired)
+		require.NoError(t, s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user", BasicAuth: true, Code: currentCode(t, s, enrollment.Secret)}))
+
+		store.policies[1] = &mfa.OrgPolicy{OrgID: 1, Enforcement: mfa.EnforcementOptional, BasicAuth: mfa.BasicAuthAllow}
+		require.NoError(t, s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user", BasicAuth: true}))
+
+		store.policies[1] = &mfa.OrgPolicy{OrgID: 1, Enforcement: mfa.EnforcementOptional, BasicAuth: mfa.BasicAuthDeny}
+		err = s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user", BasicAuth: true, Code: currentCode(t, s, enrollment.Secret)})
+		require.ErrorIs(t, err, mfa.ErrBasicAuthDenied)
+	})
+
+	t.Run("denies basic auth of users who must enroll", func(t *testing.T) {
+		s, _ := setupTestService(t, org.RoleViewer, mfa.EnforcementAll)
+		err := s.VerifyLogin(ctx, &mfa.VerifyLoginCommand{UserID: 1, Login: "user", BasicAuth: true})
+		require.ErrorIs(t, err, mfa.ErrBasicAuthDenied)
+	})
+}
+
+func TestService_Disable(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("disables with a code", func(t *testing.T) {
+		s, store := setupTestService(t, org.RoleViewer, mfa.EnforcementOptional)
+		enrollment := enroll(t, s)
+
+		require.ErrorIs(t, s.Disable(ctx, 1, "000000"), mfa.ErrInvalidCode)
+		require.NoError(t, s.Disable(ctx, 1, enrollment.RecoveryCodes[0]))
+		require.Empty(t, store.users)
+	})
+
+	t.Run("can't disable when required by policy", func(t *testing.T) {
+		s, store := setupTestService(t, org.RoleViewer, mfa.EnforcementOptional)
+		enrollment := enroll(t, s)
+		store.policies[1] = &mfa.OrgPolicy{OrgID: 1, Enforcement: mfa.EnforcementAll, BasicAuth: mfa.BasicAuthCode}
+
+		require.ErrorIs(t, s.Disable(ctx, 1, enrollment.RecoveryCodes[0]), mfa.ErrRequiredByPolicy)
+	})
+}
+
+func TestService_Status(t *testing.T) {
+	ctx := context.Background()
+	s, _ := setupTestService(t, org.RoleAdmin, mfa.EnforcementAdmins)
+
+	status, err := s.GetStatus(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, &mfa.Status{Required: true}, status)
+
+	enrollment, err := s.Enroll(ctx, 1, "user")
+	require.NoError(t, err)
+	status, err = s.GetStatus(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, &mfa.Status{Required: true, Pending: true}, status)
+
+	require.NoError(t, s.Confirm(ctx, 1, currentCode(t, s, enrollment.Secret)))
+	status, err = s.GetStatus(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, &mfa.Status{Required: true, Enabled: true, RecoveryCodesLeft: recoveryCodeCount}, status)
+
+	_, err = s.Enroll(ctx, 1, "user")
+	require.ErrorIs(t, err, mfa.ErrAlreadyEnrolled)
+
+	require.NoError(t, s.Reset(ctx, 1))
+	status, err = s.GetStatus(ctx, 1)
+	require.NoError(t, err)
+	require.False(t, status.Enabled)
+}
+
+func TestProvideService(t *testing.T) {
+	t.Run("denies basic authentication of enrolled users by default", func(t *testing.T) {
+		s, err := ProvideService(setting.NewCfg(), nil, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, mfa.BasicAuthDeny, s.defaultPolicy.BasicAuth)
+	})
+
+	t.Run("refuses to enable multi-factor authentication without the authentication broker", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.AuthBrokerEnabled = false
+		_, err := cfg.Raw.Section("auth.mfa").NewKey("enabled", "true")
+		require.NoError(t, err)
+
+		_, err = ProvideService(cfg, nil, nil, nil, nil, nil, nil, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestService_OrgPolicy(t *testing.T) {
+	ctx := context.Background()
+	s, _ := setupTestService(t, org.RoleAdmin, mfa.EnforcementOptional)
+
+	policy, err := s.GetOrgPolicy(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), policy.OrgID)
+	require.Equal(t, mfa.EnforcementOptional, policy.Enforcement)
+	require.Equal(t, mfa.BasicAuthCode, policy.BasicAuth)
+
+	err = s.SetOrgPolicy(ctx, &mfa.SetOrgPolicyCommand{OrgID: 2, Enforcement: "some", BasicAuth: mfa.BasicAuthDeny})
+	require.ErrorIs(t, err, mfa.ErrInvalidPolicy)
+
+	require.NoError(t, s.SetOrgPolicy(ctx, &mfa.SetOrgPolicyCommand{OrgID: 2, Enforcement: mfa.EnforcementAll, BasicAuth: mfa.BasicAuthDeny}))
+	policy, err = s.GetOrgPolicy(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, mfa.EnforcementAll, policy.Enforcement)
+	require.Equal(t, mfa.BasicAuthDeny, policy.BasicAuth)
+}
+
+func setupTestService(t *testing.T, role org.RoleType, enforcement mfa.Enforcement) (*Service, *fakeStore) {
+	t.Helper()
+
+	store := &fakeStore{users: map[int64]*userMFA{}, policies: map[int64]*mfa.OrgPolicy{}}
+	orgService := orgtest.NewOrgServiceFake()
+	orgService.ExpectedUserOrgDTO = []*org.UserOrgDTO{{OrgID: 1, Role: role}}
+
+	return &Service{
+		store:          store,
+		secretsService: fakes.NewFakeSecretsService(),
+		userService:    &usertest.FakeUserService{ExpectedUser: &user.User{ID: 1, Login: "user"}},
+		orgService:     orgService,
+		log:            log.NewNopLogger(),
+		now:            func() time.Time { return time.Unix(1700000000, 0) },
+		enabled:        true,
+		issuer:         "Grafana",
+		defaultPolicy:  mfa.OrgPolicy{Enforcement: enforcement, BasicAuth: mfa.BasicAuthCode},
+	}, store
+}
+
+// enroll enrolls the user 1 with a confirmed enrollment
+func enroll(t *testing.T, s *Service) *mfa.Enrollment {
+	t.Helper()
+
+	enrollment, err := s.Enroll(context.Background(), 1, "user")
+	require.NoError(t, err)
+	require.NoError(t, s.Confirm(context.Background(), 1, currentCode(t, s, enrollment.Secret)))
+	return enrollment
+}
+
+func currentCode(t *testing.T, s *Service, secret string) string {
+	t.Helper()
+
+	key, err := secretEncoding.DecodeString(secret)
+	require.NoError(t, err)
+	return hotp(key, totpStep(s.now()))
+}
+
+type fakeStore struct {
+	users    map[int64]*userMFA
+	policies map[int64]*mfa.OrgPolicy
+}
+
+func (f *fakeStore) Get(ctx context.Context, userID int64) (*userMFA, error) {
+	m, ok := f.users[userID]
+	if !ok {
+		return nil, mfa.ErrNotEnrolled.Errorf("not enrolled")
+	}
+	copied := *m
+	copied.RecoveryCodes = append([]string{}, m.RecoveryCodes...)
+	return &copied, nil
+}
+
+func (f *fakeStore) Save(ctx context.Context, m *userMFA) error {
+	copied := *m
+	f.users[m.UserID] = &copied
+	return nil
+}
+
+func (f *fakeStore) Delete(ctx context.Context, userID int64) error {
+	delete(f.users, userID)
+	return nil
+}
+
+func (f *fakeStore) UseStep(ctx context.Context, userID, step int64) (bool, error) {
+	m, ok := f.users[userID]
+	if !ok || m.LastUsedStep >= step {
+		return false, nil
+	}
+	m.LastUsedStep = step
+	return true, nil
+}
+
+func (f *fakeStore) GetOrgPolicies(ctx context.Context, orgIDs []int64) ([]*mfa.OrgPolicy, error) {
+	policies := []*mfa.OrgPolicy{}
+	for _, id := range orgIDs {
+		if p, ok := f.policies[id]; ok {
+			policies = append(policies, p)
+		}
+	}
+	return policies, nil
+}
+
+func (f *fakeStore) SetOrgPolicy(ctx context.Context, policy *mfa.OrgPolicy) error {
+	f.policies[policy.OrgID] = policy
+	return nil
+}
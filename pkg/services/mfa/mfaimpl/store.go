@@ -0,0 +1,131 @@
+package mfaimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/mfa"
+)
+
+// userMFA is the enrollment of a user, pending until it's confirmed with a code
+type userMFA struct {
+	ID     int64 `xorm:"pk autoincr 'id'"`
+	UserID int64 `xorm:"user_id"`
+	// Secret is the encrypted TOTP secret
+	Secret  []byte `xorm:"secret"`
+	Enabled bool   `xorm:"enabled"`
+	// RecoveryCodes are the hashes of the unused recovery codes
+	RecoveryCodes []string `xorm:"recovery_codes"`
+	// LastUsedStep is the time step of the last accepted code, codes can't be reused
+	LastUsedStep int64     `xorm:"last_used_step"`
+	Created      time.Time `xorm:"'created'"`
+	Updated      time.Time `xorm:"'updated'"`
+}
+
+func (m userMFA) TableName() string {
+	return "user_mfa"
+}
+
+type store interface {
+	Get(ctx context.Context, userID int64) (*userMFA, error)
+	// Save creates or replaces the enrollment of the user
+	Save(ctx context.Context, m *userMFA) error
+	Delete(ctx context.Context, userID int64) error
+	// UseStep records the time step of an accepted code, it returns false when a later
+	// or the same step was already used
+	UseStep(ctx context.Context, userID, step int64) (bool, error)
+	GetOrgPolicies(ctx context.Context, orgIDs []int64) ([]*mfa.OrgPolicy, error)
+	SetOrgPolicy(ctx context.Context, policy *mfa.OrgPolicy) error
+}
+
+type sqlStore struct {
+	db db.DB
+}
+
+func (s *sqlStore) Get(ctx context.Context, userID int64) (*userMFA, error) {
+	m := userMFA{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("user_id = ?", userID).Get(&m)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return mfa.ErrNotEnrolled.Errorf("user %d isn't enrolled", userID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *sqlStore) Save(ctx context.Context, m *userMFA) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		existing := userMFA{}
+		has, err := sess.Where("user_id = ?", m.UserID).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if !has {
+			_, err := sess.Insert(m)
+			return err
+		}
+		m.ID = existing.ID
+		_, err = sess.ID(m.ID).AllCols().Update(m)
+		return err
+	})
+}
+
+func (s *sqlStore) Delete(ctx context.Context, userID int64) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("DELETE FROM user_mfa WHERE user_id = ?", userID)
+		return err
+	})
+}
+
+func (s *sqlStore) UseStep(ctx context.Context, userID, step int64) (bool, error) {
+	var used bool
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("UPDATE user_mfa SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?", step, userID, step)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		used = affected > 0
+		return nil
+	})
+	return used, err
+}
+
+func (s *sqlStore) GetOrgPolicies(ctx context.Context, orgIDs []int64) ([]*mfa.OrgPolicy, error) {
+	policies := make([]*mfa.OrgPolicy, 0)
+	if len(orgIDs) == 0 {
+		return policies, nil
+	}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.In("org_id", orgIDs).Find(&policies)
+	})
+	return policies, err
+}
+
+func (s *sqlStore) SetOrgPolicy(ctx context.Context, policy *mfa.OrgPolicy) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		existing := mfa.OrgPolicy{}
+		has, err := sess.Where("org_id = ?", policy.OrgID).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if !has {
+			_, err := sess.Insert(policy)
+			return err
+		}
+		policy.ID = existing.ID
+		_, err = sess.ID(policy.ID).AllCols().Update(policy)
+		return err
+	})
+}
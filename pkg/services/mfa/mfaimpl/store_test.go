@@ -0,0 +1,68 @@
+package mfaimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/mfa"
+)
+
+func TestIntegrationMFAStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	ss := &sqlStore{db: db.InitTestDB(t)}
+	now := time.Now().Truncate(time.Second)
+
+	t.Run("saves enrollments", func(t *testing.T) {
+		_, err := ss.Get(ctx, 1)
+		require.ErrorIs(t, err, mfa.ErrNotEnrolled)
+
+		require.NoError(t, ss.Save(ctx, &userMFA{UserID: 1, Secret: []byte("secret"), RecoveryCodes: []string{"a", "b"}, Created: now, Updated: now}))
+		require.NoError(t, ss.Save(ctx, &userMFA{UserID: 1, Secret: []byte("other"), Enabled: true, RecoveryCodes: []string{"c"}, Created: now, Updated: now}))
+
+		m, err := ss.Get(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, []byte("other"), m.Secret)
+		require.True(t, m.Enabled)
+		require.Equal(t, []string{"c"}, m.RecoveryCodes)
+	})
+
+	t.Run("uses each step once", func(t *testing.T) {
+		used, err := ss.UseStep(ctx, 1, 10)
+		require.NoError(t, err)
+		require.True(t, used)
+
+		used, err = ss.UseStep(ctx, 1, 10)
+		require.NoError(t, err)
+		require.False(t, used)
+
+		used, err = ss.UseStep(ctx, 1, 9)
+		require.NoError(t, err)
+		require.False(t, used)
+	})
+
+	t.Run("deletes enrollments", func(t *testing.T) {
+		require.NoError(t, ss.Delete(ctx, 1))
+		_, err := ss.Get(ctx, 1)
+		require.ErrorIs(t, err, mfa.ErrNotEnrolled)
+	})
+
+	t.Run("sets organization policies", func(t *testing.T) {
+		require.NoError(t, ss.SetOrgPolicy(ctx, &mfa.OrgPolicy{OrgID: 1, Enforcement: mfa.EnforcementAdmins, BasicAuth: mfa.BasicAuthCode, Updated: now}))
+		require.NoError(t, ss.SetOrgPolicy(ctx, &mfa.OrgPolicy{OrgID: 1, Enforcement: mfa.EnforcementAll, BasicAuth: mfa.BasicAuthDeny, Updated: now}))
+		require.NoError(t, ss.SetOrgPolicy(ctx, &mfa.OrgPolicy{OrgID: 2, Enforcement: mfa.EnforcementOptional, BasicAuth: mfa.BasicAuthAllow, Updated: now}))
+
+		policies, err := ss.GetOrgPolicies(ctx, []int64{1, 3})
+		require.NoError(t, err)
+		require.Len(t, policies, 1)
+		require.Equal(t, mfa.EnforcementAll, policies[0].Enforcement)
+		require.Equal(t, mfa.BasicAuthDeny, policies[0].BasicAuth)
+	})
+}
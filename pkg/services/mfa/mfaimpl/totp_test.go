@@ -0,0 +1,88 @@
+package mfaimpl
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHOTP(t *testing.T) {
+	// Test vectors of RFC 6238 truncated to 6 digits
+	key := []byte("12345678901234567890")
+	tests := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, code := range tests {
+		require.Equal(t, code, hotp(key, totpStep(time.Unix(unix, 0))), "time %d", unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := secretEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+
+	step, ok, err := validateTOTP(secret, "081804", now)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, totpStep(now), step)
+
+	t.Run("accepts the codes of the previous and next periods", func(t *testing.T) {
+		step, ok, err := validateTOTP(secret, "081804", now.Add(totpPeriod*time.Second))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, totpStep(now), step)
+
+		_, ok, err = validateTOTP(secret, "081804", now.Add(-totpPeriod*time.Second))
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("rejects older codes and invalid codes", func(t *testing.T) {
+		_, ok, err := validateTOTP(secret, "081804", now.Add(3*totpPeriod*time.Second))
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		_, ok, err = validateTOTP(secret, "000000", now)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		_, ok, err = validateTOTP(secret, "0818", now)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("fails with invalid secret", func(t *testing.T) {
+		_, _, err := validateTOTP("!", "081804", now)
+		require.Error(t, err)
+	})
+}
+
+func TestProvisioningURI(t *testing.T) {
+	u, err := url.Parse(provisioningURI("Grafana", "admin", "JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", u.Scheme)
+	require.Equal(t, "totp", u.Host)
+	require.Equal(t, "/Grafana:admin", u.Path)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+	require.Equal(t, "Grafana", u.Query().Get("issuer"))
+	require.Equal(t, "6", u.Query().Get("digits"))
+	require.Equal(t, "30", u.Query().Get("period"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Len(t, hashes, recoveryCodeCount)
+	require.Regexp(t, `^[0-9a-f]{5}-[0-9a-f]{5}$`, codes[0])
+
+	require.Equal(t, 3, matchRecoveryCode(hashes, codes[3]))
+	require.Equal(t, -1, matchRecoveryCode(hashes, "00000-00000"))
+}
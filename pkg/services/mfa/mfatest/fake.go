@@ -0,0 +1,62 @@
+package mfatest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/mfa"
+)
+
+var _ mfa.Service = new(FakeService)
+
+type FakeService struct {
+	ExpectedEnabled    bool
+	ExpectedStatus     *mfa.Status
+	ExpectedEnrollment *mfa.Enrollment
+	ExpectedCodes      []string
+	ExpectedPolicy     *mfa.OrgPolicy
+	ExpectedErr        error
+
+	// VerifyLoginCmd is the last command passed to VerifyLogin
+	VerifyLoginCmd *mfa.VerifyLoginCommand
+}
+
+func (f *FakeService) IsEnabled() bool {
+	return f.ExpectedEnabled
+}
+
+func (f *FakeService) VerifyLogin(ctx context.Context, cmd *mfa.VerifyLoginCommand) error {
+	f.VerifyLoginCmd = cmd
+	return f.ExpectedErr
+}
+
+func (f *FakeService) GetStatus(ctx context.Context, userID int64) (*mfa.Status, error) {
+	return f.ExpectedStatus, f.ExpectedErr
+}
+
+func (f *FakeService) Enroll(ctx context.Context, userID int64, login string) (*mfa.Enrollment, error) {
+	return f.ExpectedEnrollment, f.ExpectedErr
+}
+
+func (f *FakeService) Confirm(ctx context.Context, userID int64, code string) error {
+	return f.ExpectedErr
+}
+
+func (f *FakeService) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	return f.ExpectedCodes, f.ExpectedErr
+}
+
+func (f *FakeService) Disable(ctx context.Context, userID int64, code string) error {
+	return f.ExpectedErr
+}
+
+func (f *FakeService) Reset(ctx context.Context, userID int64) error {
+	return f.ExpectedErr
+}
+
+func (f *FakeService) GetOrgPolicy(ctx context.Context, orgID int64) (*mfa.OrgPolicy, error) {
+	return f.ExpectedPolicy, f.ExpectedErr
+}
+
+func (f *FakeService) SetOrgPolicy(ctx context.Context, cmd *mfa.SetOrgPolicyCommand) error {
+	return f.ExpectedErr
+}
@@ -0,0 +1,119 @@
+package mfa
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// CodeHeader is the header of basic authenticated requests with the code of the user
+const CodeHeader = "X-Grafana-MFA-Code"
+
+var (
+	ErrCodeRequired = errutil.NewBase(errutil.StatusUnauthorized, "mfa.codeRequired", errutil.WithPublicMessage("Multi-factor authentication code required"))
+	ErrInvalidCode  = errutil.NewBase(errutil.StatusBadRequest, "mfa.invalidCode", errutil.WithPublicMessage("Invalid multi-factor authentication code"))
+	// ErrEnrollmentRequired carries the new enrollment of the user in its public payload
+	ErrEnrollmentRequired = errutil.NewBase(errutil.StatusUnauthorized, "mfa.enrollmentRequired").MustTemplate(
+		"multi-factor authentication enrollment required",
+		errutil.WithPublic("Multi-factor authentication enrollment required"),
+	)
+	ErrBasicAuthDenied  = errutil.NewBase(errutil.StatusUnauthorized, "mfa.basicAuthDenied", errutil.WithPublicMessage("Basic authentication is not allowed for this user"))
+	ErrRequiredByPolicy = errutil.NewBase(errutil.StatusForbidden, "mfa.requiredByPolicy", errutil.WithPublicMessage("Multi-factor authentication is required by the organization policy"))
+	ErrNotEnrolled      = errutil.NewBase(errutil.StatusBadRequest, "mfa.notEnrolled", errutil.WithPublicMessage("Multi-factor authentication isn't enabled for this user"))
+	ErrAlreadyEnrolled  = errutil.NewBase(errutil.StatusBadRequest, "mfa.alreadyEnrolled", errutil.WithPublicMessage("Multi-factor authentication is already enabled for this user"))
+	ErrInvalidPolicy    = errutil.NewBase(errutil.StatusBadRequest, "mfa.invalidPolicy", errutil.WithPublicMessage("Invalid multi-factor authentication policy"))
+)
+
+// Enforcement defines which users of an organization must use multi-factor authentication
+type Enforcement string
+
+const (
+	EnforcementOptional Enforcement = "optional"
+	EnforcementAdmins   Enforcement = "admins"
+	EnforcementAll      Enforcement = "all"
+)
+
+func (e Enforcement) IsValid() bool {
+	return e == EnforcementOptional || e == EnforcementAdmins || e == EnforcementAll
+}
+
+// BasicAuthPolicy defines how basic authenticated requests of users with multi-factor
+// authentication are handled
+type BasicAuthPolicy string
+
+const (
+	// BasicAuthCode requires the code in the CodeHeader header
+	BasicAuthCode BasicAuthPolicy = "code"
+	// BasicAuthDeny rejects the requests
+	BasicAuthDeny BasicAuthPolicy = "deny"
+	// BasicAuthAllow accepts the requests with the password only
+	BasicAuthAllow BasicAuthPolicy = "allow"
+)
+
+func (p BasicAuthPolicy) IsValid() bool {
+	return p == BasicAuthCode || p == BasicAuthDeny || p == BasicAuthAllow
+}
+
+// Strictest returns the strictest of two policies
+func (p BasicAuthPolicy) Strictest(other BasicAuthPolicy) BasicAuthPolicy {
+	rank := func(p BasicAuthPolicy) int {
+		switch p {
+		case BasicAuthDeny:
+			return 2
+		case BasicAuthCode:
+			return 1
+		default:
+			return 0
+		}
+	}
+	if rank(other) > rank(p) {
+		return other
+	}
+	return p
+}
+
+// OrgPolicy is the multi-factor authentication policy of an organization
+type OrgPolicy struct {
+	ID          int64           `xorm:"pk autoincr 'id'" json:"-"`
+	OrgID       int64           `xorm:"org_id" json:"orgId"`
+	Enforcement Enforcement     `xorm:"enforcement" json:"enforcement"`
+	BasicAuth   BasicAuthPolicy `xorm:"basic_auth" json:"basicAuth"`
+	Updated     time.Time       `xorm:"'updated'" json:"updated"`
+}
+
+func (p OrgPolicy) TableName() string {
+	return "org_mfa_policy"
+}
+
+type SetOrgPolicyCommand struct {
+	OrgID       int64           `json:"-"`
+	Enforcement Enforcement     `json:"enforcement"`
+	BasicAuth   BasicAuthPolicy `json:"basicAuth"`
+}
+
+type VerifyLoginCommand struct {
+	UserID int64
+	Login  string
+	// Code is the TOTP code or a recovery code
+	Code string
+	// BasicAuth is true for basic authenticated requests, which can't enroll users
+	BasicAuth bool
+}
+
+// Status is the multi-factor authentication status of a user
+type Status struct {
+	Enabled bool `json:"enabled"`
+	// Pending is true when the enrollment of the user isn't confirmed yet
+	Pending bool `json:"pending"`
+	// Required is true when a policy requires multi-factor authentication for the user
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+// Enrollment is the secret of a new enrollment with its recovery codes,
+// which are only returned once
+type Enrollment struct {
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioningUri"`
+	RecoveryCodes   []string `json:"recoveryCodes"`
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -41,6 +42,7 @@ type RulerSrv struct {
 	cfg                *setting.UnifiedAlertingSettings
 	ac                 accesscontrol.AccessControl
 	conditionValidator ConditionValidator
+	datasourceCache    datasources.CacheService
 }
 
 var (
@@ -308,6 +310,16 @@ func (srv RulerSrv) RoutePostNameRulesConfig(c *contextmodel.ReqContext, ruleGro
 		return ErrResp(http.StatusBadRequest, err, "")
 	}
 
+	datasourceType := datasourceTypeLookup(c.Req.Context(), srv.datasourceCache, c.SignedInUser)
+	for _, r := range ruleGroupConfig.Rules {
+		if r.GrafanaManagedAlert == nil {
+			continue
+		}
+		if err := validatePrometheusQueries(r.GrafanaManagedAlert.Data, datasourceType); err != nil {
+			return ErrResp(http.StatusBadRequest, fmt.Errorf("%w: %s", ngmodels.ErrAlertRuleFailedValidation, err.Error()), "")
+		}
+	}
+
 	groupKey := ngmodels.AlertRuleGroupKey{
 		OrgID:        c.SignedInUser.OrgID,
 		NamespaceUID: namespace.UID,
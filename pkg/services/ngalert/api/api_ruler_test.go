@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	dsfakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/folder"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -599,6 +600,7 @@ func createService(store *fakes.RuleStore) *RulerSrv {
 		log:             log.New("test"),
 		cfg:             nil,
 		ac:              acimpl.ProvideAccessControl(setting.NewCfg()),
+		datasourceCache: &dsfakes.FakeCacheService{},
 	}
 }
 
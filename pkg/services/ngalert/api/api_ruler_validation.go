@@ -1,17 +1,23 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/folder"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/lint"
 )
 
 // validateRuleNode validates API model (definitions.PostableExtendedRuleNode) and converts it to models.AlertRule
@@ -142,6 +148,52 @@ func validateCondition(condition string, queries []apimodels.AlertQuery) error {
 	return nil
 }
 
+// validatePrometheusQueries checks the PromQL syntax of queries to Prometheus data sources,
+// so that invalid rules are rejected when they are saved rather than failing at every evaluation.
+// The type of the data source is resolved by datasourceType because the query model does not always carry it.
+func validatePrometheusQueries(queries []apimodels.AlertQuery, datasourceType func(uid string) (string, error)) error {
+	for _, query := range queries {
+		if len(query.Model) == 0 || query.DatasourceUID == "" || expr.IsDataSource(query.DatasourceUID) {
+			continue
+		}
+		dsType, err := datasourceType(query.DatasourceUID)
+		if err != nil {
+			// Missing data sources are reported when the rule is evaluated, this is only a syntax check
+			continue
+		}
+		if dsType != datasources.DS_PROMETHEUS {
+			continue
+		}
+		var model struct {
+			Expr string `json:"expr"`
+		}
+		if err := json.Unmarshal(query.Model, &model); err != nil || model.Expr == "" {
+			continue
+		}
+		if err := lint.Lint(model.Expr).Err(); err != nil {
+			return fmt.Errorf("invalid PromQL in query %s: %w", query.RefID, err)
+		}
+	}
+	return nil
+}
+
+// datasourceTypeLookup returns a function that resolves the type of a data source by its UID.
+// Results are memoized so that a group with many rules queries the cache only once per data source.
+func datasourceTypeLookup(ctx context.Context, cache datasources.CacheService, user *user.SignedInUser) func(uid string) (string, error) {
+	types := make(map[string]string)
+	return func(uid string) (string, error) {
+		if t, ok := types[uid]; ok {
+			return t, nil
+		}
+		ds, err := cache.GetDatasourceByUID(ctx, uid, user, false)
+		if err != nil {
+			return "", err
+		}
+		types[uid] = ds.Type
+		return ds.Type, nil
+	}
+}
+
 func validateInterval(cfg *setting.UnifiedAlertingSettings, interval time.Duration) (int64, error) {
 	intervalSeconds := int64(interval.Seconds())
 
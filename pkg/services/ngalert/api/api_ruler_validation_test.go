@@ -11,6 +11,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/exp/rand"
 
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/folder"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -182,6 +184,73 @@ func TestValidateCondition(t *testing.T) {
 	}
 }
 
+func TestValidatePrometheusQueries(t *testing.T) {
+	datasourceType := func(uid string) (string, error) {
+		switch uid {
+		case "prom":
+			return datasources.DS_PROMETHEUS, nil
+		case "loki":
+			return datasources.DS_LOKI, nil
+		}
+		return "", datasources.ErrDataSourceNotFound
+	}
+
+	testCases := []struct {
+		name          string
+		datasourceUID string
+		model         string
+		isValid       bool
+	}{
+		{
+			name:          "valid promql",
+			datasourceUID: "prom",
+			model:         `{"datasource": {"type": "prometheus", "uid": "prom"}, "expr": "sum by (job) (rate(http_requests_total[$__rate_interval])) > 0"}`,
+			isValid:       true,
+		},
+		{
+			name:          "invalid promql",
+			datasourceUID: "prom",
+			model:         `{"datasource": {"type": "prometheus", "uid": "prom"}, "expr": "sum(rate(http_requests_total[5m])"}`,
+			isValid:       false,
+		},
+		{
+			name:          "invalid promql without data source in the model",
+			datasourceUID: "prom",
+			model:         `{"expr": "sum(rate(http_requests_total[5m])"}`,
+			isValid:       false,
+		},
+		{
+			name:          "other data source with expr",
+			datasourceUID: "loki",
+			model:         `{"datasource": {"type": "loki", "uid": "loki"}, "expr": "{job=\"api\"} |= \"error\""}`,
+			isValid:       true,
+		},
+		{
+			name:          "unknown data source",
+			datasourceUID: "missing",
+			model:         `{"expr": "sum("}`,
+			isValid:       true,
+		},
+		{
+			name:          "expression",
+			datasourceUID: expr.DatasourceUID,
+			model:         `{"type": "math", "expression": "$A > 0"}`,
+			isValid:       true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePrometheusQueries([]apimodels.AlertQuery{{RefID: "A", DatasourceUID: tc.datasourceUID, Model: []byte(tc.model)}}, datasourceType)
+			if tc.isValid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, "invalid PromQL in query A")
+			}
+		})
+	}
+}
+
 func TestValidateRuleGroup(t *testing.T) {
 	orgId := rand.Int63()
 	folder := randFolder()
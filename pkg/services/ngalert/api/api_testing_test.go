@@ -271,6 +271,9 @@ func createTestingApiSrv(t *testing.T, ds *fakes.FakeCacheService, ac *acMock.Mo
 	if ac == nil {
 		ac = acMock.New().WithDisabled()
 	}
+	if ds == nil {
+		ds = &fakes.FakeCacheService{}
+	}
 
 	return &TestingApiSrv{
 		DatasourceCache: ds,
@@ -12,6 +12,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/auditlog"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels_config"
@@ -230,6 +231,13 @@ func (ecp *ContactPointService) CreateContactPoint(ctx context.Context, orgID in
 	for k := range extractedSecrets {
 		contactPoint.Settings.Set(k, apimodels.RedactedValue)
 	}
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        orgID,
+		Action:       auditlog.ActionCreate,
+		ResourceKind: auditResourceKindContactPoint,
+		ResourceUID:  contactPoint.UID,
+		After:        contactPoint,
+	})
 	return contactPoint, nil
 }
 
@@ -266,6 +274,7 @@ func (ecp *ContactPointService) UpdateContactPoint(ctx context.Context, orgID in
 	if storedProvenance != provenance && storedProvenance != models.ProvenanceNone {
 		return fmt.Errorf("cannot change provenance from '%s' to '%s'", storedProvenance, provenance)
 	}
+	audited := redactedContactPoint(contactPoint)
 	// transform to internal model
 	extractedSecrets, err := RemoveSecretsForContactPoint(&contactPoint)
 	if err != nil {
@@ -306,7 +315,7 @@ func (ecp *ContactPointService) UpdateContactPoint(ctx context.Context, orgID in
 	if err != nil {
 		return err
 	}
-	return ecp.xact.InTransaction(ctx, func(ctx context.Context) error {
+	err = ecp.xact.InTransaction(ctx, func(ctx context.Context) error {
 		err = PersistConfig(ctx, ecp.amStore, &models.SaveAlertmanagerConfigurationCmd{
 			AlertmanagerConfiguration: string(data),
 			FetchedConfigurationHash:  revision.concurrencyToken,
@@ -324,9 +333,29 @@ func (ecp *ContactPointService) UpdateContactPoint(ctx context.Context, orgID in
 		contactPoint.Provenance = string(provenance)
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        orgID,
+		Action:       auditlog.ActionUpdate,
+		ResourceKind: auditResourceKindContactPoint,
+		ResourceUID:  contactPoint.UID,
+		Before:       redactedContactPoint(rawContactPoint),
+		After:        audited,
+	})
+	return nil
 }
 
 func (ecp *ContactPointService) DeleteContactPoint(ctx context.Context, orgID int64, uid string) error {
+	var before *apimodels.EmbeddedContactPoint
+	if auditlog.IsRecording(ctx) {
+		if stored, err := ecp.getContactPointDecrypted(ctx, orgID, uid); err == nil {
+			redacted := redactedContactPoint(stored)
+			before = &redacted
+		}
+	}
+
 	revision, err := getLastConfiguration(ctx, orgID, ecp.amStore)
 	if err != nil {
 		return err
@@ -359,7 +388,7 @@ func (ecp *ContactPointService) DeleteContactPoint(ctx context.Context, orgID in
 	if err != nil {
 		return err
 	}
-	return ecp.xact.InTransaction(ctx, func(ctx context.Context) error {
+	err = ecp.xact.InTransaction(ctx, func(ctx context.Context) error {
 		target := &apimodels.EmbeddedContactPoint{
 			UID: uid,
 		}
@@ -375,6 +404,50 @@ func (ecp *ContactPointService) DeleteContactPoint(ctx context.Context, orgID in
 			OrgID:                     orgID,
 		})
 	})
+	if err != nil {
+		return err
+	}
+	if before != nil {
+		auditlog.RecordChange(ctx, auditlog.Change{
+			OrgID:        orgID,
+			Action:       auditlog.ActionDelete,
+			ResourceKind: auditResourceKindContactPoint,
+			ResourceUID:  uid,
+			Before:       before,
+		})
+	}
+	return nil
+}
+
+// auditResourceKindContactPoint is the kind of contact points in the audit log
+const auditResourceKindContactPoint = "contact-points"
+
+// redactedContactPoint returns a copy of a contact point with its secure settings redacted, to be
+// recorded in the audit log.
+func redactedContactPoint(contactPoint apimodels.EmbeddedContactPoint) apimodels.EmbeddedContactPoint {
+	redacted := contactPoint
+	if contactPoint.Settings == nil {
+		return redacted
+	}
+	data, err := contactPoint.Settings.MarshalJSON()
+	if err != nil {
+		redacted.Settings = nil
+		return redacted
+	}
+	if redacted.Settings, err = simplejson.NewJson(data); err != nil {
+		redacted.Settings = nil
+		return redacted
+	}
+	secretKeys, err := GetSecretKeysForContactPointType(contactPoint.Type)
+	if err != nil {
+		return redacted
+	}
+	for _, k := range secretKeys {
+		if _, ok := redacted.Settings.CheckGet(k); ok {
+			redacted.Settings.Set(k, apimodels.RedactedValue)
+		}
+	}
+	return redacted
 }
 
 func isContactPointInUse(name string, routes []*apimodels.Route) bool {
@@ -16,6 +16,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/search/model"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
+	storesrv "github.com/grafana/grafana/pkg/services/store"
 	"github.com/grafana/grafana/pkg/services/store/entity"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/util"
@@ -66,10 +67,27 @@ func (st DBstore) DeleteAlertRulesByUID(ctx context.Context, orgID int64, ruleUI
 			return err
 		}
 		logger.Debug("deleted alert instances", "count", rows)
-		return nil
+		return st.insertEntityEvents(sess, orgID, storesrv.EntityEventTypeDelete, ruleUID...)
 	})
 }
 
+// insertEntityEvents records the changes of alert rules for the search index.
+func (st DBstore) insertEntityEvents(sess *db.Session, orgID int64, eventType storesrv.EntityEventType, ruleUIDs ...string) error {
+	if st.FeatureToggles == nil || !st.FeatureToggles.IsEnabled(featuremgmt.FlagPanelTitleSearch) {
+		return nil
+	}
+	for _, uid := range ruleUIDs {
+		if _, err := sess.Insert(&storesrv.EntityEvent{
+			EventType: eventType,
+			EntityId:  storesrv.CreateDatabaseEntityId(uid, orgID, storesrv.EntityTypeAlertRule),
+			Created:   TimeNow().Unix(),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // IncreaseVersionForAllRulesInNamespace Increases version for all rules that have specified namespace. Returns all rules that belong to the namespace
 func (st DBstore) IncreaseVersionForAllRulesInNamespace(ctx context.Context, orgID int64, namespaceUID string) ([]ngmodels.AlertRuleKeyWithVersionAndPauseStatus, error) {
 	var keys []ngmodels.AlertRuleKeyWithVersionAndPauseStatus
@@ -168,6 +186,9 @@ func (st DBstore) InsertAlertRules(ctx context.Context, rules []ngmodels.AlertRu
 					return fmt.Errorf("failed to create new rules: %w", err)
 				}
 				ids[newRules[i].UID] = newRules[i].ID
+				if err := st.insertEntityEvents(sess, newRules[i].OrgID, storesrv.EntityEventTypeCreate, newRules[i].UID); err != nil {
+					return err
+				}
 			}
 		}
 
@@ -209,6 +230,9 @@ func (st DBstore) UpdateAlertRules(ctx context.Context, rules []ngmodels.UpdateR
 				}
 				return fmt.Errorf("%w: alert rule UID %s version %d", ErrOptimisticLock, r.New.UID, r.New.Version)
 			}
+			if err := st.insertEntityEvents(sess, r.New.OrgID, storesrv.EntityEventTypeUpdate, r.New.UID); err != nil {
+				return err
+			}
 			parentVersion = r.Existing.Version
 			ruleVersions = append(ruleVersions, ngmodels.AlertRuleVersion{
 				RuleOrgID:        r.New.OrgID,
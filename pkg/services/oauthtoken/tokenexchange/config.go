@@ -0,0 +1,67 @@
+package tokenexchange
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Token types of RFC 8693
+const (
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeIDToken     = "urn:ietf:params:oauth:token-type:id_token"
+
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+)
+
+// Data source JSON data keys of the token exchange configuration
+const (
+	jsonDataEnabled          = "oauthTokenExchange"
+	jsonDataAudience         = "oauthTokenExchangeAudience"
+	jsonDataScopes           = "oauthTokenExchangeScopes"
+	jsonDataSubjectTokenType = "oauthTokenExchangeSubjectTokenType"
+)
+
+// Config is the token exchange configuration of a data source
+type Config struct {
+	// Audience is the logical name of the data source at the OAuth provider
+	Audience string
+	Scopes   []string
+	// SubjectTokenType is the type of the user's token exchanged, an access token or an ID token
+	SubjectTokenType string
+}
+
+// ConfigFromJSONData returns the token exchange configuration of a data source,
+// or nil when token exchange isn't enabled
+func ConfigFromJSONData(jsonData map[string]interface{}) (*Config, error) {
+	if enabled, _ := jsonData[jsonDataEnabled].(bool); !enabled {
+		return nil, nil
+	}
+
+	cfg := &Config{
+		SubjectTokenType: TokenTypeAccessToken,
+	}
+	cfg.Audience, _ = jsonData[jsonDataAudience].(string)
+	if scopes, ok := jsonData[jsonDataScopes].(string); ok {
+		cfg.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+		sort.Strings(cfg.Scopes)
+	}
+
+	switch tokenType, _ := jsonData[jsonDataSubjectTokenType].(string); tokenType {
+	case "", "access_token":
+	case "id_token":
+		cfg.SubjectTokenType = TokenTypeIDToken
+	default:
+		return nil, fmt.Errorf("invalid subject token type %q", tokenType)
+	}
+
+	if cfg.Audience == "" && len(cfg.Scopes) == 0 {
+		return nil, fmt.Errorf("token exchange requires an audience or scopes")
+	}
+	return cfg, nil
+}
+
+// cacheKey identifies the tokens exchanged with the configuration for a user of an OAuth provider
+func (c *Config) cacheKey(userID int64, authModule string) string {
+	return fmt.Sprintf("%d|%s|%s|%s|%s", userID, authModule, c.Audience, strings.Join(c.Scopes, " "), c.SubjectTokenType)
+}
@@ -0,0 +1,52 @@
+package tokenexchange
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+)
+
+// MiddlewareName the middleware name used by Middleware.
+const MiddlewareName = "oauth-token-exchange"
+
+// Middleware sets the token exchanged for the user of the request on the outgoing requests of
+// data sources with token exchange enabled. The configuration is read from the data source
+// JSON data of the client options.
+func Middleware(service TokenExchangeService) httpclient.Middleware {
+	return httpclient.NamedMiddlewareFunc(MiddlewareName, func(opts httpclient.Options, next http.RoundTripper) http.RoundTripper {
+		cfg, err := ConfigFromJSONData(backend.JSONDataFromHTTPClientOptions(opts))
+		if err != nil {
+			return invalidConfig(err)
+		}
+		if cfg == nil {
+			return next
+		}
+
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			usr, err := appcontext.User(req.Context())
+			if err != nil {
+				return nil, ErrNoOAuthIdentity.Errorf("%w", err)
+			}
+			token, err := service.GetToken(req.Context(), usr, cfg)
+			if err != nil {
+				return nil, err
+			}
+
+			req = req.Clone(req.Context())
+			req.Header.Set("Authorization", fmt.Sprintf("%s %s", token.Type(), token.AccessToken))
+			// the ID token forwarded with the OAuth identity isn't meant for the data source
+			req.Header.Del("X-ID-Token")
+			return next.RoundTrip(req)
+		})
+	})
+}
+
+func invalidConfig(err error) http.RoundTripper {
+	return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, fmt.Errorf("invalid OAuth token exchange configuration: %w", err)
+	})
+}
@@ -0,0 +1,263 @@
+package tokenexchange
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/login/social"
+	"github.com/grafana/grafana/pkg/services/oauthtoken"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrNoOAuthIdentity = errutil.NewBase(errutil.StatusForbidden, "oauthtoken.exchange.noIdentity",
+		errutil.WithPublicMessage("Data source requires a user logged in with OAuth"))
+	ErrExchangeFailed = errutil.NewBase(errutil.StatusForbidden, "oauthtoken.exchange.failed",
+		errutil.WithPublicMessage("Failed to exchange the OAuth token for the data source"))
+)
+
+const (
+	// defaultTokenLifetime applies to exchanged tokens without expires_in
+	defaultTokenLifetime = 5 * time.Minute
+	// refreshTokenTTL is how long exchanged tokens with a refresh token are kept
+	refreshTokenTTL = 24 * time.Hour
+	maxResponseSize = 1 << 20
+)
+
+// TokenExchangeService exchanges the OAuth token of users for tokens of data sources (RFC 8693)
+type TokenExchangeService interface {
+	// GetToken returns a token of the data source for the user, exchanged with the client of the
+	// OAuth provider the user logged in with. Tokens are cached per user and refreshed when they expire.
+	GetToken(ctx context.Context, usr *user.SignedInUser, cfg *Config) (*oauth2.Token, error)
+}
+
+var _ TokenExchangeService = (*Service)(nil)
+
+type Service struct {
+	socialService     social.Service
+	oauthTokenService oauthtoken.OAuthTokenService
+	cache             *localcache.CacheService
+	singleFlightGroup *singleflight.Group
+	log               log.Logger
+	now               func() time.Time
+}
+
+func ProvideService(socialService social.Service, oauthTokenService oauthtoken.OAuthTokenService) *Service {
+	return &Service{
+		socialService:     socialService,
+		oauthTokenService: oauthTokenService,
+		cache:             localcache.New(defaultTokenLifetime, 10*time.Minute),
+		singleFlightGroup: new(singleflight.Group),
+		log:               log.New("oauthtoken.exchange"),
+		now:               time.Now,
+	}
+}
+
+func (s *Service) GetToken(ctx context.Context, usr *user.SignedInUser, cfg *Config) (*oauth2.Token, error) {
+	authInfo, ok, err := s.oauthTokenService.HasOAuthEntry(ctx, usr)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNoOAuthIdentity.Errorf("user isn't logged in with an OAuth provider")
+	}
+
+	key := cfg.cacheKey(usr.UserID, authInfo.AuthModule)
+	token, err, _ := s.singleFlightGroup.Do(key, func() (interface{}, error) {
+		return s.getToken(ctx, usr, authInfo.AuthModule, key, cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token.(*oauth2.Token), nil
+}
+
+func (s *Service) getToken(ctx context.Context, usr *user.SignedInUser, authModule, key string, cfg *Config) (*oauth2.Token, error) {
+	cached, _ := s.cache.Get(key)
+	token, _ := cached.(*oauth2.Token)
+	if token != nil && s.now().Add(oauthtoken.ExpiryDelta).Before(token.Expiry) {
+		return token, nil
+	}
+
+	client, err := s.newClient(authModule, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if token != nil && token.RefreshToken != "" {
+		refreshed, err := client.refresh(ctx, token.RefreshToken)
+		if err == nil {
+			s.store(key, refreshed)
+			return refreshed, nil
+		}
+		// the refresh token may be expired or revoked, a new token is exchanged
+		s.log.FromContext(ctx).Debug("Failed to refresh exchanged token", "userId", usr.UserID, "error", err)
+	}
+
+	subject := s.oauthTokenService.GetCurrentOAuthToken(ctx, usr)
+	if subject == nil {
+		return nil, ErrNoOAuthIdentity.Errorf("no OAuth token found for the user")
+	}
+	subjectToken := subject.AccessToken
+	if cfg.SubjectTokenType == TokenTypeIDToken {
+		subjectToken, _ = subject.Extra("id_token").(string)
+	}
+	if subjectToken == "" {
+		return nil, ErrNoOAuthIdentity.Errorf("no subject token of type %s found for the user", cfg.SubjectTokenType)
+	}
+
+	token, err = client.exchange(ctx, subjectToken)
+	if err != nil {
+		return nil, err
+	}
+	s.log.FromContext(ctx).Debug("Exchanged OAuth token", "userId", usr.UserID, "audience", cfg.Audience, "expiry", token.Expiry)
+	s.store(key, token)
+	return token, nil
+}
+
+func (s *Service) store(key string, token *oauth2.Token) {
+	ttl := token.Expiry.Sub(s.now())
+	if token.RefreshToken != "" {
+		ttl = refreshTokenTTL
+	}
+	s.cache.Set(key, token, ttl)
+}
+
+func (s *Service) newClient(authModule string, cfg *Config) (*client, error) {
+	info := s.socialService.GetOAuthInfoProvider(strings.TrimPrefix(authModule, "oauth_"))
+	if info == nil {
+		return nil, ErrExchangeFailed.Errorf("no settings found for OAuth provider %s", authModule)
+	}
+	httpClient, err := s.socialService.GetOAuthHttpClient(authModule)
+	if err != nil {
+		return nil, err
+	}
+
+	// the token endpoint only comes from the configuration of the provider: the requests
+	// carry the client secret and the user's token, data sources can't change where they go
+	return &client{
+		httpClient:   httpClient,
+		tokenURL:     info.TokenUrl,
+		clientID:     info.ClientId,
+		clientSecret: info.ClientSecret,
+		cfg:          cfg,
+		now:          s.now,
+	}, nil
+}
+
+// client requests tokens from the token endpoint of an OAuth provider
+type client struct {
+	httpClient   *http.Client
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	cfg          *Config
+	now          func() time.Time
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IssuedTokenType  string `json:"issued_token_type"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (c *client) exchange(ctx context.Context, subjectToken string) (*oauth2.Token, error) {
+	form := url.Values{
+		"grant_type":           {grantTypeTokenExchange},
+		"subject_token":        {subjectToken},
+		"subject_token_type":   {c.cfg.SubjectTokenType},
+		"requested_token_type": {TokenTypeAccessToken},
+	}
+	if c.cfg.Audience != "" {
+		form.Set("audience", c.cfg.Audience)
+	}
+	if len(c.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	}
+	return c.request(ctx, form)
+}
+
+func (c *client) refresh(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}
+	if len(c.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	}
+	token, err := c.request(ctx, form)
+	if err != nil {
+		return nil, err
+	}
+	// providers may keep the refresh token
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+func (c *client) request(ctx context.Context, form url.Values) (*oauth2.Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic, the credentials are form encoded (RFC 6749 section 2.3.1)
+	req.SetBasicAuth(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, ErrExchangeFailed.Errorf("token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, ErrExchangeFailed.Errorf("failed to read token response: %w", err)
+	}
+	var result tokenResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, ErrExchangeFailed.Errorf("invalid token response with status %d: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Error != "" {
+		return nil, ErrExchangeFailed.Errorf("token request failed with status %d: %s %s", resp.StatusCode, result.Error, result.ErrorDescription)
+	}
+	if result.AccessToken == "" {
+		return nil, ErrExchangeFailed.Errorf("token response without access token")
+	}
+	if result.IssuedTokenType != "" && result.IssuedTokenType != TokenTypeAccessToken {
+		return nil, ErrExchangeFailed.Errorf("unexpected issued token type %s", result.IssuedTokenType)
+	}
+
+	// N_A is the token type of tokens that aren't OAuth access tokens, they're sent as bearer tokens
+	tokenType := result.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "N_A") {
+		tokenType = "Bearer"
+	}
+	lifetime := time.Duration(result.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+	return &oauth2.Token{
+		AccessToken:  result.AccessToken,
+		TokenType:    tokenType,
+		RefreshToken: result.RefreshToken,
+		Expiry:       c.now().Add(lifetime),
+	}, nil
+}
@@ -0,0 +1,343 @@
+package tokenexchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/login/social"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/oauthtoken/oauthtokentest"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestConfigFromJSONData(t *testing.T) {
+	t.Run("returns nil when disabled", func(t *testing.T) {
+		cfg, err := ConfigFromJSONData(map[string]interface{}{"oauthTokenExchangeAudience": "prometheus"})
+		require.NoError(t, err)
+		require.Nil(t, cfg)
+	})
+
+	t.Run("parses the configuration", func(t *testing.T) {
+		cfg, err := ConfigFromJSONData(map[string]interface{}{
+			"oauthTokenExchange":                 true,
+			"oauthTokenExchangeAudience":         "prometheus",
+			"oauthTokenExchangeScopes":           "metrics:read, openid",
+			"oauthTokenExchangeSubjectTokenType": "id_token",
+		})
+		require.NoError(t, err)
+		require.Equal(t, &Config{Audience: "prometheus", Scopes: []string{"metrics:read", "openid"}, SubjectTokenType: TokenTypeIDToken}, cfg)
+	})
+
+	t.Run("requires an audience or scopes", func(t *testing.T) {
+		_, err := ConfigFromJSONData(map[string]interface{}{"oauthTokenExchange": true})
+		require.Error(t, err)
+	})
+
+	t.Run("rejects unknown subject token types", func(t *testing.T) {
+		_, err := ConfigFromJSONData(map[string]interface{}{"oauthTokenExchange": true, "oauthTokenExchangeAudience": "prometheus", "oauthTokenExchangeSubjectTokenType": "saml"})
+		require.Error(t, err)
+	})
+}
+
+func TestService_GetToken(t *testing.T) {
+	ctx := context.Background()
+	usr := &user.SignedInUser{UserID: 1, Login: "user"}
+	cfg := &Config{Audience: "prometheus", Scopes: []string{"metrics:read"}, SubjectTokenType: TokenTypeAccessToken}
+
+	t.Run("exchanges the token of the user once", func(t *testing.T) {
+		idp := newMockIdP(t)
+		s := setupTestService(t, idp)
+
+		token, err := s.GetToken(ctx, usr, cfg)
+		require.NoError(t, err)
+		require.Equal(t, "exchanged-1", token.AccessToken)
+		require.Equal(t, "Bearer", token.Type())
+
+		token, err = s.GetToken(ctx, usr, cfg)
+		require.NoError(t, err)
+		require.Equal(t, "exchanged-1", token.AccessToken)
+
+		require.Len(t, idp.requests, 1)
+		request := idp.requests[0]
+		assert.Equal(t, grantTypeTokenExchange, request.Get("grant_type"))
+		assert.Equal(t, "login-token", request.Get("subject_token"))
+		assert.Equal(t, TokenTypeAccessToken, request.Get("subject_token_type"))
+		assert.Equal(t, TokenTypeAccessToken, request.Get("requested_token_type"))
+		assert.Equal(t, "prometheus", request.Get("audience"))
+		assert.Equal(t, "metrics:read", request.Get("scope"))
+	})
+
+	t.Run("sends the requests to the token endpoint of the provider only", func(t *testing.T) {
+		idp := newMockIdP(t)
+		attacker := newMockIdP(t)
+		s := setupTestService(t, idp)
+
+		dsCfg, err := ConfigFromJSONData(map[string]interface{}{
+			"oauthTokenExchange":         true,
+			"oauthTokenExchangeAudience": "prometheus",
+			"oauthTokenExchangeTokenUrl": attacker.server.URL,
+		})
+		require.NoError(t, err)
+
+		_, err = s.GetToken(ctx, usr, dsCfg)
+		require.NoError(t, err)
+		require.Len(t, idp.requests, 1)
+		require.Empty(t, attacker.requests)
+	})
+
+	t.Run("exchanges the ID token when configured", func(t *testing.T) {
+		idp := newMockIdP(t)
+		s := setupTestService(t, idp)
+
+		_, err := s.GetToken(ctx, usr, &Config{Audience: "prometheus", SubjectTokenType: TokenTypeIDToken})
+		require.NoError(t, err)
+		require.Len(t, idp.requests, 1)
+		assert.Equal(t, "login-id-token", idp.requests[0].Get("subject_token"))
+		assert.Equal(t, TokenTypeIDToken, idp.requests[0].Get("subject_token_type"))
+	})
+
+	t.Run("exchanges tokens per audience", func(t *testing.T) {
+		idp := newMockIdP(t)
+		s := setupTestService(t, idp)
+
+		_, err := s.GetToken(ctx, usr, cfg)
+		require.NoError(t, err)
+		token, err := s.GetToken(ctx, usr, &Config{Audience: "loki", SubjectTokenType: TokenTypeAccessToken})
+		require.NoError(t, err)
+		require.Equal(t, "exchanged-2", token.AccessToken)
+		require.Len(t, idp.requests, 2)
+	})
+
+	t.Run("refreshes expired tokens", func(t *testing.T) {
+		idp := newMockIdP(t)
+		s := setupTestService(t, idp)
+
+		_, err := s.GetToken(ctx, usr, cfg)
+		require.NoError(t, err)
+
+		now := s.now()
+		s.now = func() time.Time { return now.Add(2 * time.Minute) }
+		token, err := s.GetToken(ctx, usr, cfg)
+		require.NoError(t, err)
+		require.Equal(t, "exchanged-2", token.AccessToken)
+
+		require.Len(t, idp.requests, 2)
+		assert.Equal(t, "refresh_token", idp.requests[1].Get("grant_type"))
+		assert.Equal(t, "refresh-1", idp.requests[1].Get("refresh_token"))
+	})
+
+	t.Run("exchanges a new token when the refresh fails", func(t *testing.T) {
+		idp := newMockIdP(t)
+		s := setupTestService(t, idp)
+
+		_, err := s.GetToken(ctx, usr, cfg)
+		require.NoError(t, err)
+
+		idp.rejectRefresh = true
+		now := s.now()
+		s.now = func() time.Time { return now.Add(2 * time.Minute) }
+		token, err := s.GetToken(ctx, usr, cfg)
+		require.NoError(t, err)
+		require.Equal(t, "exchanged-3", token.AccessToken)
+
+		require.Len(t, idp.requests, 3)
+		assert.Equal(t, grantTypeTokenExchange, idp.requests[2].Get("grant_type"))
+	})
+
+	t.Run("fails when the provider rejects the exchange", func(t *testing.T) {
+		idp := newMockIdP(t)
+		idp.rejectExchange = true
+		s := setupTestService(t, idp)
+
+		_, err := s.GetToken(ctx, usr, cfg)
+		require.ErrorIs(t, err, ErrExchangeFailed)
+		require.ErrorContains(t, err, "unauthorized_client")
+	})
+
+	t.Run("fails for users not logged in with OAuth", func(t *testing.T) {
+		idp := newMockIdP(t)
+		s := setupTestService(t, idp)
+		s.oauthTokenService = &fakeOAuthTokenService{}
+
+		_, err := s.GetToken(ctx, usr, cfg)
+		require.ErrorIs(t, err, ErrNoOAuthIdentity)
+		require.Empty(t, idp.requests)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	idp := newMockIdP(t)
+	s := setupTestService(t, idp)
+
+	var received http.Header
+	next := httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		received = req.Header
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	middleware := Middleware(s)
+	require.Equal(t, MiddlewareName, middleware.(httpclient.MiddlewareName).MiddlewareName())
+
+	roundTripper := func(jsonData map[string]interface{}) http.RoundTripper {
+		opts := httpclient.Options{CustomOptions: map[string]interface{}{"grafanaData": jsonData}}
+		return middleware.CreateMiddleware(opts, next)
+	}
+
+	t.Run("sets the exchanged token", func(t *testing.T) {
+		rt := roundTripper(map[string]interface{}{"oauthTokenExchange": true, "oauthTokenExchangeAudience": "prometheus"})
+		ctx := appcontext.WithUser(context.Background(), &user.SignedInUser{UserID: 1})
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://prometheus/api/v1/query", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer login-token")
+		req.Header.Set("X-ID-Token", "login-id-token")
+
+		_, err = rt.RoundTrip(req)
+		require.NoError(t, err)
+		require.Equal(t, "Bearer exchanged-1", received.Get("Authorization"))
+		require.Empty(t, received.Get("X-ID-Token"))
+		// the original request isn't modified
+		require.Equal(t, "Bearer login-token", req.Header.Get("Authorization"))
+	})
+
+	t.Run("skips data sources without token exchange", func(t *testing.T) {
+		rt := roundTripper(map[string]interface{}{"oauthPassThru": true})
+		req, err := http.NewRequest(http.MethodGet, "http://prometheus/api/v1/query", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer login-token")
+
+		_, err = rt.RoundTrip(req)
+		require.NoError(t, err)
+		require.Equal(t, "Bearer login-token", received.Get("Authorization"))
+	})
+
+	t.Run("fails without user", func(t *testing.T) {
+		rt := roundTripper(map[string]interface{}{"oauthTokenExchange": true, "oauthTokenExchangeAudience": "prometheus"})
+		req, err := http.NewRequest(http.MethodGet, "http://prometheus/api/v1/query", nil)
+		require.NoError(t, err)
+
+		_, err = rt.RoundTrip(req)
+		require.ErrorIs(t, err, ErrNoOAuthIdentity)
+	})
+
+	t.Run("fails with invalid configuration", func(t *testing.T) {
+		rt := roundTripper(map[string]interface{}{"oauthTokenExchange": true})
+		req, err := http.NewRequest(http.MethodGet, "http://prometheus/api/v1/query", nil)
+		require.NoError(t, err)
+
+		_, err = rt.RoundTrip(req)
+		require.ErrorContains(t, err, "invalid OAuth token exchange configuration")
+	})
+}
+
+func setupTestService(t *testing.T, idp *mockIdP) *Service {
+	t.Helper()
+
+	s := ProvideService(&fakeSocialService{tokenURL: idp.server.URL + "/token"}, &fakeOAuthTokenService{
+		authInfo: &login.UserAuth{UserId: 1, AuthModule: "oauth_generic_oauth"},
+		token: (&oauth2.Token{AccessToken: "login-token", TokenType: "Bearer"}).
+			WithExtra(map[string]interface{}{"id_token": "login-id-token"}),
+	})
+	s.log = log.NewNopLogger()
+	return s
+}
+
+// mockIdP is an OAuth provider supporting token exchange and refresh tokens
+type mockIdP struct {
+	server         *httptest.Server
+	mu             sync.Mutex
+	requests       []url.Values
+	rejectExchange bool
+	rejectRefresh  bool
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	idp := &mockIdP{}
+	idp.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+
+		clientID, secret, ok := r.BasicAuth()
+		if r.URL.Path != "/token" || !ok || clientID != "grafana" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		require.NoError(t, r.ParseForm())
+		idp.requests = append(idp.requests, r.PostForm)
+
+		w.Header().Set("Content-Type", "application/json")
+		grantType := r.PostForm.Get("grant_type")
+		if (grantType == grantTypeTokenExchange && idp.rejectExchange) || (grantType == "refresh_token" && idp.rejectRefresh) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"unauthorized_client","error_description":"client not allowed"}`))
+			return
+		}
+
+		n := len(idp.requests)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":      fmt.Sprintf("exchanged-%d", n),
+			"issued_token_type": TokenTypeAccessToken,
+			"token_type":        "Bearer",
+			"expires_in":        60,
+			"refresh_token":     fmt.Sprintf("refresh-%d", n),
+		})
+	}))
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+type fakeSocialService struct {
+	tokenURL string
+}
+
+func (f *fakeSocialService) GetOAuthProviders() map[string]bool {
+	return map[string]bool{"generic_oauth": true}
+}
+
+func (f *fakeSocialService) GetOAuthHttpClient(string) (*http.Client, error) {
+	return http.DefaultClient, nil
+}
+
+func (f *fakeSocialService) GetConnector(string) (social.SocialConnector, error) {
+	return nil, nil
+}
+
+func (f *fakeSocialService) GetOAuthInfoProvider(name string) *social.OAuthInfo {
+	if name != "generic_oauth" {
+		return nil
+	}
+	return &social.OAuthInfo{ClientId: "grafana", ClientSecret: "secret", TokenUrl: f.tokenURL}
+}
+
+func (f *fakeSocialService) GetOAuthInfoProviders() map[string]*social.OAuthInfo {
+	return map[string]*social.OAuthInfo{"generic_oauth": f.GetOAuthInfoProvider("generic_oauth")}
+}
+
+type fakeOAuthTokenService struct {
+	oauthtokentest.Service
+	authInfo *login.UserAuth
+	token    *oauth2.Token
+}
+
+func (f *fakeOAuthTokenService) GetCurrentOAuthToken(context.Context, *user.SignedInUser) *oauth2.Token {
+	return f.token
+}
+
+func (f *fakeOAuthTokenService) HasOAuthEntry(context.Context, *user.SignedInUser) (*login.UserAuth, bool, error) {
+	return f.authInfo, f.authInfo != nil, nil
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/auditlog"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -113,7 +116,12 @@ func (s *Service) GetUserOrgList(ctx context.Context, query *org.GetUserOrgListQ
 
 // TODO: refactor service to call store CRUD method
 func (s *Service) UpdateOrg(ctx context.Context, cmd *org.UpdateOrgCommand) error {
-	return s.store.Update(ctx, cmd)
+	before := s.auditedOrg(ctx, cmd.OrgId)
+	if err := s.store.Update(ctx, cmd); err != nil {
+		return err
+	}
+	s.recordOrgChange(ctx, cmd.OrgId, before)
+	return nil
 }
 
 // TODO: refactor service to call store CRUD method
@@ -136,7 +144,12 @@ func (s *Service) CreateWithMember(ctx context.Context, cmd *org.CreateOrgComman
 
 // TODO: refactor service to call store CRUD method
 func (s *Service) UpdateAddress(ctx context.Context, cmd *org.UpdateOrgAddressCommand) error {
-	return s.store.UpdateAddress(ctx, cmd)
+	before := s.auditedOrg(ctx, cmd.OrgID)
+	if err := s.store.UpdateAddress(ctx, cmd); err != nil {
+		return err
+	}
+	s.recordOrgChange(ctx, cmd.OrgID, before)
+	return nil
 }
 
 // TODO: refactor service to call store CRUD method
@@ -179,17 +192,50 @@ func (s *Service) GetOrCreate(ctx context.Context, orgName string) (int64, error
 
 // TODO: refactor service to call store CRUD method
 func (s *Service) AddOrgUser(ctx context.Context, cmd *org.AddOrgUserCommand) error {
-	return s.store.AddOrgUser(ctx, cmd)
+	if err := s.store.AddOrgUser(ctx, cmd); err != nil {
+		return err
+	}
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        cmd.OrgID,
+		Action:       auditlog.ActionCreate,
+		ResourceKind: auditResourceKindOrgUser,
+		ResourceUID:  strconv.FormatInt(cmd.UserID, 10),
+		After:        auditedOrgUser{Role: cmd.Role},
+	})
+	return nil
 }
 
 // TODO: refactor service to call store CRUD method
 func (s *Service) UpdateOrgUser(ctx context.Context, cmd *org.UpdateOrgUserCommand) error {
-	return s.store.UpdateOrgUser(ctx, cmd)
+	before := s.auditedOrgUser(ctx, cmd.OrgID, cmd.UserID)
+	if err := s.store.UpdateOrgUser(ctx, cmd); err != nil {
+		return err
+	}
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        cmd.OrgID,
+		Action:       auditlog.ActionUpdate,
+		ResourceKind: auditResourceKindOrgUser,
+		ResourceUID:  strconv.FormatInt(cmd.UserID, 10),
+		Before:       before,
+		After:        auditedOrgUser{Role: cmd.Role},
+	})
+	return nil
 }
 
 // TODO: refactor service to call store CRUD method
 func (s *Service) RemoveOrgUser(ctx context.Context, cmd *org.RemoveOrgUserCommand) error {
-	return s.store.RemoveOrgUser(ctx, cmd)
+	before := s.auditedOrgUser(ctx, cmd.OrgID, cmd.UserID)
+	if err := s.store.RemoveOrgUser(ctx, cmd); err != nil {
+		return err
+	}
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        cmd.OrgID,
+		Action:       auditlog.ActionDelete,
+		ResourceKind: auditResourceKindOrgUser,
+		ResourceUID:  strconv.FormatInt(cmd.UserID, 10),
+		Before:       before,
+	})
+	return nil
 }
 
 // TODO: refactor service to call store CRUD method
@@ -241,3 +287,57 @@ func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
 	limits.Set(userTag, cfg.Quota.User.Org)
 	return limits, nil
 }
+
+// Kinds of resources recorded in the audit log
+const (
+	auditResourceKindOrg     = "orgs"
+	auditResourceKindOrgUser = "org_users"
+)
+
+type auditedOrgUser struct {
+	Role org.RoleType `json:"role"`
+}
+
+// auditedOrg returns the organization to be recorded in the audit log before it's changed.
+func (s *Service) auditedOrg(ctx context.Context, orgID int64) *org.Org {
+	if !auditlog.IsRecording(ctx) {
+		return nil
+	}
+	result, err := s.store.GetByID(ctx, &org.GetOrgByIDQuery{ID: orgID})
+	if err != nil {
+		return nil
+	}
+	return result
+}
+
+func (s *Service) recordOrgChange(ctx context.Context, orgID int64, before *org.Org) {
+	if !auditlog.IsRecording(ctx) {
+		return
+	}
+	auditlog.RecordChange(ctx, auditlog.Change{
+		OrgID:        orgID,
+		Action:       auditlog.ActionUpdate,
+		ResourceKind: auditResourceKindOrg,
+		ResourceUID:  strconv.FormatInt(orgID, 10),
+		Before:       before,
+		After:        s.auditedOrg(ctx, orgID),
+	})
+}
+
+// auditedOrgUser returns the membership of a user to be recorded in the audit log before it's changed.
+func (s *Service) auditedOrgUser(ctx context.Context, orgID, userID int64) *auditedOrgUser {
+	if !auditlog.IsRecording(ctx) {
+		return nil
+	}
+	result, err := s.store.SearchOrgUsers(ctx, &org.SearchOrgUsersQuery{
+		OrgID:                    orgID,
+		UserID:                   userID,
+		Limit:                    1,
+		DontEnforceAccessControl: true,
+		User:                     &user.SignedInUser{OrgID: orgID},
+	})
+	if err != nil || len(result.OrgUsers) == 0 {
+		return nil
+	}
+	return &auditedOrgUser{Role: org.RoleType(result.OrgUsers[0].Role)}
+}
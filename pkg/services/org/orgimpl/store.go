@@ -233,6 +233,11 @@ func (ss *sqlStore) Delete(ctx context.Context, cmd *org.DeleteOrgCommand) error
 			"DELETE FROM alert WHERE org_id = ?",
 			"DELETE FROM annotation WHERE org_id = ?",
 			"DELETE FROM kv_store WHERE org_id = ?",
+			"DELETE FROM org_mfa_policy WHERE org_id = ?",
+			"DELETE FROM scim_external_id WHERE org_id = ?",
+			"DELETE FROM team_external_group WHERE org_id = ?",
+			"DELETE FROM org_session_policy WHERE org_id = ?",
+			"DELETE FROM service_account_token_policy WHERE org_id = ?",
 		}
 
 		for _, sql := range deletes {
@@ -663,6 +668,7 @@ func (ss *sqlStore) RemoveOrgUser(ctx context.Context, cmd *org.RemoveOrgUserCom
 			"DELETE FROM dashboard_acl WHERE org_id=? and user_id = ?",
 			"DELETE FROM team_member WHERE org_id=? and user_id = ?",
 			"DELETE FROM query_history_star WHERE org_id=? and user_id = ?",
+			"DELETE FROM scim_external_id WHERE org_id=? and resource_type='User' and resource_id = ?",
 		}
 
 		for _, sql := range deletes {
@@ -794,6 +800,9 @@ func (ss *sqlStore) userDeletions() []string {
 		"DELETE FROM user_auth WHERE user_id = ?",
 		"DELETE FROM user_auth_token WHERE user_id = ?",
 		"DELETE FROM quota WHERE user_id = ?",
+		"DELETE FROM user_mfa WHERE user_id = ?",
+		"DELETE FROM webauthn_credential WHERE user_id = ?",
+		"DELETE FROM scim_external_id WHERE resource_type = 'User' AND resource_id = ?",
 	}
 	return deletes
 }
@@ -0,0 +1,128 @@
+package accesscontrol
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// stateAbsent is the state of provisioned roles that must be deleted.
+const stateAbsent = "absent"
+
+type configReader interface {
+	readConfig(path string) ([]*rolesAsConfig, error)
+}
+
+type configReaderImpl struct {
+	log log.Logger
+}
+
+func newConfigReader(logger log.Logger) configReader {
+	return &configReaderImpl{log: logger}
+}
+
+func (cr *configReaderImpl) readConfig(path string) ([]*rolesAsConfig, error) {
+	var configs []*rolesAsConfig
+	cr.log.Debug("Looking for access control provisioning files", "path", path)
+
+	files, err := os.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Failed to read access control provisioning files from directory", "path", path, "error", err)
+		return configs, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing access control provisioning file", "path", path, "file.Name", file.Name())
+			cfg, err := cr.parseRolesConfig(path, file)
+			if err != nil {
+				return nil, err
+			}
+
+			if cfg != nil {
+				configs = append(configs, cfg)
+			}
+		}
+	}
+
+	cr.log.Debug("Validating roles")
+	if err := validateRequiredFields(configs); err != nil {
+		return nil, err
+	}
+
+	checkOrgIDAndOrgName(configs)
+
+	return configs, nil
+}
+
+func (cr *configReaderImpl) parseRolesConfig(path string, file fs.DirEntry) (*rolesAsConfig, error) {
+	filename, err := filepath.Abs(filepath.Join(path, file.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *rolesAsConfigV1
+	err = yaml.Unmarshal(yamlFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.mapToRolesFromConfig(), nil
+}
+
+func validateRequiredFields(configs []*rolesAsConfig) error {
+	for i := range configs {
+		var errStrings []string
+		for index, role := range configs[i].Roles {
+			if role.Name == "" && role.UID == "" {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("role item %d in configuration doesn't contain required field name or uid", index+1),
+				)
+			}
+			if role.Global && (role.OrgID != 0 || role.OrgName != "") {
+				errStrings = append(
+					errStrings,
+					fmt.Sprintf("role item %d in configuration is global and can't be assigned an organization", index+1),
+				)
+			}
+		}
+
+		if len(errStrings) != 0 {
+			return errors.New(strings.Join(errStrings, "\n"))
+		}
+	}
+
+	return nil
+}
+
+func checkOrgIDAndOrgName(configs []*rolesAsConfig) {
+	for i := range configs {
+		for _, role := range configs[i].Roles {
+			if role.Global {
+				continue
+			}
+			if role.OrgID < 1 {
+				if role.OrgName == "" {
+					role.OrgID = 1
+				} else {
+					role.OrgID = 0
+				}
+			}
+		}
+	}
+}
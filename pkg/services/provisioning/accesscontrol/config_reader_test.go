@@ -0,0 +1,66 @@
+package accesscontrol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+const (
+	correctRoles = "./testdata/test-configs/roles"
+	invalidRoles = "./testdata/test-configs/invalid"
+	brokenYaml   = "./testdata/test-configs/broken-yaml"
+	missingDir   = "./testdata/test-configs/missing"
+)
+
+func TestConfigReader(t *testing.T) {
+	t.Run("Broken yaml should return error", func(t *testing.T) {
+		reader := newConfigReader(log.New("test logger"))
+		_, err := reader.readConfig(brokenYaml)
+		require.Error(t, err)
+	})
+
+	t.Run("Skip missing directory", func(t *testing.T) {
+		reader := newConfigReader(log.New("test logger"))
+		cfg, err := reader.readConfig(missingDir)
+		require.NoError(t, err)
+		require.Len(t, cfg, 0)
+	})
+
+	t.Run("Invalid roles should return error", func(t *testing.T) {
+		reader := newConfigReader(log.New("test logger"))
+		_, err := reader.readConfig(invalidRoles)
+		require.Error(t, err)
+		require.Equal(t, "role item 1 in configuration doesn't contain required field name or uid\n"+
+			"role item 2 in configuration is global and can't be assigned an organization", err.Error())
+	})
+
+	t.Run("Can read correct properties", func(t *testing.T) {
+		reader := newConfigReader(log.New("test logger"))
+		cfg, err := reader.readConfig(correctRoles)
+		require.NoError(t, err)
+		require.Len(t, cfg, 1)
+
+		roles := cfg[0].Roles
+		require.Len(t, roles, 4)
+		require.Equal(t, &roleFromConfig{
+			OrgID:       1,
+			UID:         "dashboards_creator",
+			Name:        "custom:dashboards:creator",
+			DisplayName: "Dashboard creator",
+			Description: "Create dashboards in the General folder",
+			Version:     2,
+			Permissions: []ac.Permission{{Action: "dashboards:create", Scope: "folders:uid:general"}},
+		}, roles[0])
+		require.True(t, roles[1].Global)
+		require.Equal(t, int64(0), roles[1].OrgID)
+		require.Equal(t, int64(0), roles[2].OrgID)
+		require.Equal(t, "Main Org.", roles[2].OrgName)
+		require.True(t, roles[3].Absent)
+		require.True(t, roles[3].Force)
+		require.Equal(t, int64(1), roles[3].OrgID)
+	})
+}
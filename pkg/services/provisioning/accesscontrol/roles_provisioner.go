@@ -0,0 +1,153 @@
+package accesscontrol
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+// Provision scans a directory for provisioning config files
+// and provisions the custom roles in those files.
+func Provision(ctx context.Context, configDirectory string, roleService ac.RoleService, orgService org.Service) error {
+	logger := log.New("provisioning.accesscontrol")
+	rp := RolesProvisioner{
+		log:         logger,
+		cfgProvider: newConfigReader(logger),
+		roleService: roleService,
+		orgService:  orgService,
+	}
+	return rp.applyChanges(ctx, configDirectory)
+}
+
+// RolesProvisioner is responsible for provisioning custom roles based on
+// configuration read by the `configReader`
+type RolesProvisioner struct {
+	log         log.Logger
+	cfgProvider configReader
+	roleService ac.RoleService
+	orgService  org.Service
+}
+
+func (rp *RolesProvisioner) apply(ctx context.Context, cfg *rolesAsConfig) error {
+	for _, role := range cfg.Roles {
+		if role.OrgID == 0 && role.OrgName != "" && !role.Global {
+			res, err := rp.orgService.GetByName(ctx, &org.GetOrgByNameQuery{Name: role.OrgName})
+			if err != nil {
+				return err
+			}
+			role.OrgID = res.ID
+		}
+		// Global roles are looked up from the main organization, they are returned for every organization
+		orgID := role.OrgID
+		if role.Global {
+			orgID = 1
+		}
+
+		existing, err := rp.findRole(ctx, orgID, role)
+		if err != nil {
+			return err
+		}
+
+		if role.Absent {
+			if existing == nil {
+				continue
+			}
+			rp.log.Info("Deleting role from configuration", "name", existing.Name, "uid", existing.UID)
+			if err := rp.roleService.DeleteRole(ctx, ac.DeleteRoleCommand{OrgID: orgID, UID: existing.UID, Force: role.Force, AllowGlobal: role.Global}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if existing == nil {
+			rp.log.Info("Inserting role from configuration", "name", role.Name, "uid", role.UID)
+			if _, err := rp.roleService.CreateRole(ctx, ac.CreateRoleCommand{
+				OrgID:       orgID,
+				Version:     role.Version,
+				UID:         role.UID,
+				Name:        role.Name,
+				DisplayName: role.DisplayName,
+				Description: role.Description,
+				Group:       role.Group,
+				Hidden:      role.Hidden,
+				Global:      role.Global,
+				Permissions: role.Permissions,
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Roles are only updated when the version of the configuration is higher, so that
+		// changes made through the API are kept until the configuration changes
+		if role.Version <= existing.Version {
+			rp.log.Debug("Skipping role from configuration, version is not newer", "name", existing.Name, "uid", existing.UID, "version", role.Version)
+			continue
+		}
+
+		name := role.Name
+		if name == "" {
+			name = existing.Name
+		}
+		rp.log.Info("Updating role from configuration", "name", name, "uid", existing.UID)
+		if _, err := rp.roleService.UpdateRole(ctx, ac.UpdateRoleCommand{
+			OrgID:       orgID,
+			UID:         existing.UID,
+			Version:     role.Version,
+			Name:        name,
+			DisplayName: role.DisplayName,
+			Description: role.Description,
+			Group:       role.Group,
+			Hidden:      role.Hidden,
+			Permissions: role.Permissions,
+			AllowGlobal: role.Global,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findRole returns the stored role matching the configured uid, or name when no uid is configured.
+func (rp *RolesProvisioner) findRole(ctx context.Context, orgID int64, role *roleFromConfig) (*ac.RoleDTO, error) {
+	if role.UID != "" {
+		existing, err := rp.roleService.GetRole(ctx, orgID, role.UID)
+		if err != nil {
+			if errors.Is(err, ac.ErrRoleNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return existing, nil
+	}
+
+	roles, err := rp.roleService.GetRoles(ctx, ac.GetRolesQuery{OrgID: orgID})
+	if err != nil {
+		return nil, err
+	}
+	for i := range roles {
+		if roles[i].Name == role.Name {
+			return &roles[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (rp *RolesProvisioner) applyChanges(ctx context.Context, configPath string) error {
+	configs, err := rp.cfgProvider.readConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range configs {
+		if err := rp.apply(ctx, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package accesscontrol
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+)
+
+func TestRolesProvisioner(t *testing.T) {
+	t.Run("Should return error when config reader returns error", func(t *testing.T) {
+		expectedErr := errors.New("test")
+		rp := RolesProvisioner{log: log.New("test"), cfgProvider: &testConfigReader{err: expectedErr}}
+		err := rp.applyChanges(context.Background(), "")
+		require.Equal(t, expectedErr, err)
+	})
+
+	t.Run("Should apply configurations", func(t *testing.T) {
+		cfg := []*rolesAsConfig{
+			{
+				Roles: []*roleFromConfig{
+					{OrgID: 1, UID: "new", Name: "custom:new", Version: 1},
+					{OrgID: 1, UID: "outdated", Name: "custom:outdated", Version: 3},
+					{OrgID: 1, UID: "uptodate", Name: "custom:uptodate", Version: 2},
+					{OrgName: "Org 4", Name: "custom:by-name", Version: 2},
+					{Global: true, UID: "global", Name: "custom:global"},
+					{OrgID: 1, UID: "removed", Absent: true, Force: true},
+					{OrgID: 1, UID: "unknown", Absent: true},
+				},
+			},
+		}
+		roleService := &fakeRoleService{roles: map[string]*ac.RoleDTO{
+			"outdated": {OrgID: 1, UID: "outdated", Name: "custom:outdated", Version: 2},
+			"uptodate": {OrgID: 1, UID: "uptodate", Name: "custom:uptodate", Version: 2},
+			"by-name":  {OrgID: 4, UID: "by-name", Name: "custom:by-name", Version: 1},
+			"removed":  {OrgID: 1, UID: "removed", Name: "custom:removed", Version: 1},
+		}}
+		orgMock := orgtest.NewOrgServiceFake()
+		orgMock.ExpectedOrg = &org.Org{ID: 4}
+		rp := RolesProvisioner{log: log.New("test"), cfgProvider: &testConfigReader{result: cfg}, roleService: roleService, orgService: orgMock}
+
+		err := rp.applyChanges(context.Background(), "")
+		require.NoError(t, err)
+
+		require.Len(t, roleService.created, 2)
+		require.Equal(t, "new", roleService.created[0].UID)
+		require.Equal(t, int64(1), roleService.created[0].OrgID)
+		require.True(t, roleService.created[1].Global)
+
+		require.Len(t, roleService.updated, 2)
+		require.Equal(t, "outdated", roleService.updated[0].UID)
+		require.Equal(t, int64(3), roleService.updated[0].Version)
+		require.Equal(t, "by-name", roleService.updated[1].UID)
+		require.Equal(t, int64(4), roleService.updated[1].OrgID)
+
+		require.Len(t, roleService.deleted, 1)
+		require.Equal(t, ac.DeleteRoleCommand{OrgID: 1, UID: "removed", Force: true}, roleService.deleted[0])
+	})
+}
+
+type testConfigReader struct {
+	result []*rolesAsConfig
+	err    error
+}
+
+func (tcr *testConfigReader) readConfig(_ string) ([]*rolesAsConfig, error) {
+	return tcr.result, tcr.err
+}
+
+type fakeRoleService struct {
+	ac.RoleService
+	roles   map[string]*ac.RoleDTO
+	created []ac.CreateRoleCommand
+	updated []ac.UpdateRoleCommand
+	deleted []ac.DeleteRoleCommand
+}
+
+func (s *fakeRoleService) GetRoles(_ context.Context, query ac.GetRolesQuery) ([]ac.RoleDTO, error) {
+	var roles []ac.RoleDTO
+	for _, r := range s.roles {
+		if r.OrgID == query.OrgID {
+			roles = append(roles, *r)
+		}
+	}
+	return roles, nil
+}
+
+func (s *fakeRoleService) GetRole(_ context.Context, _ int64, uid string) (*ac.RoleDTO, error) {
+	if r, ok := s.roles[uid]; ok {
+		return r, nil
+	}
+	return nil, ac.ErrRoleNotFound
+}
+
+func (s *fakeRoleService) CreateRole(_ context.Context, cmd ac.CreateRoleCommand) (*ac.RoleDTO, error) {
+	s.created = append(s.created, cmd)
+	return &ac.RoleDTO{}, nil
+}
+
+func (s *fakeRoleService) UpdateRole(_ context.Context, cmd ac.UpdateRoleCommand) (*ac.RoleDTO, error) {
+	s.updated = append(s.updated, cmd)
+	return &ac.RoleDTO{}, nil
+}
+
+func (s *fakeRoleService) DeleteRole(_ context.Context, cmd ac.DeleteRoleCommand) error {
+	s.deleted = append(s.deleted, cmd)
+	return nil
+}
@@ -0,0 +1,95 @@
+package accesscontrol
+
+import (
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+// rolesAsConfig is a normalized data object for access control config data. Any config version should be mappable
+// to this type.
+type rolesAsConfig struct {
+	Roles []*roleFromConfig
+}
+
+type roleFromConfig struct {
+	OrgID       int64
+	OrgName     string
+	Global      bool
+	UID         string
+	Name        string
+	DisplayName string
+	Description string
+	Group       string
+	Hidden      bool
+	Version     int64
+	Permissions []ac.Permission
+	Absent      bool
+	Force       bool
+}
+
+type permissionFromConfigV1 struct {
+	Action values.StringValue `json:"action" yaml:"action"`
+	Scope  values.StringValue `json:"scope" yaml:"scope"`
+}
+
+type roleFromConfigV1 struct {
+	OrgID       values.Int64Value         `json:"orgId" yaml:"orgId"`
+	OrgName     values.StringValue        `json:"orgName" yaml:"orgName"`
+	Global      values.BoolValue          `json:"global" yaml:"global"`
+	UID         values.StringValue        `json:"uid" yaml:"uid"`
+	Name        values.StringValue        `json:"name" yaml:"name"`
+	DisplayName values.StringValue        `json:"displayName" yaml:"displayName"`
+	Description values.StringValue        `json:"description" yaml:"description"`
+	Group       values.StringValue        `json:"group" yaml:"group"`
+	Hidden      values.BoolValue          `json:"hidden" yaml:"hidden"`
+	Version     values.Int64Value         `json:"version" yaml:"version"`
+	Permissions []*permissionFromConfigV1 `json:"permissions" yaml:"permissions"`
+	State       values.StringValue        `json:"state" yaml:"state"`
+	Force       values.BoolValue          `json:"force" yaml:"force"`
+}
+
+// rolesAsConfigV1 is a mapping for version 1 configs. This is mapped to its normalised version.
+type rolesAsConfigV1 struct {
+	APIVersion values.Int64Value   `json:"apiVersion" yaml:"apiVersion"`
+	Roles      []*roleFromConfigV1 `json:"roles" yaml:"roles"`
+}
+
+// mapToRolesFromConfig maps config syntax to a normalized rolesAsConfig object. Every version
+// of the config syntax should have this function.
+func (cfg *rolesAsConfigV1) mapToRolesFromConfig() *rolesAsConfig {
+	r := &rolesAsConfig{}
+	if cfg == nil {
+		return r
+	}
+
+	for _, role := range cfg.Roles {
+		if role == nil {
+			continue
+		}
+		permissions := make([]ac.Permission, 0, len(role.Permissions))
+		for _, p := range role.Permissions {
+			if p == nil {
+				continue
+			}
+			permissions = append(permissions, ac.Permission{Action: p.Action.Value(), Scope: p.Scope.Value()})
+		}
+
+		r.Roles = append(r.Roles, &roleFromConfig{
+			OrgID:       role.OrgID.Value(),
+			OrgName:     role.OrgName.Value(),
+			Global:      role.Global.Value(),
+			UID:         role.UID.Value(),
+			Name:        role.Name.Value(),
+			DisplayName: role.DisplayName.Value(),
+			Description: role.Description.Value(),
+			Group:       role.Group.Value(),
+			Hidden:      role.Hidden.Value(),
+			Version:     role.Version.Value(),
+			Permissions: permissions,
+			Absent:      role.State.Value() == stateAbsent,
+			Force:       role.Force.Value(),
+		})
+	}
+
+	return r
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/api/response"
@@ -15,14 +16,19 @@ import (
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 )
 
+// maxAccessLogPerPage caps the entries of the access log returned at once
+const maxAccessLogPerPage = 1000
+
 type Api struct {
 	PublicDashboardService publicdashboards.Service
 	RouteRegister          routing.RouteRegister
 	AccessControl          accesscontrol.AccessControl
 	Features               *featuremgmt.FeatureManager
+	Cfg                    *setting.Cfg
 	Log                    log.Logger
 }
 
@@ -31,12 +37,14 @@ func ProvideApi(
 	rr routing.RouteRegister,
 	ac accesscontrol.AccessControl,
 	features *featuremgmt.FeatureManager,
+	cfg *setting.Cfg,
 ) *Api {
 	api := &Api{
 		PublicDashboardService: pd,
 		RouteRegister:          rr,
 		AccessControl:          ac,
 		Features:               features,
+		Cfg:                    cfg,
 		Log:                    log.New("publicdashboards.api"),
 	}
 
@@ -55,9 +63,15 @@ func (api *Api) RegisterAPIEndpoints() {
 	// because it is deeply dependent on the HTTPServer.Index() method and would result in a
 	// circular dependency
 
-	api.RouteRegister.Get("/api/public/dashboards/:accessToken", routing.Wrap(api.ViewPublicDashboard))
-	api.RouteRegister.Post("/api/public/dashboards/:accessToken/panels/:panelId/query", routing.Wrap(api.QueryPublicDashboard))
-	api.RouteRegister.Get("/api/public/dashboards/:accessToken/annotations", routing.Wrap(api.GetAnnotations))
+	api.RouteRegister.Get("/api/public/dashboards/:accessToken",
+		RequiresAllowedAccess(api.PublicDashboardService, api.Cfg, AccessKindView),
+		routing.Wrap(api.ViewPublicDashboard))
+	api.RouteRegister.Post("/api/public/dashboards/:accessToken/panels/:panelId/query",
+		RequiresAllowedAccess(api.PublicDashboardService, api.Cfg, AccessKindQuery),
+		routing.Wrap(api.QueryPublicDashboard))
+	api.RouteRegister.Get("/api/public/dashboards/:accessToken/annotations",
+		RequiresAllowedAccess(api.PublicDashboardService, api.Cfg, AccessKindAnnotations),
+		routing.Wrap(api.GetAnnotations))
 
 	// Auth endpoints
 	auth := accesscontrol.Middleware(api.AccessControl)
@@ -85,6 +99,16 @@ func (api *Api) RegisterAPIEndpoints() {
 	api.RouteRegister.Delete("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid",
 		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
 		routing.Wrap(api.DeletePublicDashboard))
+
+	// Rotate Public dashboard access token
+	api.RouteRegister.Post("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid/rotate-access-token",
+		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
+		routing.Wrap(api.RotatePublicDashboardAccessToken))
+
+	// Get Public dashboard access log
+	api.RouteRegister.Get("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid/access-log",
+		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
+		routing.Wrap(api.GetPublicDashboardAccessLog))
 }
 
 // ListPublicDashboards Gets list of public dashboards by orgId
@@ -219,6 +243,83 @@ func (api *Api) DeletePublicDashboard(c *contextmodel.ReqContext) response.Respo
 	return response.JSON(http.StatusOK, nil)
 }
 
+// RotatePublicDashboardAccessToken Replaces the access token of a public dashboard
+// POST /api/dashboards/uid/:dashboardUid/public-dashboards/:uid/rotate-access-token
+func (api *Api) RotatePublicDashboardAccessToken(c *contextmodel.ReqContext) response.Response {
+	dashboardUid := web.Params(c.Req)[":dashboardUid"]
+	if !validation.IsValidShortUID(dashboardUid) {
+		return response.Err(ErrInvalidUid.Errorf("RotatePublicDashboardAccessToken: invalid dashboard Uid %s", dashboardUid))
+	}
+
+	uid := web.Params(c.Req)[":uid"]
+	if !validation.IsValidShortUID(uid) {
+		return response.Err(ErrInvalidUid.Errorf("RotatePublicDashboardAccessToken: invalid Uid %s", uid))
+	}
+
+	pd, err := api.PublicDashboardService.RotateAccessToken(c.Req.Context(), c.SignedInUser, dashboardUid, uid)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, pd)
+}
+
+// GetPublicDashboardAccessLog Gets the requests made to a public dashboard, most recent first
+// GET /api/dashboards/uid/:dashboardUid/public-dashboards/:uid/access-log
+func (api *Api) GetPublicDashboardAccessLog(c *contextmodel.ReqContext) response.Response {
+	dashboardUid := web.Params(c.Req)[":dashboardUid"]
+	if !validation.IsValidShortUID(dashboardUid) {
+		return response.Err(ErrInvalidUid.Errorf("GetPublicDashboardAccessLog: invalid dashboard Uid %s", dashboardUid))
+	}
+
+	uid := web.Params(c.Req)[":uid"]
+	if !validation.IsValidShortUID(uid) {
+		return response.Err(ErrInvalidUid.Errorf("GetPublicDashboardAccessLog: invalid Uid %s", uid))
+	}
+
+	// ensure the public dashboard belongs to the dashboard of the scope
+	pd, err := api.PublicDashboardService.FindByDashboardUid(c.Req.Context(), c.OrgID, dashboardUid)
+	if err != nil {
+		return response.Err(err)
+	}
+	if pd.Uid != uid {
+		return response.Err(ErrPublicDashboardNotFound.Errorf("GetPublicDashboardAccessLog: public dashboard not found by uid: %s", uid))
+	}
+
+	perPage := c.QueryInt("perpage")
+	if perPage <= 0 {
+		perPage = 100
+	}
+	if perPage > maxAccessLogPerPage {
+		perPage = maxAccessLogPerPage
+	}
+
+	page := c.QueryInt("page")
+	if page < 1 {
+		page = 1
+	}
+
+	query := &AccessLogQuery{
+		OrgId:              c.OrgID,
+		PublicDashboardUid: uid,
+		Page:               page,
+		Limit:              perPage,
+	}
+	if from := c.QueryInt64("from"); from > 0 {
+		query.From = time.UnixMilli(from)
+	}
+	if to := c.QueryInt64("to"); to > 0 {
+		query.To = time.UnixMilli(to)
+	}
+
+	resp, err := api.PublicDashboardService.FindAccessLog(c.Req.Context(), query)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, resp)
+}
+
 // Copied from pkg/api/metrics.go
 func toJsonStreamingResponse(features *featuremgmt.FeatureManager, qdr *backend.QueryDataResponse) response.Response {
 	statusWhenError := http.StatusBadRequest
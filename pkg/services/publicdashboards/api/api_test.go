@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -602,3 +603,172 @@ func TestAPIUpdatePublicDashboard(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIRotatePublicDashboardAccessToken(t *testing.T) {
+	dashboardUid := "abc1234"
+	publicDashboardUid := "1234asdfasdf"
+	userEditorPublicDashboard := &user.SignedInUser{UserID: 4, OrgID: 1, OrgRole: org.RoleEditor, Login: "testEditorUser", Permissions: map[int64]map[string][]string{1: {dashboards.ActionDashboardsPublicWrite: {fmt.Sprintf("dashboards:uid:%s", dashboardUid)}}}}
+	userEditorAnotherPublicDashboard := &user.SignedInUser{UserID: 4, OrgID: 1, OrgRole: org.RoleEditor, Login: "testEditorUser", Permissions: map[int64]map[string][]string{1: {dashboards.ActionDashboardsPublicWrite: {"another-uid"}}}}
+
+	testCases := []struct {
+		Name                 string
+		User                 *user.SignedInUser
+		PublicDashboardUid   string
+		ResponseErr          error
+		ExpectedHttpResponse int
+		ShouldCallService    bool
+	}{
+		{
+			Name:                 "User editor with dashboard access can rotate the access token",
+			User:                 userEditorPublicDashboard,
+			PublicDashboardUid:   publicDashboardUid,
+			ExpectedHttpResponse: http.StatusOK,
+			ShouldCallService:    true,
+		},
+		{
+			Name:                 "User editor without dashboard access cannot rotate the access token",
+			User:                 userEditorAnotherPublicDashboard,
+			PublicDashboardUid:   publicDashboardUid,
+			ExpectedHttpResponse: http.StatusForbidden,
+		},
+		{
+			Name:                 "User viewer cannot rotate the access token",
+			User:                 userViewer,
+			PublicDashboardUid:   publicDashboardUid,
+			ExpectedHttpResponse: http.StatusForbidden,
+		},
+		{
+			Name:                 "Invalid publicDashboardUid throws an error",
+			User:                 userEditorPublicDashboard,
+			PublicDashboardUid:   "inv@lid-publicd@shboard-uid!",
+			ExpectedHttpResponse: http.StatusBadRequest,
+		},
+		{
+			Name:                 "Public dashboard uid does not exist",
+			User:                 userEditorPublicDashboard,
+			PublicDashboardUid:   publicDashboardUid,
+			ResponseErr:          ErrPublicDashboardNotFound.Errorf(""),
+			ExpectedHttpResponse: http.StatusNotFound,
+			ShouldCallService:    true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			service := publicdashboards.NewFakePublicDashboardService(t)
+
+			pubdash := &PublicDashboard{Uid: test.PublicDashboardUid, DashboardUid: dashboardUid, AccessToken: "newaccesstoken"}
+			if test.ShouldCallService {
+				service.On("RotateAccessToken", mock.Anything, mock.Anything, dashboardUid, test.PublicDashboardUid).
+					Return(pubdash, test.ResponseErr)
+			}
+
+			cfg := setting.NewCfg()
+			features := featuremgmt.WithFeatures(featuremgmt.FlagPublicDashboards)
+			testServer := setupTestServer(t, cfg, features, service, nil, test.User)
+
+			response := callAPI(testServer, http.MethodPost, fmt.Sprintf("/api/dashboards/uid/%s/public-dashboards/%s/rotate-access-token", dashboardUid, test.PublicDashboardUid), nil, t)
+			assert.Equal(t, test.ExpectedHttpResponse, response.Code)
+
+			if test.ExpectedHttpResponse == http.StatusOK {
+				var jsonResp PublicDashboard
+				err := json.Unmarshal(response.Body.Bytes(), &jsonResp)
+				require.NoError(t, err)
+				assert.Equal(t, "newaccesstoken", jsonResp.AccessToken)
+			}
+
+			if !test.ShouldCallService {
+				service.AssertNotCalled(t, "RotateAccessToken")
+			}
+		})
+	}
+}
+
+func TestAPIGetPublicDashboardAccessLog(t *testing.T) {
+	dashboardUid := "abc1234"
+	publicDashboardUid := "1234asdfasdf"
+
+	testCases := []struct {
+		Name                 string
+		User                 *user.SignedInUser
+		PublicDashboardUid   string
+		Query                string
+		ExpectedHttpResponse int
+		ExpectedQuery        *AccessLogQuery
+	}{
+		{
+			Name:                 "Returns the access log with default pagination",
+			User:                 userAdminRBAC,
+			PublicDashboardUid:   publicDashboardUid,
+			ExpectedHttpResponse: http.StatusOK,
+			ExpectedQuery:        &AccessLogQuery{OrgId: 1, PublicDashboardUid: publicDashboardUid, Page: 1, Limit: 100},
+		},
+		{
+			Name:                 "Returns the access log of the time range",
+			User:                 userAdminRBAC,
+			PublicDashboardUid:   publicDashboardUid,
+			Query:                "?from=1681290000000&to=1681293600000&page=2&perpage=10",
+			ExpectedHttpResponse: http.StatusOK,
+			ExpectedQuery: &AccessLogQuery{
+				OrgId:              1,
+				PublicDashboardUid: publicDashboardUid,
+				From:               time.UnixMilli(1681290000000),
+				To:                 time.UnixMilli(1681293600000),
+				Page:               2,
+				Limit:              10,
+			},
+		},
+		{
+			Name:                 "Caps the page size of the access log",
+			User:                 userAdminRBAC,
+			PublicDashboardUid:   publicDashboardUid,
+			Query:                "?perpage=1000000",
+			ExpectedHttpResponse: http.StatusOK,
+			ExpectedQuery:        &AccessLogQuery{OrgId: 1, PublicDashboardUid: publicDashboardUid, Page: 1, Limit: maxAccessLogPerPage},
+		},
+		{
+			Name:                 "Returns 404 when the public dashboard belongs to another dashboard",
+			User:                 userAdminRBAC,
+			PublicDashboardUid:   "anotheruid",
+			ExpectedHttpResponse: http.StatusNotFound,
+		},
+		{
+			Name:                 "User viewer cannot get the access log",
+			User:                 userViewerRBAC,
+			PublicDashboardUid:   publicDashboardUid,
+			ExpectedHttpResponse: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			service := publicdashboards.NewFakePublicDashboardService(t)
+			service.On("FindByDashboardUid", mock.Anything, int64(1), dashboardUid).
+				Return(&PublicDashboard{Uid: publicDashboardUid, DashboardUid: dashboardUid}, nil).Maybe()
+
+			entries := []*AccessLogEntry{{Id: 1, PublicDashboardUid: publicDashboardUid, Kind: AccessKindQuery, PanelId: 2, IP: "10.0.0.1"}}
+			if test.ExpectedQuery != nil {
+				service.On("FindAccessLog", mock.Anything, test.ExpectedQuery).
+					Return(&AccessLogResponseWithPagination{Entries: entries, TotalCount: 1}, nil)
+			}
+
+			cfg := setting.NewCfg()
+			features := featuremgmt.WithFeatures(featuremgmt.FlagPublicDashboards)
+			testServer := setupTestServer(t, cfg, features, service, nil, test.User)
+
+			response := callAPI(testServer, http.MethodGet, fmt.Sprintf("/api/dashboards/uid/%s/public-dashboards/%s/access-log%s", dashboardUid, test.PublicDashboardUid, test.Query), nil, t)
+			assert.Equal(t, test.ExpectedHttpResponse, response.Code)
+
+			if test.ExpectedHttpResponse == http.StatusOK {
+				var jsonResp AccessLogResponseWithPagination
+				err := json.Unmarshal(response.Body.Bytes(), &jsonResp)
+				require.NoError(t, err)
+				require.Len(t, jsonResp.Entries, 1)
+				assert.Equal(t, int64(2), jsonResp.Entries[0].PanelId)
+				assert.Equal(t, "10.0.0.1", jsonResp.Entries[0].IP)
+			} else {
+				service.AssertNotCalled(t, "FindAccessLog")
+			}
+		})
+	}
+}
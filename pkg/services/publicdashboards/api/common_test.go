@@ -9,6 +9,7 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/api/routing"
@@ -28,6 +29,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/plugincontext"
 	pluginSettings "github.com/grafana/grafana/pkg/services/pluginsintegration/pluginsettings/service"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/query"
 	fakeSecrets "github.com/grafana/grafana/pkg/services/secrets/fakes"
 	"github.com/grafana/grafana/pkg/services/user"
@@ -70,7 +72,7 @@ func setupTestServer(
 
 	// build api, this will mount the routes at the same time if
 	// featuremgmt.FlagPublicDashboard is enabled
-	ProvideApi(service, rr, ac, features)
+	ProvideApi(service, rr, ac, features, cfg)
 
 	// connect routes to mux
 	rr.Register(m.Router)
@@ -78,6 +80,13 @@ func setupTestServer(
 	return m
 }
 
+// allowPublicAccess stubs the lookups of the public dashboard middleware, for tests of the public endpoints
+func allowPublicAccess(service *publicdashboards.FakePublicDashboardService) {
+	service.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).
+		Return(&PublicDashboard{Uid: "pubdashuid", IsEnabled: true}, nil).Maybe()
+	service.On("LogAccess", mock.Anything, mock.Anything).Return(nil).Maybe()
+}
+
 type testContext struct {
 	user *user.SignedInUser
 }
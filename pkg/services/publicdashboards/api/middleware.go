@@ -2,11 +2,16 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -62,6 +67,57 @@ func RequiresExistingAccessToken(publicDashboardService publicdashboards.Service
 	}
 }
 
+// maxUserAgentLength is the length of the user_agent column of the access log
+const maxUserAgentLength = 255
+
+// RequiresAllowedAccess Middleware to enforce the allowed networks of a public dashboard, and to record the access
+// in its access log. Requests to missing, paused or expired public dashboards are left to the handler.
+// The forwarded client addresses are only used for the requests of the trusted proxies of the configuration.
+func RequiresAllowedAccess(publicDashboardService publicdashboards.Service, cfg *setting.Cfg, kind AccessKind) func(c *contextmodel.ReqContext) {
+	logger := log.New("publicdashboards.api")
+
+	return func(c *contextmodel.ReqContext) {
+		accessToken, ok := web.Params(c.Req)[":accessToken"]
+		if !ok || !validation.IsValidAccessToken(accessToken) {
+			return
+		}
+
+		pubdash, err := publicDashboardService.FindByAccessToken(c.Req.Context(), accessToken)
+		if err != nil {
+			return
+		}
+
+		ip := web.ClientIP(c.Req, cfg.TrustedProxies)
+		if !pubdash.IsAllowedIP(ip) {
+			c.JsonApiErr(http.StatusForbidden, "Public dashboard is not available from this network", nil)
+			return
+		}
+
+		if !pubdash.IsEnabled || pubdash.IsExpired(time.Now()) {
+			return
+		}
+
+		userAgent := c.Req.UserAgent()
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+		entry := &AccessLogEntry{
+			OrgId:              pubdash.OrgId,
+			PublicDashboardUid: pubdash.Uid,
+			Kind:               kind,
+			IP:                 ip,
+			UserAgent:          userAgent,
+		}
+		if kind == AccessKindQuery {
+			entry.PanelId, _ = strconv.ParseInt(web.Params(c.Req)[":panelId"], 10, 64)
+		}
+
+		if err := publicDashboardService.LogAccess(c.Req.Context(), entry); err != nil {
+			logger.Warn("Failed to record public dashboard access", "publicDashboardUid", pubdash.Uid, "error", err)
+		}
+	}
+}
+
 func CountPublicDashboardRequest() func(c *contextmodel.ReqContext) {
 	return func(c *contextmodel.ReqContext) {
 		metrics.MPublicDashboardRequestCount.Inc()
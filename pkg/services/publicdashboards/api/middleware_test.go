@@ -2,16 +2,20 @@ package api
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"errors"
 
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/service"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -83,6 +87,130 @@ func TestRequiresExistingAccessToken(t *testing.T) {
 	}
 }
 
+func TestRequiresAllowedAccess(t *testing.T) {
+	expiredAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		Name                 string
+		Kind                 AccessKind
+		PanelId              string
+		AccessToken          string
+		PublicDashboard      *PublicDashboard
+		FindErr              error
+		RemoteAddr           string
+		ForwardedFor         string
+		ExpectedResponseCode int
+		ExpectedEntry        *AccessLogEntry
+	}{
+		{
+			Name:                 "Records the view of a public dashboard",
+			Kind:                 AccessKindView,
+			AccessToken:          validAccessToken,
+			PublicDashboard:      &PublicDashboard{Uid: "pubdashuid", OrgId: 1, IsEnabled: true},
+			RemoteAddr:           "10.0.0.1:52000",
+			ExpectedResponseCode: http.StatusOK,
+			ExpectedEntry:        &AccessLogEntry{OrgId: 1, PublicDashboardUid: "pubdashuid", Kind: AccessKindView, IP: "10.0.0.1", UserAgent: "test-agent"},
+		},
+		{
+			Name:                 "Records the panel of a query",
+			Kind:                 AccessKindQuery,
+			PanelId:              "2",
+			AccessToken:          validAccessToken,
+			PublicDashboard:      &PublicDashboard{Uid: "pubdashuid", OrgId: 1, IsEnabled: true, AllowedCIDRs: []string{"10.0.0.0/8"}},
+			RemoteAddr:           "10.0.0.1:52000",
+			ExpectedResponseCode: http.StatusOK,
+			ExpectedEntry:        &AccessLogEntry{OrgId: 1, PublicDashboardUid: "pubdashuid", Kind: AccessKindQuery, PanelId: 2, IP: "10.0.0.1", UserAgent: "test-agent"},
+		},
+		{
+			Name:                 "Returns 403 when the address is not allowed",
+			Kind:                 AccessKindView,
+			AccessToken:          validAccessToken,
+			PublicDashboard:      &PublicDashboard{Uid: "pubdashuid", OrgId: 1, IsEnabled: true, AllowedCIDRs: []string{"10.0.0.0/8"}},
+			RemoteAddr:           "192.168.1.1:52000",
+			ExpectedResponseCode: http.StatusForbidden,
+		},
+		{
+			Name:                 "Ignores the forwarded addresses of clients that aren't trusted proxies",
+			Kind:                 AccessKindView,
+			AccessToken:          validAccessToken,
+			PublicDashboard:      &PublicDashboard{Uid: "pubdashuid", OrgId: 1, IsEnabled: true, AllowedCIDRs: []string{"10.0.0.0/8"}},
+			RemoteAddr:           "192.168.1.1:52000",
+			ForwardedFor:         "10.0.0.1",
+			ExpectedResponseCode: http.StatusForbidden,
+		},
+		{
+			Name:                 "Uses the forwarded addresses of trusted proxies",
+			Kind:                 AccessKindView,
+			AccessToken:          validAccessToken,
+			PublicDashboard:      &PublicDashboard{Uid: "pubdashuid", OrgId: 1, IsEnabled: true, AllowedCIDRs: []string{"10.0.0.0/8"}},
+			RemoteAddr:           "172.16.0.2:52000",
+			ForwardedFor:         "10.0.0.1",
+			ExpectedResponseCode: http.StatusOK,
+			ExpectedEntry:        &AccessLogEntry{OrgId: 1, PublicDashboardUid: "pubdashuid", Kind: AccessKindView, IP: "10.0.0.1", UserAgent: "test-agent"},
+		},
+		{
+			Name:                 "Does not record the access to an expired public dashboard",
+			Kind:                 AccessKindView,
+			AccessToken:          validAccessToken,
+			PublicDashboard:      &PublicDashboard{Uid: "pubdashuid", OrgId: 1, IsEnabled: true, ExpiresAt: &expiredAt},
+			RemoteAddr:           "10.0.0.1:52000",
+			ExpectedResponseCode: http.StatusOK,
+		},
+		{
+			Name:                 "Does not record the access to a paused public dashboard",
+			Kind:                 AccessKindView,
+			AccessToken:          validAccessToken,
+			PublicDashboard:      &PublicDashboard{Uid: "pubdashuid", OrgId: 1, IsEnabled: false},
+			RemoteAddr:           "10.0.0.1:52000",
+			ExpectedResponseCode: http.StatusOK,
+		},
+		{
+			Name:                 "Leaves missing public dashboards to the handler",
+			Kind:                 AccessKindView,
+			AccessToken:          validAccessToken,
+			FindErr:              ErrPublicDashboardNotFound.Errorf(""),
+			RemoteAddr:           "10.0.0.1:52000",
+			ExpectedResponseCode: http.StatusOK,
+		},
+		{
+			Name:                 "Leaves invalid access tokens to the handler",
+			Kind:                 AccessKindView,
+			AccessToken:          "invalidAccessToken",
+			RemoteAddr:           "10.0.0.1:52000",
+			ExpectedResponseCode: http.StatusOK,
+		},
+	}
+
+	_, trustedProxies, err := net.ParseCIDR("172.16.0.0/12")
+	require.NoError(t, err)
+	cfg := setting.NewCfg()
+	cfg.TrustedProxies = []*net.IPNet{trustedProxies}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			publicdashboardService := &publicdashboards.FakePublicDashboardService{}
+			publicdashboardService.On("FindByAccessToken", mock.Anything, tt.AccessToken).Return(tt.PublicDashboard, tt.FindErr)
+			var entry *AccessLogEntry
+			publicdashboardService.On("LogAccess", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				entry = args.Get(1).(*AccessLogEntry)
+			}).Return(nil)
+
+			params := map[string]string{":accessToken": tt.AccessToken, ":panelId": tt.PanelId}
+			mw := RequiresAllowedAccess(publicdashboardService, cfg, tt.Kind)
+			_, resp := runMw(t, nil, "GET", "/api/public/dashboards/myAccesstoken", params, func(c *contextmodel.ReqContext) {
+				c.Req.RemoteAddr = tt.RemoteAddr
+				c.Req.Header.Set("User-Agent", "test-agent")
+				if tt.ForwardedFor != "" {
+					c.Req.Header.Set("X-Forwarded-For", tt.ForwardedFor)
+				}
+				mw(c)
+			})
+			require.Equal(t, tt.ExpectedResponseCode, resp.Code)
+			assert.Equal(t, tt.ExpectedEntry, entry)
+		})
+	}
+}
+
 func TestSetPublicDashboardOrgIdOnContext(t *testing.T) {
 	tests := []struct {
 		Name          string
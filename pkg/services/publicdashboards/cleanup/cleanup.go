@@ -0,0 +1,99 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Service disables the expired public dashboards and deletes the old entries of the access log
+type Service struct {
+	store    publicdashboards.Store
+	features featuremgmt.FeatureToggles
+	lock     *serverlock.ServerLockService
+	log      log.Logger
+	now      func() time.Time
+
+	interval           time.Duration
+	accessLogRetention time.Duration
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	store publicdashboards.Store,
+	features featuremgmt.FeatureToggles,
+	lock *serverlock.ServerLockService,
+) (*Service, error) {
+	section := cfg.SectionWithEnvOverrides("public_dashboards")
+	accessLogRetention, err := gtime.ParseDuration(section.Key("access_log_retention").MustString("30d"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public_dashboards access_log_retention: %w", err)
+	}
+
+	return &Service{
+		store:              store,
+		features:           features,
+		lock:               lock,
+		log:                log.New("publicdashboards.cleanup"),
+		now:                time.Now,
+		interval:           section.Key("expiration_check_interval").MustDuration(time.Minute),
+		accessLogRetention: accessLogRetention,
+	}, nil
+}
+
+func (s *Service) IsDisabled() bool {
+	return !s.features.IsEnabled(featuremgmt.FlagPublicDashboards)
+}
+
+func (s *Service) Run(ctx context.Context) error {
+	s.cleanup(ctx)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			s.cleanup(ctx)
+		}
+	}
+}
+
+// cleanup runs on one instance at a time, so that the instances of a high availability setup
+// don't all clean up at the same time
+func (s *Service) cleanup(ctx context.Context) {
+	err := s.lock.LockAndExecute(ctx, "cleanup public dashboards", s.interval, s.run)
+	if err != nil {
+		s.log.Error("failed to lock and execute cleanup of public dashboards", "error", err)
+	}
+}
+
+func (s *Service) run(ctx context.Context) {
+	now := s.now()
+
+	disabled, err := s.store.DisableExpired(ctx, now)
+	if err != nil {
+		s.log.Error("failed to disable expired public dashboards", "error", err)
+	} else if disabled > 0 {
+		s.log.Info("Disabled expired public dashboards", "count", disabled)
+	}
+
+	if s.accessLogRetention <= 0 {
+		return
+	}
+
+	deleted, err := s.store.DeleteAccessLogBefore(ctx, now.Add(-s.accessLogRetention))
+	if err != nil {
+		s.log.Error("failed to delete old public dashboard access log entries", "error", err)
+	} else if deleted > 0 {
+		s.log.Debug("Deleted old public dashboard access log entries", "count", deleted)
+	}
+}
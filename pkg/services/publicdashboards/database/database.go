@@ -3,6 +3,8 @@ package database
 import (
 	"context"
 	"encoding/json"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -27,6 +29,9 @@ type PublicDashboardStoreImpl struct {
 
 var LogPrefix = "publicdashboards.store"
 
+// timeFormat is the format of the times written with raw sql
+const timeFormat = "2006-01-02 15:04:05"
+
 // Gives us a compile time error if our database does not adhere to contract of
 // the interface
 var _ publicdashboards.Store = (*PublicDashboardStoreImpl)(nil)
@@ -194,9 +199,9 @@ func (d *PublicDashboardStoreImpl) FindByDashboardUid(ctx context.Context, orgId
 func (d *PublicDashboardStoreImpl) ExistsEnabledByDashboardUid(ctx context.Context, dashboardUid string) (bool, error) {
 	hasPublicDashboard := false
 	err := d.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
-		sql := "SELECT COUNT(*) FROM dashboard_public WHERE dashboard_uid=? AND is_enabled=true"
+		sql := "SELECT COUNT(*) FROM dashboard_public WHERE dashboard_uid=? AND is_enabled=true AND (expires_at IS NULL OR expires_at > ?)"
 
-		result, err := dbSession.SQL(sql, dashboardUid).Count()
+		result, err := dbSession.SQL(sql, dashboardUid, time.Now().UTC().Format(timeFormat)).Count()
 		if err != nil {
 			return err
 		}
@@ -212,9 +217,9 @@ func (d *PublicDashboardStoreImpl) ExistsEnabledByDashboardUid(ctx context.Conte
 func (d *PublicDashboardStoreImpl) ExistsEnabledByAccessToken(ctx context.Context, accessToken string) (bool, error) {
 	hasPublicDashboard := false
 	err := d.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
-		sql := "SELECT COUNT(*) FROM dashboard_public WHERE access_token=? AND is_enabled=true"
+		sql := "SELECT COUNT(*) FROM dashboard_public WHERE access_token=? AND is_enabled=true AND (expires_at IS NULL OR expires_at > ?)"
 
-		result, err := dbSession.SQL(sql, accessToken).Count()
+		result, err := dbSession.SQL(sql, accessToken, time.Now().UTC().Format(timeFormat)).Count()
 		if err != nil {
 			return err
 		}
@@ -268,14 +273,26 @@ func (d *PublicDashboardStoreImpl) Update(ctx context.Context, cmd SavePublicDas
 			return err
 		}
 
-		sqlResult, err := sess.Exec("UPDATE dashboard_public SET is_enabled = ?, annotations_enabled = ?, time_selection_enabled = ?, share = ?, time_settings = ?, updated_by = ?, updated_at = ? WHERE uid = ?",
+		allowedCIDRsJSON, err := json.Marshal(cmd.PublicDashboard.AllowedCIDRs)
+		if err != nil {
+			return err
+		}
+
+		var expiresAt any
+		if cmd.PublicDashboard.ExpiresAt != nil {
+			expiresAt = cmd.PublicDashboard.ExpiresAt.UTC().Format(timeFormat)
+		}
+
+		sqlResult, err := sess.Exec("UPDATE dashboard_public SET is_enabled = ?, annotations_enabled = ?, time_selection_enabled = ?, share = ?, time_settings = ?, expires_at = ?, allowed_cidrs = ?, updated_by = ?, updated_at = ? WHERE uid = ?",
 			cmd.PublicDashboard.IsEnabled,
 			cmd.PublicDashboard.AnnotationsEnabled,
 			cmd.PublicDashboard.TimeSelectionEnabled,
 			cmd.PublicDashboard.Share,
 			string(timeSettingsJSON),
+			expiresAt,
+			string(allowedCIDRsJSON),
 			cmd.PublicDashboard.UpdatedBy,
-			cmd.PublicDashboard.UpdatedAt.UTC().Format("2006-01-02 15:04:05"),
+			cmd.PublicDashboard.UpdatedAt.UTC().Format(timeFormat),
 			cmd.PublicDashboard.Uid)
 
 		if err != nil {
@@ -297,7 +314,104 @@ func (d *PublicDashboardStoreImpl) Delete(ctx context.Context, uid string) (int6
 	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
 		var err error
 		affectedRows, err = sess.Delete(dashboard)
+		if err != nil {
+			return err
+		}
+
+		_, err = sess.Delete(&AccessLogEntry{PublicDashboardUid: uid})
+		return err
+	})
+
+	return affectedRows, err
+}
+
+// UpdateAccessToken Replaces the access token of a public dashboard
+func (d *PublicDashboardStoreImpl) UpdateAccessToken(ctx context.Context, uid string, accessToken string, updatedBy int64, updatedAt time.Time) (int64, error) {
+	var affectedRows int64
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		sqlResult, err := sess.Exec("UPDATE dashboard_public SET access_token = ?, updated_by = ?, updated_at = ? WHERE uid = ?",
+			accessToken,
+			updatedBy,
+			updatedAt.UTC().Format(timeFormat),
+			uid)
+		if err != nil {
+			return err
+		}
+
+		affectedRows, err = sqlResult.RowsAffected()
+		return err
+	})
+
+	return affectedRows, err
+}
+
+// DisableExpired Disables the enabled public dashboards which expired at the given time
+func (d *PublicDashboardStoreImpl) DisableExpired(ctx context.Context, now time.Time) (int64, error) {
+	var affectedRows int64
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		sqlResult, err := sess.Exec("UPDATE dashboard_public SET is_enabled = ? WHERE is_enabled = ? AND expires_at IS NOT NULL AND expires_at <= ?",
+			false,
+			true,
+			now.UTC().Format(timeFormat))
+		if err != nil {
+			return err
+		}
+
+		affectedRows, err = sqlResult.RowsAffected()
+		return err
+	})
+
+	return affectedRows, err
+}
+
+// CreateAccessLogEntry Records a request made to a public dashboard
+func (d *PublicDashboardStoreImpl) CreateAccessLogEntry(ctx context.Context, entry *AccessLogEntry) error {
+	return d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(entry)
+		return err
+	})
+}
+
+// FindAccessLog Returns the requests made to a public dashboard, most recent first and with pagination
+func (d *PublicDashboardStoreImpl) FindAccessLog(ctx context.Context, query *AccessLogQuery) (*AccessLogResponseWithPagination, error) {
+	resp := &AccessLogResponseWithPagination{
+		Entries: make([]*AccessLogEntry, 0),
+	}
+
+	where := []string{"org_id = ?", "public_dashboard_uid = ?"}
+	args := []any{query.OrgId, query.PublicDashboardUid}
+	if !query.From.IsZero() {
+		where = append(where, "accessed_at >= ?")
+		args = append(args, query.From)
+	}
+	if !query.To.IsZero() {
+		where = append(where, "accessed_at <= ?")
+		args = append(args, query.To)
+	}
+	filter := strings.Join(where, " AND ")
 
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		resp.TotalCount, err = sess.Table("dashboard_public_access_log").Where(filter, args...).Count()
+		if err != nil {
+			return err
+		}
+
+		return sess.Where(filter, args...).Desc("accessed_at", "id").Limit(query.Limit, query.Offset).Find(&resp.Entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// DeleteAccessLogBefore Deletes the requests made to public dashboards before the given time
+func (d *PublicDashboardStoreImpl) DeleteAccessLogBefore(ctx context.Context, before time.Time) (int64, error) {
+	var affectedRows int64
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		affectedRows, err = sess.Where("accessed_at < ?", before).Delete(&AccessLogEntry{})
 		return err
 	})
 
@@ -251,6 +251,30 @@ func TestIntegrationExistsEnabledByAccessToken(t *testing.T) {
 		require.False(t, res)
 	})
 
+	t.Run("ExistsEnabledByAccessToken will return false when the public dashboard expired", func(t *testing.T) {
+		setup()
+
+		expiresAt := time.Now().Add(-time.Minute)
+		_, err := publicdashboardStore.Create(context.Background(), SavePublicDashboardCommand{
+			PublicDashboard: PublicDashboard{
+				IsEnabled:    true,
+				Uid:          "abc123",
+				DashboardUid: savedDashboard.UID,
+				OrgId:        savedDashboard.OrgID,
+				CreatedAt:    time.Now(),
+				CreatedBy:    7,
+				AccessToken:  "accessToken",
+				ExpiresAt:    &expiresAt,
+			},
+		})
+		require.NoError(t, err)
+
+		res, err := publicdashboardStore.ExistsEnabledByAccessToken(context.Background(), "accessToken")
+		require.NoError(t, err)
+
+		require.False(t, res)
+	})
+
 	t.Run("ExistsEnabledByAccessToken will return false when no public dashboard has matching access token", func(t *testing.T) {
 		setup()
 
@@ -761,6 +785,126 @@ func TestIntegrationDelete(t *testing.T) {
 	})
 }
 
+func TestIntegrationDisableExpired(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore, cfg := db.InitTestDBwithCfg(t)
+	dashboardStore, err := dashboardsDB.ProvideDashboardStore(sqlStore, cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore, cfg), quotatest.New(false, nil))
+	require.NoError(t, err)
+	publicdashboardStore := ProvideStore(sqlStore, cfg, featuremgmt.WithFeatures())
+	ctx := context.Background()
+
+	expired := insertPublicDashboard(t, publicdashboardStore, insertTestDashboard(t, dashboardStore, "expired", 1, 0, true).UID, 1, true, PublicShareType)
+	active := insertPublicDashboard(t, publicdashboardStore, insertTestDashboard(t, dashboardStore, "active", 1, 0, true).UID, 1, true, PublicShareType)
+	unlimited := insertPublicDashboard(t, publicdashboardStore, insertTestDashboard(t, dashboardStore, "unlimited", 1, 0, true).UID, 1, true, PublicShareType)
+
+	now := time.Now()
+	for pubdash, expiresAt := range map[*PublicDashboard]time.Time{expired: now.Add(-time.Hour), active: now.Add(time.Hour)} {
+		pubdash.ExpiresAt = &expiresAt
+		pubdash.UpdatedAt = now
+		_, err := publicdashboardStore.Update(ctx, SavePublicDashboardCommand{PublicDashboard: *pubdash})
+		require.NoError(t, err)
+	}
+
+	affectedRows, err := publicdashboardStore.DisableExpired(ctx, now)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, affectedRows)
+
+	for pubdash, isEnabled := range map[*PublicDashboard]bool{expired: false, active: true, unlimited: true} {
+		found, err := publicdashboardStore.Find(ctx, pubdash.Uid)
+		require.NoError(t, err)
+		assert.Equal(t, isEnabled, found.IsEnabled)
+	}
+}
+
+func TestIntegrationAccessLog(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	var publicdashboardStore *PublicDashboardStoreImpl
+	var savedPublicDashboard *PublicDashboard
+	now := time.Now().Truncate(time.Second)
+
+	setup := func() {
+		sqlStore, cfg := db.InitTestDBwithCfg(t)
+		dashboardStore, err := dashboardsDB.ProvideDashboardStore(sqlStore, cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore, cfg), quotatest.New(false, nil))
+		require.NoError(t, err)
+		publicdashboardStore = ProvideStore(sqlStore, cfg, featuremgmt.WithFeatures())
+		savedDashboard := insertTestDashboard(t, dashboardStore, "testDashie", 1, 0, true)
+		savedPublicDashboard = insertPublicDashboard(t, publicdashboardStore, savedDashboard.UID, savedDashboard.OrgID, true, PublicShareType)
+
+		for i, kind := range []AccessKind{AccessKindView, AccessKindQuery, AccessKindAnnotations} {
+			err := publicdashboardStore.CreateAccessLogEntry(context.Background(), &AccessLogEntry{
+				OrgId:              savedPublicDashboard.OrgId,
+				PublicDashboardUid: savedPublicDashboard.Uid,
+				AccessedAt:         now.Add(time.Duration(i-2) * time.Hour),
+				Kind:               kind,
+				PanelId:            int64(i),
+				IP:                 "10.0.0.1",
+				UserAgent:          "test-agent",
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	t.Run("FindAccessLog returns the entries of the time range, most recent first", func(t *testing.T) {
+		setup()
+
+		resp, err := publicdashboardStore.FindAccessLog(context.Background(), &AccessLogQuery{
+			OrgId:              savedPublicDashboard.OrgId,
+			PublicDashboardUid: savedPublicDashboard.Uid,
+			From:               now.Add(-90 * time.Minute),
+			Limit:              10,
+		})
+		require.NoError(t, err)
+
+		assert.EqualValues(t, 2, resp.TotalCount)
+		require.Len(t, resp.Entries, 2)
+		assert.Equal(t, AccessKindAnnotations, resp.Entries[0].Kind)
+		assert.Equal(t, AccessKindQuery, resp.Entries[1].Kind)
+		assert.EqualValues(t, 1, resp.Entries[1].PanelId)
+		assert.Equal(t, "test-agent", resp.Entries[1].UserAgent)
+	})
+
+	t.Run("FindAccessLog does not return the entries of another org", func(t *testing.T) {
+		setup()
+
+		resp, err := publicdashboardStore.FindAccessLog(context.Background(), &AccessLogQuery{
+			OrgId:              savedPublicDashboard.OrgId + 1,
+			PublicDashboardUid: savedPublicDashboard.Uid,
+			Limit:              10,
+		})
+		require.NoError(t, err)
+
+		assert.EqualValues(t, 0, resp.TotalCount)
+		assert.Empty(t, resp.Entries)
+	})
+
+	t.Run("DeleteAccessLogBefore deletes the old entries", func(t *testing.T) {
+		setup()
+
+		deleted, err := publicdashboardStore.DeleteAccessLogBefore(context.Background(), now.Add(-30*time.Minute))
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, deleted)
+	})
+
+	t.Run("Delete deletes the entries of the public dashboard", func(t *testing.T) {
+		setup()
+
+		_, err := publicdashboardStore.Delete(context.Background(), savedPublicDashboard.Uid)
+		require.NoError(t, err)
+
+		resp, err := publicdashboardStore.FindAccessLog(context.Background(), &AccessLogQuery{
+			OrgId:              savedPublicDashboard.OrgId,
+			PublicDashboardUid: savedPublicDashboard.Uid,
+			Limit:              10,
+		})
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, resp.TotalCount)
+	})
+}
+
 func TestGetDashboardByFolder(t *testing.T) {
 	t.Run("returns nil when dashboard is not a folder", func(t *testing.T) {
 		sqlStore, _ := db.InitTestDBwithCfg(t)
@@ -21,6 +21,10 @@ var (
 	ErrInvalidTimeRange                    = errutil.NewBase(errutil.StatusBadRequest, "publicdashboards.invalidTimeRange", errutil.WithPublicMessage("Invalid time range"))
 	ErrInvalidShareType                    = errutil.NewBase(errutil.StatusBadRequest, "publicdashboards.invalidShareType", errutil.WithPublicMessage("Invalid share type"))
 	ErrDashboardIsPublic                   = errutil.NewBase(errutil.StatusBadRequest, "publicdashboards.dashboardIsPublic", errutil.WithPublicMessage("Dashboard is already public"))
+	ErrInvalidExpiration                   = errutil.NewBase(errutil.StatusBadRequest, "publicdashboards.invalidExpiration", errutil.WithPublicMessage("Expiration must be in the future"))
+	ErrInvalidCIDR                         = errutil.NewBase(errutil.StatusBadRequest, "publicdashboards.invalidCidr", errutil.WithPublicMessage("Invalid allowed network"))
 
 	ErrPublicDashboardNotEnabled = errutil.NewBase(errutil.StatusForbidden, "publicdashboards.notEnabled", errutil.WithPublicMessage("Public dashboard paused"))
+	ErrPublicDashboardExpired    = errutil.NewBase(errutil.StatusForbidden, "publicdashboards.expired", errutil.WithPublicMessage("Public dashboard expired"))
+	ErrIPNotAllowed              = errutil.NewBase(errutil.StatusForbidden, "publicdashboards.ipNotAllowed", errutil.WithPublicMessage("Public dashboard is not available from this network"))
 )
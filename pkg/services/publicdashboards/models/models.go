@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"net"
 	"time"
 
 	"github.com/grafana/grafana/pkg/kinds/dashboard"
@@ -53,6 +54,10 @@ type PublicDashboard struct {
 	AnnotationsEnabled   bool          `json:"annotationsEnabled" xorm:"annotations_enabled"`
 	Share                ShareType     `json:"share" xorm:"share"`
 	Recipients           []EmailDTO    `json:"recipients,omitempty" xorm:"-"`
+	// ExpiresAt is when the public dashboard is disabled, it never expires when nil
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" xorm:"expires_at"`
+	// AllowedCIDRs are the networks the public dashboard can be accessed from, any network when empty
+	AllowedCIDRs []string `json:"allowedCidrs,omitempty" xorm:"allowed_cidrs"`
 }
 
 type PublicDashboardDTO struct {
@@ -71,6 +76,10 @@ type PublicDashboardDTO struct {
 	AnnotationsEnabled   *bool         `json:"annotationsEnabled"`
 	Share                ShareType     `json:"share"`
 	Recipients           []EmailDTO    `json:"recipients,omitempty"`
+	// ExpiresAt is left unchanged when nil, and removes the expiration when zero
+	ExpiresAt *time.Time `json:"expiresAt"`
+	// AllowedCIDRs is left unchanged when nil, and allows any network when empty
+	AllowedCIDRs []string `json:"allowedCidrs"`
 }
 
 type EmailDTO struct {
@@ -104,6 +113,39 @@ func (pd PublicDashboard) TableName() string {
 	return "dashboard_public"
 }
 
+// IsExpired returns true if the public dashboard expired at the given time
+func (pd PublicDashboard) IsExpired(now time.Time) bool {
+	return pd.ExpiresAt != nil && !pd.ExpiresAt.After(now)
+}
+
+// IsAllowedIP returns true if the public dashboard can be accessed from the ip address
+func (pd PublicDashboard) IsAllowedIP(ip string) bool {
+	if len(pd.AllowedCIDRs) == 0 {
+		return true
+	}
+
+	// the address may include the port when not forwarded by a proxy
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, cidr := range pd.AllowedCIDRs {
+		// single addresses are allowed along with networks
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			if network.Contains(addr) {
+				return true
+			}
+		} else if allowed := net.ParseIP(cidr); allowed != nil && allowed.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
 type PublicDashboardListQuery struct {
 	OrgID  int64
 	Query  string
@@ -128,6 +170,49 @@ type PublicDashboardListResponse struct {
 	IsEnabled    bool   `json:"isEnabled" xorm:"is_enabled"`
 }
 
+const (
+	AccessKindView        AccessKind = "view"
+	AccessKindQuery       AccessKind = "query"
+	AccessKindAnnotations AccessKind = "annotations"
+)
+
+// AccessKind is the kind of request made to a public dashboard
+type AccessKind string
+
+// AccessLogEntry is a request made to a public dashboard
+type AccessLogEntry struct {
+	Id                 int64      `json:"id" xorm:"pk autoincr 'id'"`
+	OrgId              int64      `json:"-" xorm:"org_id"`
+	PublicDashboardUid string     `json:"publicDashboardUid" xorm:"public_dashboard_uid"`
+	AccessedAt         time.Time  `json:"accessedAt" xorm:"accessed_at"`
+	Kind               AccessKind `json:"kind" xorm:"kind"`
+	// PanelId is the panel queried, for query requests
+	PanelId   int64  `json:"panelId,omitempty" xorm:"panel_id"`
+	IP        string `json:"ip" xorm:"ip"`
+	UserAgent string `json:"userAgent" xorm:"user_agent"`
+}
+
+func (e AccessLogEntry) TableName() string {
+	return "dashboard_public_access_log"
+}
+
+type AccessLogQuery struct {
+	OrgId              int64
+	PublicDashboardUid string
+	From               time.Time
+	To                 time.Time
+	Page               int
+	Limit              int
+	Offset             int
+}
+
+type AccessLogResponseWithPagination struct {
+	Entries    []*AccessLogEntry `json:"entries"`
+	TotalCount int64             `json:"totalCount"`
+	Page       int               `json:"page"`
+	PerPage    int               `json:"perPage"`
+}
+
 type TimeSettings struct {
 	From string `json:"from,omitempty"`
 	To   string `json:"to,omitempty"`
@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -9,3 +10,37 @@ import (
 func TestPublicDashboardTableName(t *testing.T) {
 	assert.Equal(t, "dashboard_public", PublicDashboard{}.TableName())
 }
+
+func TestPublicDashboardIsExpired(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	assert.False(t, PublicDashboard{}.IsExpired(now))
+	assert.False(t, PublicDashboard{ExpiresAt: &future}.IsExpired(now))
+	assert.True(t, PublicDashboard{ExpiresAt: &past}.IsExpired(now))
+	assert.True(t, PublicDashboard{ExpiresAt: &now}.IsExpired(now))
+}
+
+func TestPublicDashboardIsAllowedIP(t *testing.T) {
+	tests := []struct {
+		name         string
+		allowedCIDRs []string
+		ip           string
+		expected     bool
+	}{
+		{name: "any network when empty", ip: "203.0.113.5", expected: true},
+		{name: "address in network", allowedCIDRs: []string{"10.0.0.0/8", "203.0.113.0/24"}, ip: "203.0.113.5", expected: true},
+		{name: "address with port", allowedCIDRs: []string{"203.0.113.0/24"}, ip: "203.0.113.5:51234", expected: true},
+		{name: "single address", allowedCIDRs: []string{"203.0.113.5"}, ip: "203.0.113.5", expected: true},
+		{name: "ipv6 address in network", allowedCIDRs: []string{"2001:db8::/32"}, ip: "[2001:db8::1]:443", expected: true},
+		{name: "address outside networks", allowedCIDRs: []string{"10.0.0.0/8", "203.0.113.6"}, ip: "203.0.113.5", expected: false},
+		{name: "invalid address", allowedCIDRs: []string{"10.0.0.0/8"}, ip: "unknown", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, PublicDashboard{AllowedCIDRs: tt.allowedCIDRs}.IsAllowedIP(tt.ip))
+		})
+	}
+}
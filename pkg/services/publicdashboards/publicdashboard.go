@@ -2,6 +2,7 @@ package publicdashboards
 
 import (
 	"context"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/api/dtos"
@@ -36,6 +37,10 @@ type Service interface {
 
 	ExistsEnabledByAccessToken(ctx context.Context, accessToken string) (bool, error)
 	ExistsEnabledByDashboardUid(ctx context.Context, dashboardUid string) (bool, error)
+
+	RotateAccessToken(ctx context.Context, u *user.SignedInUser, dashboardUid string, uid string) (*PublicDashboard, error)
+	LogAccess(ctx context.Context, entry *AccessLogEntry) error
+	FindAccessLog(ctx context.Context, query *AccessLogQuery) (*AccessLogResponseWithPagination, error)
 }
 
 // ServiceWrapper these methods have different behavior between OSS and Enterprise. The latter would call the OSS service first
@@ -62,4 +67,10 @@ type Store interface {
 	ExistsEnabledByAccessToken(ctx context.Context, accessToken string) (bool, error)
 	ExistsEnabledByDashboardUid(ctx context.Context, dashboardUid string) (bool, error)
 	GetMetrics(ctx context.Context) (*Metrics, error)
+
+	UpdateAccessToken(ctx context.Context, uid string, accessToken string, updatedBy int64, updatedAt time.Time) (int64, error)
+	DisableExpired(ctx context.Context, now time.Time) (int64, error)
+	CreateAccessLogEntry(ctx context.Context, entry *AccessLogEntry) error
+	FindAccessLog(ctx context.Context, query *AccessLogQuery) (*AccessLogResponseWithPagination, error)
+	DeleteAccessLogBefore(ctx context.Context, before time.Time) (int64, error)
 }
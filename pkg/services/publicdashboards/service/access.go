@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// RotateAccessToken Replaces the access token of a public dashboard, the previous link stops working
+func (pd *PublicDashboardServiceImpl) RotateAccessToken(ctx context.Context, u *user.SignedInUser, dashboardUid string, uid string) (*PublicDashboard, error) {
+	existingPubdash, err := pd.store.Find(ctx, uid)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("RotateAccessToken: failed to find public dashboard by uid: %s: %w", uid, err)
+	}
+
+	if existingPubdash == nil || existingPubdash.OrgId != u.OrgID || existingPubdash.DashboardUid != dashboardUid {
+		return nil, ErrPublicDashboardNotFound.Errorf("RotateAccessToken: public dashboard not found by uid: %s", uid)
+	}
+
+	accessToken, err := pd.NewPublicDashboardAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	affectedRows, err := pd.store.UpdateAccessToken(ctx, uid, accessToken, u.UserID, time.Now())
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("RotateAccessToken: failed to update access token: %w", err)
+	}
+
+	if affectedRows == 0 {
+		return nil, ErrPublicDashboardNotFound.Errorf("RotateAccessToken: failed to update public dashboard not found by uid: %s", uid)
+	}
+
+	newPubdash, err := pd.store.Find(ctx, uid)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("RotateAccessToken: failed to find public dashboard by uid: %s: %w", uid, err)
+	}
+
+	pd.log.Info("Public dashboard access token rotated", "publicDashboardUid", uid, "dashboardUid", dashboardUid, "user", u.Login)
+
+	return newPubdash, nil
+}
+
+// LogAccess Records a request made to a public dashboard
+func (pd *PublicDashboardServiceImpl) LogAccess(ctx context.Context, entry *AccessLogEntry) error {
+	if entry.AccessedAt.IsZero() {
+		entry.AccessedAt = time.Now()
+	}
+
+	if err := pd.store.CreateAccessLogEntry(ctx, entry); err != nil {
+		return ErrInternalServerError.Errorf("LogAccess: failed to record access to public dashboard %s: %w", entry.PublicDashboardUid, err)
+	}
+
+	return nil
+}
+
+// FindAccessLog Returns the requests made to a public dashboard, most recent first and with pagination
+func (pd *PublicDashboardServiceImpl) FindAccessLog(ctx context.Context, query *AccessLogQuery) (*AccessLogResponseWithPagination, error) {
+	query.Offset = query.Limit * (query.Page - 1)
+	resp, err := pd.store.FindAccessLog(ctx, query)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("FindAccessLog: %w", err)
+	}
+
+	resp.Page = query.Page
+	resp.PerPage = query.Limit
+
+	return resp, nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	dashboardsDB "github.com/grafana/grafana/pkg/services/dashboards/database"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/database"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
+	"github.com/grafana/grafana/pkg/services/tag/tagimpl"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAccessTest(t *testing.T) (*PublicDashboardServiceImpl, *PublicDashboard) {
+	t.Helper()
+
+	sqlStore := db.InitTestDB(t)
+	quotaService := quotatest.New(false, nil)
+	dashboardStore, err := dashboardsDB.ProvideDashboardStore(sqlStore, sqlStore.Cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore, sqlStore.Cfg), quotaService)
+	require.NoError(t, err)
+	publicdashboardStore := database.ProvideStore(sqlStore, sqlStore.Cfg, featuremgmt.WithFeatures())
+	dashboard := insertTestDashboard(t, dashboardStore, "testDashie", 1, 0, true, []map[string]interface{}{}, nil)
+
+	service := &PublicDashboardServiceImpl{
+		log:            log.New("test.logger"),
+		store:          publicdashboardStore,
+		serviceWrapper: ProvideServiceWrapper(publicdashboardStore),
+	}
+
+	dto := &SavePublicDashboardDTO{
+		DashboardUid: dashboard.UID,
+		UserId:       7,
+		PublicDashboard: &PublicDashboardDTO{
+			OrgId:        dashboard.OrgID,
+			IsEnabled:    util.Pointer(true),
+			ExpiresAt:    util.Pointer(time.Now().Add(24 * time.Hour).Truncate(time.Second)),
+			AllowedCIDRs: []string{"10.0.0.0/8"},
+		},
+	}
+	pubdash, err := service.Create(context.Background(), SignedInUser, dto)
+	require.NoError(t, err)
+
+	return service, pubdash
+}
+
+func TestUpdatePublicDashboardExpirationAndAllowedCIDRs(t *testing.T) {
+	t.Run("Keeps the expiration and the allowed networks when not set", func(t *testing.T) {
+		service, pubdash := setupAccessTest(t)
+		require.NotNil(t, pubdash.ExpiresAt)
+		assert.Equal(t, []string{"10.0.0.0/8"}, pubdash.AllowedCIDRs)
+
+		updated, err := service.Update(context.Background(), SignedInUser, &SavePublicDashboardDTO{
+			DashboardUid:    pubdash.DashboardUid,
+			UserId:          8,
+			PublicDashboard: &PublicDashboardDTO{Uid: pubdash.Uid, IsEnabled: util.Pointer(false)},
+		})
+		require.NoError(t, err)
+
+		require.NotNil(t, updated.ExpiresAt)
+		assert.True(t, pubdash.ExpiresAt.Equal(*updated.ExpiresAt))
+		assert.Equal(t, pubdash.AllowedCIDRs, updated.AllowedCIDRs)
+	})
+
+	t.Run("Removes the expiration and the allowed networks", func(t *testing.T) {
+		service, pubdash := setupAccessTest(t)
+
+		updated, err := service.Update(context.Background(), SignedInUser, &SavePublicDashboardDTO{
+			DashboardUid: pubdash.DashboardUid,
+			UserId:       8,
+			PublicDashboard: &PublicDashboardDTO{
+				Uid:          pubdash.Uid,
+				ExpiresAt:    &time.Time{},
+				AllowedCIDRs: []string{},
+			},
+		})
+		require.NoError(t, err)
+
+		assert.Nil(t, updated.ExpiresAt)
+		assert.Empty(t, updated.AllowedCIDRs)
+	})
+
+	t.Run("Rejects an expiration in the past", func(t *testing.T) {
+		service, pubdash := setupAccessTest(t)
+
+		_, err := service.Update(context.Background(), SignedInUser, &SavePublicDashboardDTO{
+			DashboardUid: pubdash.DashboardUid,
+			UserId:       8,
+			PublicDashboard: &PublicDashboardDTO{
+				Uid:       pubdash.Uid,
+				ExpiresAt: util.Pointer(time.Now().Add(-time.Hour)),
+			},
+		})
+		require.ErrorIs(t, err, ErrInvalidExpiration)
+	})
+}
+
+func TestRotateAccessToken(t *testing.T) {
+	orgUser := &user.SignedInUser{UserID: 1234, OrgID: 1, Login: "user@login.com"}
+
+	t.Run("Replaces the access token", func(t *testing.T) {
+		service, pubdash := setupAccessTest(t)
+
+		rotated, err := service.RotateAccessToken(context.Background(), orgUser, pubdash.DashboardUid, pubdash.Uid)
+		require.NoError(t, err)
+		assert.NotEqual(t, pubdash.AccessToken, rotated.AccessToken)
+		assert.Equal(t, orgUser.UserID, rotated.UpdatedBy)
+
+		_, err = service.FindByAccessToken(context.Background(), pubdash.AccessToken)
+		require.ErrorIs(t, err, ErrPublicDashboardNotFound)
+
+		found, err := service.FindByAccessToken(context.Background(), rotated.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, pubdash.Uid, found.Uid)
+	})
+
+	t.Run("Returns ErrPublicDashboardNotFound for the public dashboard of another dashboard", func(t *testing.T) {
+		service, pubdash := setupAccessTest(t)
+
+		_, err := service.RotateAccessToken(context.Background(), orgUser, "anotherdashboard", pubdash.Uid)
+		require.ErrorIs(t, err, ErrPublicDashboardNotFound)
+	})
+}
+
+func TestFindAccessLog(t *testing.T) {
+	service, pubdash := setupAccessTest(t)
+	ctx := context.Background()
+
+	for _, kind := range []AccessKind{AccessKindView, AccessKindQuery, AccessKindQuery} {
+		err := service.LogAccess(ctx, &AccessLogEntry{OrgId: pubdash.OrgId, PublicDashboardUid: pubdash.Uid, Kind: kind, IP: "10.0.0.1"})
+		require.NoError(t, err)
+	}
+
+	resp, err := service.FindAccessLog(ctx, &AccessLogQuery{OrgId: pubdash.OrgId, PublicDashboardUid: pubdash.Uid, Page: 2, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), resp.TotalCount)
+	assert.Equal(t, 2, resp.Page)
+	assert.Equal(t, 2, resp.PerPage)
+	require.Len(t, resp.Entries, 1)
+	// most recent first
+	assert.Equal(t, AccessKindView, resp.Entries[0].Kind)
+	assert.False(t, resp.Entries[0].AccessedAt.IsZero())
+}
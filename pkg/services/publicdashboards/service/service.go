@@ -116,6 +116,10 @@ func (pd *PublicDashboardServiceImpl) FindEnabledPublicDashboardAndDashboardByAc
 		return nil, nil, ErrPublicDashboardNotEnabled.Errorf("FindEnabledPublicDashboardAndDashboardByAccessToken: Public dashboard is not enabled accessToken: %s", accessToken)
 	}
 
+	if pubdash.IsExpired(time.Now()) {
+		return nil, nil, ErrPublicDashboardExpired.Errorf("FindEnabledPublicDashboardAndDashboardByAccessToken: Public dashboard expired accessToken: %s", accessToken)
+	}
+
 	return pubdash, dash, err
 }
 
@@ -418,6 +422,11 @@ func (pd *PublicDashboardServiceImpl) newCreatePublicDashboard(ctx context.Conte
 		share = PublicShareType
 	}
 
+	var expiresAt *time.Time
+	if dto.PublicDashboard.ExpiresAt != nil && !dto.PublicDashboard.ExpiresAt.IsZero() {
+		expiresAt = dto.PublicDashboard.ExpiresAt
+	}
+
 	return &PublicDashboard{
 		Uid:                  uid,
 		DashboardUid:         dto.DashboardUid,
@@ -427,6 +436,8 @@ func (pd *PublicDashboardServiceImpl) newCreatePublicDashboard(ctx context.Conte
 		TimeSelectionEnabled: timeSelectionEnabled,
 		TimeSettings:         timeSettings,
 		Share:                share,
+		ExpiresAt:            expiresAt,
+		AllowedCIDRs:         dto.PublicDashboard.AllowedCIDRs,
 		CreatedBy:            dto.UserId,
 		CreatedAt:            time.Now(),
 		AccessToken:          accessToken,
@@ -453,6 +464,20 @@ func newUpdatePublicDashboard(dto *SavePublicDashboardDTO, pd *PublicDashboard)
 		share = pd.Share
 	}
 
+	// a zero expiration removes the expiration
+	expiresAt := pd.ExpiresAt
+	if pubdashDTO.ExpiresAt != nil {
+		expiresAt = pubdashDTO.ExpiresAt
+		if expiresAt.IsZero() {
+			expiresAt = nil
+		}
+	}
+
+	allowedCIDRs := pubdashDTO.AllowedCIDRs
+	if pubdashDTO.AllowedCIDRs == nil {
+		allowedCIDRs = pd.AllowedCIDRs
+	}
+
 	return &PublicDashboard{
 		Uid:                  pd.Uid,
 		IsEnabled:            isEnabled,
@@ -460,6 +485,8 @@ func newUpdatePublicDashboard(dto *SavePublicDashboardDTO, pd *PublicDashboard)
 		TimeSelectionEnabled: timeSelectionEnabled,
 		TimeSettings:         timeSettings,
 		Share:                share,
+		ExpiresAt:            expiresAt,
+		AllowedCIDRs:         allowedCIDRs,
 		UpdatedBy:            dto.UserId,
 		UpdatedAt:            time.Now(),
 	}
@@ -1,6 +1,9 @@
 package validation
 
 import (
+	"net"
+	"time"
+
 	"github.com/google/uuid"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/tsdb/legacydata"
@@ -13,9 +16,29 @@ func ValidatePublicDashboard(dto *SavePublicDashboardDTO) error {
 		return ErrInvalidShareType.Errorf("ValidateSavePublicDashboard: invalid share type")
 	}
 
+	// a zero expiration removes the expiration
+	expiresAt := dto.PublicDashboard.ExpiresAt
+	if expiresAt != nil && !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return ErrInvalidExpiration.Errorf("ValidateSavePublicDashboard: expiration %s is in the past", expiresAt)
+	}
+
+	for _, cidr := range dto.PublicDashboard.AllowedCIDRs {
+		if !IsValidCIDR(cidr) {
+			return ErrInvalidCIDR.Errorf("ValidateSavePublicDashboard: invalid allowed network %s", cidr)
+		}
+	}
+
 	return nil
 }
 
+// IsValidCIDR checks that an allowed network is either a CIDR or a single ip address
+func IsValidCIDR(cidr string) bool {
+	if _, _, err := net.ParseCIDR(cidr); err == nil {
+		return true
+	}
+	return net.ParseIP(cidr) != nil
+}
+
 func ValidateQueryPublicDashboardRequest(req PublicDashboardQueryDTO, pd *PublicDashboard) error {
 	if req.IntervalMs < 0 {
 		return ErrInvalidInterval.Errorf("ValidateQueryPublicDashboardRequest: intervalMS should be greater than 0")
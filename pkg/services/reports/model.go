@@ -0,0 +1,157 @@
+package reports
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrReportNotFound  = errutil.NewBase(errutil.StatusNotFound, "reports.notFound", errutil.WithPublicMessage("Report not found"))
+	ErrBaseInvalid     = errutil.NewBase(errutil.StatusBadRequest, "reports.invalid")
+	ErrDashboardAccess = errutil.NewBase(errutil.StatusForbidden, "reports.dashboardAccessDenied", errutil.WithPublicMessage("Access denied to the report dashboard"))
+)
+
+// ErrInvalid returns a bad request error whose message is shown to the user
+func ErrInvalid(format string, args ...interface{}) error {
+	err := ErrBaseInvalid.Errorf(format, args...)
+	err.PublicMessage = fmt.Sprintf(format, args...)
+	return err
+}
+
+// Layout is how a dashboard is rendered into images
+type Layout string
+
+const (
+	// LayoutDashboard renders the whole dashboard as a single image
+	LayoutDashboard Layout = "dashboard"
+	// LayoutPanels renders one image per panel
+	LayoutPanels Layout = "panels"
+)
+
+type RunState string
+
+const (
+	RunStateRunning RunState = "running"
+	RunStateSuccess RunState = "success"
+	RunStateFailed  RunState = "failed"
+)
+
+type RunTrigger string
+
+const (
+	RunTriggerSchedule RunTrigger = "schedule"
+	RunTriggerManual   RunTrigger = "manual"
+)
+
+// Report is a dashboard rendered and delivered on a schedule
+type Report struct {
+	ID     int64  `xorm:"pk autoincr 'id'" json:"id"`
+	UID    string `xorm:"uid" json:"uid"`
+	OrgID  int64  `xorm:"org_id" json:"orgId"`
+	UserID int64  `xorm:"user_id" json:"userId"`
+	Name   string `xorm:"name" json:"name"`
+
+	DashboardUID string              `xorm:"dashboard_uid" json:"dashboardUid"`
+	Variables    map[string][]string `xorm:"variables" json:"variables"`
+	From         string              `xorm:"time_from" json:"from"`
+	To           string              `xorm:"time_to" json:"to"`
+
+	// Schedule is a standard five field cron expression, evaluated in Timezone
+	Schedule string `xorm:"schedule" json:"schedule"`
+	Timezone string `xorm:"timezone" json:"timezone"`
+	Enabled  bool   `xorm:"enabled" json:"enabled"`
+	// NextRun is the unix time of the next scheduled run, zero when the report is disabled
+	NextRun int64 `xorm:"next_run" json:"nextRun"`
+
+	Layout Layout `xorm:"layout" json:"layout"`
+	Width  int    `xorm:"width" json:"width"`
+	Height int    `xorm:"height" json:"height"`
+	Theme  string `xorm:"theme" json:"theme"`
+
+	Recipients []string `xorm:"recipients" json:"recipients"`
+	Subject    string   `xorm:"subject" json:"subject"`
+	Message    string   `xorm:"message" json:"message"`
+	WebhookURL string   `xorm:"webhook_url" json:"webhookUrl"`
+
+	Created time.Time `xorm:"created" json:"created"`
+	Updated time.Time `xorm:"updated" json:"updated"`
+}
+
+// Run is one execution of a report
+type Run struct {
+	ID       int64      `xorm:"pk autoincr 'id'" json:"id"`
+	ReportID int64      `xorm:"report_id" json:"reportId"`
+	OrgID    int64      `xorm:"org_id" json:"orgId"`
+	Trigger  RunTrigger `xorm:"trigger_type" json:"trigger"`
+	State    RunState   `xorm:"state" json:"state"`
+	Error    string     `xorm:"error" json:"error,omitempty"`
+	Images   int        `xorm:"images" json:"images"`
+	Started  time.Time  `xorm:"started" json:"started"`
+	Finished *time.Time `xorm:"finished" json:"finished,omitempty"`
+}
+
+func (r Run) TableName() string { return "report_run" }
+
+// ReportSpec is the user editable part of a report
+type ReportSpec struct {
+	Name         string              `json:"name"`
+	DashboardUID string              `json:"dashboardUid"`
+	Variables    map[string][]string `json:"variables"`
+	From         string              `json:"from"`
+	To           string              `json:"to"`
+	Schedule     string              `json:"schedule"`
+	Timezone     string              `json:"timezone"`
+	Enabled      bool                `json:"enabled"`
+	Layout       Layout              `json:"layout"`
+	Width        int                 `json:"width"`
+	Height       int                 `json:"height"`
+	Theme        string              `json:"theme"`
+	Recipients   []string            `json:"recipients"`
+	Subject      string              `json:"subject"`
+	Message      string              `json:"message"`
+	WebhookURL   string              `json:"webhookUrl"`
+}
+
+type CreateReportCommand struct {
+	ReportSpec
+
+	OrgID        int64              `json:"-"`
+	SignedInUser *user.SignedInUser `json:"-"`
+}
+
+type UpdateReportCommand struct {
+	ReportSpec
+
+	UID          string             `json:"-"`
+	OrgID        int64              `json:"-"`
+	SignedInUser *user.SignedInUser `json:"-"`
+}
+
+type DeleteReportCommand struct {
+	UID   string
+	OrgID int64
+}
+
+type GetReportQuery struct {
+	UID   string
+	OrgID int64
+}
+
+type GetReportsQuery struct {
+	OrgID        int64
+	DashboardUID string
+}
+
+type SendReportCommand struct {
+	UID   string
+	OrgID int64
+}
+
+type GetRunsQuery struct {
+	UID   string
+	OrgID int64
+	Limit int
+}
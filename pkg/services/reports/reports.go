@@ -0,0 +1,16 @@
+package reports
+
+import (
+	"context"
+)
+
+type Service interface {
+	CreateReport(ctx context.Context, cmd *CreateReportCommand) (*Report, error)
+	UpdateReport(ctx context.Context, cmd *UpdateReportCommand) (*Report, error)
+	DeleteReport(ctx context.Context, cmd *DeleteReportCommand) error
+	GetReport(ctx context.Context, query *GetReportQuery) (*Report, error)
+	GetReports(ctx context.Context, query *GetReportsQuery) ([]*Report, error)
+	// SendReport runs a report immediately, regardless of its schedule
+	SendReport(ctx context.Context, cmd *SendReportCommand) (*Run, error)
+	GetRuns(ctx context.Context, query *GetRunsQuery) ([]*Run, error)
+}
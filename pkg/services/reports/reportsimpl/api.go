@@ -0,0 +1,115 @@
+package reportsimpl
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/reports"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+
+	routeRegister.Group("/api/scheduled-reports", func(reportsRoute routing.RouteRegister) {
+		reportsRoute.Get("/", authorize(ac.EvalPermission(ActionRead)), routing.Wrap(s.listHandler))
+		reportsRoute.Post("/", authorize(ac.EvalPermission(ActionCreate)), routing.Wrap(s.createHandler))
+		reportsRoute.Get("/:uid", authorize(ac.EvalPermission(ActionRead)), routing.Wrap(s.getHandler))
+		reportsRoute.Put("/:uid", authorize(ac.EvalPermission(ActionWrite)), routing.Wrap(s.updateHandler))
+		reportsRoute.Delete("/:uid", authorize(ac.EvalPermission(ActionDelete)), routing.Wrap(s.deleteHandler))
+		reportsRoute.Post("/:uid/send", authorize(ac.EvalPermission(ActionSend)), routing.Wrap(s.sendHandler))
+		reportsRoute.Get("/:uid/runs", authorize(ac.EvalPermission(ActionRead)), routing.Wrap(s.runsHandler))
+	}, middleware.ReqSignedIn)
+}
+
+func (s *Service) listHandler(c *contextmodel.ReqContext) response.Response {
+	result, err := s.GetReports(c.Req.Context(), &reports.GetReportsQuery{
+		OrgID:        c.OrgID,
+		DashboardUID: c.Query("dashboardUid"),
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get reports", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (s *Service) createHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := reports.CreateReportCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgID
+	cmd.SignedInUser = c.SignedInUser
+
+	result, err := s.CreateReport(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to create report", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (s *Service) getHandler(c *contextmodel.ReqContext) response.Response {
+	result, err := s.GetReport(c.Req.Context(), &reports.GetReportQuery{
+		UID:   web.Params(c.Req)[":uid"],
+		OrgID: c.OrgID,
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get report", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (s *Service) updateHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := reports.UpdateReportCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.UID = web.Params(c.Req)[":uid"]
+	cmd.OrgID = c.OrgID
+	cmd.SignedInUser = c.SignedInUser
+
+	result, err := s.UpdateReport(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to update report", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (s *Service) deleteHandler(c *contextmodel.ReqContext) response.Response {
+	err := s.DeleteReport(c.Req.Context(), &reports.DeleteReportCommand{
+		UID:   web.Params(c.Req)[":uid"],
+		OrgID: c.OrgID,
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to delete report", err)
+	}
+	return response.Success("Report deleted")
+}
+
+// sendHandler sends a report immediately and returns the resulting run
+func (s *Service) sendHandler(c *contextmodel.ReqContext) response.Response {
+	run, err := s.SendReport(c.Req.Context(), &reports.SendReportCommand{
+		UID:   web.Params(c.Req)[":uid"],
+		OrgID: c.OrgID,
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to send report", err)
+	}
+	return response.JSON(http.StatusOK, run)
+}
+
+func (s *Service) runsHandler(c *contextmodel.ReqContext) response.Response {
+	runs, err := s.GetRuns(c.Req.Context(), &reports.GetRunsQuery{
+		UID:   web.Params(c.Req)[":uid"],
+		OrgID: c.OrgID,
+		Limit: c.QueryInt("limit"),
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get report runs", err)
+	}
+	return response.JSON(http.StatusOK, runs)
+}
@@ -0,0 +1,53 @@
+package reportsimpl
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+const (
+	ActionRead   = "scheduled.reports:read"
+	ActionCreate = "scheduled.reports:create"
+	ActionWrite  = "scheduled.reports:write"
+	ActionDelete = "scheduled.reports:delete"
+	ActionSend   = "scheduled.reports:send"
+)
+
+var (
+	reportsReaderRole = accesscontrol.RoleDTO{
+		Name:        "fixed:scheduled.reports:reader",
+		DisplayName: "Scheduled report reader",
+		Description: "List scheduled reports and their run history",
+		Group:       "Scheduled reports",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionRead},
+		},
+	}
+
+	reportsWriterRole = accesscontrol.RoleDTO{
+		Name:        "fixed:scheduled.reports:writer",
+		DisplayName: "Scheduled report writer",
+		Description: "Create, update, delete and send scheduled reports",
+		Group:       "Scheduled reports",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionRead},
+			{Action: ActionCreate},
+			{Action: ActionWrite},
+			{Action: ActionDelete},
+			{Action: ActionSend},
+		},
+	}
+)
+
+func declareFixedRoles(ac accesscontrol.Service) error {
+	reader := accesscontrol.RoleRegistration{
+		Role:   reportsReaderRole,
+		Grants: []string{string(org.RoleAdmin)},
+	}
+	writer := accesscontrol.RoleRegistration{
+		Role:   reportsWriterRole,
+		Grants: []string{string(org.RoleAdmin)},
+	}
+
+	return ac.DeclareFixedRoles(reader, writer)
+}
@@ -0,0 +1,31 @@
+package reportsimpl
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// nextRun returns the first time after the given time that matches the cron schedule
+// in the given timezone. An empty timezone is UTC.
+func nextRun(schedule string, timezone string, after time.Time) (time.Time, error) {
+	loc := time.UTC
+	if timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := sched.Next(after.In(loc))
+	if next.IsZero() {
+		return time.Time{}, errNeverRuns
+	}
+	return next, nil
+}
@@ -0,0 +1,42 @@
+package reportsimpl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextRun(t *testing.T) {
+	after := time.Date(2023, 3, 10, 12, 30, 0, 0, time.UTC)
+
+	t.Run("evaluates the schedule in UTC by default", func(t *testing.T) {
+		next, err := nextRun("0 8 * * 1", "", after)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2023, 3, 13, 8, 0, 0, 0, time.UTC), next.UTC())
+	})
+
+	t.Run("evaluates the schedule in the report timezone", func(t *testing.T) {
+		next, err := nextRun("0 8 * * *", "America/New_York", after)
+		require.NoError(t, err)
+		// 12:30 UTC is 07:30 EST, the next run is at 08:00 EST on the same day
+		require.Equal(t, time.Date(2023, 3, 10, 13, 0, 0, 0, time.UTC), next.UTC())
+	})
+
+	t.Run("supports descriptors", func(t *testing.T) {
+		next, err := nextRun("@daily", "", after)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2023, 3, 11, 0, 0, 0, 0, time.UTC), next.UTC())
+	})
+
+	t.Run("returns an error for invalid schedules", func(t *testing.T) {
+		_, err := nextRun("every monday", "", after)
+		require.Error(t, err)
+
+		_, err = nextRun("0 8 * * *", "Mars/Olympus_Mons", after)
+		require.Error(t, err)
+
+		_, err = nextRun("0 0 30 2 *", "", after)
+		require.ErrorIs(t, err, errNeverRuns)
+	})
+}
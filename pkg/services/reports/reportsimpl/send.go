@@ -0,0 +1,372 @@
+package reportsimpl
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/reports"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+const (
+	emailTemplate = "report"
+
+	defaultDashboardWidth  = 1500
+	defaultDashboardHeight = 1080
+	defaultPanelWidth      = 1000
+	defaultPanelHeight     = 500
+)
+
+// image is a rendered dashboard or panel
+type image struct {
+	PanelID int64
+	Title   string
+	Path    string
+}
+
+// sendReport renders and delivers a report, and records the outcome in the run history
+func (s *Service) sendReport(ctx context.Context, r *reports.Report, trigger reports.RunTrigger) *reports.Run {
+	logger := s.log.FromContext(ctx).New("uid", r.UID, "orgId", r.OrgID, "trigger", trigger)
+
+	run := &reports.Run{
+		ReportID: r.ID,
+		OrgID:    r.OrgID,
+		Trigger:  trigger,
+		State:    reports.RunStateRunning,
+		Started:  s.now(),
+	}
+	if err := s.store.InsertRun(ctx, run); err != nil {
+		logger.Error("Failed to save report run", "error", err)
+	}
+
+	dash, images, err := s.render(ctx, r)
+	if err == nil {
+		err = s.deliver(ctx, r, dash, images)
+	}
+	removeImages(logger, images)
+
+	finished := s.now()
+	run.Finished = &finished
+	run.Images = len(images)
+	run.State = reports.RunStateSuccess
+	if err != nil {
+		logger.Warn("Failed to send report", "error", err)
+		run.State = reports.RunStateFailed
+		run.Error = err.Error()
+	} else {
+		logger.Info("Report sent", "images", len(images), "duration", finished.Sub(run.Started))
+	}
+
+	if run.ID != 0 {
+		if err := s.store.UpdateRun(ctx, run); err != nil {
+			logger.Error("Failed to save report run", "error", err)
+		}
+	}
+	return run
+}
+
+// removeImages deletes the rendered images, they are only needed until the report is delivered
+func removeImages(logger log.Logger, images []image) {
+	for _, img := range images {
+		if err := os.Remove(img.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logger.Warn("Failed to delete rendered report image", "path", img.Path, "error", err)
+		}
+	}
+}
+
+// render renders the report dashboard as the report owner
+func (s *Service) render(ctx context.Context, r *reports.Report) (*dashboards.Dashboard, []image, error) {
+	dash, err := s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: r.DashboardUID, OrgID: r.OrgID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get dashboard %q: %w", r.DashboardUID, err)
+	}
+
+	usr, err := s.userService.GetSignedInUserWithCacheCtx(ctx, &user.GetSignedInUserQuery{UserID: r.UserID, OrgID: r.OrgID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get report owner: %w", err)
+	}
+	g, err := guardian.NewByDashboard(ctx, dash, r.OrgID, usr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if canView, err := g.CanView(); err != nil || !canView {
+		return nil, nil, reports.ErrDashboardAccess.Errorf("report owner cannot view dashboard %q", dash.UID)
+	}
+
+	authOpts := rendering.AuthOpts{OrgID: r.OrgID, UserID: usr.UserID, OrgRole: usr.OrgRole}
+	// A session shares the render key between the images of a report
+	session, err := s.renderService.CreateRenderingSession(ctx, authOpts, rendering.SessionOpts{
+		Expiry:                     s.renderTimeout * 2,
+		RefreshExpiryOnEachRequest: true,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create rendering session: %w", err)
+	}
+	defer session.Dispose(ctx)
+
+	opts := rendering.Opts{
+		AuthOpts: authOpts,
+		ErrorOpts: rendering.ErrorOpts{
+			ErrorConcurrentLimitReached: true,
+			ErrorRenderUnavailable:      true,
+		},
+		TimeoutOpts:     rendering.TimeoutOpts{Timeout: s.renderTimeout},
+		Timezone:        r.Timezone,
+		Theme:           models.Theme(r.Theme),
+		ConcurrentLimit: s.cfg.RendererConcurrentRequestLimit,
+	}
+
+	params := dashboardParams(r)
+	images := []image{}
+
+	switch r.Layout {
+	case reports.LayoutPanels:
+		opts.Width, opts.Height = sizeOrDefault(r.Width, defaultPanelWidth), sizeOrDefault(r.Height, defaultPanelHeight)
+		for _, p := range dashboardPanels(dash.Data) {
+			q := cloneParams(params)
+			q.Set("panelId", strconv.FormatInt(p.ID, 10))
+			opts.Path = renderPath("d-solo", dash, q)
+
+			result, err := s.renderService.Render(ctx, opts, session)
+			if err != nil {
+				return dash, images, fmt.Errorf("failed to render panel %d: %w", p.ID, err)
+			}
+			images = append(images, image{PanelID: p.ID, Title: p.Title, Path: result.FilePath})
+		}
+	default:
+		opts.Width, opts.Height = sizeOrDefault(r.Width, defaultDashboardWidth), sizeOrDefault(r.Height, defaultDashboardHeight)
+		if r.Height == 0 {
+			// Capture the whole dashboard when the renderer supports it
+			if res, err := s.renderService.HasCapability(ctx, rendering.FullHeightImages); err == nil && res.IsSupported {
+				opts.Height = -1
+			}
+		}
+		q := cloneParams(params)
+		q.Set("kiosk", "")
+		opts.Path = renderPath("d", dash, q)
+
+		result, err := s.renderService.Render(ctx, opts, session)
+		if err != nil {
+			return dash, images, fmt.Errorf("failed to render dashboard: %w", err)
+		}
+		images = append(images, image{Title: dash.Title, Path: result.FilePath})
+	}
+
+	return dash, images, nil
+}
+
+func (s *Service) deliver(ctx context.Context, r *reports.Report, dash *dashboards.Dashboard, images []image) error {
+	dashboardURL := dashboards.GetFullDashboardURL(dash.UID, dash.Slug) + "?" + dashboardParams(r).Encode()
+
+	if len(r.Recipients) > 0 {
+		if err := s.sendEmail(ctx, r, dash, dashboardURL, images); err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+	}
+	if r.WebhookURL != "" {
+		if err := s.sendWebhook(ctx, r, dash, dashboardURL, images); err != nil {
+			return fmt.Errorf("failed to send webhook: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) sendEmail(ctx context.Context, r *reports.Report, dash *dashboards.Dashboard, dashboardURL string, images []image) error {
+	subject := r.Subject
+	if subject == "" {
+		subject = r.Name
+	}
+
+	files := make([]string, 0, len(images))
+	embedded := make([]map[string]interface{}, 0, len(images))
+	for _, img := range images {
+		files = append(files, img.Path)
+		// Embedded files are referenced by their file name
+		embedded = append(embedded, map[string]interface{}{
+			"Title": img.Title,
+			"CID":   filepath.Base(img.Path),
+		})
+	}
+
+	return s.notificationService.SendEmailCommandHandlerSync(ctx, &notifications.SendEmailCommandSync{
+		SendEmailCommand: notifications.SendEmailCommand{
+			To:       r.Recipients,
+			Template: emailTemplate,
+			Subject:  subject,
+			Data: map[string]interface{}{
+				"Name":           r.Name,
+				"Message":        r.Message,
+				"DashboardTitle": dash.Title,
+				"DashboardURL":   dashboardURL,
+				"TimeRange":      timeRangeText(r),
+				"Images":         embedded,
+			},
+			EmbeddedFiles: files,
+		},
+	})
+}
+
+type webhookImage struct {
+	PanelID     int64  `json:"panelId,omitempty"`
+	Title       string `json:"title"`
+	ContentType string `json:"contentType"`
+	Data        string `json:"data"`
+}
+
+type webhookPayload struct {
+	UID       string         `json:"uid"`
+	Name      string         `json:"name"`
+	Message   string         `json:"message,omitempty"`
+	Dashboard webhookRef     `json:"dashboard"`
+	From      string         `json:"from,omitempty"`
+	To        string         `json:"to,omitempty"`
+	Images    []webhookImage `json:"images"`
+}
+
+type webhookRef struct {
+	UID   string `json:"uid"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+func (s *Service) sendWebhook(ctx context.Context, r *reports.Report, dash *dashboards.Dashboard, dashboardURL string, images []image) error {
+	// The host may resolve to another address than when the report was saved
+	if err := s.checkWebhookURL(ctx, r.WebhookURL); err != nil {
+		return err
+	}
+
+	payload := webhookPayload{
+		UID:       r.UID,
+		Name:      r.Name,
+		Message:   r.Message,
+		Dashboard: webhookRef{UID: dash.UID, Title: dash.Title, URL: dashboardURL},
+		From:      r.From,
+		To:        r.To,
+		Images:    make([]webhookImage, 0, len(images)),
+	}
+	for _, img := range images {
+		content, err := os.ReadFile(img.Path)
+		if err != nil {
+			return err
+		}
+		payload.Images = append(payload.Images, webhookImage{
+			PanelID:     img.PanelID,
+			Title:       img.Title,
+			ContentType: "image/png",
+			Data:        base64.StdEncoding.EncodeToString(content),
+		})
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return s.notificationService.SendWebhookSync(ctx, &notifications.SendWebhookSync{
+		Url:         r.WebhookURL,
+		Body:        string(body),
+		HttpMethod:  "POST",
+		ContentType: "application/json",
+	})
+}
+
+type panel struct {
+	ID    int64
+	Title string
+}
+
+// dashboardPanels returns the panels to render, including the panels of collapsed rows
+func dashboardPanels(data *simplejson.Json) []panel {
+	panels := []panel{}
+	var collect func(list []interface{})
+	collect = func(list []interface{}) {
+		for _, item := range list {
+			p := simplejson.NewFromAny(item)
+			if p.Get("type").MustString() == "row" {
+				collect(p.Get("panels").MustArray())
+				continue
+			}
+			id, err := p.Get("id").Int64()
+			if err != nil {
+				continue
+			}
+			panels = append(panels, panel{ID: id, Title: p.Get("title").MustString()})
+		}
+	}
+
+	collect(data.Get("panels").MustArray())
+	// Dashboards saved before schema version 16 keep their panels in rows
+	for _, row := range data.Get("rows").MustArray() {
+		collect(simplejson.NewFromAny(row).Get("panels").MustArray())
+	}
+	return panels
+}
+
+// dashboardParams returns the query parameters selecting the report time range and variables
+func dashboardParams(r *reports.Report) url.Values {
+	q := url.Values{}
+	q.Set("orgId", strconv.FormatInt(r.OrgID, 10))
+	if r.From != "" {
+		q.Set("from", r.From)
+		q.Set("to", r.To)
+	}
+	if r.Timezone != "" {
+		q.Set("timezone", r.Timezone)
+	}
+
+	names := make([]string, 0, len(r.Variables))
+	for name := range r.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range r.Variables[name] {
+			q.Add("var-"+name, value)
+		}
+	}
+	return q
+}
+
+func renderPath(prefix string, dash *dashboards.Dashboard, q url.Values) string {
+	u := url.URL{Path: path.Join(prefix, dash.UID, dash.Slug), RawQuery: q.Encode()}
+	return u.String()
+}
+
+func cloneParams(q url.Values) url.Values {
+	c := make(url.Values, len(q))
+	for k, v := range q {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
+func sizeOrDefault(size, def int) int {
+	if size <= 0 {
+		return def
+	}
+	return size
+}
+
+func timeRangeText(r *reports.Report) string {
+	if r.From == "" {
+		return "dashboard default"
+	}
+	return fmt.Sprintf("%s to %s", r.From, r.To)
+}
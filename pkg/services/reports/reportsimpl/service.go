@@ -0,0 +1,331 @@
+package reportsimpl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/mail"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/reports"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	// schedulerInterval is how often due reports are looked up
+	schedulerInterval = time.Minute
+	defaultRunsLimit  = 20
+	maxRunsLimit      = 100
+)
+
+var errNeverRuns = errors.New("schedule never runs")
+
+type Service struct {
+	cfg                 *setting.Cfg
+	store               store
+	accessControl       ac.AccessControl
+	dashboardService    dashboards.DashboardService
+	userService         user.Service
+	renderService       rendering.Service
+	notificationService notifications.Service
+	serverLock          *serverlock.ServerLockService
+	log                 log.Logger
+	now                 func() time.Time
+
+	enabled          bool
+	renderTimeout    time.Duration
+	webhookAllowlist webhookAllowlist
+	lookupIP         func(ctx context.Context, host string) ([]net.IP, error)
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sql db.DB,
+	routeRegister routing.RouteRegister,
+	accessControl ac.AccessControl,
+	accesscontrolService ac.Service,
+	dashboardService dashboards.DashboardService,
+	userService user.Service,
+	renderService rendering.Service,
+	notificationService notifications.Service,
+	serverLock *serverlock.ServerLockService,
+) (*Service, error) {
+	section := cfg.SectionWithEnvOverrides("scheduled_reports")
+	s := &Service{
+		cfg:                 cfg,
+		store:               &sqlStore{db: sql},
+		accessControl:       accessControl,
+		dashboardService:    dashboardService,
+		userService:         userService,
+		renderService:       renderService,
+		notificationService: notificationService,
+		serverLock:          serverLock,
+		log:                 log.New("reports"),
+		now:                 time.Now,
+		enabled:             section.Key("enabled").MustBool(true),
+		renderTimeout:       section.Key("render_timeout").MustDuration(time.Minute),
+		webhookAllowlist:    newWebhookAllowlist(section.Key("webhook_allowed_hosts").MustString("")),
+		lookupIP: func(ctx context.Context, host string) ([]net.IP, error) {
+			return net.DefaultResolver.LookupIP(ctx, "ip", host)
+		},
+	}
+
+	if !s.enabled {
+		return s, nil
+	}
+
+	if err := declareFixedRoles(accesscontrolService); err != nil {
+		return nil, err
+	}
+
+	s.registerAPIEndpoints(routeRegister)
+
+	return s, nil
+}
+
+// Run sends the reports that are due. In HA setups the server lock keeps instances from
+// polling at the same time, and each report is claimed before it is sent.
+func (s *Service) Run(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := s.serverLock.LockAndExecute(ctx, "send scheduled reports", schedulerInterval/2, s.sendDueReports)
+			if err != nil {
+				s.log.Error("Failed to lock and execute scheduled reports", "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) sendDueReports(ctx context.Context) {
+	now := s.now()
+	due, err := s.store.GetDue(ctx, now.Unix())
+	if err != nil {
+		s.log.Error("Failed to get due reports", "error", err)
+		return
+	}
+
+	for _, r := range due {
+		var next int64
+		if t, err := nextRun(r.Schedule, r.Timezone, now); err != nil {
+			s.log.Warn("Disabling report with invalid schedule", "uid", r.UID, "orgId", r.OrgID, "schedule", r.Schedule, "error", err)
+		} else {
+			next = t.Unix()
+		}
+
+		claimed, err := s.store.Claim(ctx, r.ID, r.NextRun, next)
+		if err != nil {
+			s.log.Error("Failed to claim report", "uid", r.UID, "orgId", r.OrgID, "error", err)
+			continue
+		}
+		if !claimed || next == 0 {
+			continue
+		}
+
+		s.sendReport(ctx, r, reports.RunTriggerSchedule)
+	}
+}
+
+func (s *Service) CreateReport(ctx context.Context, cmd *reports.CreateReportCommand) (*reports.Report, error) {
+	now := s.now()
+	r := &reports.Report{
+		UID:     util.GenerateShortUID(),
+		OrgID:   cmd.OrgID,
+		UserID:  cmd.SignedInUser.UserID,
+		Created: now,
+		Updated: now,
+	}
+	if err := s.applySpec(ctx, r, cmd.ReportSpec, cmd.SignedInUser, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Insert(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// UpdateReport replaces the report definition. The report is then sent as the updating user.
+func (s *Service) UpdateReport(ctx context.Context, cmd *reports.UpdateReportCommand) (*reports.Report, error) {
+	r, err := s.store.Get(ctx, cmd.OrgID, cmd.UID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if err := s.applySpec(ctx, r, cmd.ReportSpec, cmd.SignedInUser, now); err != nil {
+		return nil, err
+	}
+	r.UserID = cmd.SignedInUser.UserID
+	r.Updated = now
+
+	if err := s.store.Update(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *Service) DeleteReport(ctx context.Context, cmd *reports.DeleteReportCommand) error {
+	return s.store.Delete(ctx, cmd.OrgID, cmd.UID)
+}
+
+func (s *Service) GetReport(ctx context.Context, query *reports.GetReportQuery) (*reports.Report, error) {
+	return s.store.Get(ctx, query.OrgID, query.UID)
+}
+
+func (s *Service) GetReports(ctx context.Context, query *reports.GetReportsQuery) ([]*reports.Report, error) {
+	return s.store.List(ctx, query)
+}
+
+func (s *Service) SendReport(ctx context.Context, cmd *reports.SendReportCommand) (*reports.Run, error) {
+	r, err := s.store.Get(ctx, cmd.OrgID, cmd.UID)
+	if err != nil {
+		return nil, err
+	}
+	return s.sendReport(ctx, r, reports.RunTriggerManual), nil
+}
+
+func (s *Service) GetRuns(ctx context.Context, query *reports.GetRunsQuery) ([]*reports.Run, error) {
+	r, err := s.store.Get(ctx, query.OrgID, query.UID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultRunsLimit
+	}
+	if limit > maxRunsLimit {
+		limit = maxRunsLimit
+	}
+	return s.store.GetRuns(ctx, r.ID, limit)
+}
+
+// applySpec validates the spec, applies defaults and copies it to the report
+func (s *Service) applySpec(ctx context.Context, r *reports.Report, spec reports.ReportSpec, usr *user.SignedInUser, now time.Time) error {
+	if spec.Name == "" {
+		return reports.ErrInvalid("report name is required")
+	}
+	if spec.DashboardUID == "" {
+		return reports.ErrInvalid("dashboard UID is required")
+	}
+
+	dash, err := s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: spec.DashboardUID, OrgID: usr.OrgID})
+	if err != nil {
+		if errors.Is(err, dashboards.ErrDashboardNotFound) {
+			return reports.ErrInvalid("dashboard %q not found", spec.DashboardUID)
+		}
+		return err
+	}
+	g, err := guardian.NewByDashboard(ctx, dash, usr.OrgID, usr)
+	if err != nil {
+		return err
+	}
+	if canView, err := g.CanView(); err != nil || !canView {
+		return reports.ErrDashboardAccess.Errorf("user cannot view dashboard %q", dash.UID)
+	}
+
+	if (spec.From == "") != (spec.To == "") {
+		return reports.ErrInvalid("both from and to must be set, or neither to use the dashboard time range")
+	}
+	if spec.From != "" {
+		tr := legacydata.NewDataTimeRange(spec.From, spec.To)
+		from, err := tr.ParseFrom()
+		if err != nil {
+			return reports.ErrInvalid("invalid time range from %q", spec.From)
+		}
+		to, err := tr.ParseTo()
+		if err != nil {
+			return reports.ErrInvalid("invalid time range to %q", spec.To)
+		}
+		if !from.Before(to) {
+			return reports.ErrInvalid("time range from must be before to")
+		}
+	}
+
+	if spec.Schedule == "" {
+		return reports.ErrInvalid("schedule is required")
+	}
+	next, err := nextRun(spec.Schedule, spec.Timezone, now)
+	if err != nil {
+		return reports.ErrInvalid("invalid schedule %q: %s", spec.Schedule, err)
+	}
+
+	switch spec.Layout {
+	case "":
+		spec.Layout = reports.LayoutDashboard
+	case reports.LayoutDashboard, reports.LayoutPanels:
+	default:
+		return reports.ErrInvalid("unknown layout %q", spec.Layout)
+	}
+
+	if spec.Width < 0 || spec.Height < 0 {
+		return reports.ErrInvalid("image width and height must be positive")
+	}
+
+	switch models.Theme(spec.Theme) {
+	case "":
+		spec.Theme = string(models.ThemeLight)
+	case models.ThemeLight, models.ThemeDark:
+	default:
+		return reports.ErrInvalid("unknown theme %q", spec.Theme)
+	}
+
+	if len(spec.Recipients) == 0 && spec.WebhookURL == "" {
+		return reports.ErrInvalid("at least one recipient or a webhook URL is required")
+	}
+	for _, recipient := range spec.Recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			return reports.ErrInvalid("invalid recipient %q", recipient)
+		}
+	}
+	if spec.WebhookURL != "" {
+		if err := s.checkWebhookURL(ctx, spec.WebhookURL); err != nil {
+			return err
+		}
+	}
+
+	r.Name = spec.Name
+	r.DashboardUID = spec.DashboardUID
+	r.Variables = spec.Variables
+	r.From = spec.From
+	r.To = spec.To
+	r.Schedule = spec.Schedule
+	r.Timezone = spec.Timezone
+	r.Enabled = spec.Enabled
+	r.Layout = spec.Layout
+	r.Width = spec.Width
+	r.Height = spec.Height
+	r.Theme = spec.Theme
+	r.Recipients = spec.Recipients
+	r.Subject = spec.Subject
+	r.Message = spec.Message
+	r.WebhookURL = spec.WebhookURL
+
+	r.NextRun = 0
+	if r.Enabled {
+		r.NextRun = next.Unix()
+	}
+	return nil
+}
@@ -0,0 +1,426 @@
+package reportsimpl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/reports"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeStore struct {
+	reports map[string]*reports.Report
+	runs    []*reports.Run
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{reports: map[string]*reports.Report{}}
+}
+
+func (f *fakeStore) Insert(_ context.Context, r *reports.Report) error {
+	r.ID = int64(len(f.reports) + 1)
+	f.reports[r.UID] = r
+	return nil
+}
+
+func (f *fakeStore) Update(_ context.Context, r *reports.Report) error {
+	f.reports[r.UID] = r
+	return nil
+}
+
+func (f *fakeStore) Delete(_ context.Context, _ int64, uid string) error {
+	delete(f.reports, uid)
+	return nil
+}
+
+func (f *fakeStore) Get(_ context.Context, orgID int64, uid string) (*reports.Report, error) {
+	r, ok := f.reports[uid]
+	if !ok || r.OrgID != orgID {
+		return nil, reports.ErrReportNotFound.Errorf("report %q not found", uid)
+	}
+	return r, nil
+}
+
+func (f *fakeStore) List(_ context.Context, _ *reports.GetReportsQuery) ([]*reports.Report, error) {
+	result := []*reports.Report{}
+	for _, r := range f.reports {
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+func (f *fakeStore) GetDue(_ context.Context, now int64) ([]*reports.Report, error) {
+	result := []*reports.Report{}
+	for _, r := range f.reports {
+		if r.Enabled && r.NextRun > 0 && r.NextRun <= now {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeStore) Claim(_ context.Context, id int64, prevNextRun int64, nextRun int64) (bool, error) {
+	for _, r := range f.reports {
+		if r.ID == id && r.NextRun == prevNextRun {
+			r.NextRun = nextRun
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeStore) InsertRun(_ context.Context, run *reports.Run) error {
+	run.ID = int64(len(f.runs) + 1)
+	f.runs = append(f.runs, run)
+	return nil
+}
+
+func (f *fakeStore) UpdateRun(_ context.Context, _ *reports.Run) error {
+	return nil
+}
+
+func (f *fakeStore) GetRuns(_ context.Context, reportID int64, _ int) ([]*reports.Run, error) {
+	result := []*reports.Run{}
+	for _, run := range f.runs {
+		if run.ReportID == reportID {
+			result = append(result, run)
+		}
+	}
+	return result, nil
+}
+
+type fakeSession struct {
+	rendering.Session
+}
+
+func (fakeSession) Dispose(context.Context) {}
+
+type testEnv struct {
+	service       *Service
+	store         *fakeStore
+	renderService *rendering.MockService
+	notifications *notifications.NotificationServiceMock
+	guardian      *guardian.FakeDashboardGuardian
+	image         string
+}
+
+func setupTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	dash := dashboards.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+		"uid":   "dash",
+		"title": "Servers",
+		"panels": []interface{}{
+			map[string]interface{}{"id": 1, "type": "timeseries", "title": "CPU"},
+			map[string]interface{}{"id": 2, "type": "row", "title": "Details", "panels": []interface{}{
+				map[string]interface{}{"id": 3, "type": "table", "title": "Disks"},
+			}},
+		},
+	}))
+	dash.OrgID = 1
+	dashboardService := dashboards.NewFakeDashboardService(t)
+	dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(dash, nil).Maybe()
+
+	userService := usertest.NewUserServiceFake()
+	userService.ExpectedSignedInUser = &user.SignedInUser{UserID: 2, OrgID: 1, OrgRole: org.RoleEditor}
+
+	g := &guardian.FakeDashboardGuardian{CanViewValue: true}
+	guardian.MockDashboardGuardian(g)
+
+	image := filepath.Join(t.TempDir(), "render.png")
+	require.NoError(t, os.WriteFile(image, []byte("png"), 0600))
+
+	ctrl := gomock.NewController(t)
+	renderService := rendering.NewMockService(ctrl)
+	notificationService := notifications.MockNotificationService()
+	store := newFakeStore()
+
+	return &testEnv{
+		service: &Service{
+			cfg:                 setting.NewCfg(),
+			store:               store,
+			dashboardService:    dashboardService,
+			userService:         userService,
+			renderService:       renderService,
+			notificationService: notificationService,
+			log:                 log.NewNopLogger(),
+			now:                 func() time.Time { return time.Date(2023, 3, 10, 12, 30, 0, 0, time.UTC) },
+			enabled:             true,
+			renderTimeout:       time.Minute,
+			lookupIP:            fakeLookupIP,
+		},
+		store:         store,
+		renderService: renderService,
+		notifications: notificationService,
+		guardian:      g,
+		image:         image,
+	}
+}
+
+func fakeLookupIP(_ context.Context, host string) ([]net.IP, error) {
+	switch host {
+	case "example.com":
+		return []net.IP{net.ParseIP("93.184.216.34")}, nil
+	case "internal.example.com":
+		return []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("10.0.0.5")}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (e *testEnv) expectRenders(t *testing.T, paths *[]string) {
+	t.Helper()
+
+	e.renderService.EXPECT().CreateRenderingSession(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeSession{}, nil)
+	e.renderService.EXPECT().HasCapability(gomock.Any(), rendering.FullHeightImages).Return(rendering.CapabilitySupportRequestResult{IsSupported: true}, nil).AnyTimes()
+	e.renderService.EXPECT().Render(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, opts rendering.Opts, _ rendering.Session) (*rendering.RenderResult, error) {
+			require.Equal(t, int64(2), opts.UserID)
+			require.Equal(t, org.RoleEditor, opts.OrgRole)
+			*paths = append(*paths, opts.Path)
+			return &rendering.RenderResult{FilePath: e.image}, nil
+		}).AnyTimes()
+}
+
+func testSpec() reports.ReportSpec {
+	return reports.ReportSpec{
+		Name:         "Weekly servers",
+		DashboardUID: "dash",
+		Variables:    map[string][]string{"server": {"a", "b"}},
+		From:         "now-7d",
+		To:           "now",
+		Schedule:     "0 8 * * 1",
+		Timezone:     "Europe/Paris",
+		Enabled:      true,
+		Recipients:   []string{"ops@example.com"},
+	}
+}
+
+func TestCreateReport(t *testing.T) {
+	usr := &user.SignedInUser{UserID: 2, OrgID: 1}
+
+	t.Run("applies defaults and schedules the next run", func(t *testing.T) {
+		env := setupTestEnv(t)
+
+		r, err := env.service.CreateReport(context.Background(), &reports.CreateReportCommand{ReportSpec: testSpec(), OrgID: 1, SignedInUser: usr})
+		require.NoError(t, err)
+		require.NotEmpty(t, r.UID)
+		require.Equal(t, int64(2), r.UserID)
+		require.Equal(t, reports.LayoutDashboard, r.Layout)
+		require.Equal(t, "light", r.Theme)
+		// Monday 08:00 in Paris is 07:00 UTC
+		require.Equal(t, time.Date(2023, 3, 13, 7, 0, 0, 0, time.UTC).Unix(), r.NextRun)
+	})
+
+	t.Run("does not schedule disabled reports", func(t *testing.T) {
+		env := setupTestEnv(t)
+
+		spec := testSpec()
+		spec.Enabled = false
+		r, err := env.service.CreateReport(context.Background(), &reports.CreateReportCommand{ReportSpec: spec, OrgID: 1, SignedInUser: usr})
+		require.NoError(t, err)
+		require.Zero(t, r.NextRun)
+	})
+
+	t.Run("validates the report", func(t *testing.T) {
+		env := setupTestEnv(t)
+
+		for name, modify := range map[string]func(*reports.ReportSpec){
+			"missing name":         func(s *reports.ReportSpec) { s.Name = "" },
+			"invalid schedule":     func(s *reports.ReportSpec) { s.Schedule = "daily" },
+			"invalid timezone":     func(s *reports.ReportSpec) { s.Timezone = "Nowhere" },
+			"partial time range":   func(s *reports.ReportSpec) { s.To = "" },
+			"invalid time range":   func(s *reports.ReportSpec) { s.From = "yesterday" },
+			"unknown layout":       func(s *reports.ReportSpec) { s.Layout = "grid" },
+			"invalid recipient":    func(s *reports.ReportSpec) { s.Recipients = []string{"not an email"} },
+			"invalid webhook":      func(s *reports.ReportSpec) { s.WebhookURL = "ftp://example.com" },
+			"loopback webhook":     func(s *reports.ReportSpec) { s.WebhookURL = "http://127.0.0.1:3000/api" },
+			"link-local webhook":   func(s *reports.ReportSpec) { s.WebhookURL = "http://169.254.169.254/latest/meta-data" },
+			"private webhook":      func(s *reports.ReportSpec) { s.WebhookURL = "https://internal.example.com/hook" },
+			"unresolvable webhook": func(s *reports.ReportSpec) { s.WebhookURL = "https://unknown.example.com/hook" },
+			"nowhere to deliver":   func(s *reports.ReportSpec) { s.Recipients = nil },
+			"negative image width": func(s *reports.ReportSpec) { s.Width = -1 },
+		} {
+			spec := testSpec()
+			modify(&spec)
+			_, err := env.service.CreateReport(context.Background(), &reports.CreateReportCommand{ReportSpec: spec, OrgID: 1, SignedInUser: usr})
+			require.ErrorIs(t, err, reports.ErrBaseInvalid, name)
+		}
+	})
+
+	t.Run("allows non-public webhook hosts from the allowlist", func(t *testing.T) {
+		env := setupTestEnv(t)
+		env.service.webhookAllowlist = newWebhookAllowlist("localhost, 10.0.0.0/8 ::1")
+
+		for _, webhookURL := range []string{"http://localhost:8080/hook", "https://internal.example.com/hook", "http://[::1]/hook"} {
+			spec := testSpec()
+			spec.WebhookURL = webhookURL
+			_, err := env.service.CreateReport(context.Background(), &reports.CreateReportCommand{ReportSpec: spec, OrgID: 1, SignedInUser: usr})
+			require.NoError(t, err, webhookURL)
+		}
+
+		spec := testSpec()
+		spec.WebhookURL = "http://127.0.0.1/hook"
+		_, err := env.service.CreateReport(context.Background(), &reports.CreateReportCommand{ReportSpec: spec, OrgID: 1, SignedInUser: usr})
+		require.ErrorIs(t, err, reports.ErrBaseInvalid)
+	})
+
+	t.Run("requires access to the dashboard", func(t *testing.T) {
+		env := setupTestEnv(t)
+		env.guardian.CanViewValue = false
+
+		_, err := env.service.CreateReport(context.Background(), &reports.CreateReportCommand{ReportSpec: testSpec(), OrgID: 1, SignedInUser: usr})
+		require.ErrorIs(t, err, reports.ErrDashboardAccess)
+	})
+}
+
+func TestSendReport(t *testing.T) {
+	usr := &user.SignedInUser{UserID: 2, OrgID: 1}
+
+	t.Run("renders the dashboard and sends it by email", func(t *testing.T) {
+		env := setupTestEnv(t)
+		paths := []string{}
+		env.expectRenders(t, &paths)
+
+		var sent *notifications.SendEmailCommandSync
+		env.notifications.EmailHandlerSync = func(_ context.Context, cmd *notifications.SendEmailCommandSync) error {
+			sent = cmd
+			return nil
+		}
+
+		r, err := env.service.CreateReport(context.Background(), &reports.CreateReportCommand{ReportSpec: testSpec(), OrgID: 1, SignedInUser: usr})
+		require.NoError(t, err)
+
+		run, err := env.service.SendReport(context.Background(), &reports.SendReportCommand{UID: r.UID, OrgID: 1})
+		require.NoError(t, err)
+		require.Equal(t, reports.RunStateSuccess, run.State, run.Error)
+		require.Equal(t, reports.RunTriggerManual, run.Trigger)
+		require.Equal(t, 1, run.Images)
+		require.NotNil(t, run.Finished)
+
+		require.Len(t, paths, 1)
+		u, err := url.Parse(paths[0])
+		require.NoError(t, err)
+		require.Equal(t, "d/dash/servers", u.Path)
+		require.Equal(t, []string{"a", "b"}, u.Query()["var-server"])
+		require.Equal(t, "now-7d", u.Query().Get("from"))
+		require.True(t, u.Query().Has("kiosk"))
+
+		require.NotNil(t, sent)
+		require.Equal(t, []string{"ops@example.com"}, sent.To)
+		require.Equal(t, "report", sent.Template)
+		require.Equal(t, "Weekly servers", sent.Subject)
+		require.Equal(t, []string{env.image}, sent.EmbeddedFiles)
+		images := sent.Data["Images"].([]map[string]interface{})
+		require.Equal(t, "render.png", images[0]["CID"])
+
+		_, err = os.Stat(env.image)
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("renders one image per panel and posts them to the webhook", func(t *testing.T) {
+		env := setupTestEnv(t)
+		paths := []string{}
+		env.expectRenders(t, &paths)
+
+		var body string
+		env.notifications.WebhookHandler = func(_ context.Context, cmd *notifications.SendWebhookSync) error {
+			require.Equal(t, "https://example.com/hook", cmd.Url)
+			body = cmd.Body
+			return nil
+		}
+
+		spec := testSpec()
+		spec.Layout = reports.LayoutPanels
+		spec.Recipients = nil
+		spec.WebhookURL = "https://example.com/hook"
+		r, err := env.service.CreateReport(context.Background(), &reports.CreateReportCommand{ReportSpec: spec, OrgID: 1, SignedInUser: usr})
+		require.NoError(t, err)
+
+		run, err := env.service.SendReport(context.Background(), &reports.SendReportCommand{UID: r.UID, OrgID: 1})
+		require.NoError(t, err)
+		require.Equal(t, reports.RunStateSuccess, run.State, run.Error)
+		require.Equal(t, 2, run.Images)
+
+		require.Len(t, paths, 2)
+		for i, panelID := range []string{"1", "3"} {
+			u, err := url.Parse(paths[i])
+			require.NoError(t, err)
+			require.Equal(t, "d-solo/dash/servers", u.Path)
+			require.Equal(t, panelID, u.Query().Get("panelId"))
+		}
+
+		payload := webhookPayload{}
+		require.NoError(t, json.Unmarshal([]byte(body), &payload))
+		require.Equal(t, r.UID, payload.UID)
+		require.Len(t, payload.Images, 2)
+		require.Equal(t, "Disks", payload.Images[1].Title)
+		require.Equal(t, "cG5n", payload.Images[1].Data)
+
+		_, err = os.Stat(env.image)
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("records failed runs", func(t *testing.T) {
+		env := setupTestEnv(t)
+		env.renderService.EXPECT().CreateRenderingSession(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeSession{}, nil)
+		env.renderService.EXPECT().HasCapability(gomock.Any(), gomock.Any()).Return(rendering.CapabilitySupportRequestResult{}, nil)
+		env.renderService.EXPECT().Render(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, rendering.ErrRenderUnavailable)
+
+		r, err := env.service.CreateReport(context.Background(), &reports.CreateReportCommand{ReportSpec: testSpec(), OrgID: 1, SignedInUser: usr})
+		require.NoError(t, err)
+
+		run, err := env.service.SendReport(context.Background(), &reports.SendReportCommand{UID: r.UID, OrgID: 1})
+		require.NoError(t, err)
+		require.Equal(t, reports.RunStateFailed, run.State)
+		require.Contains(t, run.Error, rendering.ErrRenderUnavailable.Error())
+
+		runs, err := env.service.GetRuns(context.Background(), &reports.GetRunsQuery{UID: r.UID, OrgID: 1})
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+	})
+
+	t.Run("sends due reports once and schedules the next run", func(t *testing.T) {
+		env := setupTestEnv(t)
+		paths := []string{}
+		env.expectRenders(t, &paths)
+		env.notifications.EmailHandlerSync = func(context.Context, *notifications.SendEmailCommandSync) error {
+			return errors.New("smtp down")
+		}
+
+		r, err := env.service.CreateReport(context.Background(), &reports.CreateReportCommand{ReportSpec: testSpec(), OrgID: 1, SignedInUser: usr})
+		require.NoError(t, err)
+		scheduled := r.NextRun
+		env.service.now = func() time.Time { return time.Unix(scheduled, 0).Add(time.Second) }
+
+		env.service.sendDueReports(context.Background())
+		env.service.sendDueReports(context.Background())
+
+		require.Len(t, paths, 1)
+		require.Equal(t, time.Unix(scheduled, 0).Add(7*24*time.Hour).Unix(), r.NextRun)
+		require.Len(t, env.store.runs, 1)
+		require.Equal(t, reports.RunTriggerSchedule, env.store.runs[0].Trigger)
+		require.Equal(t, reports.RunStateFailed, env.store.runs[0].State)
+		require.Contains(t, env.store.runs[0].Error, "smtp down")
+	})
+}
@@ -0,0 +1,145 @@
+package reportsimpl
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/reports"
+)
+
+type store interface {
+	Insert(ctx context.Context, report *reports.Report) error
+	Update(ctx context.Context, report *reports.Report) error
+	Delete(ctx context.Context, orgID int64, uid string) error
+	Get(ctx context.Context, orgID int64, uid string) (*reports.Report, error)
+	List(ctx context.Context, query *reports.GetReportsQuery) ([]*reports.Report, error)
+	// GetDue returns the enabled reports scheduled to run at or before now
+	GetDue(ctx context.Context, now int64) ([]*reports.Report, error)
+	// Claim moves the next run of a report forward, unless another instance already did.
+	// It reports whether this instance should send the report.
+	Claim(ctx context.Context, id int64, prevNextRun int64, nextRun int64) (bool, error)
+
+	InsertRun(ctx context.Context, run *reports.Run) error
+	UpdateRun(ctx context.Context, run *reports.Run) error
+	GetRuns(ctx context.Context, reportID int64, limit int) ([]*reports.Run, error)
+}
+
+type sqlStore struct {
+	db db.DB
+}
+
+func (s *sqlStore) Insert(ctx context.Context, report *reports.Report) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(report)
+		return err
+	})
+}
+
+func (s *sqlStore) Update(ctx context.Context, report *reports.Report) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.ID(report.ID).AllCols().Update(report)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return reports.ErrReportNotFound.Errorf("report %q not found", report.UID)
+		}
+		return nil
+	})
+}
+
+func (s *sqlStore) Delete(ctx context.Context, orgID int64, uid string) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		report := reports.Report{}
+		has, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(&report)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return reports.ErrReportNotFound.Errorf("report %q not found", uid)
+		}
+
+		if _, err := sess.Exec("DELETE FROM report_run WHERE report_id = ?", report.ID); err != nil {
+			return err
+		}
+		_, err = sess.Exec("DELETE FROM report WHERE id = ?", report.ID)
+		return err
+	})
+}
+
+func (s *sqlStore) Get(ctx context.Context, orgID int64, uid string) (*reports.Report, error) {
+	report := reports.Report{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(&report)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return reports.ErrReportNotFound.Errorf("report %q not found", uid)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (s *sqlStore) List(ctx context.Context, query *reports.GetReportsQuery) ([]*reports.Report, error) {
+	result := make([]*reports.Report, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		sess.Where("org_id = ?", query.OrgID)
+		if query.DashboardUID != "" {
+			sess.And("dashboard_uid = ?", query.DashboardUID)
+		}
+		return sess.Asc("name").Find(&result)
+	})
+	return result, err
+}
+
+func (s *sqlStore) GetDue(ctx context.Context, now int64) ([]*reports.Report, error) {
+	result := make([]*reports.Report, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("enabled = ? AND next_run > 0 AND next_run <= ?", true, now).Asc("next_run").Find(&result)
+	})
+	return result, err
+}
+
+func (s *sqlStore) Claim(ctx context.Context, id int64, prevNextRun int64, nextRun int64) (bool, error) {
+	claimed := false
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("UPDATE report SET next_run = ? WHERE id = ? AND next_run = ?", nextRun, id, prevNextRun)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		claimed = affected == 1
+		return nil
+	})
+	return claimed, err
+}
+
+func (s *sqlStore) InsertRun(ctx context.Context, run *reports.Run) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(run)
+		return err
+	})
+}
+
+func (s *sqlStore) UpdateRun(ctx context.Context, run *reports.Run) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.ID(run.ID).Cols("state", "error", "images", "finished").Update(run)
+		return err
+	})
+}
+
+func (s *sqlStore) GetRuns(ctx context.Context, reportID int64, limit int) ([]*reports.Run, error) {
+	result := make([]*reports.Run, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("report_id = ?", reportID).Desc("started").Desc("id").Limit(limit).Find(&result)
+	})
+	return result, err
+}
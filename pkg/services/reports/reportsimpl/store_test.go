@@ -0,0 +1,131 @@
+package reportsimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/reports"
+)
+
+func TestIntegrationReportStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	ss := &sqlStore{db: db.InitTestDB(t)}
+	now := time.Now().Truncate(time.Second)
+
+	newReport := func(uid string, nextRun int64) *reports.Report {
+		return &reports.Report{
+			UID:          uid,
+			OrgID:        1,
+			UserID:       1,
+			Name:         "Report " + uid,
+			DashboardUID: "dash",
+			Variables:    map[string][]string{"server": {"a", "b"}},
+			Schedule:     "0 8 * * *",
+			Enabled:      nextRun > 0,
+			NextRun:      nextRun,
+			Layout:       reports.LayoutPanels,
+			Theme:        "light",
+			Recipients:   []string{"ops@example.com"},
+			Created:      now,
+			Updated:      now,
+		}
+	}
+
+	due := newReport("due", now.Add(-time.Minute).Unix())
+	later := newReport("later", now.Add(time.Hour).Unix())
+	disabled := newReport("disabled", 0)
+	for _, r := range []*reports.Report{due, later, disabled} {
+		require.NoError(t, ss.Insert(ctx, r))
+		require.NotZero(t, r.ID)
+	}
+
+	t.Run("gets a report with its variables and recipients", func(t *testing.T) {
+		r, err := ss.Get(ctx, 1, "due")
+		require.NoError(t, err)
+		require.Equal(t, due.Variables, r.Variables)
+		require.Equal(t, due.Recipients, r.Recipients)
+		require.Equal(t, reports.LayoutPanels, r.Layout)
+
+		_, err = ss.Get(ctx, 2, "due")
+		require.ErrorIs(t, err, reports.ErrReportNotFound)
+	})
+
+	t.Run("lists the reports of an organization", func(t *testing.T) {
+		result, err := ss.List(ctx, &reports.GetReportsQuery{OrgID: 1})
+		require.NoError(t, err)
+		require.Len(t, result, 3)
+
+		result, err = ss.List(ctx, &reports.GetReportsQuery{OrgID: 1, DashboardUID: "other"})
+		require.NoError(t, err)
+		require.Empty(t, result)
+	})
+
+	t.Run("only returns enabled reports that are due", func(t *testing.T) {
+		result, err := ss.GetDue(ctx, now.Unix())
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.Equal(t, "due", result[0].UID)
+	})
+
+	t.Run("claims a report once", func(t *testing.T) {
+		next := now.Add(24 * time.Hour).Unix()
+		claimed, err := ss.Claim(ctx, due.ID, due.NextRun, next)
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		claimed, err = ss.Claim(ctx, due.ID, due.NextRun, next)
+		require.NoError(t, err)
+		require.False(t, claimed)
+
+		result, err := ss.GetDue(ctx, now.Unix())
+		require.NoError(t, err)
+		require.Empty(t, result)
+	})
+
+	t.Run("records runs, newest first", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			run := &reports.Run{
+				ReportID: due.ID,
+				OrgID:    1,
+				Trigger:  reports.RunTriggerSchedule,
+				State:    reports.RunStateRunning,
+				Started:  now.Add(time.Duration(i) * time.Minute),
+			}
+			require.NoError(t, ss.InsertRun(ctx, run))
+
+			finished := run.Started.Add(time.Second)
+			run.State = reports.RunStateSuccess
+			run.Images = i + 1
+			run.Finished = &finished
+			require.NoError(t, ss.UpdateRun(ctx, run))
+		}
+
+		runs, err := ss.GetRuns(ctx, due.ID, 2)
+		require.NoError(t, err)
+		require.Len(t, runs, 2)
+		require.Equal(t, 3, runs[0].Images)
+		require.Equal(t, reports.RunStateSuccess, runs[0].State)
+		require.NotNil(t, runs[0].Finished)
+	})
+
+	t.Run("deletes a report and its runs", func(t *testing.T) {
+		require.NoError(t, ss.Delete(ctx, 1, "due"))
+
+		_, err := ss.Get(ctx, 1, "due")
+		require.ErrorIs(t, err, reports.ErrReportNotFound)
+
+		runs, err := ss.GetRuns(ctx, due.ID, 10)
+		require.NoError(t, err)
+		require.Empty(t, runs)
+
+		require.ErrorIs(t, ss.Delete(ctx, 1, "due"), reports.ErrReportNotFound)
+	})
+}
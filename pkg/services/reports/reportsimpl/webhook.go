@@ -0,0 +1,86 @@
+package reportsimpl
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/reports"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// webhookAllowlist lists the hosts and networks webhooks can be sent to even though they are not public.
+type webhookAllowlist struct {
+	hosts []string
+	nets  []*net.IPNet
+}
+
+// newWebhookAllowlist parses a list of host names, IP addresses and CIDR ranges.
+func newWebhookAllowlist(value string) webhookAllowlist {
+	allowlist := webhookAllowlist{}
+	for _, entry := range util.SplitString(value) {
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			allowlist.nets = append(allowlist.nets, ipNet)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			allowlist.nets = append(allowlist.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		allowlist.hosts = append(allowlist.hosts, strings.ToLower(entry))
+	}
+	return allowlist
+}
+
+func (a webhookAllowlist) allowsHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, h := range a.hosts {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+func (a webhookAllowlist) allowsIP(ip net.IP) bool {
+	for _, ipNet := range a.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWebhookURL validates a report webhook URL. Webhooks are sent by the Grafana server, so
+// hosts resolving to loopback, link-local or private addresses are rejected unless allowed by
+// the webhook_allowed_hosts setting.
+func (s *Service) checkWebhookURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return reports.ErrInvalid("invalid webhook URL")
+	}
+
+	host := u.Hostname()
+	if s.webhookAllowlist.allowsHost(host) {
+		return nil
+	}
+
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		if ips, err = s.lookupIP(ctx, host); err != nil {
+			return reports.ErrInvalid("cannot resolve webhook host %q", host)
+		}
+	}
+	for _, ip := range ips {
+		if isNonPublicIP(ip) && !s.webhookAllowlist.allowsIP(ip) {
+			return reports.ErrInvalid("webhook host %q resolves to the non-public address %s", host, ip)
+		}
+	}
+	return nil
+}
+
+func isNonPublicIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
@@ -0,0 +1,29 @@
+package scim
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// Service is a SCIM 2.0 server (RFC 7643 and RFC 7644) provisioning the users and the
+// teams of an organization. Resource IDs are the IDs of the users and the teams.
+type Service interface {
+	ListUsers(ctx context.Context, query *ListQuery) (*ListResponse, error)
+	GetUser(ctx context.Context, orgID int64, id string) (*User, error)
+	CreateUser(ctx context.Context, orgID int64, u *User) (*User, error)
+	// ReplaceUser and PatchUser require the users:write permission of the server
+	// administrators to change the login or the email of the user
+	ReplaceUser(ctx context.Context, signedInUser *user.SignedInUser, orgID int64, id string, u *User) (*User, error)
+	PatchUser(ctx context.Context, signedInUser *user.SignedInUser, orgID int64, id string, patch *PatchRequest) (*User, error)
+	// DeleteUser removes the user from the organization, users without organization
+	// are deactivated
+	DeleteUser(ctx context.Context, orgID int64, id string) error
+
+	ListGroups(ctx context.Context, query *ListQuery) (*ListResponse, error)
+	GetGroup(ctx context.Context, orgID int64, id string) (*Group, error)
+	CreateGroup(ctx context.Context, orgID int64, g *Group) (*Group, error)
+	ReplaceGroup(ctx context.Context, orgID int64, id string, g *Group) (*Group, error)
+	PatchGroup(ctx context.Context, orgID int64, id string, patch *PatchRequest) (*Group, error)
+	DeleteGroup(ctx context.Context, orgID int64, id string) error
+}
@@ -0,0 +1,393 @@
+package scimimpl
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/scim"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const contentType = "application/scim+json"
+
+type errorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+	provision := authorize(ac.EvalPermission(ActionProvision))
+
+	routeRegister.Group(basePath, func(scimRoute routing.RouteRegister) {
+		scimRoute.Get("/ServiceProviderConfig", routing.Wrap(s.getServiceProviderConfigHandler))
+		scimRoute.Get("/ResourceTypes", routing.Wrap(s.listResourceTypesHandler))
+		scimRoute.Get("/ResourceTypes/:id", routing.Wrap(s.getResourceTypeHandler))
+		scimRoute.Get("/Schemas", routing.Wrap(s.listSchemasHandler))
+		scimRoute.Get("/Schemas/:id", routing.Wrap(s.getSchemaHandler))
+
+		scimRoute.Get("/Users", routing.Wrap(s.listUsersHandler))
+		scimRoute.Post("/Users", routing.Wrap(s.createUserHandler))
+		scimRoute.Get("/Users/:id", routing.Wrap(s.getUserHandler))
+		scimRoute.Put("/Users/:id", routing.Wrap(s.replaceUserHandler))
+		scimRoute.Patch("/Users/:id", routing.Wrap(s.patchUserHandler))
+		scimRoute.Delete("/Users/:id", routing.Wrap(s.deleteUserHandler))
+
+		scimRoute.Get("/Groups", routing.Wrap(s.listGroupsHandler))
+		scimRoute.Post("/Groups", routing.Wrap(s.createGroupHandler))
+		scimRoute.Get("/Groups/:id", routing.Wrap(s.getGroupHandler))
+		scimRoute.Put("/Groups/:id", routing.Wrap(s.replaceGroupHandler))
+		scimRoute.Patch("/Groups/:id", routing.Wrap(s.patchGroupHandler))
+		scimRoute.Delete("/Groups/:id", routing.Wrap(s.deleteGroupHandler))
+	}, middleware.ReqSignedIn, reqServiceAccount, provision)
+}
+
+// reqServiceAccount restricts the endpoints to service accounts, the provisioning clients
+// authenticate with service account tokens
+func reqServiceAccount(c *contextmodel.ReqContext) {
+	if !c.IsServiceAccount {
+		writeResponse(c, scimResponse(http.StatusForbidden, errorResponse{
+			Schemas: []string{scim.SchemaError},
+			Status:  strconv.Itoa(http.StatusForbidden),
+			Detail:  "SCIM requires a service account token",
+		}))
+	}
+}
+
+func writeResponse(c *contextmodel.ReqContext, r response.Response) {
+	r.WriteTo(c)
+}
+
+func scimResponse(status int, body interface{}) *response.NormalResponse {
+	return response.JSON(status, body).SetHeader("Content-Type", contentType)
+}
+
+func (s *Service) errorResponse(c *contextmodel.ReqContext, err error) response.Response {
+	var scimErr *scim.Error
+	if !errors.As(err, &scimErr) {
+		s.log.FromContext(c.Req.Context()).Error("SCIM request failed", "path", c.Req.URL.Path, "error", err)
+		scimErr = scim.NewError(http.StatusInternalServerError, "", "internal server error")
+	}
+	return scimResponse(scimErr.Status, errorResponse{
+		Schemas:  []string{scim.SchemaError},
+		Status:   strconv.Itoa(scimErr.Status),
+		ScimType: scimErr.Type,
+		Detail:   scimErr.Detail,
+	})
+}
+
+func decode(c *contextmodel.ReqContext, v interface{}) error {
+	if err := json.NewDecoder(c.Req.Body).Decode(v); err != nil {
+		return scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, "invalid request body: %s", err)
+	}
+	return nil
+}
+
+func (s *Service) getServiceProviderConfigHandler(c *contextmodel.ReqContext) response.Response {
+	return scimResponse(http.StatusOK, providerConfig)
+}
+
+func (s *Service) listResourceTypesHandler(c *contextmodel.ReqContext) response.Response {
+	resources := make([]interface{}, 0, len(resourceTypes))
+	for _, r := range resourceTypes {
+		resources = append(resources, r)
+	}
+	return scimResponse(http.StatusOK, listOf(resources))
+}
+
+func (s *Service) getResourceTypeHandler(c *contextmodel.ReqContext) response.Response {
+	id := web.Params(c.Req)[":id"]
+	for _, r := range resourceTypes {
+		if r.ID == id {
+			return scimResponse(http.StatusOK, r)
+		}
+	}
+	return s.errorResponse(c, scim.ErrNotFound("ResourceType", id))
+}
+
+func (s *Service) listSchemasHandler(c *contextmodel.ReqContext) response.Response {
+	return scimResponse(http.StatusOK, listOf([]interface{}{userSchema, groupSchema}))
+}
+
+func (s *Service) getSchemaHandler(c *contextmodel.ReqContext) response.Response {
+	id := web.Params(c.Req)[":id"]
+	for _, schema := range []schema{userSchema, groupSchema} {
+		if schema.ID == id {
+			return scimResponse(http.StatusOK, schema)
+		}
+	}
+	return s.errorResponse(c, scim.ErrNotFound("Schema", id))
+}
+
+func listOf(resources []interface{}) *scim.ListResponse {
+	return &scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+func (s *Service) listUsersHandler(c *contextmodel.ReqContext) response.Response {
+	query := listQuery(c)
+	result, err := s.ListUsers(c.Req.Context(), query)
+	if err != nil {
+		return s.errorResponse(c, err)
+	}
+	return s.listResponse(c, result, userAttributes)
+}
+
+func (s *Service) getUserHandler(c *contextmodel.ReqContext) response.Response {
+	u, err := s.GetUser(c.Req.Context(), c.OrgID, web.Params(c.Req)[":id"])
+	if err != nil {
+		return s.errorResponse(c, err)
+	}
+	return s.resourceResponse(c, http.StatusOK, u, userAttributes)
+}
+
+func (s *Service) createUserHandler(c *contextmodel.ReqContext) response.Response {
+	u := scim.User{}
+	if err := decode(c, &u); err != nil {
+		return s.errorResponse(c, err)
+	}
+	created, err := s.CreateUser(c.Req.Context(), c.OrgID, &u)
+	if err != nil {
+		return s.errorResponse(c, err)
+	}
+	return s.resourceResponse(c, http.StatusCreated, created, userAttributes)
+}
+
+func (s *Service) replaceUserHandler(c *contextmodel.ReqContext) response.Response {
+	u := scim.User{}
+	if err := decode(c, &u); err != nil {
+		return s.errorResponse(c, err)
+	}
+	updated, err := s.ReplaceUser(c.Req.Context(), c.SignedInUser, c.OrgID, web.Params(c.Req)[":id"], &u)
+	if err != nil {
+		return s.errorResponse(c, err)
+	}
+	return s.resourceResponse(c, http.StatusOK, updated, userAttributes)
+}
+
+func (s *Service) patchUserHandler(c *contextmodel.ReqContext) response.Response {
+	patch := scim.PatchRequest{}
+	if err := decode(c, &patch); err != nil {
+		return s.errorResponse(c, err)
+	}
+	updated, err := s.PatchUser(c.Req.Context(), c.SignedInUser, c.OrgID, web.Params(c.Req)[":id"], &patch)
+	if err != nil {
+		return s.errorResponse(c, err)
+	}
+	return s.resourceResponse(c, http.StatusOK, updated, userAttributes)
+}
+
+func (s *Service) deleteUserHandler(c *contextmodel.ReqContext) response.Response {
+	if err := s.DeleteUser(c.Req.Context(), c.OrgID, web.Params(c.Req)[":id"]); err != nil {
+		return s.errorResponse(c, err)
+	}
+	return response.Empty(http.StatusNoContent)
+}
+
+func (s *Service) listGroupsHandler(c *contextmodel.ReqContext) response.Response {
+	query := listQuery(c)
+	query.ExcludeMembers = !isReturned(c, "members")
+	result, err := s.ListGroups(c.Req.Context(), query)
+	if err != nil {
+		return s.errorResponse(c, err)
+	}
+	return s.listResponse(c, result, groupAttributes)
+}
+
+func (s *Service) getGroupHandler(c *contextmodel.ReqContext) response.Response {
+	g, err := s.GetGroup(c.Req.Context(), c.OrgID, web.Params(c.Req)[":id"])
+	if err != nil {
+		return s.errorResponse(c, err)
+	}
+	return s.resourceResponse(c, http.StatusOK, g, groupAttributes)
+}
+
+func (s *Service) createGroupHandler(c *contextmodel.ReqContext) response.Response {
+	g := scim.Group{}
+	if err := decode(c, &g); err != nil {
+		return s.errorResponse(c, err)
+	}
+	created, err := s.CreateGroup(c.Req.Context(), c.OrgID, &g)
+	if err != nil {
+		return s.errorResponse(c, err)
+	}
+	return s.resourceResponse(c, http.StatusCreated, created, groupAttributes)
+}
+
+func (s *Service) replaceGroupHandler(c *contextmodel.ReqContext) response.Response {
+	g := scim.Group{}
+	if err := decode(c, &g); err != nil {
+		return s.errorResponse(c, err)
+	}
+	updated, err := s.ReplaceGroup(c.Req.Context(), c.OrgID, web.Params(c.Req)[":id"], &g)
+	if err != nil {
+		return s.errorResponse(c, err)
+	}
+	return s.resourceResponse(c, http.StatusOK, updated, groupAttributes)
+}
+
+func (s *Service) patchGroupHandler(c *contextmodel.ReqContext) response.Response {
+	patch := scim.PatchRequest{}
+	if err := decode(c, &patch); err != nil {
+		return s.errorResponse(c, err)
+	}
+	updated, err := s.PatchGroup(c.Req.Context(), c.OrgID, web.Params(c.Req)[":id"], &patch)
+	if err != nil {
+		return s.errorResponse(c, err)
+	}
+	return s.resourceResponse(c, http.StatusOK, updated, groupAttributes)
+}
+
+func (s *Service) deleteGroupHandler(c *contextmodel.ReqContext) response.Response {
+	if err := s.DeleteGroup(c.Req.Context(), c.OrgID, web.Params(c.Req)[":id"]); err != nil {
+		return s.errorResponse(c, err)
+	}
+	return response.Empty(http.StatusNoContent)
+}
+
+func listQuery(c *contextmodel.ReqContext) *scim.ListQuery {
+	query := &scim.ListQuery{
+		OrgID:      c.OrgID,
+		Filter:     c.Query("filter"),
+		StartIndex: 1,
+		Count:      defaultCount,
+	}
+	if startIndex, err := strconv.Atoi(c.Query("startIndex")); err == nil {
+		query.StartIndex = startIndex
+	}
+	if count, err := strconv.Atoi(c.Query("count")); err == nil {
+		query.Count = count
+	}
+	return query
+}
+
+func (s *Service) resourceResponse(c *contextmodel.ReqContext, status int, resource interface{}, attributes []attribute) response.Response {
+	m, err := toMap(resource)
+	if err != nil {
+		return s.errorResponse(c, err)
+	}
+	r := scimResponse(status, project(c, m, attributes))
+	if meta, ok := m["meta"].(map[string]interface{}); ok && status == http.StatusCreated {
+		if location, ok := meta["location"].(string); ok {
+			r.SetHeader("Location", location)
+		}
+	}
+	return r
+}
+
+func (s *Service) listResponse(c *contextmodel.ReqContext, result *scim.ListResponse, attributes []attribute) response.Response {
+	for i, resource := range result.Resources {
+		m, err := toMap(resource)
+		if err != nil {
+			return s.errorResponse(c, err)
+		}
+		result.Resources[i] = project(c, m, attributes)
+	}
+	return scimResponse(http.StatusOK, result)
+}
+
+// splitAttributes splits a list of attributes of the attributes or excludedAttributes
+// parameters, without schema URI
+func splitAttributes(value string) []attrPath {
+	paths := []attrPath{}
+	for _, name := range strings.Split(value, ",") {
+		if p, err := parseAttrPath(strings.TrimSpace(name)); err == nil {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// isReturned returns false when the request excludes the attribute with the attributes or
+// excludedAttributes parameters
+func isReturned(c *contextmodel.ReqContext, name string) bool {
+	if attributes := c.Query("attributes"); attributes != "" {
+		for _, p := range splitAttributes(attributes) {
+			if strings.EqualFold(p.attr, name) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, p := range splitAttributes(c.Query("excludedAttributes")) {
+		if strings.EqualFold(p.attr, name) && p.subAttr == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// project returns the attributes of a resource requested with the attributes and
+// excludedAttributes parameters (RFC 7644 section 3.4.2.5). Attributes always returned
+// can't be excluded.
+func project(c *contextmodel.ReqContext, resource map[string]interface{}, attributes []attribute) map[string]interface{} {
+	included, excluded := c.Query("attributes"), c.Query("excludedAttributes")
+	if included == "" && excluded == "" {
+		return resource
+	}
+
+	projected := map[string]interface{}{}
+	if included != "" {
+		for _, p := range splitAttributes(included) {
+			a, ok := findAttribute(attributes, p.attr)
+			if !ok {
+				continue
+			}
+			value, ok := resource[a.Name]
+			if !ok {
+				continue
+			}
+			if p.subAttr == "" {
+				projected[a.Name] = value
+				continue
+			}
+			if complex, ok := value.(map[string]interface{}); ok {
+				sub, _ := projected[a.Name].(map[string]interface{})
+				if sub == nil {
+					sub = map[string]interface{}{}
+					projected[a.Name] = sub
+				}
+				if v := getAttribute(complex, p.subAttr); v != nil {
+					sub[p.subAttr] = v
+				}
+			}
+		}
+	} else {
+		for k, v := range resource {
+			projected[k] = v
+		}
+		for _, p := range splitAttributes(excluded) {
+			a, ok := findAttribute(attributes, p.attr)
+			if !ok || a.Returned == returnedAlways {
+				continue
+			}
+			if p.subAttr == "" {
+				delete(projected, a.Name)
+			} else if complex, ok := projected[a.Name].(map[string]interface{}); ok {
+				delete(complex, p.subAttr)
+			}
+		}
+	}
+
+	for _, a := range attributes {
+		if value, ok := resource[a.Name]; ok && a.Returned == returnedAlways {
+			projected[a.Name] = value
+		}
+	}
+	return projected
+}
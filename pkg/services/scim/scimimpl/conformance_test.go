@@ -0,0 +1,152 @@
+package scimimpl
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/scim"
+)
+
+// TestConformance checks the resources returned by the service against the schemas of
+// RFC 7643 published by the Schemas endpoint
+func TestConformance(t *testing.T) {
+	ctx := context.Background()
+	s, d := setupTestService(t)
+	alice := d.addUser("alice", "alice@example.com", testOrgID)
+	d.users[alice].Name = "Alice Smith"
+
+	created, err := s.CreateUser(ctx, testOrgID, &scim.User{
+		Schemas:    []string{scim.SchemaUser},
+		UserName:   "bob",
+		ExternalID: "b1",
+		Name:       &scim.Name{GivenName: "Bob", FamilyName: "Jones"},
+		Emails:     []scim.Email{{Value: "bob@example.com", Type: "work", Primary: true}},
+	})
+	require.NoError(t, err)
+	group, err := s.CreateGroup(ctx, testOrgID, &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		DisplayName: "Team A",
+		ExternalID:  "g1",
+		Members:     []scim.Member{{Value: strconv.FormatInt(alice, 10)}, {Value: created.ID}},
+	})
+	require.NoError(t, err)
+
+	t.Run("users", func(t *testing.T) {
+		result, err := s.ListUsers(ctx, &scim.ListQuery{OrgID: testOrgID, StartIndex: 1, Count: defaultCount})
+		require.NoError(t, err)
+		requireListResponse(t, result)
+		require.Len(t, result.Resources, 2)
+		for _, u := range result.Resources {
+			requireConformance(t, userSchema, u)
+		}
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		result, err := s.ListGroups(ctx, &scim.ListQuery{OrgID: testOrgID, StartIndex: 1, Count: defaultCount})
+		require.NoError(t, err)
+		requireListResponse(t, result)
+		require.Len(t, result.Resources, 1)
+		requireConformance(t, groupSchema, result.Resources[0])
+		requireConformance(t, groupSchema, group)
+	})
+
+	t.Run("schemas", func(t *testing.T) {
+		for _, schema := range []schema{userSchema, groupSchema} {
+			requireValidAttributes(t, resourceAttributes(schema))
+		}
+		for _, r := range resourceTypes {
+			require.Contains(t, []string{userSchema.ID, groupSchema.ID}, r.Schema)
+		}
+	})
+}
+
+func requireListResponse(t *testing.T, result *scim.ListResponse) {
+	t.Helper()
+	m, err := toMap(result)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{scim.SchemaListResponse}, m["schemas"])
+	for _, key := range []string{"totalResults", "startIndex", "itemsPerPage", "Resources"} {
+		require.Contains(t, m, key)
+	}
+}
+
+func requireConformance(t *testing.T, s schema, resource interface{}) {
+	t.Helper()
+	m, err := toMap(resource)
+	require.NoError(t, err)
+
+	require.Contains(t, m["schemas"], s.ID)
+	attributes := resourceAttributes(s)
+	for name, value := range m {
+		a, ok := findAttribute(attributes, name)
+		require.True(t, ok, "attribute %s isn't declared by %s", name, s.ID)
+		require.Equal(t, a.Name, name, "attribute names must match the schema")
+		require.NotEqual(t, returnedNever, a.Returned, "attribute %s must not be returned", name)
+		requireValue(t, a, value)
+	}
+	for _, a := range attributes {
+		if a.Required || a.Returned == returnedAlways {
+			require.Contains(t, m, a.Name, "attribute %s is required", a.Name)
+		}
+	}
+}
+
+func requireValue(t *testing.T, a attribute, value interface{}) {
+	t.Helper()
+	if a.MultiValued {
+		values, ok := value.([]interface{})
+		require.True(t, ok, "attribute %s must be multi-valued", a.Name)
+		single := a
+		single.MultiValued = false
+		for _, v := range values {
+			requireValue(t, single, v)
+		}
+		return
+	}
+
+	switch a.Type {
+	case typeString, typeReference:
+		require.IsType(t, "", value, "attribute %s must be a string", a.Name)
+	case typeBoolean:
+		require.IsType(t, true, value, "attribute %s must be a boolean", a.Name)
+	case typeDateTime:
+		require.IsType(t, "", value, "attribute %s must be a date", a.Name)
+		_, err := time.Parse(time.RFC3339, value.(string))
+		require.NoError(t, err)
+	case typeComplex:
+		complex, ok := value.(map[string]interface{})
+		require.True(t, ok, "attribute %s must be complex", a.Name)
+		for name, v := range complex {
+			sub, ok := findAttribute(a.SubAttributes, name)
+			require.True(t, ok, "attribute %s.%s isn't declared", a.Name, name)
+			require.Equal(t, sub.Name, name)
+			requireValue(t, sub, v)
+		}
+	default:
+		t.Fatalf("unexpected type %s of %s", a.Type, a.Name)
+	}
+}
+
+func requireValidAttributes(t *testing.T, attributes []attribute) {
+	t.Helper()
+	for _, a := range attributes {
+		require.Contains(t, []string{typeString, typeBoolean, typeComplex, typeDateTime, typeReference}, a.Type)
+		require.Contains(t, []string{mutabilityReadOnly, mutabilityReadWrite, mutabilityImmutable, mutabilityWriteOnly}, a.Mutability)
+		require.Contains(t, []string{returnedAlways, returnedNever, returnedDefault}, a.Returned)
+		require.Contains(t, []string{uniquenessNone, uniquenessServer}, a.Uniqueness)
+		if a.Mutability == mutabilityWriteOnly {
+			require.Equal(t, returnedNever, a.Returned)
+		}
+		require.Equal(t, a.Type == typeComplex, len(a.SubAttributes) > 0, "complex attribute %s", a.Name)
+		requireValidAttributes(t, a.SubAttributes)
+
+		data, err := json.Marshal(a)
+		require.NoError(t, err)
+		require.Contains(t, string(data), `"uniqueness"`)
+	}
+}
@@ -0,0 +1,450 @@
+package scimimpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/scim"
+)
+
+// filter is a parsed SCIM filter (RFC 7644 section 3.4.2.2), evaluated on the JSON
+// representation of the resources
+type filter interface {
+	matches(resource map[string]interface{}) bool
+}
+
+type logicalFilter struct {
+	and         bool
+	left, right filter
+}
+
+func (f logicalFilter) matches(resource map[string]interface{}) bool {
+	if f.and {
+		return f.left.matches(resource) && f.right.matches(resource)
+	}
+	return f.left.matches(resource) || f.right.matches(resource)
+}
+
+type notFilter struct {
+	filter filter
+}
+
+func (f notFilter) matches(resource map[string]interface{}) bool {
+	return !f.filter.matches(resource)
+}
+
+// valuePathFilter matches the resources with an element of a multi-valued attribute
+// matching the filter, for example emails[type eq "work"]
+type valuePathFilter struct {
+	attr   string
+	filter filter
+}
+
+func (f valuePathFilter) matches(resource map[string]interface{}) bool {
+	values, _ := getAttribute(resource, f.attr).([]interface{})
+	for _, v := range values {
+		if element, ok := v.(map[string]interface{}); ok && f.filter.matches(element) {
+			return true
+		}
+	}
+	return false
+}
+
+type attrFilter struct {
+	path  attrPath
+	op    string
+	value interface{}
+}
+
+func (f attrFilter) matches(resource map[string]interface{}) bool {
+	values := f.path.values(resource)
+	if f.op == "pr" {
+		for _, v := range values {
+			if isPresent(v) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(values) == 0 {
+		values = []interface{}{nil}
+	}
+	for _, v := range values {
+		if compare(v, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// attrPath is the path of an attribute or a sub-attribute, without schema URI
+type attrPath struct {
+	attr    string
+	subAttr string
+}
+
+func parseAttrPath(s string) (attrPath, error) {
+	// urn:ietf:params:scim:schemas:core:2.0:User:name.givenName
+	if strings.HasPrefix(strings.ToLower(s), "urn:") {
+		s = s[strings.LastIndex(s, ":")+1:]
+	}
+	attr, subAttr, _ := strings.Cut(s, ".")
+	if attr == "" || strings.Contains(subAttr, ".") {
+		return attrPath{}, fmt.Errorf("invalid attribute path %q", s)
+	}
+	return attrPath{attr: attr, subAttr: subAttr}, nil
+}
+
+// values returns the values of the attribute. Elements of multi-valued complex attributes
+// are compared with their value sub-attribute when no sub-attribute is set.
+func (p attrPath) values(resource map[string]interface{}) []interface{} {
+	subAttr := p.subAttr
+	v := getAttribute(resource, p.attr)
+	list, multiValued := v.([]interface{})
+	if !multiValued {
+		list = []interface{}{v}
+	} else if subAttr == "" {
+		subAttr = "value"
+	}
+
+	values := make([]interface{}, 0, len(list))
+	for _, element := range list {
+		if subAttr == "" {
+			values = append(values, element)
+			continue
+		}
+		if complex, ok := element.(map[string]interface{}); ok {
+			values = append(values, getAttribute(complex, subAttr))
+		} else if multiValued && p.subAttr == "" {
+			values = append(values, element)
+		}
+	}
+	return values
+}
+
+// getAttribute returns the value of an attribute, attribute names are case-insensitive
+func getAttribute(resource map[string]interface{}, name string) interface{} {
+	if v, ok := resource[name]; ok {
+		return v
+	}
+	for k, v := range resource {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+func isPresent(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return false
+	case string:
+		return value != ""
+	case []interface{}:
+		return len(value) > 0
+	case map[string]interface{}:
+		return len(value) > 0
+	default:
+		return true
+	}
+}
+
+func compare(actual interface{}, op string, expected interface{}) bool {
+	if op == "ne" {
+		return !compare(actual, "eq", expected)
+	}
+	if expected == nil {
+		return op == "eq" && !isPresent(actual)
+	}
+
+	switch e := expected.(type) {
+	case bool:
+		a, ok := actual.(bool)
+		return ok && op == "eq" && a == e
+	case float64:
+		a, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return a == e
+		case "gt":
+			return a > e
+		case "ge":
+			return a >= e
+		case "lt":
+			return a < e
+		case "le":
+			return a <= e
+		}
+		return false
+	case string:
+		a, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		a, e = strings.ToLower(a), strings.ToLower(e)
+		switch op {
+		case "eq":
+			return a == e
+		case "co":
+			return strings.Contains(a, e)
+		case "sw":
+			return strings.HasPrefix(a, e)
+		case "ew":
+			return strings.HasSuffix(a, e)
+		case "gt":
+			return a > e
+		case "ge":
+			return a >= e
+		case "lt":
+			return a < e
+		case "le":
+			return a <= e
+		}
+	}
+	return false
+}
+
+var compareOperators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true, "pr": true,
+}
+
+func errInvalidFilter(format string, args ...interface{}) *scim.Error {
+	return scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidFilter, format, args...)
+}
+
+// parseFilter parses a filter, an empty filter matches all resources
+func parseFilter(s string) (filter, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	tokens, err := tokenizeFilter(s)
+	if err != nil {
+		return nil, errInvalidFilter("%s", err)
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, errInvalidFilter("%s", err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, errInvalidFilter("unexpected %q", p.tokens[p.pos].text)
+	}
+	return f, nil
+}
+
+type filterToken struct {
+	text string
+	// quoted is true for string values
+	quoted bool
+}
+
+func tokenizeFilter(s string) ([]filterToken, error) {
+	tokens := []filterToken{}
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			tokens = append(tokens, filterToken{text: string(c)})
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(s); end++ {
+				if s[end] == '\\' {
+					end++
+				} else if s[end] == '"' {
+					break
+				}
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			var value string
+			if err := json.Unmarshal([]byte(s[i:end+1]), &value); err != nil {
+				return nil, fmt.Errorf("invalid string %s", s[i:end+1])
+			}
+			tokens = append(tokens, filterToken{text: value, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(s) && !strings.ContainsRune(" \t()[]\"", rune(s[end])) {
+				end++
+			}
+			tokens = append(tokens, filterToken{text: s[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() (filterToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return filterToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *filterParser) next() (filterToken, error) {
+	t, ok := p.peek()
+	if !ok {
+		return t, fmt.Errorf("unexpected end of filter")
+	}
+	p.pos++
+	return t, nil
+}
+
+// peekKeyword returns true when the next token is the unquoted keyword
+func (p *filterParser) peekKeyword(keyword string) bool {
+	t, ok := p.peek()
+	return ok && !t.quoted && strings.EqualFold(t.text, keyword)
+}
+
+func (p *filterParser) expect(text string) error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if t.quoted || t.text != text {
+		return fmt.Errorf("expected %q, got %q", text, t.text)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("and") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalFilter{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filter, error) {
+	if p.peekKeyword("not") {
+		p.pos++
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{filter: f}, p.expect(")")
+	}
+	if p.peekKeyword("(") {
+		p.pos++
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return f, p.expect(")")
+	}
+	return p.parseAttrExp()
+}
+
+func (p *filterParser) parseAttrExp() (filter, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if t.quoted {
+		return nil, fmt.Errorf("expected attribute, got %q", t.text)
+	}
+
+	if p.peekKeyword("[") {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		path, err := parseAttrPath(t.text)
+		if err != nil {
+			return nil, err
+		}
+		return valuePathFilter{attr: path.attr, filter: inner}, nil
+	}
+
+	path, err := parseAttrPath(t.text)
+	if err != nil {
+		return nil, err
+	}
+	opToken, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	op := strings.ToLower(opToken.text)
+	if opToken.quoted || !compareOperators[op] {
+		return nil, fmt.Errorf("unsupported operator %q", opToken.text)
+	}
+	if op == "pr" {
+		return attrFilter{path: path, op: op}, nil
+	}
+
+	valueToken, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseCompValue(valueToken)
+	if err != nil {
+		return nil, err
+	}
+	return attrFilter{path: path, op: op, value: value}, nil
+}
+
+func parseCompValue(t filterToken) (interface{}, error) {
+	if t.quoted {
+		return t.text, nil
+	}
+	switch strings.ToLower(t.text) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", t.text)
+	}
+	return f, nil
+}
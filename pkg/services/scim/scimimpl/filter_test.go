@@ -0,0 +1,82 @@
+package scimimpl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/scim"
+)
+
+func TestFilter(t *testing.T) {
+	resource := map[string]interface{}{
+		"schemas":     []interface{}{scim.SchemaUser},
+		"id":          "2",
+		"userName":    "Alice",
+		"displayName": "Alice Smith",
+		"name":        map[string]interface{}{"formatted": "Alice Smith"},
+		"emails": []interface{}{
+			map[string]interface{}{"value": "alice@example.com", "type": "work", "primary": true},
+		},
+		"active": true,
+		"meta":   map[string]interface{}{"lastModified": "2023-05-10T10:00:00Z"},
+	}
+
+	tests := []struct {
+		filter  string
+		matches bool
+	}{
+		{filter: `userName eq "alice"`, matches: true},
+		{filter: `USERNAME EQ "Alice"`, matches: true},
+		{filter: `userName eq "bob"`, matches: false},
+		{filter: `userName ne "bob"`, matches: true},
+		{filter: `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "alice"`, matches: true},
+		{filter: `displayName co "smith"`, matches: true},
+		{filter: `displayName sw "alice"`, matches: true},
+		{filter: `displayName ew "alice"`, matches: false},
+		{filter: `name.formatted eq "Alice Smith"`, matches: true},
+		{filter: `emails eq "alice@example.com"`, matches: true},
+		{filter: `emails.value ew "@example.com"`, matches: true},
+		{filter: `emails[type eq "work" and value co "alice"]`, matches: true},
+		{filter: `emails[type eq "home"]`, matches: false},
+		{filter: `active eq true`, matches: true},
+		{filter: `active eq false`, matches: false},
+		{filter: `externalId pr`, matches: false},
+		{filter: `externalId eq null`, matches: true},
+		{filter: `title pr or userName pr`, matches: true},
+		{filter: `userName eq "alice" and not (active eq false)`, matches: true},
+		{filter: `(userName eq "bob" or userName eq "alice") and active eq true`, matches: true},
+		{filter: `userName eq "bob" or userName eq "carol" and active eq true`, matches: false},
+		{filter: `meta.lastModified gt "2023-01-01T00:00:00Z"`, matches: true},
+		{filter: `meta.lastModified lt "2023-01-01T00:00:00Z"`, matches: false},
+		{filter: `displayName eq "Alice \"Smith\""`, matches: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := parseFilter(tt.filter)
+			require.NoError(t, err)
+			require.Equal(t, tt.matches, f.matches(resource))
+		})
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	for _, filter := range []string{
+		`userName`,
+		`userName eq`,
+		`userName regex "a"`,
+		`userName eq "alice`,
+		`(userName eq "alice"`,
+		`userName eq "alice" xor active eq true`,
+		`emails[type eq "work"`,
+		`not userName eq "alice"`,
+		`userName eq alice`,
+	} {
+		t.Run(filter, func(t *testing.T) {
+			_, err := parseFilter(filter)
+			var scimErr *scim.Error
+			require.ErrorAs(t, err, &scimErr)
+			require.Equal(t, scim.ErrorTypeInvalidFilter, scimErr.Type)
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package scimimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/scim"
+	"github.com/grafana/grafana/pkg/services/team"
+)
+
+var groupAttributes = resourceAttributes(groupSchema)
+
+// memberPermission is the permission of the members added to the teams
+const memberPermission = "Member"
+
+func (s *Service) ListGroups(ctx context.Context, query *scim.ListQuery) (*scim.ListResponse, error) {
+	result, err := s.teamService.SearchTeams(ctx, &team.SearchTeamsQuery{
+		OrgID:        query.OrgID,
+		SignedInUser: orgReader(query.OrgID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	externalIDs, err := s.store.GetExternalIDs(ctx, query.OrgID, scim.ResourceTypeGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]*scim.Group, 0, len(result.Teams))
+	for _, t := range result.Teams {
+		g, err := s.toGroup(ctx, t, externalIDs[t.ID], !query.ExcludeMembers)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return list(query, groups)
+}
+
+func (s *Service) GetGroup(ctx context.Context, orgID int64, id string) (*scim.Group, error) {
+	teamID, err := parseID(scim.ResourceTypeGroup, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.getGroup(ctx, orgID, teamID)
+}
+
+func (s *Service) CreateGroup(ctx context.Context, orgID int64, g *scim.Group) (*scim.Group, error) {
+	if g.DisplayName == "" {
+		return nil, scim.ErrInvalidValue("displayName is required")
+	}
+	members, err := s.memberIDs(ctx, orgID, g.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.teamService.CreateTeam(g.DisplayName, "", orgID)
+	if err != nil {
+		if errors.Is(err, team.ErrTeamNameTaken) {
+			return nil, scim.NewError(http.StatusConflict, scim.ErrorTypeUniqueness, "group %s already exists", g.DisplayName)
+		}
+		return nil, err
+	}
+	for userID := range members {
+		if err := s.setMember(ctx, orgID, t.ID, userID, memberPermission); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.store.SetExternalID(ctx, orgID, scim.ResourceTypeGroup, t.ID, g.ExternalID); err != nil {
+		return nil, err
+	}
+	s.log.Info("Provisioned team", "orgID", orgID, "teamID", t.ID, "name", t.Name)
+
+	return s.getGroup(ctx, orgID, t.ID)
+}
+
+func (s *Service) ReplaceGroup(ctx context.Context, orgID int64, id string, g *scim.Group) (*scim.Group, error) {
+	current, err := s.GetGroup(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateGroup(ctx, orgID, current, g)
+}
+
+func (s *Service) PatchGroup(ctx context.Context, orgID int64, id string, patch *scim.PatchRequest) (*scim.Group, error) {
+	current, err := s.GetGroup(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := toMap(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPatch(resource, groupAttributes, patch.Operations); err != nil {
+		return nil, err
+	}
+	patched := &scim.Group{}
+	if err := fromMap(resource, patched); err != nil {
+		return nil, err
+	}
+	return s.updateGroup(ctx, orgID, current, patched)
+}
+
+func (s *Service) DeleteGroup(ctx context.Context, orgID int64, id string) error {
+	teamID, err := parseID(scim.ResourceTypeGroup, id)
+	if err != nil {
+		return err
+	}
+	if err := s.teamService.DeleteTeam(ctx, &team.DeleteTeamCommand{OrgID: orgID, ID: teamID}); err != nil {
+		if errors.Is(err, team.ErrTeamNotFound) {
+			return scim.ErrNotFound(scim.ResourceTypeGroup, id)
+		}
+		return err
+	}
+	s.log.Info("Deprovisioned team", "orgID", orgID, "teamID", teamID)
+	return nil
+}
+
+// updateGroup renames the team and adds and removes members to match the members of the group
+func (s *Service) updateGroup(ctx context.Context, orgID int64, current, g *scim.Group) (*scim.Group, error) {
+	teamID, _ := strconv.ParseInt(current.ID, 10, 64)
+	if g.ID != "" && g.ID != current.ID {
+		return nil, scim.NewError(http.StatusBadRequest, scim.ErrorTypeMutability, "id can't be changed")
+	}
+	if g.DisplayName == "" {
+		return nil, scim.ErrInvalidValue("displayName is required")
+	}
+	members, err := s.memberIDs(ctx, orgID, g.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.DisplayName != current.DisplayName {
+		err := s.teamService.UpdateTeam(ctx, &team.UpdateTeamCommand{ID: teamID, OrgID: orgID, Name: g.DisplayName})
+		if err != nil {
+			if errors.Is(err, team.ErrTeamNameTaken) {
+				return nil, scim.NewError(http.StatusConflict, scim.ErrorTypeUniqueness, "group %s already exists", g.DisplayName)
+			}
+			return nil, err
+		}
+	}
+
+	for _, m := range current.Members {
+		userID, _ := strconv.ParseInt(m.Value, 10, 64)
+		if _, ok := members[userID]; ok {
+			delete(members, userID)
+			continue
+		}
+		if err := s.setMember(ctx, orgID, teamID, userID, ""); err != nil {
+			return nil, err
+		}
+	}
+	for userID := range members {
+		if err := s.setMember(ctx, orgID, teamID, userID, memberPermission); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.store.SetExternalID(ctx, orgID, scim.ResourceTypeGroup, teamID, g.ExternalID); err != nil {
+		return nil, err
+	}
+
+	return s.getGroup(ctx, orgID, teamID)
+}
+
+// setMember adds a member to a team, or removes it when the permission is empty
+func (s *Service) setMember(ctx context.Context, orgID, teamID, userID int64, permission string) error {
+	_, err := s.teamPermissionsService.SetUserPermission(ctx, orgID, ac.User{ID: userID}, strconv.FormatInt(teamID, 10), permission)
+	if err != nil {
+		return fmt.Errorf("failed to set permission of user %d in team %d: %w", userID, teamID, err)
+	}
+	return nil
+}
+
+// memberIDs returns the IDs of the users of the members, which must be users of the organization
+func (s *Service) memberIDs(ctx context.Context, orgID int64, members []scim.Member) (map[int64]struct{}, error) {
+	ids := make(map[int64]struct{}, len(members))
+	if len(members) == 0 {
+		return ids, nil
+	}
+
+	users, err := s.listUsers(ctx, orgID, 0)
+	if err != nil {
+		return nil, err
+	}
+	orgUsers := make(map[string]bool, len(users))
+	for _, u := range users {
+		orgUsers[u.ID] = true
+	}
+
+	for _, m := range members {
+		if m.Type != "" && m.Type != scim.ResourceTypeUser {
+			return nil, scim.ErrInvalidValue("unsupported member type %s", m.Type)
+		}
+		if !orgUsers[m.Value] {
+			return nil, scim.ErrInvalidValue("member %s isn't a user of the organization", m.Value)
+		}
+		userID, _ := strconv.ParseInt(m.Value, 10, 64)
+		ids[userID] = struct{}{}
+	}
+	return ids, nil
+}
+
+func (s *Service) getGroup(ctx context.Context, orgID, teamID int64) (*scim.Group, error) {
+	t, err := s.teamService.GetTeamByID(ctx, &team.GetTeamByIDQuery{OrgID: orgID, ID: teamID, SignedInUser: orgReader(orgID)})
+	if err != nil {
+		if errors.Is(err, team.ErrTeamNotFound) {
+			return nil, scim.ErrNotFound(scim.ResourceTypeGroup, strconv.FormatInt(teamID, 10))
+		}
+		return nil, err
+	}
+	externalIDs, err := s.store.GetExternalIDs(ctx, orgID, scim.ResourceTypeGroup)
+	if err != nil {
+		return nil, err
+	}
+	return s.toGroup(ctx, t, externalIDs[teamID], true)
+}
+
+func (s *Service) toGroup(ctx context.Context, t *team.TeamDTO, externalID string, withMembers bool) (*scim.Group, error) {
+	id := strconv.FormatInt(t.ID, 10)
+	g := &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          id,
+		ExternalID:  externalID,
+		DisplayName: t.Name,
+		Meta:        s.meta(scim.ResourceTypeGroup, "Groups", id, time.Time{}, time.Time{}),
+	}
+	if !withMembers {
+		return g, nil
+	}
+
+	members, err := s.teamService.GetTeamMembers(ctx, &team.GetTeamMembersQuery{
+		OrgID:        t.OrgID,
+		TeamID:       t.ID,
+		SignedInUser: orgReader(t.OrgID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		userID := strconv.FormatInt(m.UserID, 10)
+		g.Members = append(g.Members, scim.Member{
+			Value:   userID,
+			Display: m.Login,
+			Ref:     fmt.Sprintf("%s%s/Users/%s", s.appURL, basePath, userID),
+			Type:    scim.ResourceTypeUser,
+		})
+	}
+	return g, nil
+}
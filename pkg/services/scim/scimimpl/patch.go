@@ -0,0 +1,376 @@
+package scimimpl
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/scim"
+)
+
+const (
+	opAdd     = "add"
+	opReplace = "replace"
+	opRemove  = "remove"
+)
+
+func errInvalidPath(format string, args ...interface{}) *scim.Error {
+	return scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidPath, format, args...)
+}
+
+// patchPath is the path of a PATCH operation: attr, attr.subAttr, attr[filter] or
+// attr[filter].subAttr
+type patchPath struct {
+	attr    attribute
+	filter  filter
+	subAttr *attribute
+}
+
+func parsePatchPath(attributes []attribute, path string) (*patchPath, error) {
+	attrPart, filterPart, subPart := path, "", ""
+	if start := strings.Index(path, "["); start >= 0 {
+		end := strings.LastIndex(path, "]")
+		if end < start {
+			return nil, errInvalidPath("invalid path %q", path)
+		}
+		attrPart, filterPart, subPart = path[:start], path[start+1:end], path[end+1:]
+		if subPart != "" && !strings.HasPrefix(subPart, ".") {
+			return nil, errInvalidPath("invalid path %q", path)
+		}
+		subPart = strings.TrimPrefix(subPart, ".")
+	}
+	if filterPart == "" && strings.Contains(path, "[") {
+		return nil, errInvalidPath("invalid path %q", path)
+	}
+
+	parsed, err := parseAttrPath(attrPart)
+	if err != nil {
+		return nil, errInvalidPath("%s", err)
+	}
+	if parsed.subAttr != "" {
+		if subPart != "" {
+			return nil, errInvalidPath("invalid path %q", path)
+		}
+		subPart = parsed.subAttr
+	}
+
+	a, ok := findAttribute(attributes, parsed.attr)
+	if !ok {
+		return nil, errInvalidPath("unknown attribute %q", parsed.attr)
+	}
+	p := &patchPath{attr: a}
+	if subPart != "" {
+		sub, ok := findAttribute(a.SubAttributes, subPart)
+		if !ok {
+			return nil, errInvalidPath("unknown attribute %q", parsed.attr+"."+subPart)
+		}
+		p.subAttr = &sub
+	}
+	if filterPart != "" {
+		if !a.MultiValued {
+			return nil, errInvalidPath("%s isn't multi-valued", a.Name)
+		}
+		if p.filter, err = parseFilter(filterPart); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// applyPatch applies the operations of a PATCH request (RFC 7644 section 3.5.2) to the
+// JSON representation of a resource
+func applyPatch(resource map[string]interface{}, attributes []attribute, operations []scim.PatchOperation) error {
+	for _, operation := range operations {
+		op := strings.ToLower(operation.Op)
+		if op != opAdd && op != opReplace && op != opRemove {
+			return scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, "unsupported operation %q", operation.Op)
+		}
+
+		if operation.Path != "" {
+			if err := applyOperation(resource, attributes, op, operation.Path, operation.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Without path, the value is an object of the attributes to add or replace
+		if op == opRemove {
+			return scim.NewError(http.StatusBadRequest, scim.ErrorTypeNoTarget, "remove operation without path")
+		}
+		values, ok := operation.Value.(map[string]interface{})
+		if !ok {
+			return scim.ErrInvalidValue("%s operation without path requires an object value", op)
+		}
+		for path, value := range values {
+			// Extension schemas are nested in their URI, core attributes may be prefixed
+			if nested, ok := value.(map[string]interface{}); ok && strings.HasPrefix(strings.ToLower(path), "urn:") {
+				for subPath, subValue := range nested {
+					if err := applyOperation(resource, attributes, op, subPath, subValue); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := applyOperation(resource, attributes, op, path, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func applyOperation(resource map[string]interface{}, attributes []attribute, op, path string, value interface{}) error {
+	p, err := parsePatchPath(attributes, path)
+	if err != nil {
+		return err
+	}
+	if p.attr.Mutability == mutabilityReadOnly || (p.subAttr != nil && p.subAttr.Mutability == mutabilityReadOnly) {
+		return scim.NewError(http.StatusBadRequest, scim.ErrorTypeMutability, "%s is read-only", path)
+	}
+
+	// Attribute names are case-insensitive, the value is kept under the name of the schema
+	name := p.attr.Name
+	current := getAttribute(resource, name)
+	for k := range resource {
+		if strings.EqualFold(k, name) {
+			delete(resource, k)
+		}
+	}
+	if current != nil {
+		resource[name] = current
+	}
+
+	if op != opRemove {
+		if value, err = normalizeValue(p, value); err != nil {
+			return err
+		}
+	} else if p.attr.Required && p.subAttr == nil && p.filter == nil {
+		return scim.ErrInvalidValue("%s is required", name)
+	}
+
+	switch {
+	case p.filter != nil:
+		return patchFilteredValues(resource, p, op, value)
+	case p.attr.MultiValued && p.subAttr != nil:
+		elements, _ := current.([]interface{})
+		if len(elements) == 0 && op != opRemove {
+			resource[name] = []interface{}{map[string]interface{}{p.subAttr.Name: value}}
+			return nil
+		}
+		for _, element := range elements {
+			if complex, ok := element.(map[string]interface{}); ok {
+				patchComplex(complex, p.subAttr.Name, op, value)
+			}
+		}
+	case p.subAttr != nil:
+		complex, _ := current.(map[string]interface{})
+		if complex == nil {
+			if op == opRemove {
+				return nil
+			}
+			complex = map[string]interface{}{}
+			resource[name] = complex
+		}
+		patchComplex(complex, p.subAttr.Name, op, value)
+	case p.attr.MultiValued:
+		elements, _ := current.([]interface{})
+		values, _ := value.([]interface{})
+		switch op {
+		case opAdd:
+			resource[name] = append(elements, values...)
+		case opReplace:
+			resource[name] = values
+		case opRemove:
+			// Some clients remove members with a list of values instead of a filter
+			if values, ok := value.([]interface{}); ok && len(values) > 0 {
+				resource[name] = removeElements(elements, values)
+			} else {
+				delete(resource, name)
+			}
+		}
+	case p.attr.Type == typeComplex:
+		complex, _ := current.(map[string]interface{})
+		values, _ := value.(map[string]interface{})
+		switch {
+		case op == opRemove:
+			delete(resource, name)
+		case complex == nil:
+			resource[name] = values
+		default:
+			for k, v := range values {
+				complex[k] = v
+			}
+		}
+	default:
+		if op == opRemove {
+			delete(resource, name)
+		} else {
+			resource[name] = value
+		}
+	}
+	return nil
+}
+
+// patchFilteredValues applies an operation to the elements of a multi-valued attribute matching
+// the filter of the path
+func patchFilteredValues(resource map[string]interface{}, p *patchPath, op string, value interface{}) error {
+	name := p.attr.Name
+	elements, _ := getAttribute(resource, name).([]interface{})
+
+	matched := false
+	kept := make([]interface{}, 0, len(elements))
+	for _, element := range elements {
+		complex, ok := element.(map[string]interface{})
+		if !ok || !p.filter.matches(complex) {
+			kept = append(kept, element)
+			continue
+		}
+		matched = true
+		switch {
+		case op == opRemove && p.subAttr == nil:
+			continue
+		case p.subAttr != nil:
+			patchComplex(complex, p.subAttr.Name, op, value)
+		case op == opReplace:
+			if replacement, ok := value.(map[string]interface{}); ok {
+				complex = replacement
+			}
+		default:
+			if values, ok := value.(map[string]interface{}); ok {
+				for k, v := range values {
+					complex[k] = v
+				}
+			}
+		}
+		kept = append(kept, complex)
+	}
+
+	if !matched {
+		if op == opRemove {
+			return nil
+		}
+		// Clients add values like emails[type eq "work"].value, the element is created
+		// from the filter
+		element, ok := elementFromFilter(p.filter)
+		if !ok {
+			return scim.NewError(http.StatusBadRequest, scim.ErrorTypeNoTarget, "no value of %s matches the filter", name)
+		}
+		if p.subAttr != nil {
+			element[p.subAttr.Name] = value
+		} else if values, ok := value.(map[string]interface{}); ok {
+			for k, v := range values {
+				element[k] = v
+			}
+		}
+		kept = append(kept, element)
+	}
+	resource[name] = kept
+	return nil
+}
+
+func patchComplex(complex map[string]interface{}, name, op string, value interface{}) {
+	for k := range complex {
+		if strings.EqualFold(k, name) {
+			delete(complex, k)
+		}
+	}
+	if op != opRemove {
+		complex[name] = value
+	}
+}
+
+// elementFromFilter returns the element of a multi-valued attribute matched by an eq filter
+func elementFromFilter(f filter) (map[string]interface{}, bool) {
+	eq, ok := f.(attrFilter)
+	if !ok || eq.op != "eq" || eq.path.subAttr != "" {
+		return nil, false
+	}
+	return map[string]interface{}{eq.path.attr: eq.value}, true
+}
+
+// removeElements removes the elements with the values of the removed elements
+func removeElements(elements, removed []interface{}) []interface{} {
+	removedValues := map[string]bool{}
+	for _, r := range removed {
+		if complex, ok := r.(map[string]interface{}); ok {
+			if v, ok := getAttribute(complex, "value").(string); ok {
+				removedValues[v] = true
+			}
+		}
+	}
+	kept := make([]interface{}, 0, len(elements))
+	for _, element := range elements {
+		if complex, ok := element.(map[string]interface{}); ok {
+			if v, ok := getAttribute(complex, "value").(string); ok && removedValues[v] {
+				continue
+			}
+		}
+		kept = append(kept, element)
+	}
+	return kept
+}
+
+// normalizeValue checks the type of a value. Booleans sent as strings, like "False", are
+// converted and single values of multi-valued attributes are wrapped in a list.
+func normalizeValue(p *patchPath, value interface{}) (interface{}, error) {
+	if p.subAttr != nil {
+		return normalizeSingleValue(*p.subAttr, value)
+	}
+	if !p.attr.MultiValued || p.filter != nil {
+		return normalizeSingleValue(p.attr, value)
+	}
+
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	normalized := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		n, err := normalizeSingleValue(p.attr, v)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, n)
+	}
+	return normalized, nil
+}
+
+func normalizeSingleValue(a attribute, value interface{}) (interface{}, error) {
+	switch a.Type {
+	case typeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, scim.ErrInvalidValue("%s must be a boolean", a.Name)
+			}
+			return b, nil
+		}
+		return nil, scim.ErrInvalidValue("%s must be a boolean", a.Name)
+	case typeComplex:
+		complex, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, scim.ErrInvalidValue("%s must be an object", a.Name)
+		}
+		normalized := make(map[string]interface{}, len(complex))
+		for k, v := range complex {
+			sub, ok := findAttribute(a.SubAttributes, k)
+			if !ok {
+				return nil, scim.ErrInvalidValue("unknown attribute %q", a.Name+"."+k)
+			}
+			n, err := normalizeSingleValue(sub, v)
+			if err != nil {
+				return nil, err
+			}
+			normalized[sub.Name] = n
+		}
+		return normalized, nil
+	case typeString:
+		if _, ok := value.(string); !ok && value != nil {
+			return nil, scim.ErrInvalidValue("%s must be a string", a.Name)
+		}
+	}
+	return value, nil
+}
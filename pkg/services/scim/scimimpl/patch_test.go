@@ -0,0 +1,161 @@
+package scimimpl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/scim"
+)
+
+func userResource() map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":     []interface{}{scim.SchemaUser},
+		"id":          "2",
+		"userName":    "alice",
+		"displayName": "Alice Smith",
+		"name":        map[string]interface{}{"formatted": "Alice Smith"},
+		"emails": []interface{}{
+			map[string]interface{}{"value": "alice@example.com", "type": "work", "primary": true},
+		},
+		"active": true,
+	}
+}
+
+func groupResource() map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":     []interface{}{scim.SchemaGroup},
+		"id":          "1",
+		"displayName": "Team A",
+		"members": []interface{}{
+			map[string]interface{}{"value": "2", "display": "alice"},
+			map[string]interface{}{"value": "3", "display": "bob"},
+		},
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	t.Run("replaces attributes", func(t *testing.T) {
+		resource := userResource()
+		err := applyPatch(resource, userAttributes, []scim.PatchOperation{
+			{Op: "Replace", Path: "displayName", Value: "Alice Jones"},
+			{Op: "replace", Path: "name.givenName", Value: "Alice"},
+			{Op: "replace", Path: `emails[type eq "work"].value`, Value: "alice@jones.com"},
+			{Op: "replace", Path: "active", Value: "False"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Alice Jones", resource["displayName"])
+		require.Equal(t, map[string]interface{}{"formatted": "Alice Smith", "givenName": "Alice"}, resource["name"])
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"value": "alice@jones.com", "type": "work", "primary": true},
+		}, resource["emails"])
+		require.Equal(t, false, resource["active"])
+	})
+
+	t.Run("applies operations without path", func(t *testing.T) {
+		resource := userResource()
+		err := applyPatch(resource, userAttributes, []scim.PatchOperation{
+			{Op: "replace", Value: map[string]interface{}{
+				"active":           false,
+				"name.familyName":  "Jones",
+				"externalId":       "a1",
+				scim.SchemaUser:    map[string]interface{}{"userName": "alice.jones"},
+				"urn:other:schema": map[string]interface{}{"displayName": "Alice Jones"},
+			}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, false, resource["active"])
+		require.Equal(t, "Jones", resource["name"].(map[string]interface{})["familyName"])
+		require.Equal(t, "a1", resource["externalId"])
+		require.Equal(t, "alice.jones", resource["userName"])
+		require.Equal(t, "Alice Jones", resource["displayName"])
+	})
+
+	t.Run("creates values matching the filter", func(t *testing.T) {
+		resource := userResource()
+		delete(resource, "emails")
+		err := applyPatch(resource, userAttributes, []scim.PatchOperation{
+			{Op: "add", Path: `emails[type eq "work"].value`, Value: "alice@example.com"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"type": "work", "value": "alice@example.com"},
+		}, resource["emails"])
+	})
+
+	t.Run("adds and removes members", func(t *testing.T) {
+		resource := groupResource()
+		err := applyPatch(resource, groupAttributes, []scim.PatchOperation{
+			{Op: "add", Path: "members", Value: []interface{}{map[string]interface{}{"value": "4"}}},
+			{Op: "remove", Path: `members[value eq "2"]`},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"value": "3", "display": "bob"},
+			map[string]interface{}{"value": "4"},
+		}, resource["members"])
+	})
+
+	t.Run("removes members listed in the value", func(t *testing.T) {
+		resource := groupResource()
+		err := applyPatch(resource, groupAttributes, []scim.PatchOperation{
+			{Op: "remove", Path: "members", Value: []interface{}{map[string]interface{}{"value": "3"}}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"value": "2", "display": "alice"},
+		}, resource["members"])
+	})
+
+	t.Run("removes all members", func(t *testing.T) {
+		resource := groupResource()
+		err := applyPatch(resource, groupAttributes, []scim.PatchOperation{{Op: "remove", Path: "members"}})
+		require.NoError(t, err)
+		require.NotContains(t, resource, "members")
+	})
+
+	t.Run("replaces members", func(t *testing.T) {
+		resource := groupResource()
+		err := applyPatch(resource, groupAttributes, []scim.PatchOperation{
+			{Op: "replace", Path: "members", Value: []interface{}{map[string]interface{}{"value": "5"}}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{map[string]interface{}{"value": "5"}}, resource["members"])
+	})
+}
+
+func TestApplyPatchErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation scim.PatchOperation
+		errorType string
+	}{
+		{name: "unknown operation", operation: scim.PatchOperation{Op: "move", Path: "userName"}, errorType: scim.ErrorTypeInvalidSyntax},
+		{name: "unknown attribute", operation: scim.PatchOperation{Op: "replace", Path: "title", Value: "a"}, errorType: scim.ErrorTypeInvalidPath},
+		{name: "unknown sub-attribute", operation: scim.PatchOperation{Op: "replace", Path: "name.middleName", Value: "a"}, errorType: scim.ErrorTypeInvalidPath},
+		{name: "filter on single value", operation: scim.PatchOperation{Op: "replace", Path: `name[givenName eq "a"]`, Value: "a"}, errorType: scim.ErrorTypeInvalidPath},
+		{name: "invalid filter", operation: scim.PatchOperation{Op: "replace", Path: `emails[type eq]`, Value: "a"}, errorType: scim.ErrorTypeInvalidFilter},
+		{name: "read-only attribute", operation: scim.PatchOperation{Op: "replace", Path: "id", Value: "3"}, errorType: scim.ErrorTypeMutability},
+		{name: "required attribute", operation: scim.PatchOperation{Op: "remove", Path: "userName"}, errorType: scim.ErrorTypeInvalidValue},
+		{name: "remove without path", operation: scim.PatchOperation{Op: "remove"}, errorType: scim.ErrorTypeNoTarget},
+		{name: "invalid boolean", operation: scim.PatchOperation{Op: "replace", Path: "active", Value: "maybe"}, errorType: scim.ErrorTypeInvalidValue},
+		{name: "invalid string", operation: scim.PatchOperation{Op: "replace", Path: "userName", Value: 1.0}, errorType: scim.ErrorTypeInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyPatch(userResource(), userAttributes, []scim.PatchOperation{tt.operation})
+			var scimErr *scim.Error
+			require.ErrorAs(t, err, &scimErr)
+			require.Equal(t, tt.errorType, scimErr.Type)
+		})
+	}
+
+	t.Run("no value matches the filter", func(t *testing.T) {
+		err := applyPatch(userResource(), userAttributes, []scim.PatchOperation{
+			{Op: "replace", Path: `emails[value co "bob"].type`, Value: "home"},
+		})
+		var scimErr *scim.Error
+		require.ErrorAs(t, err, &scimErr)
+		require.Equal(t, scim.ErrorTypeNoTarget, scimErr.Type)
+	})
+}
@@ -0,0 +1,31 @@
+package scimimpl
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+const (
+	ActionProvision = "scim:provision"
+)
+
+var (
+	scimProvisionerRole = accesscontrol.RoleDTO{
+		Name:        "fixed:scim:provisioner",
+		DisplayName: "SCIM provisioner",
+		Description: "Provision the users and the teams of the organization with SCIM",
+		Group:       "Users",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionProvision},
+		},
+	}
+)
+
+func declareFixedRoles(ac accesscontrol.Service) error {
+	provisioner := accesscontrol.RoleRegistration{
+		Role:   scimProvisionerRole,
+		Grants: []string{string(org.RoleAdmin)},
+	}
+
+	return ac.DeclareFixedRoles(provisioner)
+}
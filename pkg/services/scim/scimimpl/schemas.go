@@ -0,0 +1,208 @@
+package scimimpl
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/scim"
+)
+
+// Attribute characteristics of RFC 7643 section 2.2
+const (
+	typeString    = "string"
+	typeBoolean   = "boolean"
+	typeComplex   = "complex"
+	typeDateTime  = "dateTime"
+	typeReference = "reference"
+
+	mutabilityReadOnly  = "readOnly"
+	mutabilityReadWrite = "readWrite"
+	mutabilityImmutable = "immutable"
+	mutabilityWriteOnly = "writeOnly"
+
+	returnedAlways  = "always"
+	returnedNever   = "never"
+	returnedDefault = "default"
+
+	uniquenessNone   = "none"
+	uniquenessServer = "server"
+)
+
+type attribute struct {
+	Name           string      `json:"name"`
+	Type           string      `json:"type"`
+	MultiValued    bool        `json:"multiValued"`
+	Description    string      `json:"description"`
+	Required       bool        `json:"required"`
+	CaseExact      bool        `json:"caseExact"`
+	Mutability     string      `json:"mutability"`
+	Returned       string      `json:"returned"`
+	Uniqueness     string      `json:"uniqueness"`
+	ReferenceTypes []string    `json:"referenceTypes,omitempty"`
+	SubAttributes  []attribute `json:"subAttributes,omitempty"`
+}
+
+type schema struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Attributes  []attribute `json:"attributes"`
+}
+
+func stringAttribute(name, description string, required bool, mutability, returned, uniqueness string) attribute {
+	return attribute{
+		Name:        name,
+		Type:        typeString,
+		Description: description,
+		Required:    required,
+		Mutability:  mutability,
+		Returned:    returned,
+		Uniqueness:  uniqueness,
+	}
+}
+
+// commonAttributes are the attributes of all resources (RFC 7643 section 3.1), which
+// aren't listed in the schemas
+var commonAttributes = []attribute{
+	{Name: "schemas", Type: typeReference, MultiValued: true, Required: true, CaseExact: true, Mutability: mutabilityReadWrite, Returned: returnedAlways, Uniqueness: uniquenessNone},
+	stringAttribute("id", "Identifier of the resource", false, mutabilityReadOnly, returnedAlways, uniquenessServer),
+	stringAttribute("externalId", "Identifier of the resource in the provisioning client", false, mutabilityReadWrite, returnedDefault, uniquenessNone),
+	{
+		Name: "meta", Type: typeComplex, Mutability: mutabilityReadOnly, Returned: returnedDefault, Uniqueness: uniquenessNone,
+		SubAttributes: []attribute{
+			stringAttribute("resourceType", "", false, mutabilityReadOnly, returnedDefault, uniquenessNone),
+			{Name: "created", Type: typeDateTime, Mutability: mutabilityReadOnly, Returned: returnedDefault, Uniqueness: uniquenessNone},
+			{Name: "lastModified", Type: typeDateTime, Mutability: mutabilityReadOnly, Returned: returnedDefault, Uniqueness: uniquenessNone},
+			{Name: "location", Type: typeReference, Mutability: mutabilityReadOnly, Returned: returnedDefault, Uniqueness: uniquenessNone, ReferenceTypes: []string{"uri"}},
+		},
+	},
+}
+
+var userSchema = schema{
+	Schemas:     []string{scim.SchemaSchema},
+	ID:          scim.SchemaUser,
+	Name:        "User",
+	Description: "Grafana user",
+	Attributes: []attribute{
+		stringAttribute("userName", "Login of the user", true, mutabilityReadWrite, returnedAlways, uniquenessServer),
+		{
+			Name: "name", Type: typeComplex, Description: "Name of the user", Mutability: mutabilityReadWrite, Returned: returnedDefault, Uniqueness: uniquenessNone,
+			SubAttributes: []attribute{
+				stringAttribute("formatted", "Full name", false, mutabilityReadWrite, returnedDefault, uniquenessNone),
+				stringAttribute("givenName", "Given name", false, mutabilityReadWrite, returnedDefault, uniquenessNone),
+				stringAttribute("familyName", "Family name", false, mutabilityReadWrite, returnedDefault, uniquenessNone),
+			},
+		},
+		stringAttribute("displayName", "Name of the user displayed in Grafana", false, mutabilityReadWrite, returnedDefault, uniquenessNone),
+		{
+			Name: "emails", Type: typeComplex, MultiValued: true, Description: "Email of the user, Grafana users have a single email", Mutability: mutabilityReadWrite, Returned: returnedDefault, Uniqueness: uniquenessNone,
+			SubAttributes: []attribute{
+				stringAttribute("value", "Email address", false, mutabilityReadWrite, returnedDefault, uniquenessNone),
+				stringAttribute("type", "Type of the email", false, mutabilityReadWrite, returnedDefault, uniquenessNone),
+				{Name: "primary", Type: typeBoolean, Mutability: mutabilityReadWrite, Returned: returnedDefault, Uniqueness: uniquenessNone},
+			},
+		},
+		{Name: "active", Type: typeBoolean, Description: "False when the user is disabled", Mutability: mutabilityReadWrite, Returned: returnedDefault, Uniqueness: uniquenessNone},
+	},
+}
+
+var groupSchema = schema{
+	Schemas:     []string{scim.SchemaSchema},
+	ID:          scim.SchemaGroup,
+	Name:        "Group",
+	Description: "Grafana team",
+	Attributes: []attribute{
+		stringAttribute("displayName", "Name of the team", true, mutabilityReadWrite, returnedDefault, uniquenessServer),
+		{
+			Name: "members", Type: typeComplex, MultiValued: true, Description: "Members of the team", Mutability: mutabilityReadWrite, Returned: returnedDefault, Uniqueness: uniquenessNone,
+			SubAttributes: []attribute{
+				stringAttribute("value", "Identifier of the user", false, mutabilityImmutable, returnedDefault, uniquenessNone),
+				{Name: "$ref", Type: typeReference, Mutability: mutabilityImmutable, Returned: returnedDefault, Uniqueness: uniquenessNone, ReferenceTypes: []string{"User"}},
+				stringAttribute("display", "Login of the user", false, mutabilityReadOnly, returnedDefault, uniquenessNone),
+				stringAttribute("type", "Type of the member, only User is supported", false, mutabilityImmutable, returnedDefault, uniquenessNone),
+			},
+		},
+	},
+}
+
+// resourceAttributes returns the attributes of the resources of a schema, common attributes included
+func resourceAttributes(s schema) []attribute {
+	return append(append([]attribute{}, commonAttributes...), s.Attributes...)
+}
+
+// findAttribute looks up an attribute case-insensitively
+func findAttribute(attributes []attribute, name string) (attribute, bool) {
+	for _, a := range attributes {
+		if strings.EqualFold(a.Name, name) {
+			return a, true
+		}
+	}
+	return attribute{}, false
+}
+
+type resourceType struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Endpoint    string   `json:"endpoint"`
+	Description string   `json:"description"`
+	Schema      string   `json:"schema"`
+}
+
+var resourceTypes = []resourceType{
+	{Schemas: []string{scim.SchemaResourceType}, ID: scim.ResourceTypeUser, Name: scim.ResourceTypeUser, Endpoint: "/Users", Description: "Grafana users", Schema: scim.SchemaUser},
+	{Schemas: []string{scim.SchemaResourceType}, ID: scim.ResourceTypeGroup, Name: scim.ResourceTypeGroup, Endpoint: "/Groups", Description: "Grafana teams", Schema: scim.SchemaGroup},
+}
+
+type supported struct {
+	Supported bool `json:"supported"`
+}
+
+type filterSupport struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type bulkSupport struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type authenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary"`
+}
+
+type serviceProviderConfig struct {
+	Schemas               []string               `json:"schemas"`
+	DocumentationURI      string                 `json:"documentationUri"`
+	Patch                 supported              `json:"patch"`
+	Bulk                  bulkSupport            `json:"bulk"`
+	Filter                filterSupport          `json:"filter"`
+	ChangePassword        supported              `json:"changePassword"`
+	Sort                  supported              `json:"sort"`
+	ETag                  supported              `json:"etag"`
+	AuthenticationSchemes []authenticationScheme `json:"authenticationSchemes"`
+}
+
+var providerConfig = serviceProviderConfig{
+	Schemas:          []string{scim.SchemaServiceProviderConfig},
+	DocumentationURI: "https://grafana.com/docs/grafana/latest/developers/http_api/scim/",
+	Patch:            supported{Supported: true},
+	Bulk:             bulkSupport{Supported: false},
+	Filter:           filterSupport{Supported: true, MaxResults: maxCount},
+	ChangePassword:   supported{Supported: false},
+	Sort:             supported{Supported: false},
+	ETag:             supported{Supported: false},
+	AuthenticationSchemes: []authenticationScheme{
+		{
+			Type:        "oauthbearertoken",
+			Name:        "Service account token",
+			Description: "Token of a Grafana service account, sent in the Authorization header as a bearer token",
+			Primary:     true,
+		},
+	},
+}
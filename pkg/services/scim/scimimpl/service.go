@@ -0,0 +1,199 @@
+package scimimpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/scim"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// defaultCount is the number of resources returned by list requests without count
+	defaultCount = 100
+	// maxCount is the maximum number of resources returned by list requests
+	maxCount = 1000
+	// basePath is the path of the SCIM endpoints
+	basePath = "/api/scim/v2"
+)
+
+var _ scim.Service = (*Service)(nil)
+
+type Service struct {
+	store                  store
+	userService            user.Service
+	orgService             org.Service
+	teamService            team.Service
+	teamPermissionsService ac.TeamPermissionsService
+	userTokenService       auth.UserTokenService
+	accessControl          ac.AccessControl
+	log                    log.Logger
+
+	enabled bool
+	// defaultOrgRole is the role of the provisioned users in the organization
+	defaultOrgRole org.RoleType
+	appURL         string
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sql db.DB,
+	routeRegister routing.RouteRegister,
+	accessControl ac.AccessControl,
+	accesscontrolService ac.Service,
+	userService user.Service,
+	orgService org.Service,
+	teamService team.Service,
+	teamPermissionsService ac.TeamPermissionsService,
+	userTokenService auth.UserTokenService,
+) (*Service, error) {
+	section := cfg.SectionWithEnvOverrides("auth.scim")
+	s := &Service{
+		store:                  &sqlStore{db: sql},
+		userService:            userService,
+		orgService:             orgService,
+		teamService:            teamService,
+		teamPermissionsService: teamPermissionsService,
+		userTokenService:       userTokenService,
+		accessControl:          accessControl,
+		log:                    log.New("scim"),
+		enabled:                section.Key("enabled").MustBool(false),
+		defaultOrgRole:         org.RoleType(section.Key("default_org_role").MustString(string(org.RoleViewer))),
+		appURL:                 strings.TrimSuffix(cfg.AppURL, "/"),
+	}
+	if !s.defaultOrgRole.IsValid() {
+		return nil, fmt.Errorf("invalid auth.scim default_org_role: %s", s.defaultOrgRole)
+	}
+
+	if !s.enabled {
+		return s, nil
+	}
+
+	if err := declareFixedRoles(accesscontrolService); err != nil {
+		return nil, err
+	}
+
+	s.registerAPIEndpoints(routeRegister)
+
+	return s, nil
+}
+
+func (s *Service) meta(resourceType, endpoint, id string, created, updated time.Time) *scim.Meta {
+	meta := &scim.Meta{
+		ResourceType: resourceType,
+		Location:     fmt.Sprintf("%s%s/%s/%s", s.appURL, basePath, endpoint, id),
+	}
+	if !created.IsZero() {
+		meta.Created = &created
+	}
+	if !updated.IsZero() {
+		meta.LastModified = &updated
+	}
+	return meta
+}
+
+// parseID parses the ID of a resource, IDs which aren't numbers don't exist
+func parseID(resourceType, id string) (int64, error) {
+	parsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || parsed <= 0 {
+		return 0, scim.ErrNotFound(resourceType, id)
+	}
+	return parsed, nil
+}
+
+// toMap returns the JSON representation of a resource, used to evaluate filters and
+// apply PATCH operations
+func toMap(resource interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	return m, json.Unmarshal(data, &m)
+}
+
+// fromMap converts the JSON representation of a resource back to the resource
+func fromMap(m map[string]interface{}, resource interface{}) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, resource); err != nil {
+		return scim.ErrInvalidValue("%s", err)
+	}
+	return nil
+}
+
+// list filters and paginates resources
+func list[T any](query *scim.ListQuery, resources []T) (*scim.ListResponse, error) {
+	f, err := parseFilter(query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]interface{}, 0, len(resources))
+	for _, r := range resources {
+		if f != nil {
+			m, err := toMap(r)
+			if err != nil {
+				return nil, err
+			}
+			if !f.matches(m) {
+				continue
+			}
+		}
+		matched = append(matched, r)
+	}
+
+	startIndex, count := query.StartIndex, query.Count
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = 0
+	}
+	if count > maxCount {
+		count = maxCount
+	}
+	start := startIndex - 1
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + count
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	return &scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: len(matched),
+		StartIndex:   startIndex,
+		ItemsPerPage: end - start,
+		Resources:    matched[start:end],
+	}, nil
+}
+
+// orgReader is the signed in user of the queries filtered by access control, allowed to
+// read the teams and the users of the organization
+func orgReader(orgID int64) *user.SignedInUser {
+	return &user.SignedInUser{
+		OrgID: orgID,
+		Permissions: map[int64]map[string][]string{
+			orgID: {
+				ac.ActionTeamsRead:    {ac.ScopeTeamsAll},
+				ac.ActionOrgUsersRead: {ac.ScopeUsersAll},
+			},
+		},
+	}
+}
@@ -0,0 +1,610 @@
+package scimimpl
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/auth/authtest"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/scim"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/team/teamtest"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+)
+
+const testOrgID = 1
+
+// provisioner is the service account of the SCIM client
+var provisioner = &user.SignedInUser{UserID: 100, OrgID: testOrgID, IsServiceAccount: true}
+
+func TestService_Users(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates users in the organization", func(t *testing.T) {
+		s, d := setupTestService(t)
+
+		created, err := s.CreateUser(ctx, testOrgID, &scim.User{
+			UserName:   "alice",
+			ExternalID: "a1",
+			Name:       &scim.Name{GivenName: "Alice", FamilyName: "Smith"},
+			Emails:     []scim.Email{{Value: "alice@home.com"}, {Value: "alice@example.com", Primary: true}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "alice", created.UserName)
+		require.Equal(t, "a1", created.ExternalID)
+		require.Equal(t, "Alice Smith", created.DisplayName)
+		require.Equal(t, []scim.Email{{Value: "alice@example.com", Type: "work", Primary: true}}, created.Emails)
+		require.True(t, *created.Active)
+		require.Equal(t, "http://localhost:3000/api/scim/v2/Users/"+created.ID, created.Meta.Location)
+
+		userID, _ := strconv.ParseInt(created.ID, 10, 64)
+		require.Equal(t, org.RoleViewer, d.orgUsers[testOrgID][userID])
+		require.NotEmpty(t, d.users[userID].Password)
+	})
+
+	t.Run("rejects existing logins and emails", func(t *testing.T) {
+		s, d := setupTestService(t)
+		d.addUser("alice", "alice@example.com", testOrgID)
+
+		_, err := s.CreateUser(ctx, testOrgID, &scim.User{UserName: "alice"})
+		requireSCIMError(t, err, http.StatusConflict, scim.ErrorTypeUniqueness)
+
+		_, err = s.CreateUser(ctx, testOrgID, &scim.User{UserName: "alice2", Emails: []scim.Email{{Value: "alice@example.com"}}})
+		requireSCIMError(t, err, http.StatusConflict, scim.ErrorTypeUniqueness)
+
+		_, err = s.CreateUser(ctx, testOrgID, &scim.User{})
+		requireSCIMError(t, err, http.StatusBadRequest, scim.ErrorTypeInvalidValue)
+	})
+
+	t.Run("lists the users of the organization", func(t *testing.T) {
+		s, d := setupTestService(t)
+		d.addUser("alice", "alice@example.com", testOrgID)
+		d.addUser("bob", "bob@example.com", testOrgID)
+		d.addUser("carol", "carol@example.com", 2)
+
+		result, err := s.ListUsers(ctx, &scim.ListQuery{OrgID: testOrgID, StartIndex: 1, Count: 10})
+		require.NoError(t, err)
+		require.Equal(t, 2, result.TotalResults)
+
+		result, err = s.ListUsers(ctx, &scim.ListQuery{OrgID: testOrgID, Filter: `userName eq "BOB"`, StartIndex: 1, Count: 10})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.TotalResults)
+		require.Equal(t, "bob", result.Resources[0].(*scim.User).UserName)
+
+		result, err = s.ListUsers(ctx, &scim.ListQuery{OrgID: testOrgID, StartIndex: 2, Count: 10})
+		require.NoError(t, err)
+		require.Equal(t, 2, result.TotalResults)
+		require.Equal(t, 2, result.StartIndex)
+		require.Equal(t, 1, result.ItemsPerPage)
+
+		result, err = s.ListUsers(ctx, &scim.ListQuery{OrgID: testOrgID, StartIndex: 1, Count: 0})
+		require.NoError(t, err)
+		require.Equal(t, 2, result.TotalResults)
+		require.Empty(t, result.Resources)
+
+		_, err = s.ListUsers(ctx, &scim.ListQuery{OrgID: testOrgID, Filter: `userName eq`})
+		requireSCIMError(t, err, http.StatusBadRequest, scim.ErrorTypeInvalidFilter)
+
+		_, err = s.GetUser(ctx, testOrgID, "3")
+		requireSCIMError(t, err, http.StatusNotFound, "")
+		_, err = s.GetUser(ctx, testOrgID, "abc")
+		requireSCIMError(t, err, http.StatusNotFound, "")
+	})
+
+	t.Run("patches users", func(t *testing.T) {
+		s, d := setupTestService(t)
+		id := d.addUser("alice", "alice@example.com", testOrgID)
+
+		patched, err := s.PatchUser(ctx, provisioner, testOrgID, strconv.FormatInt(id, 10), &scim.PatchRequest{
+			Schemas: []string{scim.SchemaPatchOp},
+			Operations: []scim.PatchOperation{
+				{Op: "Replace", Path: "name.givenName", Value: "Alice"},
+				{Op: "Replace", Path: "name.familyName", Value: "Jones"},
+				{Op: "Replace", Path: `emails[type eq "work"].value`, Value: "alice@jones.com"},
+				{Op: "Add", Path: "externalId", Value: "a1"},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Alice Jones", patched.DisplayName)
+		require.Equal(t, "alice@jones.com", d.users[id].Email)
+		require.Equal(t, "a1", patched.ExternalID)
+		require.True(t, *patched.Active)
+		require.Empty(t, d.revoked)
+	})
+
+	t.Run("rejects passwords", func(t *testing.T) {
+		s, d := setupTestService(t)
+		id := d.addUser("alice", "alice@example.com", testOrgID)
+
+		_, err := s.CreateUser(ctx, testOrgID, &scim.User{UserName: "bob", Password: "secret"})
+		requireSCIMError(t, err, http.StatusBadRequest, scim.ErrorTypeMutability)
+		_, err = s.ReplaceUser(ctx, provisioner, testOrgID, strconv.FormatInt(id, 10), &scim.User{UserName: "alice", Password: "secret"})
+		requireSCIMError(t, err, http.StatusBadRequest, scim.ErrorTypeMutability)
+		_, err = s.PatchUser(ctx, provisioner, testOrgID, strconv.FormatInt(id, 10), &scim.PatchRequest{
+			Operations: []scim.PatchOperation{{Op: "Add", Path: "password", Value: "secret"}},
+		})
+		requireSCIMError(t, err, http.StatusBadRequest, scim.ErrorTypeInvalidPath)
+		require.Equal(t, "salt", d.users[id].Salt)
+		require.Empty(t, d.users[id].Password)
+	})
+
+	t.Run("requires the server administrator permission to change logins and emails", func(t *testing.T) {
+		s, d := setupTestService(t)
+		s.accessControl = actest.FakeAccessControl{ExpectedEvaluate: false}
+		id := d.addUser("alice", "alice@example.com", testOrgID)
+
+		_, err := s.ReplaceUser(ctx, provisioner, testOrgID, strconv.FormatInt(id, 10), &scim.User{UserName: "mallory"})
+		requireSCIMError(t, err, http.StatusForbidden, "")
+		_, err = s.PatchUser(ctx, provisioner, testOrgID, strconv.FormatInt(id, 10), &scim.PatchRequest{
+			Operations: []scim.PatchOperation{{Op: "Replace", Path: `emails[type eq "work"].value`, Value: "mallory@example.com"}},
+		})
+		requireSCIMError(t, err, http.StatusForbidden, "")
+		require.Equal(t, "alice", d.users[id].Login)
+		require.Equal(t, "alice@example.com", d.users[id].Email)
+
+		// the other attributes can still be changed
+		replaced, err := s.ReplaceUser(ctx, provisioner, testOrgID, strconv.FormatInt(id, 10), &scim.User{UserName: "alice", DisplayName: "Alice"})
+		require.NoError(t, err)
+		require.Equal(t, "Alice", replaced.DisplayName)
+	})
+
+	t.Run("deactivates users", func(t *testing.T) {
+		s, d := setupTestService(t)
+		id := d.addUser("alice", "alice@example.com", testOrgID)
+
+		patched, err := s.PatchUser(ctx, provisioner, testOrgID, strconv.FormatInt(id, 10), &scim.PatchRequest{
+			Operations: []scim.PatchOperation{{Op: "Replace", Path: "active", Value: "False"}},
+		})
+		require.NoError(t, err)
+		require.False(t, *patched.Active)
+		require.True(t, d.users[id].IsDisabled)
+		require.Equal(t, []int64{id}, d.revoked)
+
+		active := true
+		replaced, err := s.ReplaceUser(ctx, provisioner, testOrgID, strconv.FormatInt(id, 10), &scim.User{UserName: "alice", Active: &active})
+		require.NoError(t, err)
+		require.True(t, *replaced.Active)
+		require.False(t, d.users[id].IsDisabled)
+	})
+
+	t.Run("rejects updates of users managed outside of the organization", func(t *testing.T) {
+		s, d := setupTestService(t)
+		admin := d.addUser("admin", "admin@example.com", testOrgID)
+		d.users[admin].IsAdmin = true
+		shared := d.addUser("shared", "shared@example.com", testOrgID)
+		d.orgUsers[2] = map[int64]org.RoleType{shared: org.RoleViewer}
+
+		_, err := s.ReplaceUser(ctx, provisioner, testOrgID, strconv.FormatInt(admin, 10), &scim.User{UserName: "admin"})
+		requireSCIMError(t, err, http.StatusForbidden, "")
+		_, err = s.ReplaceUser(ctx, provisioner, testOrgID, strconv.FormatInt(shared, 10), &scim.User{UserName: "shared"})
+		requireSCIMError(t, err, http.StatusForbidden, "")
+	})
+
+	t.Run("rejects renames to existing logins", func(t *testing.T) {
+		s, d := setupTestService(t)
+		id := d.addUser("alice", "alice@example.com", testOrgID)
+		d.addUser("bob", "bob@example.com", testOrgID)
+
+		_, err := s.ReplaceUser(ctx, provisioner, testOrgID, strconv.FormatInt(id, 10), &scim.User{UserName: "bob"})
+		requireSCIMError(t, err, http.StatusConflict, scim.ErrorTypeUniqueness)
+	})
+
+	t.Run("removes users from the organization", func(t *testing.T) {
+		s, d := setupTestService(t)
+		alice := d.addUser("alice", "alice@example.com", testOrgID)
+		bob := d.addUser("bob", "bob@example.com", testOrgID)
+		d.orgUsers[2] = map[int64]org.RoleType{bob: org.RoleViewer}
+
+		require.NoError(t, s.DeleteUser(ctx, testOrgID, strconv.FormatInt(alice, 10)))
+		require.NotContains(t, d.orgUsers[testOrgID], alice)
+		require.True(t, d.users[alice].IsDisabled)
+		require.Equal(t, []int64{alice}, d.revoked)
+
+		require.NoError(t, s.DeleteUser(ctx, testOrgID, strconv.FormatInt(bob, 10)))
+		require.NotContains(t, d.orgUsers[testOrgID], bob)
+		require.False(t, d.users[bob].IsDisabled)
+
+		err := s.DeleteUser(ctx, testOrgID, strconv.FormatInt(bob, 10))
+		requireSCIMError(t, err, http.StatusNotFound, "")
+	})
+
+	t.Run("only removes server administrators from the organization", func(t *testing.T) {
+		s, d := setupTestService(t)
+		admin := d.addUser("admin", "admin@example.com", testOrgID)
+		d.users[admin].IsAdmin = true
+
+		require.NoError(t, s.DeleteUser(ctx, testOrgID, strconv.FormatInt(admin, 10)))
+		require.NotContains(t, d.orgUsers[testOrgID], admin)
+		require.False(t, d.users[admin].IsDisabled)
+		require.Empty(t, d.revoked)
+	})
+}
+
+func TestService_Groups(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates teams with members", func(t *testing.T) {
+		s, d := setupTestService(t)
+		alice := d.addUser("alice", "alice@example.com", testOrgID)
+
+		created, err := s.CreateGroup(ctx, testOrgID, &scim.Group{
+			DisplayName: "Team A",
+			ExternalID:  "g1",
+			Members:     []scim.Member{{Value: strconv.FormatInt(alice, 10)}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Team A", created.DisplayName)
+		require.Equal(t, "g1", created.ExternalID)
+		require.Equal(t, []scim.Member{{
+			Value:   strconv.FormatInt(alice, 10),
+			Display: "alice",
+			Ref:     "http://localhost:3000/api/scim/v2/Users/" + strconv.FormatInt(alice, 10),
+			Type:    scim.ResourceTypeUser,
+		}}, created.Members)
+
+		_, err = s.CreateGroup(ctx, testOrgID, &scim.Group{DisplayName: "Team A"})
+		requireSCIMError(t, err, http.StatusConflict, scim.ErrorTypeUniqueness)
+	})
+
+	t.Run("rejects members outside of the organization", func(t *testing.T) {
+		s, d := setupTestService(t)
+		carol := d.addUser("carol", "carol@example.com", 2)
+
+		_, err := s.CreateGroup(ctx, testOrgID, &scim.Group{
+			DisplayName: "Team A",
+			Members:     []scim.Member{{Value: strconv.FormatInt(carol, 10)}},
+		})
+		requireSCIMError(t, err, http.StatusBadRequest, scim.ErrorTypeInvalidValue)
+		require.Empty(t, d.teams)
+	})
+
+	t.Run("patches members", func(t *testing.T) {
+		s, d := setupTestService(t)
+		alice := d.addUser("alice", "alice@example.com", testOrgID)
+		bob := d.addUser("bob", "bob@example.com", testOrgID)
+		group, err := s.CreateGroup(ctx, testOrgID, &scim.Group{DisplayName: "Team A", Members: []scim.Member{{Value: strconv.FormatInt(alice, 10)}}})
+		require.NoError(t, err)
+
+		patched, err := s.PatchGroup(ctx, testOrgID, group.ID, &scim.PatchRequest{
+			Operations: []scim.PatchOperation{
+				{Op: "Add", Path: "members", Value: []interface{}{map[string]interface{}{"value": strconv.FormatInt(bob, 10)}}},
+				{Op: "Remove", Path: "members", Value: []interface{}{map[string]interface{}{"value": strconv.FormatInt(alice, 10)}}},
+				{Op: "Replace", Path: "displayName", Value: "Team B"},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Team B", patched.DisplayName)
+		require.Equal(t, []int64{bob}, d.teamMembers(group.ID))
+
+		replaced, err := s.ReplaceGroup(ctx, testOrgID, group.ID, &scim.Group{
+			DisplayName: "Team B",
+			Members:     []scim.Member{{Value: strconv.FormatInt(alice, 10)}, {Value: strconv.FormatInt(bob, 10)}},
+		})
+		require.NoError(t, err)
+		require.Len(t, replaced.Members, 2)
+		require.Equal(t, []int64{alice, bob}, d.teamMembers(group.ID))
+	})
+
+	t.Run("lists teams", func(t *testing.T) {
+		s, d := setupTestService(t)
+		alice := d.addUser("alice", "alice@example.com", testOrgID)
+		_, err := s.CreateGroup(ctx, testOrgID, &scim.Group{DisplayName: "Team A", Members: []scim.Member{{Value: strconv.FormatInt(alice, 10)}}})
+		require.NoError(t, err)
+		_, err = s.CreateGroup(ctx, testOrgID, &scim.Group{DisplayName: "Team B"})
+		require.NoError(t, err)
+
+		result, err := s.ListGroups(ctx, &scim.ListQuery{OrgID: testOrgID, Filter: `displayName eq "team a"`, StartIndex: 1, Count: 10})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.TotalResults)
+		require.Len(t, result.Resources[0].(*scim.Group).Members, 1)
+
+		result, err = s.ListGroups(ctx, &scim.ListQuery{OrgID: testOrgID, StartIndex: 1, Count: 10, ExcludeMembers: true})
+		require.NoError(t, err)
+		require.Equal(t, 2, result.TotalResults)
+		require.Empty(t, result.Resources[0].(*scim.Group).Members)
+	})
+
+	t.Run("deletes teams", func(t *testing.T) {
+		s, d := setupTestService(t)
+		group, err := s.CreateGroup(ctx, testOrgID, &scim.Group{DisplayName: "Team A"})
+		require.NoError(t, err)
+
+		require.NoError(t, s.DeleteGroup(ctx, testOrgID, group.ID))
+		require.Empty(t, d.teams)
+
+		err = s.DeleteGroup(ctx, testOrgID, group.ID)
+		requireSCIMError(t, err, http.StatusNotFound, "")
+	})
+}
+
+func requireSCIMError(t *testing.T, err error, status int, errorType string) {
+	t.Helper()
+	var scimErr *scim.Error
+	require.ErrorAs(t, err, &scimErr)
+	require.Equal(t, status, scimErr.Status)
+	require.Equal(t, errorType, scimErr.Type)
+}
+
+func setupTestService(t *testing.T) (*Service, *directory) {
+	t.Helper()
+
+	d := &directory{
+		users:    map[int64]*user.User{},
+		orgUsers: map[int64]map[int64]org.RoleType{testOrgID: {}},
+		teams:    map[int64]*team.Team{},
+		members:  map[int64]map[int64]bool{},
+	}
+	tokens := authtest.NewFakeUserAuthTokenService()
+	tokens.RevokeAllUserTokensProvider = func(ctx context.Context, userID int64) error {
+		d.revoked = append(d.revoked, userID)
+		return nil
+	}
+
+	s := &Service{
+		store:                  &fakeStore{ids: map[externalIDKey]string{}},
+		userService:            &fakeUserService{FakeUserService: usertest.NewUserServiceFake(), d: d},
+		orgService:             &fakeOrgService{FakeOrgService: orgtest.NewOrgServiceFake(), d: d},
+		teamService:            &fakeTeamService{FakeService: teamtest.NewFakeService(), d: d},
+		teamPermissionsService: &fakeTeamPermissions{d: d},
+		userTokenService:       tokens,
+		accessControl:          actest.FakeAccessControl{ExpectedEvaluate: true},
+		log:                    log.NewNopLogger(),
+		enabled:                true,
+		defaultOrgRole:         org.RoleViewer,
+		appURL:                 "http://localhost:3000",
+	}
+	return s, d
+}
+
+// directory is the state of the fake user, organization and team services
+type directory struct {
+	nextID   int64
+	users    map[int64]*user.User
+	orgUsers map[int64]map[int64]org.RoleType
+	teams    map[int64]*team.Team
+	members  map[int64]map[int64]bool
+	revoked  []int64
+}
+
+func (d *directory) addUser(login, email string, orgID int64) int64 {
+	d.nextID++
+	d.users[d.nextID] = &user.User{ID: d.nextID, Login: login, Email: email, Salt: "salt", Created: time.Now(), Updated: time.Now()}
+	if d.orgUsers[orgID] == nil {
+		d.orgUsers[orgID] = map[int64]org.RoleType{}
+	}
+	d.orgUsers[orgID][d.nextID] = org.RoleViewer
+	return d.nextID
+}
+
+func (d *directory) teamMembers(id string) []int64 {
+	teamID, _ := strconv.ParseInt(id, 10, 64)
+	members := []int64{}
+	for userID := range d.members[teamID] {
+		members = append(members, userID)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i] < members[j] })
+	return members
+}
+
+type fakeUserService struct {
+	*usertest.FakeUserService
+	d *directory
+}
+
+func (f *fakeUserService) Create(ctx context.Context, cmd *user.CreateUserCommand) (*user.User, error) {
+	f.d.nextID++
+	u := &user.User{ID: f.d.nextID, Login: cmd.Login, Email: cmd.Email, Name: cmd.Name, Password: cmd.Password, IsDisabled: cmd.IsDisabled, Created: time.Now(), Updated: time.Now()}
+	f.d.users[u.ID] = u
+	return u, nil
+}
+
+func (f *fakeUserService) GetByID(ctx context.Context, query *user.GetUserByIDQuery) (*user.User, error) {
+	if u, ok := f.d.users[query.ID]; ok {
+		return u, nil
+	}
+	return nil, user.ErrUserNotFound
+}
+
+func (f *fakeUserService) GetByLogin(ctx context.Context, query *user.GetUserByLoginQuery) (*user.User, error) {
+	for _, u := range f.d.users {
+		if u.Login == query.LoginOrEmail || u.Email == query.LoginOrEmail {
+			return u, nil
+		}
+	}
+	return nil, user.ErrUserNotFound
+}
+
+func (f *fakeUserService) GetByEmail(ctx context.Context, query *user.GetUserByEmailQuery) (*user.User, error) {
+	for _, u := range f.d.users {
+		if u.Email == query.Email {
+			return u, nil
+		}
+	}
+	return nil, user.ErrUserNotFound
+}
+
+func (f *fakeUserService) Update(ctx context.Context, cmd *user.UpdateUserCommand) error {
+	u := f.d.users[cmd.UserID]
+	if cmd.Login != "" {
+		u.Login = cmd.Login
+	}
+	if cmd.Email != "" {
+		u.Email = cmd.Email
+	}
+	if cmd.Name != "" {
+		u.Name = cmd.Name
+	}
+	return nil
+}
+
+func (f *fakeUserService) Disable(ctx context.Context, cmd *user.DisableUserCommand) error {
+	f.d.users[cmd.UserID].IsDisabled = cmd.IsDisabled
+	return nil
+}
+
+func (f *fakeUserService) ChangePassword(ctx context.Context, cmd *user.ChangeUserPasswordCommand) error {
+	f.d.users[cmd.UserID].Password = cmd.NewPassword
+	return nil
+}
+
+type fakeOrgService struct {
+	*orgtest.FakeOrgService
+	d *directory
+}
+
+func (f *fakeOrgService) AddOrgUser(ctx context.Context, cmd *org.AddOrgUserCommand) error {
+	f.d.orgUsers[cmd.OrgID][cmd.UserID] = cmd.Role
+	return nil
+}
+
+func (f *fakeOrgService) RemoveOrgUser(ctx context.Context, cmd *org.RemoveOrgUserCommand) error {
+	delete(f.d.orgUsers[cmd.OrgID], cmd.UserID)
+	return nil
+}
+
+func (f *fakeOrgService) GetUserOrgList(ctx context.Context, query *org.GetUserOrgListQuery) ([]*org.UserOrgDTO, error) {
+	orgs := []*org.UserOrgDTO{}
+	for orgID, users := range f.d.orgUsers {
+		if role, ok := users[query.UserID]; ok {
+			orgs = append(orgs, &org.UserOrgDTO{OrgID: orgID, Role: role})
+		}
+	}
+	return orgs, nil
+}
+
+func (f *fakeOrgService) GetOrgUsers(ctx context.Context, query *org.GetOrgUsersQuery) ([]*org.OrgUserDTO, error) {
+	users := []*org.OrgUserDTO{}
+	for userID, role := range f.d.orgUsers[query.OrgID] {
+		if query.UserID != 0 && query.UserID != userID {
+			continue
+		}
+		u := f.d.users[userID]
+		users = append(users, &org.OrgUserDTO{
+			OrgID: query.OrgID, UserID: u.ID, Login: u.Login, Email: u.Email, Name: u.Name, Role: string(role), IsDisabled: u.IsDisabled,
+			Created: u.Created, Updated: u.Updated,
+		})
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+	return users, nil
+}
+
+type fakeTeamService struct {
+	*teamtest.FakeService
+	d *directory
+}
+
+func (f *fakeTeamService) CreateTeam(name, email string, orgID int64) (team.Team, error) {
+	for _, t := range f.d.teams {
+		if t.OrgID == orgID && t.Name == name {
+			return team.Team{}, team.ErrTeamNameTaken
+		}
+	}
+	f.d.nextID++
+	t := &team.Team{ID: f.d.nextID, OrgID: orgID, Name: name}
+	f.d.teams[t.ID] = t
+	return *t, nil
+}
+
+func (f *fakeTeamService) UpdateTeam(ctx context.Context, cmd *team.UpdateTeamCommand) error {
+	f.d.teams[cmd.ID].Name = cmd.Name
+	return nil
+}
+
+func (f *fakeTeamService) DeleteTeam(ctx context.Context, cmd *team.DeleteTeamCommand) error {
+	if _, ok := f.d.teams[cmd.ID]; !ok {
+		return team.ErrTeamNotFound
+	}
+	delete(f.d.teams, cmd.ID)
+	return nil
+}
+
+func (f *fakeTeamService) SearchTeams(ctx context.Context, query *team.SearchTeamsQuery) (team.SearchTeamQueryResult, error) {
+	result := team.SearchTeamQueryResult{}
+	for _, t := range f.d.teams {
+		if t.OrgID == query.OrgID {
+			result.Teams = append(result.Teams, &team.TeamDTO{ID: t.ID, OrgID: t.OrgID, Name: t.Name})
+		}
+	}
+	sort.Slice(result.Teams, func(i, j int) bool { return result.Teams[i].ID < result.Teams[j].ID })
+	result.TotalCount = int64(len(result.Teams))
+	return result, nil
+}
+
+func (f *fakeTeamService) GetTeamByID(ctx context.Context, query *team.GetTeamByIDQuery) (*team.TeamDTO, error) {
+	t, ok := f.d.teams[query.ID]
+	if !ok || t.OrgID != query.OrgID {
+		return nil, team.ErrTeamNotFound
+	}
+	return &team.TeamDTO{ID: t.ID, OrgID: t.OrgID, Name: t.Name}, nil
+}
+
+func (f *fakeTeamService) GetTeamMembers(ctx context.Context, query *team.GetTeamMembersQuery) ([]*team.TeamMemberDTO, error) {
+	members := []*team.TeamMemberDTO{}
+	for userID := range f.d.members[query.TeamID] {
+		members = append(members, &team.TeamMemberDTO{OrgID: query.OrgID, TeamID: query.TeamID, UserID: userID, Login: f.d.users[userID].Login})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].UserID < members[j].UserID })
+	return members, nil
+}
+
+type fakeTeamPermissions struct {
+	d *directory
+}
+
+func (f *fakeTeamPermissions) GetPermissions(ctx context.Context, user *user.SignedInUser, resourceID string) ([]ac.ResourcePermission, error) {
+	return nil, nil
+}
+
+func (f *fakeTeamPermissions) SetUserPermission(ctx context.Context, orgID int64, user ac.User, resourceID, permission string) (*ac.ResourcePermission, error) {
+	teamID, _ := strconv.ParseInt(resourceID, 10, 64)
+	if f.d.members[teamID] == nil {
+		f.d.members[teamID] = map[int64]bool{}
+	}
+	if permission == "" {
+		delete(f.d.members[teamID], user.ID)
+	} else {
+		f.d.members[teamID][user.ID] = true
+	}
+	return &ac.ResourcePermission{}, nil
+}
+
+type externalIDKey struct {
+	orgID        int64
+	resourceType string
+	resourceID   int64
+}
+
+type fakeStore struct {
+	ids map[externalIDKey]string
+}
+
+func (f *fakeStore) GetExternalIDs(ctx context.Context, orgID int64, resourceType string) (map[int64]string, error) {
+	ids := map[int64]string{}
+	for key, id := range f.ids {
+		if key.orgID == orgID && key.resourceType == resourceType {
+			ids[key.resourceID] = id
+		}
+	}
+	return ids, nil
+}
+
+func (f *fakeStore) SetExternalID(ctx context.Context, orgID int64, resourceType string, resourceID int64, id string) error {
+	key := externalIDKey{orgID: orgID, resourceType: resourceType, resourceID: resourceID}
+	if id == "" {
+		delete(f.ids, key)
+	} else {
+		f.ids[key] = id
+	}
+	return nil
+}
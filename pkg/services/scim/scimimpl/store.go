@@ -0,0 +1,85 @@
+package scimimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+)
+
+// externalID is the identifier of a user or a team in the provisioning client
+type externalID struct {
+	ID           int64     `xorm:"pk autoincr 'id'"`
+	OrgID        int64     `xorm:"org_id"`
+	ResourceType string    `xorm:"resource_type"`
+	ResourceID   int64     `xorm:"resource_id"`
+	ExternalID   string    `xorm:"external_id"`
+	Created      time.Time `xorm:"created"`
+	Updated      time.Time `xorm:"updated"`
+}
+
+func (e externalID) TableName() string {
+	return "scim_external_id"
+}
+
+type store interface {
+	// GetExternalIDs returns the external IDs of the resources of a type, by resource ID
+	GetExternalIDs(ctx context.Context, orgID int64, resourceType string) (map[int64]string, error)
+	// SetExternalID creates or replaces the external ID of a resource, an empty external ID
+	// deletes it
+	SetExternalID(ctx context.Context, orgID int64, resourceType string, resourceID int64, id string) error
+}
+
+type sqlStore struct {
+	db db.DB
+}
+
+func (s *sqlStore) GetExternalIDs(ctx context.Context, orgID int64, resourceType string) (map[int64]string, error) {
+	rows := make([]*externalID, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("org_id = ? AND resource_type = ?", orgID, resourceType).Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[int64]string, len(rows))
+	for _, row := range rows {
+		ids[row.ResourceID] = row.ExternalID
+	}
+	return ids, nil
+}
+
+func (s *sqlStore) SetExternalID(ctx context.Context, orgID int64, resourceType string, resourceID int64, id string) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		existing := externalID{}
+		has, err := sess.Where("org_id = ? AND resource_type = ? AND resource_id = ?", orgID, resourceType, resourceID).Get(&existing)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case id == "":
+			if has {
+				_, err = sess.ID(existing.ID).Delete(&externalID{})
+			}
+		case has:
+			if existing.ExternalID != id {
+				existing.ExternalID = id
+				existing.Updated = time.Now()
+				_, err = sess.ID(existing.ID).Cols("external_id", "updated").Update(&existing)
+			}
+		default:
+			now := time.Now()
+			_, err = sess.Insert(&externalID{
+				OrgID:        orgID,
+				ResourceType: resourceType,
+				ResourceID:   resourceID,
+				ExternalID:   id,
+				Created:      now,
+				Updated:      now,
+			})
+		}
+		return err
+	})
+}
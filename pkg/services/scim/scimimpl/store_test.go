@@ -0,0 +1,39 @@
+package scimimpl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/scim"
+)
+
+func TestIntegrationSCIMStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	ss := &sqlStore{db: db.InitTestDB(t)}
+
+	require.NoError(t, ss.SetExternalID(ctx, 1, scim.ResourceTypeUser, 10, "a"))
+	require.NoError(t, ss.SetExternalID(ctx, 1, scim.ResourceTypeUser, 11, "b"))
+	require.NoError(t, ss.SetExternalID(ctx, 1, scim.ResourceTypeUser, 10, "c"))
+	require.NoError(t, ss.SetExternalID(ctx, 1, scim.ResourceTypeGroup, 10, "d"))
+	require.NoError(t, ss.SetExternalID(ctx, 2, scim.ResourceTypeUser, 10, "e"))
+
+	ids, err := ss.GetExternalIDs(ctx, 1, scim.ResourceTypeUser)
+	require.NoError(t, err)
+	require.Equal(t, map[int64]string{10: "c", 11: "b"}, ids)
+
+	require.NoError(t, ss.SetExternalID(ctx, 1, scim.ResourceTypeUser, 11, ""))
+	ids, err = ss.GetExternalIDs(ctx, 1, scim.ResourceTypeUser)
+	require.NoError(t, err)
+	require.Equal(t, map[int64]string{10: "c"}, ids)
+
+	ids, err = ss.GetExternalIDs(ctx, 1, scim.ResourceTypeGroup)
+	require.NoError(t, err)
+	require.Equal(t, map[int64]string{10: "d"}, ids)
+}
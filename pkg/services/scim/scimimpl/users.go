@@ -0,0 +1,349 @@
+package scimimpl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/scim"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var userAttributes = resourceAttributes(userSchema)
+
+func (s *Service) ListUsers(ctx context.Context, query *scim.ListQuery) (*scim.ListResponse, error) {
+	users, err := s.listUsers(ctx, query.OrgID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return list(query, users)
+}
+
+func (s *Service) GetUser(ctx context.Context, orgID int64, id string) (*scim.User, error) {
+	userID, err := parseID(scim.ResourceTypeUser, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.getUser(ctx, orgID, userID)
+}
+
+func (s *Service) CreateUser(ctx context.Context, orgID int64, u *scim.User) (*scim.User, error) {
+	if u.UserName == "" {
+		return nil, scim.ErrInvalidValue("userName is required")
+	}
+	if u.Password != "" {
+		return nil, errPasswordNotSupported()
+	}
+	email := primaryEmail(u)
+	if err := s.checkUserConflict(ctx, 0, u.UserName, email); err != nil {
+		return nil, err
+	}
+
+	// Users authenticate with the identity provider, they get a random password
+	password, err := util.GetRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.userService.Create(ctx, &user.CreateUserCommand{
+		Login:        u.UserName,
+		Email:        email,
+		Name:         userName(u, ""),
+		Password:     password,
+		IsDisabled:   u.Active != nil && !*u.Active,
+		OrgID:        orgID,
+		SkipOrgSetup: true,
+	})
+	if err != nil {
+		if errors.Is(err, user.ErrUserAlreadyExists) {
+			return nil, scim.NewError(http.StatusConflict, scim.ErrorTypeUniqueness, "user %s already exists", u.UserName)
+		}
+		return nil, err
+	}
+
+	if err := s.orgService.AddOrgUser(ctx, &org.AddOrgUserCommand{OrgID: orgID, UserID: created.ID, Role: s.defaultOrgRole}); err != nil {
+		return nil, err
+	}
+	if err := s.store.SetExternalID(ctx, orgID, scim.ResourceTypeUser, created.ID, u.ExternalID); err != nil {
+		return nil, err
+	}
+	s.log.Info("Provisioned user", "orgID", orgID, "userID", created.ID, "login", created.Login)
+
+	return s.getUser(ctx, orgID, created.ID)
+}
+
+func (s *Service) ReplaceUser(ctx context.Context, signedInUser *user.SignedInUser, orgID int64, id string, u *scim.User) (*scim.User, error) {
+	current, err := s.GetUser(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateUser(ctx, signedInUser, orgID, current, u)
+}
+
+func (s *Service) PatchUser(ctx context.Context, signedInUser *user.SignedInUser, orgID int64, id string, patch *scim.PatchRequest) (*scim.User, error) {
+	current, err := s.GetUser(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := toMap(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPatch(resource, userAttributes, patch.Operations); err != nil {
+		return nil, err
+	}
+	patched := &scim.User{}
+	if err := fromMap(resource, patched); err != nil {
+		return nil, err
+	}
+	return s.updateUser(ctx, signedInUser, orgID, current, patched)
+}
+
+func (s *Service) DeleteUser(ctx context.Context, orgID int64, id string) error {
+	current, err := s.GetUser(ctx, orgID, id)
+	if err != nil {
+		return err
+	}
+	userID, _ := strconv.ParseInt(current.ID, 10, 64)
+
+	// the users that the client doesn't manage are only removed from the organization
+	managed := true
+	if err := s.checkManaged(ctx, orgID, userID); err != nil {
+		var scimErr *scim.Error
+		if !errors.As(err, &scimErr) {
+			return err
+		}
+		managed = false
+	}
+
+	if err := s.orgService.RemoveOrgUser(ctx, &org.RemoveOrgUserCommand{OrgID: orgID, UserID: userID}); err != nil {
+		return err
+	}
+
+	if managed {
+		if err := s.deactivate(ctx, userID); err != nil {
+			return err
+		}
+	}
+	s.log.Info("Deprovisioned user", "orgID", orgID, "userID", userID, "deactivated", managed)
+	return nil
+}
+
+// updateUser replaces the attributes of a user, attributes that Grafana users don't
+// have are ignored
+func (s *Service) updateUser(ctx context.Context, signedInUser *user.SignedInUser, orgID int64, current, u *scim.User) (*scim.User, error) {
+	userID, _ := strconv.ParseInt(current.ID, 10, 64)
+	if u.ID != "" && u.ID != current.ID {
+		return nil, scim.NewError(http.StatusBadRequest, scim.ErrorTypeMutability, "id can't be changed")
+	}
+	if u.UserName == "" {
+		return nil, scim.ErrInvalidValue("userName is required")
+	}
+	if u.Password != "" {
+		return nil, errPasswordNotSupported()
+	}
+	if err := s.checkManaged(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
+
+	cmd := &user.UpdateUserCommand{
+		UserID: userID,
+		Login:  u.UserName,
+		Name:   userName(u, current.DisplayName),
+		Email:  primaryEmail(u),
+	}
+	if cmd.Email == "" {
+		cmd.Email = primaryEmail(current)
+	}
+	// Users log in with their login or email, changing them is reserved to the server administrators
+	if cmd.Login != current.UserName || cmd.Email != primaryEmail(current) {
+		canWrite, err := s.accessControl.Evaluate(ctx, signedInUser, ac.EvalPermission(ac.ActionUsersWrite, ac.Scope("global.users", "id", current.ID)))
+		if err != nil {
+			return nil, err
+		}
+		if !canWrite {
+			return nil, scim.NewError(http.StatusForbidden, "", "changing the login or the email of user %d requires server administrator permissions", userID)
+		}
+	}
+	if err := s.checkUserConflict(ctx, userID, cmd.Login, cmd.Email); err != nil {
+		return nil, err
+	}
+	if err := s.userService.Update(ctx, cmd); err != nil {
+		return nil, err
+	}
+
+	if u.Active != nil && *u.Active != *current.Active {
+		if *u.Active {
+			err := s.userService.Disable(ctx, &user.DisableUserCommand{UserID: userID, IsDisabled: false})
+			if err != nil {
+				return nil, err
+			}
+		} else if err := s.deactivate(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.store.SetExternalID(ctx, orgID, scim.ResourceTypeUser, userID, u.ExternalID); err != nil {
+		return nil, err
+	}
+
+	return s.getUser(ctx, orgID, userID)
+}
+
+// errPasswordNotSupported is returned for the password attribute, users authenticate with
+// the identity provider and their password can't be set by the provisioning client
+func errPasswordNotSupported() *scim.Error {
+	return scim.NewError(http.StatusBadRequest, scim.ErrorTypeMutability, "password can't be set, users log in with the identity provider")
+}
+
+// deactivate disables a user and revokes their sessions
+func (s *Service) deactivate(ctx context.Context, userID int64) error {
+	if err := s.userService.Disable(ctx, &user.DisableUserCommand{UserID: userID, IsDisabled: true}); err != nil {
+		return err
+	}
+	return s.userTokenService.RevokeAllUserTokens(ctx, userID)
+}
+
+// checkManaged checks that the user can be updated by the provisioning client of the
+// organization. Server administrators and members of other organizations can only be
+// removed from the organization.
+func (s *Service) checkManaged(ctx context.Context, orgID, userID int64) error {
+	usr, err := s.userService.GetByID(ctx, &user.GetUserByIDQuery{ID: userID})
+	if err != nil {
+		return err
+	}
+	if usr.IsAdmin {
+		return scim.NewError(http.StatusForbidden, "", "user %d is a server administrator", userID)
+	}
+
+	orgs, err := s.orgService.GetUserOrgList(ctx, &org.GetUserOrgListQuery{UserID: userID})
+	if err != nil {
+		return err
+	}
+	for _, o := range orgs {
+		if o.OrgID != orgID {
+			return scim.NewError(http.StatusForbidden, "", "user %d is a member of other organizations", userID)
+		}
+	}
+	return nil
+}
+
+// checkUserConflict checks that the login and the email aren't used by another user
+func (s *Service) checkUserConflict(ctx context.Context, userID int64, login, email string) error {
+	existing, err := s.userService.GetByLogin(ctx, &user.GetUserByLoginQuery{LoginOrEmail: login})
+	if err != nil && !errors.Is(err, user.ErrUserNotFound) {
+		return err
+	}
+	if err == nil && existing.ID != userID {
+		return scim.NewError(http.StatusConflict, scim.ErrorTypeUniqueness, "userName %s is already used", login)
+	}
+
+	if email == "" {
+		return nil
+	}
+	existing, err = s.userService.GetByEmail(ctx, &user.GetUserByEmailQuery{Email: email})
+	if err != nil && !errors.Is(err, user.ErrUserNotFound) {
+		return err
+	}
+	if err == nil && existing.ID != userID {
+		return scim.NewError(http.StatusConflict, scim.ErrorTypeUniqueness, "email %s is already used", email)
+	}
+	return nil
+}
+
+func (s *Service) getUser(ctx context.Context, orgID, userID int64) (*scim.User, error) {
+	users, err := s.listUsers(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, scim.ErrNotFound(scim.ResourceTypeUser, strconv.FormatInt(userID, 10))
+	}
+	return users[0], nil
+}
+
+// listUsers returns the users of the organization, or the user with the ID when userID is set
+func (s *Service) listUsers(ctx context.Context, orgID, userID int64) ([]*scim.User, error) {
+	orgUsers, err := s.orgService.GetOrgUsers(ctx, &org.GetOrgUsersQuery{
+		OrgID:  orgID,
+		UserID: userID,
+		User:   orgReader(orgID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	externalIDs, err := s.store.GetExternalIDs(ctx, orgID, scim.ResourceTypeUser)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*scim.User, 0, len(orgUsers))
+	for _, u := range orgUsers {
+		users = append(users, s.toUser(u, externalIDs[u.UserID]))
+	}
+	return users, nil
+}
+
+func (s *Service) toUser(u *org.OrgUserDTO, externalID string) *scim.User {
+	id := strconv.FormatInt(u.UserID, 10)
+	active := !u.IsDisabled
+	resource := &scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          id,
+		ExternalID:  externalID,
+		UserName:    u.Login,
+		DisplayName: u.Name,
+		Active:      &active,
+		Meta:        s.meta(scim.ResourceTypeUser, "Users", id, u.Created, u.Updated),
+	}
+	if u.Name != "" {
+		resource.Name = &scim.Name{Formatted: u.Name}
+	}
+	if u.Email != "" {
+		resource.Emails = []scim.Email{{Value: u.Email, Type: "work", Primary: true}}
+	}
+	return resource
+}
+
+// userName returns the name of a Grafana user from the displayName, the formatted name or
+// the given and family names. Clients update any of them, so the first one which isn't
+// the current name is used.
+func userName(u *scim.User, current string) string {
+	candidates := []string{u.DisplayName}
+	if u.Name != nil {
+		candidates = append(candidates, u.Name.Formatted, strings.TrimSpace(u.Name.GivenName+" "+u.Name.FamilyName))
+	}
+
+	name := ""
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+		if c != current {
+			return c
+		}
+		name = c
+	}
+	return name
+}
+
+// primaryEmail returns the primary email, or the first email when none is primary
+func primaryEmail(u *scim.User) string {
+	for _, e := range u.Emails {
+		if e.Primary && e.Value != "" {
+			return e.Value
+		}
+	}
+	for _, e := range u.Emails {
+		if e.Value != "" {
+			return e.Value
+		}
+	}
+	return ""
+}
@@ -221,7 +221,8 @@ func getEntityReferences(resp *backend.DataResponse) ([]entityReferences, error)
 			return nil, errors.New("invalid value in uid field")
 		}
 
-		if entityKind(kind) != entityKindDashboard {
+		// dashboards and alert rules reference data sources
+		if entityKind(kind) != entityKindDashboard && entityKind(kind) != entityKindAlertRule {
 			out = append(out, entityReferences{
 				entityKind: entityKind(kind),
 				uid:        uid,
@@ -252,7 +253,7 @@ func getEntityReferences(resp *backend.DataResponse) ([]entityReferences, error)
 			}
 		}
 
-		out = append(out, entityReferences{entityKind: entityKindDashboard, uid: uid, dsUids: uids})
+		out = append(out, entityReferences{entityKind: entityKind(kind), uid: uid, dsUids: uids})
 	}
 
 	return out, nil
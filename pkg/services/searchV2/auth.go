@@ -7,7 +7,9 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/sqlstore/permissions"
 	"github.com/grafana/grafana/pkg/services/user"
 )
@@ -36,6 +38,8 @@ type dashIdQueryResult struct {
 func (a *simpleAuthService) GetDashboardReadFilter(ctx context.Context, orgID int64, user *user.SignedInUser) (ResourceFilter, error) {
 	if !a.ac.IsDisabled() {
 		canReadDashboard, canReadFolder := accesscontrol.Checker(user, dashboards.ActionDashboardsRead), accesscontrol.Checker(user, dashboards.ActionFoldersRead)
+		canReadAlertRule := accesscontrol.Checker(user, accesscontrol.ActionAlertingRuleRead)
+		canReadDatasource := accesscontrol.Checker(user, datasources.ActionRead)
 		return func(kind entityKind, uid, parent string) bool {
 			if kind == entityKindFolder {
 				scopes, err := dashboards.GetInheritedScopes(ctx, orgID, uid, a.folderService)
@@ -52,6 +56,22 @@ func (a *simpleAuthService) GetDashboardReadFilter(ctx context.Context, orgID in
 				scopes = append(scopes, dashboards.ScopeDashboardsProvider.GetResourceScopeUID(uid))
 				scopes = append(scopes, dashboards.ScopeFoldersProvider.GetResourceScopeUID(parent))
 				return canReadDashboard(scopes...)
+			} else if kind == entityKindAlertRule || kind == entityKindLibraryPanel {
+				// library panels in the General folder are readable by everyone
+				if kind == entityKindLibraryPanel && parent == folder.GeneralFolderUID {
+					return true
+				}
+				scopes, err := dashboards.GetInheritedScopes(ctx, orgID, parent, a.folderService)
+				if err != nil {
+					a.logger.Debug("could not retrieve inherited folder scopes:", "err", err)
+				}
+				scopes = append(scopes, dashboards.ScopeFoldersProvider.GetResourceScopeUID(parent))
+				if kind == entityKindAlertRule {
+					return canReadAlertRule(scopes...)
+				}
+				return canReadFolder(scopes...)
+			} else if kind == entityKindDatasource {
+				return canReadDatasource(datasources.ScopeProvider.GetResourceScopeUID(uid))
 			}
 			return false
 		}, nil
@@ -90,7 +110,14 @@ func (a *simpleAuthService) GetDashboardReadFilter(ctx context.Context, orgID in
 		uids[rows[i].UID] = true
 	}
 
-	return func(_ entityKind, uid, _ string) bool {
+	return func(kind entityKind, uid, parent string) bool {
+		switch kind {
+		case entityKindAlertRule, entityKindLibraryPanel:
+			// readable when the folder is
+			return parent == folder.GeneralFolderUID || uids[parent]
+		case entityKindDatasource:
+			return user.HasRole(org.RoleAdmin)
+		}
 		return uids[uid]
 	}, err
 }
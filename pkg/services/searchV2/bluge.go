@@ -33,12 +33,14 @@ const (
 	documentFieldTransformer = "transformer"
 	documentFieldDSUID       = "ds_uid"
 	documentFieldDSType      = "ds_type"
+	documentFieldQuery       = "query" // query text of alert rules
 	DocumentFieldCreatedAt   = "created_at"
 	DocumentFieldUpdatedAt   = "updated_at"
 )
 
-func initOrgIndex(dashboards []dashboard, logger log.Logger, extendDoc ExtendDashboardFunc) (*orgIndex, error) {
-	dashboardWriter, err := bluge.OpenWriter(bluge.InMemoryOnlyConfig())
+// initOrgIndex builds the index of an organization. The index is stored in dir, or kept in memory when dir is empty.
+func initOrgIndex(dashboards []dashboard, entities orgEntities, logger log.Logger, extendDoc ExtendDashboardFunc, dir string) (*orgIndex, error) {
+	dashboardWriter, err := bluge.OpenWriter(indexConfig(dir))
 	if err != nil {
 		return nil, fmt.Errorf("error opening writer: %v", err)
 	}
@@ -120,6 +122,26 @@ func initOrgIndex(dashboards []dashboard, logger log.Logger, extendDoc ExtendDas
 		}
 	}
 
+	// Then the entities stored outside dashboards.
+	for _, rule := range entities.alertRules {
+		batch.Insert(getAlertRuleDoc(rule))
+		if err := flushIfRequired(false); err != nil {
+			return nil, err
+		}
+	}
+	for _, panel := range entities.libraryPanels {
+		batch.Insert(getLibraryPanelDoc(panel, folderIdLookup[panel.folderID]))
+		if err := flushIfRequired(false); err != nil {
+			return nil, err
+		}
+	}
+	for _, ds := range entities.dataSources {
+		batch.Insert(getDataSourceDoc(ds))
+		if err := flushIfRequired(false); err != nil {
+			return nil, err
+		}
+	}
+
 	// Flush docs in batch with force as we are in the end.
 	if err := flushIfRequired(true); err != nil {
 		return nil, err
@@ -131,6 +153,7 @@ func initOrgIndex(dashboards []dashboard, logger log.Logger, extendDoc ExtendDas
 		writers: map[indexType]*bluge.Writer{
 			indexTypeDashboard: dashboardWriter,
 		},
+		dir: dir,
 	}, err
 }
 
@@ -238,6 +261,72 @@ func getDashboardPanelDocs(dash dashboard, location string) []*bluge.Document {
 	return docs
 }
 
+// entityDocID returns the document ID of entities stored outside dashboards. They are
+// prefixed with the entity kind since the UIDs of different kinds may collide.
+func entityDocID(kind entityKind, uid string) string {
+	return string(kind) + "/" + uid
+}
+
+// entityUID returns the UID of the entity of a document.
+func entityUID(kind entityKind, docID string) string {
+	switch kind {
+	case entityKindAlertRule, entityKindLibraryPanel, entityKindDatasource:
+		return strings.TrimPrefix(docID, string(kind)+"/")
+	}
+	return docID
+}
+
+func getAlertRuleDoc(rule alertRule) *bluge.Document {
+	url := fmt.Sprintf("/alerting/grafana/%s/view", rule.uid)
+	doc := newSearchDocument(entityDocID(entityKindAlertRule, rule.uid), rule.title, "", url).
+		AddField(bluge.NewKeywordField(documentFieldKind, string(entityKindAlertRule)).Aggregatable().StoreValue()).
+		AddField(bluge.NewKeywordField(documentFieldLocation, rule.folderUID).Aggregatable().StoreValue()).
+		AddField(bluge.NewDateTimeField(DocumentFieldUpdatedAt, rule.updated).Sortable().StoreValue())
+
+	// alert rules use key=value labels as tags
+	for k, v := range rule.labels {
+		doc.AddField(bluge.NewKeywordField(documentFieldTag, k+"="+v).
+			StoreValue().
+			Aggregatable().
+			SearchTermPositions())
+	}
+
+	for _, dsUID := range rule.dsUIDs {
+		doc.AddField(bluge.NewKeywordField(documentFieldDSUID, dsUID).
+			StoreValue().
+			Aggregatable().
+			SearchTermPositions())
+	}
+
+	for _, query := range rule.queries {
+		doc.AddField(bluge.NewTextField(documentFieldQuery, query))
+	}
+
+	return doc
+}
+
+func getLibraryPanelDoc(panel libraryPanel, location string) *bluge.Document {
+	doc := newSearchDocument(entityDocID(entityKindLibraryPanel, panel.uid), panel.name, panel.description, "/library-panels").
+		AddField(bluge.NewKeywordField(documentFieldKind, string(entityKindLibraryPanel)).Aggregatable().StoreValue()).
+		AddField(bluge.NewKeywordField(documentFieldLocation, location).Aggregatable().StoreValue()).
+		AddField(bluge.NewDateTimeField(DocumentFieldCreatedAt, panel.created).Sortable().StoreValue()).
+		AddField(bluge.NewDateTimeField(DocumentFieldUpdatedAt, panel.updated).Sortable().StoreValue())
+
+	if panel.panelType != "" {
+		doc.AddField(bluge.NewKeywordField(documentFieldPanelType, panel.panelType).Aggregatable().StoreValue())
+	}
+	return doc
+}
+
+func getDataSourceDoc(ds dataSource) *bluge.Document {
+	url := fmt.Sprintf("/datasources/edit/%s", ds.uid)
+	return newSearchDocument(entityDocID(entityKindDatasource, ds.uid), ds.name, "", url).
+		AddField(bluge.NewKeywordField(documentFieldKind, string(entityKindDatasource)).Aggregatable().StoreValue()).
+		AddField(bluge.NewKeywordField(documentFieldDSType, ds.dsType).Aggregatable().StoreValue()).
+		AddField(bluge.NewDateTimeField(DocumentFieldCreatedAt, ds.created).Sortable().StoreValue()).
+		AddField(bluge.NewDateTimeField(DocumentFieldUpdatedAt, ds.updated).Sortable().StoreValue())
+}
+
 // Names need to be indexed a few ways to support key features
 func newSearchDocument(uid string, name string, descr string, url string) *bluge.Document {
 	doc := bluge.NewDocument(uid)
@@ -460,6 +549,12 @@ func doSearchQuery(
 				SetAnalyzer(ngramQueryAnalyzer).SetBoost(1))
 		}
 
+		// Query text of alert rules
+		bq.AddShould(bluge.NewMatchQuery(q.Query).
+			SetField(documentFieldQuery).
+			SetOperator(bluge.MatchQueryOperatorAnd).
+			SetBoost(1))
+
 		fullQuery.AddMust(bq)
 	}
 
@@ -581,7 +676,7 @@ func doSearchQuery(
 		}
 
 		fKind.Append(kind)
-		fUID.Append(uid)
+		fUID.Append(entityUID(entityKind(kind), uid))
 		fPType.Append(ptype)
 		fName.Append(name)
 		fURL.Append(url)
@@ -0,0 +1,295 @@
+package searchV2
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blugelabs/bluge"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/libraryelements/model"
+	"github.com/grafana/grafana/pkg/services/store"
+)
+
+// Same as expr.DatasourceUID, avoids depending on the expressions package.
+const expressionDatasourceUID = "__expr__"
+
+// Query model fields holding the query text of alert rule queries.
+var alertQueryTextFields = []string{"expr", "query", "rawSql", "target", "expression"}
+
+// entityLoader loads the entities indexed along with dashboards, all the entities of the organization
+// when uid is empty. They are updated from entity events and on full re-indexing.
+type entityLoader interface {
+	LoadAlertRules(ctx context.Context, orgID int64, uid string) ([]alertRule, error)
+	LoadLibraryPanels(ctx context.Context, orgID int64, uid string) ([]libraryPanel, error)
+	LoadDataSources(ctx context.Context, orgID int64, uid string) ([]dataSource, error)
+}
+
+// orgEntities are the entities of an organization indexed along with dashboards.
+type orgEntities struct {
+	alertRules    []alertRule
+	libraryPanels []libraryPanel
+	dataSources   []dataSource
+}
+
+type alertRule struct {
+	uid       string
+	title     string
+	folderUID string
+	labels    map[string]string
+	dsUIDs    []string
+	queries   []string
+	updated   time.Time
+}
+
+type libraryPanel struct {
+	uid         string
+	name        string
+	description string
+	folderID    int64
+	panelType   string
+	created     time.Time
+	updated     time.Time
+}
+
+type dataSource struct {
+	uid     string
+	name    string
+	dsType  string
+	created time.Time
+	updated time.Time
+}
+
+func (i *searchIndex) loadEntities(ctx context.Context, orgID int64) (orgEntities, error) {
+	var entities orgEntities
+	var err error
+	if entities.alertRules, err = i.entityLoader.LoadAlertRules(ctx, orgID, ""); err != nil {
+		return entities, err
+	}
+	if entities.libraryPanels, err = i.entityLoader.LoadLibraryPanels(ctx, orgID, ""); err != nil {
+		return entities, err
+	}
+	if entities.dataSources, err = i.entityLoader.LoadDataSources(ctx, orgID, ""); err != nil {
+		return entities, err
+	}
+	return entities, nil
+}
+
+// applyEntityEvent updates the document of an entity stored outside dashboards, or removes it when
+// the entity no longer exists.
+func (i *searchIndex) applyEntityEvent(ctx context.Context, orgID int64, kind store.EntityType, uid string) error {
+	var docKind entityKind
+	var doc *bluge.Document
+	switch kind {
+	case store.EntityTypeAlertRule:
+		docKind = entityKindAlertRule
+		rules, err := i.entityLoader.LoadAlertRules(ctx, orgID, uid)
+		if err != nil {
+			return err
+		}
+		if len(rules) > 0 {
+			doc = getAlertRuleDoc(rules[0])
+		}
+	case store.EntityTypeLibraryPanel:
+		docKind = entityKindLibraryPanel
+		panels, err := i.entityLoader.LoadLibraryPanels(ctx, orgID, uid)
+		if err != nil {
+			return err
+		}
+		if len(panels) > 0 {
+			folderUID := folder.GeneralFolderUID
+			if panels[0].folderID != 0 {
+				if folderUID, err = i.folderIdLookup(ctx, panels[0].folderID); err != nil {
+					return err
+				}
+			}
+			doc = getLibraryPanelDoc(panels[0], folderUID)
+		}
+	case store.EntityTypeDataSource:
+		docKind = entityKindDatasource
+		dataSources, err := i.entityLoader.LoadDataSources(ctx, orgID, uid)
+		if err != nil {
+			return err
+		}
+		if len(dataSources) > 0 {
+			doc = getDataSourceDoc(dataSources[0])
+		}
+	default:
+		return nil
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	index, ok := i.perOrgIndex[orgID]
+	if !ok {
+		// Skip event for org not yet fully indexed.
+		return nil
+	}
+	writer := index.writerForIndex(indexTypeDashboard)
+	if doc == nil {
+		return writer.Delete(bluge.NewDocument(entityDocID(docKind, uid)).ID())
+	}
+	return writer.Update(doc.ID(), doc)
+}
+
+type alertRuleQueryResult struct {
+	UID          string `xorm:"uid"`
+	Title        string
+	NamespaceUID string `xorm:"namespace_uid"`
+	Labels       string
+	Data         string
+	Updated      time.Time
+}
+
+func (l sqlDashboardLoader) LoadAlertRules(ctx context.Context, orgID int64, uid string) ([]alertRule, error) {
+	ctx, span := l.tracer.Start(ctx, "sqlDashboardLoader LoadAlertRules")
+	span.SetAttributes("orgID", orgID, attribute.Key("orgID").Int64(orgID))
+	defer span.End()
+
+	rows := make([]*alertRuleQueryResult, 0)
+	err := l.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		sess.Table("alert_rule").Where("org_id = ?", orgID)
+		if uid != "" {
+			sess.And("uid = ?", uid)
+		}
+		return sess.Cols("uid", "title", "namespace_uid", "labels", "data", "updated").Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]alertRule, 0, len(rows))
+	for _, row := range rows {
+		rule := alertRule{
+			uid:       row.UID,
+			title:     row.Title,
+			folderUID: row.NamespaceUID,
+			updated:   row.Updated,
+		}
+		if row.Labels != "" {
+			if err := json.Unmarshal([]byte(row.Labels), &rule.labels); err != nil {
+				l.logger.Warn("Error indexing alert rule labels", "error", err, "ruleUid", row.UID)
+			}
+		}
+		rule.dsUIDs, rule.queries = parseAlertRuleQueries(row.Data)
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseAlertRuleQueries returns the data sources and the query text of alert rule queries.
+func parseAlertRuleQueries(data string) ([]string, []string) {
+	var queries []struct {
+		DatasourceUID string                 `json:"datasourceUid"`
+		Model         map[string]interface{} `json:"model"`
+	}
+	if err := json.Unmarshal([]byte(data), &queries); err != nil {
+		return nil, nil
+	}
+
+	dsUIDs := make(map[string]bool, len(queries))
+	texts := make([]string, 0, len(queries))
+	for _, q := range queries {
+		if q.DatasourceUID != "" && q.DatasourceUID != expressionDatasourceUID {
+			dsUIDs[q.DatasourceUID] = true
+		}
+		for _, field := range alertQueryTextFields {
+			if text, ok := q.Model[field].(string); ok && strings.TrimSpace(text) != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+
+	uids := make([]string, 0, len(dsUIDs))
+	for uid := range dsUIDs {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	return uids, texts
+}
+
+type libraryPanelQueryResult struct {
+	UID         string `xorm:"uid"`
+	FolderID    int64  `xorm:"folder_id"`
+	Name        string
+	Description string
+	Type        string
+	Created     time.Time
+	Updated     time.Time
+}
+
+func (l sqlDashboardLoader) LoadLibraryPanels(ctx context.Context, orgID int64, uid string) ([]libraryPanel, error) {
+	ctx, span := l.tracer.Start(ctx, "sqlDashboardLoader LoadLibraryPanels")
+	span.SetAttributes("orgID", orgID, attribute.Key("orgID").Int64(orgID))
+	defer span.End()
+
+	rows := make([]*libraryPanelQueryResult, 0)
+	err := l.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		sess.Table("library_element").Where("org_id = ? AND kind = ?", orgID, int64(model.PanelElement))
+		if uid != "" {
+			sess.And("uid = ?", uid)
+		}
+		return sess.Cols("uid", "folder_id", "name", "description", "type", "created", "updated").Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	panels := make([]libraryPanel, 0, len(rows))
+	for _, row := range rows {
+		panels = append(panels, libraryPanel{
+			uid:         row.UID,
+			name:        row.Name,
+			description: row.Description,
+			folderID:    row.FolderID,
+			panelType:   row.Type,
+			created:     row.Created,
+			updated:     row.Updated,
+		})
+	}
+	return panels, nil
+}
+
+type dataSourceQueryResult struct {
+	UID     string `xorm:"uid"`
+	Name    string
+	Type    string
+	Created time.Time
+	Updated time.Time
+}
+
+func (l sqlDashboardLoader) LoadDataSources(ctx context.Context, orgID int64, uid string) ([]dataSource, error) {
+	ctx, span := l.tracer.Start(ctx, "sqlDashboardLoader LoadDataSources")
+	span.SetAttributes("orgID", orgID, attribute.Key("orgID").Int64(orgID))
+	defer span.End()
+
+	rows := make([]*dataSourceQueryResult, 0)
+	err := l.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		sess.Table("data_source").Where("org_id = ?", orgID)
+		if uid != "" {
+			sess.And("uid = ?", uid)
+		}
+		return sess.Cols("uid", "name", "type", "created", "updated").Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dataSources := make([]dataSource, 0, len(rows))
+	for _, row := range rows {
+		dataSources = append(dataSources, dataSource{
+			uid:     row.UID,
+			name:    row.Name,
+			dsType:  row.Type,
+			created: row.Created,
+			updated: row.Updated,
+		})
+	}
+	return dataSources, nil
+}
@@ -0,0 +1,116 @@
+package searchV2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/store"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func initTestOrgIndexWithEntities(t *testing.T, loader *testDashboardLoader) *orgIndex {
+	t.Helper()
+	index := newSearchIndex(loader, loader, &store.MockEntityEventsService{}, &NoopDocumentExtender{}, func(ctx context.Context, folderId int64) (string, error) { return "x", nil }, tracing.InitializeTracerForTest(), featuremgmt.WithFeatures(), setting.SearchSettings{})
+	_, err := index.buildOrgIndex(context.Background(), testOrgID)
+	require.NoError(t, err)
+	return index.perOrgIndex[testOrgID]
+}
+
+var testEntitiesLoader = &testDashboardLoader{
+	dashboards: testDashboards,
+	alertRules: []alertRule{
+		{uid: "1", title: "High CPU", folderUID: "folder-a", labels: map[string]string{"team": "ops"}, dsUIDs: []string{"prom"}, queries: []string{"rate(node_cpu_seconds_total[5m])"}},
+	},
+	libraryPanels: []libraryPanel{
+		{uid: "1", name: "CPU panel", panelType: "timeseries"},
+	},
+	dataSources: []dataSource{
+		{uid: "prom", name: "Prometheus CPU", dsType: "prometheus"},
+	},
+}
+
+func searchUIDs(t *testing.T, index *orgIndex, filter ResourceFilter, query DashboardQuery) []string {
+	t.Helper()
+	resp := doSearchQuery(context.Background(), testLogger, index, filter, query, &NoopQueryExtender{}, "")
+	require.NoError(t, resp.Error)
+	require.Len(t, resp.Frames, 1)
+	field, idx := resp.Frames[0].FieldByName("uid")
+	require.NotEqual(t, -1, idx)
+	uids := make([]string, 0, field.Len())
+	for i := 0; i < field.Len(); i++ {
+		uids = append(uids, field.At(i).(string))
+	}
+	return uids
+}
+
+func TestDashboardIndex_Entities(t *testing.T) {
+	index := initTestOrgIndexWithEntities(t, testEntitiesLoader)
+
+	t.Run("entities are searchable by kind", func(t *testing.T) {
+		for kind, uid := range map[entityKind]string{
+			entityKindAlertRule:    "1",
+			entityKindLibraryPanel: "1",
+			entityKindDatasource:   "prom",
+		} {
+			uids := searchUIDs(t, index, testAllowAllFilter, DashboardQuery{Query: "cpu", Kind: []string{string(kind)}})
+			require.Equal(t, []string{uid}, uids, kind)
+		}
+	})
+
+	t.Run("alert rules are found by query text", func(t *testing.T) {
+		uids := searchUIDs(t, index, testAllowAllFilter, DashboardQuery{Query: "node_cpu_seconds_total", Kind: []string{string(entityKindAlertRule)}})
+		require.Equal(t, []string{"1"}, uids)
+	})
+
+	t.Run("entities are filtered by permissions", func(t *testing.T) {
+		filter := func(kind entityKind, uid, parent string) bool {
+			return kind != entityKindAlertRule
+		}
+		uids := searchUIDs(t, index, filter, DashboardQuery{Query: "cpu", Kind: []string{string(entityKindAlertRule), string(entityKindDatasource)}})
+		require.Equal(t, []string{"prom"}, uids)
+	})
+}
+
+func TestDashboardIndex_EntityEvents(t *testing.T) {
+	loader := &testDashboardLoader{
+		dashboards:    testDashboards,
+		alertRules:    testEntitiesLoader.alertRules,
+		libraryPanels: testEntitiesLoader.libraryPanels,
+		dataSources:   testEntitiesLoader.dataSources,
+	}
+	index := newSearchIndex(loader, loader, &store.MockEntityEventsService{}, &NoopDocumentExtender{}, func(ctx context.Context, folderId int64) (string, error) { return "x", nil }, tracing.InitializeTracerForTest(), featuremgmt.WithFeatures(), setting.SearchSettings{})
+	_, err := index.buildOrgIndex(context.Background(), testOrgID)
+	require.NoError(t, err)
+
+	loader.alertRules = nil
+	loader.libraryPanels = append(loader.libraryPanels, libraryPanel{uid: "2", name: "Memory panel", folderID: 1})
+	loader.dataSources = []dataSource{{uid: "prom", name: "Prometheus memory", dsType: "prometheus"}}
+
+	require.NoError(t, index.applyEvent(context.Background(), testOrgID, store.EntityTypeAlertRule, "1", store.EntityEventTypeDelete))
+	require.NoError(t, index.applyEvent(context.Background(), testOrgID, store.EntityTypeLibraryPanel, "2", store.EntityEventTypeCreate))
+	require.NoError(t, index.applyEvent(context.Background(), testOrgID, store.EntityTypeDataSource, "prom", store.EntityEventTypeUpdate))
+
+	orgIdx := index.perOrgIndex[testOrgID]
+	kinds := []string{string(entityKindAlertRule), string(entityKindLibraryPanel), string(entityKindDatasource)}
+	require.Equal(t, []string{"1"}, searchUIDs(t, orgIdx, testAllowAllFilter, DashboardQuery{Query: "cpu", Kind: kinds}))
+	require.ElementsMatch(t, []string{"2", "prom"}, searchUIDs(t, orgIdx, testAllowAllFilter, DashboardQuery{Query: "memory", Kind: kinds}))
+}
+
+func TestParseAlertRuleQueries(t *testing.T) {
+	dsUIDs, queries := parseAlertRuleQueries(`[
+		{"datasourceUid": "prom", "model": {"expr": "up == 0"}},
+		{"datasourceUid": "mysql", "model": {"rawSql": "SELECT 1"}},
+		{"datasourceUid": "__expr__", "model": {"expression": "$A > 1"}},
+		{"datasourceUid": "prom", "model": {"expr": " "}}
+	]`)
+	require.Equal(t, []string{"mysql", "prom"}, dsUIDs)
+	require.Equal(t, []string{"up == 0", "SELECT 1", "$A > 1"}, queries)
+
+	dsUIDs, queries = parseAlertRuleQueries("invalid")
+	require.Empty(t, dsUIDs)
+	require.Empty(t, queries)
+}
@@ -21,19 +21,23 @@ type PermissionFilter struct {
 type entityKind string
 
 const (
-	entityKindPanel      entityKind = entity.StandardKindPanel
-	entityKindDashboard  entityKind = entity.StandardKindDashboard
-	entityKindFolder     entityKind = entity.StandardKindFolder
-	entityKindDatasource entityKind = entity.StandardKindDataSource
-	entityKindQuery      entityKind = entity.StandardKindQuery
+	entityKindPanel        entityKind = entity.StandardKindPanel
+	entityKindDashboard    entityKind = entity.StandardKindDashboard
+	entityKindFolder       entityKind = entity.StandardKindFolder
+	entityKindDatasource   entityKind = entity.StandardKindDataSource
+	entityKindQuery        entityKind = entity.StandardKindQuery
+	entityKindAlertRule    entityKind = entity.StandardKindAlertRule
+	entityKindLibraryPanel entityKind = entity.StandardKindLibraryPanel
 )
 
 func (r entityKind) IsValid() bool {
-	return r == entityKindPanel || r == entityKindDashboard || r == entityKindFolder
+	return r == entityKindPanel || r == entityKindDashboard || r == entityKindFolder ||
+		r == entityKindAlertRule || r == entityKindLibraryPanel || r == entityKindDatasource
 }
 
 func (r entityKind) supportsAuthzCheck() bool {
-	return r == entityKindPanel || r == entityKindDashboard || r == entityKindFolder
+	return r == entityKindPanel || r == entityKindDashboard || r == entityKindFolder ||
+		r == entityKindAlertRule || r == entityKindLibraryPanel || r == entityKindDatasource
 }
 
 var (
@@ -79,6 +83,10 @@ func (q *PermissionFilter) canAccess(kind entityKind, id, location string) bool
 		decision := q.filter(kind, id, location)
 		q.logAccessDecision(decision, kind, id, "resourceFilter")
 		return decision
+	case entityKindAlertRule, entityKindLibraryPanel, entityKindDatasource:
+		decision := q.filter(kind, entityUID(kind, id), location)
+		q.logAccessDecision(decision, kind, id, "resourceFilter")
+		return decision
 	case entityKindPanel:
 		matches := panelIdFieldRegex.FindStringSubmatch(id)
 		submatchCount := len(matches)
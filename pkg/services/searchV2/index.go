@@ -67,6 +67,8 @@ type buildSignal struct {
 
 type orgIndex struct {
 	writers map[indexType]*bluge.Writer
+	// dir is the directory the index is stored in, empty for in-memory indexes
+	dir string
 }
 
 type indexType string
@@ -90,6 +92,7 @@ func (i *orgIndex) readerForIndex(idxType indexType) (*bluge.Reader, func(), err
 type searchIndex struct {
 	mu                      sync.RWMutex
 	loader                  dashboardLoader
+	entityLoader            entityLoader
 	perOrgIndex             map[int64]*orgIndex
 	initializedOrgs         map[int64]bool
 	initialIndexingComplete bool
@@ -105,9 +108,10 @@ type searchIndex struct {
 	settings                setting.SearchSettings
 }
 
-func newSearchIndex(dashLoader dashboardLoader, evStore eventStore, extender DocumentExtender, folderIDs folderUIDLookup, tracer tracing.Tracer, features featuremgmt.FeatureToggles, settings setting.SearchSettings) *searchIndex {
+func newSearchIndex(dashLoader dashboardLoader, entityLoader entityLoader, evStore eventStore, extender DocumentExtender, folderIDs folderUIDLookup, tracer tracing.Tracer, features featuremgmt.FeatureToggles, settings setting.SearchSettings) *searchIndex {
 	return &searchIndex{
 		loader:          dashLoader,
+		entityLoader:    entityLoader,
 		eventStore:      evStore,
 		perOrgIndex:     map[int64]*orgIndex{},
 		initializedOrgs: map[int64]bool{},
@@ -203,12 +207,36 @@ func (i *searchIndex) run(ctx context.Context, orgIDs []int64, reIndexSignalCh c
 		lastEventID = lastEvent.Id
 	}
 
+	// Persisted indexes miss the changes made since they were last updated, events after the
+	// oldest of them are replayed before serving search requests.
+	replayFromEventID := lastEventID
+	if i.persistent() {
+		replayFromEventID = i.openPersistedIndexes(initialSetupCtx, orgIDs, lastEvent)
+	}
+
 	err = i.buildInitialIndexes(initialSetupCtx, orgIDs)
 	if err != nil {
 		initialSetupSpan.End()
 		return err
 	}
 
+	if replayFromEventID < lastEventID {
+		lastEventID = i.replayIndexUpdates(initialSetupCtx, replayFromEventID)
+		i.persistIndexState(lastEventID)
+	}
+
+	// Number of asynchronous re-indexing routines started and not finished. Persisted indexes
+	// do not record applied events meanwhile, since events are re-applied once re-indexing is done.
+	reIndexesInProgress := 0
+
+	applyUpdates := func(ctx context.Context) {
+		var applied int
+		lastEventID, applied = i.applyIndexUpdates(ctx, lastEventID)
+		if applied > 0 && reIndexesInProgress == 0 {
+			i.persistIndexState(lastEventID)
+		}
+	}
+
 	// This semaphore channel allows limiting concurrent async re-indexing routines to 1.
 	asyncReIndexSemaphore := make(chan struct{}, 1)
 
@@ -225,12 +253,12 @@ func (i *searchIndex) run(ctx context.Context, orgIDs []int64, reIndexSignalCh c
 		select {
 		case doneCh := <-i.syncCh:
 			// Executed on search read requests to make sure index is consistent.
-			lastEventID = i.applyIndexUpdates(ctx, lastEventID)
+			applyUpdates(ctx)
 			close(doneCh)
 		case <-partialUpdateTimer.C:
 			// Periodically apply updates collected in entity events table.
 			partialIndexUpdateCtx, span := i.tracer.Start(ctx, "searchV2 partial update timer")
-			lastEventID = i.applyIndexUpdates(partialIndexUpdateCtx, lastEventID)
+			applyUpdates(partialIndexUpdateCtx)
 			span.End()
 			partialUpdateTimer.Reset(partialUpdateInterval)
 		case <-reIndexSignalCh:
@@ -256,6 +284,7 @@ func (i *searchIndex) run(ctx context.Context, orgIDs []int64, reIndexSignalCh c
 			// Full re-indexing will be later re-started in `case lastIndexedEventID := <-reIndexDoneCh`
 			// branch.
 			fullReIndexTimer.Stop()
+			reIndexesInProgress++
 			go func() {
 				defer span.End()
 				// We need semaphore here since asynchronous re-indexing may be in progress already.
@@ -274,6 +303,7 @@ func (i *searchIndex) run(ctx context.Context, orgIDs []int64, reIndexSignalCh c
 			// come to an approach which does not require periodic re-indexing at all. One possible way
 			// is to use DB triggers, see https://github.com/grafana/grafana/pull/47712.
 			lastIndexedEventID := lastEventID
+			reIndexesInProgress++
 			go func() {
 				defer span.End()
 				// Do full re-index asynchronously to avoid blocking index synchronization
@@ -293,6 +323,7 @@ func (i *searchIndex) run(ctx context.Context, orgIDs []int64, reIndexSignalCh c
 			// Asynchronous re-indexing is finished. Set lastEventID to the value which
 			// was actual at the re-indexing start – so that we could re-apply all the
 			// events happened during async index build process and make sure it's consistent.
+			reIndexesInProgress--
 			if lastEventID != lastIndexedEventID {
 				i.logger.Info("Re-apply event ID to last indexed", "currentEventID", lastEventID, "lastIndexedEventID", lastIndexedEventID)
 				lastEventID = lastIndexedEventID
@@ -301,6 +332,7 @@ func (i *searchIndex) run(ctx context.Context, orgIDs []int64, reIndexSignalCh c
 			}
 			fullReIndexTimer.Reset(reIndexInterval)
 		case <-ctx.Done():
+			i.closeIndexes()
 			return ctx.Err()
 		}
 	}
@@ -308,14 +340,18 @@ func (i *searchIndex) run(ctx context.Context, orgIDs []int64, reIndexSignalCh c
 
 func (i *searchIndex) buildInitialIndexes(ctx context.Context, orgIDs []int64) error {
 	started := time.Now()
-	i.logger.Info("Start building in-memory indexes")
+	i.logger.Info("Start building indexes")
 	for _, orgID := range orgIDs {
+		if _, ok := i.getOrgIndex(orgID); ok {
+			// Opened from disk.
+			continue
+		}
 		err := i.buildInitialIndex(ctx, orgID)
 		if err != nil {
 			return fmt.Errorf("can't build initial dashboard search index for org %d: %w", orgID, err)
 		}
 	}
-	i.logger.Info("Finish building in-memory indexes", "elapsed", time.Since(started))
+	i.logger.Info("Finish building indexes", "elapsed", time.Since(started))
 	return nil
 }
 
@@ -478,6 +514,19 @@ func (i *searchIndex) buildOrgIndex(ctx context.Context, orgID int64) (int, erro
 		cancel()
 	}()
 
+	// Persisted indexes record the last event before loading dashboards, events after it
+	// may not be reflected in the loaded dashboards and are re-applied on restart.
+	var lastEventID int64
+	if i.persistent() {
+		lastEvent, err := i.eventStore.GetLastEvent(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("error getting last event: %w", err)
+		}
+		if lastEvent != nil {
+			lastEventID = lastEvent.Id
+		}
+	}
+
 	i.logger.Info("Start building org index", "orgId", orgID)
 	dashboards, err := i.loader.LoadDashboards(ctx, orgID, "")
 	orgSearchIndexLoadTime := time.Since(started)
@@ -485,6 +534,11 @@ func (i *searchIndex) buildOrgIndex(ctx context.Context, orgID int64) (int, erro
 	if err != nil {
 		return 0, fmt.Errorf("error loading dashboards: %w, elapsed: %s", err, orgSearchIndexLoadTime.String())
 	}
+	entities, err := i.loadEntities(ctx, orgID)
+	orgSearchIndexLoadTime = time.Since(started)
+	if err != nil {
+		return 0, fmt.Errorf("error loading entities: %w, elapsed: %s", err, orgSearchIndexLoadTime.String())
+	}
 	i.logger.Info("Finish loading org dashboards", "elapsed", orgSearchIndexLoadTime, "orgId", orgID)
 
 	dashboardExtender := i.extender.GetDashboardExtender(orgID)
@@ -493,11 +547,19 @@ func (i *searchIndex) buildOrgIndex(ctx context.Context, orgID int64) (int, erro
 	initOrgIndexSpan.SetAttributes("org_id", orgID, attribute.Key("org_id").Int64(orgID))
 	initOrgIndexSpan.SetAttributes("dashboardCount", len(dashboards), attribute.Key("dashboardCount").Int(len(dashboards)))
 
-	index, err := initOrgIndex(dashboards, i.logger, dashboardExtender)
+	dir, err := i.newIndexDir(orgID)
+	if err != nil {
+		initOrgIndexSpan.End()
+		return 0, err
+	}
+	index, err := initOrgIndex(dashboards, entities, i.logger, dashboardExtender, dir)
 
 	initOrgIndexSpan.End()
 
 	if err != nil {
+		if dir != "" {
+			_ = os.RemoveAll(dir)
+		}
 		return 0, fmt.Errorf("error initializing index: %w", err)
 	}
 	orgSearchIndexTotalTime := time.Since(started)
@@ -519,6 +581,12 @@ func (i *searchIndex) buildOrgIndex(ctx context.Context, orgID int64) (int, erro
 	i.perOrgIndex[orgID] = index
 	i.mu.Unlock()
 
+	if i.persistent() {
+		if err := i.saveOrgIndex(orgID, index, lastEventID); err != nil {
+			i.logger.Error("Failed to save search index", "orgId", orgID, "error", err)
+		}
+	}
+
 	i.initializationMutex.Lock()
 	i.initializedOrgs[orgID] = true
 	i.initializationMutex.Unlock()
@@ -594,27 +662,29 @@ func (i *searchIndex) withCtxData(ctx context.Context, params ...interface{}) []
 	return params
 }
 
-func (i *searchIndex) applyIndexUpdates(ctx context.Context, lastEventID int64) int64 {
+// applyIndexUpdates applies the events after lastEventID, and returns the last applied event
+// along with the number of applied events.
+func (i *searchIndex) applyIndexUpdates(ctx context.Context, lastEventID int64) (int64, int) {
 	ctx = log.InitCounter(ctx)
 	events, err := i.eventStore.GetAllEventsAfter(ctx, lastEventID)
 	if err != nil {
 		i.logger.Error("can't load events", "error", err)
-		return lastEventID
+		return lastEventID, 0
 	}
 	if len(events) == 0 {
-		return lastEventID
+		return lastEventID, 0
 	}
 	started := time.Now()
-	for _, e := range events {
+	for n, e := range events {
 		err := i.applyEventOnIndex(ctx, e)
 		if err != nil {
 			i.logger.Error("can't apply event", "error", err)
-			return lastEventID
+			return lastEventID, n
 		}
 		lastEventID = e.Id
 	}
 	i.logger.Info("Index updates applied", i.withCtxData(ctx, "indexEventsAppliedElapsed", time.Since(started), "numEvents", len(events))...)
-	return lastEventID
+	return lastEventID, len(events)
 }
 
 func (i *searchIndex) applyEventOnIndex(ctx context.Context, e *store.EntityEvent) error {
@@ -651,6 +721,11 @@ func (i *searchIndex) applyEvent(ctx context.Context, orgID int64, kind store.En
 	}
 	i.mu.Unlock()
 
+	switch kind {
+	case store.EntityTypeAlertRule, store.EntityTypeLibraryPanel, store.EntityTypeDataSource:
+		return i.applyEntityEvent(ctx, orgID, kind, uid)
+	}
+
 	// Both dashboard and folder share same DB table.
 	dbDashboards, err := i.loader.LoadDashboards(ctx, orgID, uid)
 	if err != nil {
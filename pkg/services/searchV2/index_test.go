@@ -21,13 +21,46 @@ import (
 )
 
 type testDashboardLoader struct {
-	dashboards []dashboard
+	dashboards    []dashboard
+	alertRules    []alertRule
+	libraryPanels []libraryPanel
+	dataSources   []dataSource
 }
 
 func (t *testDashboardLoader) LoadDashboards(_ context.Context, _ int64, _ string) ([]dashboard, error) {
 	return t.dashboards, nil
 }
 
+func (t *testDashboardLoader) LoadAlertRules(_ context.Context, _ int64, uid string) ([]alertRule, error) {
+	rules := make([]alertRule, 0, len(t.alertRules))
+	for _, rule := range t.alertRules {
+		if uid == "" || rule.uid == uid {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (t *testDashboardLoader) LoadLibraryPanels(_ context.Context, _ int64, uid string) ([]libraryPanel, error) {
+	panels := make([]libraryPanel, 0, len(t.libraryPanels))
+	for _, panel := range t.libraryPanels {
+		if uid == "" || panel.uid == uid {
+			panels = append(panels, panel)
+		}
+	}
+	return panels, nil
+}
+
+func (t *testDashboardLoader) LoadDataSources(_ context.Context, _ int64, uid string) ([]dataSource, error) {
+	dataSources := make([]dataSource, 0, len(t.dataSources))
+	for _, ds := range t.dataSources {
+		if uid == "" || ds.uid == uid {
+			dataSources = append(dataSources, ds)
+		}
+	}
+	return dataSources, nil
+}
+
 var testLogger = log.New("index-test-logger")
 
 var testAllowAllFilter = func(kind entityKind, uid, parent string) bool {
@@ -62,7 +95,7 @@ func initTestIndexFromDashesExtended(t *testing.T, dashboards []dashboard, exten
 	dashboardLoader := &testDashboardLoader{
 		dashboards: dashboards,
 	}
-	index := newSearchIndex(dashboardLoader, &store.MockEntityEventsService{}, extender, func(ctx context.Context, folderId int64) (string, error) { return "x", nil }, tracing.InitializeTracerForTest(), featuremgmt.WithFeatures(), setting.SearchSettings{})
+	index := newSearchIndex(dashboardLoader, dashboardLoader, &store.MockEntityEventsService{}, extender, func(ctx context.Context, folderId int64) (string, error) { return "x", nil }, tracing.InitializeTracerForTest(), featuremgmt.WithFeatures(), setting.SearchSettings{})
 	require.NotNil(t, index)
 	numDashboards, err := index.buildOrgIndex(context.Background(), testOrgID)
 	require.NoError(t, err)
@@ -0,0 +1,348 @@
+package searchV2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/blugelabs/bluge"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/services/store"
+)
+
+// indexSchemaVersion must be increased when the indexed documents change, persisted
+// indexes of another version are rebuilt on startup.
+const indexSchemaVersion = 2
+
+const indexMetaFile = "meta.json"
+
+// Reasons to rebuild a persisted index on startup.
+const (
+	rebuildReasonMissing = "missing"
+	rebuildReasonSchema  = "schema"
+	rebuildReasonEvents  = "events"
+	rebuildReasonError   = "error"
+)
+
+var (
+	searchIndexSizeBytes = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "index_size_bytes",
+			Help:      "The size of the search index stored on disk",
+		})
+	searchIndexRebuildsCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "index_rebuilds_total",
+			Help:      "A counter for persisted search indexes rebuilt from scratch on startup",
+		},
+		[]string{"reason"},
+	)
+	searchIndexReplayedEventsCounter = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "index_replayed_events_total",
+			Help:      "A counter for entity events replayed on persisted search indexes on startup",
+		})
+	searchIndexReplayDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "index_replay_duration_seconds",
+			Help:      "The time taken to replay entity events on persisted search indexes on startup",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+		})
+)
+
+// indexMeta describes the persisted index of an organization.
+type indexMeta struct {
+	SchemaVersion int `json:"schemaVersion"`
+	// Dir is the directory of the index, relative to the organization directory
+	Dir string `json:"dir"`
+	// LastEventID is the last entity event reflected in the index
+	LastEventID int64 `json:"lastEventId"`
+}
+
+func indexConfig(dir string) bluge.Config {
+	if dir == "" {
+		return bluge.InMemoryOnlyConfig()
+	}
+	return bluge.DefaultConfig(dir)
+}
+
+func (i *searchIndex) persistent() bool {
+	return i.settings.IndexPath != ""
+}
+
+func (i *searchIndex) orgIndexPath(orgID int64) string {
+	return filepath.Join(i.settings.IndexPath, strconv.FormatInt(orgID, 10))
+}
+
+// newIndexDir creates a directory for a new index of the organization. Indexes are kept in memory
+// when no index path is configured, and an empty string is returned.
+func (i *searchIndex) newIndexDir(orgID int64) (string, error) {
+	if !i.persistent() {
+		return "", nil
+	}
+	dir := filepath.Join(i.orgIndexPath(orgID), strconv.FormatInt(time.Now().UnixNano(), 10))
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("can't create index directory: %w", err)
+	}
+	return dir, nil
+}
+
+// openPersistedIndexes opens the indexes stored on disk, and returns the event after which events
+// must be replayed to bring them up to date. Organizations without a usable index are left to be built.
+func (i *searchIndex) openPersistedIndexes(ctx context.Context, orgIDs []int64, lastEvent *store.EntityEvent) int64 {
+	var replayFromEventID int64
+	if lastEvent != nil {
+		replayFromEventID = lastEvent.Id
+	}
+
+	for _, orgID := range orgIDs {
+		index, indexLastEventID, reason := i.openPersistedOrgIndex(ctx, orgID, lastEvent)
+		if index == nil {
+			i.logger.Info("Persisted search index can't be used, rebuilding", "orgId", orgID, "reason", reason)
+			searchIndexRebuildsCounter.WithLabelValues(reason).Inc()
+			continue
+		}
+		if err := removeStaleIndexDirs(i.orgIndexPath(orgID), index.dir); err != nil {
+			i.logger.Warn("Failed to remove stale search index", "orgId", orgID, "error", err)
+		}
+
+		i.mu.Lock()
+		i.perOrgIndex[orgID] = index
+		i.mu.Unlock()
+
+		i.initializationMutex.Lock()
+		i.initializedOrgs[orgID] = true
+		i.initializationMutex.Unlock()
+
+		i.logger.Info("Opened persisted search index", "orgId", orgID, "lastEventId", indexLastEventID)
+		if indexLastEventID < replayFromEventID {
+			replayFromEventID = indexLastEventID
+		}
+	}
+	return replayFromEventID
+}
+
+// openPersistedOrgIndex opens the index of an organization stored on disk, along with the last
+// event it reflects. When the index can't be used the reason is returned instead.
+func (i *searchIndex) openPersistedOrgIndex(ctx context.Context, orgID int64, lastEvent *store.EntityEvent) (*orgIndex, int64, string) {
+	orgPath := i.orgIndexPath(orgID)
+	meta, err := readIndexMeta(orgPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, 0, rebuildReasonMissing
+		}
+		i.logger.Warn("Failed to read search index metadata", "orgId", orgID, "error", err)
+		return nil, 0, rebuildReasonError
+	}
+	if meta.SchemaVersion != indexSchemaVersion {
+		return nil, 0, rebuildReasonSchema
+	}
+
+	canReplay, err := i.canReplayEventsAfter(ctx, meta.LastEventID, lastEvent)
+	if err != nil {
+		i.logger.Warn("Failed to check search index events", "orgId", orgID, "error", err)
+		return nil, 0, rebuildReasonError
+	}
+	if !canReplay {
+		return nil, 0, rebuildReasonEvents
+	}
+
+	dir := filepath.Join(orgPath, filepath.Base(meta.Dir))
+	if _, err := os.Stat(dir); err != nil {
+		return nil, 0, rebuildReasonMissing
+	}
+	writer, err := bluge.OpenWriter(indexConfig(dir))
+	if err != nil {
+		i.logger.Warn("Failed to open search index", "orgId", orgID, "error", err)
+		return nil, 0, rebuildReasonError
+	}
+
+	return &orgIndex{
+		writers: map[indexType]*bluge.Writer{
+			indexTypeDashboard: writer,
+		},
+		dir: dir,
+	}, meta.LastEventID, ""
+}
+
+// canReplayEventsAfter checks that all the events after lastEventID are still stored, since
+// old events are regularly deleted. Events are deleted oldest first, so all later events are
+// available as long as the last applied one is.
+func (i *searchIndex) canReplayEventsAfter(ctx context.Context, lastEventID int64, lastEvent *store.EntityEvent) (bool, error) {
+	if lastEvent == nil || lastEventID == 0 || lastEventID > lastEvent.Id {
+		return false, nil
+	}
+	if lastEventID == lastEvent.Id {
+		return true, nil
+	}
+	events, err := i.eventStore.GetAllEventsAfter(ctx, lastEventID-1)
+	if err != nil {
+		return false, err
+	}
+	return len(events) > 0 && events[0].Id == lastEventID, nil
+}
+
+// replayIndexUpdates applies the events missed by persisted indexes while Grafana was stopped.
+func (i *searchIndex) replayIndexUpdates(ctx context.Context, lastEventID int64) int64 {
+	started := time.Now()
+	lastEventID, applied := i.applyIndexUpdates(ctx, lastEventID)
+	searchIndexReplayDuration.Observe(time.Since(started).Seconds())
+	searchIndexReplayedEventsCounter.Add(float64(applied))
+	i.logger.Info("Replayed events on persisted search indexes", "numEvents", applied, "elapsed", time.Since(started))
+	return lastEventID
+}
+
+// saveOrgIndex records the index of an organization as its current persisted index, and removes
+// its previous indexes.
+func (i *searchIndex) saveOrgIndex(orgID int64, index *orgIndex, lastEventID int64) error {
+	orgPath := i.orgIndexPath(orgID)
+	if err := writeIndexMeta(orgPath, index, lastEventID); err != nil {
+		return err
+	}
+	if err := removeStaleIndexDirs(orgPath, index.dir); err != nil {
+		return err
+	}
+	i.updateIndexSizeMetric()
+	return nil
+}
+
+// persistIndexState records the last event applied to the persisted indexes. Index writers only
+// return from a batch once it is synced to disk, so the metadata never gets ahead of the index.
+func (i *searchIndex) persistIndexState(lastEventID int64) {
+	if !i.persistent() {
+		return
+	}
+
+	i.mu.RLock()
+	for orgID, index := range i.perOrgIndex {
+		if err := writeIndexMeta(i.orgIndexPath(orgID), index, lastEventID); err != nil {
+			i.logger.Error("Failed to save search index metadata", "orgId", orgID, "error", err)
+		}
+	}
+	i.mu.RUnlock()
+
+	i.updateIndexSizeMetric()
+}
+
+// closeIndexes closes the index writers of all organizations, releasing the locks of the
+// persisted indexes. Closing a writer twice is a no-op.
+func (i *searchIndex) closeIndexes() {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	for orgID, index := range i.perOrgIndex {
+		for _, w := range index.writers {
+			if err := w.Close(); err != nil {
+				i.logger.Warn("Failed to close search index", "orgId", orgID, "error", err)
+			}
+		}
+	}
+}
+
+func (i *searchIndex) updateIndexSizeMetric() {
+	size, err := dirSize(i.settings.IndexPath)
+	if err != nil {
+		i.logger.Debug("Failed to get search index size", "error", err)
+		return
+	}
+	searchIndexSizeBytes.Set(float64(size))
+}
+
+func readIndexMeta(orgPath string) (*indexMeta, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning since the path is built from the configured index path and the org ID.
+	data, err := os.ReadFile(filepath.Join(orgPath, indexMetaFile))
+	if err != nil {
+		return nil, err
+	}
+	meta := &indexMeta{}
+	if err := json.Unmarshal(data, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func writeIndexMeta(orgPath string, index *orgIndex, lastEventID int64) error {
+	data, err := json.Marshal(indexMeta{
+		SchemaVersion: indexSchemaVersion,
+		Dir:           filepath.Base(index.dir),
+		LastEventID:   lastEventID,
+	})
+	if err != nil {
+		return err
+	}
+	// Write, sync and rename, so that the metadata is never partially written
+	tmpFile := filepath.Join(orgPath, indexMetaFile+".tmp")
+	if err := writeFileSync(tmpFile, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile, filepath.Join(orgPath, indexMetaFile)); err != nil {
+		return err
+	}
+	return syncDir(orgPath)
+}
+
+func writeFileSync(name string, data []byte) error {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning since the path is built from the configured index path and the org ID.
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir makes the renames in a directory durable.
+func syncDir(dir string) error {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning since the path is built from the configured index path and the org ID.
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		_ = d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+// removeStaleIndexDirs removes the index directories of an organization other than the current one.
+func removeStaleIndexDirs(orgPath string, currentDir string) error {
+	entries, err := os.ReadDir(orgPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == filepath.Base(currentDir) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(orgPath, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
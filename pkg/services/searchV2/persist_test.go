@@ -0,0 +1,97 @@
+package searchV2
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/store"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func newTestPersistentIndex(t *testing.T, indexPath string, events *store.MockEntityEventsService) *searchIndex {
+	t.Helper()
+	loader := &testDashboardLoader{dashboards: dashboardsWithTitles("Servers", "Databases")}
+	return newSearchIndex(loader, loader, events, &NoopDocumentExtender{}, func(ctx context.Context, folderId int64) (string, error) { return "x", nil },
+		tracing.InitializeTracerForTest(), featuremgmt.WithFeatures(), setting.SearchSettings{IndexPath: indexPath})
+}
+
+func TestPersistentIndex(t *testing.T) {
+	indexPath := t.TempDir()
+
+	events := &store.MockEntityEventsService{}
+	events.On("GetLastEvent", mock.Anything).Return(&store.EntityEvent{Id: 5}, nil)
+	index := newTestPersistentIndex(t, indexPath, events)
+	_, err := index.buildOrgIndex(context.Background(), testOrgID)
+	require.NoError(t, err)
+
+	meta, err := readIndexMeta(index.orgIndexPath(testOrgID))
+	require.NoError(t, err)
+	require.Equal(t, indexSchemaVersion, meta.SchemaVersion)
+	require.Equal(t, int64(5), meta.LastEventID)
+	require.DirExists(t, filepath.Join(index.orgIndexPath(testOrgID), meta.Dir))
+
+	// Rebuilding replaces the index directory
+	_, err = index.buildOrgIndex(context.Background(), testOrgID)
+	require.NoError(t, err)
+	entries, err := os.ReadDir(index.orgIndexPath(testOrgID))
+	require.NoError(t, err)
+	require.Len(t, entries, 2) // index directory and metadata
+
+	// Close the index as on shutdown
+	index.closeIndexes()
+	_, err = os.Stat(filepath.Join(index.orgIndexPath(testOrgID), indexMetaFile+".tmp"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	t.Run("opens the persisted index and replays the events after it", func(t *testing.T) {
+		events := &store.MockEntityEventsService{}
+		events.On("GetAllEventsAfter", mock.Anything, int64(4)).Return([]*store.EntityEvent{{Id: 5}, {Id: 6}, {Id: 7}}, nil)
+		index := newTestPersistentIndex(t, indexPath, events)
+
+		replayFrom := index.openPersistedIndexes(context.Background(), []int64{testOrgID}, &store.EntityEvent{Id: 7})
+		require.Equal(t, int64(5), replayFrom)
+
+		orgIdx, ok := index.getOrgIndex(testOrgID)
+		require.True(t, ok)
+		_, found, err := getDashboardLocation(orgIdx, "2")
+		require.NoError(t, err)
+		require.True(t, found)
+		require.True(t, index.initializedOrgs[testOrgID])
+
+		index.closeIndexes()
+	})
+
+	t.Run("rebuilds the index when events were deleted", func(t *testing.T) {
+		events := &store.MockEntityEventsService{}
+		events.On("GetAllEventsAfter", mock.Anything, int64(4)).Return([]*store.EntityEvent{{Id: 6}, {Id: 7}}, nil)
+		index := newTestPersistentIndex(t, indexPath, events)
+
+		replayFrom := index.openPersistedIndexes(context.Background(), []int64{testOrgID}, &store.EntityEvent{Id: 7})
+		require.Equal(t, int64(7), replayFrom)
+		_, ok := index.getOrgIndex(testOrgID)
+		require.False(t, ok)
+	})
+
+	t.Run("rebuilds the index when the schema changed", func(t *testing.T) {
+		orgPath := index.orgIndexPath(testOrgID)
+		meta, err := readIndexMeta(orgPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(orgPath, indexMetaFile), []byte(`{"schemaVersion": 0, "dir": "`+meta.Dir+`", "lastEventId": 5}`), 0600))
+
+		index := newTestPersistentIndex(t, indexPath, &store.MockEntityEventsService{})
+		_, _, reason := index.openPersistedOrgIndex(context.Background(), testOrgID, &store.EntityEvent{Id: 5})
+		require.Equal(t, rebuildReasonSchema, reason)
+	})
+
+	t.Run("builds the index when there is none", func(t *testing.T) {
+		index := newTestPersistentIndex(t, indexPath, &store.MockEntityEventsService{})
+		_, _, reason := index.openPersistedOrgIndex(context.Background(), 2, &store.EntityEvent{Id: 5})
+		require.Equal(t, rebuildReasonMissing, reason)
+	})
+}
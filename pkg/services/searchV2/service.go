@@ -86,6 +86,7 @@ func ProvideService(cfg *setting.Cfg, sql db.DB, entityEventStore store.EntityEv
 	userService user.Service, folderService folder.Service) SearchService {
 	extender := &NoopExtender{}
 	logger := log.New("searchV2")
+	loader := newSQLDashboardLoader(sql, tracer, cfg.Search)
 	s := &StandardSearchService{
 		cfg: cfg,
 		sql: sql,
@@ -97,7 +98,8 @@ func ProvideService(cfg *setting.Cfg, sql db.DB, entityEventStore store.EntityEv
 			logger:        logger,
 		},
 		dashboardIndex: newSearchIndex(
-			newSQLDashboardLoader(sql, tracer, cfg.Search),
+			loader,
+			loader,
 			entityEventStore,
 			extender.GetDocumentExtender(),
 			newFolderIDLookup(sql),
@@ -326,6 +326,7 @@ type fakeServiceAccountService struct {
 	ExpectedServiceAccount        *serviceaccounts.ServiceAccountDTO
 	ExpectedServiceAccountProfile *serviceaccounts.ServiceAccountProfileDTO
 	ExpectedMigrationResult       *serviceaccounts.MigrationResult
+	ExpectedTokenPolicy           *serviceaccounts.TokenPolicy
 }
 
 func (f *fakeServiceAccountService) CreateServiceAccount(ctx context.Context, orgID int64, saForm *serviceaccounts.CreateServiceAccountForm) (*serviceaccounts.ServiceAccountDTO, error) {
@@ -356,6 +357,19 @@ func (f *fakeServiceAccountService) DeleteServiceAccountToken(ctx context.Contex
 	return f.ExpectedErr
 }
 
+func (f *fakeServiceAccountService) RotateServiceAccountToken(ctx context.Context, orgID, id, tokenID int64, cmd *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	return f.ExpectedAPIKey, f.ExpectedErr
+}
+
+func (f *fakeServiceAccountService) GetTokenPolicy(ctx context.Context, orgID int64) (*serviceaccounts.TokenPolicy, error) {
+	return f.ExpectedTokenPolicy, f.ExpectedErr
+}
+
+func (f *fakeServiceAccountService) SetTokenPolicy(ctx context.Context, policy *serviceaccounts.TokenPolicy) error {
+	f.ExpectedTokenPolicy = policy
+	return f.ExpectedErr
+}
+
 func (f *fakeServiceAccountService) MigrateApiKeysToServiceAccounts(ctx context.Context, orgID int64) (*serviceaccounts.MigrationResult, error) {
 	fmt.Printf("fake migration result: %v", f.ExpectedMigrationResult)
 	return f.ExpectedMigrationResult, f.ExpectedErr
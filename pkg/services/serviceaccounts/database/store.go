@@ -42,6 +42,11 @@ func ProvideServiceAccountsStore(cfg *setting.Cfg, store db.DB, apiKeyService ap
 	}
 }
 
+// InTransaction runs fn in a transaction shared by the store operations using its context.
+func (s *ServiceAccountsStoreImpl) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.sqlStore.InTransaction(ctx, fn)
+}
+
 // CreateServiceAccount creates service account
 func (s *ServiceAccountsStoreImpl) CreateServiceAccount(ctx context.Context, orgId int64, saForm *serviceaccounts.CreateServiceAccountForm) (*serviceaccounts.ServiceAccountDTO, error) {
 	generatedLogin := "sa-" + strings.ToLower(saForm.Name)
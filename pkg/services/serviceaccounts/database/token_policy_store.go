@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+)
+
+type serviceAccountTokenPolicy struct {
+	Id                int64
+	OrgId             int64
+	MaxSecondsToLive  int64
+	RequireExpiration bool
+	Created           time.Time
+	Updated           time.Time
+}
+
+// GetTokenPolicy returns the token policy of the organization, or an empty policy if none is configured
+func (s *ServiceAccountsStoreImpl) GetTokenPolicy(ctx context.Context, orgID int64) (*serviceaccounts.TokenPolicy, error) {
+	result := &serviceaccounts.TokenPolicy{OrgID: orgID}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var policy serviceAccountTokenPolicy
+		found, err := sess.Where("org_id = ?", orgID).Get(&policy)
+		if err != nil || !found {
+			return err
+		}
+		result.MaxSecondsToLive = policy.MaxSecondsToLive
+		result.RequireExpiration = policy.RequireExpiration
+		return nil
+	})
+	return result, err
+}
+
+// SetTokenPolicy stores the token policy of the organization, an empty policy removes it
+func (s *ServiceAccountsStoreImpl) SetTokenPolicy(ctx context.Context, policy *serviceaccounts.TokenPolicy) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		if policy.MaxSecondsToLive == 0 && !policy.RequireExpiration {
+			_, err := sess.Exec("DELETE FROM service_account_token_policy WHERE org_id = ?", policy.OrgID)
+			return err
+		}
+
+		now := time.Now()
+		var existing serviceAccountTokenPolicy
+		found, err := sess.Where("org_id = ?", policy.OrgID).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if found {
+			existing.MaxSecondsToLive = policy.MaxSecondsToLive
+			existing.RequireExpiration = policy.RequireExpiration
+			existing.Updated = now
+			_, err = sess.ID(existing.Id).Cols("max_seconds_to_live", "require_expiration", "updated").Update(&existing)
+			return err
+		}
+
+		_, err = sess.Insert(&serviceAccountTokenPolicy{
+			OrgId:             policy.OrgID,
+			MaxSecondsToLive:  policy.MaxSecondsToLive,
+			RequireExpiration: policy.RequireExpiration,
+			Created:           now,
+			Updated:           now,
+		})
+		return err
+	})
+}
+
+// ListExpiringTokens returns the service account tokens that are still valid but expire before the given time
+func (s *ServiceAccountsStoreImpl) ListExpiringTokens(ctx context.Context, before time.Time) ([]serviceaccounts.ExpiringToken, error) {
+	result := make([]serviceaccounts.ExpiringToken, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		quotedUser := s.sqlStore.GetDialect().Quote("user")
+		rawSQL := `SELECT
+			api_key.id AS token_id,
+			api_key.name AS token_name,
+			api_key.expires AS expires,
+			api_key.org_id AS org_id,
+			api_key.service_account_id AS service_account_id,
+			` + quotedUser + `.name AS service_account_name
+			FROM api_key
+			INNER JOIN ` + quotedUser + ` ON ` + quotedUser + `.id = api_key.service_account_id
+			WHERE api_key.service_account_id IS NOT NULL
+			AND api_key.expires IS NOT NULL
+			AND api_key.expires > ? AND api_key.expires <= ?
+			AND (api_key.is_revoked IS NULL OR api_key.is_revoked = ?)
+			ORDER BY api_key.expires ASC`
+		return sess.SQL(rawSQL, time.Now().Unix(), before.Unix(), s.sqlStore.GetDialect().BooleanStr(false)).Find(&result)
+	})
+	return result, err
+}
+
+// ExpireServiceAccountToken shortens the lifetime of a service account token so that it expires at the given time
+func (s *ServiceAccountsStoreImpl) ExpireServiceAccountToken(ctx context.Context, orgID, serviceAccountID, tokenID int64, expires time.Time) error {
+	rawSQL := "UPDATE api_key SET expires = ? WHERE id=? and org_id=? and service_account_id=?"
+
+	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		result, err := sess.Exec(rawSQL, expires.Unix(), tokenID, orgID, serviceAccountID)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if affected == 0 {
+			return serviceaccounts.ErrServiceAccountTokenNotFound.Errorf("service account token with id %d not found for service account with id %d", tokenID, serviceAccountID)
+		}
+
+		return err
+	})
+}
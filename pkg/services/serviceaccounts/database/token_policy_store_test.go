@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/apikeygen"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/tests"
+)
+
+func TestStore_TokenPolicy(t *testing.T) {
+	_, store := setupTestDatabase(t)
+	ctx := context.Background()
+
+	policy, err := store.GetTokenPolicy(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, serviceaccounts.TokenPolicy{OrgID: 1}, *policy)
+
+	require.NoError(t, store.SetTokenPolicy(ctx, &serviceaccounts.TokenPolicy{OrgID: 1, MaxSecondsToLive: 3600, RequireExpiration: true}))
+	require.NoError(t, store.SetTokenPolicy(ctx, &serviceaccounts.TokenPolicy{OrgID: 1, MaxSecondsToLive: 7200, RequireExpiration: true}))
+
+	policy, err = store.GetTokenPolicy(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7200), policy.MaxSecondsToLive)
+	assert.True(t, policy.RequireExpiration)
+
+	policy, err = store.GetTokenPolicy(ctx, 2)
+	require.NoError(t, err)
+	assert.Zero(t, policy.MaxSecondsToLive)
+
+	require.NoError(t, store.SetTokenPolicy(ctx, &serviceaccounts.TokenPolicy{OrgID: 1}))
+	policy, err = store.GetTokenPolicy(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, serviceaccounts.TokenPolicy{OrgID: 1}, *policy)
+}
+
+func TestStore_ListExpiringTokens(t *testing.T) {
+	db, store := setupTestDatabase(t)
+	sa := tests.SetupUserServiceAccount(t, db, tests.TestUser{Name: "deployer", Login: "sa-deployer", IsServiceAccount: true})
+	ctx := context.Background()
+
+	addToken := func(name string, secondsToLive int64) int64 {
+		key, err := apikeygen.New(sa.OrgID, name)
+		require.NoError(t, err)
+		token, err := store.AddServiceAccountToken(ctx, sa.ID, &serviceaccounts.AddServiceAccountTokenCommand{
+			Name:          name,
+			OrgId:         sa.OrgID,
+			Key:           key.HashedKey,
+			SecondsToLive: secondsToLive,
+		})
+		require.NoError(t, err)
+		return token.ID
+	}
+
+	soonID := addToken("soon", 3600)
+	addToken("later", 30*24*3600)
+	addToken("never", 0)
+	revokedID := addToken("revoked", 3600)
+	require.NoError(t, store.RevokeServiceAccountToken(ctx, sa.OrgID, sa.ID, revokedID))
+
+	tokens, err := store.ListExpiringTokens(ctx, time.Now().Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, soonID, tokens[0].TokenID)
+	assert.Equal(t, "soon", tokens[0].TokenName)
+	assert.Equal(t, sa.ID, tokens[0].ServiceAccountID)
+	assert.Equal(t, "deployer", tokens[0].ServiceAccountName)
+
+	t.Run("should shorten token lifetime", func(t *testing.T) {
+		expires := time.Now().Add(time.Minute)
+		require.NoError(t, store.ExpireServiceAccountToken(ctx, sa.OrgID, sa.ID, soonID, expires))
+
+		keys, err := store.ListTokens(ctx, &serviceaccounts.GetSATokensQuery{OrgID: &sa.OrgID, ServiceAccountID: &sa.ID})
+		require.NoError(t, err)
+		for _, k := range keys {
+			if k.ID == soonID {
+				require.NotNil(t, k.Expires)
+				assert.Equal(t, expires.Unix(), *k.Expires)
+			}
+		}
+
+		err = store.ExpireServiceAccountToken(ctx, sa.OrgID, sa.ID, 1000, expires)
+		require.ErrorIs(t, err, serviceaccounts.ErrServiceAccountTokenNotFound)
+	})
+}
+
+func TestStore_InTransaction(t *testing.T) {
+	db, store := setupTestDatabase(t)
+	sa := tests.SetupUserServiceAccount(t, db, tests.TestUser{Name: "deployer", Login: "sa-deployer", IsServiceAccount: true})
+	ctx := context.Background()
+
+	key, err := apikeygen.New(sa.OrgID, "rotated")
+	require.NoError(t, err)
+	err = store.InTransaction(ctx, func(ctx context.Context) error {
+		if _, err := store.AddServiceAccountToken(ctx, sa.ID, &serviceaccounts.AddServiceAccountTokenCommand{
+			Name:  "rotated",
+			OrgId: sa.OrgID,
+			Key:   key.HashedKey,
+		}); err != nil {
+			return err
+		}
+		return store.ExpireServiceAccountToken(ctx, sa.OrgID, sa.ID, 1000, time.Now())
+	})
+	require.ErrorIs(t, err, serviceaccounts.ErrServiceAccountTokenNotFound)
+
+	// the token added in the failed transaction is rolled back
+	keys, err := store.ListTokens(ctx, &serviceaccounts.GetSATokensQuery{OrgID: &sa.OrgID, ServiceAccountID: &sa.ID})
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
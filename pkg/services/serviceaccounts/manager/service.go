@@ -9,9 +9,11 @@ import (
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/api"
@@ -35,6 +37,19 @@ type ServiceAccountsService struct {
 
 	secretScanEnabled  bool
 	secretScanInterval time.Duration
+
+	serverLock               *serverlock.ServerLockService
+	kvStore                  kvstore.KVStore
+	orgService               org.Service
+	notificationService      notifications.Service
+	appURL                   string
+	tokenExpiryWarningDays   int
+	tokenExpiryWebhookURL    string
+	tokenExpiryEmail         bool
+	tokenRotationGracePeriod time.Duration
+	// global token lifetime limits, see setting.Cfg.ApiKeyMaxSecondsToLive and setting.Cfg.SATokenExpirationDayLimit
+	tokenMaxSecondsToLive   int64
+	tokenExpirationDayLimit int
 }
 
 func ProvideServiceAccountsService(
@@ -49,6 +64,8 @@ func ProvideServiceAccountsService(
 	orgService org.Service,
 	permissionService accesscontrol.ServiceAccountPermissionsService,
 	accesscontrolService accesscontrol.Service,
+	notificationService notifications.Service,
+	serverLock *serverlock.ServerLockService,
 ) (*ServiceAccountsService, error) {
 	serviceAccountsStore := database.ProvideServiceAccountsStore(
 		cfg,
@@ -59,11 +76,25 @@ func ProvideServiceAccountsService(
 		orgService,
 	)
 	s := &ServiceAccountsService{
-		store:         serviceAccountsStore,
-		log:           log.New("serviceaccounts"),
-		backgroundLog: log.New("serviceaccounts.background"),
+		store:               serviceAccountsStore,
+		log:                 log.New("serviceaccounts"),
+		backgroundLog:       log.New("serviceaccounts.background"),
+		serverLock:          serverLock,
+		kvStore:             kvStore,
+		orgService:          orgService,
+		notificationService: notificationService,
+		appURL:              cfg.AppURL,
+		// -1 disables the limit
+		tokenMaxSecondsToLive:   cfg.ApiKeyMaxSecondsToLive,
+		tokenExpirationDayLimit: cfg.SATokenExpirationDayLimit,
 	}
 
+	saSection := cfg.SectionWithEnvOverrides("service_accounts")
+	s.tokenExpiryWarningDays = saSection.Key("token_expiry_warning_days").MustInt(7)
+	s.tokenExpiryWebhookURL = saSection.Key("token_expiry_warning_webhook_url").MustString("")
+	s.tokenExpiryEmail = saSection.Key("token_expiry_warning_email").MustBool(true)
+	s.tokenRotationGracePeriod = saSection.Key("token_rotation_grace_period").MustDuration(defaultTokenRotationGracePeriod)
+
 	if err := RegisterRoles(accesscontrolService); err != nil {
 		s.log.Error("Failed to register roles", "error", err)
 	}
@@ -120,6 +151,9 @@ func (sa *ServiceAccountsService) Run(ctx context.Context) error {
 		defer tokenCheckTicker.Stop()
 	}
 
+	tokenExpiryTicker := time.NewTicker(tokenExpiryCheckInterval)
+	defer tokenExpiryTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -142,6 +176,18 @@ func (sa *ServiceAccountsService) Run(ctx context.Context) error {
 			if err := sa.secretScanService.CheckTokens(ctx); err != nil {
 				sa.backgroundLog.Warn("Failed to check for leaked tokens", "error", err.Error())
 			}
+		case <-tokenExpiryTicker.C:
+			sa.backgroundLog.Debug("checking for expiring tokens")
+
+			// Only one instance of a HA setup notifies about the expiring tokens
+			err := sa.serverLock.LockAndExecute(ctx, "service account token expiry warnings", tokenExpiryCheckInterval/2, func(ctx context.Context) {
+				if err := sa.checkExpiringTokens(ctx); err != nil {
+					sa.backgroundLog.Warn("Failed to check for expiring tokens", "error", err.Error())
+				}
+			})
+			if err != nil {
+				sa.backgroundLog.Error("Failed to lock and execute the token expiry check", "error", err.Error())
+			}
 		}
 	}
 }
@@ -209,6 +255,9 @@ func (sa *ServiceAccountsService) AddServiceAccountToken(ctx context.Context, se
 	if err := validServiceAccountID(serviceAccountID); err != nil {
 		return nil, err
 	}
+	if err := sa.checkTokenPolicy(ctx, query.OrgId, query.SecondsToLive); err != nil {
+		return nil, err
+	}
 	return sa.store.AddServiceAccountToken(ctx, serviceAccountID, query)
 }
 
@@ -3,6 +3,7 @@ package manager
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -21,6 +22,12 @@ type FakeServiceAccountStore struct {
 	expectedMigratedResults                 *serviceaccounts.MigrationResult
 	ExpectedAPIKeys                         []apikey.APIKey
 	ExpectedAPIKey                          *apikey.APIKey
+	ExpectedTokenPolicy                     *serviceaccounts.TokenPolicy
+	ExpectedExpiringTokens                  []serviceaccounts.ExpiringToken
+	RevokedTokenID                          int64
+	ExpiredTokenID                          int64
+	ExpiredTokenAt                          time.Time
+	AddedToken                              *serviceaccounts.AddServiceAccountTokenCommand
 	ExpectedBoolean                         bool
 	ExpectedError                           error
 }
@@ -82,11 +89,13 @@ func (f *FakeServiceAccountStore) ListTokens(ctx context.Context, query *service
 
 // RevokeServiceAccountToken is a fake revoking a service account token.
 func (f *FakeServiceAccountStore) RevokeServiceAccountToken(ctx context.Context, orgId, serviceAccountId, tokenId int64) error {
+	f.RevokedTokenID = tokenId
 	return f.ExpectedError
 }
 
 // AddServiceAccountToken is a fake adding a service account token.
 func (f *FakeServiceAccountStore) AddServiceAccountToken(ctx context.Context, serviceAccountID int64, cmd *serviceaccounts.AddServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	f.AddedToken = cmd
 	return f.ExpectedAPIKey, f.ExpectedError
 }
 
@@ -95,6 +104,37 @@ func (f *FakeServiceAccountStore) DeleteServiceAccountToken(ctx context.Context,
 	return f.ExpectedError
 }
 
+// ExpireServiceAccountToken is a fake shortening the lifetime of a service account token.
+func (f *FakeServiceAccountStore) ExpireServiceAccountToken(ctx context.Context, orgID, serviceAccountID, tokenID int64, expires time.Time) error {
+	f.ExpiredTokenID = tokenID
+	f.ExpiredTokenAt = expires
+	return f.ExpectedError
+}
+
+// ListExpiringTokens is a fake listing service account tokens about to expire.
+func (f *FakeServiceAccountStore) ListExpiringTokens(ctx context.Context, before time.Time) ([]serviceaccounts.ExpiringToken, error) {
+	return f.ExpectedExpiringTokens, f.ExpectedError
+}
+
+// GetTokenPolicy is a fake getting the token policy of an organization.
+func (f *FakeServiceAccountStore) GetTokenPolicy(ctx context.Context, orgID int64) (*serviceaccounts.TokenPolicy, error) {
+	if f.ExpectedTokenPolicy == nil {
+		return &serviceaccounts.TokenPolicy{OrgID: orgID}, nil
+	}
+	return f.ExpectedTokenPolicy, nil
+}
+
+// SetTokenPolicy is a fake setting the token policy of an organization.
+func (f *FakeServiceAccountStore) SetTokenPolicy(ctx context.Context, policy *serviceaccounts.TokenPolicy) error {
+	f.ExpectedTokenPolicy = policy
+	return f.ExpectedError
+}
+
+// InTransaction is a fake running fn without a transaction.
+func (f *FakeServiceAccountStore) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 // GetUsageMetrics is a fake getting usage metrics.
 func (f *FakeServiceAccountStore) GetUsageMetrics(ctx context.Context) (*serviceaccounts.Stats, error) {
 	return f.ExpectedStats, f.ExpectedError
@@ -110,7 +150,7 @@ func (f *SecretsCheckerFake) CheckTokens(ctx context.Context) error {
 
 func TestProvideServiceAccount_DeleteServiceAccount(t *testing.T) {
 	storeMock := newServiceAccountStoreFake()
-	svc := ServiceAccountsService{storeMock, log.New("test"), log.New("background.test"), &SecretsCheckerFake{}, false, 0}
+	svc := ServiceAccountsService{store: storeMock, log: log.New("test"), backgroundLog: log.New("background.test"), secretScanService: &SecretsCheckerFake{}, secretScanEnabled: false, secretScanInterval: 0}
 	testOrgId := 1
 
 	t.Run("should create service account", func(t *testing.T) {
@@ -2,6 +2,7 @@ package manager
 
 import (
 	"context"
+	"time"
 
 	"github.com/grafana/grafana/pkg/services/apikey"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
@@ -21,5 +22,10 @@ type store interface {
 	RevokeServiceAccountToken(ctx context.Context, orgId, serviceAccountId, tokenId int64) error
 	AddServiceAccountToken(ctx context.Context, serviceAccountID int64, cmd *serviceaccounts.AddServiceAccountTokenCommand) (*apikey.APIKey, error)
 	DeleteServiceAccountToken(ctx context.Context, orgID, serviceAccountID, tokenID int64) error
+	ExpireServiceAccountToken(ctx context.Context, orgID, serviceAccountID, tokenID int64, expires time.Time) error
+	ListExpiringTokens(ctx context.Context, before time.Time) ([]serviceaccounts.ExpiringToken, error)
+	GetTokenPolicy(ctx context.Context, orgID int64) (*serviceaccounts.TokenPolicy, error)
+	SetTokenPolicy(ctx context.Context, policy *serviceaccounts.TokenPolicy) error
 	GetUsageMetrics(ctx context.Context) (*serviceaccounts.Stats, error)
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
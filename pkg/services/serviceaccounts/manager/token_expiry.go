@@ -0,0 +1,139 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+)
+
+const (
+	tokenExpiryCheckInterval = time.Hour
+	tokenExpiryKVNamespace   = "serviceaccounts.token-expiry"
+	tokenExpiryEmailTemplate = "service_account_token_expiry"
+)
+
+type tokenExpiryPayload struct {
+	OrgID              int64     `json:"orgId"`
+	ServiceAccountID   int64     `json:"serviceAccountId"`
+	ServiceAccountName string    `json:"serviceAccountName"`
+	TokenID            int64     `json:"tokenId"`
+	TokenName          string    `json:"tokenName"`
+	ExpiresAt          time.Time `json:"expiresAt"`
+	DaysUntilExpiry    int       `json:"daysUntilExpiry"`
+}
+
+// checkExpiringTokens warns about service account tokens expiring within the configured number of days.
+// Each token is notified only once per expiration date.
+func (sa *ServiceAccountsService) checkExpiringTokens(ctx context.Context) error {
+	if sa.tokenExpiryWarningDays <= 0 || (sa.tokenExpiryWebhookURL == "" && !sa.tokenExpiryEmail) {
+		return nil
+	}
+
+	tokens, err := sa.store.ListExpiringTokens(ctx, time.Now().AddDate(0, 0, sa.tokenExpiryWarningDays))
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		kv := kvstore.WithNamespace(sa.kvStore, token.OrgID, tokenExpiryKVNamespace)
+		key := strconv.FormatInt(token.TokenID, 10)
+		expires := strconv.FormatInt(token.Expires, 10)
+
+		notified, ok, err := kv.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if ok && notified == expires {
+			continue
+		}
+
+		if err := sa.warnTokenExpiry(ctx, token); err != nil {
+			sa.backgroundLog.Warn("Failed to send service account token expiry warning", "tokenId", token.TokenID, "error", err)
+			continue
+		}
+
+		if err := kv.Set(ctx, key, expires); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sa *ServiceAccountsService) warnTokenExpiry(ctx context.Context, token serviceaccounts.ExpiringToken) error {
+	expiresAt := time.Unix(token.Expires, 0).UTC()
+	payload := tokenExpiryPayload{
+		OrgID:              token.OrgID,
+		ServiceAccountID:   token.ServiceAccountID,
+		ServiceAccountName: token.ServiceAccountName,
+		TokenID:            token.TokenID,
+		TokenName:          token.TokenName,
+		ExpiresAt:          expiresAt,
+		DaysUntilExpiry:    int(math.Ceil(time.Until(expiresAt).Hours() / 24)),
+	}
+
+	if sa.tokenExpiryWebhookURL != "" {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		if err := sa.notificationService.SendWebhookSync(ctx, &notifications.SendWebhookSync{
+			Url:         sa.tokenExpiryWebhookURL,
+			Body:        string(body),
+			HttpMethod:  "POST",
+			ContentType: "application/json",
+		}); err != nil {
+			return err
+		}
+	}
+
+	if !sa.tokenExpiryEmail {
+		return nil
+	}
+
+	recipients, err := sa.orgAdminEmails(ctx, token.OrgID)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+
+	return sa.notificationService.SendEmailCommandHandlerSync(ctx, &notifications.SendEmailCommandSync{
+		SendEmailCommand: notifications.SendEmailCommand{
+			To:       recipients,
+			Template: tokenExpiryEmailTemplate,
+			Subject:  fmt.Sprintf("Service account token %s expires in %d day(s)", token.TokenName, payload.DaysUntilExpiry),
+			Data: map[string]interface{}{
+				"ServiceAccountName": token.ServiceAccountName,
+				"TokenName":          token.TokenName,
+				"ExpiresAt":          expiresAt.Format(time.RFC1123),
+				"DaysUntilExpiry":    payload.DaysUntilExpiry,
+				"ServiceAccountURL":  fmt.Sprintf("%sorg/serviceaccounts/%d?orgId=%d", sa.appURL, token.ServiceAccountID, token.OrgID),
+			},
+		},
+	})
+}
+
+func (sa *ServiceAccountsService) orgAdminEmails(ctx context.Context, orgID int64) ([]string, error) {
+	users, err := sa.orgService.GetOrgUsers(ctx, &org.GetOrgUsersQuery{
+		OrgID:                    orgID,
+		DontEnforceAccessControl: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]string, 0)
+	for _, u := range users {
+		if u.Role == string(org.RoleAdmin) && !u.IsDisabled && u.Email != "" {
+			emails = append(emails, u.Email)
+		}
+	}
+	return emails, nil
+}
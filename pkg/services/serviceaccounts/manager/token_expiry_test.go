@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+)
+
+func TestServiceAccountsService_CheckExpiringTokens(t *testing.T) {
+	expires := time.Now().Add(36 * time.Hour).Unix()
+
+	storeMock := newServiceAccountStoreFake()
+	storeMock.ExpectedExpiringTokens = []serviceaccounts.ExpiringToken{
+		{TokenID: 3, TokenName: "ci", Expires: expires, OrgID: 1, ServiceAccountID: 2, ServiceAccountName: "deployer"},
+	}
+	orgService := orgtest.NewOrgServiceFake()
+	orgService.ExpectedOrgUsers = []*org.OrgUserDTO{
+		{Email: "admin@example.com", Role: string(org.RoleAdmin)},
+		{Email: "disabled@example.com", Role: string(org.RoleAdmin), IsDisabled: true},
+		{Email: "editor@example.com", Role: string(org.RoleEditor)},
+	}
+
+	webhooks, emails := 0, 0
+	notificationService := notifications.MockNotificationService()
+	notificationService.WebhookHandler = func(context.Context, *notifications.SendWebhookSync) error {
+		webhooks++
+		return nil
+	}
+	notificationService.EmailHandlerSync = func(context.Context, *notifications.SendEmailCommandSync) error {
+		emails++
+		return nil
+	}
+
+	svc := ServiceAccountsService{
+		store:                  storeMock,
+		log:                    log.New("test"),
+		backgroundLog:          log.New("background.test"),
+		kvStore:                kvstore.NewFakeKVStore(),
+		orgService:             orgService,
+		notificationService:    notificationService,
+		tokenExpiryWarningDays: 7,
+		tokenExpiryWebhookURL:  "https://example.com/hook",
+		tokenExpiryEmail:       true,
+	}
+
+	require.NoError(t, svc.checkExpiringTokens(context.Background()))
+	assert.Equal(t, 1, webhooks)
+	assert.Equal(t, 1, emails)
+	assert.Equal(t, []string{"admin@example.com"}, notificationService.EmailSync.To)
+	assert.Equal(t, tokenExpiryEmailTemplate, notificationService.EmailSync.Template)
+
+	var payload tokenExpiryPayload
+	require.NoError(t, json.Unmarshal([]byte(notificationService.Webhook.Body), &payload))
+	assert.Equal(t, int64(3), payload.TokenID)
+	assert.Equal(t, "deployer", payload.ServiceAccountName)
+	assert.Equal(t, 2, payload.DaysUntilExpiry)
+
+	t.Run("should warn only once per expiration", func(t *testing.T) {
+		require.NoError(t, svc.checkExpiringTokens(context.Background()))
+		assert.Equal(t, 1, webhooks)
+		assert.Equal(t, 1, emails)
+	})
+
+	t.Run("should warn again when the expiration changes", func(t *testing.T) {
+		storeMock.ExpectedExpiringTokens[0].Expires = expires + 3600
+		require.NoError(t, svc.checkExpiringTokens(context.Background()))
+		assert.Equal(t, 2, webhooks)
+		assert.Equal(t, 2, emails)
+	})
+
+	t.Run("should not warn when disabled", func(t *testing.T) {
+		svc.tokenExpiryWarningDays = 0
+		storeMock.ExpectedExpiringTokens[0].TokenID = 4
+		require.NoError(t, svc.checkExpiringTokens(context.Background()))
+		assert.Equal(t, 2, webhooks)
+	})
+}
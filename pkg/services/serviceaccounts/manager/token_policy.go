@@ -0,0 +1,162 @@
+package manager
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+)
+
+const (
+	defaultTokenRotationGracePeriod = 24 * time.Hour
+	rotatedTokenSuffixFormat        = "20060102150405"
+)
+
+var rotatedTokenSuffix = regexp.MustCompile(`-\d{14}$`)
+
+func (sa *ServiceAccountsService) GetTokenPolicy(ctx context.Context, orgID int64) (*serviceaccounts.TokenPolicy, error) {
+	if err := validOrgID(orgID); err != nil {
+		return nil, err
+	}
+	return sa.store.GetTokenPolicy(ctx, orgID)
+}
+
+func (sa *ServiceAccountsService) SetTokenPolicy(ctx context.Context, policy *serviceaccounts.TokenPolicy) error {
+	if err := validOrgID(policy.OrgID); err != nil {
+		return err
+	}
+	if policy.MaxSecondsToLive < 0 {
+		return serviceaccounts.ErrInvalidTokenPolicy.Errorf("invalid maximum token lifetime %d", policy.MaxSecondsToLive)
+	}
+	return sa.store.SetTokenPolicy(ctx, policy)
+}
+
+// checkTokenPolicy verifies that a token with the given lifetime complies with the token policy of the organization
+func (sa *ServiceAccountsService) checkTokenPolicy(ctx context.Context, orgID int64, secondsToLive int64) error {
+	policy, err := sa.store.GetTokenPolicy(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	if secondsToLive == 0 && policy.RequireExpiration {
+		return serviceaccounts.ErrTokenExpirationRequired.Errorf("organization %d requires service account tokens to expire", orgID)
+	}
+	if policy.MaxSecondsToLive > 0 && (secondsToLive == 0 || secondsToLive > policy.MaxSecondsToLive) {
+		return serviceaccounts.ErrTokenLifetimeExceeded.Errorf("token lifetime %d exceeds the maximum of %d seconds", secondsToLive, policy.MaxSecondsToLive)
+	}
+	return nil
+}
+
+// checkGlobalTokenLifetime verifies that a token with the given lifetime complies with the global limits
+func (sa *ServiceAccountsService) checkGlobalTokenLifetime(secondsToLive int64) error {
+	if sa.tokenMaxSecondsToLive != -1 {
+		if secondsToLive == 0 {
+			return serviceaccounts.ErrTokenGlobalLifetimeExceeded.Errorf("tokens without expiration exceed the global limit of %d seconds", sa.tokenMaxSecondsToLive)
+		}
+		if secondsToLive > sa.tokenMaxSecondsToLive {
+			return serviceaccounts.ErrTokenGlobalLifetimeExceeded.Errorf("token lifetime %d exceeds the global limit of %d seconds", secondsToLive, sa.tokenMaxSecondsToLive)
+		}
+	}
+
+	if sa.tokenExpirationDayLimit > 0 {
+		dayExpireLimit := time.Now().Add(time.Duration(sa.tokenExpirationDayLimit) * time.Hour * 24).Truncate(24 * time.Hour)
+		expirationDate := time.Now().Add(time.Duration(secondsToLive) * time.Second).Truncate(24 * time.Hour)
+		if expirationDate.After(dayExpireLimit) {
+			return serviceaccounts.ErrTokenGlobalLifetimeExceeded.Errorf("token expiration exceeds the global limit of %d days", sa.tokenExpirationDayLimit)
+		}
+	}
+	return nil
+}
+
+// RotateServiceAccountToken issues a new token replacing an existing one. The replaced token keeps
+// working during the grace period so that clients can switch over, and is revoked afterwards.
+func (sa *ServiceAccountsService) RotateServiceAccountToken(ctx context.Context, orgID, serviceAccountID, tokenID int64,
+	cmd *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	if err := validOrgID(orgID); err != nil {
+		return nil, err
+	}
+	if err := validServiceAccountID(serviceAccountID); err != nil {
+		return nil, err
+	}
+	if err := validServiceAccountTokenID(tokenID); err != nil {
+		return nil, err
+	}
+
+	gracePeriod := sa.tokenRotationGracePeriod
+	if cmd.GracePeriodSeconds != nil {
+		if *cmd.GracePeriodSeconds < 0 {
+			return nil, serviceaccounts.ErrInvalidGracePeriod.Errorf("invalid grace period %d", *cmd.GracePeriodSeconds)
+		}
+		gracePeriod = time.Duration(*cmd.GracePeriodSeconds) * time.Second
+	}
+
+	tokens, err := sa.store.ListTokens(ctx, &serviceaccounts.GetSATokensQuery{
+		OrgID:            &orgID,
+		ServiceAccountID: &serviceAccountID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var token *apikey.APIKey
+	for i := range tokens {
+		if tokens[i].ID == tokenID {
+			token = &tokens[i]
+			break
+		}
+	}
+	if token == nil {
+		return nil, serviceaccounts.ErrServiceAccountTokenNotFound.Errorf("service account token with id %d not found for service account with id %d", tokenID, serviceAccountID)
+	}
+
+	now := time.Now()
+	if (token.IsRevoked != nil && *token.IsRevoked) || (token.Expires != nil && *token.Expires <= now.Unix()) {
+		return nil, serviceaccounts.ErrTokenNotRotatable.Errorf("service account token with id %d is expired or revoked", tokenID)
+	}
+
+	// The new token inherits the lifetime of the rotated token unless specified otherwise
+	secondsToLive := cmd.SecondsToLive
+	if secondsToLive == 0 && token.Expires != nil {
+		secondsToLive = *token.Expires - token.Created.Unix()
+	}
+	// The global limits may have changed since the rotated token was created
+	if err := sa.checkGlobalTokenLifetime(secondsToLive); err != nil {
+		return nil, err
+	}
+
+	name := cmd.Name
+	if name == "" {
+		name = rotatedTokenSuffix.ReplaceAllString(token.Name, "") + "-" + now.UTC().Format(rotatedTokenSuffixFormat)
+	}
+
+	// The new token is not issued if the old one can not be revoked
+	var newToken *apikey.APIKey
+	err = sa.store.InTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		newToken, err = sa.AddServiceAccountToken(ctx, serviceAccountID, &serviceaccounts.AddServiceAccountTokenCommand{
+			Name:          name,
+			OrgId:         orgID,
+			Key:           cmd.Key,
+			SecondsToLive: secondsToLive,
+		})
+		if err != nil {
+			return err
+		}
+
+		if gracePeriod == 0 {
+			return sa.store.RevokeServiceAccountToken(ctx, orgID, serviceAccountID, tokenID)
+		}
+		if revokeAt := now.Add(gracePeriod); token.Expires == nil || revokeAt.Unix() < *token.Expires {
+			return sa.store.ExpireServiceAccountToken(ctx, orgID, serviceAccountID, tokenID, revokeAt)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sa.log.Info("Rotated service account token", "orgId", orgID, "serviceAccountId", serviceAccountID, "tokenId", tokenID, "newTokenId", newToken.ID, "gracePeriod", gracePeriod)
+	return newToken, nil
+}
@@ -0,0 +1,170 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+)
+
+func TestServiceAccountsService_TokenPolicy(t *testing.T) {
+	storeMock := newServiceAccountStoreFake()
+	svc := ServiceAccountsService{store: storeMock, log: log.New("test")}
+
+	testCases := []struct {
+		desc          string
+		policy        serviceaccounts.TokenPolicy
+		secondsToLive int64
+		expectedErr   error
+	}{
+		{
+			desc:          "should allow non-expiring tokens without policy",
+			secondsToLive: 0,
+		},
+		{
+			desc:          "should reject non-expiring tokens when expiration is required",
+			policy:        serviceaccounts.TokenPolicy{OrgID: 1, RequireExpiration: true},
+			secondsToLive: 0,
+			expectedErr:   serviceaccounts.ErrTokenExpirationRequired,
+		},
+		{
+			desc:          "should reject non-expiring tokens when lifetime is limited",
+			policy:        serviceaccounts.TokenPolicy{OrgID: 1, MaxSecondsToLive: 3600},
+			secondsToLive: 0,
+			expectedErr:   serviceaccounts.ErrTokenLifetimeExceeded,
+		},
+		{
+			desc:          "should reject tokens exceeding the maximum lifetime",
+			policy:        serviceaccounts.TokenPolicy{OrgID: 1, MaxSecondsToLive: 3600},
+			secondsToLive: 3601,
+			expectedErr:   serviceaccounts.ErrTokenLifetimeExceeded,
+		},
+		{
+			desc:          "should allow tokens within the maximum lifetime",
+			policy:        serviceaccounts.TokenPolicy{OrgID: 1, MaxSecondsToLive: 3600, RequireExpiration: true},
+			secondsToLive: 3600,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			storeMock.ExpectedTokenPolicy = &tc.policy
+			storeMock.ExpectedAPIKey = &apikey.APIKey{ID: 1}
+
+			_, err := svc.AddServiceAccountToken(context.Background(), 1, &serviceaccounts.AddServiceAccountTokenCommand{
+				Name:          "test",
+				OrgId:         1,
+				SecondsToLive: tc.secondsToLive,
+			})
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("should reject negative maximum lifetime", func(t *testing.T) {
+		err := svc.SetTokenPolicy(context.Background(), &serviceaccounts.TokenPolicy{OrgID: 1, MaxSecondsToLive: -1})
+		require.ErrorIs(t, err, serviceaccounts.ErrInvalidTokenPolicy)
+	})
+}
+
+func TestServiceAccountsService_RotateServiceAccountToken(t *testing.T) {
+	created := time.Now().Add(-time.Hour)
+	expires := created.Add(48 * time.Hour).Unix()
+	revoked := true
+
+	setup := func(tokens ...apikey.APIKey) (*FakeServiceAccountStore, *ServiceAccountsService) {
+		storeMock := newServiceAccountStoreFake()
+		storeMock.ExpectedAPIKeys = tokens
+		storeMock.ExpectedAPIKey = &apikey.APIKey{ID: 2}
+		return storeMock, &ServiceAccountsService{store: storeMock, log: log.New("test"), tokenRotationGracePeriod: time.Hour, tokenMaxSecondsToLive: -1}
+	}
+
+	t.Run("should issue a new token and expire the old one after the grace period", func(t *testing.T) {
+		storeMock, svc := setup(apikey.APIKey{ID: 1, Name: "ci-20230101120000", Created: created, Expires: &expires})
+
+		token, err := svc.RotateServiceAccountToken(context.Background(), 1, 1, 1, &serviceaccounts.RotateServiceAccountTokenCommand{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), token.ID)
+
+		require.NotNil(t, storeMock.AddedToken)
+		assert.Regexp(t, `^ci-\d{14}$`, storeMock.AddedToken.Name)
+		assert.Equal(t, int64(48*3600), storeMock.AddedToken.SecondsToLive)
+		assert.Equal(t, int64(1), storeMock.ExpiredTokenID)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), storeMock.ExpiredTokenAt, time.Minute)
+		assert.Zero(t, storeMock.RevokedTokenID)
+	})
+
+	t.Run("should keep the old expiration when it is before the end of the grace period", func(t *testing.T) {
+		soon := time.Now().Add(time.Minute).Unix()
+		storeMock, svc := setup(apikey.APIKey{ID: 1, Name: "ci", Created: created, Expires: &soon})
+
+		_, err := svc.RotateServiceAccountToken(context.Background(), 1, 1, 1, &serviceaccounts.RotateServiceAccountTokenCommand{Name: "ci-new", SecondsToLive: 60})
+		require.NoError(t, err)
+		assert.Equal(t, "ci-new", storeMock.AddedToken.Name)
+		assert.Equal(t, int64(60), storeMock.AddedToken.SecondsToLive)
+		assert.Zero(t, storeMock.ExpiredTokenID)
+	})
+
+	t.Run("should revoke the old token immediately without grace period", func(t *testing.T) {
+		storeMock, svc := setup(apikey.APIKey{ID: 1, Name: "ci", Created: created})
+		grace := int64(0)
+
+		_, err := svc.RotateServiceAccountToken(context.Background(), 1, 1, 1, &serviceaccounts.RotateServiceAccountTokenCommand{GracePeriodSeconds: &grace})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), storeMock.AddedToken.SecondsToLive)
+		assert.Equal(t, int64(1), storeMock.RevokedTokenID)
+	})
+
+	t.Run("should not rotate revoked tokens", func(t *testing.T) {
+		_, svc := setup(apikey.APIKey{ID: 1, Name: "ci", Created: created, IsRevoked: &revoked})
+
+		_, err := svc.RotateServiceAccountToken(context.Background(), 1, 1, 1, &serviceaccounts.RotateServiceAccountTokenCommand{})
+		require.ErrorIs(t, err, serviceaccounts.ErrTokenNotRotatable)
+	})
+
+	t.Run("should fail for unknown tokens", func(t *testing.T) {
+		_, svc := setup()
+
+		_, err := svc.RotateServiceAccountToken(context.Background(), 1, 1, 1, &serviceaccounts.RotateServiceAccountTokenCommand{})
+		require.ErrorIs(t, err, serviceaccounts.ErrServiceAccountTokenNotFound)
+	})
+
+	t.Run("should check the inherited lifetime against the global limits", func(t *testing.T) {
+		storeMock, svc := setup(apikey.APIKey{ID: 1, Name: "ci", Created: created, Expires: &expires})
+		svc.tokenMaxSecondsToLive = 3600
+
+		_, err := svc.RotateServiceAccountToken(context.Background(), 1, 1, 1, &serviceaccounts.RotateServiceAccountTokenCommand{})
+		require.ErrorIs(t, err, serviceaccounts.ErrTokenGlobalLifetimeExceeded)
+		assert.Nil(t, storeMock.AddedToken)
+		assert.Zero(t, storeMock.ExpiredTokenID)
+	})
+
+	t.Run("should check tokens without expiration against the global limits", func(t *testing.T) {
+		_, svc := setup(apikey.APIKey{ID: 1, Name: "ci", Created: created})
+		svc.tokenExpirationDayLimit = 30
+
+		_, err := svc.RotateServiceAccountToken(context.Background(), 1, 1, 1, &serviceaccounts.RotateServiceAccountTokenCommand{})
+		require.NoError(t, err)
+
+		svc.tokenMaxSecondsToLive = 3600
+		_, err = svc.RotateServiceAccountToken(context.Background(), 1, 1, 1, &serviceaccounts.RotateServiceAccountTokenCommand{})
+		require.ErrorIs(t, err, serviceaccounts.ErrTokenGlobalLifetimeExceeded)
+	})
+
+	t.Run("should reject negative grace period", func(t *testing.T) {
+		_, svc := setup(apikey.APIKey{ID: 1, Name: "ci", Created: created})
+		grace := int64(-1)
+
+		_, err := svc.RotateServiceAccountToken(context.Background(), 1, 1, 1, &serviceaccounts.RotateServiceAccountTokenCommand{GracePeriodSeconds: &grace})
+		require.ErrorIs(t, err, serviceaccounts.ErrInvalidGracePeriod)
+	})
+}
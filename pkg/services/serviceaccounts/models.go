@@ -35,6 +35,12 @@ var (
 	ErrServiceAccountTokenNotFound       = errutil.NewBase(errutil.StatusNotFound, "serviceaccounts.ErrTokenNotFound", errutil.WithPublicMessage("service account token not found"))
 	ErrInvalidTokenExpiration            = errutil.NewBase(errutil.StatusValidationFailed, "serviceaccounts.ErrInvalidInput", errutil.WithPublicMessage("invalid SecondsToLive value"))
 	ErrDuplicateToken                    = errutil.NewBase(errutil.StatusBadRequest, "serviceaccounts.ErrTokenAlreadyExists", errutil.WithPublicMessage("service account token with given name already exists in the organization"))
+	ErrTokenExpirationRequired           = errutil.NewBase(errutil.StatusBadRequest, "serviceaccounts.ErrTokenExpirationRequired", errutil.WithPublicMessage("the organization token policy does not allow tokens without expiration"))
+	ErrTokenLifetimeExceeded             = errutil.NewBase(errutil.StatusBadRequest, "serviceaccounts.ErrTokenLifetimeExceeded", errutil.WithPublicMessage("token lifetime exceeds the maximum allowed by the organization token policy"))
+	ErrTokenGlobalLifetimeExceeded       = errutil.NewBase(errutil.StatusBadRequest, "serviceaccounts.ErrTokenGlobalLifetimeExceeded", errutil.WithPublicMessage("token lifetime exceeds the global limit for service account tokens"))
+	ErrInvalidTokenPolicy                = errutil.NewBase(errutil.StatusValidationFailed, "serviceaccounts.ErrInvalidTokenPolicy", errutil.WithPublicMessage("invalid token policy"))
+	ErrTokenNotRotatable                 = errutil.NewBase(errutil.StatusBadRequest, "serviceaccounts.ErrTokenNotRotatable", errutil.WithPublicMessage("expired or revoked service account tokens can not be rotated"))
+	ErrInvalidGracePeriod                = errutil.NewBase(errutil.StatusValidationFailed, "serviceaccounts.ErrInvalidGracePeriod", errutil.WithPublicMessage("invalid GracePeriodSeconds value"))
 )
 
 type MigrationResult struct {
@@ -100,6 +106,39 @@ type AddServiceAccountTokenCommand struct {
 	SecondsToLive int64  `json:"secondsToLive"`
 }
 
+type RotateServiceAccountTokenCommand struct {
+	// Name of the new token, defaults to the name of the rotated token with a timestamp suffix
+	Name  string `json:"name"`
+	OrgId int64  `json:"-"`
+	Key   string `json:"-"`
+	// Lifetime of the new token, defaults to the lifetime of the rotated token
+	SecondsToLive int64 `json:"secondsToLive"`
+	// Time during which the rotated token remains valid, defaults to [service_accounts] token_rotation_grace_period
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds"`
+}
+
+// TokenPolicy restricts the lifetime of the service account tokens of an organization.
+// swagger:model
+type TokenPolicy struct {
+	OrgID int64 `json:"orgId" xorm:"org_id"`
+	// Maximum lifetime of new tokens in seconds, 0 means no limit
+	// example: 2592000
+	MaxSecondsToLive int64 `json:"maxSecondsToLive" xorm:"max_seconds_to_live"`
+	// Forbids the creation of tokens without expiration
+	// example: true
+	RequireExpiration bool `json:"requireExpiration" xorm:"require_expiration"`
+}
+
+// ExpiringToken is a service account token that is about to expire.
+type ExpiringToken struct {
+	TokenID            int64  `xorm:"token_id"`
+	TokenName          string `xorm:"token_name"`
+	Expires            int64  `xorm:"expires"`
+	OrgID              int64  `xorm:"org_id"`
+	ServiceAccountID   int64  `xorm:"service_account_id"`
+	ServiceAccountName string `xorm:"service_account_name"`
+}
+
 type SearchOrgServiceAccountsQuery struct {
 	OrgID        int64
 	Query        string
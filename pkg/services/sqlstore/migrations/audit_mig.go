@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addAuditEventMigrations(mg *Migrator) {
+	auditEventV1 := Table{
+		Name: "audit_event",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "actor_id", Type: DB_BigInt, Nullable: false},
+			{Name: "actor_login", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "actor_kind", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "action", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "resource_kind", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "resource_uid", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "before_state", Type: DB_MediumText, Nullable: true},
+			{Name: "after_state", Type: DB_MediumText, Nullable: true},
+			{Name: "diff", Type: DB_MediumText, Nullable: true},
+			{Name: "ip_address", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "request_id", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "method", Type: DB_NVarchar, Length: 10, Nullable: false},
+			{Name: "path", Type: DB_Text, Nullable: false},
+			{Name: "route", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "status_code", Type: DB_Int, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"created"}},
+			{Cols: []string{"org_id", "created"}},
+			{Cols: []string{"actor_id"}},
+			{Cols: []string{"resource_kind", "resource_uid"}},
+			{Cols: []string{"request_id"}},
+		},
+	}
+
+	mg.AddMigration("create audit_event table v1", NewAddTableMigration(auditEventV1))
+	addTableIndicesMigrations(mg, "v1", auditEventV1)
+}
@@ -97,3 +97,40 @@ func addPublicDashboardMigration(mg *Migrator) {
 		"UPDATE dashboard_public SET share='public' WHERE share=''",
 	))
 }
+
+func addPublicDashboardAccessMigrations(mg *Migrator) {
+	dashboardPublic := Table{Name: "dashboard_public"}
+
+	mg.AddMigration("add expires_at column", NewAddColumnMigration(dashboardPublic, &Column{
+		Name:     "expires_at",
+		Type:     DB_DateTime,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add allowed_cidrs column", NewAddColumnMigration(dashboardPublic, &Column{
+		Name:     "allowed_cidrs",
+		Type:     DB_Text,
+		Nullable: true,
+	}))
+
+	accessLogV1 := Table{
+		Name: "dashboard_public_access_log",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "public_dashboard_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "accessed_at", Type: DB_DateTime, Nullable: false},
+			{Name: "kind", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "panel_id", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "ip", Type: DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "user_agent", Type: DB_NVarchar, Length: 255, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"public_dashboard_uid", "accessed_at"}},
+			{Cols: []string{"accessed_at"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard public access log table v1", NewAddTableMigration(accessLogV1))
+	addTableIndicesMigrations(mg, "v1", accessLogV1)
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addGitSyncMigrations(mg *Migrator) {
+	dashboardGitSyncV1 := Table{
+		Name: "dashboard_git_sync",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "path", Type: DB_Text, Nullable: false},
+			{Name: "version", Type: DB_Int, Nullable: false},
+			{Name: "hash", Type: DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "commit_hash", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "state", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "message", Type: DB_Text, Nullable: true},
+			{Name: "resolution", Type: DB_NVarchar, Length: 20, Nullable: true},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "dashboard_uid"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create dashboard_git_sync table v1", NewAddTableMigration(dashboardGitSyncV1))
+	addTableIndicesMigrations(mg, "v1", dashboardGitSyncV1)
+}
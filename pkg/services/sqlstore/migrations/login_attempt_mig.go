@@ -40,3 +40,26 @@ func addLoginAttemptMigrations(mg *Migrator) {
 		"ip_address": "ip_address",
 	})
 }
+
+func addLoginAttemptIPAddressMigrations(mg *Migrator) {
+	loginAttemptV2 := Table{
+		Name: "login_attempt",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "username", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "ip_address", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "created", Type: DB_Int, Default: "0", Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"username"}},
+			{Cols: []string{"ip_address"}},
+		},
+	}
+
+	// IPv6 addresses don't fit in 30 characters
+	mg.AddMigration("alter login_attempt.ip_address to varchar(50)", NewRawSQLMigration("").
+		Postgres("ALTER TABLE login_attempt ALTER COLUMN ip_address TYPE VARCHAR(50);").
+		Mysql("ALTER TABLE login_attempt MODIFY ip_address VARCHAR(50) NOT NULL;"))
+
+	mg.AddMigration("add index login_attempt.ip_address", NewAddIndexMigration(loginAttemptV2, loginAttemptV2.Indices[1]))
+}
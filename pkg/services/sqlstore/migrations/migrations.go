@@ -91,6 +91,22 @@ func (*OSSMigrations) AddMigration(mg *Migrator) {
 	AddExternalAlertmanagerToDatasourceMigration(mg)
 
 	addFolderMigrations(mg)
+
+	addReportMigrations(mg)
+
+	addAuditEventMigrations(mg)
+
+	addGitSyncMigrations(mg)
+
+	addPublicDashboardAccessMigrations(mg)
+
+	addMFAMigrations(mg)
+	addSCIMMigrations(mg)
+	addTeamSyncMigrations(mg)
+	addLoginAttemptIPAddressMigrations(mg)
+	addServiceAccountTokenPolicyMigrations(mg)
+	addWebAuthnMigrations(mg)
+
 	if mg.Cfg != nil && mg.Cfg.IsFeatureToggleEnabled != nil {
 		if mg.Cfg.IsFeatureToggleEnabled(featuremgmt.FlagExternalServiceAuth) {
 			oauthserver.AddMigration(mg)
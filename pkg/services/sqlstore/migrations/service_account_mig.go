@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addServiceAccountTokenPolicyMigrations(mg *Migrator) {
+	tokenPolicyV1 := Table{
+		Name: "service_account_token_policy",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "max_seconds_to_live", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "require_expiration", Type: DB_Bool, Nullable: false, Default: "0"},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create service_account_token_policy table v1", NewAddTableMigration(tokenPolicyV1))
+	addTableIndicesMigrations(mg, "v1", tokenPolicyV1)
+}
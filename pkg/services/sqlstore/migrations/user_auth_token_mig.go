@@ -44,4 +44,46 @@ func addUserAuthTokenMigrations(mg *Migrator) {
 			},
 		),
 	)
+
+	mg.AddMigration(
+		"Add device_name to the user auth token",
+		NewAddColumnMigration(
+			userAuthTokenV1,
+			&Column{
+				Name:     "device_name",
+				Type:     DB_NVarchar,
+				Length:   190,
+				Nullable: true,
+			},
+		),
+	)
+
+	mg.AddMigration(
+		"Add last_seen_at to the user auth token",
+		NewAddColumnMigration(
+			userAuthTokenV1,
+			&Column{
+				Name:     "last_seen_at",
+				Type:     DB_Int,
+				Nullable: true,
+			},
+		),
+	)
+
+	orgSessionPolicyV1 := Table{
+		Name: "org_session_policy",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "idle_timeout", Type: DB_Int, Nullable: false},
+			{Name: "created", Type: DB_Int, Nullable: false},
+			{Name: "updated", Type: DB_Int, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create org session policy table", NewAddTableMigration(orgSessionPolicyV1))
+	mg.AddMigration("add unique index org_session_policy.org_id", NewAddIndexMigration(orgSessionPolicyV1, orgSessionPolicyV1.Indices[0]))
 }
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addWebAuthnMigrations(mg *Migrator) {
+	webAuthnCredentialV1 := Table{
+		Name: "webauthn_credential",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "credential_id", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "public_key", Type: DB_Blob, Nullable: false},
+			{Name: "sign_count", Type: DB_BigInt, Nullable: false},
+			{Name: "aaguid", Type: DB_NVarchar, Length: 32, Nullable: true},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "transports", Type: DB_Text, Nullable: true},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "last_used_at", Type: DB_DateTime, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"credential_id"}, Type: UniqueIndex},
+			{Cols: []string{"user_id"}},
+		},
+	}
+
+	mg.AddMigration("create webauthn_credential table v1", NewAddTableMigration(webAuthnCredentialV1))
+	addTableIndicesMigrations(mg, "v1", webAuthnCredentialV1)
+}
@@ -27,6 +27,10 @@ const (
 	EntityTypeFolder    EntityType = "folder"
 	EntityTypeImage     EntityType = "image"
 	EntityTypeJSON      EntityType = "json"
+
+	EntityTypeAlertRule    EntityType = "alert-rule"
+	EntityTypeLibraryPanel EntityType = "library-panel"
+	EntityTypeDataSource   EntityType = "datasource"
 )
 
 // CreateDatabaseEntityId creates entityId for entities stored in the existing SQL tables
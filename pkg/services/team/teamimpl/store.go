@@ -140,6 +140,8 @@ func (ss *xormStore) Delete(ctx context.Context, cmd *team.DeleteTeamCommand) er
 			"DELETE FROM team WHERE org_id=? and id = ?",
 			"DELETE FROM dashboard_acl WHERE org_id=? and team_id = ?",
 			"DELETE FROM team_role WHERE org_id=? and team_id = ?",
+			"DELETE FROM scim_external_id WHERE org_id=? and resource_type='Group' and resource_id = ?",
+			"DELETE FROM team_external_group WHERE org_id=? and team_id = ?",
 		}
 
 		for _, sql := range deletes {
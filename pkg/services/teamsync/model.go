@@ -0,0 +1,37 @@
+package teamsync
+
+import (
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrGroupAlreadyAdded = errutil.NewBase(errutil.StatusBadRequest, "teamsync.groupAlreadyAdded", errutil.WithPublicMessage("Group is already added to this team"))
+	ErrGroupNotFound     = errutil.NewBase(errutil.StatusNotFound, "teamsync.groupNotFound", errutil.WithPublicMessage("Group not found"))
+	ErrInvalidGroup      = errutil.NewBase(errutil.StatusBadRequest, "teamsync.invalidGroup", errutil.WithPublicMessage("Group ID is missing or too long"))
+	ErrTeamNotFound      = errutil.NewBase(errutil.StatusNotFound, "teamsync.teamNotFound", errutil.WithPublicMessage("Team not found"))
+)
+
+// TeamGroupDTO is an external group whose users are members of a team. Group IDs are
+// compared case-insensitively.
+type TeamGroupDTO struct {
+	OrgID   int64  `json:"orgId"`
+	TeamID  int64  `json:"teamId"`
+	GroupID string `json:"groupId"`
+}
+
+type GetGroupsQuery struct {
+	OrgID  int64
+	TeamID int64
+}
+
+type AddGroupCommand struct {
+	OrgID   int64  `json:"-"`
+	TeamID  int64  `json:"-"`
+	GroupID string `json:"groupId"`
+}
+
+type RemoveGroupCommand struct {
+	OrgID   int64
+	TeamID  int64
+	GroupID string
+}
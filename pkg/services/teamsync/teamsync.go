@@ -0,0 +1,17 @@
+package teamsync
+
+import (
+	"context"
+)
+
+// Service maps the groups of external users to teams. Users are added to the teams of their
+// groups when they log in, and removed from the teams of the groups they left.
+type Service interface {
+	// GetGroups returns the external groups of a team
+	GetGroups(ctx context.Context, query *GetGroupsQuery) ([]*TeamGroupDTO, error)
+	AddGroup(ctx context.Context, cmd *AddGroupCommand) error
+	RemoveGroup(ctx context.Context, cmd *RemoveGroupCommand) error
+	// SyncUser updates the team memberships of a user from their external groups. Only the
+	// memberships added by the team sync are removed, members added manually stay in their teams.
+	SyncUser(ctx context.Context, userID int64, groups []string) error
+}
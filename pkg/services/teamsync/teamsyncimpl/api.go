@@ -0,0 +1,78 @@
+package teamsyncimpl
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/teamsync"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+	readGroups := authorize(ac.EvalPermission(ac.ActionTeamsPermissionsRead, ac.ScopeTeamsID))
+	writeGroups := authorize(ac.EvalPermission(ac.ActionTeamsPermissionsWrite, ac.ScopeTeamsID))
+
+	routeRegister.Group("/api/teams/:teamId/groups", func(groupsRoute routing.RouteRegister) {
+		groupsRoute.Get("/", readGroups, routing.Wrap(s.getGroupsHandler))
+		groupsRoute.Post("/", writeGroups, routing.Wrap(s.addGroupHandler))
+		// group IDs with slashes are passed in the groupId query parameter
+		groupsRoute.Delete("/", writeGroups, routing.Wrap(s.removeGroupHandler))
+		groupsRoute.Delete("/:groupId", writeGroups, routing.Wrap(s.removeGroupHandler))
+	}, middleware.ReqSignedIn)
+}
+
+func (s *Service) getGroupsHandler(c *contextmodel.ReqContext) response.Response {
+	teamID, err := strconv.ParseInt(web.Params(c.Req)[":teamId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "teamId is invalid", err)
+	}
+
+	groups, err := s.GetGroups(c.Req.Context(), &teamsync.GetGroupsQuery{OrgID: c.OrgID, TeamID: teamID})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get team groups", err)
+	}
+	return response.JSON(http.StatusOK, groups)
+}
+
+func (s *Service) addGroupHandler(c *contextmodel.ReqContext) response.Response {
+	teamID, err := strconv.ParseInt(web.Params(c.Req)[":teamId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "teamId is invalid", err)
+	}
+
+	cmd := teamsync.AddGroupCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.OrgID
+	cmd.TeamID = teamID
+
+	if err := s.AddGroup(c.Req.Context(), &cmd); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to add group to team", err)
+	}
+	return response.Success("Group added to Team")
+}
+
+func (s *Service) removeGroupHandler(c *contextmodel.ReqContext) response.Response {
+	teamID, err := strconv.ParseInt(web.Params(c.Req)[":teamId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "teamId is invalid", err)
+	}
+
+	groupID := web.Params(c.Req)[":groupId"]
+	if groupID == "" {
+		groupID = c.Query("groupId")
+	}
+
+	err = s.RemoveGroup(c.Req.Context(), &teamsync.RemoveGroupCommand{OrgID: c.OrgID, TeamID: teamID, GroupID: groupID})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to remove group from team", err)
+	}
+	return response.Success("Team Group removed")
+}
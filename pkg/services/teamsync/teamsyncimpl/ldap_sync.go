@@ -0,0 +1,132 @@
+package teamsyncimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/ldap/multildap"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+const (
+	// ldapSyncPageSize is the number of users synced per page
+	ldapSyncPageSize = 100
+	// ldapSyncLockInterval keeps the instances of HA setups from running the same scheduled
+	// sync, it's shorter than the one minute resolution of the schedule
+	ldapSyncLockInterval = 30 * time.Second
+)
+
+func (s *Service) IsDisabled() bool {
+	return s.ldapSyncSchedule == nil
+}
+
+// Run syncs the LDAP users on the sync_cron schedule of the auth.ldap section
+func (s *Service) Run(ctx context.Context) error {
+	syncUsers := func(ctx context.Context) {
+		if err := s.SyncLDAPUsers(ctx); err != nil {
+			s.log.Error("Failed to sync LDAP users", "error", err)
+		}
+	}
+
+	for {
+		timer := time.NewTimer(time.Until(s.ldapSyncSchedule.Next(time.Now())))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+
+		err := s.serverLock.LockAndExecute(ctx, "ldap sync users", ldapSyncLockInterval, syncUsers)
+		if err != nil {
+			s.log.Error("Failed to lock and execute LDAP sync", "error", err)
+		}
+	}
+}
+
+// SyncLDAPUsers updates the users who last logged in with LDAP, with their org roles and their
+// teams. Users who aren't found in LDAP anymore are disabled and logged out.
+func (s *Service) SyncLDAPUsers(ctx context.Context) error {
+	start := time.Now()
+	synced, disabled := 0, 0
+	for page := 1; ; page++ {
+		result, err := s.userService.Search(ctx, &user.SearchUsersQuery{
+			SignedInUser: usersReader,
+			AuthModule:   login.LDAPAuthModule,
+			Page:         page,
+			Limit:        ldapSyncPageSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to search LDAP users: %w", err)
+		}
+
+		for _, u := range result.Users {
+			found, err := s.syncLDAPUser(ctx, u)
+			if err != nil {
+				return err
+			}
+			if found {
+				synced++
+			} else {
+				disabled++
+			}
+		}
+
+		if len(result.Users) < ldapSyncPageSize {
+			break
+		}
+	}
+
+	s.log.Info("Synced LDAP users", "synced", synced, "notFound", disabled, "duration", time.Since(start))
+	return nil
+}
+
+// syncLDAPUser updates a user from LDAP, it returns false when the user wasn't found. Errors
+// other than missing users stop the sync, so that an LDAP server which is down doesn't
+// disable everyone.
+func (s *Service) syncLDAPUser(ctx context.Context, u *user.UserSearchHitDTO) (bool, error) {
+	info, err := s.ldapService.User(u.Login)
+	if err != nil {
+		if !errors.Is(err, multildap.ErrDidNotFindUser) {
+			return false, fmt.Errorf("failed to get user %s from LDAP: %w", u.Login, err)
+		}
+
+		if u.Login == s.adminLogin {
+			s.log.Warn("Not disabling the server admin missing in LDAP", "login", u.Login)
+			return false, nil
+		}
+		if u.IsDisabled {
+			return false, nil
+		}
+
+		s.log.Info("Disabling user missing in LDAP", "login", u.Login)
+		if err := s.loginService.DisableExternalUser(ctx, u.Login); err != nil {
+			return false, fmt.Errorf("failed to disable user %s: %w", u.Login, err)
+		}
+		if err := s.userTokenService.RevokeAllUserTokens(ctx, u.ID); err != nil {
+			return false, fmt.Errorf("failed to revoke sessions of user %s: %w", u.Login, err)
+		}
+		return false, nil
+	}
+
+	_, err = s.loginService.UpsertUser(ctx, &login.UpsertUserCommand{
+		ExternalUser:     info,
+		SignupAllowed:    s.ldapAllowSignup,
+		UserLookupParams: login.UserLookupParams{UserID: &u.ID},
+	})
+	if err != nil {
+		return true, fmt.Errorf("failed to update user %s: %w", u.Login, err)
+	}
+	return true, nil
+}
+
+// usersReader is the user the LDAP users are searched with
+var usersReader = &user.SignedInUser{
+	Permissions: map[int64]map[string][]string{
+		0: {ac.ActionUsersRead: {ac.ScopeGlobalUsersAll}},
+	},
+}
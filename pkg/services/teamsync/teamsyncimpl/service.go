@@ -0,0 +1,235 @@
+package teamsyncimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/ldap/service"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/teamsync"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// maxGroupIDLength is the length of the group_id column
+const maxGroupIDLength = 190
+
+var (
+	_ teamsync.Service = (*Service)(nil)
+	_ ldap.Groups      = (*Service)(nil)
+)
+
+type Service struct {
+	store                  store
+	teamService            team.Service
+	teamPermissionsService ac.TeamPermissionsService
+	orgService             org.Service
+	userService            user.Service
+	loginService           login.Service
+	ldapService            service.LDAP
+	userTokenService       auth.UserTokenService
+	serverLock             *serverlock.ServerLockService
+	accessControl          ac.AccessControl
+	log                    log.Logger
+
+	// adminLogin is the login of the server admin created by Grafana, the LDAP sync never disables it
+	adminLogin       string
+	ldapAllowSignup  bool
+	ldapSyncSchedule cron.Schedule
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sql db.DB,
+	routeRegister routing.RouteRegister,
+	accessControl ac.AccessControl,
+	teamService team.Service,
+	teamPermissionsService ac.TeamPermissionsService,
+	orgService org.Service,
+	userService user.Service,
+	loginService login.Service,
+	ldapService service.LDAP,
+	userTokenService auth.UserTokenService,
+	serverLock *serverlock.ServerLockService,
+) (*Service, error) {
+	s := &Service{
+		store:                  &sqlStore{db: sql},
+		teamService:            teamService,
+		teamPermissionsService: teamPermissionsService,
+		orgService:             orgService,
+		userService:            userService,
+		loginService:           loginService,
+		ldapService:            ldapService,
+		userTokenService:       userTokenService,
+		serverLock:             serverLock,
+		accessControl:          accessControl,
+		log:                    log.New("teamsync"),
+		adminLogin:             cfg.AdminUser,
+		ldapAllowSignup:        cfg.LDAPAllowSignup,
+	}
+
+	if cfg.LDAPAuthEnabled && cfg.LDAPActiveSyncEnabled {
+		schedule, err := cron.ParseStandard(cfg.LDAPSyncCron)
+		if err != nil {
+			return nil, fmt.Errorf("invalid auth.ldap sync_cron %q: %w", cfg.LDAPSyncCron, err)
+		}
+		s.ldapSyncSchedule = schedule
+	}
+
+	// logins which don't go through the authn service upsert users with the login service
+	loginService.SetTeamSyncFunc(func(usr *user.User, externalUser *login.ExternalUserInfo) error {
+		return s.SyncUser(context.Background(), usr.ID, externalUser.Groups)
+	})
+
+	s.registerAPIEndpoints(routeRegister)
+
+	return s, nil
+}
+
+func (s *Service) GetGroups(ctx context.Context, query *teamsync.GetGroupsQuery) ([]*teamsync.TeamGroupDTO, error) {
+	return s.store.GetGroups(ctx, query.OrgID, query.TeamID)
+}
+
+func (s *Service) AddGroup(ctx context.Context, cmd *teamsync.AddGroupCommand) error {
+	groupID := strings.TrimSpace(cmd.GroupID)
+	if groupID == "" || len(groupID) > maxGroupIDLength {
+		return teamsync.ErrInvalidGroup.Errorf("invalid group ID %q", cmd.GroupID)
+	}
+
+	_, err := s.teamService.GetTeamByID(ctx, &team.GetTeamByIDQuery{OrgID: cmd.OrgID, ID: cmd.TeamID, SignedInUser: teamsReader(cmd.OrgID)})
+	if err != nil {
+		if errors.Is(err, team.ErrTeamNotFound) {
+			return teamsync.ErrTeamNotFound.Errorf("team %d not found", cmd.TeamID)
+		}
+		return err
+	}
+
+	return s.store.AddGroup(ctx, cmd.OrgID, cmd.TeamID, groupID)
+}
+
+func (s *Service) RemoveGroup(ctx context.Context, cmd *teamsync.RemoveGroupCommand) error {
+	return s.store.RemoveGroup(ctx, cmd.OrgID, cmd.TeamID, strings.TrimSpace(cmd.GroupID))
+}
+
+type teamKey struct {
+	orgID  int64
+	teamID int64
+}
+
+func (s *Service) SyncUser(ctx context.Context, userID int64, groups []string) error {
+	orgs, err := s.orgService.GetUserOrgList(ctx, &org.GetUserOrgListQuery{UserID: userID})
+	if err != nil {
+		return fmt.Errorf("failed to get organizations of user %d: %w", userID, err)
+	}
+	orgIDs := make([]int64, 0, len(orgs))
+	for _, o := range orgs {
+		orgIDs = append(orgIDs, o.OrgID)
+	}
+
+	mappings, err := s.store.FindTeams(ctx, orgIDs, groups)
+	if err != nil {
+		return fmt.Errorf("failed to find teams of groups: %w", err)
+	}
+	missing := make(map[teamKey]bool, len(mappings))
+	for _, m := range mappings {
+		missing[teamKey{m.OrgID, m.TeamID}] = true
+	}
+
+	memberships, err := s.teamService.GetUserTeamMemberships(ctx, 0, userID, false)
+	if err != nil {
+		return fmt.Errorf("failed to get team memberships of user %d: %w", userID, err)
+	}
+
+	for _, m := range memberships {
+		key := teamKey{m.OrgID, m.TeamID}
+		if missing[key] {
+			delete(missing, key)
+			continue
+		}
+		// members added manually aren't managed by the team sync
+		if !m.External {
+			continue
+		}
+
+		s.log.FromContext(ctx).Debug("Removing user from team", "userId", userID, "orgId", m.OrgID, "teamId", m.TeamID)
+		if err := s.setMember(ctx, m.OrgID, m.TeamID, userID, ""); err != nil {
+			return err
+		}
+	}
+
+	for key := range missing {
+		s.log.FromContext(ctx).Debug("Adding user to team", "userId", userID, "orgId", key.orgID, "teamId", key.teamID)
+		if err := s.setMember(ctx, key.orgID, key.teamID, userID, "Member"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setMember adds an external member to a team, or removes it when the permission is empty
+func (s *Service) setMember(ctx context.Context, orgID, teamID, userID int64, permission string) error {
+	_, err := s.teamPermissionsService.SetUserPermission(ctx, orgID, ac.User{ID: userID, IsExternal: true}, strconv.FormatInt(teamID, 10), permission)
+	if err != nil {
+		return fmt.Errorf("failed to set permission of user %d in team %d: %w", userID, teamID, err)
+	}
+	return nil
+}
+
+// GetTeams returns the teams of the groups in the organizations, for the LDAP debug view
+func (s *Service) GetTeams(groups []string, orgIDs []int64) ([]ldap.TeamOrgGroupDTO, error) {
+	ctx := context.Background()
+	mappings, err := s.store.FindTeams(ctx, orgIDs, groups)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ldap.TeamOrgGroupDTO, 0, len(mappings))
+	orgNames := map[int64]string{}
+	for _, m := range mappings {
+		t, err := s.teamService.GetTeamByID(ctx, &team.GetTeamByIDQuery{OrgID: m.OrgID, ID: m.TeamID, SignedInUser: teamsReader(m.OrgID)})
+		if err != nil {
+			return nil, err
+		}
+
+		orgName, ok := orgNames[m.OrgID]
+		if !ok {
+			o, err := s.orgService.GetByID(ctx, &org.GetOrgByIDQuery{ID: m.OrgID})
+			if err != nil {
+				return nil, err
+			}
+			orgName = o.Name
+			orgNames[m.OrgID] = orgName
+		}
+
+		result = append(result, ldap.TeamOrgGroupDTO{TeamName: t.Name, OrgName: orgName, GroupDN: m.GroupID})
+	}
+	return result, nil
+}
+
+// teamsReader is the user the team service is queried with, team sync isn't restricted by the
+// permissions of the synced users
+func teamsReader(orgID int64) *user.SignedInUser {
+	return &user.SignedInUser{
+		OrgID: orgID,
+		Permissions: map[int64]map[string][]string{
+			orgID: {
+				ac.ActionTeamsRead: {ac.ScopeTeamsAll},
+			},
+		},
+	}
+}
@@ -0,0 +1,277 @@
+package teamsyncimpl
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth/authtest"
+	"github.com/grafana/grafana/pkg/services/ldap/multildap"
+	"github.com/grafana/grafana/pkg/services/ldap/service"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/team/teamtest"
+	"github.com/grafana/grafana/pkg/services/teamsync"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+)
+
+const testUserID = 5
+
+func TestService_SyncUser(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("adds users to the teams of their groups", func(t *testing.T) {
+		s, teams := setupTestService(t)
+		require.NoError(t, s.SyncUser(ctx, testUserID, []string{"CN=Editors,DC=grafana,DC=org", "admins"}))
+		require.Equal(t, []string{"1/1 external", "1/2 external"}, teams.memberships())
+	})
+
+	t.Run("removes users from the teams of groups they left", func(t *testing.T) {
+		s, teams := setupTestService(t)
+		require.NoError(t, s.SyncUser(ctx, testUserID, []string{"admins", "viewers"}))
+		require.NoError(t, s.SyncUser(ctx, testUserID, []string{"viewers"}))
+		require.Equal(t, []string{"1/3 external"}, teams.memberships())
+
+		require.NoError(t, s.SyncUser(ctx, testUserID, nil))
+		require.Empty(t, teams.memberships())
+	})
+
+	t.Run("keeps the members added manually", func(t *testing.T) {
+		s, teams := setupTestService(t)
+		teams.members[teamKey{1, 1}] = false
+		teams.members[teamKey{1, 4}] = false
+
+		require.NoError(t, s.SyncUser(ctx, testUserID, []string{"cn=editors,dc=grafana,dc=org"}))
+		require.Equal(t, []string{"1/1 manual", "1/4 manual"}, teams.memberships())
+
+		require.NoError(t, s.SyncUser(ctx, testUserID, nil))
+		require.Equal(t, []string{"1/1 manual", "1/4 manual"}, teams.memberships())
+	})
+
+	t.Run("only adds users to the teams of their organizations", func(t *testing.T) {
+		s, teams := setupTestService(t)
+		require.NoError(t, s.SyncUser(ctx, testUserID, []string{"other-org"}))
+		require.Empty(t, teams.memberships())
+	})
+}
+
+func TestService_Groups(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("adds groups to teams", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		require.NoError(t, s.AddGroup(ctx, &teamsync.AddGroupCommand{OrgID: 1, TeamID: 4, GroupID: " developers "}))
+
+		groups, err := s.GetGroups(ctx, &teamsync.GetGroupsQuery{OrgID: 1, TeamID: 4})
+		require.NoError(t, err)
+		require.Equal(t, []*teamsync.TeamGroupDTO{{OrgID: 1, TeamID: 4, GroupID: "developers"}}, groups)
+	})
+
+	t.Run("rejects invalid groups", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		err := s.AddGroup(ctx, &teamsync.AddGroupCommand{OrgID: 1, TeamID: 4, GroupID: " "})
+		require.ErrorIs(t, err, teamsync.ErrInvalidGroup)
+
+		err = s.AddGroup(ctx, &teamsync.AddGroupCommand{OrgID: 1, TeamID: 4, GroupID: strings.Repeat("a", maxGroupIDLength+1)})
+		require.ErrorIs(t, err, teamsync.ErrInvalidGroup)
+	})
+
+	t.Run("rejects unknown teams", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		err := s.AddGroup(ctx, &teamsync.AddGroupCommand{OrgID: 1, TeamID: 10, GroupID: "developers"})
+		require.ErrorIs(t, err, teamsync.ErrTeamNotFound)
+	})
+}
+
+func TestService_SyncLDAPUsers(t *testing.T) {
+	ctx := context.Background()
+
+	s, _ := setupTestService(t)
+	s.adminLogin = "admin"
+	s.userService = &usertest.FakeUserService{ExpectedSearchUsers: user.SearchUserQueryResult{Users: []*user.UserSearchHitDTO{
+		{ID: 1, Login: "admin"},
+		{ID: 2, Login: "alice"},
+		{ID: 3, Login: "bob"},
+		{ID: 4, Login: "carol", IsDisabled: true},
+	}}}
+	ldapService := &fakeLDAP{users: map[string]*login.ExternalUserInfo{
+		"alice": {Login: "alice", AuthModule: login.LDAPAuthModule, Groups: []string{"admins"}},
+	}}
+	s.ldapService = ldapService
+	loginService := &fakeLoginService{}
+	s.loginService = loginService
+	revoked := []int64{}
+	s.userTokenService = &authtest.FakeUserAuthTokenService{
+		RevokeAllUserTokensProvider: func(ctx context.Context, userID int64) error {
+			revoked = append(revoked, userID)
+			return nil
+		},
+	}
+
+	require.NoError(t, s.SyncLDAPUsers(ctx))
+	require.Equal(t, []string{"alice"}, loginService.upserted)
+	require.Equal(t, []string{"bob"}, loginService.disabled)
+	require.Equal(t, []int64{3}, revoked)
+
+	t.Run("stops when LDAP fails", func(t *testing.T) {
+		ldapService.err = errors.New("connection refused")
+		loginService.upserted, loginService.disabled = nil, nil
+
+		require.Error(t, s.SyncLDAPUsers(ctx))
+		require.Empty(t, loginService.upserted)
+		require.Empty(t, loginService.disabled)
+	})
+}
+
+func setupTestService(t *testing.T) (*Service, *fakeTeams) {
+	t.Helper()
+
+	teams := &fakeTeams{members: map[teamKey]bool{}}
+	s := &Service{
+		store: &fakeStore{groups: []*teamsync.TeamGroupDTO{
+			{OrgID: 1, TeamID: 1, GroupID: "cn=editors,dc=grafana,dc=org"},
+			{OrgID: 1, TeamID: 2, GroupID: "admins"},
+			{OrgID: 1, TeamID: 3, GroupID: "viewers"},
+			{OrgID: 2, TeamID: 5, GroupID: "other-org"},
+		}},
+		teamService:            teams,
+		teamPermissionsService: teams,
+		orgService:             &orgtest.FakeOrgService{ExpectedUserOrgDTO: []*org.UserOrgDTO{{OrgID: 1, Role: org.RoleViewer}}},
+		log:                    log.NewNopLogger(),
+	}
+	return s, teams
+}
+
+// fakeTeams keeps the memberships of the test user, by team, with whether they're external
+type fakeTeams struct {
+	teamtest.FakeService
+	members map[teamKey]bool
+}
+
+func (f *fakeTeams) memberships() []string {
+	result := []string{}
+	for key, external := range f.members {
+		kind := "manual"
+		if external {
+			kind = "external"
+		}
+		result = append(result, strconv.FormatInt(key.orgID, 10)+"/"+strconv.FormatInt(key.teamID, 10)+" "+kind)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (f *fakeTeams) GetTeamByID(ctx context.Context, query *team.GetTeamByIDQuery) (*team.TeamDTO, error) {
+	if query.ID > 5 {
+		return nil, team.ErrTeamNotFound
+	}
+	return &team.TeamDTO{ID: query.ID, OrgID: query.OrgID}, nil
+}
+
+func (f *fakeTeams) GetUserTeamMemberships(ctx context.Context, orgID, userID int64, external bool) ([]*team.TeamMemberDTO, error) {
+	result := []*team.TeamMemberDTO{}
+	for key, isExternal := range f.members {
+		if external && !isExternal {
+			continue
+		}
+		result = append(result, &team.TeamMemberDTO{OrgID: key.orgID, TeamID: key.teamID, UserID: userID, External: isExternal})
+	}
+	return result, nil
+}
+
+func (f *fakeTeams) GetPermissions(ctx context.Context, user *user.SignedInUser, resourceID string) ([]ac.ResourcePermission, error) {
+	return nil, nil
+}
+
+func (f *fakeTeams) SetUserPermission(ctx context.Context, orgID int64, user ac.User, resourceID, permission string) (*ac.ResourcePermission, error) {
+	teamID, err := strconv.ParseInt(resourceID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if permission == "" {
+		delete(f.members, teamKey{orgID, teamID})
+	} else {
+		f.members[teamKey{orgID, teamID}] = user.IsExternal
+	}
+	return &ac.ResourcePermission{}, nil
+}
+
+type fakeStore struct {
+	groups []*teamsync.TeamGroupDTO
+}
+
+func (f *fakeStore) GetGroups(ctx context.Context, orgID, teamID int64) ([]*teamsync.TeamGroupDTO, error) {
+	result := []*teamsync.TeamGroupDTO{}
+	for _, g := range f.groups {
+		if g.OrgID == orgID && g.TeamID == teamID {
+			result = append(result, g)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeStore) AddGroup(ctx context.Context, orgID, teamID int64, groupID string) error {
+	f.groups = append(f.groups, &teamsync.TeamGroupDTO{OrgID: orgID, TeamID: teamID, GroupID: groupID})
+	return nil
+}
+
+func (f *fakeStore) RemoveGroup(ctx context.Context, orgID, teamID int64, groupID string) error {
+	return nil
+}
+
+func (f *fakeStore) FindTeams(ctx context.Context, orgIDs []int64, groups []string) ([]*teamsync.TeamGroupDTO, error) {
+	result := []*teamsync.TeamGroupDTO{}
+	for _, g := range f.groups {
+		for _, orgID := range orgIDs {
+			for _, group := range groups {
+				if g.OrgID == orgID && strings.EqualFold(g.GroupID, group) {
+					result = append(result, g)
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+type fakeLDAP struct {
+	service.LDAPFakeService
+	users map[string]*login.ExternalUserInfo
+	err   error
+}
+
+func (f *fakeLDAP) User(username string) (*login.ExternalUserInfo, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if u, ok := f.users[username]; ok {
+		return u, nil
+	}
+	return nil, multildap.ErrDidNotFindUser
+}
+
+type fakeLoginService struct {
+	upserted []string
+	disabled []string
+}
+
+func (f *fakeLoginService) UpsertUser(ctx context.Context, cmd *login.UpsertUserCommand) (*user.User, error) {
+	f.upserted = append(f.upserted, cmd.ExternalUser.Login)
+	return &user.User{ID: *cmd.UserLookupParams.UserID, Login: cmd.ExternalUser.Login}, nil
+}
+
+func (f *fakeLoginService) DisableExternalUser(ctx context.Context, username string) error {
+	f.disabled = append(f.disabled, username)
+	return nil
+}
+
+func (f *fakeLoginService) SetTeamSyncFunc(login.TeamSyncFunc) {}
@@ -0,0 +1,119 @@
+package teamsyncimpl
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/teamsync"
+)
+
+// teamGroup is an external group mapped to a team
+type teamGroup struct {
+	ID      int64     `xorm:"pk autoincr 'id'"`
+	OrgID   int64     `xorm:"org_id"`
+	TeamID  int64     `xorm:"team_id"`
+	GroupID string    `xorm:"group_id"`
+	Created time.Time `xorm:"created"`
+	Updated time.Time `xorm:"updated"`
+}
+
+func (g teamGroup) TableName() string {
+	return "team_external_group"
+}
+
+func (g *teamGroup) toDTO() *teamsync.TeamGroupDTO {
+	return &teamsync.TeamGroupDTO{OrgID: g.OrgID, TeamID: g.TeamID, GroupID: g.GroupID}
+}
+
+type store interface {
+	GetGroups(ctx context.Context, orgID, teamID int64) ([]*teamsync.TeamGroupDTO, error)
+	AddGroup(ctx context.Context, orgID, teamID int64, groupID string) error
+	RemoveGroup(ctx context.Context, orgID, teamID int64, groupID string) error
+	// FindTeams returns the mappings of the groups in the organizations, matching
+	// the groups case-insensitively
+	FindTeams(ctx context.Context, orgIDs []int64, groups []string) ([]*teamsync.TeamGroupDTO, error)
+}
+
+type sqlStore struct {
+	db db.DB
+}
+
+func (s *sqlStore) GetGroups(ctx context.Context, orgID, teamID int64) ([]*teamsync.TeamGroupDTO, error) {
+	rows := make([]*teamGroup, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("org_id = ? AND team_id = ?", orgID, teamID).Asc("group_id").Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]*teamsync.TeamGroupDTO, 0, len(rows))
+	for _, row := range rows {
+		groups = append(groups, row.toDTO())
+	}
+	return groups, nil
+}
+
+func (s *sqlStore) AddGroup(ctx context.Context, orgID, teamID int64, groupID string) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		exists, err := sess.Where("org_id = ? AND team_id = ? AND LOWER(group_id) = ?", orgID, teamID, strings.ToLower(groupID)).Exist(&teamGroup{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return teamsync.ErrGroupAlreadyAdded.Errorf("group %s is already added to team %d", groupID, teamID)
+		}
+
+		now := time.Now()
+		_, err = sess.Insert(&teamGroup{
+			OrgID:   orgID,
+			TeamID:  teamID,
+			GroupID: groupID,
+			Created: now,
+			Updated: now,
+		})
+		return err
+	})
+}
+
+func (s *sqlStore) RemoveGroup(ctx context.Context, orgID, teamID int64, groupID string) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		deleted, err := sess.Where("org_id = ? AND team_id = ? AND LOWER(group_id) = ?", orgID, teamID, strings.ToLower(groupID)).Delete(&teamGroup{})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return teamsync.ErrGroupNotFound.Errorf("group %s not found in team %d", groupID, teamID)
+		}
+		return nil
+	})
+}
+
+func (s *sqlStore) FindTeams(ctx context.Context, orgIDs []int64, groups []string) ([]*teamsync.TeamGroupDTO, error) {
+	if len(orgIDs) == 0 || len(groups) == 0 {
+		return []*teamsync.TeamGroupDTO{}, nil
+	}
+
+	args := make([]interface{}, 0, len(groups))
+	for _, group := range groups {
+		args = append(args, strings.ToLower(group))
+	}
+
+	rows := make([]*teamGroup, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.In("org_id", orgIDs).
+			Where("LOWER(group_id) IN (?"+strings.Repeat(",?", len(args)-1)+")", args...).
+			Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*teamsync.TeamGroupDTO, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, row.toDTO())
+	}
+	return result, nil
+}
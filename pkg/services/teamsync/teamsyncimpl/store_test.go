@@ -0,0 +1,52 @@
+package teamsyncimpl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/teamsync"
+)
+
+func TestIntegrationTeamSyncStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	ss := &sqlStore{db: db.InitTestDB(t)}
+
+	require.NoError(t, ss.AddGroup(ctx, 1, 1, "cn=Editors,dc=grafana,dc=org"))
+	require.NoError(t, ss.AddGroup(ctx, 1, 1, "admins"))
+	require.NoError(t, ss.AddGroup(ctx, 1, 2, "admins"))
+	require.NoError(t, ss.AddGroup(ctx, 2, 3, "admins"))
+	require.ErrorIs(t, ss.AddGroup(ctx, 1, 1, "ADMINS"), teamsync.ErrGroupAlreadyAdded)
+
+	groups, err := ss.GetGroups(ctx, 1, 1)
+	require.NoError(t, err)
+	require.Equal(t, []*teamsync.TeamGroupDTO{
+		{OrgID: 1, TeamID: 1, GroupID: "admins"},
+		{OrgID: 1, TeamID: 1, GroupID: "cn=Editors,dc=grafana,dc=org"},
+	}, groups)
+
+	teams, err := ss.FindTeams(ctx, []int64{1}, []string{"CN=editors,DC=grafana,DC=org", "Admins", "viewers"})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []*teamsync.TeamGroupDTO{
+		{OrgID: 1, TeamID: 1, GroupID: "cn=Editors,dc=grafana,dc=org"},
+		{OrgID: 1, TeamID: 1, GroupID: "admins"},
+		{OrgID: 1, TeamID: 2, GroupID: "admins"},
+	}, teams)
+
+	teams, err = ss.FindTeams(ctx, []int64{1, 2}, nil)
+	require.NoError(t, err)
+	require.Empty(t, teams)
+
+	require.NoError(t, ss.RemoveGroup(ctx, 1, 1, "CN=EDITORS,dc=grafana,dc=org"))
+	require.ErrorIs(t, ss.RemoveGroup(ctx, 1, 1, "cn=editors,dc=grafana,dc=org"), teamsync.ErrGroupNotFound)
+
+	groups, err = ss.GetGroups(ctx, 1, 1)
+	require.NoError(t, err)
+	require.Equal(t, []*teamsync.TeamGroupDTO{{OrgID: 1, TeamID: 1, GroupID: "admins"}}, groups)
+}
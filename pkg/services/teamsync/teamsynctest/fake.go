@@ -0,0 +1,36 @@
+package teamsynctest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/teamsync"
+)
+
+var _ teamsync.Service = new(FakeService)
+
+type FakeService struct {
+	ExpectedGroups []*teamsync.TeamGroupDTO
+	ExpectedErr    error
+
+	// SyncedUserID and SyncedGroups are the arguments of the last call to SyncUser
+	SyncedUserID int64
+	SyncedGroups []string
+}
+
+func (f *FakeService) GetGroups(ctx context.Context, query *teamsync.GetGroupsQuery) ([]*teamsync.TeamGroupDTO, error) {
+	return f.ExpectedGroups, f.ExpectedErr
+}
+
+func (f *FakeService) AddGroup(ctx context.Context, cmd *teamsync.AddGroupCommand) error {
+	return f.ExpectedErr
+}
+
+func (f *FakeService) RemoveGroup(ctx context.Context, cmd *teamsync.RemoveGroupCommand) error {
+	return f.ExpectedErr
+}
+
+func (f *FakeService) SyncUser(ctx context.Context, userID int64, groups []string) error {
+	f.SyncedUserID = userID
+	f.SyncedGroups = groups
+	return f.ExpectedErr
+}
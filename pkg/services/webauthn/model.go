@@ -0,0 +1,160 @@
+package webauthn
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// AssertionHeader is the header of requests authenticated with an assertion instead of a session
+const AssertionHeader = "X-Grafana-WebAuthn-Assertion"
+
+var (
+	ErrInvalidChallenge    = errutil.NewBase(errutil.StatusUnauthorized, "webauthn.invalidChallenge", errutil.WithPublicMessage("WebAuthn challenge is invalid or expired"))
+	ErrInvalidCredential   = errutil.NewBase(errutil.StatusBadRequest, "webauthn.invalidCredential", errutil.WithPublicMessage("Invalid WebAuthn credential"))
+	ErrInvalidAssertion    = errutil.NewBase(errutil.StatusUnauthorized, "webauthn.invalidAssertion", errutil.WithPublicMessage("Invalid WebAuthn assertion"))
+	ErrAssertionRequired   = errutil.NewBase(errutil.StatusUnauthorized, "webauthn.assertionRequired", errutil.WithPublicMessage("WebAuthn assertion required"))
+	ErrCredentialNotFound  = errutil.NewBase(errutil.StatusNotFound, "webauthn.credentialNotFound", errutil.WithPublicMessage("WebAuthn credential not found"))
+	ErrDuplicateCredential = errutil.NewBase(errutil.StatusBadRequest, "webauthn.duplicateCredential", errutil.WithPublicMessage("WebAuthn credential is already registered"))
+)
+
+// UserVerification is the user verification requirement of the relying party
+type UserVerification string
+
+const (
+	UserVerificationRequired    UserVerification = "required"
+	UserVerificationPreferred   UserVerification = "preferred"
+	UserVerificationDiscouraged UserVerification = "discouraged"
+)
+
+func (v UserVerification) IsValid() bool {
+	return v == UserVerificationRequired || v == UserVerificationPreferred || v == UserVerificationDiscouraged
+}
+
+// Credential is a public key credential registered by a user
+type Credential struct {
+	ID     int64 `xorm:"pk autoincr 'id'"`
+	UserID int64 `xorm:"user_id"`
+	// CredentialID is the base64url encoded ID of the credential chosen by the authenticator
+	CredentialID string `xorm:"credential_id"`
+	// PublicKey is the COSE encoded public key of the credential
+	PublicKey []byte `xorm:"public_key"`
+	// SignCount is the last signature counter reported by the authenticator, it detects cloned authenticators
+	SignCount  int64      `xorm:"sign_count"`
+	AAGUID     string     `xorm:"aaguid"`
+	Name       string     `xorm:"name"`
+	Transports []string   `xorm:"transports"`
+	Created    time.Time  `xorm:"'created'"`
+	LastUsedAt *time.Time `xorm:"last_used_at"`
+}
+
+func (c Credential) TableName() string {
+	return "webauthn_credential"
+}
+
+type CredentialDTO struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Transports []string   `json:"transports"`
+	Created    time.Time  `json:"created"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+type RelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	// ID is the base64url encoded user handle
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string           `json:"residentKey"`
+	UserVerification UserVerification `json:"userVerification"`
+}
+
+// CreationOptions are the options of a registration ceremony, passed to navigator.credentials.create
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingParty           `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are the options of an authentication ceremony, passed to navigator.credentials.get
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification UserVerification       `json:"userVerification"`
+}
+
+// AttestationResponse is the base64url encoded result of navigator.credentials.create
+type AttestationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the base64url encoded result of navigator.credentials.get
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+type BeginRegistrationCommand struct {
+	UserID      int64  `json:"-"`
+	Login       string `json:"-"`
+	DisplayName string `json:"-"`
+}
+
+type FinishRegistrationCommand struct {
+	UserID int64 `json:"-"`
+	// Name helps the user to recognize the credential, e.g. "YubiKey" or "Laptop"
+	Name       string              `json:"name"`
+	Credential AttestationResponse `json:"credential"`
+}
+
+type BeginLoginCommand struct {
+	// Login restricts the ceremony to the credentials of a user, it's required for
+	// the second factor and optional for passwordless logins with discoverable credentials
+	Login string `json:"login"`
+}
+
+type FinishLoginCommand struct {
+	Assertion AssertionResponse
+	// UserID restricts the assertion to the credentials of a user, 0 accepts any user
+	UserID int64
+}
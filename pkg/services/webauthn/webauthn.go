@@ -0,0 +1,24 @@
+package webauthn
+
+import (
+	"context"
+)
+
+type Service interface {
+	// IsEnabled returns true when WebAuthn is enabled in the configuration
+	IsEnabled() bool
+	// PasswordlessEnabled returns true when users can log in with a credential instead of their password
+	PasswordlessEnabled() bool
+	// SecondFactorEnabled returns true when users with credentials must use one after their password
+	SecondFactorEnabled() bool
+	// BeginRegistration starts the registration of a credential, it's completed by FinishRegistration
+	BeginRegistration(ctx context.Context, cmd *BeginRegistrationCommand) (*CreationOptions, error)
+	FinishRegistration(ctx context.Context, cmd *FinishRegistrationCommand) (*CredentialDTO, error)
+	// BeginLogin starts an authentication ceremony, it's completed by FinishLogin
+	BeginLogin(ctx context.Context, cmd *BeginLoginCommand) (*RequestOptions, error)
+	// FinishLogin verifies an assertion and returns the ID of the user owning the credential
+	FinishLogin(ctx context.Context, cmd *FinishLoginCommand) (int64, error)
+	HasCredentials(ctx context.Context, userID int64) (bool, error)
+	GetCredentials(ctx context.Context, userID int64) ([]*CredentialDTO, error)
+	DeleteCredential(ctx context.Context, userID, credentialID int64) error
+}
@@ -0,0 +1,91 @@
+package webauthnimpl
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/user/webauthn", func(userRoute routing.RouteRegister) {
+		userRoute.Get("/credentials", routing.Wrap(s.getCredentialsHandler))
+		userRoute.Delete("/credentials/:id", routing.Wrap(s.deleteCredentialHandler))
+		userRoute.Post("/registration/begin", routing.Wrap(s.beginRegistrationHandler))
+		userRoute.Post("/registration/finish", routing.Wrap(s.finishRegistrationHandler))
+	}, middleware.ReqSignedInNoAnonymous)
+
+	// the ceremony is completed by POST /login/webauthn or by the login form
+	// when WebAuthn is used as second factor
+	routeRegister.Post("/api/login/webauthn/begin", routing.Wrap(s.beginLoginHandler))
+}
+
+func (s *Service) getCredentialsHandler(c *contextmodel.ReqContext) response.Response {
+	if c.IsServiceAccount {
+		return response.Error(http.StatusBadRequest, "Service accounts can't use WebAuthn", nil)
+	}
+	credentials, err := s.GetCredentials(c.Req.Context(), c.UserID)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get WebAuthn credentials", err)
+	}
+	return response.JSON(http.StatusOK, credentials)
+}
+
+func (s *Service) deleteCredentialHandler(c *contextmodel.ReqContext) response.Response {
+	id, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	if err := s.DeleteCredential(c.Req.Context(), c.UserID, id); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to delete WebAuthn credential", err)
+	}
+	return response.Success("WebAuthn credential deleted")
+}
+
+func (s *Service) beginRegistrationHandler(c *contextmodel.ReqContext) response.Response {
+	if c.IsServiceAccount {
+		return response.Error(http.StatusBadRequest, "Service accounts can't use WebAuthn", nil)
+	}
+	options, err := s.BeginRegistration(c.Req.Context(), &webauthn.BeginRegistrationCommand{
+		UserID:      c.UserID,
+		Login:       c.Login,
+		DisplayName: c.Name,
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to begin WebAuthn registration", err)
+	}
+	return response.JSON(http.StatusOK, options)
+}
+
+func (s *Service) finishRegistrationHandler(c *contextmodel.ReqContext) response.Response {
+	if c.IsServiceAccount {
+		return response.Error(http.StatusBadRequest, "Service accounts can't use WebAuthn", nil)
+	}
+	cmd := webauthn.FinishRegistrationCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.UserID = c.UserID
+	credential, err := s.FinishRegistration(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to register WebAuthn credential", err)
+	}
+	return response.JSON(http.StatusOK, credential)
+}
+
+func (s *Service) beginLoginHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := webauthn.BeginLoginCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	options, err := s.BeginLogin(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to begin WebAuthn login", err)
+	}
+	return response.JSON(http.StatusOK, options)
+}
@@ -0,0 +1,207 @@
+package webauthnimpl
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/webauthn"
+)
+
+// softAuthenticator is a software authenticator with an ES256 credential, it runs the
+// ceremonies like a browser and a platform authenticator would
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+	origin       string
+	rpID         string
+	// flags are the authenticator data flags of assertions
+	flags byte
+}
+
+func newSoftAuthenticator(t *testing.T, origin, rpID string) *softAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+
+	return &softAuthenticator{
+		key:          key,
+		credentialID: credentialID,
+		origin:       origin,
+		rpID:         rpID,
+		flags:        flagUserPresent | flagUserVerified,
+	}
+}
+
+func (a *softAuthenticator) create(t *testing.T, options *webauthn.CreationOptions) webauthn.AttestationResponse {
+	t.Helper()
+
+	userHandle, err := base64.RawURLEncoding.DecodeString(options.User.ID)
+	require.NoError(t, err)
+	a.userHandle = userHandle
+
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+	coseKey := encodeCBOR(map[interface{}]interface{}{
+		coseKeyType:  int64(coseKeyTypeEC2),
+		coseKeyAlg:   algES256,
+		coseKeyCurve: int64(coseCurveP256),
+		coseKeyX:     x,
+		coseKeyY:     y,
+	})
+
+	authData := a.authenticatorData(flagUserPresent | flagUserVerified | flagAttestedCredentialData)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, coseKey...)
+
+	response := webauthn.AttestationResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		RawID: base64.RawURLEncoding.EncodeToString(a.credentialID),
+		Type:  "public-key",
+	}
+	response.Response.ClientDataJSON = a.clientData(t, ceremonyCreate, options.Challenge)
+	response.Response.AttestationObject = base64.RawURLEncoding.EncodeToString(encodeCBOR(map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": authData,
+	}))
+	response.Response.Transports = []string{"internal"}
+	return response
+}
+
+func (a *softAuthenticator) get(t *testing.T, options *webauthn.RequestOptions) webauthn.AssertionResponse {
+	t.Helper()
+
+	a.signCount++
+	authData := a.authenticatorData(a.flags)
+	clientData := a.clientData(t, ceremonyGet, options.Challenge)
+	rawClientData, err := base64.RawURLEncoding.DecodeString(clientData)
+	require.NoError(t, err)
+	clientDataHash := sha256.Sum256(rawClientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	response := webauthn.AssertionResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		RawID: base64.RawURLEncoding.EncodeToString(a.credentialID),
+		Type:  "public-key",
+	}
+	response.Response.ClientDataJSON = clientData
+	response.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authData)
+	response.Response.Signature = base64.RawURLEncoding.EncodeToString(signature)
+	response.Response.UserHandle = base64.RawURLEncoding.EncodeToString(a.userHandle)
+	return response
+}
+
+func (a *softAuthenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, ceremony, challenge string) string {
+	t.Helper()
+
+	data, err := json.Marshal(clientData{Type: ceremony, Challenge: challenge, Origin: a.origin})
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// encodeCBOR encodes the subset of CBOR decoded by decodeCBOR
+func encodeCBOR(value interface{}) []byte {
+	switch v := value.(type) {
+	case int64:
+		if v < 0 {
+			return encodeCBORHead(1, uint64(-1-v))
+		}
+		return encodeCBORHead(0, uint64(v))
+	case []byte:
+		return append(encodeCBORHead(2, uint64(len(v))), v...)
+	case string:
+		return append(encodeCBORHead(3, uint64(len(v))), v...)
+	case []interface{}:
+		data := encodeCBORHead(4, uint64(len(v)))
+		for _, item := range v {
+			data = append(data, encodeCBOR(item)...)
+		}
+		return data
+	case map[interface{}]interface{}:
+		data := encodeCBORHead(5, uint64(len(v)))
+		for key, item := range v {
+			data = append(data, encodeCBOR(key)...)
+			data = append(data, encodeCBOR(item)...)
+		}
+		return data
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	default:
+		panic(fmt.Sprintf("cbor: unsupported type %T", value))
+	}
+}
+
+func encodeCBORHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, arg)
+	}
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	t.Run("decodes nested items", func(t *testing.T) {
+		value := map[interface{}]interface{}{
+			"fmt":    "none",
+			int64(1): int64(-300),
+			int64(2): []interface{}{[]byte{1, 2, 3}, true, int64(70000)},
+		}
+		item, rest, err := decodeCBOR(append(encodeCBOR(value), 0xff))
+		require.NoError(t, err)
+		require.Equal(t, []byte{0xff}, rest)
+		require.Equal(t, value, item)
+	})
+
+	t.Run("rejects truncated data", func(t *testing.T) {
+		data := encodeCBOR([]byte("credential"))
+		_, _, err := decodeCBOR(data[:len(data)-1])
+		require.ErrorIs(t, err, errCBORTruncated)
+	})
+
+	t.Run("rejects deeply nested items", func(t *testing.T) {
+		var value interface{} = int64(1)
+		for i := 0; i <= maxCBORDepth+1; i++ {
+			value = []interface{}{value}
+		}
+		_, _, err := decodeCBOR(encodeCBOR(value))
+		require.Error(t, err)
+	})
+}
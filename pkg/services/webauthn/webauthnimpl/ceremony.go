@@ -0,0 +1,153 @@
+package webauthnimpl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/webauthn"
+)
+
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+)
+
+// authenticator data flags
+const (
+	flagUserPresent            byte = 0x01
+	flagUserVerified           byte = 0x04
+	flagAttestedCredentialData byte = 0x40
+	flagExtensionData          byte = 0x80
+)
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+	// attested credential data, only present in registrations
+	aaguid       []byte
+	credentialID []byte
+	publicKey    *publicKey
+	rawPublicKey []byte
+}
+
+// verifyClientData checks the client data collected by the browser during a ceremony
+func (s *Service) verifyClientData(raw []byte, ceremony string, challenge string) error {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("invalid client data: %w", err)
+	}
+	if data.Type != ceremony {
+		return fmt.Errorf("unexpected ceremony type %q", data.Type)
+	}
+	if subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(challenge)) != 1 {
+		return errors.New("challenge mismatch")
+	}
+	if data.CrossOrigin {
+		return errors.New("cross-origin ceremonies are not allowed")
+	}
+	for _, origin := range s.origins {
+		if data.Origin == origin {
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected origin %q", data.Origin)
+}
+
+// verifyAuthenticatorData checks that the authenticator data is scoped to the relying party
+// and that the user was present, and verified if required
+func (s *Service) verifyAuthenticatorData(data *authenticatorData, userVerification webauthn.UserVerification) error {
+	rpIDHash := sha256.Sum256([]byte(s.rpID))
+	if !bytes.Equal(data.rpIDHash, rpIDHash[:]) {
+		return errors.New("relying party ID mismatch")
+	}
+	if data.flags&flagUserPresent == 0 {
+		return errors.New("user not present")
+	}
+	if userVerification == webauthn.UserVerificationRequired && data.flags&flagUserVerified == 0 {
+		return errors.New("user not verified")
+	}
+	return nil
+}
+
+func parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, errors.New("authenticator data is too short")
+	}
+
+	data := &authenticatorData{
+		rpIDHash:  raw[:32],
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	rest := raw[37:]
+
+	if data.flags&flagAttestedCredentialData != 0 {
+		if len(rest) < 18 {
+			return nil, errors.New("attested credential data is too short")
+		}
+		data.aaguid = rest[:16]
+		length := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if length == 0 || length > 1023 || len(rest) < length {
+			return nil, errors.New("invalid credential ID length")
+		}
+		data.credentialID = rest[:length]
+		rest = rest[length:]
+
+		key, remaining, err := parsePublicKey(rest)
+		if err != nil {
+			return nil, err
+		}
+		data.publicKey = key
+		data.rawPublicKey = rest[:len(rest)-len(remaining)]
+		rest = remaining
+	}
+
+	if data.flags&flagExtensionData != 0 {
+		var err error
+		if _, rest, err = decodeCBOR(rest); err != nil {
+			return nil, fmt.Errorf("invalid extension data: %w", err)
+		}
+	}
+
+	if len(rest) != 0 {
+		return nil, errors.New("unexpected trailing authenticator data")
+	}
+	return data, nil
+}
+
+// parseAttestationObject returns the authenticator data of an attestation object. Attestation
+// isn't requested from authenticators, so the attestation statement isn't verified: like with
+// the "none" format, the credential is trusted because the signed in user registers it.
+func parseAttestationObject(raw []byte) ([]byte, error) {
+	item, rest, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("unexpected trailing attestation data")
+	}
+	object, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("attestation object is not a map")
+	}
+
+	authData, _ := object["authData"].([]byte)
+	if authData == nil {
+		return nil, errors.New("incomplete attestation object")
+	}
+	return authData, nil
+}
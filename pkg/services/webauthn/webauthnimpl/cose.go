@@ -0,0 +1,117 @@
+package webauthnimpl
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers (RFC 9053) supported for credentials
+const (
+	algES256 int64 = -7
+	algEdDSA int64 = -8
+	algRS256 int64 = -257
+)
+
+// COSE key parameters
+const (
+	coseKeyType      int64 = 1
+	coseKeyAlg       int64 = 3
+	coseKeyCurve     int64 = -1
+	coseKeyX         int64 = -2
+	coseKeyY         int64 = -3
+	coseKeyRSAN      int64 = -1
+	coseKeyRSAE      int64 = -2
+	coseKeyTypeOKP         = 1
+	coseKeyTypeEC2         = 2
+	coseKeyTypeRSA         = 3
+	coseCurveP256          = 1
+	coseCurveEd25519       = 6
+)
+
+// supportedAlgorithms are offered to authenticators in order of preference
+var supportedAlgorithms = []int64{algES256, algEdDSA, algRS256}
+
+// publicKey is a credential public key with its signature algorithm
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+// parsePublicKey parses a COSE encoded public key and returns the remaining bytes
+func parsePublicKey(data []byte) (*publicKey, []byte, error) {
+	item, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	params, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return nil, nil, errors.New("public key is not a COSE key")
+	}
+
+	kty, _ := params[coseKeyType].(int64)
+	alg, _ := params[coseKeyAlg].(int64)
+
+	switch {
+	case alg == algES256 && kty == coseKeyTypeEC2:
+		crv, _ := params[coseKeyCurve].(int64)
+		x, _ := params[coseKeyX].([]byte)
+		y, _ := params[coseKeyY].([]byte)
+		if crv != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, nil, errors.New("invalid ES256 public key")
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, nil, errors.New("ES256 public key is not on the curve")
+		}
+		return &publicKey{alg: alg, key: key}, rest, nil
+	case alg == algEdDSA && kty == coseKeyTypeOKP:
+		crv, _ := params[coseKeyCurve].(int64)
+		x, _ := params[coseKeyX].([]byte)
+		if crv != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, nil, errors.New("invalid EdDSA public key")
+		}
+		return &publicKey{alg: alg, key: ed25519.PublicKey(x)}, rest, nil
+	case alg == algRS256 && kty == coseKeyTypeRSA:
+		n, _ := params[coseKeyRSAN].([]byte)
+		e, _ := params[coseKeyRSAE].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, nil, errors.New("invalid RS256 public key")
+		}
+		exponent := 0
+		for _, b := range e {
+			exponent = exponent<<8 | int(b)
+		}
+		return &publicKey{alg: alg, key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}}, rest, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported public key algorithm %d with key type %d", alg, kty)
+	}
+}
+
+// verify checks the signature of data with the key
+func (k *publicKey) verify(data, signature []byte) error {
+	switch k.alg {
+	case algES256:
+		digest := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(k.key.(*ecdsa.PublicKey), digest[:], signature) {
+			return errors.New("invalid ES256 signature")
+		}
+		return nil
+	case algEdDSA:
+		if !ed25519.Verify(k.key.(ed25519.PublicKey), data, signature) {
+			return errors.New("invalid EdDSA signature")
+		}
+		return nil
+	case algRS256:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(k.key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature)
+	default:
+		return fmt.Errorf("unsupported signature algorithm %d", k.alg)
+	}
+}
@@ -0,0 +1,470 @@
+package webauthnimpl
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var _ webauthn.Service = (*Service)(nil)
+
+const (
+	challengeKeyPrefix = "webauthn-challenge-"
+	challengeLength    = 32
+
+	challengeRegistration = "registration"
+	challengeLogin        = "login"
+
+	defaultCredentialName = "Passkey"
+	maxCredentialNameLen  = 190
+	// maxCredentialIDLen keeps the encoded credential IDs within their column
+	maxCredentialIDLen = 191
+)
+
+// challenge is the state of a ceremony kept in the remote cache until it's completed
+type challenge struct {
+	Type string `json:"type"`
+	// UserID is the user the ceremony was started for, 0 for passwordless logins
+	// with discoverable credentials and -1 when the login doesn't match any user
+	UserID int64 `json:"userId"`
+}
+
+type Service struct {
+	store       store
+	cache       remotecache.CacheStorage
+	userService user.Service
+	log         log.Logger
+	now         func() time.Time
+
+	enabled      bool
+	passwordless bool
+	secondFactor bool
+	rpID         string
+	rpName       string
+	// origins are the origins allowed to run ceremonies, they must be within rpID
+	origins          []string
+	timeout          time.Duration
+	userVerification webauthn.UserVerification
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sql db.DB,
+	routeRegister routing.RouteRegister,
+	cache *remotecache.RemoteCache,
+	userService user.Service,
+) (*Service, error) {
+	section := cfg.SectionWithEnvOverrides("auth.webauthn")
+	s := &Service{
+		store:            &sqlStore{db: sql},
+		cache:            cache,
+		userService:      userService,
+		log:              log.New("webauthn"),
+		now:              time.Now,
+		enabled:          section.Key("enabled").MustBool(false),
+		passwordless:     section.Key("passwordless_login").MustBool(false),
+		secondFactor:     section.Key("second_factor").MustBool(false),
+		rpName:           section.Key("rp_display_name").MustString("Grafana"),
+		timeout:          section.Key("timeout").MustDuration(5 * time.Minute),
+		userVerification: webauthn.UserVerification(section.Key("user_verification").MustString(string(webauthn.UserVerificationPreferred))),
+	}
+	if !s.userVerification.IsValid() {
+		return nil, fmt.Errorf("invalid auth.webauthn user_verification: %s", s.userVerification)
+	}
+	if s.timeout <= 0 {
+		return nil, fmt.Errorf("invalid auth.webauthn timeout: %s", s.timeout)
+	}
+
+	if !s.enabled {
+		return s, nil
+	}
+
+	// the assertions of the second factor are only verified by the password clients of the
+	// authentication broker, the logins of the legacy authentication would skip them
+	if s.secondFactor && !cfg.AuthBrokerEnabled {
+		return nil, errors.New("auth.webauthn second_factor requires the authentication broker, set broker = true in the auth section")
+	}
+
+	appURL, err := url.Parse(cfg.AppURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse root_url: %w", err)
+	}
+	s.rpID = section.Key("rp_id").MustString(appURL.Hostname())
+	s.origins = util.SplitString(section.Key("origins").MustString(appURL.Scheme + "://" + appURL.Host))
+	if s.rpID == "" {
+		return nil, errors.New("auth.webauthn rp_id is required")
+	}
+	for i, origin := range s.origins {
+		s.origins[i] = strings.TrimSuffix(origin, "/")
+		u, err := url.Parse(s.origins[i])
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid auth.webauthn origin: %s", origin)
+		}
+		if host := u.Hostname(); host != s.rpID && !strings.HasSuffix(host, "."+s.rpID) {
+			return nil, fmt.Errorf("auth.webauthn origin %s isn't within rp_id %s", origin, s.rpID)
+		}
+	}
+
+	s.registerAPIEndpoints(routeRegister)
+
+	return s, nil
+}
+
+func (s *Service) IsEnabled() bool {
+	return s.enabled
+}
+
+func (s *Service) PasswordlessEnabled() bool {
+	return s.enabled && s.passwordless
+}
+
+func (s *Service) SecondFactorEnabled() bool {
+	return s.enabled && s.secondFactor
+}
+
+func (s *Service) BeginRegistration(ctx context.Context, cmd *webauthn.BeginRegistrationCommand) (*webauthn.CreationOptions, error) {
+	credentials, err := s.store.List(ctx, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+	code, err := s.newChallenge(ctx, challenge{Type: challengeRegistration, UserID: cmd.UserID})
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]webauthn.CredentialParameter, 0, len(supportedAlgorithms))
+	for _, alg := range supportedAlgorithms {
+		params = append(params, webauthn.CredentialParameter{Type: "public-key", Alg: alg})
+	}
+	displayName := cmd.DisplayName
+	if displayName == "" {
+		displayName = cmd.Login
+	}
+
+	return &webauthn.CreationOptions{
+		Challenge: code,
+		RP:        webauthn.RelyingParty{ID: s.rpID, Name: s.rpName},
+		User: webauthn.UserEntity{
+			ID:          userHandle(cmd.UserID),
+			Name:        cmd.Login,
+			DisplayName: displayName,
+		},
+		PubKeyCredParams:   params,
+		Timeout:            s.timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(credentials),
+		AuthenticatorSelection: webauthn.AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: s.userVerification,
+		},
+		Attestation: "none",
+	}, nil
+}
+
+func (s *Service) FinishRegistration(ctx context.Context, cmd *webauthn.FinishRegistrationCommand) (*webauthn.CredentialDTO, error) {
+	response := cmd.Credential
+	if response.Type != "public-key" {
+		return nil, webauthn.ErrInvalidCredential.Errorf("unexpected credential type %q", response.Type)
+	}
+	rawClientData, err := decode(response.Response.ClientDataJSON)
+	if err != nil {
+		return nil, webauthn.ErrInvalidCredential.Errorf("invalid client data: %w", err)
+	}
+	rawID, err := decode(response.RawID)
+	if err != nil || len(rawID) == 0 || len(rawID) > maxCredentialIDLen {
+		return nil, webauthn.ErrInvalidCredential.Errorf("invalid credential ID")
+	}
+	attestationObject, err := decode(response.Response.AttestationObject)
+	if err != nil {
+		return nil, webauthn.ErrInvalidCredential.Errorf("invalid attestation object: %w", err)
+	}
+
+	code, err := s.consumeChallenge(ctx, rawClientData, challenge{Type: challengeRegistration, UserID: cmd.UserID})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyClientData(rawClientData, ceremonyCreate, code); err != nil {
+		return nil, webauthn.ErrInvalidCredential.Errorf("%w", err)
+	}
+
+	rawAuthData, err := parseAttestationObject(attestationObject)
+	if err != nil {
+		return nil, webauthn.ErrInvalidCredential.Errorf("%w", err)
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, webauthn.ErrInvalidCredential.Errorf("%w", err)
+	}
+	if err := s.verifyAuthenticatorData(authData, s.userVerification); err != nil {
+		return nil, webauthn.ErrInvalidCredential.Errorf("%w", err)
+	}
+	if authData.publicKey == nil {
+		return nil, webauthn.ErrInvalidCredential.Errorf("missing attested credential data")
+	}
+	if subtle.ConstantTimeCompare(authData.credentialID, rawID) != 1 {
+		return nil, webauthn.ErrInvalidCredential.Errorf("credential ID mismatch")
+	}
+
+	name := strings.TrimSpace(cmd.Name)
+	if name == "" {
+		name = defaultCredentialName
+	}
+	if len(name) > maxCredentialNameLen {
+		return nil, webauthn.ErrInvalidCredential.Errorf("credential name is too long")
+	}
+
+	credential := &webauthn.Credential{
+		UserID:       cmd.UserID,
+		CredentialID: base64.RawURLEncoding.EncodeToString(rawID),
+		PublicKey:    authData.rawPublicKey,
+		SignCount:    int64(authData.signCount),
+		AAGUID:       hex.EncodeToString(authData.aaguid),
+		Name:         name,
+		Transports:   response.Response.Transports,
+		Created:      s.now(),
+	}
+	if err := s.store.Insert(ctx, credential); err != nil {
+		return nil, err
+	}
+	s.log.FromContext(ctx).Info("Registered WebAuthn credential", "userId", cmd.UserID, "credentialId", credential.ID)
+	return toDTO(credential), nil
+}
+
+func (s *Service) BeginLogin(ctx context.Context, cmd *webauthn.BeginLoginCommand) (*webauthn.RequestOptions, error) {
+	state := challenge{Type: challengeLogin}
+	var credentials []*webauthn.Credential
+	if cmd.Login != "" {
+		usr, err := s.userService.GetByLogin(ctx, &user.GetUserByLoginQuery{LoginOrEmail: cmd.Login})
+		switch {
+		case errors.Is(err, user.ErrUserNotFound):
+			// the ceremony can't succeed, but unknown users aren't revealed
+			state.UserID = -1
+		case err != nil:
+			return nil, err
+		default:
+			state.UserID = usr.ID
+			if credentials, err = s.store.List(ctx, usr.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	code, err := s.newChallenge(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	// ceremonies without login are passwordless, which require user verification
+	userVerification := s.userVerification
+	if cmd.Login == "" {
+		userVerification = webauthn.UserVerificationRequired
+	}
+	return &webauthn.RequestOptions{
+		Challenge:        code,
+		Timeout:          s.timeout.Milliseconds(),
+		RPID:             s.rpID,
+		AllowCredentials: descriptors(credentials),
+		UserVerification: userVerification,
+	}, nil
+}
+
+func (s *Service) FinishLogin(ctx context.Context, cmd *webauthn.FinishLoginCommand) (int64, error) {
+	assertion := cmd.Assertion
+	if assertion.Type != "public-key" {
+		return 0, webauthn.ErrInvalidAssertion.Errorf("unexpected credential type %q", assertion.Type)
+	}
+	rawClientData, err := decode(assertion.Response.ClientDataJSON)
+	if err != nil {
+		return 0, webauthn.ErrInvalidAssertion.Errorf("invalid client data: %w", err)
+	}
+	rawID, err := decode(assertion.RawID)
+	if err != nil || len(rawID) == 0 {
+		return 0, webauthn.ErrInvalidAssertion.Errorf("invalid credential ID")
+	}
+	rawAuthData, err := decode(assertion.Response.AuthenticatorData)
+	if err != nil {
+		return 0, webauthn.ErrInvalidAssertion.Errorf("invalid authenticator data: %w", err)
+	}
+	signature, err := decode(assertion.Response.Signature)
+	if err != nil {
+		return 0, webauthn.ErrInvalidAssertion.Errorf("invalid signature: %w", err)
+	}
+	handle, err := decode(assertion.Response.UserHandle)
+	if err != nil {
+		return 0, webauthn.ErrInvalidAssertion.Errorf("invalid user handle: %w", err)
+	}
+
+	code, err := s.consumeChallenge(ctx, rawClientData, challenge{Type: challengeLogin, UserID: cmd.UserID})
+	if err != nil {
+		return 0, err
+	}
+	if err := s.verifyClientData(rawClientData, ceremonyGet, code); err != nil {
+		return 0, webauthn.ErrInvalidAssertion.Errorf("%w", err)
+	}
+
+	credential, err := s.store.GetByCredentialID(ctx, base64.RawURLEncoding.EncodeToString(rawID))
+	if err != nil {
+		if errors.Is(err, webauthn.ErrCredentialNotFound) {
+			return 0, webauthn.ErrInvalidAssertion.Errorf("unknown credential")
+		}
+		return 0, err
+	}
+	if cmd.UserID != 0 && credential.UserID != cmd.UserID {
+		return 0, webauthn.ErrInvalidAssertion.Errorf("credential belongs to another user")
+	}
+	if len(handle) != 0 && string(handle) != strconv.FormatInt(credential.UserID, 10) {
+		return 0, webauthn.ErrInvalidAssertion.Errorf("user handle mismatch")
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, webauthn.ErrInvalidAssertion.Errorf("%w", err)
+	}
+	// passwordless logins are a single factor unless the authenticator verified the user
+	userVerification := s.userVerification
+	if cmd.UserID == 0 {
+		userVerification = webauthn.UserVerificationRequired
+	}
+	if err := s.verifyAuthenticatorData(authData, userVerification); err != nil {
+		return 0, webauthn.ErrInvalidAssertion.Errorf("%w", err)
+	}
+	key, _, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if err := key.verify(signed, signature); err != nil {
+		return 0, webauthn.ErrInvalidAssertion.Errorf("%w", err)
+	}
+
+	// A signature counter that doesn't increase indicates a cloned authenticator,
+	// authenticators without counter always report 0
+	used, err := s.store.UpdateSignCount(ctx, credential.ID, int64(authData.signCount), s.now())
+	if err != nil {
+		return 0, err
+	}
+	if !used {
+		s.log.FromContext(ctx).Warn("WebAuthn signature counter didn't increase, the authenticator may be cloned", "userId", credential.UserID, "credentialId", credential.ID)
+		return 0, webauthn.ErrInvalidAssertion.Errorf("signature counter didn't increase")
+	}
+	return credential.UserID, nil
+}
+
+func (s *Service) HasCredentials(ctx context.Context, userID int64) (bool, error) {
+	credentials, err := s.store.List(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return len(credentials) > 0, nil
+}
+
+func (s *Service) GetCredentials(ctx context.Context, userID int64) ([]*webauthn.CredentialDTO, error) {
+	credentials, err := s.store.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	dtos := make([]*webauthn.CredentialDTO, 0, len(credentials))
+	for _, c := range credentials {
+		dtos = append(dtos, toDTO(c))
+	}
+	return dtos, nil
+}
+
+func (s *Service) DeleteCredential(ctx context.Context, userID, credentialID int64) error {
+	return s.store.Delete(ctx, userID, credentialID)
+}
+
+func (s *Service) newChallenge(ctx context.Context, state challenge) (string, error) {
+	b := make([]byte, challengeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := base64.RawURLEncoding.EncodeToString(b)
+	value, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	if err := s.cache.Set(ctx, challengeKeyPrefix+code, value, s.timeout); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// consumeChallenge removes the challenge of the client data so it can't be replayed, and
+// checks that the ceremony was started for the expected type and user. An expected user
+// of 0 accepts ceremonies started for any user.
+func (s *Service) consumeChallenge(ctx context.Context, rawClientData []byte, expected challenge) (string, error) {
+	var data clientData
+	if err := json.Unmarshal(rawClientData, &data); err != nil || data.Challenge == "" {
+		return "", webauthn.ErrInvalidChallenge.Errorf("missing challenge")
+	}
+	key := challengeKeyPrefix + data.Challenge
+	value, err := s.cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return "", webauthn.ErrInvalidChallenge.Errorf("unknown or expired challenge")
+		}
+		return "", err
+	}
+	if err := s.cache.Delete(ctx, key); err != nil {
+		return "", err
+	}
+
+	var state challenge
+	if err := json.Unmarshal(value, &state); err != nil {
+		return "", err
+	}
+	if state.Type != expected.Type {
+		return "", webauthn.ErrInvalidChallenge.Errorf("challenge was issued for a %s", state.Type)
+	}
+	if state.UserID < 0 || (expected.UserID != 0 && state.UserID != 0 && state.UserID != expected.UserID) {
+		return "", webauthn.ErrInvalidChallenge.Errorf("challenge was issued for another user")
+	}
+	return data.Challenge, nil
+}
+
+// userHandle is the base64url encoded handle identifying the user to authenticators
+func userHandle(userID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(userID, 10)))
+}
+
+// decode decodes base64url values, with or without padding
+func decode(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+func descriptors(credentials []*webauthn.Credential) []webauthn.CredentialDescriptor {
+	result := make([]webauthn.CredentialDescriptor, 0, len(credentials))
+	for _, c := range credentials {
+		result = append(result, webauthn.CredentialDescriptor{Type: "public-key", ID: c.CredentialID, Transports: c.Transports})
+	}
+	return result
+}
+
+func toDTO(c *webauthn.Credential) *webauthn.CredentialDTO {
+	return &webauthn.CredentialDTO{
+		ID:         c.ID,
+		Name:       c.Name,
+		Transports: c.Transports,
+		Created:    c.Created,
+		LastUsedAt: c.LastUsedAt,
+	}
+}
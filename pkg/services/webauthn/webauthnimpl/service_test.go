@@ -0,0 +1,378 @@
+package webauthnimpl
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	testOrigin = "https://grafana.example.com"
+	testRPID   = "grafana.example.com"
+)
+
+func TestProvideService(t *testing.T) {
+	newCfg := func(t *testing.T, secondFactor string) *setting.Cfg {
+		cfg := setting.NewCfg()
+		cfg.AppURL = testOrigin + "/"
+		cfg.AuthBrokerEnabled = false
+		section := cfg.Raw.Section("auth.webauthn")
+		_, err := section.NewKey("enabled", "true")
+		require.NoError(t, err)
+		_, err = section.NewKey("second_factor", secondFactor)
+		require.NoError(t, err)
+		return cfg
+	}
+
+	t.Run("refuses to enable the second factor without the authentication broker", func(t *testing.T) {
+		_, err := ProvideService(newCfg(t, "true"), nil, nil, nil, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("starts without the authentication broker when the second factor is disabled", func(t *testing.T) {
+		_, err := ProvideService(newCfg(t, "false"), nil, routing.NewRouteRegister(), nil, nil)
+		require.NoError(t, err)
+	})
+}
+
+func TestService_Registration(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("registers credentials", func(t *testing.T) {
+		s, store := setupTestService(t)
+		authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+
+		credential := register(t, s, authenticator, 1)
+		require.Equal(t, "YubiKey", credential.Name)
+		require.Equal(t, []string{"internal"}, credential.Transports)
+		require.Len(t, store.credentials, 1)
+
+		options, err := s.BeginRegistration(ctx, &webauthn.BeginRegistrationCommand{UserID: 1, Login: "user"})
+		require.NoError(t, err)
+		require.Equal(t, testRPID, options.RP.ID)
+		require.Equal(t, "user", options.User.DisplayName)
+		require.Len(t, options.ExcludeCredentials, 1)
+		require.Equal(t, store.credentials[0].CredentialID, options.ExcludeCredentials[0].ID)
+	})
+
+	t.Run("rejects replayed challenges", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+
+		options, err := s.BeginRegistration(ctx, &webauthn.BeginRegistrationCommand{UserID: 1, Login: "user"})
+		require.NoError(t, err)
+		response := authenticator.create(t, options)
+		_, err = s.FinishRegistration(ctx, &webauthn.FinishRegistrationCommand{UserID: 1, Credential: response})
+		require.NoError(t, err)
+
+		_, err = s.FinishRegistration(ctx, &webauthn.FinishRegistrationCommand{UserID: 1, Credential: response})
+		require.ErrorIs(t, err, webauthn.ErrInvalidChallenge)
+	})
+
+	t.Run("rejects challenges of other users", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+
+		options, err := s.BeginRegistration(ctx, &webauthn.BeginRegistrationCommand{UserID: 1, Login: "user"})
+		require.NoError(t, err)
+		_, err = s.FinishRegistration(ctx, &webauthn.FinishRegistrationCommand{UserID: 2, Credential: authenticator.create(t, options)})
+		require.ErrorIs(t, err, webauthn.ErrInvalidChallenge)
+	})
+
+	t.Run("rejects other origins", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		authenticator := newSoftAuthenticator(t, "https://evil.example.com", testRPID)
+
+		options, err := s.BeginRegistration(ctx, &webauthn.BeginRegistrationCommand{UserID: 1, Login: "user"})
+		require.NoError(t, err)
+		_, err = s.FinishRegistration(ctx, &webauthn.FinishRegistrationCommand{UserID: 1, Credential: authenticator.create(t, options)})
+		require.ErrorIs(t, err, webauthn.ErrInvalidCredential)
+	})
+
+	t.Run("rejects credentials of other relying parties", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		authenticator := newSoftAuthenticator(t, testOrigin, "example.com")
+
+		options, err := s.BeginRegistration(ctx, &webauthn.BeginRegistrationCommand{UserID: 1, Login: "user"})
+		require.NoError(t, err)
+		_, err = s.FinishRegistration(ctx, &webauthn.FinishRegistrationCommand{UserID: 1, Credential: authenticator.create(t, options)})
+		require.ErrorIs(t, err, webauthn.ErrInvalidCredential)
+	})
+
+	t.Run("rejects login challenges", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+
+		options, err := s.BeginLogin(ctx, &webauthn.BeginLoginCommand{})
+		require.NoError(t, err)
+		_, err = s.FinishRegistration(ctx, &webauthn.FinishRegistrationCommand{UserID: 1, Credential: authenticator.create(t, &webauthn.CreationOptions{
+			Challenge: options.Challenge,
+			User:      webauthn.UserEntity{ID: userHandle(1)},
+		})})
+		require.ErrorIs(t, err, webauthn.ErrInvalidChallenge)
+	})
+}
+
+func TestService_Login(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("verifies assertions of discoverable credentials", func(t *testing.T) {
+		s, store := setupTestService(t)
+		authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+		register(t, s, authenticator, 1)
+
+		options, err := s.BeginLogin(ctx, &webauthn.BeginLoginCommand{})
+		require.NoError(t, err)
+		require.Empty(t, options.AllowCredentials)
+
+		userID, err := s.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: authenticator.get(t, options)})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), userID)
+		require.Equal(t, int64(1), store.credentials[0].SignCount)
+		require.NotNil(t, store.credentials[0].LastUsedAt)
+	})
+
+	t.Run("restricts the ceremony to the credentials of the user", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+		register(t, s, authenticator, 1)
+
+		options, err := s.BeginLogin(ctx, &webauthn.BeginLoginCommand{Login: "user"})
+		require.NoError(t, err)
+		require.Len(t, options.AllowCredentials, 1)
+
+		_, err = s.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: authenticator.get(t, options), UserID: 2})
+		require.ErrorIs(t, err, webauthn.ErrInvalidChallenge)
+	})
+
+	t.Run("rejects credentials of other users", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+		register(t, s, authenticator, 1)
+
+		options, err := s.BeginLogin(ctx, &webauthn.BeginLoginCommand{})
+		require.NoError(t, err)
+		_, err = s.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: authenticator.get(t, options), UserID: 2})
+		require.ErrorIs(t, err, webauthn.ErrInvalidAssertion)
+	})
+
+	t.Run("doesn't reveal unknown users", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		s.userService = &usertest.FakeUserService{ExpectedError: user.ErrUserNotFound}
+		authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+		register(t, s, authenticator, 1)
+
+		options, err := s.BeginLogin(ctx, &webauthn.BeginLoginCommand{Login: "unknown"})
+		require.NoError(t, err)
+		require.Empty(t, options.AllowCredentials)
+
+		_, err = s.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: authenticator.get(t, options)})
+		require.ErrorIs(t, err, webauthn.ErrInvalidChallenge)
+	})
+
+	t.Run("rejects replayed assertions", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+		register(t, s, authenticator, 1)
+
+		options, err := s.BeginLogin(ctx, &webauthn.BeginLoginCommand{})
+		require.NoError(t, err)
+		assertion := authenticator.get(t, options)
+		_, err = s.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: assertion})
+		require.NoError(t, err)
+
+		_, err = s.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: assertion})
+		require.ErrorIs(t, err, webauthn.ErrInvalidChallenge)
+	})
+
+	t.Run("rejects invalid signatures", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+		register(t, s, authenticator, 1)
+
+		options, err := s.BeginLogin(ctx, &webauthn.BeginLoginCommand{})
+		require.NoError(t, err)
+		assertion := authenticator.get(t, options)
+		// sign with another key
+		other := newSoftAuthenticator(t, testOrigin, testRPID)
+		other.credentialID = authenticator.credentialID
+		assertion.Response.Signature = other.get(t, options).Response.Signature
+
+		_, err = s.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: assertion})
+		require.ErrorIs(t, err, webauthn.ErrInvalidAssertion)
+	})
+
+	t.Run("rejects signature counters that don't increase", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+		register(t, s, authenticator, 1)
+
+		options, err := s.BeginLogin(ctx, &webauthn.BeginLoginCommand{})
+		require.NoError(t, err)
+		_, err = s.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: authenticator.get(t, options)})
+		require.NoError(t, err)
+
+		// a clone of the authenticator reuses the counter
+		authenticator.signCount--
+		options, err = s.BeginLogin(ctx, &webauthn.BeginLoginCommand{})
+		require.NoError(t, err)
+		_, err = s.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: authenticator.get(t, options)})
+		require.ErrorIs(t, err, webauthn.ErrInvalidAssertion)
+	})
+
+	t.Run("requires user verification for passwordless logins", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+		register(t, s, authenticator, 1)
+
+		authenticator.flags = flagUserPresent
+		options, err := s.BeginLogin(ctx, &webauthn.BeginLoginCommand{})
+		require.NoError(t, err)
+		require.Equal(t, webauthn.UserVerificationRequired, options.UserVerification)
+		_, err = s.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: authenticator.get(t, options)})
+		require.ErrorIs(t, err, webauthn.ErrInvalidAssertion)
+
+		// the second factor follows the configuration
+		options, err = s.BeginLogin(ctx, &webauthn.BeginLoginCommand{Login: "user"})
+		require.NoError(t, err)
+		require.Equal(t, webauthn.UserVerificationPreferred, options.UserVerification)
+		userID, err := s.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: authenticator.get(t, options), UserID: 1})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), userID)
+	})
+
+	t.Run("requires user verification when configured", func(t *testing.T) {
+		s, _ := setupTestService(t)
+		s.userVerification = webauthn.UserVerificationRequired
+		authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+		register(t, s, authenticator, 1)
+
+		authenticator.flags = flagUserPresent
+		options, err := s.BeginLogin(ctx, &webauthn.BeginLoginCommand{Login: "user"})
+		require.NoError(t, err)
+		_, err = s.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: authenticator.get(t, options), UserID: 1})
+		require.ErrorIs(t, err, webauthn.ErrInvalidAssertion)
+	})
+}
+
+func TestService_DeleteCredential(t *testing.T) {
+	ctx := context.Background()
+	s, _ := setupTestService(t)
+	authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+	credential := register(t, s, authenticator, 1)
+
+	require.ErrorIs(t, s.DeleteCredential(ctx, 2, credential.ID), webauthn.ErrCredentialNotFound)
+	require.NoError(t, s.DeleteCredential(ctx, 1, credential.ID))
+
+	has, err := s.HasCredentials(ctx, 1)
+	require.NoError(t, err)
+	require.False(t, has)
+
+	options, err := s.BeginLogin(ctx, &webauthn.BeginLoginCommand{})
+	require.NoError(t, err)
+	_, err = s.FinishLogin(ctx, &webauthn.FinishLoginCommand{Assertion: authenticator.get(t, options)})
+	require.ErrorIs(t, err, webauthn.ErrInvalidAssertion)
+}
+
+func setupTestService(t *testing.T) (*Service, *fakeStore) {
+	t.Helper()
+
+	store := &fakeStore{}
+	return &Service{
+		store:            store,
+		cache:            remotecache.NewFakeCacheStorage(),
+		userService:      &usertest.FakeUserService{ExpectedUser: &user.User{ID: 1, Login: "user"}},
+		log:              log.NewNopLogger(),
+		now:              func() time.Time { return time.Unix(1700000000, 0) },
+		enabled:          true,
+		passwordless:     true,
+		rpID:             testRPID,
+		rpName:           "Grafana",
+		origins:          []string{testOrigin},
+		timeout:          time.Minute,
+		userVerification: webauthn.UserVerificationPreferred,
+	}, store
+}
+
+// register registers a credential of the authenticator for the user
+func register(t *testing.T, s *Service, authenticator *softAuthenticator, userID int64) *webauthn.CredentialDTO {
+	t.Helper()
+
+	ctx := context.Background()
+	options, err := s.BeginRegistration(ctx, &webauthn.BeginRegistrationCommand{UserID: userID, Login: "user"})
+	require.NoError(t, err)
+	credential, err := s.FinishRegistration(ctx, &webauthn.FinishRegistrationCommand{
+		UserID:     userID,
+		Name:       "YubiKey",
+		Credential: authenticator.create(t, options),
+	})
+	require.NoError(t, err)
+	return credential
+}
+
+type fakeStore struct {
+	credentials []*webauthn.Credential
+}
+
+func (f *fakeStore) List(ctx context.Context, userID int64) ([]*webauthn.Credential, error) {
+	result := make([]*webauthn.Credential, 0)
+	for _, c := range f.credentials {
+		if c.UserID == userID {
+			result = append(result, c)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func (f *fakeStore) GetByCredentialID(ctx context.Context, credentialID string) (*webauthn.Credential, error) {
+	for _, c := range f.credentials {
+		if c.CredentialID == credentialID {
+			copied := *c
+			return &copied, nil
+		}
+	}
+	return nil, webauthn.ErrCredentialNotFound.Errorf("credential not found")
+}
+
+func (f *fakeStore) Insert(ctx context.Context, credential *webauthn.Credential) error {
+	if _, err := f.GetByCredentialID(ctx, credential.CredentialID); err == nil {
+		return webauthn.ErrDuplicateCredential.Errorf("credential is already registered")
+	}
+	credential.ID = int64(len(f.credentials) + 1)
+	copied := *credential
+	f.credentials = append(f.credentials, &copied)
+	return nil
+}
+
+func (f *fakeStore) UpdateSignCount(ctx context.Context, id, signCount int64, usedAt time.Time) (bool, error) {
+	for _, c := range f.credentials {
+		if c.ID == id && (c.SignCount < signCount || (signCount == 0 && c.SignCount == 0)) {
+			c.SignCount = signCount
+			c.LastUsedAt = &usedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeStore) Delete(ctx context.Context, userID, id int64) error {
+	for i, c := range f.credentials {
+		if c.ID == id && c.UserID == userID {
+			f.credentials = append(f.credentials[:i], f.credentials[i+1:]...)
+			return nil
+		}
+	}
+	return webauthn.ErrCredentialNotFound.Errorf("credential not found")
+}
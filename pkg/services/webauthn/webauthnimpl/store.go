@@ -0,0 +1,103 @@
+package webauthnimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+)
+
+type store interface {
+	List(ctx context.Context, userID int64) ([]*webauthn.Credential, error)
+	GetByCredentialID(ctx context.Context, credentialID string) (*webauthn.Credential, error)
+	Insert(ctx context.Context, credential *webauthn.Credential) error
+	// UpdateSignCount records the use of a credential, it returns false when the signature
+	// counter didn't increase and the credential wasn't updated
+	UpdateSignCount(ctx context.Context, id, signCount int64, usedAt time.Time) (bool, error)
+	Delete(ctx context.Context, userID, id int64) error
+}
+
+type sqlStore struct {
+	db db.DB
+}
+
+func (s *sqlStore) List(ctx context.Context, userID int64) ([]*webauthn.Credential, error) {
+	credentials := make([]*webauthn.Credential, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("user_id = ?", userID).Asc("id").Find(&credentials)
+	})
+	return credentials, err
+}
+
+func (s *sqlStore) GetByCredentialID(ctx context.Context, credentialID string) (*webauthn.Credential, error) {
+	credential := webauthn.Credential{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("credential_id = ?", credentialID).Get(&credential)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return webauthn.ErrCredentialNotFound.Errorf("credential not found")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (s *sqlStore) Insert(ctx context.Context, credential *webauthn.Credential) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		exists, err := sess.Where("credential_id = ?", credential.CredentialID).Exist(&webauthn.Credential{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return webauthn.ErrDuplicateCredential.Errorf("credential is already registered")
+		}
+		_, err = sess.Insert(credential)
+		return err
+	})
+}
+
+func (s *sqlStore) UpdateSignCount(ctx context.Context, id, signCount int64, usedAt time.Time) (bool, error) {
+	var updated bool
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		condition := "sign_count < ?"
+		if signCount == 0 {
+			// authenticators without counter report 0 for every signature
+			condition = "sign_count <= ?"
+		}
+		res, err := sess.Exec("UPDATE webauthn_credential SET sign_count = ?, last_used_at = ? WHERE id = ? AND "+condition,
+			signCount, usedAt, id, signCount)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		updated = affected > 0
+		return nil
+	})
+	return updated, err
+}
+
+func (s *sqlStore) Delete(ctx context.Context, userID, id int64) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("DELETE FROM webauthn_credential WHERE id = ? AND user_id = ?", id, userID)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return webauthn.ErrCredentialNotFound.Errorf("credential not found")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,78 @@
+package webauthnimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/webauthn"
+)
+
+func TestIntegrationWebAuthnStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	ss := &sqlStore{db: db.InitTestDB(t)}
+	now := time.Now().Truncate(time.Second)
+
+	credential := &webauthn.Credential{
+		UserID:       1,
+		CredentialID: "credential",
+		PublicKey:    []byte("key"),
+		SignCount:    5,
+		Name:         "YubiKey",
+		Transports:   []string{"usb", "nfc"},
+		Created:      now,
+	}
+
+	t.Run("inserts credentials once", func(t *testing.T) {
+		require.NoError(t, ss.Insert(ctx, credential))
+		require.NotZero(t, credential.ID)
+
+		duplicate := *credential
+		duplicate.ID = 0
+		duplicate.UserID = 2
+		require.ErrorIs(t, ss.Insert(ctx, &duplicate), webauthn.ErrDuplicateCredential)
+
+		credentials, err := ss.List(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, credentials, 1)
+		require.Equal(t, []string{"usb", "nfc"}, credentials[0].Transports)
+
+		credentials, err = ss.List(ctx, 2)
+		require.NoError(t, err)
+		require.Empty(t, credentials)
+	})
+
+	t.Run("updates increasing signature counters", func(t *testing.T) {
+		updated, err := ss.UpdateSignCount(ctx, credential.ID, 5, now)
+		require.NoError(t, err)
+		require.False(t, updated)
+
+		updated, err = ss.UpdateSignCount(ctx, credential.ID, 0, now)
+		require.NoError(t, err)
+		require.False(t, updated)
+
+		updated, err = ss.UpdateSignCount(ctx, credential.ID, 6, now)
+		require.NoError(t, err)
+		require.True(t, updated)
+
+		stored, err := ss.GetByCredentialID(ctx, "credential")
+		require.NoError(t, err)
+		require.Equal(t, int64(6), stored.SignCount)
+		require.NotNil(t, stored.LastUsedAt)
+	})
+
+	t.Run("deletes credentials of the user", func(t *testing.T) {
+		require.ErrorIs(t, ss.Delete(ctx, 2, credential.ID), webauthn.ErrCredentialNotFound)
+		require.NoError(t, ss.Delete(ctx, 1, credential.ID))
+
+		_, err := ss.GetByCredentialID(ctx, "credential")
+		require.ErrorIs(t, err, webauthn.ErrCredentialNotFound)
+	})
+}
@@ -0,0 +1,65 @@
+package webauthntest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/webauthn"
+)
+
+var _ webauthn.Service = new(FakeService)
+
+type FakeService struct {
+	ExpectedEnabled         bool
+	ExpectedPasswordless    bool
+	ExpectedSecondFactor    bool
+	ExpectedCreationOptions *webauthn.CreationOptions
+	ExpectedRequestOptions  *webauthn.RequestOptions
+	ExpectedCredential      *webauthn.CredentialDTO
+	ExpectedCredentials     []*webauthn.CredentialDTO
+	ExpectedUserID          int64
+	ExpectedErr             error
+
+	// FinishLoginCmd is the last command passed to FinishLogin
+	FinishLoginCmd *webauthn.FinishLoginCommand
+}
+
+func (f *FakeService) IsEnabled() bool {
+	return f.ExpectedEnabled
+}
+
+func (f *FakeService) PasswordlessEnabled() bool {
+	return f.ExpectedPasswordless
+}
+
+func (f *FakeService) SecondFactorEnabled() bool {
+	return f.ExpectedSecondFactor
+}
+
+func (f *FakeService) BeginRegistration(ctx context.Context, cmd *webauthn.BeginRegistrationCommand) (*webauthn.CreationOptions, error) {
+	return f.ExpectedCreationOptions, f.ExpectedErr
+}
+
+func (f *FakeService) FinishRegistration(ctx context.Context, cmd *webauthn.FinishRegistrationCommand) (*webauthn.CredentialDTO, error) {
+	return f.ExpectedCredential, f.ExpectedErr
+}
+
+func (f *FakeService) BeginLogin(ctx context.Context, cmd *webauthn.BeginLoginCommand) (*webauthn.RequestOptions, error) {
+	return f.ExpectedRequestOptions, f.ExpectedErr
+}
+
+func (f *FakeService) FinishLogin(ctx context.Context, cmd *webauthn.FinishLoginCommand) (int64, error) {
+	f.FinishLoginCmd = cmd
+	return f.ExpectedUserID, f.ExpectedErr
+}
+
+func (f *FakeService) HasCredentials(ctx context.Context, userID int64) (bool, error) {
+	return len(f.ExpectedCredentials) > 0, f.ExpectedErr
+}
+
+func (f *FakeService) GetCredentials(ctx context.Context, userID int64) ([]*webauthn.CredentialDTO, error) {
+	return f.ExpectedCredentials, f.ExpectedErr
+}
+
+func (f *FakeService) DeleteCredential(ctx context.Context, userID, credentialID int64) error {
+	return f.ExpectedErr
+}
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -209,6 +210,7 @@ type Cfg struct {
 	// Security
 	DisableInitAdminCreation          bool
 	DisableBruteForceLoginProtection  bool
+	TrustedProxies                    []*net.IPNet
 	CookieSecure                      bool
 	CookieSameSiteDisabled            bool
 	CookieSameSiteMode                http.SameSite
@@ -318,6 +320,7 @@ type Cfg struct {
 	JWTAuthJWKSetFile              string
 	JWTAuthAutoSignUp              bool
 	JWTAuthRoleAttributePath       string
+	JWTAuthGroupsAttributePath     string
 	JWTAuthRoleAttributeStrict     bool
 	JWTAuthAllowAssignGrafanaAdmin bool
 	JWTAuthSkipOrgRoleSync         bool
@@ -445,6 +448,11 @@ type Cfg struct {
 	LDAPAllowSignup       bool
 	LDAPActiveSyncEnabled bool
 	LDAPSyncCron          string
+	// LDAP connection pools
+	LDAPPoolMaxIdleConnections int
+	LDAPPoolIdleTimeout        time.Duration
+	LDAPPoolStrategy           string
+	LDAPHealthCheckInterval    time.Duration
 
 	DefaultTheme    string
 	DefaultLanguage string
@@ -474,6 +482,8 @@ type Cfg struct {
 	// LiveAllowedOrigins is a set of origins accepted by Live. If not provided
 	// then Live uses AppURL as the only allowed origin.
 	LiveAllowedOrigins []string
+	// LiveMaxOTLPBodyBytes is the maximum size of the OTLP push requests, after decompression.
+	LiveMaxOTLPBodyBytes int64
 
 	// GitHub OAuth
 	GitHubAuthEnabled     bool
@@ -1161,7 +1171,7 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	cfg.readSqlDataSourceSettings()
 
 	cfg.Storage = readStorageSettings(iniFile)
-	cfg.Search = readSearchSettings(iniFile)
+	cfg.Search = readSearchSettings(iniFile, cfg.DataPath)
 
 	cfg.SecureSocksDSProxy, err = readSecureSocksDSProxySettings(iniFile)
 	if err != nil {
@@ -1259,6 +1269,10 @@ func (cfg *Cfg) readLDAPConfig() {
 	cfg.LDAPSkipOrgRoleSync = ldapSec.Key("skip_org_role_sync").MustBool(false)
 	cfg.LDAPActiveSyncEnabled = ldapSec.Key("active_sync_enabled").MustBool(false)
 	cfg.LDAPAllowSignup = ldapSec.Key("allow_sign_up").MustBool(true)
+	cfg.LDAPPoolMaxIdleConnections = ldapSec.Key("pool_max_idle_connections").MustInt(4)
+	cfg.LDAPPoolIdleTimeout = ldapSec.Key("pool_idle_timeout").MustDuration(5 * time.Minute)
+	cfg.LDAPPoolStrategy = valueAsString(ldapSec, "pool_strategy", "failover")
+	cfg.LDAPHealthCheckInterval = ldapSec.Key("health_check_interval").MustDuration(30 * time.Second)
 }
 
 func (cfg *Cfg) handleAWSConfig() {
@@ -1371,6 +1385,12 @@ func readSecuritySettings(iniFile *ini.File, cfg *Cfg) error {
 	DisableGravatar = security.Key("disable_gravatar").MustBool(true)
 	cfg.DisableBruteForceLoginProtection = security.Key("disable_brute_force_login_protection").MustBool(false)
 
+	trustedProxies, err := parseTrustedProxies(valueAsString(security, "trusted_proxies", ""))
+	if err != nil {
+		return err
+	}
+	cfg.TrustedProxies = trustedProxies
+
 	CookieSecure = security.Key("cookie_secure").MustBool(false)
 	cfg.CookieSecure = CookieSecure
 
@@ -1432,6 +1452,33 @@ func readSecuritySettings(iniFile *ini.File, cfg *Cfg) error {
 
 	return nil
 }
+
+// parseTrustedProxies parses the comma-separated IP addresses and CIDRs of the proxies
+// allowed to set the X-Forwarded-For and X-Real-IP headers
+func parseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range util.SplitString(value) {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: not an IP address or CIDR", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
 func readAuthAzureADSettings(cfg *Cfg) {
 	sec := cfg.SectionWithEnvOverrides("auth.azuread")
 	cfg.AzureADEnabled = sec.Key("enabled").MustBool(false)
@@ -1579,6 +1626,7 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 	cfg.JWTAuthJWKSetFile = valueAsString(authJWT, "jwk_set_file", "")
 	cfg.JWTAuthAutoSignUp = authJWT.Key("auto_sign_up").MustBool(false)
 	cfg.JWTAuthRoleAttributePath = valueAsString(authJWT, "role_attribute_path", "")
+	cfg.JWTAuthGroupsAttributePath = valueAsString(authJWT, "groups_attribute_path", "")
 	cfg.JWTAuthRoleAttributeStrict = authJWT.Key("role_attribute_strict").MustBool(false)
 	cfg.JWTAuthAllowAssignGrafanaAdmin = authJWT.Key("allow_assign_grafana_admin").MustBool(false)
 	cfg.JWTAuthSkipOrgRoleSync = authJWT.Key("skip_org_role_sync").MustBool(false)
@@ -1943,6 +1991,10 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 		return fmt.Errorf("unsupported live HA engine type: %s", cfg.LiveHAEngine)
 	}
 	cfg.LiveHAEngineAddress = section.Key("ha_engine_address").MustString("127.0.0.1:6379")
+	cfg.LiveMaxOTLPBodyBytes = section.Key("max_otlp_body_bytes").MustInt64(10 << 20)
+	if cfg.LiveMaxOTLPBodyBytes <= 0 {
+		return fmt.Errorf("unexpected value %d for [live] max_otlp_body_bytes", cfg.LiveMaxOTLPBodyBytes)
+	}
 
 	var originPatterns []string
 	allowedOrigins := section.Key("allowed_origins").MustString("")
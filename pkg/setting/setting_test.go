@@ -809,3 +809,15 @@ func TestRedactedValue(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTrustedProxies(t *testing.T) {
+	networks, err := parseTrustedProxies("10.0.0.1, 192.168.0.0/16, ::1")
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+	assert.Equal(t, "10.0.0.1/32", networks[0].String())
+	assert.Equal(t, "192.168.0.0/16", networks[1].String())
+	assert.Equal(t, "::1/128", networks[2].String())
+
+	_, err = parseTrustedProxies("proxy.local")
+	require.Error(t, err)
+}
@@ -43,6 +43,9 @@ type Client interface {
 	GetConfiguredFields() ConfiguredFields
 	ExecuteMultisearch(r *MultiSearchRequest) (*MultiSearchResponse, error)
 	MultiSearch() *MultiSearchRequestBuilder
+	ExecuteSearch(r *SearchRequest) (*SearchResponse, error)
+	OpenPointInTime(keepAlive string) (string, error)
+	ClosePointInTime(id string) error
 }
 
 // NewClient creates a new elasticsearch client
@@ -136,9 +139,12 @@ func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body [
 	u.RawQuery = uriQuery
 
 	var req *http.Request
-	if method == http.MethodPost {
+	switch method {
+	case http.MethodPost:
 		req, err = http.NewRequestWithContext(c.ctx, http.MethodPost, u.String(), bytes.NewBuffer(body))
-	} else {
+	case http.MethodDelete:
+		req, err = http.NewRequestWithContext(c.ctx, http.MethodDelete, u.String(), bytes.NewBuffer(body))
+	default:
 		req, err = http.NewRequestWithContext(c.ctx, http.MethodGet, u.String(), nil)
 	}
 	if err != nil {
@@ -238,3 +244,92 @@ func (c *baseClientImpl) getMultiSearchQueryParameters() string {
 func (c *baseClientImpl) MultiSearch() *MultiSearchRequestBuilder {
 	return NewMultiSearchRequestBuilder()
 }
+
+// ExecuteSearch executes a single search request. Requests that carry a point in time
+// are sent without an index, since the point in time already pins the searched indices.
+func (c *baseClientImpl) ExecuteSearch(r *SearchRequest) (*SearchResponse, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	uriPath := "_search"
+	uriQuery := ""
+	if _, ok := r.CustomProps["pit"]; !ok {
+		uriPath = path.Join(strings.Join(c.indices, ","), "_search")
+		uriQuery = "ignore_unavailable=true"
+	}
+
+	res, err := c.executeRequest(http.MethodPost, uriPath, uriQuery, body)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			c.logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	c.logger.Debug("Received search response", "code", res.StatusCode, "status", res.Status, "content-length", res.ContentLength)
+
+	var sr SearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&sr); err != nil {
+		return nil, err
+	}
+
+	return &sr, nil
+}
+
+// OpenPointInTime opens a point in time over the client indices and returns its id
+func (c *baseClientImpl) OpenPointInTime(keepAlive string) (string, error) {
+	uriPath := path.Join(strings.Join(c.indices, ","), "_pit")
+	uriQuery := url.Values{"keep_alive": []string{keepAlive}, "ignore_unavailable": []string{"true"}}.Encode()
+
+	res, err := c.executeRequest(http.MethodPost, uriPath, uriQuery, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			c.logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to open point in time: %s", res.Status)
+	}
+
+	var pit struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&pit); err != nil {
+		return "", err
+	}
+	if pit.ID == "" {
+		return "", fmt.Errorf("failed to open point in time: empty id")
+	}
+
+	return pit.ID, nil
+}
+
+// ClosePointInTime releases a point in time opened with OpenPointInTime
+func (c *baseClientImpl) ClosePointInTime(id string) error {
+	body, err := json.Marshal(map[string]string{"id": id})
+	if err != nil {
+		return err
+	}
+
+	res, err := c.executeRequest(http.MethodDelete, "_pit", "", body)
+	if err != nil {
+		return err
+	}
+	if err := res.Body.Close(); err != nil {
+		c.logger.Warn("Failed to close response body", "err", err)
+	}
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to close point in time: %s", res.Status)
+	}
+
+	return nil
+}
@@ -215,6 +215,67 @@ func TestClient_Index(t *testing.T) {
 	}
 }
 
+func TestClient_PointInTimeSearch(t *testing.T) {
+	requests := []*http.Request{}
+	bodies := []string{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		buf, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(buf))
+
+		rw.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/logs-*/_pit":
+			_, err = rw.Write([]byte(`{ "id": "pit-id" }`))
+		case r.Method == http.MethodDelete:
+			_, err = rw.Write([]byte(`{ "succeeded": true }`))
+		default:
+			_, err = rw.Write([]byte(`{ "hits": { "hits": [{ "_id": "1", "sort": [1526406600000] }] } }`))
+		}
+		require.NoError(t, err)
+	}))
+	t.Cleanup(ts.Close)
+
+	ds := DatasourceInfo{
+		URL:              ts.URL,
+		HTTPClient:       ts.Client(),
+		Database:         "logs-*",
+		ConfiguredFields: ConfiguredFields{TimeField: "@timestamp"},
+	}
+
+	c, err := NewClient(context.Background(), &ds, backend.TimeRange{})
+	require.NoError(t, err)
+
+	id, err := c.OpenPointInTime("1m")
+	require.NoError(t, err)
+	require.Equal(t, "pit-id", id)
+	require.Equal(t, http.MethodPost, requests[0].Method)
+	require.Equal(t, "1m", requests[0].URL.Query().Get("keep_alive"))
+
+	sr, err := NewSearchRequestBuilder(0).AddPointInTime(id, "1m").Build()
+	require.NoError(t, err)
+	res, err := c.ExecuteSearch(sr)
+	require.NoError(t, err)
+	require.Len(t, res.Hits.Hits, 1)
+	require.Equal(t, "/_search", requests[1].URL.Path)
+
+	body, err := simplejson.NewJson([]byte(bodies[1]))
+	require.NoError(t, err)
+	assert.Equal(t, "pit-id", body.GetPath("pit", "id").MustString())
+
+	sr, err = NewSearchRequestBuilder(0).Build()
+	require.NoError(t, err)
+	_, err = c.ExecuteSearch(sr)
+	require.NoError(t, err)
+	require.Equal(t, "/logs-*/_search", requests[2].URL.Path)
+
+	require.NoError(t, c.ClosePointInTime(id))
+	require.Equal(t, http.MethodDelete, requests[3].Method)
+	require.Equal(t, "/_pit", requests[3].URL.Path)
+}
+
 func createMultisearchForTest(t *testing.T, c Client) (*MultiSearchRequest, error) {
 	t.Helper()
 
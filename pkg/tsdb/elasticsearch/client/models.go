@@ -137,6 +137,27 @@ func (f *RangeFilter) MarshalJSON() ([]byte, error) {
 	return json.Marshal(root)
 }
 
+// IdsExclusionFilter represents a filter excluding documents by id
+type IdsExclusionFilter struct {
+	Filter
+	Ids []string
+}
+
+// MarshalJSON returns the JSON encoding of the ids exclusion filter.
+func (f *IdsExclusionFilter) MarshalJSON() ([]byte, error) {
+	root := map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must_not": map[string]interface{}{
+				"ids": map[string]interface{}{
+					"values": f.Ids,
+				},
+			},
+		},
+	}
+
+	return json.Marshal(root)
+}
+
 // Aggregation represents an aggregation
 type Aggregation interface{}
 
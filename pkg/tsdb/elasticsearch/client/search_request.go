@@ -137,6 +137,15 @@ func (b *SearchRequestBuilder) AddSearchAfter(value interface{}) *SearchRequestB
 	return b
 }
 
+// AddPointInTime runs the search request against an open point in time
+func (b *SearchRequestBuilder) AddPointInTime(id string, keepAlive string) *SearchRequestBuilder {
+	b.customProps["pit"] = map[string]string{
+		"id":         id,
+		"keep_alive": keepAlive,
+	}
+	return b
+}
+
 // Query creates and return a query builder
 func (b *SearchRequestBuilder) Query() *QueryBuilder {
 	if b.queryBuilder == nil {
@@ -279,6 +288,18 @@ func (b *FilterQueryBuilder) AddDateRangeFilter(timeField string, lte, gte int64
 	return b
 }
 
+// AddIdsExclusionFilter adds a new filter excluding the documents with the given ids
+func (b *FilterQueryBuilder) AddIdsExclusionFilter(ids []string) *FilterQueryBuilder {
+	if len(ids) == 0 {
+		return b
+	}
+
+	b.filters = append(b.filters, &IdsExclusionFilter{
+		Ids: ids,
+	})
+	return b
+}
+
 // AddQueryStringFilter adds a new query string filter
 func (b *FilterQueryBuilder) AddQueryStringFilter(querystring string, analyseWildcard bool) *FilterQueryBuilder {
 	if len(strings.TrimSpace(querystring)) == 0 {
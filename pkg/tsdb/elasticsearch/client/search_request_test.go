@@ -421,3 +421,16 @@ func TestMultiSearchRequest(t *testing.T) {
 		})
 	})
 }
+
+func TestIdsExclusionFilter(t *testing.T) {
+	b := NewFilterQueryBuilder()
+	b.AddIdsExclusionFilter(nil)
+	b.AddIdsExclusionFilter([]string{"a", "b"})
+	filters, err := b.Build()
+	require.NoError(t, err)
+	require.Len(t, filters, 1)
+
+	body, err := json.Marshal(filters[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"bool":{"must_not":{"ids":{"values":["a","b"]}}}}`, string(body))
+}
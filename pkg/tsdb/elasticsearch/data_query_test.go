@@ -2,6 +2,7 @@ package elasticsearch
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -1741,6 +1742,18 @@ func (c *fakeClient) MultiSearch() *es.MultiSearchRequestBuilder {
 	return c.builder
 }
 
+func (c *fakeClient) ExecuteSearch(r *es.SearchRequest) (*es.SearchResponse, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (c *fakeClient) OpenPointInTime(keepAlive string) (string, error) {
+	return "", fmt.Errorf("not implemented")
+}
+
+func (c *fakeClient) ClosePointInTime(id string) error {
+	return fmt.Errorf("not implemented")
+}
+
 func newDataQuery(body string) (backend.QueryDataRequest, error) {
 	return backend.QueryDataRequest{
 		Queries: []backend.DataQuery{
@@ -12,10 +12,12 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 
 	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -27,6 +29,10 @@ var eslog = log.New("tsdb.elasticsearch")
 type Service struct {
 	httpClientProvider httpclient.Provider
 	im                 instancemgmt.InstanceManager
+
+	// open streams, keyed by datasource UID and channel path
+	streams   map[string]data.FrameJSONCache
+	streamsMu sync.RWMutex
 }
 
 func ProvideService(httpClientProvider httpclient.Provider) *Service {
@@ -35,6 +41,7 @@ func ProvideService(httpClientProvider httpclient.Provider) *Service {
 	return &Service{
 		im:                 datasource.NewInstanceManager(newInstanceSettings(httpClientProvider)),
 		httpClientProvider: httpClientProvider,
+		streams:            make(map[string]data.FrameJSONCache),
 	}
 }
 
@@ -0,0 +1,361 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+const (
+	streamPageSize     = 500
+	streamPITKeepAlive = "1m"
+)
+
+// streamPollInterval is the delay between two polls of a tailed logs query
+var streamPollInterval = 2 * time.Second
+
+func (s *Service) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	_, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, err
+	}
+
+	// Expect tail/${key}
+	if !strings.HasPrefix(req.Path, "tail/") {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, fmt.Errorf("expected tail in channel path")
+	}
+
+	query, err := parseStreamQuery(req.Data)
+	if err != nil {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, err
+	}
+	if !isLogsQuery(query) {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, fmt.Errorf("only logs queries can be streamed")
+	}
+
+	s.streamsMu.RLock()
+	defer s.streamsMu.RUnlock()
+
+	cache, ok := s.streams[streamKey(req.PluginContext, req.Path)]
+	if ok {
+		msg, err := backend.NewInitialData(cache.Bytes(data.IncludeAll))
+		return &backend.SubscribeStreamResponse{
+			Status:      backend.SubscribeStreamStatusOK,
+			InitialData: msg,
+		}, err
+	}
+
+	// nothing yet
+	return &backend.SubscribeStreamResponse{
+		Status: backend.SubscribeStreamStatusOK,
+	}, nil
+}
+
+// Single instance for each channel (results are shared with all listeners)
+func (s *Service) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return err
+	}
+
+	query, err := parseStreamQuery(req.Data)
+	if err != nil {
+		return err
+	}
+	if !isLogsQuery(query) {
+		return fmt.Errorf("only logs queries can be streamed")
+	}
+
+	logger := eslog.FromContext(ctx)
+	key := streamKey(req.PluginContext, req.Path)
+
+	defer func() {
+		s.streamsMu.Lock()
+		delete(s.streams, key)
+		s.streamsMu.Unlock()
+	}()
+
+	tail := newLogsTail(query, dsInfo.ConfiguredFields, time.Now())
+	prev := data.FrameJSONCache{}
+
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Debug("Stop streaming (context canceled)", "path", req.Path)
+			return nil
+		case t := <-ticker.C:
+			client, err := es.NewClient(ctx, dsInfo, backend.TimeRange{From: tail.cursor, To: t})
+			if err != nil {
+				return err
+			}
+
+			frame, position, err := tail.poll(client, t)
+			if err != nil {
+				// Keep polling, the next tick resumes from the same cursor
+				logger.Warn("Failed to poll elasticsearch logs", "path", req.Path, "err", err)
+				continue
+			}
+			if frame == nil {
+				tail.advance(position)
+				continue
+			}
+
+			next, err := data.FrameToJSONCache(frame)
+			if err != nil {
+				return err
+			}
+			if next.SameSchema(&prev) {
+				err = sender.SendBytes(next.Bytes(data.IncludeDataOnly))
+			} else {
+				err = sender.SendFrame(frame, data.IncludeAll)
+			}
+			if err != nil {
+				return err
+			}
+			prev = next
+			// The cursor only moves once the documents are sent
+			tail.advance(position)
+
+			// Cache the latest data for new subscribers
+			s.streamsMu.Lock()
+			s.streams[key] = prev
+			s.streamsMu.Unlock()
+		}
+	}
+}
+
+func (s *Service) PublishStream(_ context.Context, _ *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return &backend.PublishStreamResponse{
+		Status: backend.PublishStreamStatusPermissionDenied,
+	}, nil
+}
+
+func streamKey(pluginCtx backend.PluginContext, path string) string {
+	if pluginCtx.DataSourceInstanceSettings == nil {
+		return path
+	}
+	return fmt.Sprintf("%s/%s", pluginCtx.DataSourceInstanceSettings.UID, path)
+}
+
+func parseStreamQuery(raw json.RawMessage) (*Query, error) {
+	queries, err := parseQuery([]backend.DataQuery{{RefID: "A", JSON: raw}})
+	if err != nil {
+		return nil, err
+	}
+	if len(queries) == 0 || len(queries[0].Metrics) == 0 {
+		return nil, fmt.Errorf("missing logs query in channel")
+	}
+	return queries[0], nil
+}
+
+// tailPosition is the position of a tailed logs query. The cursor is the time of the
+// newest document sent so far, seen holds the ids of the documents sent at that time.
+type tailPosition struct {
+	cursor time.Time
+	seen   map[string]bool
+}
+
+// logsTail keeps the position of a tailed logs query between polls. Each poll searches
+// documents at or after the cursor, excluding the ones already sent at the cursor
+// timestamp, so that many documents sharing a timestamp can't stall the tail.
+type logsTail struct {
+	query            *Query
+	configuredFields es.ConfiguredFields
+	tailPosition
+}
+
+func newLogsTail(query *Query, configuredFields es.ConfiguredFields, start time.Time) *logsTail {
+	return &logsTail{
+		query:            query,
+		configuredFields: configuredFields,
+		tailPosition:     tailPosition{cursor: start, seen: make(map[string]bool)},
+	}
+}
+
+// advance moves the tail to the position returned by poll, once its documents are sent
+func (t *logsTail) advance(position tailPosition) {
+	t.tailPosition = position
+}
+
+// poll fetches the documents added since the previous poll and returns them as a logs
+// frame, or nil when there is nothing new, with the position after these documents.
+// The position of the tail doesn't change until advance is called, so documents that
+// couldn't be sent are searched again. Pages are read through a point in time, which adds
+// a tiebreaker to the timestamp sort, so that search_after is consistent; data sources that
+// cannot open one are read in a single page. At most limit documents are returned, the
+// following ones are sent by the next polls.
+func (t *logsTail) poll(client es.Client, now time.Time) (*data.Frame, tailPosition, error) {
+	limit := stringToIntWithDefaultValue(t.query.Metrics[0].Settings.Get("limit").MustString(), defaultSize)
+
+	pit, err := client.OpenPointInTime(streamPITKeepAlive)
+	if err != nil {
+		pit = ""
+	}
+	defer func() {
+		if pit != "" {
+			_ = client.ClosePointInTime(pit)
+		}
+	}()
+
+	position := t.tailPosition.clone()
+	hits := make([]map[string]interface{}, 0)
+	var searchAfter []interface{}
+	for len(hits) < limit {
+		req, err := t.buildSearchRequest(client.GetConfiguredFields().TimeField, now, pit, searchAfter)
+		if err != nil {
+			return nil, t.tailPosition, err
+		}
+
+		res, err := client.ExecuteSearch(req)
+		if err != nil {
+			return nil, t.tailPosition, err
+		}
+		if res.Error != nil {
+			return nil, t.tailPosition, errors.New(getErrorFromElasticResponse(res))
+		}
+		if res.Hits == nil || len(res.Hits.Hits) == 0 {
+			break
+		}
+
+		for _, hit := range res.Hits.Hits {
+			if len(hits) == limit {
+				break
+			}
+			if position.accept(hit) {
+				hits = append(hits, hit)
+			}
+		}
+
+		if pit == "" || len(res.Hits.Hits) < streamPageSize {
+			break
+		}
+		last, ok := res.Hits.Hits[len(res.Hits.Hits)-1]["sort"].([]interface{})
+		if !ok {
+			break
+		}
+		searchAfter = last
+	}
+
+	if len(hits) == 0 {
+		return nil, position, nil
+	}
+
+	queryRes := backend.DataResponse{}
+	res := &es.SearchResponse{Hits: &es.SearchResponseHits{Hits: hits}}
+	if err := processLogsResponse(res, t.query, t.configuredFields, &queryRes); err != nil {
+		return nil, t.tailPosition, err
+	}
+	if len(queryRes.Frames) == 0 {
+		return nil, position, nil
+	}
+	return queryRes.Frames[0], position, nil
+}
+
+// seenIDs returns the sorted ids of the documents sent at the cursor timestamp
+func (p tailPosition) seenIDs() []string {
+	ids := make([]string, 0, len(p.seen))
+	for id := range p.seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (p tailPosition) clone() tailPosition {
+	seen := make(map[string]bool, len(p.seen))
+	for id := range p.seen {
+		seen[id] = true
+	}
+	return tailPosition{cursor: p.cursor, seen: seen}
+}
+
+// accept reports whether a hit was not sent yet, and moves the cursor forward
+func (p *tailPosition) accept(hit map[string]interface{}) bool {
+	id := fmt.Sprintf("%v", hit["_id"])
+	ts, ok := hitSortTime(hit)
+	if !ok {
+		if p.seen[id] {
+			return false
+		}
+		p.seen[id] = true
+		return true
+	}
+
+	switch {
+	case ts.Before(p.cursor):
+		return false
+	case ts.After(p.cursor):
+		p.cursor = ts
+		p.seen = map[string]bool{id: true}
+		return true
+	default:
+		if p.seen[id] {
+			return false
+		}
+		p.seen[id] = true
+		return true
+	}
+}
+
+func (t *logsTail) buildSearchRequest(timeField string, now time.Time, pit string, searchAfter []interface{}) (*es.SearchRequest, error) {
+	b := es.NewSearchRequestBuilder(t.query.Interval)
+	b.Size(streamPageSize)
+	b.Sort(es.SortOrderAsc, timeField, "boolean")
+	b.AddDocValueField(timeField)
+	b.AddTimeFieldWithStandardizedFormat(timeField)
+	b.AddHighlight()
+	if pit != "" {
+		b.AddPointInTime(pit, streamPITKeepAlive)
+	}
+	for _, value := range searchAfter {
+		b.AddSearchAfter(value)
+	}
+
+	filters := b.Query().Bool().Filter()
+	filters.AddDateRangeFilter(timeField, now.UnixMilli(), t.cursor.UnixMilli(), es.DateFormatEpochMS)
+	filters.AddIdsExclusionFilter(t.seenIDs())
+	filters.AddQueryStringFilter(t.query.RawQuery, true)
+
+	return b.Build()
+}
+
+// hitSortTime reads the document time from the first sort value, which elasticsearch
+// returns as epoch milliseconds when sorting on a date field.
+func hitSortTime(hit map[string]interface{}) (time.Time, bool) {
+	values, ok := hit["sort"].([]interface{})
+	if !ok || len(values) == 0 {
+		return time.Time{}, false
+	}
+	switch v := values[0].(type) {
+	case float64:
+		return time.UnixMilli(int64(v)), true
+	case json.Number:
+		ms, err := v.Int64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(ms), true
+	default:
+		return time.Time{}, false
+	}
+}
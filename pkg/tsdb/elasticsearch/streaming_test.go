@@ -0,0 +1,187 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+type fakeStreamClient struct {
+	*fakeClient
+	pitID          string
+	openedPIT      int
+	closedPIT      []string
+	searchRequests []*es.SearchRequest
+	searchHits     [][]map[string]interface{}
+}
+
+func (c *fakeStreamClient) ExecuteSearch(r *es.SearchRequest) (*es.SearchResponse, error) {
+	c.searchRequests = append(c.searchRequests, r)
+	hits := []map[string]interface{}{}
+	if len(c.searchHits) > 0 {
+		hits = c.searchHits[0]
+		c.searchHits = c.searchHits[1:]
+	}
+	return &es.SearchResponse{Hits: &es.SearchResponseHits{Hits: hits}}, nil
+}
+
+func (c *fakeStreamClient) OpenPointInTime(keepAlive string) (string, error) {
+	c.openedPIT++
+	return c.pitID, nil
+}
+
+func (c *fakeStreamClient) ClosePointInTime(id string) error {
+	c.closedPIT = append(c.closedPIT, id)
+	return nil
+}
+
+func newStreamHit(id string, ts time.Time, line string) map[string]interface{} {
+	return map[string]interface{}{
+		"_id":     id,
+		"_index":  "logs",
+		"sort":    []interface{}{float64(ts.UnixMilli())},
+		"_source": map[string]interface{}{"@timestamp": ts.Format(time.RFC3339Nano), "line": line},
+	}
+}
+
+func TestLogsTail(t *testing.T) {
+	query, err := parseStreamQuery(json.RawMessage(`{
+		"query": "level:error",
+		"metrics": [{ "type": "logs", "id": "1" }]
+	}`))
+	require.NoError(t, err)
+
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Sends new documents and deduplicates the ones at the cursor", func(t *testing.T) {
+		c := &fakeStreamClient{fakeClient: newFakeClient(), pitID: "pit-1"}
+		tail := newLogsTail(query, c.configuredFields, start)
+
+		c.searchHits = [][]map[string]interface{}{{
+			newStreamHit("a", start.Add(time.Second), "first"),
+			newStreamHit("b", start.Add(2*time.Second), "second"),
+		}}
+		frame, position, err := tail.poll(c, start.Add(3*time.Second))
+		require.NoError(t, err)
+		require.NotNil(t, frame)
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, start.Add(2*time.Second), position.cursor.UTC())
+		tail.advance(position)
+		require.Equal(t, []string{"pit-1"}, c.closedPIT)
+
+		// "b" is excluded from the search since it was sent at the cursor, and deduplicated if returned anyway
+		c.searchHits = [][]map[string]interface{}{{
+			newStreamHit("b", start.Add(2*time.Second), "second"),
+			newStreamHit("c", start.Add(2*time.Second), "third"),
+		}}
+		frame, position, err = tail.poll(c, start.Add(4*time.Second))
+		require.NoError(t, err)
+		require.NotNil(t, frame)
+		require.Equal(t, 1, frame.Rows())
+		require.Equal(t, &es.IdsExclusionFilter{Ids: []string{"b"}}, c.searchRequests[1].Query.Bool.Filters[1])
+		tail.advance(position)
+
+		c.searchHits = [][]map[string]interface{}{{
+			newStreamHit("b", start.Add(2*time.Second), "second"),
+			newStreamHit("c", start.Add(2*time.Second), "third"),
+		}}
+		frame, _, err = tail.poll(c, start.Add(5*time.Second))
+		require.NoError(t, err)
+		require.Nil(t, frame)
+	})
+
+	t.Run("Keeps the cursor until the documents are sent", func(t *testing.T) {
+		c := &fakeStreamClient{fakeClient: newFakeClient(), pitID: "pit-4"}
+		tail := newLogsTail(query, c.configuredFields, start)
+
+		hits := []map[string]interface{}{
+			newStreamHit("a", start.Add(time.Second), "first"),
+			newStreamHit("b", start.Add(2*time.Second), "second"),
+		}
+		c.searchHits = [][]map[string]interface{}{hits}
+		_, _, err := tail.poll(c, start.Add(3*time.Second))
+		require.NoError(t, err)
+		require.Equal(t, start, tail.cursor)
+		require.Empty(t, tail.seen)
+
+		// the documents of a poll that wasn't sent are returned by the next poll
+		c.searchHits = [][]map[string]interface{}{hits}
+		frame, _, err := tail.poll(c, start.Add(4*time.Second))
+		require.NoError(t, err)
+		require.Equal(t, 2, frame.Rows())
+		rangeFilter := c.searchRequests[1].Query.Bool.Filters[0].(*es.RangeFilter)
+		require.Equal(t, start.UnixMilli(), rangeFilter.Gte)
+	})
+
+	t.Run("Searches from the cursor with the point in time", func(t *testing.T) {
+		c := &fakeStreamClient{fakeClient: newFakeClient(), pitID: "pit-2"}
+		tail := newLogsTail(query, c.configuredFields, start)
+
+		_, _, err := tail.poll(c, start.Add(time.Minute))
+		require.NoError(t, err)
+		require.Len(t, c.searchRequests, 1)
+
+		sr := c.searchRequests[0]
+		require.Equal(t, map[string]string{"id": "pit-2", "keep_alive": streamPITKeepAlive}, sr.CustomProps["pit"])
+		require.Equal(t, map[string]string{"order": "asc", "unmapped_type": "boolean"}, sr.Sort["@timestamp"])
+
+		rangeFilter := sr.Query.Bool.Filters[0].(*es.RangeFilter)
+		require.Equal(t, start.UnixMilli(), rangeFilter.Gte)
+		require.Equal(t, start.Add(time.Minute).UnixMilli(), rangeFilter.Lte)
+	})
+
+	t.Run("Returns at most limit documents and sends the others on the next poll", func(t *testing.T) {
+		query, err := parseStreamQuery(json.RawMessage(`{
+			"metrics": [{ "type": "logs", "id": "1", "settings": { "limit": "2" } }]
+		}`))
+		require.NoError(t, err)
+
+		c := &fakeStreamClient{fakeClient: newFakeClient(), pitID: "pit-5"}
+		tail := newLogsTail(query, c.configuredFields, start)
+
+		hits := []map[string]interface{}{
+			newStreamHit("a", start.Add(time.Second), "first"),
+			newStreamHit("b", start.Add(time.Second), "second"),
+			newStreamHit("c", start.Add(time.Second), "third"),
+		}
+		c.searchHits = [][]map[string]interface{}{hits}
+		frame, position, err := tail.poll(c, start.Add(2*time.Second))
+		require.NoError(t, err)
+		require.Equal(t, 2, frame.Rows())
+		tail.advance(position)
+
+		c.searchHits = [][]map[string]interface{}{hits[2:]}
+		frame, _, err = tail.poll(c, start.Add(3*time.Second))
+		require.NoError(t, err)
+		require.Equal(t, 1, frame.Rows())
+		require.Equal(t, &es.IdsExclusionFilter{Ids: []string{"a", "b"}}, c.searchRequests[1].Query.Bool.Filters[1])
+	})
+
+	t.Run("Pages with search_after while pages are full", func(t *testing.T) {
+		query, err := parseStreamQuery(json.RawMessage(`{
+			"metrics": [{ "type": "logs", "id": "1", "settings": { "limit": "1000" } }]
+		}`))
+		require.NoError(t, err)
+
+		c := &fakeStreamClient{fakeClient: newFakeClient(), pitID: "pit-3"}
+		tail := newLogsTail(query, c.configuredFields, start)
+
+		page := make([]map[string]interface{}, 0, streamPageSize)
+		for i := 0; i < streamPageSize; i++ {
+			page = append(page, newStreamHit(fmt.Sprintf("doc-%d", i), start.Add(time.Duration(i)*time.Millisecond), "line"))
+		}
+		c.searchHits = [][]map[string]interface{}{page}
+
+		frame, _, err := tail.poll(c, start.Add(time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, streamPageSize, frame.Rows())
+		require.Len(t, c.searchRequests, 2)
+		require.Nil(t, c.searchRequests[0].CustomProps["search_after"])
+		require.Equal(t, page[streamPageSize-1]["sort"], c.searchRequests[1].CustomProps["search_after"])
+	})
+}
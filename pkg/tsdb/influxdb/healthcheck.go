@@ -10,6 +10,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/tsdb/influxdb/flux"
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/influxsql"
 	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
 )
 
@@ -34,6 +35,8 @@ func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthReque
 		return CheckFluxHealth(ctx, dsInfo, req)
 	case influxVersionInfluxQL:
 		return CheckInfluxQLHealth(ctx, dsInfo, s)
+	case influxVersionSQL:
+		return CheckSQLHealth(ctx, dsInfo, req)
 	default:
 		return getHealthCheckMessage(logger, "", errors.New("unknown influx version"))
 	}
@@ -118,6 +121,38 @@ func CheckInfluxQLHealth(ctx context.Context, dsInfo *models.DatasourceInfo, s *
 	return getHealthCheckMessage(logger, "", errors.New("error connecting influxDB influxQL"))
 }
 
+func CheckSQLHealth(ctx context.Context, dsInfo *models.DatasourceInfo,
+	req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	logger := logger.FromContext(ctx)
+	ds, err := influxsql.Query(ctx, dsInfo, backend.QueryDataRequest{
+		PluginContext: req.PluginContext,
+		Queries: []backend.DataQuery{
+			{
+				RefID:         refID,
+				JSON:          []byte(`{ "rawSql": "SELECT 1", "format": "table" }`),
+				Interval:      1 * time.Minute,
+				MaxDataPoints: 423,
+				TimeRange: backend.TimeRange{
+					From: time.Now().AddDate(0, 0, -1),
+					To:   time.Now(),
+				},
+			},
+		},
+	})
+
+	if err != nil {
+		return getHealthCheckMessage(logger, "error performing sql query", err)
+	}
+	if res, ok := ds.Responses[refID]; ok {
+		if res.Error != nil {
+			return getHealthCheckMessage(logger, "error reading InfluxDB", res.Error)
+		}
+		return getHealthCheckMessage(logger, "", nil)
+	}
+
+	return getHealthCheckMessage(logger, "", errors.New("error connecting InfluxDB SQL"))
+}
+
 func getHealthCheckMessage(logger log.Logger, message string, err error) (*backend.CheckHealthResult, error) {
 	if err == nil {
 		return &backend.CheckHealthResult{
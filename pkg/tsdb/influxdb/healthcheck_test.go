@@ -37,6 +37,17 @@ func Test_healthcheck(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, backend.HealthStatusOk, res.Status)
 	})
+	t.Run("should do successful health check for version SQL", func(t *testing.T) {
+		s := GetMockService(influxVersionSQL, RoundTripper{
+			Body: `[{"Int64(1)": 1}]`,
+		})
+		res, err := s.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{},
+			Headers:       nil,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, backend.HealthStatusOk, res.Status)
+	})
 	t.Run("should fail when version is unknown", func(t *testing.T) {
 		s := GetMockService("unknown-influx-version", RoundTripper{
 			Body: `{"results": [{"series": [{"columns": ["name"],"name": "measurements","values": [["cpu"],["disk"],["diskio"],["kernel"],["mem"],["processes"],["swap"],["system"]]}],"statement_id": 0}]}`,
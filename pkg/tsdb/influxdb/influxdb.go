@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/influxdb/flux"
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/influxsql"
 	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
 )
 
@@ -101,6 +102,9 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 	if version == "Flux" {
 		return flux.Query(ctx, dsInfo, *req)
 	}
+	if version == influxVersionSQL {
+		return influxsql.Query(ctx, dsInfo, *req)
+	}
 
 	logger.Debug("Making a non-Flux type query")
 
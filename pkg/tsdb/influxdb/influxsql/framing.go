@@ -0,0 +1,226 @@
+package influxsql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+)
+
+// Timestamps in JSON results are formatted without a time zone and are always UTC
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999"}
+
+// readRows reads a JSON array of row objects. Column order is kept as it appears in the
+// first row, since SQL results are positional and decoding to maps would lose it.
+func readRows(r io.Reader) ([]string, []map[string]interface{}, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, nil, err
+	}
+
+	columns := []string{}
+	known := map[string]bool{}
+	rows := []map[string]interface{}{}
+	for dec.More() {
+		if err := expectDelim(dec, '{'); err != nil {
+			return nil, nil, err
+		}
+		row := map[string]interface{}{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			key, ok := tok.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("unexpected token %v in row", tok)
+			}
+			var value interface{}
+			if err := dec.Decode(&value); err != nil {
+				return nil, nil, err
+			}
+			row[key] = value
+			if !known[key] {
+				known[key] = true
+				columns = append(columns, key)
+			}
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return nil, nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	if err := expectDelim(dec, ']'); err != nil {
+		return nil, nil, err
+	}
+	return columns, rows, nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("unexpected token %v, expected %v", tok, delim)
+	}
+	return nil
+}
+
+// rowsToFrame converts result rows to a frame following the data plane conventions:
+// time series queries return a wide time series frame, other queries a table frame.
+func rowsToFrame(query queryModel, columns []string, rows []map[string]interface{}) (*data.Frame, error) {
+	fields := make([]*data.Field, 0, len(columns))
+	for _, column := range columns {
+		fields = append(fields, newField(column, rows))
+	}
+
+	frame := data.NewFrame(query.RefID, fields...)
+	frame.Meta = &data.FrameMeta{
+		Type:                data.FrameTypeTable,
+		ExecutedQueryString: query.RawSQL,
+	}
+
+	if query.Format != formatTimeSeries {
+		return frame, nil
+	}
+
+	tsSchema := frame.TimeSeriesSchema()
+	switch {
+	case len(rows) == 0:
+	case tsSchema.Type == data.TimeSeriesTypeNot:
+		return nil, fmt.Errorf("time series query requires a time column and at least one numeric column")
+	case tsSchema.Type == data.TimeSeriesTypeLong:
+		// Like the SQL data sources, long results must be ordered by time
+		wide, err := data.LongToWide(frame, query.fillMissing())
+		if err != nil {
+			return nil, err
+		}
+		wide.Meta = frame.Meta
+		frame = wide
+	}
+
+	if fillMissing := query.fillMissing(); fillMissing != nil && len(rows) > 0 {
+		interval := time.Duration(query.FillInterval * float64(time.Second))
+		var err error
+		if frame, err = sqleng.Resample(frame, interval, query.TimeRange, fillMissing); err != nil {
+			frame.AppendNotices(data.Notice{Text: "Failed to resample dataframe", Severity: data.NoticeSeverityWarning})
+		}
+	}
+
+	frame.Meta.Type = data.FrameTypeTimeSeriesWide
+	frame.Meta.TypeVersion = data.FrameTypeVersion{0, 1}
+	return frame, nil
+}
+
+func newField(column string, rows []map[string]interface{}) *data.Field {
+	var sample interface{}
+	for _, row := range rows {
+		if v := row[column]; v != nil {
+			sample = v
+			break
+		}
+	}
+
+	switch v := sample.(type) {
+	case json.Number:
+		if isIntegerColumn(column, rows) {
+			values := make([]*int64, len(rows))
+			for i, row := range rows {
+				if n, ok := row[column].(json.Number); ok {
+					if iv, err := n.Int64(); err == nil {
+						values[i] = &iv
+					}
+				}
+			}
+			return data.NewField(column, nil, values)
+		}
+		values := make([]*float64, len(rows))
+		for i, row := range rows {
+			if n, ok := row[column].(json.Number); ok {
+				if fv, err := n.Float64(); err == nil {
+					values[i] = &fv
+				}
+			}
+		}
+		return data.NewField(column, nil, values)
+	case bool:
+		values := make([]*bool, len(rows))
+		for i, row := range rows {
+			if b, ok := row[column].(bool); ok {
+				values[i] = &b
+			}
+		}
+		return data.NewField(column, nil, values)
+	case string:
+		if _, ok := parseTime(v); ok {
+			values := make([]*time.Time, len(rows))
+			isTime := true
+			for i, row := range rows {
+				s, ok := row[column].(string)
+				if !ok {
+					continue
+				}
+				t, ok := parseTime(s)
+				if !ok {
+					isTime = false
+					break
+				}
+				values[i] = &t
+			}
+			if isTime {
+				return data.NewField(column, nil, values)
+			}
+		}
+	}
+
+	values := make([]*string, len(rows))
+	for i, row := range rows {
+		switch v := row[column].(type) {
+		case nil:
+		case string:
+			values[i] = &v
+		default:
+			b, err := json.Marshal(v)
+			if err == nil {
+				s := string(b)
+				values[i] = &s
+			}
+		}
+	}
+	return data.NewField(column, nil, values)
+}
+
+func isIntegerColumn(column string, rows []map[string]interface{}) bool {
+	for _, row := range rows {
+		n, ok := row[column].(json.Number)
+		if !ok {
+			continue
+		}
+		if strings.ContainsAny(n.String(), ".eE") {
+			return false
+		}
+		if _, err := strconv.ParseInt(n.String(), 10, 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func parseTime(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,124 @@
+package influxsql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
+)
+
+var (
+	glog = log.New("tsdb.influx_sql")
+)
+
+type sqlRequest struct {
+	Database string `json:"db"`
+	Query    string `json:"q"`
+	Format   string `json:"format"`
+}
+
+// Query interpolates SQL queries, executes them with the InfluxDB 3 HTTP query API, and returns the results.
+func Query(ctx context.Context, dsInfo *models.DatasourceInfo, tsdbQuery backend.QueryDataRequest) (
+	*backend.QueryDataResponse, error) {
+	logger := glog.FromContext(ctx)
+	tRes := backend.NewQueryDataResponse()
+
+	if dsInfo.DbName == "" {
+		return &backend.QueryDataResponse{}, fmt.Errorf("missing database in datasource configuration")
+	}
+
+	macroEngine := newInfluxSQLMacroEngine(dsInfo.TimeInterval)
+	for _, query := range tsdbQuery.Queries {
+		qm, err := getQueryModel(query)
+		if err != nil {
+			tRes.Responses[query.RefID] = backend.DataResponse{Error: err}
+			continue
+		}
+
+		rawSQL, err := macroEngine.Interpolate(&query, query.TimeRange, qm.RawSQL)
+		if err != nil {
+			tRes.Responses[query.RefID] = backend.DataResponse{Error: err}
+			continue
+		}
+		// The fill argument of $__timeGroup is set up in the query JSON while interpolating
+		if qm, err = getQueryModel(query); err != nil {
+			tRes.Responses[query.RefID] = backend.DataResponse{Error: err}
+			continue
+		}
+		qm.RawSQL = rawSQL
+
+		logger.Debug("Executing SQL query", "refId", query.RefID, "query", qm.RawSQL)
+		tRes.Responses[query.RefID] = execute(ctx, logger, dsInfo, *qm)
+	}
+	return tRes, nil
+}
+
+func execute(ctx context.Context, logger log.Logger, dsInfo *models.DatasourceInfo, query queryModel) backend.DataResponse {
+	req, err := createRequest(ctx, dsInfo, query.RawSQL)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+
+	res, err := dsInfo.HTTPClient.Do(req)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return backend.DataResponse{Error: fmt.Errorf("InfluxDB returned error status: %s %s", res.Status, bytes.TrimSpace(body))}
+	}
+
+	columns, rows, err := readRows(res.Body)
+	if err != nil {
+		return backend.DataResponse{Error: fmt.Errorf("error reading query response: %w", err)}
+	}
+
+	frame, err := rowsToFrame(query, columns, rows)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+	return backend.DataResponse{Frames: []*data.Frame{frame}}
+}
+
+func createRequest(ctx context.Context, dsInfo *models.DatasourceInfo, query string) (*http.Request, error) {
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "api/v3/query_sql")
+
+	body, err := json.Marshal(sqlRequest{
+		Database: dsInfo.DbName,
+		Query:    query,
+		Format:   "json",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if dsInfo.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+dsInfo.Token)
+	}
+	return req, nil
+}
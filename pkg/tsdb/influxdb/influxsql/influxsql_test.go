@@ -0,0 +1,135 @@
+package influxsql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
+)
+
+func executeMockedQuery(t *testing.T, body string, rawSQL string, format queryFormat) (backend.DataResponse, sqlRequest, http.Header) {
+	t.Helper()
+
+	var received sqlRequest
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v3/query_sql", r.URL.Path)
+		headers = r.Header.Clone()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	dsInfo := &models.DatasourceInfo{
+		HTTPClient: server.Client(),
+		URL:        server.URL,
+		DbName:     "testdb",
+		Token:      "sometoken",
+	}
+	model, err := json.Marshal(map[string]string{"rawSql": rawSQL, "format": string(format)})
+	require.NoError(t, err)
+
+	res, err := Query(context.Background(), dsInfo, backend.QueryDataRequest{
+		Queries: []backend.DataQuery{{
+			RefID: "A",
+			JSON:  model,
+			TimeRange: backend.TimeRange{
+				From: time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC),
+				To:   time.Date(2023, 3, 1, 11, 0, 0, 0, time.UTC),
+			},
+			Interval: time.Minute,
+		}},
+	})
+	require.NoError(t, err)
+	return res.Responses["A"], received, headers
+}
+
+func TestExecutor(t *testing.T) {
+	t.Run("table query", func(t *testing.T) {
+		body := `[{"host":"a","usage":1.5,"count":3,"ok":true},{"host":"b","usage":2,"count":4,"ok":null}]`
+		res, req, headers := executeMockedQuery(t, body, "SELECT * FROM cpu WHERE $__timeFilter(time)", formatTable)
+		require.NoError(t, res.Error)
+
+		require.Equal(t, "testdb", req.Database)
+		require.Equal(t, "json", req.Format)
+		require.Equal(t, "SELECT * FROM cpu WHERE time >= '2023-03-01T10:00:00Z' AND time <= '2023-03-01T11:00:00Z'", req.Query)
+		require.Equal(t, "Bearer sometoken", headers.Get("Authorization"))
+
+		require.Len(t, res.Frames, 1)
+		frame := res.Frames[0]
+		require.Equal(t, data.FrameTypeTable, frame.Meta.Type)
+		require.Equal(t, req.Query, frame.Meta.ExecutedQueryString)
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, []string{"host", "usage", "count", "ok"}, []string{frame.Fields[0].Name, frame.Fields[1].Name, frame.Fields[2].Name, frame.Fields[3].Name})
+		require.Equal(t, data.FieldTypeNullableString, frame.Fields[0].Type())
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[1].Type())
+		require.Equal(t, data.FieldTypeNullableInt64, frame.Fields[2].Type())
+		require.Equal(t, data.FieldTypeNullableBool, frame.Fields[3].Type())
+	})
+
+	t.Run("time series query is converted to wide frames", func(t *testing.T) {
+		body := `[
+			{"time":"2023-03-01T10:00:00","host":"a","usage":1},
+			{"time":"2023-03-01T10:00:00","host":"b","usage":2},
+			{"time":"2023-03-01T10:01:00","host":"a","usage":3},
+			{"time":"2023-03-01T10:01:00","host":"b","usage":4}
+		]`
+		res, _, _ := executeMockedQuery(t, body, "SELECT time, host, usage FROM cpu ORDER BY time", formatTimeSeries)
+		require.NoError(t, res.Error)
+
+		require.Len(t, res.Frames, 1)
+		frame := res.Frames[0]
+		require.Equal(t, data.FrameTypeTimeSeriesWide, frame.Meta.Type)
+		require.Equal(t, 2, frame.Rows())
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, time.Date(2023, 3, 1, 10, 1, 0, 0, time.UTC), frame.Fields[0].At(1))
+		require.Equal(t, data.Labels{"host": "a"}, frame.Fields[1].Labels)
+		require.Equal(t, data.Labels{"host": "b"}, frame.Fields[2].Labels)
+	})
+
+	t.Run("time series query with fill", func(t *testing.T) {
+		body := `[{"time":"2023-03-01T10:00:00","usage":1.5},{"time":"2023-03-01T10:30:00","usage":3.5}]`
+		res, req, _ := executeMockedQuery(t, body, "SELECT $__timeGroupAlias(time, '10m', 0), avg(usage) AS usage FROM cpu GROUP BY 1 ORDER BY 1", formatTimeSeries)
+		require.NoError(t, res.Error)
+		require.Equal(t, `SELECT date_bin(INTERVAL '600000000000 nanoseconds', time, TIMESTAMP '1970-01-01T00:00:00Z') AS "time", avg(usage) AS usage FROM cpu GROUP BY 1 ORDER BY 1`, req.Query)
+
+		require.Len(t, res.Frames, 1)
+		frame := res.Frames[0]
+		require.Equal(t, 7, frame.Rows())
+		values := make([]float64, 0, frame.Rows())
+		for i := 0; i < frame.Rows(); i++ {
+			v, ok := frame.Fields[1].ConcreteAt(i)
+			require.True(t, ok)
+			values = append(values, v.(float64))
+		}
+		require.Equal(t, []float64{1.5, 0, 0, 3.5, 0, 0, 0}, values)
+	})
+
+	t.Run("time series query without time column", func(t *testing.T) {
+		res, _, _ := executeMockedQuery(t, `[{"host":"a"}]`, "SELECT host FROM cpu", formatTimeSeries)
+		require.Error(t, res.Error)
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "table 'cpu' not found", http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		dsInfo := &models.DatasourceInfo{HTTPClient: server.Client(), URL: server.URL, DbName: "testdb"}
+		res, err := Query(context.Background(), dsInfo, backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: "A", JSON: []byte(`{"rawSql": "SELECT * FROM cpu"}`)}},
+		})
+		require.NoError(t, err)
+		require.ErrorContains(t, res.Responses["A"].Error, "table 'cpu' not found")
+	})
+}
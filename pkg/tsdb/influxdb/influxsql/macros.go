@@ -0,0 +1,113 @@
+package influxsql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+)
+
+const rsIdentifier = `([_a-zA-Z0-9]+)`
+const sExpr = `\$` + rsIdentifier + `\(([^\)]*)\)`
+
+var macroExp = regexp.MustCompile(sExpr)
+
+// influxSQLMacroEngine interpolates the macros shared with the SQL data sources into
+// the SQL dialect of InfluxDB 3, which is based on Apache DataFusion.
+type influxSQLMacroEngine struct {
+	*sqleng.SQLMacroEngineBase
+	timeInterval string
+}
+
+func newInfluxSQLMacroEngine(timeInterval string) *influxSQLMacroEngine {
+	return &influxSQLMacroEngine{
+		SQLMacroEngineBase: sqleng.NewSQLMacroEngineBase(),
+		timeInterval:       timeInterval,
+	}
+}
+
+func (m *influxSQLMacroEngine) Interpolate(query *backend.DataQuery, timeRange backend.TimeRange, sql string) (string, error) {
+	// Variables are interpolated first, so they can be used as macro arguments
+	sql, err := sqleng.Interpolate(*query, timeRange, m.timeInterval, sql)
+	if err != nil {
+		return "", err
+	}
+
+	var macroError error
+	sql = m.ReplaceAllStringSubmatchFunc(macroExp, sql, func(groups []string) string {
+		args := strings.Split(groups[2], ",")
+		for i, arg := range args {
+			args[i] = strings.Trim(arg, " ")
+		}
+		res, err := m.evaluateMacro(timeRange, query, groups[1], args)
+		if err != nil && macroError == nil {
+			macroError = err
+			return "macro_error()"
+		}
+		return res
+	})
+
+	if macroError != nil {
+		return "", macroError
+	}
+
+	return sql, nil
+}
+
+func (m *influxSQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *backend.DataQuery, name string, args []string) (string, error) {
+	from := timeRange.From.UTC().Format(time.RFC3339Nano)
+	to := timeRange.To.UTC().Format(time.RFC3339Nano)
+
+	switch name {
+	case "__time":
+		if len(args) == 0 || args[0] == "" {
+			return "", fmt.Errorf("missing time column argument for macro %v", name)
+		}
+		return fmt.Sprintf("%s AS \"time\"", args[0]), nil
+	case "__timeFilter":
+		if len(args) == 0 || args[0] == "" {
+			return "", fmt.Errorf("missing time column argument for macro %v", name)
+		}
+		return fmt.Sprintf("%s >= '%s' AND %s <= '%s'", args[0], from, args[0], to), nil
+	case "__timeFrom":
+		return fmt.Sprintf("'%s'", from), nil
+	case "__timeTo":
+		return fmt.Sprintf("'%s'", to), nil
+	case "__timeGroup":
+		if len(args) < 2 {
+			return "", fmt.Errorf("macro %v needs time column and interval and optional fill value", name)
+		}
+		interval, err := gtime.ParseInterval(strings.Trim(args[1], `'`))
+		if err != nil {
+			return "", fmt.Errorf("error parsing interval %v", args[1])
+		}
+		if len(args) == 3 {
+			if err := sqleng.SetupFillmode(query, interval, args[2]); err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("date_bin(INTERVAL '%d nanoseconds', %s, TIMESTAMP '1970-01-01T00:00:00Z')", interval.Nanoseconds(), args[0]), nil
+	case "__timeGroupAlias":
+		tg, err := m.evaluateMacro(timeRange, query, "__timeGroup", args)
+		if err != nil {
+			return "", err
+		}
+		return tg + " AS \"time\"", nil
+	case "__unixEpochFrom":
+		return fmt.Sprintf("%d", timeRange.From.UTC().Unix()), nil
+	case "__unixEpochTo":
+		return fmt.Sprintf("%d", timeRange.To.UTC().Unix()), nil
+	case "__unixEpochNanoFilter":
+		if len(args) == 0 || args[0] == "" {
+			return "", fmt.Errorf("missing time column argument for macro %v", name)
+		}
+		return fmt.Sprintf("%s >= %d AND %s <= %d", args[0], timeRange.From.UTC().UnixNano(), args[0], timeRange.To.UTC().UnixNano()), nil
+	default:
+		return "", fmt.Errorf("unknown macro %q", name)
+	}
+}
@@ -0,0 +1,93 @@
+package influxsql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacroEngine(t *testing.T) {
+	engine := newInfluxSQLMacroEngine("10s")
+	timeRange := backend.TimeRange{
+		From: time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC),
+		To:   time.Date(2023, 3, 1, 11, 0, 0, 0, time.UTC),
+	}
+	query := &backend.DataQuery{
+		TimeRange:     timeRange,
+		Interval:      time.Minute,
+		MaxDataPoints: 100,
+	}
+
+	tests := []struct {
+		name   string
+		before string
+		after  string
+	}{
+		{
+			name:   "time filter",
+			before: "SELECT * FROM cpu WHERE $__timeFilter(time)",
+			after:  "SELECT * FROM cpu WHERE time >= '2023-03-01T10:00:00Z' AND time <= '2023-03-01T11:00:00Z'",
+		},
+		{
+			name:   "time from and to",
+			before: "WHERE time BETWEEN $__timeFrom() AND $__timeTo()",
+			after:  "WHERE time BETWEEN '2023-03-01T10:00:00Z' AND '2023-03-01T11:00:00Z'",
+		},
+		{
+			name:   "time group alias",
+			before: "SELECT $__timeGroupAlias(time, '5m'), avg(usage) FROM cpu",
+			after:  `SELECT date_bin(INTERVAL '300000000000 nanoseconds', time, TIMESTAMP '1970-01-01T00:00:00Z') AS "time", avg(usage) FROM cpu`,
+		},
+		{
+			name:   "time group with interval variable",
+			before: "SELECT $__timeGroup(time, $__interval) FROM cpu",
+			after:  "SELECT date_bin(INTERVAL '60000000000 nanoseconds', time, TIMESTAMP '1970-01-01T00:00:00Z') FROM cpu",
+		},
+		{
+			name:   "unix epoch",
+			before: "WHERE $__unixEpochNanoFilter(ts) AND a > $__unixEpochFrom() AND a < $__unixEpochTo()",
+			after:  "WHERE ts >= 1677664800000000000 AND ts <= 1677668400000000000 AND a > 1677664800 AND a < 1677668400",
+		},
+		{
+			name:   "interval variables",
+			before: "$__interval $__interval_ms",
+			after:  "1m 60000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, err := engine.Interpolate(query, timeRange, tt.before)
+			require.NoError(t, err)
+			require.Equal(t, tt.after, sql)
+		})
+	}
+
+	t.Run("unknown macro", func(t *testing.T) {
+		_, err := engine.Interpolate(query, timeRange, "SELECT $__unknown(time)")
+		require.Error(t, err)
+	})
+
+	t.Run("time group with fill", func(t *testing.T) {
+		fillQuery := &backend.DataQuery{JSON: []byte(`{"rawSql": "", "format": "time_series"}`), TimeRange: timeRange}
+		sql, err := engine.Interpolate(fillQuery, timeRange, "SELECT $__timeGroup(time, '5m', previous) FROM cpu")
+		require.NoError(t, err)
+		require.Equal(t, "SELECT date_bin(INTERVAL '300000000000 nanoseconds', time, TIMESTAMP '1970-01-01T00:00:00Z') FROM cpu", sql)
+
+		qm, err := getQueryModel(*fillQuery)
+		require.NoError(t, err)
+		require.True(t, qm.Fill)
+		require.Equal(t, 300.0, qm.FillInterval)
+		require.Equal(t, &data.FillMissing{Mode: data.FillModePrevious}, qm.fillMissing())
+
+		_, err = engine.Interpolate(fillQuery, timeRange, "SELECT $__timeGroup(time, '5m', zero) FROM cpu")
+		require.Error(t, err)
+	})
+
+	t.Run("missing time group interval", func(t *testing.T) {
+		_, err := engine.Interpolate(query, timeRange, "SELECT $__timeGroup(time)")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,71 @@
+package influxsql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type queryFormat string
+
+const (
+	formatTable      queryFormat = "table"
+	formatTimeSeries queryFormat = "time_series"
+)
+
+// queryModel represents a query.
+type queryModel struct {
+	RawSQL string      `json:"rawSql"`
+	Format queryFormat `json:"format"`
+
+	// Set by the fill argument of the $__timeGroup macro
+	Fill         bool    `json:"fill"`
+	FillInterval float64 `json:"fillInterval"`
+	FillMode     string  `json:"fillMode"`
+	FillValue    float64 `json:"fillValue"`
+
+	// Not from JSON
+	RefID         string            `json:"-"`
+	TimeRange     backend.TimeRange `json:"-"`
+	MaxDataPoints int64             `json:"-"`
+	Interval      time.Duration     `json:"-"`
+}
+
+func getQueryModel(query backend.DataQuery) (*queryModel, error) {
+	model := &queryModel{}
+	if err := json.Unmarshal(query.JSON, model); err != nil {
+		return nil, fmt.Errorf("error reading query: %w", err)
+	}
+	if model.Format == "" {
+		model.Format = formatTable
+	}
+	if model.Format != formatTable && model.Format != formatTimeSeries {
+		return nil, fmt.Errorf("unsupported query format %q", model.Format)
+	}
+
+	// Copy directly from the well typed query
+	model.RefID = query.RefID
+	model.TimeRange = query.TimeRange
+	model.MaxDataPoints = query.MaxDataPoints
+	model.Interval = query.Interval
+	return model, nil
+}
+
+// fillMissing returns how the missing values of time series are filled, or nil when they aren't
+func (qm *queryModel) fillMissing() *data.FillMissing {
+	if !qm.Fill {
+		return nil
+	}
+	switch strings.ToLower(qm.FillMode) {
+	case "previous":
+		return &data.FillMissing{Mode: data.FillModePrevious}
+	case "value":
+		return &data.FillMissing{Mode: data.FillModeValue, Value: qm.FillValue}
+	default:
+		return &data.FillMissing{Mode: data.FillModeNull}
+	}
+}
@@ -0,0 +1,338 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// Warning codes returned by Lint
+const (
+	WarningRateOnNonCounter       = "rate-on-non-counter"
+	WarningAggregationNoBy        = "aggregation-without-grouping"
+	WarningUnboundedRegex         = "unbounded-regex"
+	WarningGaugeFunctionOnCounter = "gauge-function-on-counter"
+)
+
+// Position is a location in the linted query. Offsets are zero based byte offsets,
+// lines and columns are one based.
+type Position struct {
+	Start  int `json:"start"`
+	End    int `json:"end"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is a syntax error found when parsing the query
+type Error struct {
+	Message  string   `json:"message"`
+	Position Position `json:"position"`
+}
+
+// Warning is a valid construct that is likely a mistake
+type Warning struct {
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+	Position Position `json:"position"`
+}
+
+// Result is the outcome of linting a query
+type Result struct {
+	Errors   []Error   `json:"errors"`
+	Warnings []Warning `json:"warnings"`
+}
+
+// Valid reports whether the query parsed without syntax errors
+func (r Result) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// Err returns the first syntax error as a Go error, or nil for valid queries
+func (r Result) Err() error {
+	if r.Valid() {
+		return nil
+	}
+	e := r.Errors[0]
+	return fmt.Errorf("%d:%d: parse error: %s", e.Position.Line, e.Position.Column, e.Message)
+}
+
+// Grafana variables are not PromQL. They are replaced before parsing with a value of the
+// same length, so that positions reported by the parser match the original query.
+var (
+	durationVariables = []string{"$__rate_interval", "${__rate_interval}", "$__interval", "${__interval}", "$__range", "${__range}"}
+	numberVariables   = []string{"$__interval_ms", "${__interval_ms}", "$__range_ms", "${__range_ms}", "$__range_s", "${__range_s}"}
+	// Template variables used as a range or subquery duration, e.g. rate(x[$interval])
+	rangeVariableRegexp = regexp.MustCompile(`\[\s*(\$\w+|\$\{\w+\}|\[\[\w+\]\])\s*(:|\])`)
+	// Other template variables, e.g. $job, ${job:regex} or [[job]]
+	templateVariableRegexp = regexp.MustCompile(`^(\$\w+|\$\{[^}]+\}|\[\[[^\]]+\]\])`)
+	counterSuffixRegexp    = regexp.MustCompile(`(_total|_count|_sum|_bucket)$`)
+)
+
+var counterFunctions = map[string]bool{
+	"rate":     true,
+	"irate":    true,
+	"increase": true,
+	"resets":   true,
+}
+
+var gaugeFunctions = map[string]bool{
+	"delta":          true,
+	"idelta":         true,
+	"deriv":          true,
+	"predict_linear": true,
+}
+
+// Template variables may stand for a label name, a metric name or a number. Queries with variables
+// are parsed with each placeholder in turn, and are not linted when none of them fits.
+var variablePlaceholders = []string{"x", "1"}
+
+// Lint parses a PromQL query and reports syntax errors along with warnings about common mistakes.
+func Lint(query string) Result {
+	result := Result{Errors: []Error{}, Warnings: []Warning{}}
+	input := replaceVariables(query)
+
+	variables := templateVariables(input)
+	var expr parser.Expr
+	var err error
+	if len(variables) == 0 {
+		expr, err = parser.ParseExpr(input)
+	} else {
+		for _, placeholder := range variablePlaceholders {
+			if expr, err = parser.ParseExpr(replaceRanges(input, variables, placeholder)); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			// The values of the variables are unknown, the error may not be in the query
+			return result
+		}
+	}
+	if err != nil {
+		var parseErrs parser.ParseErrors
+		if errors.As(err, &parseErrs) {
+			for _, e := range parseErrs {
+				result.Errors = append(result.Errors, Error{
+					Message:  e.Err.Error(),
+					Position: position(query, e.PositionRange),
+				})
+			}
+		} else {
+			result.Errors = append(result.Errors, Error{Message: err.Error(), Position: position(query, parser.PositionRange{})})
+		}
+		return result
+	}
+
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		switch n := node.(type) {
+		case *parser.Call:
+			result.Warnings = append(result.Warnings, lintCall(query, n, variables)...)
+		case *parser.BinaryExpr:
+			result.Warnings = append(result.Warnings, lintBinaryExpr(query, n)...)
+		case *parser.VectorSelector:
+			if overlaps(n.PosRange, variables) {
+				return nil
+			}
+			for _, m := range n.LabelMatchers {
+				if isUnboundedRegex(m) {
+					result.Warnings = append(result.Warnings, Warning{
+						Code:     WarningUnboundedRegex,
+						Message:  fmt.Sprintf("regex %q on label %q matches almost every value and selects a large number of series", m.Value, m.Name),
+						Position: position(query, n.PosRange),
+					})
+				}
+			}
+		}
+		return nil
+	})
+
+	return result
+}
+
+func lintCall(query string, call *parser.Call, variables []parser.PositionRange) []Warning {
+	name := call.Func.Name
+	if !counterFunctions[name] && !gaugeFunctions[name] {
+		return nil
+	}
+
+	warnings := []Warning{}
+	for _, arg := range call.Args {
+		ms, ok := unwrapParens(arg).(*parser.MatrixSelector)
+		if !ok {
+			continue
+		}
+		vs, ok := ms.VectorSelector.(*parser.VectorSelector)
+		if !ok || vs.Name == "" || overlaps(vs.PosRange, variables) {
+			continue
+		}
+
+		isCounter := counterSuffixRegexp.MatchString(vs.Name)
+		switch {
+		case counterFunctions[name] && !isCounter:
+			warnings = append(warnings, Warning{
+				Code:     WarningRateOnNonCounter,
+				Message:  fmt.Sprintf("%s() should only be used with counters, but %q does not have a counter suffix", name, vs.Name),
+				Position: position(query, call.PosRange),
+			})
+		case gaugeFunctions[name] && strings.HasSuffix(vs.Name, "_total"):
+			warnings = append(warnings, Warning{
+				Code:     WarningGaugeFunctionOnCounter,
+				Message:  fmt.Sprintf("%s() should only be used with gauges, use rate() or increase() for counter %q", name, vs.Name),
+				Position: position(query, call.PosRange),
+			})
+		}
+	}
+	return warnings
+}
+
+// lintBinaryExpr warns when only one operand of a vector binary expression is aggregated
+// without grouping. That operand has no labels left, so it cannot match the other one.
+func lintBinaryExpr(query string, expr *parser.BinaryExpr) []Warning {
+	if expr.LHS.Type() != parser.ValueTypeVector || expr.RHS.Type() != parser.ValueTypeVector {
+		return nil
+	}
+
+	lhs, lhsOk := ungroupedAggregation(expr.LHS)
+	rhs, rhsOk := ungroupedAggregation(expr.RHS)
+	if lhsOk == rhsOk {
+		return nil
+	}
+
+	agg := lhs
+	if rhsOk {
+		agg = rhs
+	}
+	return []Warning{{
+		Code:     WarningAggregationNoBy,
+		Message:  fmt.Sprintf("%s() without 'by' or 'without' removes all labels, so it cannot match the labels of the other operand", agg.Op),
+		Position: position(query, agg.PosRange),
+	}}
+}
+
+func ungroupedAggregation(expr parser.Expr) (*parser.AggregateExpr, bool) {
+	agg, ok := unwrapParens(expr).(*parser.AggregateExpr)
+	if !ok || agg.Op.IsAggregatorWithParam() || agg.Without || len(agg.Grouping) > 0 {
+		return nil, false
+	}
+	return agg, true
+}
+
+func isUnboundedRegex(m *labels.Matcher) bool {
+	if m.Type != labels.MatchRegexp {
+		return false
+	}
+	v := m.Value
+	return v == ".*" || v == ".+" || strings.HasPrefix(v, ".*") || strings.HasPrefix(v, ".+")
+}
+
+func unwrapParens(expr parser.Expr) parser.Expr {
+	for {
+		p, ok := expr.(*parser.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = p.Expr
+	}
+}
+
+func replaceVariables(query string) string {
+	// Number variables first, since $__interval is a prefix of $__interval_ms
+	for _, v := range numberVariables {
+		query = strings.ReplaceAll(query, v, pad("1", len(v)))
+	}
+	for _, v := range durationVariables {
+		query = strings.ReplaceAll(query, v, pad("1m", len(v)))
+	}
+	return rangeVariableRegexp.ReplaceAllStringFunc(query, func(s string) string {
+		m := rangeVariableRegexp.FindStringSubmatch(s)
+		return strings.Replace(s, m[1], pad("1m", len(m[1])), 1)
+	})
+}
+
+// templateVariables returns the positions of the template variables left in the query, outside
+// of string literals and comments.
+func templateVariables(query string) []parser.PositionRange {
+	var variables []parser.PositionRange
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '$' || c == '[':
+			if loc := templateVariableRegexp.FindStringIndex(query[i:]); loc != nil {
+				variables = append(variables, parser.PositionRange{Start: parser.Pos(i), End: parser.Pos(i + loc[1])})
+				i += loc[1] - 1
+			}
+		}
+	}
+	return variables
+}
+
+// replaceRanges replaces the given ranges of the query with a placeholder of the same length.
+func replaceRanges(query string, ranges []parser.PositionRange, placeholder string) string {
+	var b strings.Builder
+	last := 0
+	for _, r := range ranges {
+		b.WriteString(query[last:r.Start])
+		b.WriteString(pad(placeholder, int(r.End-r.Start)))
+		last = int(r.End)
+	}
+	b.WriteString(query[last:])
+	return b.String()
+}
+
+func overlaps(r parser.PositionRange, ranges []parser.PositionRange) bool {
+	for _, v := range ranges {
+		if v.Start < r.End && r.Start < v.End {
+			return true
+		}
+	}
+	return false
+}
+
+func pad(value string, length int) string {
+	if len(value) >= length {
+		return value
+	}
+	return value + strings.Repeat(" ", length-len(value))
+}
+
+func position(query string, r parser.PositionRange) Position {
+	start := int(r.Start)
+	if start < 0 || start > len(query) {
+		start = 0
+	}
+	end := int(r.End)
+	if end < start || end > len(query) {
+		end = start
+	}
+
+	line, lastLineBreak := 1, -1
+	for i, c := range query[:start] {
+		if c == '\n' {
+			lastLineBreak = i
+			line++
+		}
+	}
+
+	return Position{
+		Start:  start,
+		End:    end,
+		Line:   line,
+		Column: start - lastLineBreak,
+	}
+}
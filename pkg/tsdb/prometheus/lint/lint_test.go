@@ -0,0 +1,94 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	t.Run("valid query has no errors or warnings", func(t *testing.T) {
+		res := Lint(`sum by (job) (rate(http_requests_total{job="api"}[5m]))`)
+		require.True(t, res.Valid())
+		require.NoError(t, res.Err())
+		require.Empty(t, res.Warnings)
+	})
+
+	t.Run("syntax error has a position", func(t *testing.T) {
+		res := Lint("sum(rate(http_requests_total[5m])\n  by (job)")
+		require.False(t, res.Valid())
+		require.NotEmpty(t, res.Errors)
+		require.Equal(t, "unexpected <by> in aggregation", res.Errors[0].Message)
+		require.Equal(t, 2, res.Errors[0].Position.Line)
+		require.Equal(t, 3, res.Errors[0].Position.Column)
+		require.Error(t, res.Err())
+	})
+
+	t.Run("grafana variables are accepted and keep positions", func(t *testing.T) {
+		res := Lint(`rate(http_requests_total[$__rate_interval]) * $__interval_ms + rate(up_total[$interval]) + rate(x_total[${__range}])`)
+		require.True(t, res.Valid(), res.Errors)
+
+		res = Lint(`rate(http_requests_total[$__interval]) +`)
+		require.False(t, res.Valid())
+		require.Equal(t, len(`rate(http_requests_total[$__interval]) +`), res.Errors[0].Position.Start)
+	})
+
+	t.Run("template variables are replaced by a placeholder fitting their context", func(t *testing.T) {
+		for _, query := range []string{
+			`sum by ($groupBy) (rate(http_requests_total{job="$job"}[5m]))`,
+			`sum by (${labels:csv}) (up) > $threshold`,
+			`topk([[limit]], up)`,
+			`rate($metric[5m])`,
+			`$metric{job=~"$job"}`,
+		} {
+			res := Lint(query)
+			require.True(t, res.Valid(), query, res.Errors)
+			require.Empty(t, res.Warnings, query)
+		}
+	})
+
+	t.Run("queries are not linted when no placeholder fits the template variables", func(t *testing.T) {
+		res := Lint(`up{job=$job} offset $offset`)
+		require.True(t, res.Valid())
+		require.Empty(t, res.Warnings)
+	})
+
+	t.Run("syntax errors are still reported with template variables in strings", func(t *testing.T) {
+		res := Lint(`sum(up{job="$job"}`)
+		require.False(t, res.Valid())
+	})
+
+	t.Run("rate on a gauge", func(t *testing.T) {
+		res := Lint(`rate(node_memory_free_bytes[5m])`)
+		require.True(t, res.Valid())
+		require.Len(t, res.Warnings, 1)
+		require.Equal(t, WarningRateOnNonCounter, res.Warnings[0].Code)
+		require.Equal(t, 0, res.Warnings[0].Position.Start)
+		require.Equal(t, len(`rate(node_memory_free_bytes[5m])`), res.Warnings[0].Position.End)
+	})
+
+	t.Run("deriv on a counter", func(t *testing.T) {
+		res := Lint(`deriv(http_requests_total[5m])`)
+		require.Len(t, res.Warnings, 1)
+		require.Equal(t, WarningGaugeFunctionOnCounter, res.Warnings[0].Code)
+	})
+
+	t.Run("aggregation without grouping in a binary expression", func(t *testing.T) {
+		res := Lint(`rate(errors_total[5m]) / sum(rate(requests_total[5m]))`)
+		require.Len(t, res.Warnings, 1)
+		require.Equal(t, WarningAggregationNoBy, res.Warnings[0].Code)
+		require.Equal(t, len(`rate(errors_total[5m]) / `), res.Warnings[0].Position.Start)
+
+		res = Lint(`sum(rate(errors_total[5m])) / sum(rate(requests_total[5m]))`)
+		require.Empty(t, res.Warnings)
+
+		res = Lint(`sum(rate(errors_total[5m])) > 10`)
+		require.Empty(t, res.Warnings)
+	})
+
+	t.Run("unbounded regex", func(t *testing.T) {
+		res := Lint(`up{job=~".*", instance=~"host-.*"}`)
+		require.Len(t, res.Warnings, 1)
+		require.Equal(t, WarningUnboundedRegex, res.Warnings[0].Code)
+	})
+}
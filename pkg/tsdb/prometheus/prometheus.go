@@ -116,6 +116,14 @@ func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceReq
 		return sender.Send(vResp)
 	}
 
+	if strings.EqualFold(req.Path, "lint") {
+		resp, err := i.resource.Lint(req)
+		if err != nil {
+			return err
+		}
+		return sender.Send(resp)
+	}
+
 	resp, err := i.resource.Execute(ctx, req)
 	if err != nil {
 		return err
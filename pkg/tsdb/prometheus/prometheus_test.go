@@ -2,6 +2,7 @@ package prometheus
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"testing"
@@ -15,6 +16,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/lint"
 )
 
 type fakeSender struct{}
@@ -23,6 +25,15 @@ func (sender *fakeSender) Send(resp *backend.CallResourceResponse) error {
 	return nil
 }
 
+type recordingSender struct {
+	resp *backend.CallResourceResponse
+}
+
+func (sender *recordingSender) Send(resp *backend.CallResourceResponse) error {
+	sender.resp = resp
+	return nil
+}
+
 type fakeRoundtripper struct {
 	Req *http.Request
 }
@@ -105,6 +116,38 @@ func TestService(t *testing.T) {
 				require.Equal(t, []byte("match%5B%5D: ALERTS\nstart: 1655271408\nend: 1655293008"), body)
 				require.Equal(t, "http://localhost:9090/api/v1/series", httpProvider.Roundtripper.Req.URL.String())
 			})
+
+			t.Run("lints query without calling prometheus", func(t *testing.T) {
+				httpProvider := &fakeHTTPClientProvider{}
+				service := &Service{
+					im: datasource.NewInstanceManager(newInstanceSettings(httpProvider, &setting.Cfg{}, &featuremgmt.FeatureManager{}, nil)),
+				}
+
+				req := &backend.CallResourceRequest{
+					PluginContext: backend.PluginContext{
+						DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+							Type:     "prometheus",
+							URL:      "http://localhost:9090",
+							JSONData: []byte("{}"),
+						},
+					},
+					Path:   "lint",
+					Method: http.MethodPost,
+					URL:    "lint",
+					Body:   []byte(`{"query": "rate(node_load1[5m]"}`),
+				}
+
+				sender := &recordingSender{}
+				err := service.CallResource(context.Background(), req, sender)
+				require.NoError(t, err)
+				require.Nil(t, httpProvider.Roundtripper.Req)
+				require.Equal(t, http.StatusOK, sender.resp.Status)
+
+				var res lint.Result
+				require.NoError(t, json.Unmarshal(sender.resp.Body, &res))
+				require.Len(t, res.Errors, 1)
+				require.Equal(t, 1, res.Errors[0].Position.Line)
+			})
 		})
 	})
 }
@@ -45,6 +45,7 @@ type QueryData struct {
 	enableWideSeries   bool
 	enableDataplane    bool
 	exemplarSampler    func() exemplar.Sampler
+	splitter           *splitter
 }
 
 func New(
@@ -67,6 +68,16 @@ func New(
 
 	promClient := client.NewClient(httpClient, httpMethod, settings.URL)
 
+	querySplitter, err := newSplitter(jsonData)
+	if err != nil {
+		return nil, err
+	}
+	enableWideSeries := features.IsEnabled(featuremgmt.FlagPrometheusWideSeries)
+	if enableWideSeries {
+		// Wide frames hold every series in one frame, which cannot be merged across splits
+		querySplitter = nil
+	}
+
 	// standard deviation sampler is the default for backwards compatibility
 	exemplarSampler := exemplar.NewStandardDeviationSampler
 
@@ -82,9 +93,10 @@ func New(
 		TimeInterval:       timeInterval,
 		ID:                 settings.ID,
 		URL:                settings.URL,
-		enableWideSeries:   features.IsEnabled(featuremgmt.FlagPrometheusWideSeries),
+		enableWideSeries:   enableWideSeries,
 		enableDataplane:    features.IsEnabled(featuremgmt.FlagPrometheusDataplane),
 		exemplarSampler:    exemplarSampler,
+		splitter:           querySplitter,
 	}, nil
 }
 
@@ -129,7 +141,12 @@ func (s *QueryData) fetch(ctx context.Context, client *client.Client, q *models.
 	}
 
 	if q.RangeQuery {
-		res := s.rangeQuery(traceCtx, client, q, headers)
+		var res backend.DataResponse
+		if s.splitter != nil {
+			res = s.splitRangeQuery(traceCtx, client, q, headers)
+		} else {
+			res = s.rangeQuery(traceCtx, client, q, headers)
+		}
 		if res.Error != nil {
 			if dr.Error == nil {
 				dr.Error = res.Error
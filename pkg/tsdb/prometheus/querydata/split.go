@@ -0,0 +1,227 @@
+package querydata
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+
+	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/client"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/models"
+)
+
+const (
+	defaultSplitMaxConcurrency = 4
+	// Splits ending within this window of now may still receive samples, so they are never cached
+	splitCacheSettleWindow = 10 * time.Minute
+	splitCacheTTL          = 10 * time.Minute
+)
+
+// splitter runs long range queries as several step-aligned sub-range queries.
+// Sub-range boundaries are aligned to multiples of the split interval, so the same
+// complete splits are produced on every dashboard refresh and can be served from cache.
+type splitter struct {
+	interval       time.Duration
+	maxConcurrency int
+	cache          *cache.Cache
+}
+
+func newSplitter(jsonData map[string]interface{}) (*splitter, error) {
+	raw, ok := jsonData["querySplitInterval"].(string)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+
+	interval, err := intervalv2.ParseIntervalStringToTimeDuration(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query split interval %q: %w", raw, err)
+	}
+	if interval <= 0 {
+		return nil, nil
+	}
+
+	maxConcurrency := defaultSplitMaxConcurrency
+	if v, ok := jsonData["querySplitMaxConcurrency"].(float64); ok && v > 0 {
+		maxConcurrency = int(v)
+	}
+
+	s := &splitter{
+		interval:       interval,
+		maxConcurrency: maxConcurrency,
+	}
+	if cacheEnabled, ok := jsonData["querySplitCache"].(bool); !ok || cacheEnabled {
+		s.cache = cache.New(splitCacheTTL, 2*splitCacheTTL)
+	}
+	return s, nil
+}
+
+// split returns the sub-range queries for q, or nil when q fits in a single split.
+func (s *splitter) split(q *models.Query) []*models.Query {
+	tr := q.TimeRange()
+	if tr.Step <= 0 || s.interval <= tr.Step || tr.End.Sub(tr.Start) <= s.interval {
+		return nil
+	}
+
+	queries := []*models.Query{}
+	for start := tr.Start; !start.After(tr.End); {
+		next := models.AlignTimeRange(start, s.interval, q.UtcOffsetSec).Add(s.interval)
+		// Sub-ranges start on the step grid of the original query, so points are not shifted
+		next = models.AlignTimeRange(next, tr.Step, q.UtcOffsetSec)
+		if !next.After(start) {
+			next = start.Add(tr.Step)
+		}
+
+		end := next.Add(-tr.Step)
+		if end.After(tr.End) {
+			end = tr.End
+		}
+
+		sub := *q
+		sub.Start = start
+		sub.End = end
+		queries = append(queries, &sub)
+
+		start = next
+	}
+
+	return queries
+}
+
+// cacheKey includes every field of the query that changes the frames of the response:
+// the ref ID and the legend format are set on the frames, exemplar queries don't get
+// the empty frame of the responses without series.
+func (s *splitter) cacheKey(q *models.Query) string {
+	return fmt.Sprintf("%q|%q|%q|%t|%d|%d|%d|%d",
+		q.RefId, q.Expr, q.LegendFormat, q.ExemplarQuery, q.Step, q.UtcOffsetSec, q.Start.UnixMilli(), q.End.UnixMilli())
+}
+
+// identityHeaders are the headers that forward the user identity to Prometheus: the OAuth
+// pass-through tokens, the forwarded cookies and the user header.
+var identityHeaders = []string{"Authorization", "X-ID-Token", "Cookie", "X-Grafana-User"}
+
+// cacheable reports whether a split result is complete and safe to share between requests.
+// Queries that forward the user identity to Prometheus may see different data per user.
+func (s *splitter) cacheable(q *models.Query, headers map[string]string, now time.Time) bool {
+	if s.cache == nil || !q.End.Before(now.Add(-splitCacheSettleWindow)) {
+		return false
+	}
+	for k := range headers {
+		if isIdentityHeader(k) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentityHeader(name string) bool {
+	for _, h := range identityHeaders {
+		if strings.EqualFold(name, h) {
+			return true
+		}
+	}
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, "x-grafana-") && strings.HasSuffix(name, "-forwarded")
+}
+
+func (s *QueryData) splitRangeQuery(ctx context.Context, c *client.Client, q *models.Query, headers map[string]string) backend.DataResponse {
+	splits := s.splitter.split(q)
+	if len(splits) == 0 {
+		return s.rangeQuery(ctx, c, q, headers)
+	}
+
+	logger := s.log.FromContext(ctx)
+	logger.Debug("Splitting range query", "query", q.Expr, "splits", len(splits), "interval", s.splitter.interval)
+
+	now := time.Now()
+	responses := make([]backend.DataResponse, len(splits))
+	sem := make(chan struct{}, s.splitter.maxConcurrency)
+	wg := sync.WaitGroup{}
+
+	for i, sub := range splits {
+		cacheable := s.splitter.cacheable(sub, headers, now)
+		if cacheable {
+			if cached, ok := s.splitter.cache.Get(s.splitter.cacheKey(sub)); ok {
+				responses[i] = cached.(backend.DataResponse)
+				continue
+			}
+		}
+
+		wg.Add(1)
+		go func(i int, sub *models.Query, cacheable bool) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				responses[i] = backend.DataResponse{Error: ctx.Err()}
+				return
+			}
+
+			res := s.rangeQuery(ctx, c, sub, headers)
+			if res.Error == nil && cacheable {
+				s.splitter.cache.SetDefault(s.splitter.cacheKey(sub), res)
+			}
+			responses[i] = res
+		}(i, sub, cacheable)
+	}
+	wg.Wait()
+
+	for _, res := range responses {
+		if res.Error != nil {
+			return backend.DataResponse{Error: res.Error}
+		}
+	}
+
+	return backend.DataResponse{Frames: mergeSplitFrames(responses)}
+}
+
+// mergeSplitFrames joins the frames of consecutive splits. Frames describing the same
+// series, i.e. with the same name, fields and labels, are concatenated in time order.
+func mergeSplitFrames(responses []backend.DataResponse) data.Frames {
+	merged := data.Frames{}
+	byKey := map[string]*data.Frame{}
+
+	for _, res := range responses {
+		for _, frame := range res.Frames {
+			if len(frame.Fields) == 0 {
+				continue
+			}
+
+			key := splitFrameKey(frame)
+			existing, ok := byKey[key]
+			if !ok {
+				// Copy the frame, cached split frames must not be modified
+				existing = frame.EmptyCopy()
+				existing.Meta = frame.Meta
+				byKey[key] = existing
+				merged = append(merged, existing)
+			}
+			for row := 0; row < frame.Rows(); row++ {
+				existing.AppendRow(frame.RowCopy(row)...)
+			}
+		}
+	}
+
+	// Keep the metadata frame that is returned for queries without results
+	if len(merged) == 0 && len(responses) > 0 {
+		return responses[0].Frames
+	}
+
+	return merged
+}
+
+func splitFrameKey(frame *data.Frame) string {
+	parts := []string{frame.Name}
+	for _, field := range frame.Fields {
+		labels := field.Labels.String()
+		parts = append(parts, fmt.Sprintf("%s{%s}%s", field.Name, labels, field.Type()))
+	}
+	return strings.Join(parts, "|")
+}
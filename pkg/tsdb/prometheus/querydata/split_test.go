@@ -0,0 +1,177 @@
+package querydata_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/querydata"
+)
+
+// matrixRoundTripper answers range queries with one sample per step for a single series
+type matrixRoundTripper struct {
+	mu     sync.Mutex
+	ranges [][2]int64
+}
+
+func (rt *matrixRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+	start, _ := strconv.ParseFloat(req.Form.Get("start"), 64)
+	end, _ := strconv.ParseFloat(req.Form.Get("end"), 64)
+	step, _ := strconv.ParseFloat(req.Form.Get("step"), 64)
+
+	rt.mu.Lock()
+	rt.ranges = append(rt.ranges, [2]int64{int64(start), int64(end)})
+	rt.mu.Unlock()
+
+	values := []string{}
+	for ts := start; ts <= end; ts += step {
+		values = append(values, fmt.Sprintf(`[%d,"%d"]`, int64(ts), int64(ts)))
+	}
+	body := fmt.Sprintf(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up","job":"prometheus"},"values":[%s]}]}}`, strings.Join(values, ","))
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+	}, nil
+}
+
+func (rt *matrixRoundTripper) requests() int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return len(rt.ranges)
+}
+
+func setupSplit(t *testing.T, jsonData string) (*querydata.QueryData, *matrixRoundTripper) {
+	t.Helper()
+
+	rt := &matrixRoundTripper{}
+	settings := backend.DataSourceInstanceSettings{
+		URL:      "http://localhost:9090",
+		JSONData: json.RawMessage(jsonData),
+	}
+	features := &fakeFeatureToggles{flags: map[string]bool{}}
+
+	qd, err := querydata.New(&http.Client{Transport: rt}, features, tracing.InitializeTracerForTest(), settings, &logtest.Fake{})
+	require.NoError(t, err)
+	return qd, rt
+}
+
+func splitQueryRequest(from, to time.Time) *backend.QueryDataRequest {
+	return &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: from, To: to},
+				JSON:      json.RawMessage(`{"expr": "up", "range": true, "interval": "1h"}`),
+			},
+		},
+	}
+}
+
+func TestPrometheus_splitRangeQuery(t *testing.T) {
+	from := time.Date(2022, 1, 1, 5, 0, 0, 0, time.UTC)
+	to := from.Add(72 * time.Hour)
+
+	t.Run("does not split when no split interval is configured", func(t *testing.T) {
+		qd, rt := setupSplit(t, `{"timeInterval": "1h", "httpMethod": "POST"}`)
+
+		res, err := qd.Execute(context.Background(), splitQueryRequest(from, to))
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+		require.Equal(t, 1, rt.requests())
+	})
+
+	t.Run("splits on day boundaries and merges the series", func(t *testing.T) {
+		qd, rt := setupSplit(t, `{"timeInterval": "1h", "httpMethod": "POST", "querySplitInterval": "1d", "querySplitMaxConcurrency": 2}`)
+
+		res, err := qd.Execute(context.Background(), splitQueryRequest(from, to))
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+
+		// 2022-01-01 05:00 to 2022-01-04 05:00 covers four calendar days
+		require.Equal(t, 4, rt.requests())
+		for _, r := range rt.ranges {
+			require.Equal(t, r[0]/86400, r[1]/86400, "split must not cross a day boundary")
+		}
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Equal(t, 73, frames[0].Rows())
+
+		var prev time.Time
+		for i := 0; i < frames[0].Rows(); i++ {
+			ts := frames[0].Fields[0].At(i).(time.Time)
+			if i > 0 {
+				require.Equal(t, time.Hour, ts.Sub(prev))
+			}
+			prev = ts
+		}
+	})
+
+	t.Run("serves complete splits from the cache", func(t *testing.T) {
+		qd, rt := setupSplit(t, `{"timeInterval": "1h", "httpMethod": "POST", "querySplitInterval": "1d"}`)
+
+		_, err := qd.Execute(context.Background(), splitQueryRequest(from, to))
+		require.NoError(t, err)
+		require.Equal(t, 4, rt.requests())
+
+		res, err := qd.Execute(context.Background(), splitQueryRequest(from, to.Add(time.Hour)))
+		require.NoError(t, err)
+		// Only the last split has a different range
+		require.Equal(t, 5, rt.requests())
+		require.Equal(t, 74, res.Responses["A"].Frames[0].Rows())
+	})
+
+	t.Run("does not share the cache between queries with different frames", func(t *testing.T) {
+		qd, rt := setupSplit(t, `{"timeInterval": "1h", "httpMethod": "POST", "querySplitInterval": "1d"}`)
+
+		_, err := qd.Execute(context.Background(), splitQueryRequest(from, to))
+		require.NoError(t, err)
+		require.Equal(t, 4, rt.requests())
+
+		req := splitQueryRequest(from, to)
+		req.Queries[0].RefID = "B"
+		_, err = qd.Execute(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, 8, rt.requests())
+
+		req = splitQueryRequest(from, to)
+		req.Queries[0].JSON = json.RawMessage(`{"expr": "up", "range": true, "interval": "1h", "legendFormat": "{{job}}"}`)
+		_, err = qd.Execute(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, 12, rt.requests())
+	})
+
+	t.Run("does not cache queries forwarding the user identity", func(t *testing.T) {
+		for _, header := range []string{"Authorization", "X-ID-Token", "Cookie", "X-Grafana-User", "X-Grafana-Id-Token-Forwarded"} {
+			t.Run(header, func(t *testing.T) {
+				qd, rt := setupSplit(t, `{"timeInterval": "1h", "httpMethod": "POST", "querySplitInterval": "1d"}`)
+
+				req := splitQueryRequest(from, to)
+				req.Headers = map[string]string{header: "secret"}
+				_, err := qd.Execute(context.Background(), req)
+				require.NoError(t, err)
+				_, err = qd.Execute(context.Background(), req)
+				require.NoError(t, err)
+				require.Equal(t, 8, rt.requests())
+			})
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package resource
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/lint"
+)
+
+type lintRequest struct {
+	Query string `json:"query"`
+}
+
+// Lint validates the PromQL query of a resource request without sending it to Prometheus.
+// The query is read from the JSON body, or from the query string for GET requests.
+func (r *Resource) Lint(req *backend.CallResourceRequest) (*backend.CallResourceResponse, error) {
+	var lr lintRequest
+	if len(req.Body) > 0 {
+		if err := json.Unmarshal(req.Body, &lr); err != nil {
+			return jsonResponse(http.StatusBadRequest, map[string]string{"message": "invalid lint request: " + err.Error()})
+		}
+	} else if u, err := url.Parse(req.URL); err == nil {
+		lr.Query = u.Query().Get("query")
+	}
+
+	if lr.Query == "" {
+		return jsonResponse(http.StatusBadRequest, map[string]string{"message": "missing query"})
+	}
+
+	return jsonResponse(http.StatusOK, lint.Lint(lr.Query))
+}
+
+func jsonResponse(status int, body interface{}) (*backend.CallResourceResponse, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &backend.CallResourceResponse{
+		Status:  status,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    b,
+	}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
@@ -62,6 +63,13 @@ func getRowFillValues(f *data.Frame, tsSchema data.TimeSeriesSchema, currentTime
 	return vals
 }
 
+// Resample resamples a time-series data.Frame at every interval of the time range, filling
+// the missing values. It is used by the data sources supporting the fill argument of the
+// $__timeGroup macro outside of the SQL engine.
+func Resample(f *data.Frame, interval time.Duration, timeRange backend.TimeRange, fillMissing *data.FillMissing) (*data.Frame, error) {
+	return resample(f, dataQueryModel{Interval: interval, TimeRange: timeRange, FillMissing: fillMissing})
+}
+
 // resample resample provided time-series data.Frame.
 // This is needed in the case of the selected query interval doesn't
 // match the intervals of the time-series field in the data.Frame and
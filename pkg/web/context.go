@@ -95,6 +95,55 @@ func RemoteAddr(req *http.Request) string {
 	return addr
 }
 
+// ClientIP returns the IP address of the client of the request. Unlike RemoteAddr, the
+// X-Forwarded-For and X-Real-IP headers are only honoured when the request comes from
+// one of the trusted proxies, other clients could set them to any address.
+func ClientIP(req *http.Request, trustedProxies []*net.IPNet) string {
+	peer := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+
+	if !isTrustedProxy(peer, trustedProxies) {
+		return peer
+	}
+
+	// the trusted proxies append the address of their client, so the rightmost
+	// address that isn't a trusted proxy is the client
+	if forwarded := req.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		addrs := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(addrs) - 1; i >= 0; i-- {
+			addr := strings.TrimSpace(addrs[i])
+			if net.ParseIP(addr) == nil {
+				break
+			}
+			if !isTrustedProxy(addr, trustedProxies) {
+				return addr
+			}
+		}
+	}
+
+	if addr := strings.TrimSpace(req.Header.Get("X-Real-IP")); net.ParseIP(addr) != nil {
+		return addr
+	}
+
+	return peer
+}
+
+func isTrustedProxy(addr string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 const (
 	headerContentType = "Content-Type"
 	contentTypeJSON   = "application/json; charset=UTF-8"
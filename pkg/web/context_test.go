@@ -1,11 +1,13 @@
 package web
 
 import (
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 )
@@ -98,6 +100,58 @@ func TestContext_RemoteAddr(t *testing.T) {
 	}
 }
 
+func TestClientIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	trusted := []*net.IPNet{proxies}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{
+			name:       "Ignores the headers of clients that aren't trusted proxies",
+			remoteAddr: "203.0.113.7:51234",
+			header: http.Header{
+				"X-Real-Ip":       []string{"192.168.1.1"},
+				"X-Forwarded-For": []string{"192.168.1.1"},
+			},
+			want: "203.0.113.7",
+		},
+		{
+			name:       "Returns the rightmost forwarded address that isn't a trusted proxy",
+			remoteAddr: "10.0.0.2:51234",
+			header:     http.Header{"X-Forwarded-For": []string{"192.168.1.1, 203.0.113.7, 10.0.0.3"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Returns X-Real-Ip of trusted proxies without X-Forwarded-For",
+			remoteAddr: "10.0.0.2:51234",
+			header:     http.Header{"X-Real-Ip": []string{"203.0.113.7"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Returns the trusted proxy when the headers are invalid",
+			remoteAddr: "10.0.0.2:51234",
+			header:     http.Header{"X-Real-Ip": []string{"this is not a valid IP"}},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "Returns IPv6 addresses without brackets",
+			remoteAddr: "[::1]:51234",
+			want:       "::1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &http.Request{RemoteAddr: tt.remoteAddr, Header: tt.header}
+			assert.Equal(t, tt.want, ClientIP(req, trusted))
+		})
+	}
+}
+
 func TestContext_noHandler(t *testing.T) {
 	recorder := httptest.NewRecorder()
 